@@ -36,6 +36,7 @@ import (
 	"k8s.io/klog/v2"
 	kcmnames "k8s.io/kubernetes/cmd/kube-controller-manager/names"
 
+	"k8s.io/cloud-provider-gcp/pkg/controller/nodenetworkconfig"
 	"k8s.io/cloud-provider-gcp/providers/gce"
 	_ "k8s.io/cloud-provider-gcp/providers/gce"
 )
@@ -84,6 +85,10 @@ var (
 
 	// enableL4ILBFineGrainedLocks enables resource-specific locking for L4 ILB.
 	enableL4ILBFineGrainedLocks bool
+
+	// nodeNetworkConfigPodCIDRMaskSize is the mask size of the alias IP ranges
+	// the nodenetworkconfig controller adds to nodes.
+	nodeNetworkConfigPodCIDRMaskSize int
 )
 
 func main() {
@@ -107,6 +112,9 @@ func main() {
 	cloudProviderFS.BoolVar(&enableGKETenantController, "enable-gke-tenant-controller", false, "Enables the GKE Tenant Controller Manager for Multi-Tenancy.")
 	cloudProviderFS.BoolVar(&enableL4ILBFineGrainedLocks, "enable-l4-ilb-fine-grained-lock", false, "Enable resource-specific locking for L4 ILB")
 
+	nodeNetworkConfigFS := fss.FlagSet("nodenetworkconfig controller")
+	nodeNetworkConfigFS.IntVar(&nodeNetworkConfigPodCIDRMaskSize, "node-network-config-pod-cidr-mask-size", nodenetworkconfig.DefaultPodCIDRMaskSize, "Mask size of the alias IP ranges added to nodes by the nodenetworkconfig controller for dynamic pod IP allocation.")
+
 	// add new controllers and initializers
 	nodeIpamController := nodeIPAMController{}
 	nodeIpamController.nodeIPAMControllerOptions.NodeIPAMControllerConfiguration = &nodeIpamController.nodeIPAMControllerConfiguration
@@ -133,6 +141,10 @@ func main() {
 		Constructor: startGkeNetworkParamSetControllerWrapper,
 	}
 
+	controllerInitializers[nodenetworkconfig.ControllerName] = app.ControllerInitFuncConstructor{
+		Constructor: startNodeNetworkConfigControllerWrapper,
+	}
+
 	controllerInitializers[gkeServiceLBControllerName] = app.ControllerInitFuncConstructor{
 		InitContext: app.ControllerInitContext{
 			ClientName: gkeServiceControllerClientName,
@@ -151,6 +163,7 @@ func main() {
 
 	// add controllers disabled by default
 	app.ControllersDisabledByDefault.Insert("gkenetworkparamset")
+	app.ControllersDisabledByDefault.Insert(nodenetworkconfig.ControllerName)
 	app.ControllersDisabledByDefault.Insert(gkeServiceLBControllerName)
 	app.ControllersDisabledByDefault.Insert(gkeTenantControllerManagerName)
 
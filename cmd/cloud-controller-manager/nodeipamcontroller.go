@@ -29,6 +29,7 @@ import (
 	nodeipamcontroller "k8s.io/cloud-provider-gcp/pkg/controller/nodeipam"
 	nodeipamconfig "k8s.io/cloud-provider-gcp/pkg/controller/nodeipam/config"
 	"k8s.io/cloud-provider-gcp/pkg/controller/nodeipam/ipam"
+	"k8s.io/cloud-provider-gcp/pkg/controller/nodenetworkconfig"
 	utilnode "k8s.io/cloud-provider-gcp/pkg/util/node"
 	"k8s.io/cloud-provider/app"
 	cloudcontrollerconfig "k8s.io/cloud-provider/app/config"
@@ -71,6 +72,11 @@ func startNodeIpamController(ccmConfig *cloudcontrollerconfig.CompletedConfig, n
 	nwInformer := nwInfFactory.Networking().V1().Networks()
 	gnpInformer := nwInfFactory.Networking().V1().GKENetworkParamSets()
 
+	// The alias IP ranges of the nodenetworkconfig controller are not additional pod CIDRs.
+	if genericcontrollermanager.IsControllerEnabled(nodenetworkconfig.ControllerName, app.ControllersDisabledByDefault, ccmConfig.ComponentConfig.Generic.Controllers) {
+		nodeIPAMConfig.NodeNetworkConfigPodCIDRMaskSize = int32(nodeNetworkConfigPodCIDRMaskSize)
+	}
+
 	// Wrap the informer to filter nodes
 	filteringInformer := &utilnode.GKEFilteringNodeInformer{NodeInformer: ccmConfig.SharedInformers.Core().V1().Nodes()}
 
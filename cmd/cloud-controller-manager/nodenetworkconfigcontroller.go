@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nncclientset "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned"
+	nncinformers "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/informers/externalversions"
+	cloudprovider "k8s.io/cloud-provider"
+	nodenetworkconfigcontroller "k8s.io/cloud-provider-gcp/pkg/controller/nodenetworkconfig"
+	"k8s.io/cloud-provider-gcp/providers/gce"
+	"k8s.io/cloud-provider/app"
+	cloudcontrollerconfig "k8s.io/cloud-provider/app/config"
+	genericcontrollermanager "k8s.io/controller-manager/app"
+	"k8s.io/controller-manager/controller"
+)
+
+func startNodeNetworkConfigControllerWrapper(initCtx app.ControllerInitContext, config *cloudcontrollerconfig.CompletedConfig, c cloudprovider.Interface) app.InitFunc {
+	return func(ctx context.Context, controllerCtx genericcontrollermanager.ControllerContext) (controller.Interface, bool, error) {
+		return startNodeNetworkConfigController(config, controllerCtx, c)
+	}
+}
+
+func startNodeNetworkConfigController(ccmConfig *cloudcontrollerconfig.CompletedConfig, controllerCtx genericcontrollermanager.ControllerContext, cloud cloudprovider.Interface) (controller.Interface, bool, error) {
+	gceCloud, ok := cloud.(*gce.Cloud)
+	if !ok {
+		err := fmt.Errorf("NodeNetworkConfigController does not support %v provider", cloud.ProviderName())
+		return nil, false, err
+	}
+
+	kubeConfig := ccmConfig.Complete().Kubeconfig
+	kubeConfig.ContentType = jsonContentType // required to serialize NodeNetworkConfig to json
+
+	nncClient, err := nncclientset.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// alias IP ranges are carved out of the cluster CIDR
+	clusterCIDRs, err := validClusterCIDR(ccmConfig.ComponentConfig.KubeCloudShared.ClusterCIDR)
+	if err != nil {
+		return nil, false, err
+	}
+
+	nncInfFactory := nncinformers.NewSharedInformerFactory(nncClient, 30*time.Second)
+	nncInformer := nncInfFactory.Networking().V1().NodeNetworkConfigs()
+
+	nodeNetworkConfigController, err := nodenetworkconfigcontroller.NewNodeNetworkConfigController(
+		ccmConfig.SharedInformers.Core().V1().Nodes(),
+		nncClient,
+		nncInformer,
+		gceCloud,
+		nncInfFactory,
+		clusterCIDRs,
+		nodeNetworkConfigPodCIDRMaskSize,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	go nodeNetworkConfigController.Run(1, controllerCtx.Stop, controllerCtx.ControllerManagerMetrics)
+	return nil, true, nil
+}
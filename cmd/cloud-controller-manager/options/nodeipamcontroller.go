@@ -18,11 +18,16 @@ package options
 
 import (
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/pflag"
 
 	nodeipamconfig "k8s.io/cloud-provider-gcp/pkg/controller/nodeipam/config"
+	"k8s.io/cloud-provider-gcp/pkg/controller/nodeipam/ipam"
+	utilnode "k8s.io/cloud-provider-gcp/pkg/util/node"
+	netutils "k8s.io/utils/net"
 )
 
 // NodeIPAMControllerOptions holds the NodeIpamController options.
@@ -41,6 +46,14 @@ func (o *NodeIPAMControllerOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.Int32Var(&o.NodeCIDRMaskSizeIPv6, "node-cidr-mask-size-ipv6", o.NodeCIDRMaskSizeIPv6, "Mask size for IPv6 node cidr in dual-stack cluster. Default is 64.")
 	fs.BoolVar(&o.EnableMultiSubnetCluster, "enable-multi-subnet-cluster", o.EnableMultiSubnetCluster, "Enabled multi-subnet cluster feature. This enables generating updated nodeTopology custom resource. ")
 	fs.BoolVar(&o.EnableMultiNetworking, "enable-multi-networking", o.EnableMultiNetworking, "Enabled multi-networking related logics such as multi-networking IPAM.")
+	fs.StringSliceVar(&o.PodSecondaryRangeNames, "pod-secondary-range-names", o.PodSecondaryRangeNames, "Comma-separated names of the subnetwork secondary ranges pod alias IP ranges are taken from, in order of preference. Alias IP ranges of a node's default network interface from these ranges beyond the first one are published in the "+utilnode.AdditionalPodCIDRsAnnotationKey+" node annotation, except those the nodenetworkconfig controller, if enabled, adds out of the cluster CIDR. Only used by the CloudAllocator.")
+	fs.DurationVar(&o.AdditionalPodCIDRsResyncPeriod.Duration, "additional-pod-cidrs-resync-period", o.AdditionalPodCIDRsResyncPeriod.Duration, "Period at which all nodes are resynced to discover alias IP ranges added to their instances from --pod-secondary-range-names, at the cost of one GCE instance lookup per node. Zero uses the default of 10m, a negative period disables the resync so that only node events discover them. Only used by the CloudAllocator.")
+	fs.Var(clusterCIDRsValue{&o.AdditionalClusterCIDRs}, "additional-cluster-cidrs", "Comma-separated cluster CIDRs node CIDRs are allocated from once the --cluster-cidr of the same IP family is exhausted, in priority order within each IP family. Each CIDR may be followed by '=<node CIDR mask size>', e.g. 10.8.0.0/14=26. Cluster CIDRs must not overlap, and must not be removed while nodes use them. Only used by the RangeAllocator.")
+	fs.StringVar(&o.ClusterCIDRsConfigMap, "cluster-cidrs-configmap", o.ClusterCIDRsConfigMap, "The namespace/name of a ConfigMap whose '"+ipam.ClusterCIDRsConfigMapKey+"' key lists further cluster CIDRs in the format of --additional-cluster-cidrs. Changes to it are applied without a restart. Only used by the RangeAllocator.")
+	fs.StringVar(&o.NodeCIDRPoolsConfigMap, "node-cidr-pools-configmap", o.NodeCIDRPoolsConfigMap, "The namespace/name of a ConfigMap whose '"+ipam.NodeCIDRPoolsConfigMapKey+"' key lists pools of CIDRs, with their own node CIDR mask sizes, for the nodes matching their node selectors. Changes to it are applied without a restart. Only used by the RangeAllocator.")
+	fs.StringVar(&o.AllocatorStateConfigMap, "allocator-state-configmap", o.AllocatorStateConfigMap, "The namespace/name of a ConfigMap the allocated and quarantined node CIDRs are checkpointed to. When set, the allocator state is restored from it on startup and validated against the nodes once they are synced, instead of listing all nodes before starting. As the restored state may miss the allocations made after its last checkpoint, a few node CIDRs of each IP family are kept out of the allocations and checkpointed as reserved, and only these are allocated until the state is validated. Only used by the RangeAllocator.")
+	fs.DurationVar(&o.CIDRReleaseQuarantine.Duration, "cidr-release-quarantine", o.CIDRReleaseQuarantine.Duration, "How long the node CIDRs of deleted nodes are kept from being allocated to other nodes, as they may still be routed. Zero releases them immediately. Only used by the RangeAllocator.")
+	fs.Float64SliceVar(&o.CIDRUtilizationAlertThresholds, "cidr-utilization-alert-thresholds", o.CIDRUtilizationAlertThresholds, "Comma-separated cluster CIDR utilization percentages, e.g. 80,95, at which a "+ipam.CIDRUtilizationHighReason+" warning event is recorded on the node allocated the CIDR that crossed them and on the cluster. Not used by the CloudAllocator.")
 }
 
 // ApplyTo fills up NodeIpamController config with options.
@@ -63,6 +76,14 @@ func (o *NodeIPAMControllerOptions) ApplyTo(cfg *nodeipamconfig.NodeIPAMControll
 	cfg.NodeCIDRMaskSizeIPv6 = o.NodeCIDRMaskSizeIPv6
 	cfg.EnableMultiSubnetCluster = o.EnableMultiSubnetCluster
 	cfg.EnableMultiNetworking = o.EnableMultiNetworking
+	cfg.PodSecondaryRangeNames = o.PodSecondaryRangeNames
+	cfg.AdditionalPodCIDRsResyncPeriod = o.AdditionalPodCIDRsResyncPeriod
+	cfg.AdditionalClusterCIDRs = o.AdditionalClusterCIDRs
+	cfg.ClusterCIDRsConfigMap = o.ClusterCIDRsConfigMap
+	cfg.NodeCIDRPoolsConfigMap = o.NodeCIDRPoolsConfigMap
+	cfg.AllocatorStateConfigMap = o.AllocatorStateConfigMap
+	cfg.CIDRReleaseQuarantine = o.CIDRReleaseQuarantine
+	cfg.CIDRUtilizationAlertThresholds = o.CIDRUtilizationAlertThresholds
 
 	return nil
 }
@@ -78,6 +99,86 @@ func (o *NodeIPAMControllerOptions) Validate() []error {
 	if len(serviceCIDRList) > 2 {
 		errs = append(errs, fmt.Errorf("--service-cluster-ip-range can not contain more than two entries"))
 	}
+	if o.CIDRReleaseQuarantine.Duration < 0 {
+		errs = append(errs, fmt.Errorf("--cidr-release-quarantine can not be negative"))
+	}
+	errs = append(errs, validateAdditionalClusterCIDRs(o.AdditionalClusterCIDRs)...)
+	for _, threshold := range o.CIDRUtilizationAlertThresholds {
+		if threshold <= 0 || threshold > 100 {
+			errs = append(errs, fmt.Errorf("--cidr-utilization-alert-thresholds must be percentages in (0, 100], got %v", threshold))
+		}
+	}
 
 	return errs
 }
+
+// validateAdditionalClusterCIDRs checks that the additional cluster CIDRs are valid and
+// do not overlap, and that their node CIDR mask sizes fit in them. The CIDRs are not
+// checked against --cluster-cidr, which is not part of this configuration.
+func validateAdditionalClusterCIDRs(clusterCIDRs []nodeipamconfig.ClusterCIDR) []error {
+	var errs []error
+	var cidrs []*net.IPNet
+	for _, clusterCIDR := range clusterCIDRs {
+		_, cidr, err := netutils.ParseCIDRSloppy(clusterCIDR.CIDR)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("--additional-cluster-cidrs contains an invalid CIDR %q: %v", clusterCIDR.CIDR, err))
+			continue
+		}
+		prefixSize, bits := cidr.Mask.Size()
+		if maskSize := int(clusterCIDR.NodeCIDRMaskSize); maskSize != 0 && (maskSize < prefixSize || maskSize > bits) {
+			errs = append(errs, fmt.Errorf("--additional-cluster-cidrs node CIDR mask size %d of %v must be between %d and %d", maskSize, cidr, prefixSize, bits))
+		}
+		for _, other := range cidrs {
+			if other.Contains(cidr.IP) || cidr.Contains(other.IP) {
+				errs = append(errs, fmt.Errorf("--additional-cluster-cidrs CIDR %v overlaps with %v", cidr, other))
+			}
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return errs
+}
+
+// clusterCIDRsValue is the flag value of a list of cluster CIDRs, given as
+// comma-separated CIDRs each optionally followed by "=" and its node CIDR mask size,
+// e.g. "10.8.0.0/14=26,fd00:8::/48". The CIDRs are validated by Validate.
+type clusterCIDRsValue struct {
+	clusterCIDRs *[]nodeipamconfig.ClusterCIDR
+}
+
+func (v clusterCIDRsValue) String() string {
+	var entries []string
+	for _, clusterCIDR := range *v.clusterCIDRs {
+		if clusterCIDR.NodeCIDRMaskSize == 0 {
+			entries = append(entries, clusterCIDR.CIDR)
+		} else {
+			entries = append(entries, fmt.Sprintf("%s=%d", clusterCIDR.CIDR, clusterCIDR.NodeCIDRMaskSize))
+		}
+	}
+	return strings.Join(entries, ",")
+}
+
+func (v clusterCIDRsValue) Set(value string) error {
+	var clusterCIDRs []nodeipamconfig.ClusterCIDR
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		cidr, maskString, hasMask := strings.Cut(entry, "=")
+		clusterCIDR := nodeipamconfig.ClusterCIDR{CIDR: cidr}
+		if hasMask {
+			maskSize, err := strconv.ParseInt(maskString, 10, 32)
+			if err != nil || maskSize <= 0 {
+				return fmt.Errorf("invalid node CIDR mask size in %q", entry)
+			}
+			clusterCIDR.NodeCIDRMaskSize = int32(maskSize)
+		}
+		clusterCIDRs = append(clusterCIDRs, clusterCIDR)
+	}
+	*v.clusterCIDRs = clusterCIDRs
+	return nil
+}
+
+func (v clusterCIDRsValue) Type() string {
+	return "clusterCIDRs"
+}
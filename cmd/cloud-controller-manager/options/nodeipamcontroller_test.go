@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	nodeipamconfig "k8s.io/cloud-provider-gcp/pkg/controller/nodeipam/config"
+)
+
+func TestAdditionalClusterCIDRs(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		flag         string
+		expected     []nodeipamconfig.ClusterCIDR
+		expectSetErr bool
+		expectErrs   int
+	}{
+		{
+			desc: "empty",
+			flag: "",
+		},
+		{
+			desc: "dual-stack CIDRs with and without node CIDR mask sizes",
+			flag: "10.20.0.0/16=26, fd00:20::/48=64,10.30.0.0/16",
+			expected: []nodeipamconfig.ClusterCIDR{
+				{CIDR: "10.20.0.0/16", NodeCIDRMaskSize: 26},
+				{CIDR: "fd00:20::/48", NodeCIDRMaskSize: 64},
+				{CIDR: "10.30.0.0/16"},
+			},
+		},
+		{
+			desc:         "invalid node CIDR mask size",
+			flag:         "10.20.0.0/16=x",
+			expectSetErr: true,
+		},
+		{
+			desc:         "zero node CIDR mask size",
+			flag:         "10.20.0.0/16=0",
+			expectSetErr: true,
+		},
+		{
+			desc:       "invalid CIDR",
+			flag:       "10.20.0.0",
+			expected:   []nodeipamconfig.ClusterCIDR{{CIDR: "10.20.0.0"}},
+			expectErrs: 1,
+		},
+		{
+			desc:       "node CIDR mask sizes that do not fit the CIDRs",
+			flag:       "10.20.0.0/16=12,fd00:20::/48=129",
+			expected:   []nodeipamconfig.ClusterCIDR{{CIDR: "10.20.0.0/16", NodeCIDRMaskSize: 12}, {CIDR: "fd00:20::/48", NodeCIDRMaskSize: 129}},
+			expectErrs: 2,
+		},
+		{
+			desc:       "overlapping CIDRs",
+			flag:       "10.20.0.0/16,10.20.128.0/17=24",
+			expected:   []nodeipamconfig.ClusterCIDR{{CIDR: "10.20.0.0/16"}, {CIDR: "10.20.128.0/17", NodeCIDRMaskSize: 24}},
+			expectErrs: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			o := &NodeIPAMControllerOptions{NodeIPAMControllerConfiguration: &nodeipamconfig.NodeIPAMControllerConfiguration{}}
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			o.AddFlags(fs)
+			err := fs.Parse([]string{"--additional-cluster-cidrs=" + tc.flag})
+			if (err != nil) != tc.expectSetErr {
+				t.Fatalf("Parse() error = %v, expectSetErr %v", err, tc.expectSetErr)
+			}
+			if tc.expectSetErr {
+				return
+			}
+			if !reflect.DeepEqual(o.AdditionalClusterCIDRs, tc.expected) {
+				t.Errorf("AdditionalClusterCIDRs = %+v, expected %+v", o.AdditionalClusterCIDRs, tc.expected)
+			}
+			if errs := o.Validate(); len(errs) != tc.expectErrs {
+				t.Errorf("Validate() = %v, expected %d errors", errs, tc.expectErrs)
+			}
+
+			cfg := &nodeipamconfig.NodeIPAMControllerConfiguration{}
+			if err := o.ApplyTo(cfg); err != nil {
+				t.Fatalf("ApplyTo() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg.AdditionalClusterCIDRs, tc.expected) {
+				t.Errorf("ApplyTo() AdditionalClusterCIDRs = %+v, expected %+v", cfg.AdditionalClusterCIDRs, tc.expected)
+			}
+		})
+	}
+}
@@ -32,8 +32,16 @@ type IPConfig struct {
 	// The CNI must provide this CIDR, which is used to allocate IPs for pods created
 	// early in the node's lifecycle, typically including critical system pods.
 	InitialPodCidr string `protobuf:"bytes,3,opt,name=initial_pod_cidr,json=initialPodCidr,proto3" json:"initial_pod_cidr,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// netns is the path to the network namespace of the pod sandbox, as passed
+	// in CNI_NETNS. It is recorded with the allocation so that the daemon can
+	// garbage collect IPs of sandboxes that were removed without a CNI DEL.
+	Netns string `protobuf:"bytes,4,opt,name=netns,proto3" json:"netns,omitempty"`
+	// requested_ip is an optional address to assign to the pod. It is assigned
+	// if it is free in the node's pod CIDR blocks, otherwise another address is
+	// assigned. It is ignored if an address is reserved for the pod.
+	RequestedIp   string `protobuf:"bytes,5,opt,name=requested_ip,json=requestedIp,proto3" json:"requested_ip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *IPConfig) Reset() {
@@ -87,6 +95,20 @@ func (x *IPConfig) GetInitialPodCidr() string {
 	return ""
 }
 
+func (x *IPConfig) GetNetns() string {
+	if x != nil {
+		return x.Netns
+	}
+	return ""
+}
+
+func (x *IPConfig) GetRequestedIp() string {
+	if x != nil {
+		return x.RequestedIp
+	}
+	return ""
+}
+
 // AllocatePodIPRequest contains the parameters required to allocate a pod IP.
 // The allocation takes into account the specified network, the initial CIDR block, and the IP family
 // configuration (IPv4, IPv6, or dual-stack). If the node depletes its initial block of pod IPs,
@@ -100,9 +122,11 @@ type AllocatePodIPRequest struct {
 	Ipv4Config *IPConfig `protobuf:"bytes,2,opt,name=ipv4_config,json=ipv4Config,proto3" json:"ipv4_config,omitempty"`
 	// ipv6_config specifies the pod configuration for IPv6 allocation.
 	Ipv6Config *IPConfig `protobuf:"bytes,3,opt,name=ipv6_config,json=ipv6Config,proto3" json:"ipv6_config,omitempty"`
-	// pod_name is the name of the pod. This is for logging purposes.
+	// pod_name is the name of the pod. It is used to look up the addresses
+	// reserved for the pod, and for logging purposes.
 	PodName string `protobuf:"bytes,4,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
-	// pod_namespace is the namespace of the pod. This is for logging purposes.
+	// pod_namespace is the namespace of the pod. It is used to look up the
+	// addresses reserved for the pod, and for logging purposes.
 	PodNamespace  string `protobuf:"bytes,5,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -524,15 +548,416 @@ func (*CheckPodIPResponse) Descriptor() ([]byte, []int) {
 	return file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDescGZIP(), []int{7}
 }
 
+// PodAttachment identifies a pod interface by its container ID and interface name.
+type PodAttachment struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// container_id is the id of the pod container.
+	ContainerId string `protobuf:"bytes,1,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	// interface_name is the name of the pod interface.
+	InterfaceName string `protobuf:"bytes,2,opt,name=interface_name,json=interfaceName,proto3" json:"interface_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PodAttachment) Reset() {
+	*x = PodAttachment{}
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PodAttachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PodAttachment) ProtoMessage() {}
+
+func (x *PodAttachment) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PodAttachment.ProtoReflect.Descriptor instead.
+func (*PodAttachment) Descriptor() ([]byte, []int) {
+	return file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PodAttachment) GetContainerId() string {
+	if x != nil {
+		return x.ContainerId
+	}
+	return ""
+}
+
+func (x *PodAttachment) GetInterfaceName() string {
+	if x != nil {
+		return x.InterfaceName
+	}
+	return ""
+}
+
+// ReleaseStalePodIPsRequest contains the attachments of a network that are
+// still valid, as passed by the container runtime in a CNI GC.
+type ReleaseStalePodIPsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// network is the name of the network.
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// valid_attachments are the pod interfaces whose IPs must be kept.
+	ValidAttachments []*PodAttachment `protobuf:"bytes,2,rep,name=valid_attachments,json=validAttachments,proto3" json:"valid_attachments,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ReleaseStalePodIPsRequest) Reset() {
+	*x = ReleaseStalePodIPsRequest{}
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseStalePodIPsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseStalePodIPsRequest) ProtoMessage() {}
+
+func (x *ReleaseStalePodIPsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseStalePodIPsRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseStalePodIPsRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReleaseStalePodIPsRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *ReleaseStalePodIPsRequest) GetValidAttachments() []*PodAttachment {
+	if x != nil {
+		return x.ValidAttachments
+	}
+	return nil
+}
+
+// ReleaseStalePodIPsResponse lists the pod interfaces whose IPs were released.
+type ReleaseStalePodIPsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// released_attachments are the pod interfaces whose IPs were released.
+	ReleasedAttachments []*PodAttachment `protobuf:"bytes,1,rep,name=released_attachments,json=releasedAttachments,proto3" json:"released_attachments,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ReleaseStalePodIPsResponse) Reset() {
+	*x = ReleaseStalePodIPsResponse{}
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseStalePodIPsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseStalePodIPsResponse) ProtoMessage() {}
+
+func (x *ReleaseStalePodIPsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseStalePodIPsResponse.ProtoReflect.Descriptor instead.
+func (*ReleaseStalePodIPsResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ReleaseStalePodIPsResponse) GetReleasedAttachments() []*PodAttachment {
+	if x != nil {
+		return x.ReleasedAttachments
+	}
+	return nil
+}
+
+// GetNetworkStatusRequest contains the parameters required to get the status of a network.
+type GetNetworkStatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// network is the name of the network.
+	Network       string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetNetworkStatusRequest) Reset() {
+	*x = GetNetworkStatusRequest{}
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNetworkStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNetworkStatusRequest) ProtoMessage() {}
+
+func (x *GetNetworkStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNetworkStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetNetworkStatusRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetNetworkStatusRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+// GetNetworkStatusResponse contains the number of IPs of each IP family that
+// can be allocated immediately, i.e. without adding capacity.
+type GetNetworkStatusResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// available_ipv4 is the number of IPv4 addresses available for new pods.
+	AvailableIpv4 int64 `protobuf:"varint,1,opt,name=available_ipv4,json=availableIpv4,proto3" json:"available_ipv4,omitempty"`
+	// available_ipv6 is the number of IPv6 addresses available for new pods.
+	AvailableIpv6 int64 `protobuf:"varint,2,opt,name=available_ipv6,json=availableIpv6,proto3" json:"available_ipv6,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetNetworkStatusResponse) Reset() {
+	*x = GetNetworkStatusResponse{}
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNetworkStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNetworkStatusResponse) ProtoMessage() {}
+
+func (x *GetNetworkStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNetworkStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetNetworkStatusResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetNetworkStatusResponse) GetAvailableIpv4() int64 {
+	if x != nil {
+		return x.AvailableIpv4
+	}
+	return 0
+}
+
+func (x *GetNetworkStatusResponse) GetAvailableIpv6() int64 {
+	if x != nil {
+		return x.AvailableIpv6
+	}
+	return 0
+}
+
+// ReportIPConflictRequest identifies an IP allocated to a pod interface that
+// is in use by another host.
+type ReportIPConflictRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// network is the name of the network.
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// interface_name is the name of the pod interface.
+	InterfaceName string `protobuf:"bytes,2,opt,name=interface_name,json=interfaceName,proto3" json:"interface_name,omitempty"`
+	// container_id is the id of the pod container.
+	ContainerId string `protobuf:"bytes,3,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	// ip_address is the conflicting IP address allocated to the pod interface.
+	IpAddress string `protobuf:"bytes,4,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
+	// pod_name is the name of the pod. This is for logging purposes.
+	PodName string `protobuf:"bytes,5,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	// pod_namespace is the namespace of the pod. This is for logging purposes.
+	PodNamespace string `protobuf:"bytes,6,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	// reason describes how the conflict was detected, e.g. "duplicate address
+	// detected by ARP probe". It is recorded with the quarantine.
+	Reason        string `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportIPConflictRequest) Reset() {
+	*x = ReportIPConflictRequest{}
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportIPConflictRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportIPConflictRequest) ProtoMessage() {}
+
+func (x *ReportIPConflictRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportIPConflictRequest.ProtoReflect.Descriptor instead.
+func (*ReportIPConflictRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ReportIPConflictRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *ReportIPConflictRequest) GetInterfaceName() string {
+	if x != nil {
+		return x.InterfaceName
+	}
+	return ""
+}
+
+func (x *ReportIPConflictRequest) GetContainerId() string {
+	if x != nil {
+		return x.ContainerId
+	}
+	return ""
+}
+
+func (x *ReportIPConflictRequest) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *ReportIPConflictRequest) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+func (x *ReportIPConflictRequest) GetPodNamespace() string {
+	if x != nil {
+		return x.PodNamespace
+	}
+	return ""
+}
+
+func (x *ReportIPConflictRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// ReportIPConflictResponse indicates the conflicting IP was quarantined and released.
+// An empty message is returned on success; RPC errors are used to indicate failure.
+type ReportIPConflictResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportIPConflictResponse) Reset() {
+	*x = ReportIPConflictResponse{}
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportIPConflictResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportIPConflictResponse) ProtoMessage() {}
+
+func (x *ReportIPConflictResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportIPConflictResponse.ProtoReflect.Descriptor instead.
+func (*ReportIPConflictResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDescGZIP(), []int{14}
+}
+
 var File_metis_api_adaptiveipam_v1_adaptiveipam_proto protoreflect.FileDescriptor
 
 const file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDesc = "" +
 	"\n" +
-	",metis/api/adaptiveipam/v1/adaptiveipam.proto\x12\x0fadaptiveipam.v1\"~\n" +
+	",metis/api/adaptiveipam/v1/adaptiveipam.proto\x12\x0fadaptiveipam.v1\"\xb7\x01\n" +
 	"\bIPConfig\x12%\n" +
 	"\x0einterface_name\x18\x01 \x01(\tR\rinterfaceName\x12!\n" +
 	"\fcontainer_id\x18\x02 \x01(\tR\vcontainerId\x12(\n" +
-	"\x10initial_pod_cidr\x18\x03 \x01(\tR\x0einitialPodCidr\"\xe8\x01\n" +
+	"\x10initial_pod_cidr\x18\x03 \x01(\tR\x0einitialPodCidr\x12\x14\n" +
+	"\x05netns\x18\x04 \x01(\tR\x05netns\x12!\n" +
+	"\frequested_ip\x18\x05 \x01(\tR\vrequestedIp\"\xe8\x01\n" +
 	"\x14AllocatePodIPRequest\x12\x18\n" +
 	"\anetwork\x18\x01 \x01(\tR\anetwork\x12:\n" +
 	"\vipv4_config\x18\x02 \x01(\v2\x19.adaptiveipam.v1.IPConfigR\n" +
@@ -561,12 +986,38 @@ const file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDesc = "" +
 	"\fcontainer_id\x18\x03 \x01(\tR\vcontainerId\x12\x19\n" +
 	"\bpod_name\x18\x04 \x01(\tR\apodName\x12#\n" +
 	"\rpod_namespace\x18\x05 \x01(\tR\fpodNamespace\"\x14\n" +
-	"\x12CheckPodIPResponse2\xab\x02\n" +
+	"\x12CheckPodIPResponse\"Y\n" +
+	"\rPodAttachment\x12!\n" +
+	"\fcontainer_id\x18\x01 \x01(\tR\vcontainerId\x12%\n" +
+	"\x0einterface_name\x18\x02 \x01(\tR\rinterfaceName\"\x82\x01\n" +
+	"\x19ReleaseStalePodIPsRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12K\n" +
+	"\x11valid_attachments\x18\x02 \x03(\v2\x1e.adaptiveipam.v1.PodAttachmentR\x10validAttachments\"o\n" +
+	"\x1aReleaseStalePodIPsResponse\x12Q\n" +
+	"\x14released_attachments\x18\x01 \x03(\v2\x1e.adaptiveipam.v1.PodAttachmentR\x13releasedAttachments\"3\n" +
+	"\x17GetNetworkStatusRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\"h\n" +
+	"\x18GetNetworkStatusResponse\x12%\n" +
+	"\x0eavailable_ipv4\x18\x01 \x01(\x03R\ravailableIpv4\x12%\n" +
+	"\x0eavailable_ipv6\x18\x02 \x01(\x03R\ravailableIpv6\"\xf4\x01\n" +
+	"\x17ReportIPConflictRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12%\n" +
+	"\x0einterface_name\x18\x02 \x01(\tR\rinterfaceName\x12!\n" +
+	"\fcontainer_id\x18\x03 \x01(\tR\vcontainerId\x12\x1d\n" +
+	"\n" +
+	"ip_address\x18\x04 \x01(\tR\tipAddress\x12\x19\n" +
+	"\bpod_name\x18\x05 \x01(\tR\apodName\x12#\n" +
+	"\rpod_namespace\x18\x06 \x01(\tR\fpodNamespace\x12\x16\n" +
+	"\x06reason\x18\a \x01(\tR\x06reason\"\x1a\n" +
+	"\x18ReportIPConflictResponse2\xec\x04\n" +
 	"\fAdaptiveIpam\x12^\n" +
 	"\rAllocatePodIP\x12%.adaptiveipam.v1.AllocatePodIPRequest\x1a&.adaptiveipam.v1.AllocatePodIPResponse\x12d\n" +
 	"\x0fDeallocatePodIP\x12'.adaptiveipam.v1.DeallocatePodIPRequest\x1a(.adaptiveipam.v1.DeallocatePodIPResponse\x12U\n" +
 	"\n" +
-	"CheckPodIP\x12\".adaptiveipam.v1.CheckPodIPRequest\x1a#.adaptiveipam.v1.CheckPodIPResponseB/Z-k8s.io/metis/api/adaptiveipam/v1;adaptiveipamb\x06proto3"
+	"CheckPodIP\x12\".adaptiveipam.v1.CheckPodIPRequest\x1a#.adaptiveipam.v1.CheckPodIPResponse\x12m\n" +
+	"\x12ReleaseStalePodIPs\x12*.adaptiveipam.v1.ReleaseStalePodIPsRequest\x1a+.adaptiveipam.v1.ReleaseStalePodIPsResponse\x12g\n" +
+	"\x10GetNetworkStatus\x12(.adaptiveipam.v1.GetNetworkStatusRequest\x1a).adaptiveipam.v1.GetNetworkStatusResponse\x12g\n" +
+	"\x10ReportIPConflict\x12(.adaptiveipam.v1.ReportIPConflictRequest\x1a).adaptiveipam.v1.ReportIPConflictResponseB/Z-k8s.io/metis/api/adaptiveipam/v1;adaptiveipamb\x06proto3"
 
 var (
 	file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDescOnce sync.Once
@@ -580,33 +1031,48 @@ func file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDescGZIP() []byte {
 	return file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDescData
 }
 
-var file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_metis_api_adaptiveipam_v1_adaptiveipam_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_metis_api_adaptiveipam_v1_adaptiveipam_proto_goTypes = []any{
-	(*IPConfig)(nil),                // 0: adaptiveipam.v1.IPConfig
-	(*AllocatePodIPRequest)(nil),    // 1: adaptiveipam.v1.AllocatePodIPRequest
-	(*PodIP)(nil),                   // 2: adaptiveipam.v1.PodIP
-	(*AllocatePodIPResponse)(nil),   // 3: adaptiveipam.v1.AllocatePodIPResponse
-	(*DeallocatePodIPRequest)(nil),  // 4: adaptiveipam.v1.DeallocatePodIPRequest
-	(*DeallocatePodIPResponse)(nil), // 5: adaptiveipam.v1.DeallocatePodIPResponse
-	(*CheckPodIPRequest)(nil),       // 6: adaptiveipam.v1.CheckPodIPRequest
-	(*CheckPodIPResponse)(nil),      // 7: adaptiveipam.v1.CheckPodIPResponse
+	(*IPConfig)(nil),                   // 0: adaptiveipam.v1.IPConfig
+	(*AllocatePodIPRequest)(nil),       // 1: adaptiveipam.v1.AllocatePodIPRequest
+	(*PodIP)(nil),                      // 2: adaptiveipam.v1.PodIP
+	(*AllocatePodIPResponse)(nil),      // 3: adaptiveipam.v1.AllocatePodIPResponse
+	(*DeallocatePodIPRequest)(nil),     // 4: adaptiveipam.v1.DeallocatePodIPRequest
+	(*DeallocatePodIPResponse)(nil),    // 5: adaptiveipam.v1.DeallocatePodIPResponse
+	(*CheckPodIPRequest)(nil),          // 6: adaptiveipam.v1.CheckPodIPRequest
+	(*CheckPodIPResponse)(nil),         // 7: adaptiveipam.v1.CheckPodIPResponse
+	(*PodAttachment)(nil),              // 8: adaptiveipam.v1.PodAttachment
+	(*ReleaseStalePodIPsRequest)(nil),  // 9: adaptiveipam.v1.ReleaseStalePodIPsRequest
+	(*ReleaseStalePodIPsResponse)(nil), // 10: adaptiveipam.v1.ReleaseStalePodIPsResponse
+	(*GetNetworkStatusRequest)(nil),    // 11: adaptiveipam.v1.GetNetworkStatusRequest
+	(*GetNetworkStatusResponse)(nil),   // 12: adaptiveipam.v1.GetNetworkStatusResponse
+	(*ReportIPConflictRequest)(nil),    // 13: adaptiveipam.v1.ReportIPConflictRequest
+	(*ReportIPConflictResponse)(nil),   // 14: adaptiveipam.v1.ReportIPConflictResponse
 }
 var file_metis_api_adaptiveipam_v1_adaptiveipam_proto_depIdxs = []int32{
-	0, // 0: adaptiveipam.v1.AllocatePodIPRequest.ipv4_config:type_name -> adaptiveipam.v1.IPConfig
-	0, // 1: adaptiveipam.v1.AllocatePodIPRequest.ipv6_config:type_name -> adaptiveipam.v1.IPConfig
-	2, // 2: adaptiveipam.v1.AllocatePodIPResponse.ipv4:type_name -> adaptiveipam.v1.PodIP
-	2, // 3: adaptiveipam.v1.AllocatePodIPResponse.ipv6:type_name -> adaptiveipam.v1.PodIP
-	1, // 4: adaptiveipam.v1.AdaptiveIpam.AllocatePodIP:input_type -> adaptiveipam.v1.AllocatePodIPRequest
-	4, // 5: adaptiveipam.v1.AdaptiveIpam.DeallocatePodIP:input_type -> adaptiveipam.v1.DeallocatePodIPRequest
-	6, // 6: adaptiveipam.v1.AdaptiveIpam.CheckPodIP:input_type -> adaptiveipam.v1.CheckPodIPRequest
-	3, // 7: adaptiveipam.v1.AdaptiveIpam.AllocatePodIP:output_type -> adaptiveipam.v1.AllocatePodIPResponse
-	5, // 8: adaptiveipam.v1.AdaptiveIpam.DeallocatePodIP:output_type -> adaptiveipam.v1.DeallocatePodIPResponse
-	7, // 9: adaptiveipam.v1.AdaptiveIpam.CheckPodIP:output_type -> adaptiveipam.v1.CheckPodIPResponse
-	7, // [7:10] is the sub-list for method output_type
-	4, // [4:7] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	0,  // 0: adaptiveipam.v1.AllocatePodIPRequest.ipv4_config:type_name -> adaptiveipam.v1.IPConfig
+	0,  // 1: adaptiveipam.v1.AllocatePodIPRequest.ipv6_config:type_name -> adaptiveipam.v1.IPConfig
+	2,  // 2: adaptiveipam.v1.AllocatePodIPResponse.ipv4:type_name -> adaptiveipam.v1.PodIP
+	2,  // 3: adaptiveipam.v1.AllocatePodIPResponse.ipv6:type_name -> adaptiveipam.v1.PodIP
+	8,  // 4: adaptiveipam.v1.ReleaseStalePodIPsRequest.valid_attachments:type_name -> adaptiveipam.v1.PodAttachment
+	8,  // 5: adaptiveipam.v1.ReleaseStalePodIPsResponse.released_attachments:type_name -> adaptiveipam.v1.PodAttachment
+	1,  // 6: adaptiveipam.v1.AdaptiveIpam.AllocatePodIP:input_type -> adaptiveipam.v1.AllocatePodIPRequest
+	4,  // 7: adaptiveipam.v1.AdaptiveIpam.DeallocatePodIP:input_type -> adaptiveipam.v1.DeallocatePodIPRequest
+	6,  // 8: adaptiveipam.v1.AdaptiveIpam.CheckPodIP:input_type -> adaptiveipam.v1.CheckPodIPRequest
+	9,  // 9: adaptiveipam.v1.AdaptiveIpam.ReleaseStalePodIPs:input_type -> adaptiveipam.v1.ReleaseStalePodIPsRequest
+	11, // 10: adaptiveipam.v1.AdaptiveIpam.GetNetworkStatus:input_type -> adaptiveipam.v1.GetNetworkStatusRequest
+	13, // 11: adaptiveipam.v1.AdaptiveIpam.ReportIPConflict:input_type -> adaptiveipam.v1.ReportIPConflictRequest
+	3,  // 12: adaptiveipam.v1.AdaptiveIpam.AllocatePodIP:output_type -> adaptiveipam.v1.AllocatePodIPResponse
+	5,  // 13: adaptiveipam.v1.AdaptiveIpam.DeallocatePodIP:output_type -> adaptiveipam.v1.DeallocatePodIPResponse
+	7,  // 14: adaptiveipam.v1.AdaptiveIpam.CheckPodIP:output_type -> adaptiveipam.v1.CheckPodIPResponse
+	10, // 15: adaptiveipam.v1.AdaptiveIpam.ReleaseStalePodIPs:output_type -> adaptiveipam.v1.ReleaseStalePodIPsResponse
+	12, // 16: adaptiveipam.v1.AdaptiveIpam.GetNetworkStatus:output_type -> adaptiveipam.v1.GetNetworkStatusResponse
+	14, // 17: adaptiveipam.v1.AdaptiveIpam.ReportIPConflict:output_type -> adaptiveipam.v1.ReportIPConflictResponse
+	12, // [12:18] is the sub-list for method output_type
+	6,  // [6:12] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_metis_api_adaptiveipam_v1_adaptiveipam_proto_init() }
@@ -620,7 +1086,7 @@ func file_metis_api_adaptiveipam_v1_adaptiveipam_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDesc), len(file_metis_api_adaptiveipam_v1_adaptiveipam_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
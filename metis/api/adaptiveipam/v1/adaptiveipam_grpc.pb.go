@@ -19,9 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	AdaptiveIpam_AllocatePodIP_FullMethodName   = "/adaptiveipam.v1.AdaptiveIpam/AllocatePodIP"
-	AdaptiveIpam_DeallocatePodIP_FullMethodName = "/adaptiveipam.v1.AdaptiveIpam/DeallocatePodIP"
-	AdaptiveIpam_CheckPodIP_FullMethodName      = "/adaptiveipam.v1.AdaptiveIpam/CheckPodIP"
+	AdaptiveIpam_AllocatePodIP_FullMethodName      = "/adaptiveipam.v1.AdaptiveIpam/AllocatePodIP"
+	AdaptiveIpam_DeallocatePodIP_FullMethodName    = "/adaptiveipam.v1.AdaptiveIpam/DeallocatePodIP"
+	AdaptiveIpam_CheckPodIP_FullMethodName         = "/adaptiveipam.v1.AdaptiveIpam/CheckPodIP"
+	AdaptiveIpam_ReleaseStalePodIPs_FullMethodName = "/adaptiveipam.v1.AdaptiveIpam/ReleaseStalePodIPs"
+	AdaptiveIpam_GetNetworkStatus_FullMethodName   = "/adaptiveipam.v1.AdaptiveIpam/GetNetworkStatus"
+	AdaptiveIpam_ReportIPConflict_FullMethodName   = "/adaptiveipam.v1.AdaptiveIpam/ReportIPConflict"
 )
 
 // AdaptiveIpamClient is the client API for AdaptiveIpam service.
@@ -42,6 +45,19 @@ type AdaptiveIpamClient interface {
 	// An empty response is returned on success; RPC errors are used to indicate failure
 	// or if the IP is not allocated.
 	CheckPodIP(ctx context.Context, in *CheckPodIPRequest, opts ...grpc.CallOption) (*CheckPodIPResponse, error)
+	// ReleaseStalePodIPs releases the IPs of all pod interfaces on a network
+	// except the attachments the container runtime still considers valid. It
+	// backs the CNI GC verb.
+	ReleaseStalePodIPs(ctx context.Context, in *ReleaseStalePodIPsRequest, opts ...grpc.CallOption) (*ReleaseStalePodIPsResponse, error)
+	// GetNetworkStatus returns the number of IPs available for new pods on a
+	// network. It backs the CNI STATUS verb.
+	GetNetworkStatus(ctx context.Context, in *GetNetworkStatusRequest, opts ...grpc.CallOption) (*GetNetworkStatusResponse, error)
+	// ReportIPConflict reports that an IP allocated to a pod interface is
+	// already in use by another host on the network. The IP is quarantined, so
+	// that it is not allocated again until the quarantine is removed, and
+	// released from the pod interface. A following AllocatePodIP for the
+	// interface allocates another IP.
+	ReportIPConflict(ctx context.Context, in *ReportIPConflictRequest, opts ...grpc.CallOption) (*ReportIPConflictResponse, error)
 }
 
 type adaptiveIpamClient struct {
@@ -82,6 +98,36 @@ func (c *adaptiveIpamClient) CheckPodIP(ctx context.Context, in *CheckPodIPReque
 	return out, nil
 }
 
+func (c *adaptiveIpamClient) ReleaseStalePodIPs(ctx context.Context, in *ReleaseStalePodIPsRequest, opts ...grpc.CallOption) (*ReleaseStalePodIPsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReleaseStalePodIPsResponse)
+	err := c.cc.Invoke(ctx, AdaptiveIpam_ReleaseStalePodIPs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adaptiveIpamClient) GetNetworkStatus(ctx context.Context, in *GetNetworkStatusRequest, opts ...grpc.CallOption) (*GetNetworkStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetNetworkStatusResponse)
+	err := c.cc.Invoke(ctx, AdaptiveIpam_GetNetworkStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adaptiveIpamClient) ReportIPConflict(ctx context.Context, in *ReportIPConflictRequest, opts ...grpc.CallOption) (*ReportIPConflictResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportIPConflictResponse)
+	err := c.cc.Invoke(ctx, AdaptiveIpam_ReportIPConflict_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AdaptiveIpamServer is the server API for AdaptiveIpam service.
 // All implementations must embed UnimplementedAdaptiveIpamServer
 // for forward compatibility.
@@ -100,6 +146,19 @@ type AdaptiveIpamServer interface {
 	// An empty response is returned on success; RPC errors are used to indicate failure
 	// or if the IP is not allocated.
 	CheckPodIP(context.Context, *CheckPodIPRequest) (*CheckPodIPResponse, error)
+	// ReleaseStalePodIPs releases the IPs of all pod interfaces on a network
+	// except the attachments the container runtime still considers valid. It
+	// backs the CNI GC verb.
+	ReleaseStalePodIPs(context.Context, *ReleaseStalePodIPsRequest) (*ReleaseStalePodIPsResponse, error)
+	// GetNetworkStatus returns the number of IPs available for new pods on a
+	// network. It backs the CNI STATUS verb.
+	GetNetworkStatus(context.Context, *GetNetworkStatusRequest) (*GetNetworkStatusResponse, error)
+	// ReportIPConflict reports that an IP allocated to a pod interface is
+	// already in use by another host on the network. The IP is quarantined, so
+	// that it is not allocated again until the quarantine is removed, and
+	// released from the pod interface. A following AllocatePodIP for the
+	// interface allocates another IP.
+	ReportIPConflict(context.Context, *ReportIPConflictRequest) (*ReportIPConflictResponse, error)
 	mustEmbedUnimplementedAdaptiveIpamServer()
 }
 
@@ -119,6 +178,15 @@ func (UnimplementedAdaptiveIpamServer) DeallocatePodIP(context.Context, *Dealloc
 func (UnimplementedAdaptiveIpamServer) CheckPodIP(context.Context, *CheckPodIPRequest) (*CheckPodIPResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method CheckPodIP not implemented")
 }
+func (UnimplementedAdaptiveIpamServer) ReleaseStalePodIPs(context.Context, *ReleaseStalePodIPsRequest) (*ReleaseStalePodIPsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReleaseStalePodIPs not implemented")
+}
+func (UnimplementedAdaptiveIpamServer) GetNetworkStatus(context.Context, *GetNetworkStatusRequest) (*GetNetworkStatusResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetNetworkStatus not implemented")
+}
+func (UnimplementedAdaptiveIpamServer) ReportIPConflict(context.Context, *ReportIPConflictRequest) (*ReportIPConflictResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportIPConflict not implemented")
+}
 func (UnimplementedAdaptiveIpamServer) mustEmbedUnimplementedAdaptiveIpamServer() {}
 func (UnimplementedAdaptiveIpamServer) testEmbeddedByValue()                      {}
 
@@ -194,6 +262,60 @@ func _AdaptiveIpam_CheckPodIP_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AdaptiveIpam_ReleaseStalePodIPs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseStalePodIPsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdaptiveIpamServer).ReleaseStalePodIPs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdaptiveIpam_ReleaseStalePodIPs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdaptiveIpamServer).ReleaseStalePodIPs(ctx, req.(*ReleaseStalePodIPsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdaptiveIpam_GetNetworkStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNetworkStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdaptiveIpamServer).GetNetworkStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdaptiveIpam_GetNetworkStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdaptiveIpamServer).GetNetworkStatus(ctx, req.(*GetNetworkStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdaptiveIpam_ReportIPConflict_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportIPConflictRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdaptiveIpamServer).ReportIPConflict(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdaptiveIpam_ReportIPConflict_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdaptiveIpamServer).ReportIPConflict(ctx, req.(*ReportIPConflictRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AdaptiveIpam_ServiceDesc is the grpc.ServiceDesc for AdaptiveIpam service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -213,6 +335,18 @@ var AdaptiveIpam_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CheckPodIP",
 			Handler:    _AdaptiveIpam_CheckPodIP_Handler,
 		},
+		{
+			MethodName: "ReleaseStalePodIPs",
+			Handler:    _AdaptiveIpam_ReleaseStalePodIPs_Handler,
+		},
+		{
+			MethodName: "GetNetworkStatus",
+			Handler:    _AdaptiveIpam_GetNetworkStatus_Handler,
+		},
+		{
+			MethodName: "ReportIPConflict",
+			Handler:    _AdaptiveIpam_ReportIPConflict_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "metis/api/adaptiveipam/v1/adaptiveipam.proto",
@@ -9,6 +9,7 @@ package adminv1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,11 +22,92 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// ListCIDRBlocksRequest requests a dump of CIDR blocks from the DB.
+// Type is the kind of change.
+type WatchEvent_Type int32
+
+const (
+	WatchEvent_TYPE_UNSPECIFIED WatchEvent_Type = 0
+	// An IP address was assigned to a pod.
+	WatchEvent_IP_ALLOCATED WatchEvent_Type = 1
+	// An IP address was released by a pod.
+	WatchEvent_IP_RELEASED WatchEvent_Type = 2
+	// A CIDR block was added to the store.
+	WatchEvent_BLOCK_ADDED WatchEvent_Type = 3
+	// A CIDR block was marked as Draining to scale down.
+	WatchEvent_BLOCK_DRAINING WatchEvent_Type = 4
+	// A CIDR block was deleted from the store after it was released.
+	WatchEvent_BLOCK_DELETED WatchEvent_Type = 5
+	// More pod capacity is requested for a network.
+	WatchEvent_SCALE_UP_REQUESTED WatchEvent_Type = 6
+	// The NodeNetworkConfig of the node was patched.
+	WatchEvent_NNC_PATCHED WatchEvent_Type = 7
+)
+
+// Enum value maps for WatchEvent_Type.
+var (
+	WatchEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "IP_ALLOCATED",
+		2: "IP_RELEASED",
+		3: "BLOCK_ADDED",
+		4: "BLOCK_DRAINING",
+		5: "BLOCK_DELETED",
+		6: "SCALE_UP_REQUESTED",
+		7: "NNC_PATCHED",
+	}
+	WatchEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED":   0,
+		"IP_ALLOCATED":       1,
+		"IP_RELEASED":        2,
+		"BLOCK_ADDED":        3,
+		"BLOCK_DRAINING":     4,
+		"BLOCK_DELETED":      5,
+		"SCALE_UP_REQUESTED": 6,
+		"NNC_PATCHED":        7,
+	}
+)
+
+func (x WatchEvent_Type) Enum() *WatchEvent_Type {
+	p := new(WatchEvent_Type)
+	*p = x
+	return p
+}
+
+func (x WatchEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WatchEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_metis_api_admin_v1_admin_proto_enumTypes[0].Descriptor()
+}
+
+func (WatchEvent_Type) Type() protoreflect.EnumType {
+	return &file_metis_api_admin_v1_admin_proto_enumTypes[0]
+}
+
+func (x WatchEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WatchEvent_Type.Descriptor instead.
+func (WatchEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{20, 0}
+}
+
+// ListCIDRBlocksRequest requests CIDR blocks from the DB. All filters are
+// optional and combined with AND.
 type ListCIDRBlocksRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Optional native SQLite WHERE clause to filter the returned rows.
-	Filter        string `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Only return CIDR blocks of this network.
+	Network string `protobuf:"bytes,2,opt,name=network,proto3" json:"network,omitempty"`
+	// Only return CIDR blocks of this IP family ("ipv4" or "ipv6").
+	IpFamily string `protobuf:"bytes,3,opt,name=ip_family,json=ipFamily,proto3" json:"ip_family,omitempty"`
+	// Only return CIDR blocks in this state ("Ready", "Draining" or "Deleting").
+	State string `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	// The maximum number of CIDR blocks to return. The daemon picks a default if unset.
+	PageSize int32 `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// The next_page_token of a previous response to continue listing from.
+	PageToken     string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -60,36 +142,66 @@ func (*ListCIDRBlocksRequest) Descriptor() ([]byte, []int) {
 	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{0}
 }
 
-func (x *ListCIDRBlocksRequest) GetFilter() string {
+func (x *ListCIDRBlocksRequest) GetNetwork() string {
 	if x != nil {
-		return x.Filter
+		return x.Network
 	}
 	return ""
 }
 
-// ListIPAddressesRequest requests a dump of IP addresses from the DB.
-type ListIPAddressesRequest struct {
+func (x *ListCIDRBlocksRequest) GetIpFamily() string {
+	if x != nil {
+		return x.IpFamily
+	}
+	return ""
+}
+
+func (x *ListCIDRBlocksRequest) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *ListCIDRBlocksRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListCIDRBlocksRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// ListCIDRBlocksResponse is a page of CIDR blocks ordered by ID.
+type ListCIDRBlocksResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Optional native SQLite WHERE clause to filter the returned rows.
-	Filter        string `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// The CIDR blocks of this page.
+	CidrBlocks []*CIDRBlock `protobuf:"bytes,1,rep,name=cidr_blocks,json=cidrBlocks,proto3" json:"cidr_blocks,omitempty"`
+	// Token to request the next page, empty if this is the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListIPAddressesRequest) Reset() {
-	*x = ListIPAddressesRequest{}
+func (x *ListCIDRBlocksResponse) Reset() {
+	*x = ListCIDRBlocksResponse{}
 	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListIPAddressesRequest) String() string {
+func (x *ListCIDRBlocksResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListIPAddressesRequest) ProtoMessage() {}
+func (*ListCIDRBlocksResponse) ProtoMessage() {}
 
-func (x *ListIPAddressesRequest) ProtoReflect() protoreflect.Message {
+func (x *ListCIDRBlocksResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -101,43 +213,64 @@ func (x *ListIPAddressesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListIPAddressesRequest.ProtoReflect.Descriptor instead.
-func (*ListIPAddressesRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListCIDRBlocksResponse.ProtoReflect.Descriptor instead.
+func (*ListCIDRBlocksResponse) Descriptor() ([]byte, []int) {
 	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *ListIPAddressesRequest) GetFilter() string {
+func (x *ListCIDRBlocksResponse) GetCidrBlocks() []*CIDRBlock {
 	if x != nil {
-		return x.Filter
+		return x.CidrBlocks
+	}
+	return nil
+}
+
+func (x *ListCIDRBlocksResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
 	}
 	return ""
 }
 
-// AdminTableDumpResponse is a generic table output containing column headers and row values.
-type AdminTableDumpResponse struct {
+// CIDRBlock is a record of the cidr_blocks table.
+type CIDRBlock struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// The column headers dynamically extracted from the database.
-	Headers []string `protobuf:"bytes,1,rep,name=headers,proto3" json:"headers,omitempty"`
-	// The individual row records mapped column-by-column.
-	Rows          []*Row `protobuf:"bytes,2,rep,name=rows,proto3" json:"rows,omitempty"`
+	// Unique identifier of the CIDR block.
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// The IP range, e.g. "10.0.1.0/28".
+	Cidr string `protobuf:"bytes,2,opt,name=cidr,proto3" json:"cidr,omitempty"`
+	// The logical network the block belongs to.
+	Network string `protobuf:"bytes,3,opt,name=network,proto3" json:"network,omitempty"`
+	// The IP family of the block, "ipv4" or "ipv6".
+	IpFamily string `protobuf:"bytes,4,opt,name=ip_family,json=ipFamily,proto3" json:"ip_family,omitempty"`
+	// The total number of IP addresses of the block.
+	TotalIps int64 `protobuf:"varint,5,opt,name=total_ips,json=totalIps,proto3" json:"total_ips,omitempty"`
+	// The number of IP addresses of the block that are assigned to pods.
+	AllocatedIps int64 `protobuf:"varint,6,opt,name=allocated_ips,json=allocatedIps,proto3" json:"allocated_ips,omitempty"`
+	// The operational state of the block.
+	State string `protobuf:"bytes,7,opt,name=state,proto3" json:"state,omitempty"`
+	// When the block was added to the store.
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// When the block was last updated.
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AdminTableDumpResponse) Reset() {
-	*x = AdminTableDumpResponse{}
+func (x *CIDRBlock) Reset() {
+	*x = CIDRBlock{}
 	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AdminTableDumpResponse) String() string {
+func (x *CIDRBlock) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AdminTableDumpResponse) ProtoMessage() {}
+func (*CIDRBlock) ProtoMessage() {}
 
-func (x *AdminTableDumpResponse) ProtoReflect() protoreflect.Message {
+func (x *CIDRBlock) ProtoReflect() protoreflect.Message {
 	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -149,48 +282,114 @@ func (x *AdminTableDumpResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AdminTableDumpResponse.ProtoReflect.Descriptor instead.
-func (*AdminTableDumpResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use CIDRBlock.ProtoReflect.Descriptor instead.
+func (*CIDRBlock) Descriptor() ([]byte, []int) {
 	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *AdminTableDumpResponse) GetHeaders() []string {
+func (x *CIDRBlock) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *CIDRBlock) GetCidr() string {
+	if x != nil {
+		return x.Cidr
+	}
+	return ""
+}
+
+func (x *CIDRBlock) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *CIDRBlock) GetIpFamily() string {
+	if x != nil {
+		return x.IpFamily
+	}
+	return ""
+}
+
+func (x *CIDRBlock) GetTotalIps() int64 {
+	if x != nil {
+		return x.TotalIps
+	}
+	return 0
+}
+
+func (x *CIDRBlock) GetAllocatedIps() int64 {
+	if x != nil {
+		return x.AllocatedIps
+	}
+	return 0
+}
+
+func (x *CIDRBlock) GetState() string {
 	if x != nil {
-		return x.Headers
+		return x.State
+	}
+	return ""
+}
+
+func (x *CIDRBlock) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
 	}
 	return nil
 }
 
-func (x *AdminTableDumpResponse) GetRows() []*Row {
+func (x *CIDRBlock) GetUpdatedAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Rows
+		return x.UpdatedAt
 	}
 	return nil
 }
 
-// Row represents a single table record as generic string data.
-type Row struct {
+// ListIPAddressesRequest requests IP addresses from the DB. All filters are
+// optional and combined with AND.
+type ListIPAddressesRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// The string representations of each cell in the row aligned with headers.
-	Values        []string `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	// Only return IP addresses of CIDR blocks of this network.
+	Network string `protobuf:"bytes,2,opt,name=network,proto3" json:"network,omitempty"`
+	// Only return IP addresses of this IP family ("ipv4" or "ipv6").
+	IpFamily string `protobuf:"bytes,3,opt,name=ip_family,json=ipFamily,proto3" json:"ip_family,omitempty"`
+	// Only return IP addresses of CIDR blocks in this state ("Ready", "Draining" or "Deleting").
+	State string `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	// Only return IP addresses held by this container ID.
+	ContainerId string `protobuf:"bytes,5,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	// Only return IP addresses held by pods in this namespace.
+	PodNamespace string `protobuf:"bytes,6,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	// Only return IP addresses held by pods with this name.
+	PodName string `protobuf:"bytes,7,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	// Only return IP addresses that are currently assigned to a pod.
+	AllocatedOnly bool `protobuf:"varint,8,opt,name=allocated_only,json=allocatedOnly,proto3" json:"allocated_only,omitempty"`
+	// The maximum number of IP addresses to return. The daemon picks a default if unset.
+	PageSize int32 `protobuf:"varint,9,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// The next_page_token of a previous response to continue listing from.
+	PageToken     string `protobuf:"bytes,10,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Row) Reset() {
-	*x = Row{}
+func (x *ListIPAddressesRequest) Reset() {
+	*x = ListIPAddressesRequest{}
 	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Row) String() string {
+func (x *ListIPAddressesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Row) ProtoMessage() {}
+func (*ListIPAddressesRequest) ProtoMessage() {}
 
-func (x *Row) ProtoReflect() protoreflect.Message {
+func (x *ListIPAddressesRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -202,35 +401,2198 @@ func (x *Row) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Row.ProtoReflect.Descriptor instead.
-func (*Row) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListIPAddressesRequest.ProtoReflect.Descriptor instead.
+func (*ListIPAddressesRequest) Descriptor() ([]byte, []int) {
 	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *Row) GetValues() []string {
+func (x *ListIPAddressesRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *ListIPAddressesRequest) GetIpFamily() string {
+	if x != nil {
+		return x.IpFamily
+	}
+	return ""
+}
+
+func (x *ListIPAddressesRequest) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *ListIPAddressesRequest) GetContainerId() string {
+	if x != nil {
+		return x.ContainerId
+	}
+	return ""
+}
+
+func (x *ListIPAddressesRequest) GetPodNamespace() string {
+	if x != nil {
+		return x.PodNamespace
+	}
+	return ""
+}
+
+func (x *ListIPAddressesRequest) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+func (x *ListIPAddressesRequest) GetAllocatedOnly() bool {
+	if x != nil {
+		return x.AllocatedOnly
+	}
+	return false
+}
+
+func (x *ListIPAddressesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListIPAddressesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// ListIPAddressesResponse is a page of IP addresses ordered by ID.
+type ListIPAddressesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The IP addresses of this page.
+	IpAddresses []*IPAddress `protobuf:"bytes,1,rep,name=ip_addresses,json=ipAddresses,proto3" json:"ip_addresses,omitempty"`
+	// Token to request the next page, empty if this is the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIPAddressesResponse) Reset() {
+	*x = ListIPAddressesResponse{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIPAddressesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIPAddressesResponse) ProtoMessage() {}
+
+func (x *ListIPAddressesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIPAddressesResponse.ProtoReflect.Descriptor instead.
+func (*ListIPAddressesResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListIPAddressesResponse) GetIpAddresses() []*IPAddress {
 	if x != nil {
-		return x.Values
+		return x.IpAddresses
 	}
 	return nil
 }
 
-var File_metis_api_admin_v1_admin_proto protoreflect.FileDescriptor
+func (x *ListIPAddressesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
 
-const file_metis_api_admin_v1_admin_proto_rawDesc = "" +
+// IPAddress is a record of the ip_addresses table together with the
+// network and IP family of its CIDR block.
+type IPAddress struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unique identifier of the IP address record.
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// The IP address, e.g. "10.0.1.2".
+	Address string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	// The ID of the CIDR block the address belongs to.
+	CidrBlockId int64 `protobuf:"varint,3,opt,name=cidr_block_id,json=cidrBlockId,proto3" json:"cidr_block_id,omitempty"`
+	// The network of the CIDR block the address belongs to.
+	Network string `protobuf:"bytes,4,opt,name=network,proto3" json:"network,omitempty"`
+	// The IP family of the address, "ipv4" or "ipv6".
+	IpFamily string `protobuf:"bytes,5,opt,name=ip_family,json=ipFamily,proto3" json:"ip_family,omitempty"`
+	// The container ID of the pod holding the address.
+	ContainerId string `protobuf:"bytes,6,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	// The name of the pod holding the address.
+	PodName string `protobuf:"bytes,7,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	// The namespace of the pod holding the address.
+	PodNamespace string `protobuf:"bytes,8,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	// The interface name inside the container holding the address.
+	InterfaceName string `protobuf:"bytes,9,opt,name=interface_name,json=interfaceName,proto3" json:"interface_name,omitempty"`
+	// Whether the address is currently assigned to a pod.
+	IsAllocated bool `protobuf:"varint,10,opt,name=is_allocated,json=isAllocated,proto3" json:"is_allocated,omitempty"`
+	// When a released address finishes its cooldown and can be reassigned.
+	ReleaseAt *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=release_at,json=releaseAt,proto3" json:"release_at,omitempty"`
+	// When the address was assigned to its current container.
+	AllocatedAt *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=allocated_at,json=allocatedAt,proto3" json:"allocated_at,omitempty"`
+	// When the address was last updated.
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IPAddress) Reset() {
+	*x = IPAddress{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IPAddress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IPAddress) ProtoMessage() {}
+
+func (x *IPAddress) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IPAddress.ProtoReflect.Descriptor instead.
+func (*IPAddress) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *IPAddress) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *IPAddress) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *IPAddress) GetCidrBlockId() int64 {
+	if x != nil {
+		return x.CidrBlockId
+	}
+	return 0
+}
+
+func (x *IPAddress) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *IPAddress) GetIpFamily() string {
+	if x != nil {
+		return x.IpFamily
+	}
+	return ""
+}
+
+func (x *IPAddress) GetContainerId() string {
+	if x != nil {
+		return x.ContainerId
+	}
+	return ""
+}
+
+func (x *IPAddress) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+func (x *IPAddress) GetPodNamespace() string {
+	if x != nil {
+		return x.PodNamespace
+	}
+	return ""
+}
+
+func (x *IPAddress) GetInterfaceName() string {
+	if x != nil {
+		return x.InterfaceName
+	}
+	return ""
+}
+
+func (x *IPAddress) GetIsAllocated() bool {
+	if x != nil {
+		return x.IsAllocated
+	}
+	return false
+}
+
+func (x *IPAddress) GetReleaseAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReleaseAt
+	}
+	return nil
+}
+
+func (x *IPAddress) GetAllocatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AllocatedAt
+	}
+	return nil
+}
+
+func (x *IPAddress) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// GarbageCollectRequest requests a garbage collection pass of the IPs held by
+// pods that no longer exist on the node.
+type GarbageCollectRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only report the stale IP owners without releasing their IPs.
+	DryRun        bool `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GarbageCollectRequest) Reset() {
+	*x = GarbageCollectRequest{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GarbageCollectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GarbageCollectRequest) ProtoMessage() {}
+
+func (x *GarbageCollectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GarbageCollectRequest.ProtoReflect.Descriptor instead.
+func (*GarbageCollectRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GarbageCollectRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+// GarbageCollectResponse lists the stale IP owners found by a garbage collection pass.
+type GarbageCollectResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The stale IP owners. Their IPs were released unless dry_run was set.
+	StaleOwners []*StaleIPOwner `protobuf:"bytes,1,rep,name=stale_owners,json=staleOwners,proto3" json:"stale_owners,omitempty"`
+	// Whether the pass was a dry run.
+	DryRun        bool `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GarbageCollectResponse) Reset() {
+	*x = GarbageCollectResponse{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GarbageCollectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GarbageCollectResponse) ProtoMessage() {}
+
+func (x *GarbageCollectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GarbageCollectResponse.ProtoReflect.Descriptor instead.
+func (*GarbageCollectResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GarbageCollectResponse) GetStaleOwners() []*StaleIPOwner {
+	if x != nil {
+		return x.StaleOwners
+	}
+	return nil
+}
+
+func (x *GarbageCollectResponse) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+// StaleIPOwner is a container interface holding IPs for a pod or sandbox that
+// no longer exists.
+type StaleIPOwner struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The network of the IPs.
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// The container ID holding the IPs.
+	ContainerId string `protobuf:"bytes,2,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	// The interface name inside the container holding the IPs.
+	InterfaceName string `protobuf:"bytes,3,opt,name=interface_name,json=interfaceName,proto3" json:"interface_name,omitempty"`
+	// The namespace of the pod holding the IPs.
+	PodNamespace string `protobuf:"bytes,4,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	// The name of the pod holding the IPs.
+	PodName string `protobuf:"bytes,5,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	// The network namespace path of the pod sandbox.
+	Netns string `protobuf:"bytes,6,opt,name=netns,proto3" json:"netns,omitempty"`
+	// The IPs held by the owner.
+	Addresses []string `protobuf:"bytes,7,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	// Why the owner is considered stale, e.g. "PodNotFound".
+	Reason string `protobuf:"bytes,8,opt,name=reason,proto3" json:"reason,omitempty"`
+	// When the oldest of the IPs was assigned to the owner.
+	AllocatedAt   *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=allocated_at,json=allocatedAt,proto3" json:"allocated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StaleIPOwner) Reset() {
+	*x = StaleIPOwner{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StaleIPOwner) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StaleIPOwner) ProtoMessage() {}
+
+func (x *StaleIPOwner) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StaleIPOwner.ProtoReflect.Descriptor instead.
+func (*StaleIPOwner) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StaleIPOwner) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *StaleIPOwner) GetContainerId() string {
+	if x != nil {
+		return x.ContainerId
+	}
+	return ""
+}
+
+func (x *StaleIPOwner) GetInterfaceName() string {
+	if x != nil {
+		return x.InterfaceName
+	}
+	return ""
+}
+
+func (x *StaleIPOwner) GetPodNamespace() string {
+	if x != nil {
+		return x.PodNamespace
+	}
+	return ""
+}
+
+func (x *StaleIPOwner) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+func (x *StaleIPOwner) GetNetns() string {
+	if x != nil {
+		return x.Netns
+	}
+	return ""
+}
+
+func (x *StaleIPOwner) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *StaleIPOwner) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *StaleIPOwner) GetAllocatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AllocatedAt
+	}
+	return nil
+}
+
+// IPReservation is an address excluded from regular allocation. If the pod
+// fields are set, the address is assigned to that pod only.
+type IPReservation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The network of the address.
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// The reserved IP address, e.g. "10.0.1.2".
+	Address string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	// The IP family of the address, "ipv4" or "ipv6".
+	IpFamily string `protobuf:"bytes,3,opt,name=ip_family,json=ipFamily,proto3" json:"ip_family,omitempty"`
+	// The namespace of the pod the address is reserved for, if any.
+	PodNamespace string `protobuf:"bytes,4,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	// The name of the pod the address is reserved for, if any.
+	PodName string `protobuf:"bytes,5,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	// When the reservation was added.
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IPReservation) Reset() {
+	*x = IPReservation{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IPReservation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IPReservation) ProtoMessage() {}
+
+func (x *IPReservation) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IPReservation.ProtoReflect.Descriptor instead.
+func (*IPReservation) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *IPReservation) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *IPReservation) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *IPReservation) GetIpFamily() string {
+	if x != nil {
+		return x.IpFamily
+	}
+	return ""
+}
+
+func (x *IPReservation) GetPodNamespace() string {
+	if x != nil {
+		return x.PodNamespace
+	}
+	return ""
+}
+
+func (x *IPReservation) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+func (x *IPReservation) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+// AddIPReservationRequest reserves an address. The pod namespace and name
+// must be both set or both empty.
+type AddIPReservationRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The network of the address. Defaults to the default pod network.
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// The IP address to reserve.
+	Address string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	// The namespace of the pod to reserve the address for.
+	PodNamespace string `protobuf:"bytes,3,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	// The name of the pod to reserve the address for.
+	PodName       string `protobuf:"bytes,4,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddIPReservationRequest) Reset() {
+	*x = AddIPReservationRequest{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddIPReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddIPReservationRequest) ProtoMessage() {}
+
+func (x *AddIPReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddIPReservationRequest.ProtoReflect.Descriptor instead.
+func (*AddIPReservationRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AddIPReservationRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *AddIPReservationRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *AddIPReservationRequest) GetPodNamespace() string {
+	if x != nil {
+		return x.PodNamespace
+	}
+	return ""
+}
+
+func (x *AddIPReservationRequest) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+// AddIPReservationResponse returns the added reservation.
+type AddIPReservationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reservation   *IPReservation         `protobuf:"bytes,1,opt,name=reservation,proto3" json:"reservation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddIPReservationResponse) Reset() {
+	*x = AddIPReservationResponse{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddIPReservationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddIPReservationResponse) ProtoMessage() {}
+
+func (x *AddIPReservationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddIPReservationResponse.ProtoReflect.Descriptor instead.
+func (*AddIPReservationResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AddIPReservationResponse) GetReservation() *IPReservation {
+	if x != nil {
+		return x.Reservation
+	}
+	return nil
+}
+
+// RemoveIPReservationRequest removes the reservation of an address.
+type RemoveIPReservationRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The network of the address. Defaults to the default pod network.
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// The reserved IP address.
+	Address       string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveIPReservationRequest) Reset() {
+	*x = RemoveIPReservationRequest{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveIPReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveIPReservationRequest) ProtoMessage() {}
+
+func (x *RemoveIPReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveIPReservationRequest.ProtoReflect.Descriptor instead.
+func (*RemoveIPReservationRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RemoveIPReservationRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *RemoveIPReservationRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+// RemoveIPReservationResponse is returned once the reservation is removed.
+type RemoveIPReservationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveIPReservationResponse) Reset() {
+	*x = RemoveIPReservationResponse{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveIPReservationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveIPReservationResponse) ProtoMessage() {}
+
+func (x *RemoveIPReservationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveIPReservationResponse.ProtoReflect.Descriptor instead.
+func (*RemoveIPReservationResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{13}
+}
+
+// ListIPReservationsRequest requests the reserved addresses.
+type ListIPReservationsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only list the reservations of this network. All networks if empty.
+	Network       string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIPReservationsRequest) Reset() {
+	*x = ListIPReservationsRequest{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIPReservationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIPReservationsRequest) ProtoMessage() {}
+
+func (x *ListIPReservationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIPReservationsRequest.ProtoReflect.Descriptor instead.
+func (*ListIPReservationsRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListIPReservationsRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+// ListIPReservationsResponse lists the reserved addresses in the order they were added.
+type ListIPReservationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reservations  []*IPReservation       `protobuf:"bytes,1,rep,name=reservations,proto3" json:"reservations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIPReservationsResponse) Reset() {
+	*x = ListIPReservationsResponse{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIPReservationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIPReservationsResponse) ProtoMessage() {}
+
+func (x *ListIPReservationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIPReservationsResponse.ProtoReflect.Descriptor instead.
+func (*ListIPReservationsResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListIPReservationsResponse) GetReservations() []*IPReservation {
+	if x != nil {
+		return x.Reservations
+	}
+	return nil
+}
+
+// ListAllocationHistoryRequest requests records of the allocation history.
+// All filters are optional and combined with AND.
+type ListAllocationHistoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only return records of this network.
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// Only return records of this IP address.
+	Address string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	// Only return records of this container ID.
+	ContainerId string `protobuf:"bytes,3,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	// Only return records of pods in this namespace.
+	PodNamespace string `protobuf:"bytes,4,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	// Only return records of pods with this name.
+	PodName string `protobuf:"bytes,5,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	// Only return records of events that occurred at or after this time.
+	Since *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=since,proto3" json:"since,omitempty"`
+	// The maximum number of records to return. The daemon picks a default if unset.
+	PageSize int32 `protobuf:"varint,7,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// The next_page_token of a previous response to continue listing from.
+	PageToken     string `protobuf:"bytes,8,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAllocationHistoryRequest) Reset() {
+	*x = ListAllocationHistoryRequest{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAllocationHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAllocationHistoryRequest) ProtoMessage() {}
+
+func (x *ListAllocationHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAllocationHistoryRequest.ProtoReflect.Descriptor instead.
+func (*ListAllocationHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListAllocationHistoryRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *ListAllocationHistoryRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *ListAllocationHistoryRequest) GetContainerId() string {
+	if x != nil {
+		return x.ContainerId
+	}
+	return ""
+}
+
+func (x *ListAllocationHistoryRequest) GetPodNamespace() string {
+	if x != nil {
+		return x.PodNamespace
+	}
+	return ""
+}
+
+func (x *ListAllocationHistoryRequest) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+func (x *ListAllocationHistoryRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *ListAllocationHistoryRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListAllocationHistoryRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// ListAllocationHistoryResponse is a page of the allocation history in the
+// order the events were recorded.
+type ListAllocationHistoryResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The records of this page.
+	Records []*HistoryRecord `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	// Token to request the next page, empty if this is the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAllocationHistoryResponse) Reset() {
+	*x = ListAllocationHistoryResponse{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAllocationHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAllocationHistoryResponse) ProtoMessage() {}
+
+func (x *ListAllocationHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAllocationHistoryResponse.ProtoReflect.Descriptor instead.
+func (*ListAllocationHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListAllocationHistoryResponse) GetRecords() []*HistoryRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+func (x *ListAllocationHistoryResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// HistoryRecord is an event of the allocation history of an IP address or a
+// CIDR block. The address and owner fields are empty for CIDR block events.
+type HistoryRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unique identifier of the record, increasing in the order the events were recorded.
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// The kind of event, e.g. "Allocated", "Released", "CooldownExpired" or "BlockDraining".
+	Event string `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	// The network of the CIDR block of the event.
+	Network string `protobuf:"bytes,3,opt,name=network,proto3" json:"network,omitempty"`
+	// The CIDR block of the event, e.g. "10.0.1.0/28".
+	Cidr string `protobuf:"bytes,4,opt,name=cidr,proto3" json:"cidr,omitempty"`
+	// The IP family of the CIDR block, "ipv4" or "ipv6".
+	IpFamily string `protobuf:"bytes,5,opt,name=ip_family,json=ipFamily,proto3" json:"ip_family,omitempty"`
+	// The IP address of the event, e.g. "10.0.1.2".
+	Address string `protobuf:"bytes,6,opt,name=address,proto3" json:"address,omitempty"`
+	// The container ID holding the address.
+	ContainerId string `protobuf:"bytes,7,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	// The interface name inside the container holding the address.
+	InterfaceName string `protobuf:"bytes,8,opt,name=interface_name,json=interfaceName,proto3" json:"interface_name,omitempty"`
+	// The namespace of the pod holding the address.
+	PodNamespace string `protobuf:"bytes,9,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	// The name of the pod holding the address.
+	PodName string `protobuf:"bytes,10,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	// For "Released" events, when the release cooldown of the address ends.
+	ReleaseAt *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=release_at,json=releaseAt,proto3" json:"release_at,omitempty"`
+	// When the event occurred.
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryRecord) Reset() {
+	*x = HistoryRecord{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRecord) ProtoMessage() {}
+
+func (x *HistoryRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRecord.ProtoReflect.Descriptor instead.
+func (*HistoryRecord) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *HistoryRecord) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *HistoryRecord) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *HistoryRecord) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *HistoryRecord) GetCidr() string {
+	if x != nil {
+		return x.Cidr
+	}
+	return ""
+}
+
+func (x *HistoryRecord) GetIpFamily() string {
+	if x != nil {
+		return x.IpFamily
+	}
+	return ""
+}
+
+func (x *HistoryRecord) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *HistoryRecord) GetContainerId() string {
+	if x != nil {
+		return x.ContainerId
+	}
+	return ""
+}
+
+func (x *HistoryRecord) GetInterfaceName() string {
+	if x != nil {
+		return x.InterfaceName
+	}
+	return ""
+}
+
+func (x *HistoryRecord) GetPodNamespace() string {
+	if x != nil {
+		return x.PodNamespace
+	}
+	return ""
+}
+
+func (x *HistoryRecord) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+func (x *HistoryRecord) GetReleaseAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReleaseAt
+	}
+	return nil
+}
+
+func (x *HistoryRecord) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+// WatchEventsRequest starts a stream of events. All filters are optional and
+// combined with AND.
+type WatchEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only stream events of this network.
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// Only stream events of these types.
+	Types         []WatchEvent_Type `protobuf:"varint,2,rep,packed,name=types,proto3,enum=admin.v1.WatchEvent_Type" json:"types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *WatchEventsRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *WatchEventsRequest) GetTypes() []WatchEvent_Type {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+// WatchEvent is a change of the IPAM state of the node. Only the fields that
+// apply to the type of the event are set.
+type WatchEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The kind of change.
+	Type WatchEvent_Type `protobuf:"varint,1,opt,name=type,proto3,enum=admin.v1.WatchEvent_Type" json:"type,omitempty"`
+	// When the change happened.
+	Time *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	// The network of the change.
+	Network string `protobuf:"bytes,3,opt,name=network,proto3" json:"network,omitempty"`
+	// The IP family of the change, "ipv4" or "ipv6".
+	IpFamily string `protobuf:"bytes,4,opt,name=ip_family,json=ipFamily,proto3" json:"ip_family,omitempty"`
+	// The CIDR block of the change, e.g. "10.0.1.0/28".
+	Cidr string `protobuf:"bytes,5,opt,name=cidr,proto3" json:"cidr,omitempty"`
+	// The IP address of IP events, e.g. "10.0.1.2".
+	Address string `protobuf:"bytes,6,opt,name=address,proto3" json:"address,omitempty"`
+	// The container ID holding the address of IP events.
+	ContainerId string `protobuf:"bytes,7,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	// The interface name inside the container holding the address of IP events.
+	InterfaceName string `protobuf:"bytes,8,opt,name=interface_name,json=interfaceName,proto3" json:"interface_name,omitempty"`
+	// The namespace of the pod holding the address of IP events.
+	PodNamespace string `protobuf:"bytes,9,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	// The name of the pod holding the address of IP events.
+	PodName string `protobuf:"bytes,10,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	// The pod capacity requested for the network in the NodeNetworkConfig, set
+	// for SCALE_UP_REQUESTED and NNC_PATCHED events.
+	TargetPods    int32 `protobuf:"varint,11,opt,name=target_pods,json=targetPods,proto3" json:"target_pods,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *WatchEvent) GetType() WatchEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return WatchEvent_TYPE_UNSPECIFIED
+}
+
+func (x *WatchEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetIpFamily() string {
+	if x != nil {
+		return x.IpFamily
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetCidr() string {
+	if x != nil {
+		return x.Cidr
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetContainerId() string {
+	if x != nil {
+		return x.ContainerId
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetInterfaceName() string {
+	if x != nil {
+		return x.InterfaceName
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetPodNamespace() string {
+	if x != nil {
+		return x.PodNamespace
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetTargetPods() int32 {
+	if x != nil {
+		return x.TargetPods
+	}
+	return 0
+}
+
+// CreateSnapshotRequest requests a snapshot of the store.
+type CreateSnapshotRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The path of the snapshot file on the node. If empty, the snapshot is
+	// written to the snapshot directory of the daemon, whose oldest snapshots
+	// are deleted beyond the configured retention.
+	Path          string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSnapshotRequest) Reset() {
+	*x = CreateSnapshotRequest{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSnapshotRequest) ProtoMessage() {}
+
+func (x *CreateSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*CreateSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CreateSnapshotRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+// CreateSnapshotResponse describes the written snapshot.
+type CreateSnapshotResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The path of the snapshot file on the node.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// The size of the snapshot file in bytes.
+	SizeBytes     int64 `protobuf:"varint,2,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSnapshotResponse) Reset() {
+	*x = CreateSnapshotResponse{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSnapshotResponse) ProtoMessage() {}
+
+func (x *CreateSnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSnapshotResponse.ProtoReflect.Descriptor instead.
+func (*CreateSnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *CreateSnapshotResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *CreateSnapshotResponse) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+// RestoreSnapshotRequest requests the store to be restored from a snapshot.
+type RestoreSnapshotRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The path of the snapshot file on the node. Required.
+	Path          string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreSnapshotRequest) Reset() {
+	*x = RestoreSnapshotRequest{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreSnapshotRequest) ProtoMessage() {}
+
+func (x *RestoreSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*RestoreSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *RestoreSnapshotRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+// RestoreSnapshotResponse is the response of a successful restore.
+type RestoreSnapshotResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreSnapshotResponse) Reset() {
+	*x = RestoreSnapshotResponse{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreSnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreSnapshotResponse) ProtoMessage() {}
+
+func (x *RestoreSnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreSnapshotResponse.ProtoReflect.Descriptor instead.
+func (*RestoreSnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{24}
+}
+
+// IPQuarantine is an address or a CIDR block that is not allocated until the
+// quarantine is removed. Exactly one of address and cidr is set.
+type IPQuarantine struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The network of the address or CIDR block.
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// The quarantined IP address, e.g. "10.0.1.2".
+	Address string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	// The quarantined CIDR block, e.g. "10.0.1.0/28".
+	Cidr string `protobuf:"bytes,3,opt,name=cidr,proto3" json:"cidr,omitempty"`
+	// The IP family of the address or CIDR block, "ipv4" or "ipv6".
+	IpFamily string `protobuf:"bytes,4,opt,name=ip_family,json=ipFamily,proto3" json:"ip_family,omitempty"`
+	// Why the address or CIDR block was quarantined.
+	Reason string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	// When the quarantine was added.
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IPQuarantine) Reset() {
+	*x = IPQuarantine{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IPQuarantine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IPQuarantine) ProtoMessage() {}
+
+func (x *IPQuarantine) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IPQuarantine.ProtoReflect.Descriptor instead.
+func (*IPQuarantine) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *IPQuarantine) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *IPQuarantine) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *IPQuarantine) GetCidr() string {
+	if x != nil {
+		return x.Cidr
+	}
+	return ""
+}
+
+func (x *IPQuarantine) GetIpFamily() string {
+	if x != nil {
+		return x.IpFamily
+	}
+	return ""
+}
+
+func (x *IPQuarantine) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *IPQuarantine) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+// AddIPQuarantineRequest quarantines an address or a CIDR block. Exactly one
+// of address and cidr must be set.
+type AddIPQuarantineRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The network of the address or CIDR block. Defaults to the default pod network.
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// The IP address to quarantine.
+	Address string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	// The CIDR block to quarantine.
+	Cidr string `protobuf:"bytes,3,opt,name=cidr,proto3" json:"cidr,omitempty"`
+	// Why the address or CIDR block is quarantined.
+	Reason        string `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddIPQuarantineRequest) Reset() {
+	*x = AddIPQuarantineRequest{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddIPQuarantineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddIPQuarantineRequest) ProtoMessage() {}
+
+func (x *AddIPQuarantineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddIPQuarantineRequest.ProtoReflect.Descriptor instead.
+func (*AddIPQuarantineRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *AddIPQuarantineRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *AddIPQuarantineRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *AddIPQuarantineRequest) GetCidr() string {
+	if x != nil {
+		return x.Cidr
+	}
+	return ""
+}
+
+func (x *AddIPQuarantineRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// AddIPQuarantineResponse returns the added quarantine.
+type AddIPQuarantineResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quarantine    *IPQuarantine          `protobuf:"bytes,1,opt,name=quarantine,proto3" json:"quarantine,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddIPQuarantineResponse) Reset() {
+	*x = AddIPQuarantineResponse{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddIPQuarantineResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddIPQuarantineResponse) ProtoMessage() {}
+
+func (x *AddIPQuarantineResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddIPQuarantineResponse.ProtoReflect.Descriptor instead.
+func (*AddIPQuarantineResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *AddIPQuarantineResponse) GetQuarantine() *IPQuarantine {
+	if x != nil {
+		return x.Quarantine
+	}
+	return nil
+}
+
+// RemoveIPQuarantineRequest removes the quarantine of an address or a CIDR
+// block. Exactly one of address and cidr must be set.
+type RemoveIPQuarantineRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The network of the address or CIDR block. Defaults to the default pod network.
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// The quarantined IP address.
+	Address string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	// The quarantined CIDR block.
+	Cidr          string `protobuf:"bytes,3,opt,name=cidr,proto3" json:"cidr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveIPQuarantineRequest) Reset() {
+	*x = RemoveIPQuarantineRequest{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveIPQuarantineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveIPQuarantineRequest) ProtoMessage() {}
+
+func (x *RemoveIPQuarantineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveIPQuarantineRequest.ProtoReflect.Descriptor instead.
+func (*RemoveIPQuarantineRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RemoveIPQuarantineRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *RemoveIPQuarantineRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *RemoveIPQuarantineRequest) GetCidr() string {
+	if x != nil {
+		return x.Cidr
+	}
+	return ""
+}
+
+// RemoveIPQuarantineResponse is returned once the quarantine is removed.
+type RemoveIPQuarantineResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveIPQuarantineResponse) Reset() {
+	*x = RemoveIPQuarantineResponse{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveIPQuarantineResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveIPQuarantineResponse) ProtoMessage() {}
+
+func (x *RemoveIPQuarantineResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveIPQuarantineResponse.ProtoReflect.Descriptor instead.
+func (*RemoveIPQuarantineResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{29}
+}
+
+// ListIPQuarantinesRequest requests the quarantined addresses and CIDR blocks.
+type ListIPQuarantinesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only list the quarantines of this network. All networks if empty.
+	Network       string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIPQuarantinesRequest) Reset() {
+	*x = ListIPQuarantinesRequest{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIPQuarantinesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIPQuarantinesRequest) ProtoMessage() {}
+
+func (x *ListIPQuarantinesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIPQuarantinesRequest.ProtoReflect.Descriptor instead.
+func (*ListIPQuarantinesRequest) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ListIPQuarantinesRequest) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+// ListIPQuarantinesResponse lists the quarantines in the order they were added.
+type ListIPQuarantinesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quarantines   []*IPQuarantine        `protobuf:"bytes,1,rep,name=quarantines,proto3" json:"quarantines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIPQuarantinesResponse) Reset() {
+	*x = ListIPQuarantinesResponse{}
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIPQuarantinesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIPQuarantinesResponse) ProtoMessage() {}
+
+func (x *ListIPQuarantinesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_metis_api_admin_v1_admin_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIPQuarantinesResponse.ProtoReflect.Descriptor instead.
+func (*ListIPQuarantinesResponse) Descriptor() ([]byte, []int) {
+	return file_metis_api_admin_v1_admin_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ListIPQuarantinesResponse) GetQuarantines() []*IPQuarantine {
+	if x != nil {
+		return x.Quarantines
+	}
+	return nil
+}
+
+var File_metis_api_admin_v1_admin_proto protoreflect.FileDescriptor
+
+const file_metis_api_admin_v1_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x1emetis/api/admin/v1/admin.proto\x12\badmin.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xae\x01\n" +
+	"\x15ListCIDRBlocksRequest\x12\x18\n" +
+	"\anetwork\x18\x02 \x01(\tR\anetwork\x12\x1b\n" +
+	"\tip_family\x18\x03 \x01(\tR\bipFamily\x12\x14\n" +
+	"\x05state\x18\x04 \x01(\tR\x05state\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x06 \x01(\tR\tpageTokenJ\x04\b\x01\x10\x02R\x06filter\"v\n" +
+	"\x16ListCIDRBlocksResponse\x124\n" +
+	"\vcidr_blocks\x18\x01 \x03(\v2\x13.admin.v1.CIDRBlockR\n" +
+	"cidrBlocks\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xb4\x02\n" +
+	"\tCIDRBlock\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04cidr\x18\x02 \x01(\tR\x04cidr\x12\x18\n" +
+	"\anetwork\x18\x03 \x01(\tR\anetwork\x12\x1b\n" +
+	"\tip_family\x18\x04 \x01(\tR\bipFamily\x12\x1b\n" +
+	"\ttotal_ips\x18\x05 \x01(\x03R\btotalIps\x12#\n" +
+	"\rallocated_ips\x18\x06 \x01(\x03R\fallocatedIps\x12\x14\n" +
+	"\x05state\x18\a \x01(\tR\x05state\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xb9\x02\n" +
+	"\x16ListIPAddressesRequest\x12\x18\n" +
+	"\anetwork\x18\x02 \x01(\tR\anetwork\x12\x1b\n" +
+	"\tip_family\x18\x03 \x01(\tR\bipFamily\x12\x14\n" +
+	"\x05state\x18\x04 \x01(\tR\x05state\x12!\n" +
+	"\fcontainer_id\x18\x05 \x01(\tR\vcontainerId\x12#\n" +
+	"\rpod_namespace\x18\x06 \x01(\tR\fpodNamespace\x12\x19\n" +
+	"\bpod_name\x18\a \x01(\tR\apodName\x12%\n" +
+	"\x0eallocated_only\x18\b \x01(\bR\rallocatedOnly\x12\x1b\n" +
+	"\tpage_size\x18\t \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\n" +
+	" \x01(\tR\tpageTokenJ\x04\b\x01\x10\x02R\x06filter\"y\n" +
+	"\x17ListIPAddressesResponse\x126\n" +
+	"\fip_addresses\x18\x01 \x03(\v2\x13.admin.v1.IPAddressR\vipAddresses\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xf2\x03\n" +
+	"\tIPAddress\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\"\n" +
+	"\rcidr_block_id\x18\x03 \x01(\x03R\vcidrBlockId\x12\x18\n" +
+	"\anetwork\x18\x04 \x01(\tR\anetwork\x12\x1b\n" +
+	"\tip_family\x18\x05 \x01(\tR\bipFamily\x12!\n" +
+	"\fcontainer_id\x18\x06 \x01(\tR\vcontainerId\x12\x19\n" +
+	"\bpod_name\x18\a \x01(\tR\apodName\x12#\n" +
+	"\rpod_namespace\x18\b \x01(\tR\fpodNamespace\x12%\n" +
+	"\x0einterface_name\x18\t \x01(\tR\rinterfaceName\x12!\n" +
+	"\fis_allocated\x18\n" +
+	" \x01(\bR\visAllocated\x129\n" +
+	"\n" +
+	"release_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\treleaseAt\x12=\n" +
+	"\fallocated_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\vallocatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"0\n" +
+	"\x15GarbageCollectRequest\x12\x17\n" +
+	"\adry_run\x18\x01 \x01(\bR\x06dryRun\"l\n" +
+	"\x16GarbageCollectResponse\x129\n" +
+	"\fstale_owners\x18\x01 \x03(\v2\x16.admin.v1.StaleIPOwnerR\vstaleOwners\x12\x17\n" +
+	"\adry_run\x18\x02 \x01(\bR\x06dryRun\"\xbd\x02\n" +
+	"\fStaleIPOwner\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12!\n" +
+	"\fcontainer_id\x18\x02 \x01(\tR\vcontainerId\x12%\n" +
+	"\x0einterface_name\x18\x03 \x01(\tR\rinterfaceName\x12#\n" +
+	"\rpod_namespace\x18\x04 \x01(\tR\fpodNamespace\x12\x19\n" +
+	"\bpod_name\x18\x05 \x01(\tR\apodName\x12\x14\n" +
+	"\x05netns\x18\x06 \x01(\tR\x05netns\x12\x1c\n" +
+	"\taddresses\x18\a \x03(\tR\taddresses\x12\x16\n" +
+	"\x06reason\x18\b \x01(\tR\x06reason\x12=\n" +
+	"\fallocated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\vallocatedAt\"\xdb\x01\n" +
+	"\rIPReservation\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x1b\n" +
+	"\tip_family\x18\x03 \x01(\tR\bipFamily\x12#\n" +
+	"\rpod_namespace\x18\x04 \x01(\tR\fpodNamespace\x12\x19\n" +
+	"\bpod_name\x18\x05 \x01(\tR\apodName\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x8d\x01\n" +
+	"\x17AddIPReservationRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12#\n" +
+	"\rpod_namespace\x18\x03 \x01(\tR\fpodNamespace\x12\x19\n" +
+	"\bpod_name\x18\x04 \x01(\tR\apodName\"U\n" +
+	"\x18AddIPReservationResponse\x129\n" +
+	"\vreservation\x18\x01 \x01(\v2\x17.admin.v1.IPReservationR\vreservation\"P\n" +
+	"\x1aRemoveIPReservationRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\"\x1d\n" +
+	"\x1bRemoveIPReservationResponse\"5\n" +
+	"\x19ListIPReservationsRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\"Y\n" +
+	"\x1aListIPReservationsResponse\x12;\n" +
+	"\freservations\x18\x01 \x03(\v2\x17.admin.v1.IPReservationR\freservations\"\xa3\x02\n" +
+	"\x1cListAllocationHistoryRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12!\n" +
+	"\fcontainer_id\x18\x03 \x01(\tR\vcontainerId\x12#\n" +
+	"\rpod_namespace\x18\x04 \x01(\tR\fpodNamespace\x12\x19\n" +
+	"\bpod_name\x18\x05 \x01(\tR\apodName\x120\n" +
+	"\x05since\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\x1b\n" +
+	"\tpage_size\x18\a \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\b \x01(\tR\tpageToken\"z\n" +
+	"\x1dListAllocationHistoryResponse\x121\n" +
+	"\arecords\x18\x01 \x03(\v2\x17.admin.v1.HistoryRecordR\arecords\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x9c\x03\n" +
+	"\rHistoryRecord\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05event\x18\x02 \x01(\tR\x05event\x12\x18\n" +
+	"\anetwork\x18\x03 \x01(\tR\anetwork\x12\x12\n" +
+	"\x04cidr\x18\x04 \x01(\tR\x04cidr\x12\x1b\n" +
+	"\tip_family\x18\x05 \x01(\tR\bipFamily\x12\x18\n" +
+	"\aaddress\x18\x06 \x01(\tR\aaddress\x12!\n" +
+	"\fcontainer_id\x18\a \x01(\tR\vcontainerId\x12%\n" +
+	"\x0einterface_name\x18\b \x01(\tR\rinterfaceName\x12#\n" +
+	"\rpod_namespace\x18\t \x01(\tR\fpodNamespace\x12\x19\n" +
+	"\bpod_name\x18\n" +
+	" \x01(\tR\apodName\x129\n" +
+	"\n" +
+	"release_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\treleaseAt\x12;\n" +
+	"\voccurred_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\"_\n" +
+	"\x12WatchEventsRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12/\n" +
+	"\x05types\x18\x02 \x03(\x0e2\x19.admin.v1.WatchEvent.TypeR\x05types\"\x9e\x04\n" +
+	"\n" +
+	"WatchEvent\x12-\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x19.admin.v1.WatchEvent.TypeR\x04type\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x18\n" +
+	"\anetwork\x18\x03 \x01(\tR\anetwork\x12\x1b\n" +
+	"\tip_family\x18\x04 \x01(\tR\bipFamily\x12\x12\n" +
+	"\x04cidr\x18\x05 \x01(\tR\x04cidr\x12\x18\n" +
+	"\aaddress\x18\x06 \x01(\tR\aaddress\x12!\n" +
+	"\fcontainer_id\x18\a \x01(\tR\vcontainerId\x12%\n" +
+	"\x0einterface_name\x18\b \x01(\tR\rinterfaceName\x12#\n" +
+	"\rpod_namespace\x18\t \x01(\tR\fpodNamespace\x12\x19\n" +
+	"\bpod_name\x18\n" +
+	" \x01(\tR\apodName\x12\x1f\n" +
+	"\vtarget_pods\x18\v \x01(\x05R\n" +
+	"targetPods\"\xa0\x01\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fIP_ALLOCATED\x10\x01\x12\x0f\n" +
+	"\vIP_RELEASED\x10\x02\x12\x0f\n" +
+	"\vBLOCK_ADDED\x10\x03\x12\x12\n" +
+	"\x0eBLOCK_DRAINING\x10\x04\x12\x11\n" +
+	"\rBLOCK_DELETED\x10\x05\x12\x16\n" +
+	"\x12SCALE_UP_REQUESTED\x10\x06\x12\x0f\n" +
+	"\vNNC_PATCHED\x10\a\"+\n" +
+	"\x15CreateSnapshotRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"K\n" +
+	"\x16CreateSnapshotResponse\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x02 \x01(\x03R\tsizeBytes\",\n" +
+	"\x16RestoreSnapshotRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"\x19\n" +
+	"\x17RestoreSnapshotResponse\"\xc6\x01\n" +
+	"\fIPQuarantine\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x12\n" +
+	"\x04cidr\x18\x03 \x01(\tR\x04cidr\x12\x1b\n" +
+	"\tip_family\x18\x04 \x01(\tR\bipFamily\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"x\n" +
+	"\x16AddIPQuarantineRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x12\n" +
+	"\x04cidr\x18\x03 \x01(\tR\x04cidr\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"Q\n" +
+	"\x17AddIPQuarantineResponse\x126\n" +
 	"\n" +
-	"\x1emetis/api/admin/v1/admin.proto\x12\badmin.v1\"/\n" +
-	"\x15ListCIDRBlocksRequest\x12\x16\n" +
-	"\x06filter\x18\x01 \x01(\tR\x06filter\"0\n" +
-	"\x16ListIPAddressesRequest\x12\x16\n" +
-	"\x06filter\x18\x01 \x01(\tR\x06filter\"U\n" +
-	"\x16AdminTableDumpResponse\x12\x18\n" +
-	"\aheaders\x18\x01 \x03(\tR\aheaders\x12!\n" +
-	"\x04rows\x18\x02 \x03(\v2\r.admin.v1.RowR\x04rows\"\x1d\n" +
-	"\x03Row\x12\x16\n" +
-	"\x06values\x18\x01 \x03(\tR\x06values2\xb3\x01\n" +
+	"quarantine\x18\x01 \x01(\v2\x16.admin.v1.IPQuarantineR\n" +
+	"quarantine\"c\n" +
+	"\x19RemoveIPQuarantineRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x12\n" +
+	"\x04cidr\x18\x03 \x01(\tR\x04cidr\"\x1c\n" +
+	"\x1aRemoveIPQuarantineResponse\"4\n" +
+	"\x18ListIPQuarantinesRequest\x12\x18\n" +
+	"\anetwork\x18\x01 \x01(\tR\anetwork\"U\n" +
+	"\x19ListIPQuarantinesResponse\x128\n" +
+	"\vquarantines\x18\x01 \x03(\v2\x16.admin.v1.IPQuarantineR\vquarantines2\x9c\t\n" +
 	"\x05Admin\x12S\n" +
-	"\x0eListCIDRBlocks\x12\x1f.admin.v1.ListCIDRBlocksRequest\x1a .admin.v1.AdminTableDumpResponse\x12U\n" +
-	"\x0fListIPAddresses\x12 .admin.v1.ListIPAddressesRequest\x1a .admin.v1.AdminTableDumpResponseB#Z!k8s.io/metis/api/admin/v1;adminv1b\x06proto3"
+	"\x0eListCIDRBlocks\x12\x1f.admin.v1.ListCIDRBlocksRequest\x1a .admin.v1.ListCIDRBlocksResponse\x12V\n" +
+	"\x0fListIPAddresses\x12 .admin.v1.ListIPAddressesRequest\x1a!.admin.v1.ListIPAddressesResponse\x12S\n" +
+	"\x0eGarbageCollect\x12\x1f.admin.v1.GarbageCollectRequest\x1a .admin.v1.GarbageCollectResponse\x12Y\n" +
+	"\x10AddIPReservation\x12!.admin.v1.AddIPReservationRequest\x1a\".admin.v1.AddIPReservationResponse\x12b\n" +
+	"\x13RemoveIPReservation\x12$.admin.v1.RemoveIPReservationRequest\x1a%.admin.v1.RemoveIPReservationResponse\x12_\n" +
+	"\x12ListIPReservations\x12#.admin.v1.ListIPReservationsRequest\x1a$.admin.v1.ListIPReservationsResponse\x12h\n" +
+	"\x15ListAllocationHistory\x12&.admin.v1.ListAllocationHistoryRequest\x1a'.admin.v1.ListAllocationHistoryResponse\x12C\n" +
+	"\vWatchEvents\x12\x1c.admin.v1.WatchEventsRequest\x1a\x14.admin.v1.WatchEvent0\x01\x12S\n" +
+	"\x0eCreateSnapshot\x12\x1f.admin.v1.CreateSnapshotRequest\x1a .admin.v1.CreateSnapshotResponse\x12V\n" +
+	"\x0fRestoreSnapshot\x12 .admin.v1.RestoreSnapshotRequest\x1a!.admin.v1.RestoreSnapshotResponse\x12V\n" +
+	"\x0fAddIPQuarantine\x12 .admin.v1.AddIPQuarantineRequest\x1a!.admin.v1.AddIPQuarantineResponse\x12_\n" +
+	"\x12RemoveIPQuarantine\x12#.admin.v1.RemoveIPQuarantineRequest\x1a$.admin.v1.RemoveIPQuarantineResponse\x12\\\n" +
+	"\x11ListIPQuarantines\x12\".admin.v1.ListIPQuarantinesRequest\x1a#.admin.v1.ListIPQuarantinesResponseB#Z!k8s.io/metis/api/admin/v1;adminv1b\x06proto3"
 
 var (
 	file_metis_api_admin_v1_admin_proto_rawDescOnce sync.Once
@@ -244,24 +2606,98 @@ func file_metis_api_admin_v1_admin_proto_rawDescGZIP() []byte {
 	return file_metis_api_admin_v1_admin_proto_rawDescData
 }
 
-var file_metis_api_admin_v1_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_metis_api_admin_v1_admin_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_metis_api_admin_v1_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
 var file_metis_api_admin_v1_admin_proto_goTypes = []any{
-	(*ListCIDRBlocksRequest)(nil),  // 0: admin.v1.ListCIDRBlocksRequest
-	(*ListIPAddressesRequest)(nil), // 1: admin.v1.ListIPAddressesRequest
-	(*AdminTableDumpResponse)(nil), // 2: admin.v1.AdminTableDumpResponse
-	(*Row)(nil),                    // 3: admin.v1.Row
+	(WatchEvent_Type)(0),                  // 0: admin.v1.WatchEvent.Type
+	(*ListCIDRBlocksRequest)(nil),         // 1: admin.v1.ListCIDRBlocksRequest
+	(*ListCIDRBlocksResponse)(nil),        // 2: admin.v1.ListCIDRBlocksResponse
+	(*CIDRBlock)(nil),                     // 3: admin.v1.CIDRBlock
+	(*ListIPAddressesRequest)(nil),        // 4: admin.v1.ListIPAddressesRequest
+	(*ListIPAddressesResponse)(nil),       // 5: admin.v1.ListIPAddressesResponse
+	(*IPAddress)(nil),                     // 6: admin.v1.IPAddress
+	(*GarbageCollectRequest)(nil),         // 7: admin.v1.GarbageCollectRequest
+	(*GarbageCollectResponse)(nil),        // 8: admin.v1.GarbageCollectResponse
+	(*StaleIPOwner)(nil),                  // 9: admin.v1.StaleIPOwner
+	(*IPReservation)(nil),                 // 10: admin.v1.IPReservation
+	(*AddIPReservationRequest)(nil),       // 11: admin.v1.AddIPReservationRequest
+	(*AddIPReservationResponse)(nil),      // 12: admin.v1.AddIPReservationResponse
+	(*RemoveIPReservationRequest)(nil),    // 13: admin.v1.RemoveIPReservationRequest
+	(*RemoveIPReservationResponse)(nil),   // 14: admin.v1.RemoveIPReservationResponse
+	(*ListIPReservationsRequest)(nil),     // 15: admin.v1.ListIPReservationsRequest
+	(*ListIPReservationsResponse)(nil),    // 16: admin.v1.ListIPReservationsResponse
+	(*ListAllocationHistoryRequest)(nil),  // 17: admin.v1.ListAllocationHistoryRequest
+	(*ListAllocationHistoryResponse)(nil), // 18: admin.v1.ListAllocationHistoryResponse
+	(*HistoryRecord)(nil),                 // 19: admin.v1.HistoryRecord
+	(*WatchEventsRequest)(nil),            // 20: admin.v1.WatchEventsRequest
+	(*WatchEvent)(nil),                    // 21: admin.v1.WatchEvent
+	(*CreateSnapshotRequest)(nil),         // 22: admin.v1.CreateSnapshotRequest
+	(*CreateSnapshotResponse)(nil),        // 23: admin.v1.CreateSnapshotResponse
+	(*RestoreSnapshotRequest)(nil),        // 24: admin.v1.RestoreSnapshotRequest
+	(*RestoreSnapshotResponse)(nil),       // 25: admin.v1.RestoreSnapshotResponse
+	(*IPQuarantine)(nil),                  // 26: admin.v1.IPQuarantine
+	(*AddIPQuarantineRequest)(nil),        // 27: admin.v1.AddIPQuarantineRequest
+	(*AddIPQuarantineResponse)(nil),       // 28: admin.v1.AddIPQuarantineResponse
+	(*RemoveIPQuarantineRequest)(nil),     // 29: admin.v1.RemoveIPQuarantineRequest
+	(*RemoveIPQuarantineResponse)(nil),    // 30: admin.v1.RemoveIPQuarantineResponse
+	(*ListIPQuarantinesRequest)(nil),      // 31: admin.v1.ListIPQuarantinesRequest
+	(*ListIPQuarantinesResponse)(nil),     // 32: admin.v1.ListIPQuarantinesResponse
+	(*timestamppb.Timestamp)(nil),         // 33: google.protobuf.Timestamp
 }
 var file_metis_api_admin_v1_admin_proto_depIdxs = []int32{
-	3, // 0: admin.v1.AdminTableDumpResponse.rows:type_name -> admin.v1.Row
-	0, // 1: admin.v1.Admin.ListCIDRBlocks:input_type -> admin.v1.ListCIDRBlocksRequest
-	1, // 2: admin.v1.Admin.ListIPAddresses:input_type -> admin.v1.ListIPAddressesRequest
-	2, // 3: admin.v1.Admin.ListCIDRBlocks:output_type -> admin.v1.AdminTableDumpResponse
-	2, // 4: admin.v1.Admin.ListIPAddresses:output_type -> admin.v1.AdminTableDumpResponse
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	3,  // 0: admin.v1.ListCIDRBlocksResponse.cidr_blocks:type_name -> admin.v1.CIDRBlock
+	33, // 1: admin.v1.CIDRBlock.created_at:type_name -> google.protobuf.Timestamp
+	33, // 2: admin.v1.CIDRBlock.updated_at:type_name -> google.protobuf.Timestamp
+	6,  // 3: admin.v1.ListIPAddressesResponse.ip_addresses:type_name -> admin.v1.IPAddress
+	33, // 4: admin.v1.IPAddress.release_at:type_name -> google.protobuf.Timestamp
+	33, // 5: admin.v1.IPAddress.allocated_at:type_name -> google.protobuf.Timestamp
+	33, // 6: admin.v1.IPAddress.updated_at:type_name -> google.protobuf.Timestamp
+	9,  // 7: admin.v1.GarbageCollectResponse.stale_owners:type_name -> admin.v1.StaleIPOwner
+	33, // 8: admin.v1.StaleIPOwner.allocated_at:type_name -> google.protobuf.Timestamp
+	33, // 9: admin.v1.IPReservation.created_at:type_name -> google.protobuf.Timestamp
+	10, // 10: admin.v1.AddIPReservationResponse.reservation:type_name -> admin.v1.IPReservation
+	10, // 11: admin.v1.ListIPReservationsResponse.reservations:type_name -> admin.v1.IPReservation
+	33, // 12: admin.v1.ListAllocationHistoryRequest.since:type_name -> google.protobuf.Timestamp
+	19, // 13: admin.v1.ListAllocationHistoryResponse.records:type_name -> admin.v1.HistoryRecord
+	33, // 14: admin.v1.HistoryRecord.release_at:type_name -> google.protobuf.Timestamp
+	33, // 15: admin.v1.HistoryRecord.occurred_at:type_name -> google.protobuf.Timestamp
+	0,  // 16: admin.v1.WatchEventsRequest.types:type_name -> admin.v1.WatchEvent.Type
+	0,  // 17: admin.v1.WatchEvent.type:type_name -> admin.v1.WatchEvent.Type
+	33, // 18: admin.v1.WatchEvent.time:type_name -> google.protobuf.Timestamp
+	33, // 19: admin.v1.IPQuarantine.created_at:type_name -> google.protobuf.Timestamp
+	26, // 20: admin.v1.AddIPQuarantineResponse.quarantine:type_name -> admin.v1.IPQuarantine
+	26, // 21: admin.v1.ListIPQuarantinesResponse.quarantines:type_name -> admin.v1.IPQuarantine
+	1,  // 22: admin.v1.Admin.ListCIDRBlocks:input_type -> admin.v1.ListCIDRBlocksRequest
+	4,  // 23: admin.v1.Admin.ListIPAddresses:input_type -> admin.v1.ListIPAddressesRequest
+	7,  // 24: admin.v1.Admin.GarbageCollect:input_type -> admin.v1.GarbageCollectRequest
+	11, // 25: admin.v1.Admin.AddIPReservation:input_type -> admin.v1.AddIPReservationRequest
+	13, // 26: admin.v1.Admin.RemoveIPReservation:input_type -> admin.v1.RemoveIPReservationRequest
+	15, // 27: admin.v1.Admin.ListIPReservations:input_type -> admin.v1.ListIPReservationsRequest
+	17, // 28: admin.v1.Admin.ListAllocationHistory:input_type -> admin.v1.ListAllocationHistoryRequest
+	20, // 29: admin.v1.Admin.WatchEvents:input_type -> admin.v1.WatchEventsRequest
+	22, // 30: admin.v1.Admin.CreateSnapshot:input_type -> admin.v1.CreateSnapshotRequest
+	24, // 31: admin.v1.Admin.RestoreSnapshot:input_type -> admin.v1.RestoreSnapshotRequest
+	27, // 32: admin.v1.Admin.AddIPQuarantine:input_type -> admin.v1.AddIPQuarantineRequest
+	29, // 33: admin.v1.Admin.RemoveIPQuarantine:input_type -> admin.v1.RemoveIPQuarantineRequest
+	31, // 34: admin.v1.Admin.ListIPQuarantines:input_type -> admin.v1.ListIPQuarantinesRequest
+	2,  // 35: admin.v1.Admin.ListCIDRBlocks:output_type -> admin.v1.ListCIDRBlocksResponse
+	5,  // 36: admin.v1.Admin.ListIPAddresses:output_type -> admin.v1.ListIPAddressesResponse
+	8,  // 37: admin.v1.Admin.GarbageCollect:output_type -> admin.v1.GarbageCollectResponse
+	12, // 38: admin.v1.Admin.AddIPReservation:output_type -> admin.v1.AddIPReservationResponse
+	14, // 39: admin.v1.Admin.RemoveIPReservation:output_type -> admin.v1.RemoveIPReservationResponse
+	16, // 40: admin.v1.Admin.ListIPReservations:output_type -> admin.v1.ListIPReservationsResponse
+	18, // 41: admin.v1.Admin.ListAllocationHistory:output_type -> admin.v1.ListAllocationHistoryResponse
+	21, // 42: admin.v1.Admin.WatchEvents:output_type -> admin.v1.WatchEvent
+	23, // 43: admin.v1.Admin.CreateSnapshot:output_type -> admin.v1.CreateSnapshotResponse
+	25, // 44: admin.v1.Admin.RestoreSnapshot:output_type -> admin.v1.RestoreSnapshotResponse
+	28, // 45: admin.v1.Admin.AddIPQuarantine:output_type -> admin.v1.AddIPQuarantineResponse
+	30, // 46: admin.v1.Admin.RemoveIPQuarantine:output_type -> admin.v1.RemoveIPQuarantineResponse
+	32, // 47: admin.v1.Admin.ListIPQuarantines:output_type -> admin.v1.ListIPQuarantinesResponse
+	35, // [35:48] is the sub-list for method output_type
+	22, // [22:35] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
 }
 
 func init() { file_metis_api_admin_v1_admin_proto_init() }
@@ -274,13 +2710,14 @@ func file_metis_api_admin_v1_admin_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_metis_api_admin_v1_admin_proto_rawDesc), len(file_metis_api_admin_v1_admin_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      1,
+			NumMessages:   32,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_metis_api_admin_v1_admin_proto_goTypes,
 		DependencyIndexes: file_metis_api_admin_v1_admin_proto_depIdxs,
+		EnumInfos:         file_metis_api_admin_v1_admin_proto_enumTypes,
 		MessageInfos:      file_metis_api_admin_v1_admin_proto_msgTypes,
 	}.Build()
 	File_metis_api_admin_v1_admin_proto = out.File
@@ -19,8 +19,19 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Admin_ListCIDRBlocks_FullMethodName  = "/admin.v1.Admin/ListCIDRBlocks"
-	Admin_ListIPAddresses_FullMethodName = "/admin.v1.Admin/ListIPAddresses"
+	Admin_ListCIDRBlocks_FullMethodName        = "/admin.v1.Admin/ListCIDRBlocks"
+	Admin_ListIPAddresses_FullMethodName       = "/admin.v1.Admin/ListIPAddresses"
+	Admin_GarbageCollect_FullMethodName        = "/admin.v1.Admin/GarbageCollect"
+	Admin_AddIPReservation_FullMethodName      = "/admin.v1.Admin/AddIPReservation"
+	Admin_RemoveIPReservation_FullMethodName   = "/admin.v1.Admin/RemoveIPReservation"
+	Admin_ListIPReservations_FullMethodName    = "/admin.v1.Admin/ListIPReservations"
+	Admin_ListAllocationHistory_FullMethodName = "/admin.v1.Admin/ListAllocationHistory"
+	Admin_WatchEvents_FullMethodName           = "/admin.v1.Admin/WatchEvents"
+	Admin_CreateSnapshot_FullMethodName        = "/admin.v1.Admin/CreateSnapshot"
+	Admin_RestoreSnapshot_FullMethodName       = "/admin.v1.Admin/RestoreSnapshot"
+	Admin_AddIPQuarantine_FullMethodName       = "/admin.v1.Admin/AddIPQuarantine"
+	Admin_RemoveIPQuarantine_FullMethodName    = "/admin.v1.Admin/RemoveIPQuarantine"
+	Admin_ListIPQuarantines_FullMethodName     = "/admin.v1.Admin/ListIPQuarantines"
 )
 
 // AdminClient is the client API for Admin service.
@@ -29,10 +40,38 @@ const (
 //
 // Admin service exposes internal Metis daemon state for structural debugging over UDS.
 type AdminClient interface {
-	// ListCIDRBlocks returns a formatted table of all CIDR blocks.
-	ListCIDRBlocks(ctx context.Context, in *ListCIDRBlocksRequest, opts ...grpc.CallOption) (*AdminTableDumpResponse, error)
-	// ListIPAddresses returns a formatted table of all IP addresses.
-	ListIPAddresses(ctx context.Context, in *ListIPAddressesRequest, opts ...grpc.CallOption) (*AdminTableDumpResponse, error)
+	// ListCIDRBlocks returns a page of CIDR blocks matching the request filters.
+	ListCIDRBlocks(ctx context.Context, in *ListCIDRBlocksRequest, opts ...grpc.CallOption) (*ListCIDRBlocksResponse, error)
+	// ListIPAddresses returns a page of IP addresses matching the request filters.
+	ListIPAddresses(ctx context.Context, in *ListIPAddressesRequest, opts ...grpc.CallOption) (*ListIPAddressesResponse, error)
+	// GarbageCollect releases the IPs of pods that no longer exist on the node.
+	GarbageCollect(ctx context.Context, in *GarbageCollectRequest, opts ...grpc.CallOption) (*GarbageCollectResponse, error)
+	// AddIPReservation reserves an address, optionally for a specific pod.
+	AddIPReservation(ctx context.Context, in *AddIPReservationRequest, opts ...grpc.CallOption) (*AddIPReservationResponse, error)
+	// RemoveIPReservation removes the reservation of an address.
+	RemoveIPReservation(ctx context.Context, in *RemoveIPReservationRequest, opts ...grpc.CallOption) (*RemoveIPReservationResponse, error)
+	// ListIPReservations returns the reserved addresses.
+	ListIPReservations(ctx context.Context, in *ListIPReservationsRequest, opts ...grpc.CallOption) (*ListIPReservationsResponse, error)
+	// ListAllocationHistory returns a page of the allocation history matching the request filters.
+	ListAllocationHistory(ctx context.Context, in *ListAllocationHistoryRequest, opts ...grpc.CallOption) (*ListAllocationHistoryResponse, error)
+	// WatchEvents streams the changes of the IPAM state of the node as they
+	// happen. Past events are not replayed.
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error)
+	// CreateSnapshot writes a consistent snapshot of the store to a file on the
+	// node while the daemon keeps serving requests.
+	CreateSnapshot(ctx context.Context, in *CreateSnapshotRequest, opts ...grpc.CallOption) (*CreateSnapshotResponse, error)
+	// RestoreSnapshot replaces the content of the store with a snapshot file on
+	// the node, deletes the restored CIDR blocks no longer assigned to the node,
+	// and claims the IPs of the running pods of the node again. Allocations are
+	// held off until the restore completes.
+	RestoreSnapshot(ctx context.Context, in *RestoreSnapshotRequest, opts ...grpc.CallOption) (*RestoreSnapshotResponse, error)
+	// AddIPQuarantine quarantines an address or a CIDR block, so that it is not
+	// allocated until the quarantine is removed.
+	AddIPQuarantine(ctx context.Context, in *AddIPQuarantineRequest, opts ...grpc.CallOption) (*AddIPQuarantineResponse, error)
+	// RemoveIPQuarantine removes the quarantine of an address or a CIDR block.
+	RemoveIPQuarantine(ctx context.Context, in *RemoveIPQuarantineRequest, opts ...grpc.CallOption) (*RemoveIPQuarantineResponse, error)
+	// ListIPQuarantines returns the quarantined addresses and CIDR blocks.
+	ListIPQuarantines(ctx context.Context, in *ListIPQuarantinesRequest, opts ...grpc.CallOption) (*ListIPQuarantinesResponse, error)
 }
 
 type adminClient struct {
@@ -43,9 +82,9 @@ func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
 	return &adminClient{cc}
 }
 
-func (c *adminClient) ListCIDRBlocks(ctx context.Context, in *ListCIDRBlocksRequest, opts ...grpc.CallOption) (*AdminTableDumpResponse, error) {
+func (c *adminClient) ListCIDRBlocks(ctx context.Context, in *ListCIDRBlocksRequest, opts ...grpc.CallOption) (*ListCIDRBlocksResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(AdminTableDumpResponse)
+	out := new(ListCIDRBlocksResponse)
 	err := c.cc.Invoke(ctx, Admin_ListCIDRBlocks_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
@@ -53,9 +92,9 @@ func (c *adminClient) ListCIDRBlocks(ctx context.Context, in *ListCIDRBlocksRequ
 	return out, nil
 }
 
-func (c *adminClient) ListIPAddresses(ctx context.Context, in *ListIPAddressesRequest, opts ...grpc.CallOption) (*AdminTableDumpResponse, error) {
+func (c *adminClient) ListIPAddresses(ctx context.Context, in *ListIPAddressesRequest, opts ...grpc.CallOption) (*ListIPAddressesResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(AdminTableDumpResponse)
+	out := new(ListIPAddressesResponse)
 	err := c.cc.Invoke(ctx, Admin_ListIPAddresses_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
@@ -63,16 +102,163 @@ func (c *adminClient) ListIPAddresses(ctx context.Context, in *ListIPAddressesRe
 	return out, nil
 }
 
+func (c *adminClient) GarbageCollect(ctx context.Context, in *GarbageCollectRequest, opts ...grpc.CallOption) (*GarbageCollectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GarbageCollectResponse)
+	err := c.cc.Invoke(ctx, Admin_GarbageCollect_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) AddIPReservation(ctx context.Context, in *AddIPReservationRequest, opts ...grpc.CallOption) (*AddIPReservationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddIPReservationResponse)
+	err := c.cc.Invoke(ctx, Admin_AddIPReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) RemoveIPReservation(ctx context.Context, in *RemoveIPReservationRequest, opts ...grpc.CallOption) (*RemoveIPReservationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveIPReservationResponse)
+	err := c.cc.Invoke(ctx, Admin_RemoveIPReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListIPReservations(ctx context.Context, in *ListIPReservationsRequest, opts ...grpc.CallOption) (*ListIPReservationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListIPReservationsResponse)
+	err := c.cc.Invoke(ctx, Admin_ListIPReservations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListAllocationHistory(ctx context.Context, in *ListAllocationHistoryRequest, opts ...grpc.CallOption) (*ListAllocationHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAllocationHistoryResponse)
+	err := c.cc.Invoke(ctx, Admin_ListAllocationHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[0], Admin_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEventsRequest, WatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_WatchEventsClient = grpc.ServerStreamingClient[WatchEvent]
+
+func (c *adminClient) CreateSnapshot(ctx context.Context, in *CreateSnapshotRequest, opts ...grpc.CallOption) (*CreateSnapshotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateSnapshotResponse)
+	err := c.cc.Invoke(ctx, Admin_CreateSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) RestoreSnapshot(ctx context.Context, in *RestoreSnapshotRequest, opts ...grpc.CallOption) (*RestoreSnapshotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreSnapshotResponse)
+	err := c.cc.Invoke(ctx, Admin_RestoreSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) AddIPQuarantine(ctx context.Context, in *AddIPQuarantineRequest, opts ...grpc.CallOption) (*AddIPQuarantineResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddIPQuarantineResponse)
+	err := c.cc.Invoke(ctx, Admin_AddIPQuarantine_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) RemoveIPQuarantine(ctx context.Context, in *RemoveIPQuarantineRequest, opts ...grpc.CallOption) (*RemoveIPQuarantineResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveIPQuarantineResponse)
+	err := c.cc.Invoke(ctx, Admin_RemoveIPQuarantine_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListIPQuarantines(ctx context.Context, in *ListIPQuarantinesRequest, opts ...grpc.CallOption) (*ListIPQuarantinesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListIPQuarantinesResponse)
+	err := c.cc.Invoke(ctx, Admin_ListIPQuarantines_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AdminServer is the server API for Admin service.
 // All implementations must embed UnimplementedAdminServer
 // for forward compatibility.
 //
 // Admin service exposes internal Metis daemon state for structural debugging over UDS.
 type AdminServer interface {
-	// ListCIDRBlocks returns a formatted table of all CIDR blocks.
-	ListCIDRBlocks(context.Context, *ListCIDRBlocksRequest) (*AdminTableDumpResponse, error)
-	// ListIPAddresses returns a formatted table of all IP addresses.
-	ListIPAddresses(context.Context, *ListIPAddressesRequest) (*AdminTableDumpResponse, error)
+	// ListCIDRBlocks returns a page of CIDR blocks matching the request filters.
+	ListCIDRBlocks(context.Context, *ListCIDRBlocksRequest) (*ListCIDRBlocksResponse, error)
+	// ListIPAddresses returns a page of IP addresses matching the request filters.
+	ListIPAddresses(context.Context, *ListIPAddressesRequest) (*ListIPAddressesResponse, error)
+	// GarbageCollect releases the IPs of pods that no longer exist on the node.
+	GarbageCollect(context.Context, *GarbageCollectRequest) (*GarbageCollectResponse, error)
+	// AddIPReservation reserves an address, optionally for a specific pod.
+	AddIPReservation(context.Context, *AddIPReservationRequest) (*AddIPReservationResponse, error)
+	// RemoveIPReservation removes the reservation of an address.
+	RemoveIPReservation(context.Context, *RemoveIPReservationRequest) (*RemoveIPReservationResponse, error)
+	// ListIPReservations returns the reserved addresses.
+	ListIPReservations(context.Context, *ListIPReservationsRequest) (*ListIPReservationsResponse, error)
+	// ListAllocationHistory returns a page of the allocation history matching the request filters.
+	ListAllocationHistory(context.Context, *ListAllocationHistoryRequest) (*ListAllocationHistoryResponse, error)
+	// WatchEvents streams the changes of the IPAM state of the node as they
+	// happen. Past events are not replayed.
+	WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[WatchEvent]) error
+	// CreateSnapshot writes a consistent snapshot of the store to a file on the
+	// node while the daemon keeps serving requests.
+	CreateSnapshot(context.Context, *CreateSnapshotRequest) (*CreateSnapshotResponse, error)
+	// RestoreSnapshot replaces the content of the store with a snapshot file on
+	// the node, deletes the restored CIDR blocks no longer assigned to the node,
+	// and claims the IPs of the running pods of the node again. Allocations are
+	// held off until the restore completes.
+	RestoreSnapshot(context.Context, *RestoreSnapshotRequest) (*RestoreSnapshotResponse, error)
+	// AddIPQuarantine quarantines an address or a CIDR block, so that it is not
+	// allocated until the quarantine is removed.
+	AddIPQuarantine(context.Context, *AddIPQuarantineRequest) (*AddIPQuarantineResponse, error)
+	// RemoveIPQuarantine removes the quarantine of an address or a CIDR block.
+	RemoveIPQuarantine(context.Context, *RemoveIPQuarantineRequest) (*RemoveIPQuarantineResponse, error)
+	// ListIPQuarantines returns the quarantined addresses and CIDR blocks.
+	ListIPQuarantines(context.Context, *ListIPQuarantinesRequest) (*ListIPQuarantinesResponse, error)
 	mustEmbedUnimplementedAdminServer()
 }
 
@@ -83,12 +269,45 @@ type AdminServer interface {
 // pointer dereference when methods are called.
 type UnimplementedAdminServer struct{}
 
-func (UnimplementedAdminServer) ListCIDRBlocks(context.Context, *ListCIDRBlocksRequest) (*AdminTableDumpResponse, error) {
+func (UnimplementedAdminServer) ListCIDRBlocks(context.Context, *ListCIDRBlocksRequest) (*ListCIDRBlocksResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListCIDRBlocks not implemented")
 }
-func (UnimplementedAdminServer) ListIPAddresses(context.Context, *ListIPAddressesRequest) (*AdminTableDumpResponse, error) {
+func (UnimplementedAdminServer) ListIPAddresses(context.Context, *ListIPAddressesRequest) (*ListIPAddressesResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListIPAddresses not implemented")
 }
+func (UnimplementedAdminServer) GarbageCollect(context.Context, *GarbageCollectRequest) (*GarbageCollectResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GarbageCollect not implemented")
+}
+func (UnimplementedAdminServer) AddIPReservation(context.Context, *AddIPReservationRequest) (*AddIPReservationResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddIPReservation not implemented")
+}
+func (UnimplementedAdminServer) RemoveIPReservation(context.Context, *RemoveIPReservationRequest) (*RemoveIPReservationResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RemoveIPReservation not implemented")
+}
+func (UnimplementedAdminServer) ListIPReservations(context.Context, *ListIPReservationsRequest) (*ListIPReservationsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListIPReservations not implemented")
+}
+func (UnimplementedAdminServer) ListAllocationHistory(context.Context, *ListAllocationHistoryRequest) (*ListAllocationHistoryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListAllocationHistory not implemented")
+}
+func (UnimplementedAdminServer) WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[WatchEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedAdminServer) CreateSnapshot(context.Context, *CreateSnapshotRequest) (*CreateSnapshotResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateSnapshot not implemented")
+}
+func (UnimplementedAdminServer) RestoreSnapshot(context.Context, *RestoreSnapshotRequest) (*RestoreSnapshotResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RestoreSnapshot not implemented")
+}
+func (UnimplementedAdminServer) AddIPQuarantine(context.Context, *AddIPQuarantineRequest) (*AddIPQuarantineResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddIPQuarantine not implemented")
+}
+func (UnimplementedAdminServer) RemoveIPQuarantine(context.Context, *RemoveIPQuarantineRequest) (*RemoveIPQuarantineResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RemoveIPQuarantine not implemented")
+}
+func (UnimplementedAdminServer) ListIPQuarantines(context.Context, *ListIPQuarantinesRequest) (*ListIPQuarantinesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListIPQuarantines not implemented")
+}
 func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
 func (UnimplementedAdminServer) testEmbeddedByValue()               {}
 
@@ -146,6 +365,197 @@ func _Admin_ListIPAddresses_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Admin_GarbageCollect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GarbageCollectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GarbageCollect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GarbageCollect_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GarbageCollect(ctx, req.(*GarbageCollectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_AddIPReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddIPReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).AddIPReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_AddIPReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).AddIPReservation(ctx, req.(*AddIPReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_RemoveIPReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveIPReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).RemoveIPReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_RemoveIPReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).RemoveIPReservation(ctx, req.(*RemoveIPReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListIPReservations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListIPReservationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListIPReservations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_ListIPReservations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListIPReservations(ctx, req.(*ListIPReservationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListAllocationHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAllocationHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListAllocationHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_ListAllocationHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListAllocationHistory(ctx, req.(*ListAllocationHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).WatchEvents(m, &grpc.GenericServerStream[WatchEventsRequest, WatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Admin_WatchEventsServer = grpc.ServerStreamingServer[WatchEvent]
+
+func _Admin_CreateSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).CreateSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_CreateSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).CreateSnapshot(ctx, req.(*CreateSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_RestoreSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).RestoreSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_RestoreSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).RestoreSnapshot(ctx, req.(*RestoreSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_AddIPQuarantine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddIPQuarantineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).AddIPQuarantine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_AddIPQuarantine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).AddIPQuarantine(ctx, req.(*AddIPQuarantineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_RemoveIPQuarantine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveIPQuarantineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).RemoveIPQuarantine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_RemoveIPQuarantine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).RemoveIPQuarantine(ctx, req.(*RemoveIPQuarantineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListIPQuarantines_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListIPQuarantinesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListIPQuarantines(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_ListIPQuarantines_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListIPQuarantines(ctx, req.(*ListIPQuarantinesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -161,7 +571,53 @@ var Admin_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListIPAddresses",
 			Handler:    _Admin_ListIPAddresses_Handler,
 		},
+		{
+			MethodName: "GarbageCollect",
+			Handler:    _Admin_GarbageCollect_Handler,
+		},
+		{
+			MethodName: "AddIPReservation",
+			Handler:    _Admin_AddIPReservation_Handler,
+		},
+		{
+			MethodName: "RemoveIPReservation",
+			Handler:    _Admin_RemoveIPReservation_Handler,
+		},
+		{
+			MethodName: "ListIPReservations",
+			Handler:    _Admin_ListIPReservations_Handler,
+		},
+		{
+			MethodName: "ListAllocationHistory",
+			Handler:    _Admin_ListAllocationHistory_Handler,
+		},
+		{
+			MethodName: "CreateSnapshot",
+			Handler:    _Admin_CreateSnapshot_Handler,
+		},
+		{
+			MethodName: "RestoreSnapshot",
+			Handler:    _Admin_RestoreSnapshot_Handler,
+		},
+		{
+			MethodName: "AddIPQuarantine",
+			Handler:    _Admin_AddIPQuarantine_Handler,
+		},
+		{
+			MethodName: "RemoveIPQuarantine",
+			Handler:    _Admin_RemoveIPQuarantine_Handler,
+		},
+		{
+			MethodName: "ListIPQuarantines",
+			Handler:    _Admin_ListIPQuarantines_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _Admin_WatchEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "metis/api/admin/v1/admin.proto",
 }
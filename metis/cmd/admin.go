@@ -18,21 +18,70 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	adminv1 "k8s.io/metis/api/admin/v1"
 	"k8s.io/metis/pkg"
 )
 
+// adminListOptions holds the filter and pagination flags shared by the admin list commands.
+type adminListOptions struct {
+	network   string
+	ipFamily  string
+	state     string
+	pageSize  int32
+	pageToken string
+}
+
+func (o *adminListOptions) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.network, "network", "", "Only list records of this network")
+	fs.StringVar(&o.ipFamily, "ip-family", "", "Only list records of this IP family (ipv4 or ipv6)")
+	fs.StringVar(&o.state, "state", "", "Only list records of CIDR blocks in this state (Ready, Draining or Deleting)")
+	fs.Int32Var(&o.pageSize, "page-size", 0, "Maximum number of records to list, the daemon default is used if 0")
+	fs.StringVar(&o.pageToken, "page-token", "", "Continue listing from the page token printed by a previous command")
+}
+
+// ipAddressListOptions holds the flags of the ip-addresses list command.
+type ipAddressListOptions struct {
+	adminListOptions
+	containerID   string
+	podNamespace  string
+	podName       string
+	allocatedOnly bool
+}
+
+func (o *ipAddressListOptions) addFlags(fs *pflag.FlagSet) {
+	o.adminListOptions.addFlags(fs)
+	fs.StringVar(&o.containerID, "container-id", "", "Only list IP addresses held by this container ID")
+	fs.StringVar(&o.podNamespace, "pod-namespace", "", "Only list IP addresses held by pods in this namespace")
+	fs.StringVar(&o.podName, "pod-name", "", "Only list IP addresses held by pods with this name")
+	fs.BoolVar(&o.allocatedOnly, "allocated-only", false, "Only list IP addresses that are currently assigned to a pod")
+}
+
+// adminListResponse is a page returned by an admin list RPC.
+type adminListResponse interface {
+	proto.Message
+	GetNextPageToken() string
+}
+
 func newAdminCommand() *cobra.Command {
 	var outputFormat string
-	var filter string
 
 	cmd := &cobra.Command{
 		Use:    "admin",
@@ -42,60 +91,501 @@ func newAdminCommand() *cobra.Command {
 
 	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "Output format (json or table)")
 	cmd.PersistentFlags().MarkHidden("output")
-	cmd.PersistentFlags().StringVarP(&filter, "filter", "f", "", "Filter rows in list output")
-	cmd.PersistentFlags().MarkHidden("filter")
 
 	cidrCmd := &cobra.Command{
 		Use:    "cidr-blocks",
 		Short:  "Manage CIDR blocks",
 		Hidden: true,
 	}
-	cidrCmd.AddCommand(&cobra.Command{
+	cidrOpts := &adminListOptions{}
+	cidrListCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List CIDR blocks",
 		Long:  "List CIDR blocks.",
 		Example: `  # List all CIDR blocks
   metis admin cidr-blocks list
-  # List a specific CIDR block by ID
-  metis admin cidr-blocks list --filter "id = '1'"
-  # List all Ready CIDR blocks
-  metis admin cidr-blocks list --filter "state = 'Ready'"`,
+  # List all Ready IPv6 CIDR blocks of a network
+  metis admin cidr-blocks list --network default --ip-family ipv6 --state Ready
+  # List the next 100 CIDR blocks after a previous page
+  metis admin cidr-blocks list --page-size 100 --page-token 100`,
 		Run: func(_ *cobra.Command, _ []string) {
-			executeAdminListCommand(outputFormat, func(ctx context.Context, client adminv1.AdminClient) (*adminv1.AdminTableDumpResponse, error) {
-				return client.ListCIDRBlocks(ctx, &adminv1.ListCIDRBlocksRequest{Filter: filter})
+			executeAdminListCommand(outputFormat, func(ctx context.Context, client adminv1.AdminClient) (adminListResponse, error) {
+				return client.ListCIDRBlocks(ctx, &adminv1.ListCIDRBlocksRequest{
+					Network:   cidrOpts.network,
+					IpFamily:  cidrOpts.ipFamily,
+					State:     cidrOpts.state,
+					PageSize:  cidrOpts.pageSize,
+					PageToken: cidrOpts.pageToken,
+				})
 			})
 		},
-	})
+	}
+	cidrOpts.addFlags(cidrListCmd.Flags())
+	cidrCmd.AddCommand(cidrListCmd)
 
 	ipCmd := &cobra.Command{
 		Use:    "ip-addresses",
 		Short:  "Manage IP addresses",
 		Hidden: true,
 	}
-	ipCmd.AddCommand(&cobra.Command{
+	ipOpts := &ipAddressListOptions{}
+	ipListCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List IP addresses",
 		Long:  "List IP addresses.",
 		Example: `  # List all IP addresses
   metis admin ip-addresses list
-  # List a specific IP address by ID
-  metis admin ip-addresses list --filter "id = '1'"
   # List IP addresses that are allocated in a specific namespace
-  metis admin ip-addresses list --filter "pod_namespace = 'default' AND is_allocated = '1'"`,
+  metis admin ip-addresses list --pod-namespace default --allocated-only
+  # List the IP addresses held by a container
+  metis admin ip-addresses list --container-id f093u09jfioj`,
 		Run: func(_ *cobra.Command, _ []string) {
-			executeAdminListCommand(outputFormat, func(ctx context.Context, client adminv1.AdminClient) (*adminv1.AdminTableDumpResponse, error) {
-				return client.ListIPAddresses(ctx, &adminv1.ListIPAddressesRequest{Filter: filter})
+			executeAdminListCommand(outputFormat, func(ctx context.Context, client adminv1.AdminClient) (adminListResponse, error) {
+				return client.ListIPAddresses(ctx, &adminv1.ListIPAddressesRequest{
+					Network:       ipOpts.network,
+					IpFamily:      ipOpts.ipFamily,
+					State:         ipOpts.state,
+					ContainerId:   ipOpts.containerID,
+					PodNamespace:  ipOpts.podNamespace,
+					PodName:       ipOpts.podName,
+					AllocatedOnly: ipOpts.allocatedOnly,
+					PageSize:      ipOpts.pageSize,
+					PageToken:     ipOpts.pageToken,
+				})
 			})
 		},
-	})
+	}
+	ipOpts.addFlags(ipListCmd.Flags())
+	ipCmd.AddCommand(ipListCmd)
+
+	var dryRun bool
+	gcCmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Release IPs of pods that no longer exist",
+		Long: `Release the IPs held by pods that no longer exist on the node, e.g. because
+their CNI DEL was lost. The IPs are released with the daemon release cooldown.`,
+		Example: `  # List the IPs that would be released
+  metis admin gc --dry-run
+  # Release the IPs of pods that no longer exist
+  metis admin gc`,
+		Run: func(_ *cobra.Command, _ []string) {
+			executeAdminGCCommand(outputFormat, dryRun)
+		},
+	}
+	gcCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the IPs that would be released")
 
 	cmd.AddCommand(cidrCmd)
 	cmd.AddCommand(ipCmd)
+	cmd.AddCommand(gcCmd)
+	cmd.AddCommand(newAdminReservationsCommand(&outputFormat))
+	cmd.AddCommand(newAdminQuarantineCommand(&outputFormat))
+	cmd.AddCommand(newAdminHistoryCommand(&outputFormat))
+	cmd.AddCommand(newAdminWatchCommand(&outputFormat))
+	cmd.AddCommand(newAdminSnapshotCommand(&outputFormat))
+	cmd.AddCommand(newAdminRestoreCommand(&outputFormat))
+
+	return cmd
+}
+
+func newAdminReservationsCommand(outputFormat *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "reservations",
+		Short:  "Manage IP reservations",
+		Hidden: true,
+	}
+
+	var network, address, podNamespace, podName string
+	addCmd := &cobra.Command{
+		Use:   "add",
+		Short: "Reserve an IP address",
+		Long: `Reserve an IP address. A reserved address is never assigned by the regular
+allocation path. If a pod is given, the address is assigned to that pod whenever
+it is created on the node.`,
+		Example: `  # Keep an address from being assigned to any pod
+  metis admin reservations add --address 10.0.1.2
+  # Assign an address to a specific pod
+  metis admin reservations add --address 10.0.1.3 --pod-namespace default --pod-name db-0`,
+		Run: func(_ *cobra.Command, _ []string) {
+			executeAdminReservationCommand(*outputFormat, func(ctx context.Context, client adminv1.AdminClient) (proto.Message, error) {
+				return client.AddIPReservation(ctx, &adminv1.AddIPReservationRequest{
+					Network:      network,
+					Address:      address,
+					PodNamespace: podNamespace,
+					PodName:      podName,
+				})
+			})
+		},
+	}
+	addCmd.Flags().StringVar(&network, "network", "", "Network of the address, the default pod network if empty")
+	addCmd.Flags().StringVar(&address, "address", "", "IP address to reserve")
+	addCmd.Flags().StringVar(&podNamespace, "pod-namespace", "", "Namespace of the pod to reserve the address for")
+	addCmd.Flags().StringVar(&podName, "pod-name", "", "Name of the pod to reserve the address for")
+	addCmd.MarkFlagRequired("address")
+
+	removeCmd := &cobra.Command{
+		Use:     "remove",
+		Short:   "Remove the reservation of an IP address",
+		Long:    "Remove the reservation of an IP address. An address assigned to a pod stays assigned until the pod is deleted.",
+		Example: `  metis admin reservations remove --address 10.0.1.2`,
+		Run: func(_ *cobra.Command, _ []string) {
+			executeAdminReservationCommand(*outputFormat, func(ctx context.Context, client adminv1.AdminClient) (proto.Message, error) {
+				return client.RemoveIPReservation(ctx, &adminv1.RemoveIPReservationRequest{
+					Network: network,
+					Address: address,
+				})
+			})
+		},
+	}
+	removeCmd.Flags().StringVar(&network, "network", "", "Network of the address, the default pod network if empty")
+	removeCmd.Flags().StringVar(&address, "address", "", "Reserved IP address")
+	removeCmd.MarkFlagRequired("address")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List IP reservations",
+		Long:  "List IP reservations.",
+		Example: `  # List all IP reservations
+  metis admin reservations list
+  # List the IP reservations of a network
+  metis admin reservations list --network default`,
+		Run: func(_ *cobra.Command, _ []string) {
+			executeAdminReservationCommand(*outputFormat, func(ctx context.Context, client adminv1.AdminClient) (proto.Message, error) {
+				return client.ListIPReservations(ctx, &adminv1.ListIPReservationsRequest{Network: network})
+			})
+		},
+	}
+	listCmd.Flags().StringVar(&network, "network", "", "Only list reservations of this network")
+
+	cmd.AddCommand(addCmd)
+	cmd.AddCommand(removeCmd)
+	cmd.AddCommand(listCmd)
+	return cmd
+}
+
+func newAdminQuarantineCommand(outputFormat *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "quarantine",
+		Short:  "Manage quarantined IP addresses and CIDR blocks",
+		Hidden: true,
+	}
+
+	var network, address, cidr, reason string
+	addCmd := &cobra.Command{
+		Use:   "add",
+		Short: "Quarantine an IP address or a CIDR block",
+		Long: `Quarantine an IP address or a CIDR block. A quarantined address, or any address
+of a quarantined CIDR block, is not assigned to pods until the quarantine is
+removed. Addresses already assigned stay assigned until their pod is deleted.`,
+		Example: `  # Stop assigning an address used by another host
+  metis admin quarantine add --address 10.0.1.2 --reason "duplicate address"
+  # Stop assigning the addresses of a CIDR block
+  metis admin quarantine add --cidr 10.0.1.0/28`,
+		Run: func(_ *cobra.Command, _ []string) {
+			executeAdminQuarantineCommand(*outputFormat, func(ctx context.Context, client adminv1.AdminClient) (proto.Message, error) {
+				return client.AddIPQuarantine(ctx, &adminv1.AddIPQuarantineRequest{
+					Network: network,
+					Address: address,
+					Cidr:    cidr,
+					Reason:  reason,
+				})
+			})
+		},
+	}
+	addCmd.Flags().StringVar(&network, "network", "", "Network of the address or CIDR block, the default pod network if empty")
+	addCmd.Flags().StringVar(&address, "address", "", "IP address to quarantine")
+	addCmd.Flags().StringVar(&cidr, "cidr", "", "CIDR block to quarantine")
+	addCmd.Flags().StringVar(&reason, "reason", "", "Why the address or CIDR block is quarantined")
+	addCmd.MarkFlagsOneRequired("address", "cidr")
+	addCmd.MarkFlagsMutuallyExclusive("address", "cidr")
+
+	removeCmd := &cobra.Command{
+		Use:     "remove",
+		Short:   "Remove the quarantine of an IP address or a CIDR block",
+		Long:    "Remove the quarantine of an IP address or a CIDR block, so that it is assigned to pods again.",
+		Example: `  metis admin quarantine remove --address 10.0.1.2`,
+		Run: func(_ *cobra.Command, _ []string) {
+			executeAdminQuarantineCommand(*outputFormat, func(ctx context.Context, client adminv1.AdminClient) (proto.Message, error) {
+				return client.RemoveIPQuarantine(ctx, &adminv1.RemoveIPQuarantineRequest{
+					Network: network,
+					Address: address,
+					Cidr:    cidr,
+				})
+			})
+		},
+	}
+	removeCmd.Flags().StringVar(&network, "network", "", "Network of the address or CIDR block, the default pod network if empty")
+	removeCmd.Flags().StringVar(&address, "address", "", "Quarantined IP address")
+	removeCmd.Flags().StringVar(&cidr, "cidr", "", "Quarantined CIDR block")
+	removeCmd.MarkFlagsOneRequired("address", "cidr")
+	removeCmd.MarkFlagsMutuallyExclusive("address", "cidr")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List quarantined IP addresses and CIDR blocks",
+		Long:  "List quarantined IP addresses and CIDR blocks.",
+		Example: `  # List all quarantines
+  metis admin quarantine list
+  # List the quarantines of a network
+  metis admin quarantine list --network default`,
+		Run: func(_ *cobra.Command, _ []string) {
+			executeAdminQuarantineCommand(*outputFormat, func(ctx context.Context, client adminv1.AdminClient) (proto.Message, error) {
+				return client.ListIPQuarantines(ctx, &adminv1.ListIPQuarantinesRequest{Network: network})
+			})
+		},
+	}
+	listCmd.Flags().StringVar(&network, "network", "", "Only list quarantines of this network")
+
+	cmd.AddCommand(addCmd)
+	cmd.AddCommand(removeCmd)
+	cmd.AddCommand(listCmd)
+	return cmd
+}
+
+func newAdminHistoryCommand(outputFormat *string) *cobra.Command {
+	var network, address, pod, containerID string
+	var since time.Duration
+	var pageSize int32
+	var pageToken string
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List the allocation history",
+		Long: `List the allocation history of IP addresses and CIDR blocks in the order the
+events were recorded. The history is kept for a limited time and number of records.`,
+		Example: `  # List who held an address in the last hour
+  metis admin history --ip 10.0.1.17 --since 1h
+  # List the addresses held by a pod
+  metis admin history --pod default/web-0`,
+		Run: func(_ *cobra.Command, _ []string) {
+			req := &adminv1.ListAllocationHistoryRequest{
+				Network:     network,
+				Address:     address,
+				ContainerId: containerID,
+				PageSize:    pageSize,
+				PageToken:   pageToken,
+			}
+			if pod != "" {
+				namespace, name, ok := strings.Cut(pod, "/")
+				if !ok || namespace == "" || name == "" {
+					fmt.Fprintf(os.Stderr, "invalid --pod %q, must be <namespace>/<name>\n", pod)
+					os.Exit(1)
+				}
+				req.PodNamespace, req.PodName = namespace, name
+			}
+			if since > 0 {
+				req.Since = timestamppb.New(time.Now().Add(-since))
+			}
+			executeAdminListCommand(*outputFormat, func(ctx context.Context, client adminv1.AdminClient) (adminListResponse, error) {
+				return client.ListAllocationHistory(ctx, req)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&network, "network", "", "Only list records of this network")
+	cmd.Flags().StringVar(&address, "ip", "", "Only list records of this IP address")
+	cmd.Flags().StringVar(&pod, "pod", "", "Only list records of this pod, as <namespace>/<name>")
+	cmd.Flags().StringVar(&containerID, "container-id", "", "Only list records of this container ID")
+	cmd.Flags().DurationVar(&since, "since", 0, "Only list events that occurred within this duration (e.g., 1h)")
+	cmd.Flags().Int32Var(&pageSize, "page-size", 0, "Maximum number of records to list, the daemon default is used if 0")
+	cmd.Flags().StringVar(&pageToken, "page-token", "", "Continue listing from the page token printed by a previous command")
+	return cmd
+}
+
+func newAdminWatchCommand(outputFormat *string) *cobra.Command {
+	var network string
+	var types []string
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch IPAM events",
+		Long: `Watch the IP allocations and releases, CIDR block changes, scale-up requests and
+NodeNetworkConfig patches of the daemon as they happen, until interrupted.`,
+		Example: `  # Watch all events
+  metis admin watch
+  # Watch the IP allocations and releases of a network as JSON lines
+  metis admin watch --network default --type IP_ALLOCATED,IP_RELEASED -o json`,
+		Run: func(_ *cobra.Command, _ []string) {
+			req := &adminv1.WatchEventsRequest{Network: network}
+			for _, t := range types {
+				v, ok := adminv1.WatchEvent_Type_value[strings.ToUpper(t)]
+				if !ok || v == int32(adminv1.WatchEvent_TYPE_UNSPECIFIED) {
+					fmt.Fprintf(os.Stderr, "invalid --type %q\n", t)
+					os.Exit(1)
+				}
+				req.Types = append(req.Types, adminv1.WatchEvent_Type(v))
+			}
+			executeAdminWatchCommand(*outputFormat, req)
+		},
+	}
+	cmd.Flags().StringVar(&network, "network", "", "Only watch events of this network")
+	cmd.Flags().StringSliceVar(&types, "type", nil, "Only watch events of these types (e.g., IP_ALLOCATED,BLOCK_ADDED)")
+	return cmd
+}
+
+func executeAdminWatchCommand(outputFormat string, req *adminv1.WatchEventsRequest) {
+	client, conn, err := getAdminClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	stream, err := client.WatchEvents(ctx, req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to watch: %v\n", err)
+		os.Exit(1)
+	}
+	if outputFormat == "table" {
+		printWatchEvent(os.Stdout, nil, outputFormat)
+	}
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "failed to watch: %v\n", err)
+			os.Exit(1)
+		}
+		if err := printWatchEvent(os.Stdout, ev, outputFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to print event: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// watchEventTableFormat is the row format of the table output of the watch
+// command. Columns have a fixed width since rows are printed as they arrive.
+const watchEventTableFormat = "%-20s  %-18s  %-12s  %-9s  %-18s  %-39s  %-40s  %s\n"
+
+// printWatchEvent prints an event as a table row or as a single line of JSON.
+// A nil event prints the table header.
+func printWatchEvent(out io.Writer, ev *adminv1.WatchEvent, outputFormat string) error {
+	if outputFormat != "table" {
+		b, err := protojson.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+	if ev == nil {
+		_, err := fmt.Fprintf(out, watchEventTableFormat, "TIME", "TYPE", "NETWORK", "IP_FAMILY", "CIDR", "ADDRESS", "POD", "TARGET_PODS")
+		return err
+	}
+	pod := "NULL"
+	if ev.PodName != "" {
+		pod = ev.PodNamespace + "/" + ev.PodName
+	}
+	targetPods := "NULL"
+	if ev.TargetPods != 0 {
+		targetPods = strconv.Itoa(int(ev.TargetPods))
+	}
+	_, err := fmt.Fprintf(out, watchEventTableFormat, formatTimestamp(ev.Time), ev.Type, ev.Network, orNull(ev.IpFamily),
+		orNull(ev.Cidr), orNull(ev.Address), pod, targetPods)
+	return err
+}
+
+func newAdminSnapshotCommand(outputFormat *string) *cobra.Command {
+	var path string
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Write a snapshot of the store",
+		Long: `Write a consistent snapshot of the store of the daemon to a file on the node,
+using the SQLite online backup API while the daemon keeps serving requests.`,
+		Example: `  # Write a snapshot to the snapshot directory of the daemon
+  metis admin snapshot
+  # Write a snapshot to a specific file
+  metis admin snapshot --path /var/lib/cni/metis/before-upgrade.sqlite`,
+		Run: func(_ *cobra.Command, _ []string) {
+			req := &adminv1.CreateSnapshotRequest{Path: absPath(path)}
+			executeAdminSnapshotCommand(*outputFormat, func(ctx context.Context, client adminv1.AdminClient) (proto.Message, error) {
+				return client.CreateSnapshot(ctx, req)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&path, "path", "", "Path of the snapshot file, a new file in the snapshot directory of the daemon if empty")
+	return cmd
+}
 
+func newAdminRestoreCommand(outputFormat *string) *cobra.Command {
+	var path string
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore the store from a snapshot",
+		Long: `Replace the content of the store of the daemon with a snapshot file on the node.
+The CIDR blocks of the snapshot that are no longer assigned to the node are
+deleted, with the IPs allocated from them. The IPs of the running pods of the
+node are claimed again, and no IPs are allocated until the restore completes.`,
+		Example: `  metis admin restore --path /var/lib/cni/metis/snapshots/metis-20260101T000000.000Z.sqlite`,
+		Run: func(_ *cobra.Command, _ []string) {
+			req := &adminv1.RestoreSnapshotRequest{Path: absPath(path)}
+			executeAdminSnapshotCommand(*outputFormat, func(ctx context.Context, client adminv1.AdminClient) (proto.Message, error) {
+				return client.RestoreSnapshot(ctx, req)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&path, "path", "", "Path of the snapshot file")
+	cmd.MarkFlagRequired("path")
 	return cmd
 }
 
-func executeAdminListCommand(outputFormat string, queryFunc func(context.Context, adminv1.AdminClient) (*adminv1.AdminTableDumpResponse, error)) {
+// absPath returns the absolute path of a file for the daemon, which does not
+// share the working directory of the command.
+func absPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid path %q: %v\n", path, err)
+		os.Exit(1)
+	}
+	return abs
+}
+
+func executeAdminSnapshotCommand(outputFormat string, callFunc func(context.Context, adminv1.AdminClient) (proto.Message, error)) {
+	client, conn, err := getAdminClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+	res, err := callFunc(context.Background(), client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to call the daemon: %v\n", err)
+		os.Exit(1)
+	}
+	if err := printSnapshotResponse(os.Stdout, res, outputFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print response: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printSnapshotResponse(out io.Writer, res proto.Message, outputFormat string) error {
+	if outputFormat != "table" {
+		b, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(res)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+
+	switch res := res.(type) {
+	case *adminv1.CreateSnapshotResponse:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PATH\tSIZE_BYTES")
+		fmt.Fprintf(w, "%s\t%d\n", res.Path, res.SizeBytes)
+		return w.Flush()
+	case *adminv1.RestoreSnapshotResponse:
+		_, err := fmt.Fprintln(out, "Restored the snapshot")
+		return err
+	default:
+		return fmt.Errorf("unsupported response type %T", res)
+	}
+}
+
+func executeAdminListCommand(outputFormat string, queryFunc func(context.Context, adminv1.AdminClient) (adminListResponse, error)) {
 	client, conn, err := getAdminClient()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
@@ -107,31 +597,215 @@ func executeAdminListCommand(outputFormat string, queryFunc func(context.Context
 		fmt.Fprintf(os.Stderr, "failed to query: %v\n", err)
 		os.Exit(1)
 	}
-	printDumpResponse(res, outputFormat)
+	if err := printListResponse(os.Stdout, res, outputFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print response: %v\n", err)
+		os.Exit(1)
+	}
+	if token := res.GetNextPageToken(); token != "" && outputFormat == "table" {
+		fmt.Fprintf(os.Stderr, "More records available, continue with --page-token %s\n", token)
+	}
 }
 
-func printDumpResponse(res *adminv1.AdminTableDumpResponse, outputFormat string) {
-	if outputFormat == "table" {
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		// Print Headers
-		fmt.Fprintln(w, strings.ToUpper(strings.Join(res.Headers, "\t")))
-		// Print Rows
-		for _, row := range res.Rows {
-			fmt.Fprintln(w, strings.Join(row.Values, "\t"))
+func executeAdminGCCommand(outputFormat string, dryRun bool) {
+	client, conn, err := getAdminClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+	res, err := client.GarbageCollect(context.Background(), &adminv1.GarbageCollectRequest{DryRun: dryRun})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to garbage collect: %v\n", err)
+		os.Exit(1)
+	}
+	if err := printGarbageCollectResponse(os.Stdout, res, outputFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print response: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func executeAdminReservationCommand(outputFormat string, callFunc func(context.Context, adminv1.AdminClient) (proto.Message, error)) {
+	client, conn, err := getAdminClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+	res, err := callFunc(context.Background(), client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to update reservations: %v\n", err)
+		os.Exit(1)
+	}
+	if err := printReservationResponse(os.Stdout, res, outputFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print response: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printReservationResponse(out io.Writer, res proto.Message, outputFormat string) error {
+	if outputFormat != "table" {
+		b, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(res)
+		if err != nil {
+			return err
 		}
-		w.Flush()
-	} else {
-		var jsonPayload []map[string]any
-		for _, row := range res.Rows {
-			rowMap := map[string]any{}
-			for i, header := range res.Headers {
-				rowMap[header] = row.Values[i]
-			}
-			jsonPayload = append(jsonPayload, rowMap)
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+
+	var reservations []*adminv1.IPReservation
+	switch res := res.(type) {
+	case *adminv1.AddIPReservationResponse:
+		reservations = append(reservations, res.Reservation)
+	case *adminv1.RemoveIPReservationResponse:
+		_, err := fmt.Fprintln(out, "Removed the reservation")
+		return err
+	case *adminv1.ListIPReservationsResponse:
+		reservations = res.Reservations
+	default:
+		return fmt.Errorf("unsupported response type %T", res)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NETWORK\tADDRESS\tIP_FAMILY\tPOD_NAMESPACE\tPOD_NAME\tCREATED_AT")
+	for _, r := range reservations {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Network, r.Address, r.IpFamily, orNull(r.PodNamespace), orNull(r.PodName), formatTimestamp(r.CreatedAt))
+	}
+	return w.Flush()
+}
+
+func executeAdminQuarantineCommand(outputFormat string, callFunc func(context.Context, adminv1.AdminClient) (proto.Message, error)) {
+	client, conn, err := getAdminClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+	res, err := callFunc(context.Background(), client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to update quarantines: %v\n", err)
+		os.Exit(1)
+	}
+	if err := printQuarantineResponse(os.Stdout, res, outputFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print response: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printQuarantineResponse(out io.Writer, res proto.Message, outputFormat string) error {
+	if outputFormat != "table" {
+		b, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(res)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+
+	var quarantines []*adminv1.IPQuarantine
+	switch res := res.(type) {
+	case *adminv1.AddIPQuarantineResponse:
+		quarantines = append(quarantines, res.Quarantine)
+	case *adminv1.RemoveIPQuarantineResponse:
+		_, err := fmt.Fprintln(out, "Removed the quarantine")
+		return err
+	case *adminv1.ListIPQuarantinesResponse:
+		quarantines = res.Quarantines
+	default:
+		return fmt.Errorf("unsupported response type %T", res)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NETWORK\tADDRESS\tCIDR\tIP_FAMILY\tREASON\tCREATED_AT")
+	for _, q := range quarantines {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			q.Network, orNull(q.Address), orNull(q.Cidr), q.IpFamily, orNull(q.Reason), formatTimestamp(q.CreatedAt))
+	}
+	return w.Flush()
+}
+
+func printGarbageCollectResponse(out io.Writer, res *adminv1.GarbageCollectResponse, outputFormat string) error {
+	if outputFormat != "table" {
+		b, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(res)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NETWORK\tCONTAINER_ID\tINTERFACE_NAME\tPOD_NAMESPACE\tPOD_NAME\tADDRESSES\tREASON\tALLOCATED_AT")
+	for _, o := range res.StaleOwners {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			o.Network, o.ContainerId, orNull(o.InterfaceName), orNull(o.PodNamespace), orNull(o.PodName),
+			strings.Join(o.Addresses, ","), o.Reason, formatTimestamp(o.AllocatedAt))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	verb := "Released"
+	if res.DryRun {
+		verb = "Would release"
+	}
+	_, err := fmt.Fprintf(out, "%s the IPs of %d stale owners\n", verb, len(res.StaleOwners))
+	return err
+}
+
+func printListResponse(out io.Writer, res adminListResponse, outputFormat string) error {
+	if outputFormat != "table" {
+		b, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(res)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	switch res := res.(type) {
+	case *adminv1.ListCIDRBlocksResponse:
+		fmt.Fprintln(w, "ID\tCIDR\tNETWORK\tIP_FAMILY\tTOTAL_IPS\tALLOCATED_IPS\tSTATE\tCREATED_AT\tUPDATED_AT")
+		for _, b := range res.CidrBlocks {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n",
+				b.Id, b.Cidr, b.Network, b.IpFamily, b.TotalIps, b.AllocatedIps, b.State,
+				formatTimestamp(b.CreatedAt), formatTimestamp(b.UpdatedAt))
+		}
+	case *adminv1.ListIPAddressesResponse:
+		fmt.Fprintln(w, "ID\tADDRESS\tCIDR_BLOCK_ID\tNETWORK\tIP_FAMILY\tCONTAINER_ID\tPOD_NAMESPACE\tPOD_NAME\tINTERFACE_NAME\tIS_ALLOCATED\tRELEASE_AT\tALLOCATED_AT\tUPDATED_AT")
+		for _, a := range res.IpAddresses {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				a.Id, a.Address, a.CidrBlockId, a.Network, a.IpFamily,
+				orNull(a.ContainerId), orNull(a.PodNamespace), orNull(a.PodName), orNull(a.InterfaceName),
+				strconv.FormatBool(a.IsAllocated),
+				formatTimestamp(a.ReleaseAt), formatTimestamp(a.AllocatedAt), formatTimestamp(a.UpdatedAt))
 		}
-		b, _ := json.MarshalIndent(jsonPayload, "", "  ")
-		fmt.Println(string(b))
+	case *adminv1.ListAllocationHistoryResponse:
+		fmt.Fprintln(w, "OCCURRED_AT\tEVENT\tNETWORK\tCIDR\tADDRESS\tCONTAINER_ID\tINTERFACE_NAME\tPOD_NAMESPACE\tPOD_NAME\tRELEASE_AT")
+		for _, r := range res.Records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				formatTimestamp(r.OccurredAt), r.Event, r.Network, r.Cidr, orNull(r.Address),
+				orNull(r.ContainerId), orNull(r.InterfaceName), orNull(r.PodNamespace), orNull(r.PodName),
+				formatTimestamp(r.ReleaseAt))
+		}
+	default:
+		return fmt.Errorf("unsupported response type %T", res)
+	}
+	return w.Flush()
+}
+
+func formatTimestamp(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return "NULL"
+	}
+	return ts.AsTime().Format(time.RFC3339)
+}
+
+func orNull(s string) string {
+	if s == "" {
+		return "NULL"
 	}
+	return s
 }
 
 func getAdminClient() (adminv1.AdminClient, *grpc.ClientConn, error) {
@@ -27,9 +27,11 @@ func runCni() {
 
 	skel.PluginMainFuncs(
 		skel.CNIFuncs{
-			Add:   plugin.CmdAdd,
-			Check: plugin.CmdCheck,
-			Del:   plugin.CmdDel,
+			Add:    plugin.CmdAdd,
+			Check:  plugin.CmdCheck,
+			Del:    plugin.CmdDel,
+			GC:     plugin.CmdGC,
+			Status: plugin.CmdStatus,
 		},
 		version.All,
 		"Metis CNI plugin",
@@ -40,7 +40,10 @@ func newDaemonCommand() *cobra.Command {
 		Run: func(cmd *cobra.Command, _ []string) {
 			cliflag.PrintFlags(cmd.Flags())
 			var cfg daemon.Config
-			_ = opts.applyTo(&cfg)
+			if err := opts.applyTo(&cfg); err != nil {
+				klog.ErrorS(err, "Invalid daemon options")
+				os.Exit(1)
+			}
 			d := daemon.NewDaemon(cfg)
 
 			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
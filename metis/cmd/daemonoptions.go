@@ -17,6 +17,8 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
+
 	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/metis/pkg"
 	"k8s.io/metis/pkg/daemon"
@@ -48,6 +50,49 @@ func (o *daemonOptions) addFlags() cliflag.NamedFlagSets {
 	fs.StringVar(&o.SocketPath, "socket-path", pkg.DefaultSockPath, "Path to the Unix domain socket")
 	fs.DurationVar(&o.DrainingExpiration, "draining-expiration", daemon.DefaultDrainingExpiration, "Draining expiration duration (e.g., 5h). 0 or negative values will be interpreted as the default value.")
 	fs.DurationVar(&o.SustainedLowUtilizationDuration, "sustained-low-utilization-duration", daemon.DefaultSustainedLowUtilizationDuration, "Sustained low utilization duration (e.g., 8h). 0 or negative values will be interpreted as the default value.")
+	fs.DurationVar(&o.UtilizationReportInterval, "utilization-report-interval", daemon.DefaultUtilizationReportInterval, fmt.Sprintf("Minimum interval between two updates of the IP utilization published in the %s condition of the NodeNetworkConfig status and its %s annotation (e.g., 30s). 0 or negative values will be interpreted as the default value.", daemon.IPUtilizationConditionType, daemon.IPUtilizationAnnotation))
+
+	fs = fss.FlagSet("scaling policy")
+	fs.StringVar(&o.ScalingPolicy, "scaling-policy", daemon.DefaultScalingPolicy, fmt.Sprintf("The scaling policy of the networks without a policy in --network-scaling-policies, one of %q, %q or %q. The %s annotation of the NodeNetworkConfig takes precedence.", daemon.ScalingPolicyUtilization, daemon.ScalingPolicyMinFreeIPs, daemon.ScalingPolicyRateBased, daemon.ScalingPolicyAnnotation))
+	fs.Var(cliflag.NewMapStringString(&o.NetworkScalingPolicies), "network-scaling-policies", "A set of <network>=<policy> pairs selecting the scaling policy of specific networks (e.g., gpu-network=min-free-ips).")
+	fs.IntVar(&o.MinFreeIPs, "min-free-ips", daemon.DefaultMinFreeIPs, "Number of free IPs kept warm by the min-free-ips scaling policy. 0 or negative values will be interpreted as the default value.")
+	fs.DurationVar(&o.RateWindow, "rate-window", daemon.DefaultRateWindow, "Window the rate-based scaling policy measures the IP demand growth over (e.g., 1m). 0 or negative values will be interpreted as the default value.")
+	fs.DurationVar(&o.RateLeadTime, "rate-lead-time", daemon.DefaultRateLeadTime, "How far ahead the rate-based scaling policy pre-warms IPs for at the current demand growth rate (e.g., 30s). 0 or negative values will be interpreted as the default value.")
+
+	fs = fss.FlagSet("network configuration")
+	fs.StringVar(&o.NetworkConfigFile, "network-config-file", "", fmt.Sprintf("Path to a %s file (YAML or JSON) overriding the settings of the daemon flags for all or specific networks. Changes to the file take effect without restarting the daemon.", daemon.NetworkConfigurationKind))
+	fs.DurationVar(&o.NetworkConfigReloadInterval, "network-config-reload-interval", daemon.DefaultNetworkConfigReloadInterval, "How often the --network-config-file is checked for changes (e.g., 10s). 0 or negative values will be interpreted as the default value.")
+
+	fs = fss.FlagSet("cidr sources")
+	fs.StringSliceVar(&o.CIDRSources, "cidr-sources", []string{daemon.CIDRSourceNNC}, fmt.Sprintf("Comma-separated sources of the CIDR blocks of the node, any of %q, %q or %q. Without %q the NodeNetworkConfig is not used, e.g. in clusters without the GCE control plane, and only the network of the %q source scales, within --cluster-cidr.", daemon.CIDRSourceNNC, daemon.CIDRSourceClusterCIDR, daemon.CIDRSourceFile, daemon.CIDRSourceNNC, daemon.CIDRSourceClusterCIDR))
+	fs.StringSliceVar(&o.ClusterCIDR.ClusterCIDRs, "cluster-cidr", nil, fmt.Sprintf("Comma-separated ranges reserved for the node, at most one per IP family, the %q source carves its blocks from (e.g., 10.244.1.0/24). Without the %q source, the blocks are assigned as the network scales up and returned as it scales down.", daemon.CIDRSourceClusterCIDR, daemon.CIDRSourceNNC))
+	fs.StringVar(&o.ClusterCIDR.Network, "cluster-cidr-network", "", fmt.Sprintf("Network of the blocks of the %q source, the default pod network if empty.", daemon.CIDRSourceClusterCIDR))
+	fs.IntVar(&o.ClusterCIDR.BlockMaskSize, "cluster-cidr-block-mask-size", daemon.DefaultClusterCIDRBlockMaskSize, "Prefix length of the IPv4 blocks carved from --cluster-cidr. 0 or negative values will be interpreted as the default value.")
+	fs.IntVar(&o.ClusterCIDR.IPv6BlockMaskSize, "cluster-cidr-ipv6-block-mask-size", daemon.DefaultClusterCIDRIPv6BlockMaskSize, "Prefix length of the IPv6 blocks carved from --cluster-cidr, at most 16 bits longer than the IPv6 cluster CIDR. 0 or negative values will be interpreted as the default value.")
+	fs.IntVar(&o.ClusterCIDR.MaxBlocks, "cluster-cidr-max-blocks", 0, "Maximum number of blocks carved from each --cluster-cidr range. All blocks are carved if 0 or negative.")
+	fs.StringVar(&o.CIDRFile, "cidr-file", "", fmt.Sprintf("Path to a %s file (YAML or JSON) with the static blocks of the %q source. Changes to the file take effect without restarting the daemon.", daemon.CIDRBlockListKind, daemon.CIDRSourceFile))
+	fs.DurationVar(&o.CIDRFileReloadInterval, "cidr-file-reload-interval", daemon.DefaultCIDRFileReloadInterval, "How often the --cidr-file is checked for changes (e.g., 10s). 0 or negative values will be interpreted as the default value.")
+
+	fs = fss.FlagSet("garbage collection")
+	fs.DurationVar(&o.GCInterval, "gc-interval", daemon.DefaultGCInterval, "Interval of the garbage collection of IPs held by pods that no longer exist on the node (e.g., 10m). A pass also runs at startup. 0 or negative values will be interpreted as the default value.")
+	fs.BoolVar(&o.GCCheckNetns, "gc-check-netns", false, "Also release IPs whose pod sandbox network namespace no longer exists. Requires the host netns directory to be mounted into the daemon.")
+	fs.BoolVar(&o.GCDryRun, "gc-dry-run", false, "Only log the IPs the garbage collection would release.")
+
+	fs = fss.FlagSet("allocation history")
+	fs.DurationVar(&o.HistoryMaxAge, "history-max-age", daemon.DefaultHistoryMaxAge, "How long the allocation history of IPs and CIDR blocks is kept (e.g., 24h). 0 or negative values will be interpreted as the default value.")
+	fs.IntVar(&o.HistoryMaxRecords, "history-max-records", daemon.DefaultHistoryMaxRecords, "Maximum number of allocation history records kept, the oldest records are pruned first. 0 or negative values will be interpreted as the default value.")
+
+	fs = fss.FlagSet("store snapshots")
+	fs.StringVar(&o.SnapshotDir, "snapshot-dir", "", "Directory of the store snapshots, a snapshots directory next to --db-path if empty. The newest valid snapshot is restored at startup if the store fails its integrity check.")
+	fs.DurationVar(&o.SnapshotInterval, "snapshot-interval", 0, "Interval of the store snapshots written to --snapshot-dir (e.g., 1h). Periodic snapshots are disabled if 0 or negative.")
+	fs.IntVar(&o.SnapshotRetention, "snapshot-retention", daemon.DefaultSnapshotRetention, "Number of snapshots kept in --snapshot-dir, the oldest snapshots are deleted first. 0 or negative values will be interpreted as the default value.")
+	fs.BoolVar(&o.RebuildState, "rebuild-state", false, "Rebuild the store state from the NodeNetworkConfig and the running pods of the node at startup. The state is always rebuilt if the store was lost or restored from a snapshot.")
+
+	fs = fss.FlagSet("metrics")
+	fs.StringVar(&o.MetricsBindAddress, "metrics-bind-address", "", "The TCP address (e.g., 127.0.0.1:9990) to serve Prometheus metrics on. The metrics listener is disabled if empty.")
+
+	fs = fss.FlagSet("tracing")
+	fs.StringVar(&o.OTLPEndpoint, "otlp-endpoint", "", "The address (e.g., localhost:4317) of an OTLP gRPC collector to export the spans of the gRPC requests to, without TLS. Tracing is disabled if empty.")
 
 	return fss
 }
@@ -64,6 +109,42 @@ func (o *daemonOptions) applyTo(cfg *daemon.Config) error {
 	cfg.SocketPath = o.SocketPath
 	cfg.DrainingExpiration = o.DrainingExpiration
 	cfg.SustainedLowUtilizationDuration = o.SustainedLowUtilizationDuration
+	cfg.UtilizationReportInterval = o.UtilizationReportInterval
+	if err := daemon.ValidateScalingPolicyName(o.ScalingPolicy); err != nil {
+		return fmt.Errorf("invalid --scaling-policy: %w", err)
+	}
+	for network, policy := range o.NetworkScalingPolicies {
+		if err := daemon.ValidateScalingPolicyName(policy); err != nil {
+			return fmt.Errorf("invalid --network-scaling-policies entry for network %q: %w", network, err)
+		}
+	}
+	cfg.ScalingPolicy = o.ScalingPolicy
+	cfg.NetworkScalingPolicies = o.NetworkScalingPolicies
+	cfg.MinFreeIPs = o.MinFreeIPs
+	cfg.RateWindow = o.RateWindow
+	cfg.RateLeadTime = o.RateLeadTime
+	cfg.NetworkConfigFile = o.NetworkConfigFile
+	cfg.NetworkConfigReloadInterval = o.NetworkConfigReloadInterval
+	for _, name := range o.CIDRSources {
+		if err := daemon.ValidateCIDRSourceName(name); err != nil {
+			return fmt.Errorf("invalid --cidr-sources: %w", err)
+		}
+	}
+	cfg.CIDRSources = o.CIDRSources
+	cfg.ClusterCIDR = o.ClusterCIDR
+	cfg.CIDRFile = o.CIDRFile
+	cfg.CIDRFileReloadInterval = o.CIDRFileReloadInterval
+	cfg.GCInterval = o.GCInterval
+	cfg.GCCheckNetns = o.GCCheckNetns
+	cfg.GCDryRun = o.GCDryRun
+	cfg.HistoryMaxAge = o.HistoryMaxAge
+	cfg.HistoryMaxRecords = o.HistoryMaxRecords
+	cfg.SnapshotDir = o.SnapshotDir
+	cfg.SnapshotInterval = o.SnapshotInterval
+	cfg.SnapshotRetention = o.SnapshotRetention
+	cfg.RebuildState = o.RebuildState
+	cfg.MetricsBindAddress = o.MetricsBindAddress
+	cfg.OTLPEndpoint = o.OTLPEndpoint
 
 	return nil
 }
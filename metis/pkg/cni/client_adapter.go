@@ -41,3 +41,15 @@ func (a *directClientAdapter) DeallocatePodIP(ctx context.Context, in *pb.Deallo
 func (a *directClientAdapter) CheckPodIP(ctx context.Context, in *pb.CheckPodIPRequest, _ ...grpc.CallOption) (*pb.CheckPodIPResponse, error) {
 	return a.engine.CheckPodIP(ctx, in)
 }
+
+func (a *directClientAdapter) ReleaseStalePodIPs(ctx context.Context, in *pb.ReleaseStalePodIPsRequest, _ ...grpc.CallOption) (*pb.ReleaseStalePodIPsResponse, error) {
+	return a.engine.ReleaseStalePodIPs(ctx, in)
+}
+
+func (a *directClientAdapter) GetNetworkStatus(ctx context.Context, in *pb.GetNetworkStatusRequest, _ ...grpc.CallOption) (*pb.GetNetworkStatusResponse, error) {
+	return a.engine.GetNetworkStatus(ctx, in)
+}
+
+func (a *directClientAdapter) ReportIPConflict(ctx context.Context, in *pb.ReportIPConflictRequest, _ ...grpc.CallOption) (*pb.ReportIPConflictResponse, error) {
+	return a.engine.ReportIPConflict(ctx, in)
+}
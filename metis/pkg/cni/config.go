@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/containernetworking/cni/pkg/types"
 	"k8s.io/metis/pkg"
@@ -64,3 +65,30 @@ func loadK8sArgs(args string) (*K8sArgs, error) {
 	}
 	return k8sArgs, nil
 }
+
+// requestedIPs returns the IPv4 and IPv6 addresses requested in the IP
+// argument of k8sArgs, if any.
+func requestedIPs(k8sArgs *K8sArgs) (string, string, error) {
+	if k8sArgs == nil || k8sArgs.IP == "" {
+		return "", "", nil
+	}
+	var ipv4, ipv6 string
+	for _, s := range strings.Split(string(k8sArgs.IP), ",") {
+		ip := net.ParseIP(strings.TrimSpace(s))
+		if ip == nil {
+			return "", "", fmt.Errorf("invalid requested IP %q", s)
+		}
+		if ip.To4() != nil {
+			if ipv4 != "" {
+				return "", "", fmt.Errorf("more than one IPv4 address requested: %s", k8sArgs.IP)
+			}
+			ipv4 = ip.String()
+		} else {
+			if ipv6 != "" {
+				return "", "", fmt.Errorf("more than one IPv6 address requested: %s", k8sArgs.IP)
+			}
+			ipv6 = ip.String()
+		}
+	}
+	return ipv4, ipv6, nil
+}
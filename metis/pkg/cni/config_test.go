@@ -223,3 +223,34 @@ func TestLoadK8sArgs(t *testing.T) {
 		t.Errorf("expected error with invalid format, got nil")
 	}
 }
+
+func TestRequestedIPs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     string
+		wantIPv4 string
+		wantIPv6 string
+		wantErr  bool
+	}{
+		{name: "None", args: "K8S_POD_NAME=test-pod;K8S_POD_NAMESPACE=test-ns"},
+		{name: "IPv4", args: "K8S_POD_NAME=test-pod;IP=10.240.0.10", wantIPv4: "10.240.0.10"},
+		{name: "DualStack", args: "IP=2001:db8::a,10.240.0.10", wantIPv4: "10.240.0.10", wantIPv6: "2001:db8::a"},
+		{name: "Invalid", args: "IP=10.240.0.300", wantErr: true},
+		{name: "DuplicateFamily", args: "IP=10.240.0.10,10.240.0.11", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			k8sArgs, err := loadK8sArgs(tc.args)
+			if err != nil {
+				t.Fatalf("loadK8sArgs failed: %v", err)
+			}
+			ipv4, ipv6, err := requestedIPs(k8sArgs)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %t, got %v", tc.wantErr, err)
+			}
+			if ipv4 != tc.wantIPv4 || ipv6 != tc.wantIPv6 {
+				t.Errorf("expected requested IPs %q and %q, got %q and %q", tc.wantIPv4, tc.wantIPv6, ipv4, ipv6)
+			}
+		})
+	}
+}
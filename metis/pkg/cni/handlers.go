@@ -17,7 +17,6 @@ limitations under the License.
 package cni
 
 import (
-	"context"
 	"fmt"
 	"net"
 
@@ -25,7 +24,10 @@ import (
 	"github.com/containernetworking/cni/pkg/types"
 	current "github.com/containernetworking/cni/pkg/types/100"
 
+	"google.golang.org/grpc/status"
+
 	pb "k8s.io/metis/api/adaptiveipam/v1"
+	"k8s.io/metis/pkg"
 )
 
 func (p *Plugin) CmdAdd(args *skel.CmdArgs) error {
@@ -67,6 +69,7 @@ func (p *Plugin) cmdAdd(args *skel.CmdArgs) (*current.Result, error) {
 			InterfaceName:  args.IfName,
 			ContainerId:    args.ContainerID,
 			InitialPodCidr: ipNet.String(),
+			Netns:          args.Netns,
 		}
 
 		if bits == 32 && req.Ipv4Config == nil {
@@ -81,16 +84,21 @@ func (p *Plugin) cmdAdd(args *skel.CmdArgs) (*current.Result, error) {
 		req.PodNamespace = string(session.k8sArgs.K8S_POD_NAMESPACE)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
-	defer cancel()
+	requestedIPv4, requestedIPv6, err := requestedIPs(session.k8sArgs)
+	if err != nil {
+		return nil, fmt.Errorf("metis cni add: %w", err)
+	}
+	if req.Ipv4Config != nil {
+		req.Ipv4Config.RequestedIp = requestedIPv4
+	}
+	if req.Ipv6Config != nil {
+		req.Ipv6Config.RequestedIp = requestedIPv6
+	}
 
-	session.logger.Info("AllocatePodIP request", "req", req)
-	resp, err := session.client.AllocatePodIP(ctx, req)
+	resp, err := p.allocatePodIP(session, req)
 	if err != nil {
-		session.logger.Info("AllocatePodIP failed", "err", err)
-		return nil, fmt.Errorf("metis cni add: allocation via daemon failed: %w", err)
+		return nil, err
 	}
-	session.logger.Info("AllocatePodIP response", "resp", resp)
 
 	result, err := toCNIResult(resp, session.pluginConf, args)
 	if err != nil {
@@ -112,7 +120,7 @@ func (p *Plugin) cmdDel(args *skel.CmdArgs) error {
 	}
 	defer session.close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	ctx, cancel := session.rpcContext()
 	defer cancel()
 
 	req := &pb.DeallocatePodIPRequest{
@@ -130,7 +138,7 @@ func (p *Plugin) cmdDel(args *skel.CmdArgs) error {
 	_, err = session.client.DeallocatePodIP(ctx, req)
 	if err != nil {
 		session.logger.Info("DeallocatePodIP failed", "err", err)
-		return fmt.Errorf("metis cni delete: deallocation via daemon failed: %w", err)
+		return daemonError(err, "metis cni delete: deallocation via daemon failed")
 	}
 
 	session.logger.Info("Successfully deallocated IP")
@@ -148,7 +156,7 @@ func (p *Plugin) cmdCheck(args *skel.CmdArgs) error {
 	}
 	defer session.close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+	ctx, cancel := session.rpcContext()
 	defer cancel()
 
 	req := &pb.CheckPodIPRequest{
@@ -166,13 +174,118 @@ func (p *Plugin) cmdCheck(args *skel.CmdArgs) error {
 	_, err = session.client.CheckPodIP(ctx, req)
 	if err != nil {
 		session.logger.Info("CheckPodIP failed", "err", err)
-		return fmt.Errorf("metis cni check: check via daemon failed: %w", err)
+		return daemonError(err, "metis cni check: check via daemon failed")
 	}
 
 	session.logger.Info("Successfully checked IP")
 	return nil
 }
 
+func (p *Plugin) CmdGC(args *skel.CmdArgs) error {
+	return p.cmdGC(args)
+}
+
+// cmdGC releases the IPs of all attachments of the network except the valid
+// attachments passed by the container runtime.
+func (p *Plugin) cmdGC(args *skel.CmdArgs) error {
+	session, err := p.prepare(args, "GC")
+	if err != nil {
+		return fmt.Errorf("metis cni gc: prepare failed: %w", err)
+	}
+	defer session.close()
+
+	ctx, cancel := session.rpcContext()
+	defer cancel()
+
+	req := &pb.ReleaseStalePodIPsRequest{
+		// Network is left empty to use the daemon's default VPC network for now.
+		// TODO(https://github.com/kubernetes/cloud-provider-gcp/issues/1111): Support multi-network by passing the correct VPC network name.
+	}
+	for _, a := range session.pluginConf.ValidAttachments {
+		req.ValidAttachments = append(req.ValidAttachments, &pb.PodAttachment{
+			ContainerId:   a.ContainerID,
+			InterfaceName: a.IfName,
+		})
+	}
+
+	session.logger.Info("ReleaseStalePodIPs request", "validAttachments", len(req.ValidAttachments))
+	resp, err := session.client.ReleaseStalePodIPs(ctx, req)
+	if err != nil {
+		session.logger.Info("ReleaseStalePodIPs failed", "err", err)
+		return daemonError(err, "metis cni gc: release via daemon failed")
+	}
+
+	session.logger.Info("Successfully released stale IPs", "releasedAttachments", resp.ReleasedAttachments)
+	return nil
+}
+
+func (p *Plugin) CmdStatus(args *skel.CmdArgs) error {
+	return p.cmdStatus(args)
+}
+
+// cmdStatus reports whether the plugin can service ADD requests. The daemon
+// adds capacity on demand, so the plugin is ready as long as the daemon
+// responds. Without the daemon, it is only ready while the store has free IPs
+// of each configured IP family.
+func (p *Plugin) cmdStatus(args *skel.CmdArgs) error {
+	session, err := p.prepare(args, "STATUS")
+	if err != nil {
+		return fmt.Errorf("metis cni status: prepare failed: %w", err)
+	}
+	defer session.close()
+
+	ctx, cancel := session.rpcContext()
+	defer cancel()
+
+	resp, err := session.client.GetNetworkStatus(ctx, &pb.GetNetworkStatusRequest{})
+	if err != nil {
+		session.logger.Info("GetNetworkStatus failed", "err", err)
+		return types.NewError(errPluginNotAvailable, "metis daemon is not available", err.Error())
+	}
+	session.logger.Info("GetNetworkStatus response", "resp", resp, "direct", session.direct)
+	if !session.direct {
+		return nil
+	}
+
+	var wantIPv4, wantIPv6 bool
+	for _, rangeSet := range session.pluginConf.IPAM.Ranges {
+		if len(rangeSet) == 0 || rangeSet[0].Subnet.IP == nil {
+			continue
+		}
+		if rangeSet[0].Subnet.IP.To4() != nil {
+			wantIPv4 = true
+		} else {
+			wantIPv6 = true
+		}
+	}
+	if (wantIPv4 && resp.AvailableIpv4 == 0) || (wantIPv6 && resp.AvailableIpv6 == 0) || (!wantIPv4 && !wantIPv6 && resp.AvailableIpv4+resp.AvailableIpv6 == 0) {
+		return types.NewError(errPluginNotAvailable, "metis daemon is not available and no IPs are free",
+			fmt.Sprintf("%d IPv4 and %d IPv6 addresses available", resp.AvailableIpv4, resp.AvailableIpv6))
+	}
+	return nil
+}
+
+// cniErrorCodes maps the reasons of the errors returned by the daemon to CNI
+// error codes. Errors with other reasons are reported as internal errors.
+var cniErrorCodes = map[string]uint{
+	pkg.ReasonExhausted:          types.ErrTryAgainLater,
+	pkg.ReasonStoreBusy:          types.ErrTryAgainLater,
+	pkg.ReasonAddressUnavailable: types.ErrTryAgainLater,
+	pkg.ReasonInvalidCIDR:        types.ErrInvalidNetworkConfig,
+	pkg.ReasonInvalidArgument:    types.ErrInvalidEnvironmentVariables,
+	pkg.ReasonNotAllocated:       types.ErrUnknownContainer,
+}
+
+// daemonError returns the error of a failed daemon RPC, as a CNI error with
+// the code of its reason if the daemon returned one.
+func daemonError(err error, msg string) error {
+	code, ok := cniErrorCodes[pkg.ErrorReason(err)]
+	if !ok {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	return types.NewError(code, msg, status.Convert(err).Message())
+}
+
 func buildIPConfig(ipConfig *pb.PodIP) (*current.IPConfig, net.IP, error) {
 	if ipConfig == nil {
 		return nil, nil, nil
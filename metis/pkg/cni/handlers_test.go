@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -31,14 +32,18 @@ import (
 	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
 	nncfake "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned/fake"
 	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
 	current "github.com/containernetworking/cni/pkg/types/100"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	pb "k8s.io/metis/api/adaptiveipam/v1"
+	"k8s.io/metis/pkg"
 	"k8s.io/metis/pkg/daemon"
 )
 
@@ -47,6 +52,9 @@ type mockAdaptiveIpamClient struct {
 	allocatePodIPFunc   func(ctx context.Context, in *pb.AllocatePodIPRequest) (*pb.AllocatePodIPResponse, error)
 	deallocatePodIPFunc func(ctx context.Context, in *pb.DeallocatePodIPRequest) (*pb.DeallocatePodIPResponse, error)
 	checkPodIPFunc      func(ctx context.Context, in *pb.CheckPodIPRequest) (*pb.CheckPodIPResponse, error)
+	releaseStaleFunc    func(ctx context.Context, in *pb.ReleaseStalePodIPsRequest) (*pb.ReleaseStalePodIPsResponse, error)
+	networkStatusFunc   func(ctx context.Context, in *pb.GetNetworkStatusRequest) (*pb.GetNetworkStatusResponse, error)
+	reportConflictFunc  func(ctx context.Context, in *pb.ReportIPConflictRequest) (*pb.ReportIPConflictResponse, error)
 }
 
 func (m *mockAdaptiveIpamClient) AllocatePodIP(ctx context.Context, in *pb.AllocatePodIPRequest, _ ...grpc.CallOption) (*pb.AllocatePodIPResponse, error) {
@@ -70,6 +78,27 @@ func (m *mockAdaptiveIpamClient) CheckPodIP(ctx context.Context, in *pb.CheckPod
 	return nil, fmt.Errorf("unimplemented")
 }
 
+func (m *mockAdaptiveIpamClient) ReleaseStalePodIPs(ctx context.Context, in *pb.ReleaseStalePodIPsRequest, _ ...grpc.CallOption) (*pb.ReleaseStalePodIPsResponse, error) {
+	if m.releaseStaleFunc != nil {
+		return m.releaseStaleFunc(ctx, in)
+	}
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (m *mockAdaptiveIpamClient) GetNetworkStatus(ctx context.Context, in *pb.GetNetworkStatusRequest, _ ...grpc.CallOption) (*pb.GetNetworkStatusResponse, error) {
+	if m.networkStatusFunc != nil {
+		return m.networkStatusFunc(ctx, in)
+	}
+	return nil, fmt.Errorf("unimplemented")
+}
+
+func (m *mockAdaptiveIpamClient) ReportIPConflict(ctx context.Context, in *pb.ReportIPConflictRequest, _ ...grpc.CallOption) (*pb.ReportIPConflictResponse, error) {
+	if m.reportConflictFunc != nil {
+		return m.reportConflictFunc(ctx, in)
+	}
+	return nil, fmt.Errorf("unimplemented")
+}
+
 func TestCmdAdd(t *testing.T) {
 	cases := []struct {
 		name           string
@@ -311,6 +340,74 @@ func TestCmdCheck(t *testing.T) {
 	}
 }
 
+func TestCmdGC(t *testing.T) {
+	mockClient := &mockAdaptiveIpamClient{}
+	plugin := NewPlugin(
+		WithClientFunc(func(_ string) (pb.AdaptiveIpamClient, *grpc.ClientConn, error) {
+			return mockClient, nil, nil
+		}),
+		WithLogFile(filepath.Join(t.TempDir(), "metis-cni.log")),
+	)
+
+	var got *pb.ReleaseStalePodIPsRequest
+	mockClient.releaseStaleFunc = func(_ context.Context, in *pb.ReleaseStalePodIPsRequest) (*pb.ReleaseStalePodIPsResponse, error) {
+		got = in
+		return &pb.ReleaseStalePodIPsResponse{}, nil
+	}
+
+	args := &skel.CmdArgs{
+		StdinData: []byte(`{"cniVersion": "1.1.0", "name": "test-net", "type": "metis", "cni.dev/valid-attachments": [{"containerID": "c1", "ifname": "eth0"}, {"containerID": "c2", "ifname": "eth1"}]}`),
+	}
+	if err := plugin.cmdGC(args); err != nil {
+		t.Fatalf("cmdGC failed: %v", err)
+	}
+	if got == nil {
+		t.Fatal("ReleaseStalePodIPs was not called")
+	}
+	if len(got.ValidAttachments) != 2 || got.ValidAttachments[1].ContainerId != "c2" || got.ValidAttachments[1].InterfaceName != "eth1" {
+		t.Errorf("Unexpected valid attachments %v", got.ValidAttachments)
+	}
+}
+
+func TestCmdStatus(t *testing.T) {
+	cases := []struct {
+		name      string
+		statusErr error
+		wantCode  uint
+	}{
+		{name: "DaemonReady"},
+		{name: "DaemonNotResponding", statusErr: fmt.Errorf("connection refused"), wantCode: errPluginNotAvailable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := &mockAdaptiveIpamClient{
+				// The daemon adds capacity on demand, so no free IPs are needed.
+				networkStatusFunc: func(_ context.Context, _ *pb.GetNetworkStatusRequest) (*pb.GetNetworkStatusResponse, error) {
+					return &pb.GetNetworkStatusResponse{}, tc.statusErr
+				},
+			}
+			plugin := NewPlugin(
+				WithClientFunc(func(_ string) (pb.AdaptiveIpamClient, *grpc.ClientConn, error) {
+					return mockClient, nil, nil
+				}),
+				WithLogFile(filepath.Join(t.TempDir(), "metis-cni.log")),
+			)
+
+			err := plugin.cmdStatus(&skel.CmdArgs{StdinData: []byte(`{"cniVersion": "1.1.0", "name": "test-net", "type": "metis"}`)})
+			if tc.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("cmdStatus failed: %v", err)
+				}
+				return
+			}
+			cniErr, ok := err.(*types.Error)
+			if !ok || cniErr.Code != tc.wantCode {
+				t.Errorf("Expected CNI error code %d, got %v", tc.wantCode, err)
+			}
+		})
+	}
+}
+
 func TestCniWithActualDaemon(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "metis-e2e-")
 	if err != nil {
@@ -484,6 +581,168 @@ func TestCmdAdd_CleanStdout(t *testing.T) {
 	}
 }
 
+func TestCmdAdd_DaemonErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		wantCode uint
+	}{
+		{name: "Exhausted", err: pkg.StatusErrorf(codes.ResourceExhausted, pkg.ReasonExhausted, "no IPs"), wantCode: types.ErrTryAgainLater},
+		{name: "Store busy", err: pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "database is locked"), wantCode: types.ErrTryAgainLater},
+		{name: "Invalid CIDR", err: pkg.StatusErrorf(codes.InvalidArgument, pkg.ReasonInvalidCIDR, "invalid initial_pod_cidr"), wantCode: types.ErrInvalidNetworkConfig},
+		{name: "No reason", err: status.Error(codes.Unknown, "boom"), wantCode: types.ErrInternal},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requestID string
+			mockClient := &mockAdaptiveIpamClient{
+				allocatePodIPFunc: func(ctx context.Context, _ *pb.AllocatePodIPRequest) (*pb.AllocatePodIPResponse, error) {
+					requestID = pkg.RequestIDFromContext(ctx)
+					return nil, tc.err
+				},
+			}
+			plugin := NewPlugin(
+				WithClientFunc(func(_ string) (pb.AdaptiveIpamClient, *grpc.ClientConn, error) {
+					return mockClient, nil, nil
+				}),
+				WithLogFile(filepath.Join(t.TempDir(), "metis-cni.log")),
+			)
+
+			_, err := plugin.cmdAdd(&skel.CmdArgs{
+				ContainerID: "test-container-id",
+				IfName:      "eth0",
+				StdinData:   []byte(`{"cniVersion": "1.1.0", "name": "test-net", "type": "metis", "ipam": {"type": "metis", "ranges": [[{"subnet": "10.240.0.0/24"}]]}}`),
+			})
+			if err == nil {
+				t.Fatal("Expected cmdAdd to fail")
+			}
+			// The skel package reports errors that are no CNI errors as internal errors.
+			code := types.ErrInternal
+			var cniErr *types.Error
+			if errors.As(err, &cniErr) {
+				code = cniErr.Code
+			}
+			if code != tc.wantCode {
+				t.Errorf("Expected CNI error code %d, got %d (%v)", tc.wantCode, code, err)
+			}
+			if requestID == "" {
+				t.Error("Expected the request ID to be sent to the daemon")
+			}
+		})
+	}
+}
+
+func TestCmdAdd_ConflictProbe(t *testing.T) {
+	const stdinData = `{"cniVersion": "0.4.0", "name": "test-net", "type": "metis", "ipam": {"type": "metis", "ranges": [[{"subnet": "10.240.0.0/24"}],[{"subnet": "2600:1900::/112"}]]},
+		"conflictProbe": {"ipv4Command": ["arping", "-D"], "ipv6Command": ["dad"]}}`
+	cases := []struct {
+		name string
+		// inUse are the addresses the probe reports in use.
+		inUse            map[string]bool
+		probeErr         error
+		expectErr        bool
+		expectedIPs      []string
+		expectedCalls    int
+		expectedReported []string
+	}{
+		{
+			name:          "No conflict",
+			expectedIPs:   []string{"10.240.0.2", "2600:1900::2"},
+			expectedCalls: 1,
+		},
+		{
+			name:             "IPv4 conflict is retried",
+			inUse:            map[string]bool{"10.240.0.2": true, "10.240.0.3": true},
+			expectedIPs:      []string{"10.240.0.4", "2600:1900::2"},
+			expectedCalls:    3,
+			expectedReported: []string{"10.240.0.2", "10.240.0.3"},
+		},
+		{
+			name:          "Failed probe hands out the IPs",
+			probeErr:      fmt.Errorf("arping not found"),
+			expectedIPs:   []string{"10.240.0.2", "2600:1900::2"},
+			expectedCalls: 1,
+		},
+		{
+			name:             "Retries are bounded",
+			inUse:            map[string]bool{"2600:1900::2": true, "2600:1900::3": true, "2600:1900::4": true, "2600:1900::5": true},
+			expectErr:        true,
+			expectedCalls:    maxConflictRetries + 1,
+			expectedReported: []string{"2600:1900::2", "2600:1900::3", "2600:1900::4", "2600:1900::5"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The mock daemon hands out the next IP of a family once the
+			// current one was reported.
+			next := map[string]int{"ipv4": 2, "ipv6": 2}
+			var reported []string
+			calls := 0
+			mockClient := &mockAdaptiveIpamClient{
+				allocatePodIPFunc: func(_ context.Context, _ *pb.AllocatePodIPRequest) (*pb.AllocatePodIPResponse, error) {
+					calls++
+					return &pb.AllocatePodIPResponse{
+						Ipv4: &pb.PodIP{IpAddress: fmt.Sprintf("10.240.0.%d", next["ipv4"]), Cidr: "10.240.0.0/24"},
+						Ipv6: &pb.PodIP{IpAddress: fmt.Sprintf("2600:1900::%d", next["ipv6"]), Cidr: "2600:1900::/112"},
+					}, nil
+				},
+				reportConflictFunc: func(_ context.Context, in *pb.ReportIPConflictRequest) (*pb.ReportIPConflictResponse, error) {
+					if in.ContainerId != "test-container-id" || in.InterfaceName != "eth0" || in.PodName != "test-pod" {
+						t.Errorf("Unexpected ReportIPConflict request %v", in)
+					}
+					reported = append(reported, in.IpAddress)
+					if net.ParseIP(in.IpAddress).To4() != nil {
+						next["ipv4"]++
+					} else {
+						next["ipv6"]++
+					}
+					return &pb.ReportIPConflictResponse{}, nil
+				},
+			}
+			plugin := NewPlugin(
+				WithClientFunc(func(_ string) (pb.AdaptiveIpamClient, *grpc.ClientConn, error) {
+					return mockClient, nil, nil
+				}),
+				WithLogFile(filepath.Join(t.TempDir(), "metis-cni.log")),
+				WithConflictProbeFunc(func(_ context.Context, command []string, address string) (bool, error) {
+					if (command[0] == "arping") != (net.ParseIP(address).To4() != nil) {
+						t.Errorf("Probe command %v used for address %s", command, address)
+					}
+					return tc.inUse[address], tc.probeErr
+				}),
+			)
+
+			result, err := plugin.cmdAdd(&skel.CmdArgs{
+				ContainerID: "test-container-id",
+				Netns:       "/var/run/netns/test",
+				IfName:      "eth0",
+				Args:        "K8S_POD_NAME=test-pod;K8S_POD_NAMESPACE=test-ns",
+				StdinData:   []byte(stdinData),
+			})
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("cmdAdd error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if calls != tc.expectedCalls {
+				t.Errorf("Expected %d AllocatePodIP calls, got %d", tc.expectedCalls, calls)
+			}
+			if fmt.Sprint(reported) != fmt.Sprint(tc.expectedReported) {
+				t.Errorf("Expected reported conflicts %v, got %v", tc.expectedReported, reported)
+			}
+			if tc.expectErr {
+				return
+			}
+			var got []string
+			for _, ip := range result.IPs {
+				got = append(got, ip.Address.IP.String())
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.expectedIPs) {
+				t.Errorf("Expected IPs %v, got %v", tc.expectedIPs, got)
+			}
+		})
+	}
+}
+
 func TestDirectFallback_DaemonUnavailable(t *testing.T) {
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "metis_fallback_test.sqlite")
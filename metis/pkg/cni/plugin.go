@@ -70,6 +70,13 @@ func WithLogFile(path string) Option {
 	}
 }
 
+// WithConflictProbeFunc overrides how the conflict probe commands are run.
+func WithConflictProbeFunc(fn func(ctx context.Context, command []string, address string) (bool, error)) Option {
+	return func(p *Plugin) {
+		p.probeFunc = fn
+	}
+}
+
 // NewPlugin creates a new Plugin with functional options.
 func NewPlugin(opts ...Option) *Plugin {
 	p := &Plugin{
@@ -77,6 +84,7 @@ func NewPlugin(opts ...Option) *Plugin {
 		socketPath:    pkg.DefaultSockPath,
 		dbPath:        pkg.DefaultDBPath,
 		logFile:       pkg.DefaultCNILogPath,
+		probeFunc:     runConflictProbe,
 	}
 	for _, opt := range opts {
 		opt(p)
@@ -91,6 +99,17 @@ type pluginSession struct {
 	conn       *grpc.ClientConn
 	logger     logr.Logger
 	cleanup    func()
+	// direct is set if the daemon is unavailable and the session uses the
+	// local IPAM engine instead.
+	direct bool
+	// requestID is sent with each daemon RPC of the session, so that the log
+	// lines of the daemon can be correlated with the ones of the plugin.
+	requestID string
+}
+
+// rpcContext returns the context of a daemon RPC of the session.
+func (s *pluginSession) rpcContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(pkg.WithRequestID(context.Background(), s.requestID), defaultRPCTimeout)
 }
 
 func (s *pluginSession) close() {
@@ -113,7 +132,8 @@ func (p *Plugin) prepare(args *skel.CmdArgs, command string) (*pluginSession, er
 		logFile = conf.LogFile
 	}
 
-	logger, cleanup, err := p.setupLogging(args, command, logFile)
+	requestID := pkg.NewRequestID()
+	logger, cleanup, err := p.setupLogging(args, command, logFile, requestID)
 	if err != nil {
 		return nil, err
 	}
@@ -153,8 +173,9 @@ func (p *Plugin) prepare(args *skel.CmdArgs, command string) (*pluginSession, er
 	}
 
 	sessionCleanup := cleanup
+	direct := clientErr != nil
 
-	if clientErr != nil {
+	if direct {
 		logger.Info("Daemon unavailable, falling back to direct local IPAM engine", "socketPath", socketPath, "dbPath", dbPath, "err", clientErr)
 		ctx := context.Background()
 		storeInstance, err := store.NewStore(ctx, logger, dbPath)
@@ -179,10 +200,12 @@ func (p *Plugin) prepare(args *skel.CmdArgs, command string) (*pluginSession, er
 		conn:       conn,
 		logger:     logger,
 		cleanup:    sessionCleanup,
+		direct:     direct,
+		requestID:  requestID,
 	}, nil
 }
 
-func (p *Plugin) setupLogging(args *skel.CmdArgs, command string, logFile string) (logger logr.Logger, cleanup func(), err error) {
+func (p *Plugin) setupLogging(args *skel.CmdArgs, command string, logFile string, requestID string) (logger logr.Logger, cleanup func(), err error) {
 	klog.LogToStderr(false)
 	if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
 		return logr.Logger{}, nil, fmt.Errorf("failed to create log directory for %s: %v", logFile, err)
@@ -193,7 +216,7 @@ func (p *Plugin) setupLogging(args *skel.CmdArgs, command string, logFile string
 	}
 	klog.SetOutput(f)
 
-	logger = klog.Background().WithName("metis").WithName("cni").WithValues("containerID", args.ContainerID, "command", command)
+	logger = klog.Background().WithName("metis").WithName("cni").WithValues("containerID", args.ContainerID, "command", command, "requestID", requestID)
 	logger.Info("Received CNI request", "netns", args.Netns, "ifName", args.IfName, "args", args.Args, "path", args.Path, "stdinData", string(args.StdinData))
 
 	cleanup = func() {
@@ -0,0 +1,162 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cni
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	pb "k8s.io/metis/api/adaptiveipam/v1"
+)
+
+const (
+	// maxConflictRetries is the number of times an allocation is retried
+	// after one of its IPs was found in use by another host.
+	maxConflictRetries = 3
+	// defaultConflictProbeTimeout bounds a conflict probe command if the
+	// configuration sets no timeout.
+	defaultConflictProbeTimeout = 3 * time.Second
+	// conflictProbeExitCode is the exit code of a conflict probe command if the
+	// address is in use, like the one of "arping -D".
+	conflictProbeExitCode = 1
+)
+
+// allocatePodIP allocates the IPs of the pod. If a conflict probe is
+// configured, each allocated IP is probed, and an IP in use by another host is
+// reported to the daemon, which quarantines and releases it. The allocation is
+// then retried, which keeps the IPs without conflict and allocates new ones
+// for the conflicting IPs.
+func (p *Plugin) allocatePodIP(session *pluginSession, req *pb.AllocatePodIPRequest) (*pb.AllocatePodIPResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := session.allocatePodIP(req)
+		if err != nil {
+			return nil, err
+		}
+		conflicts := p.probeConflicts(session, resp)
+		if len(conflicts) == 0 {
+			return resp, nil
+		}
+		for _, conflict := range conflicts {
+			if err := session.reportIPConflict(req, conflict); err != nil {
+				return nil, err
+			}
+		}
+		if attempt == maxConflictRetries {
+			return nil, fmt.Errorf("metis cni add: allocated IPs are in use by other hosts after %d retries, last %s", maxConflictRetries, strings.Join(conflicts, ", "))
+		}
+		session.logger.Info("Retrying allocation after IP conflict", "conflicts", conflicts, "attempt", attempt+1)
+	}
+}
+
+func (s *pluginSession) allocatePodIP(req *pb.AllocatePodIPRequest) (*pb.AllocatePodIPResponse, error) {
+	ctx, cancel := s.rpcContext()
+	defer cancel()
+
+	s.logger.Info("AllocatePodIP request", "req", req)
+	resp, err := s.client.AllocatePodIP(ctx, req)
+	if err != nil {
+		s.logger.Info("AllocatePodIP failed", "err", err)
+		return nil, daemonError(err, "metis cni add: allocation via daemon failed")
+	}
+	s.logger.Info("AllocatePodIP response", "resp", resp)
+	return resp, nil
+}
+
+func (s *pluginSession) reportIPConflict(req *pb.AllocatePodIPRequest, address string) error {
+	ctx, cancel := s.rpcContext()
+	defer cancel()
+
+	config := req.Ipv4Config
+	if strings.Contains(address, ":") {
+		config = req.Ipv6Config
+	}
+	conflict := &pb.ReportIPConflictRequest{
+		Network:       req.Network,
+		InterfaceName: config.GetInterfaceName(),
+		ContainerId:   config.GetContainerId(),
+		IpAddress:     address,
+		PodName:       req.PodName,
+		PodNamespace:  req.PodNamespace,
+		Reason:        "duplicate address detected by the CNI conflict probe",
+	}
+	s.logger.Info("ReportIPConflict request", "req", conflict)
+	if _, err := s.client.ReportIPConflict(ctx, conflict); err != nil {
+		s.logger.Info("ReportIPConflict failed", "err", err)
+		return daemonError(err, fmt.Sprintf("metis cni add: reporting conflicting IP %s to daemon failed", address))
+	}
+	return nil
+}
+
+// probeConflicts returns the allocated IPs that the conflict probe found in use
+// by another host. A failed probe is logged and does not count as a conflict.
+func (p *Plugin) probeConflicts(session *pluginSession, resp *pb.AllocatePodIPResponse) []string {
+	probe := session.pluginConf.ConflictProbe
+	if probe == nil {
+		return nil
+	}
+	timeout := defaultConflictProbeTimeout
+	if probe.TimeoutSeconds > 0 {
+		timeout = time.Duration(probe.TimeoutSeconds) * time.Second
+	}
+
+	var conflicts []string
+	for _, target := range []struct {
+		ip      *pb.PodIP
+		command []string
+	}{
+		{ip: resp.Ipv4, command: probe.IPv4Command},
+		{ip: resp.Ipv6, command: probe.IPv6Command},
+	} {
+		if target.ip == nil || len(target.command) == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		conflict, err := p.probeFunc(ctx, target.command, target.ip.IpAddress)
+		cancel()
+		if err != nil {
+			session.logger.Info("Conflict probe failed, assuming the IP is free", "ip", target.ip.IpAddress, "err", err)
+			continue
+		}
+		if conflict {
+			session.logger.Info("Conflict probe found the IP in use by another host", "ip", target.ip.IpAddress)
+			conflicts = append(conflicts, target.ip.IpAddress)
+		}
+	}
+	return conflicts
+}
+
+// runConflictProbe runs a conflict probe command with the address appended to
+// its arguments, and reports whether the address is in use.
+func runConflictProbe(ctx context.Context, command []string, address string) (bool, error) {
+	args := append(append([]string{}, command[1:]...), address)
+	// The output must not reach the stdout of the plugin, which carries the
+	// CNI result.
+	out, err := exec.CommandContext(ctx, command[0], args...).CombinedOutput()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == conflictProbeExitCode:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%s: %w: %s", strings.Join(command, " "), err, strings.TrimSpace(string(out)))
+	}
+}
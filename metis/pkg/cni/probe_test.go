@@ -0,0 +1,62 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cni
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunConflictProbe(t *testing.T) {
+	tests := []struct {
+		name           string
+		command        []string
+		expectConflict bool
+		expectErr      bool
+	}{
+		{
+			name:    "Address free",
+			command: []string{"sh", "-c", `test "$0" = 10.0.0.2`},
+		},
+		{
+			name:           "Address in use",
+			command:        []string{"sh", "-c", "exit 1"},
+			expectConflict: true,
+		},
+		{
+			name:      "Probe error",
+			command:   []string{"sh", "-c", "exit 2"},
+			expectErr: true,
+		},
+		{
+			name:      "Missing command",
+			command:   []string{"/nonexistent/arping"},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			conflict, err := runConflictProbe(context.Background(), tc.command, "10.0.0.2")
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("runConflictProbe error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if conflict != tc.expectConflict {
+				t.Errorf("Expected conflict %v, got %v", tc.expectConflict, conflict)
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package cni
 
 import (
+	"context"
+
 	"github.com/containernetworking/cni/pkg/types"
 	"google.golang.org/grpc"
 	pb "k8s.io/metis/api/adaptiveipam/v1"
@@ -39,6 +41,10 @@ type IPAM struct {
 	Routes []Route         `json:"routes,omitempty"`
 }
 
+// errPluginNotAvailable is the well-known error code of CNI spec 1.1 for a
+// plugin that cannot service ADD requests.
+const errPluginNotAvailable uint = 50
+
 // PluginConf extends standard CNI network configuration.
 type PluginConf struct {
 	types.PluginConf
@@ -46,6 +52,24 @@ type PluginConf struct {
 	DaemonSocket string `json:"daemonSocket,omitempty"`
 	DBPath       string `json:"dbPath,omitempty"`
 	LogFile      string `json:"logFile,omitempty"`
+	// ConflictProbe enables the duplicate address detection of the allocated
+	// IPs before they are handed out to the pod.
+	ConflictProbe *ConflictProbe `json:"conflictProbe,omitempty"`
+}
+
+// ConflictProbe configures the commands probing whether another host on the
+// network already uses an allocated IP, e.g. "arping -D" for IPv4 or a
+// duplicate address detection with ndisc6 for IPv6. The address is appended to
+// the arguments of the command. The command must exit with 0 if the address is
+// free and with 1 if it is in use. Any other result is logged and the address
+// is handed out, so that a broken probe does not block pod creation.
+type ConflictProbe struct {
+	// IPv4Command probes the IPv4 addresses. IPv4 addresses are not probed if empty.
+	IPv4Command []string `json:"ipv4Command,omitempty"`
+	// IPv6Command probes the IPv6 addresses. IPv6 addresses are not probed if empty.
+	IPv6Command []string `json:"ipv6Command,omitempty"`
+	// TimeoutSeconds bounds each run of a command. Defaults to 3 seconds.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
 // K8sArgs contains the standard Kubernetes CNI arguments.
@@ -55,6 +79,9 @@ type K8sArgs struct {
 	K8S_POD_NAME      types.UnmarshallableString `json:"K8S_POD_NAME"`
 	K8S_POD_NAMESPACE types.UnmarshallableString `json:"K8S_POD_NAMESPACE"`
 	// revive:enable:var-naming CNI LoadArgs requires exact match
+	// IP is a comma-separated list of addresses requested for the pod, at
+	// most one per IP family, following the convention of the host-local IPAM.
+	IP types.UnmarshallableString `json:"IP"`
 }
 
 // Plugin holds the runtime configuration and handlers for the CNI plugin.
@@ -63,4 +90,7 @@ type Plugin struct {
 	socketPath    string
 	dbPath        string
 	logFile       string
+	// probeFunc runs a conflict probe command for an address and reports
+	// whether the address is in use.
+	probeFunc func(ctx context.Context, command []string, address string) (bool, error)
 }
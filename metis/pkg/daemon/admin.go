@@ -18,36 +18,502 @@ package daemon
 
 import (
 	"context"
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
+	networkv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/network/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	adminv1 "k8s.io/metis/api/admin/v1"
+	"k8s.io/metis/pkg/store"
 )
 
 // ListCIDRBlocks implements AdminServer.ListCIDRBlocks
-func (s *adaptiveIpamServer) ListCIDRBlocks(ctx context.Context, req *adminv1.ListCIDRBlocksRequest) (*adminv1.AdminTableDumpResponse, error) {
-	headers, results, err := s.store.AdminListCIDRBlocks(ctx, req.Filter)
+func (s *adaptiveIpamServer) ListCIDRBlocks(ctx context.Context, req *adminv1.ListCIDRBlocksRequest) (*adminv1.ListCIDRBlocksResponse, error) {
+	ipFamily, state, err := parseAdminFilters(req.IpFamily, req.State)
 	if err != nil {
 		return nil, err
 	}
-	return buildAdminTableDumpResponse(headers, results), nil
+	page, err := parseAdminPage(req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := store.AdminCIDRBlockFilter{
+		Network:  req.Network,
+		IPFamily: ipFamily,
+		State:    state,
+	}
+	blocks, nextID, err := s.store.AdminListCIDRBlocks(ctx, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &adminv1.ListCIDRBlocksResponse{NextPageToken: adminPageToken(nextID)}
+	for _, b := range blocks {
+		resp.CidrBlocks = append(resp.CidrBlocks, &adminv1.CIDRBlock{
+			Id:           b.ID,
+			Cidr:         b.CIDR,
+			Network:      b.Network,
+			IpFamily:     string(b.IPFamily),
+			TotalIps:     b.TotalIPs,
+			AllocatedIps: b.AllocatedIPs,
+			State:        string(b.State),
+			CreatedAt:    adminTimestamp(b.CreatedAt),
+			UpdatedAt:    adminTimestamp(b.UpdatedAt),
+		})
+	}
+	return resp, nil
 }
 
 // ListIPAddresses implements AdminServer.ListIPAddresses
-func (s *adaptiveIpamServer) ListIPAddresses(ctx context.Context, req *adminv1.ListIPAddressesRequest) (*adminv1.AdminTableDumpResponse, error) {
-	headers, results, err := s.store.AdminListIPAddresses(ctx, req.Filter)
+func (s *adaptiveIpamServer) ListIPAddresses(ctx context.Context, req *adminv1.ListIPAddressesRequest) (*adminv1.ListIPAddressesResponse, error) {
+	ipFamily, state, err := parseAdminFilters(req.IpFamily, req.State)
+	if err != nil {
+		return nil, err
+	}
+	page, err := parseAdminPage(req.PageSize, req.PageToken)
 	if err != nil {
 		return nil, err
 	}
-	return buildAdminTableDumpResponse(headers, results), nil
+
+	filter := store.AdminIPAddressFilter{
+		Network:       req.Network,
+		IPFamily:      ipFamily,
+		State:         state,
+		ContainerID:   req.ContainerId,
+		PodNamespace:  req.PodNamespace,
+		PodName:       req.PodName,
+		AllocatedOnly: req.AllocatedOnly,
+	}
+	addresses, nextID, err := s.store.AdminListIPAddresses(ctx, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &adminv1.ListIPAddressesResponse{NextPageToken: adminPageToken(nextID)}
+	for _, a := range addresses {
+		resp.IpAddresses = append(resp.IpAddresses, &adminv1.IPAddress{
+			Id:            a.ID,
+			Address:       a.Address,
+			CidrBlockId:   a.CIDRBlockID,
+			Network:       a.Network,
+			IpFamily:      string(a.IPFamily),
+			ContainerId:   a.ContainerID,
+			PodName:       a.PodName,
+			PodNamespace:  a.PodNamespace,
+			InterfaceName: a.InterfaceName,
+			IsAllocated:   a.IsAllocated,
+			ReleaseAt:     adminTimestamp(a.ReleaseAt),
+			AllocatedAt:   adminTimestamp(a.AllocatedAt),
+			UpdatedAt:     adminTimestamp(a.UpdatedAt),
+		})
+	}
+	return resp, nil
 }
 
-func buildAdminTableDumpResponse(headers []string, results [][]string) *adminv1.AdminTableDumpResponse {
-	resp := &adminv1.AdminTableDumpResponse{
-		Headers: headers,
+// GarbageCollect implements AdminServer.GarbageCollect
+func (s *adaptiveIpamServer) GarbageCollect(ctx context.Context, req *adminv1.GarbageCollectRequest) (*adminv1.GarbageCollectResponse, error) {
+	if s.gc == nil {
+		return nil, status.Error(codes.Unavailable, "garbage collector is not running")
 	}
-	for _, rowValue := range results {
-		resp.Rows = append(resp.Rows, &adminv1.Row{
-			Values: rowValue,
+	stale, err := s.gc.Collect(ctx, req.DryRun)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "garbage collection failed: %v", err)
+	}
+
+	resp := &adminv1.GarbageCollectResponse{DryRun: req.DryRun}
+	for _, o := range stale {
+		resp.StaleOwners = append(resp.StaleOwners, &adminv1.StaleIPOwner{
+			Network:       o.Network,
+			ContainerId:   o.ContainerID,
+			InterfaceName: o.InterfaceName,
+			PodNamespace:  o.PodNamespace,
+			PodName:       o.PodName,
+			Netns:         o.Netns,
+			Addresses:     o.Addresses,
+			Reason:        o.Reason,
+			AllocatedAt:   adminTimestamp(o.AllocatedAt),
 		})
 	}
-	return resp
+	return resp, nil
+}
+
+// AddIPReservation implements AdminServer.AddIPReservation
+func (s *adaptiveIpamServer) AddIPReservation(ctx context.Context, req *adminv1.AddIPReservationRequest) (*adminv1.AddIPReservationResponse, error) {
+	if _, err := netip.ParseAddr(req.Address); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid address %q", req.Address)
+	}
+	if (req.PodNamespace == "") != (req.PodName == "") {
+		return nil, status.Error(codes.InvalidArgument, "pod_namespace and pod_name must be both set or both empty")
+	}
+	if req.Network == "" {
+		req.Network = networkv1.DefaultPodNetworkName
+	}
+
+	r, err := s.store.AddIPReservation(ctx, store.IPReservation{
+		Network:      req.Network,
+		Address:      req.Address,
+		PodNamespace: req.PodNamespace,
+		PodName:      req.PodName,
+	})
+	if errors.Is(err, store.ErrReservationExists) {
+		return nil, status.Errorf(codes.AlreadyExists, "address %s or pod %s/%s is already reserved on network %s", req.Address, req.PodNamespace, req.PodName, req.Network)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &adminv1.AddIPReservationResponse{Reservation: adminIPReservation(r)}, nil
+}
+
+// RemoveIPReservation implements AdminServer.RemoveIPReservation
+func (s *adaptiveIpamServer) RemoveIPReservation(ctx context.Context, req *adminv1.RemoveIPReservationRequest) (*adminv1.RemoveIPReservationResponse, error) {
+	if req.Network == "" {
+		req.Network = networkv1.DefaultPodNetworkName
+	}
+	err := s.store.RemoveIPReservation(ctx, req.Network, req.Address)
+	if errors.Is(err, store.ErrReservationNotFound) {
+		return nil, status.Errorf(codes.NotFound, "address %s is not reserved on network %s", req.Address, req.Network)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &adminv1.RemoveIPReservationResponse{}, nil
+}
+
+// ListIPReservations implements AdminServer.ListIPReservations
+func (s *adaptiveIpamServer) ListIPReservations(ctx context.Context, req *adminv1.ListIPReservationsRequest) (*adminv1.ListIPReservationsResponse, error) {
+	reservations, err := s.store.ListIPReservations(ctx, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &adminv1.ListIPReservationsResponse{}
+	for _, r := range reservations {
+		resp.Reservations = append(resp.Reservations, adminIPReservation(r))
+	}
+	return resp, nil
+}
+
+// AddIPQuarantine implements AdminServer.AddIPQuarantine
+func (s *adaptiveIpamServer) AddIPQuarantine(ctx context.Context, req *adminv1.AddIPQuarantineRequest) (*adminv1.AddIPQuarantineResponse, error) {
+	if err := validateQuarantineTarget(req.Address, req.Cidr); err != nil {
+		return nil, err
+	}
+	if req.Network == "" {
+		req.Network = networkv1.DefaultPodNetworkName
+	}
+
+	q, err := s.store.AddIPQuarantine(ctx, store.IPQuarantine{
+		Network: req.Network,
+		Address: req.Address,
+		CIDR:    req.Cidr,
+		Reason:  req.Reason,
+	})
+	if errors.Is(err, store.ErrQuarantineExists) {
+		return nil, status.Errorf(codes.AlreadyExists, "%s%s is already quarantined on network %s", req.Address, req.Cidr, req.Network)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Quarantined IPs", "network", q.Network, "address", q.Address, "cidr", q.CIDR, "reason", q.Reason)
+	return &adminv1.AddIPQuarantineResponse{Quarantine: adminIPQuarantine(q)}, nil
+}
+
+// RemoveIPQuarantine implements AdminServer.RemoveIPQuarantine
+func (s *adaptiveIpamServer) RemoveIPQuarantine(ctx context.Context, req *adminv1.RemoveIPQuarantineRequest) (*adminv1.RemoveIPQuarantineResponse, error) {
+	if err := validateQuarantineTarget(req.Address, req.Cidr); err != nil {
+		return nil, err
+	}
+	if req.Network == "" {
+		req.Network = networkv1.DefaultPodNetworkName
+	}
+	err := s.store.RemoveIPQuarantine(ctx, req.Network, req.Address, req.Cidr)
+	if errors.Is(err, store.ErrQuarantineNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%s%s is not quarantined on network %s", req.Address, req.Cidr, req.Network)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Removed IP quarantine", "network", req.Network, "address", req.Address, "cidr", req.Cidr)
+	return &adminv1.RemoveIPQuarantineResponse{}, nil
+}
+
+// ListIPQuarantines implements AdminServer.ListIPQuarantines
+func (s *adaptiveIpamServer) ListIPQuarantines(ctx context.Context, req *adminv1.ListIPQuarantinesRequest) (*adminv1.ListIPQuarantinesResponse, error) {
+	quarantines, err := s.store.ListIPQuarantines(ctx, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &adminv1.ListIPQuarantinesResponse{}
+	for _, q := range quarantines {
+		resp.Quarantines = append(resp.Quarantines, adminIPQuarantine(q))
+	}
+	return resp, nil
+}
+
+// ListAllocationHistory implements AdminServer.ListAllocationHistory
+func (s *adaptiveIpamServer) ListAllocationHistory(ctx context.Context, req *adminv1.ListAllocationHistoryRequest) (*adminv1.ListAllocationHistoryResponse, error) {
+	if req.Address != "" {
+		if _, err := netip.ParseAddr(req.Address); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid address %q", req.Address)
+		}
+	}
+	page, err := parseAdminPage(req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := store.HistoryFilter{
+		Network:      req.Network,
+		Address:      req.Address,
+		ContainerID:  req.ContainerId,
+		PodNamespace: req.PodNamespace,
+		PodName:      req.PodName,
+	}
+	if req.Since != nil {
+		filter.Since = req.Since.AsTime()
+	}
+	records, nextID, err := s.store.ListHistory(ctx, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &adminv1.ListAllocationHistoryResponse{NextPageToken: adminPageToken(nextID)}
+	for _, r := range records {
+		resp.Records = append(resp.Records, &adminv1.HistoryRecord{
+			Id:            r.ID,
+			Event:         string(r.Event),
+			Network:       r.Network,
+			Cidr:          r.CIDR,
+			IpFamily:      string(r.IPFamily),
+			Address:       r.Address,
+			ContainerId:   r.ContainerID,
+			InterfaceName: r.InterfaceName,
+			PodNamespace:  r.PodNamespace,
+			PodName:       r.PodName,
+			ReleaseAt:     adminTimestamp(r.ReleaseAt),
+			OccurredAt:    adminTimestamp(r.OccurredAt),
+		})
+	}
+	return resp, nil
+}
+
+// WatchEvents implements AdminServer.WatchEvents
+func (s *adaptiveIpamServer) WatchEvents(req *adminv1.WatchEventsRequest, stream adminv1.Admin_WatchEventsServer) error {
+	types := map[WatchEventType]bool{}
+	for _, t := range req.Types {
+		eventType, ok := watchEventTypeFromProto[t]
+		if !ok {
+			return status.Errorf(codes.InvalidArgument, "invalid event type %v", t)
+		}
+		types[eventType] = true
+	}
+
+	sub := s.events.Subscribe(0)
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev, ok := <-sub.Events():
+			if !ok {
+				if errors.Is(sub.Err(), errSubscriberTooSlow) {
+					return status.Error(codes.ResourceExhausted, "watch stream fell too far behind the events")
+				}
+				return status.Error(codes.Unavailable, "daemon is shutting down")
+			}
+			if req.Network != "" && ev.Network != req.Network {
+				continue
+			}
+			if len(types) > 0 && !types[ev.Type] {
+				continue
+			}
+			if err := stream.Send(adminWatchEvent(ev)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// CreateSnapshot implements AdminServer.CreateSnapshot
+func (s *adaptiveIpamServer) CreateSnapshot(ctx context.Context, req *adminv1.CreateSnapshotRequest) (*adminv1.CreateSnapshotResponse, error) {
+	if s.snapshots == nil {
+		return nil, status.Error(codes.Unavailable, "snapshotter is not running")
+	}
+	if req.Path != "" && !filepath.IsAbs(req.Path) {
+		return nil, status.Errorf(codes.InvalidArgument, "path %q must be absolute", req.Path)
+	}
+	path, err := s.snapshots.Snapshot(ctx, req.Path)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "snapshot failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to stat snapshot: %v", err)
+	}
+	return &adminv1.CreateSnapshotResponse{Path: path, SizeBytes: info.Size()}, nil
+}
+
+// RestoreSnapshot implements AdminServer.RestoreSnapshot
+func (s *adaptiveIpamServer) RestoreSnapshot(ctx context.Context, req *adminv1.RestoreSnapshotRequest) (*adminv1.RestoreSnapshotResponse, error) {
+	if s.snapshots == nil {
+		return nil, status.Error(codes.Unavailable, "snapshotter is not running")
+	}
+	if !filepath.IsAbs(req.Path) {
+		return nil, status.Errorf(codes.InvalidArgument, "path %q must be absolute", req.Path)
+	}
+	// Hold off allocations until the IPs allocated since the snapshot was
+	// taken are claimed again, so that they are not allocated twice.
+	s.engine.storeMu.Lock()
+	defer s.engine.storeMu.Unlock()
+	if err := s.snapshots.Restore(ctx, req.Path); err != nil {
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			return nil, status.Errorf(codes.NotFound, "snapshot %s not found", req.Path)
+		case errors.Is(err, store.ErrIntegrityCheckFailed), errors.Is(err, store.ErrSchemaTooNew):
+			return nil, status.Errorf(codes.FailedPrecondition, "invalid snapshot: %v", err)
+		default:
+			return nil, status.Errorf(codes.Unavailable, "restore failed: %v", err)
+		}
+	}
+
+	// Reconcile the NodeNetworkConfig with the restored CIDR blocks.
+	s.engine.requestsMu.RLock()
+	monitor := s.engine.monitor
+	s.engine.requestsMu.RUnlock()
+	if monitor != nil {
+		monitor.enqueue()
+	}
+	return &adminv1.RestoreSnapshotResponse{}, nil
+}
+
+// watchEventTypeFromProto maps the event types of WatchEventsRequest to WatchEventTypes.
+var watchEventTypeFromProto = map[adminv1.WatchEvent_Type]WatchEventType{
+	adminv1.WatchEvent_IP_ALLOCATED:       WatchEventIPAllocated,
+	adminv1.WatchEvent_IP_RELEASED:        WatchEventIPReleased,
+	adminv1.WatchEvent_BLOCK_ADDED:        WatchEventBlockAdded,
+	adminv1.WatchEvent_BLOCK_DRAINING:     WatchEventBlockDraining,
+	adminv1.WatchEvent_BLOCK_DELETED:      WatchEventBlockDeleted,
+	adminv1.WatchEvent_SCALE_UP_REQUESTED: WatchEventScaleUpRequested,
+	adminv1.WatchEvent_NNC_PATCHED:        WatchEventNNCPatched,
+}
+
+// adminWatchEvent converts a WatchEvent to its proto representation.
+func adminWatchEvent(ev WatchEvent) *adminv1.WatchEvent {
+	res := &adminv1.WatchEvent{
+		Time:          adminTimestamp(ev.Time),
+		Network:       ev.Network,
+		IpFamily:      string(ev.IPFamily),
+		Cidr:          ev.CIDR,
+		Address:       ev.Address,
+		ContainerId:   ev.ContainerID,
+		InterfaceName: ev.InterfaceName,
+		PodNamespace:  ev.PodNamespace,
+		PodName:       ev.PodName,
+		TargetPods:    ev.TargetPods,
+	}
+	for t, eventType := range watchEventTypeFromProto {
+		if eventType == ev.Type {
+			res.Type = t
+		}
+	}
+	return res
+}
+
+// adminIPReservation converts a store reservation to its proto representation.
+func adminIPReservation(r store.IPReservation) *adminv1.IPReservation {
+	return &adminv1.IPReservation{
+		Network:      r.Network,
+		Address:      r.Address,
+		IpFamily:     string(r.IPFamily),
+		PodNamespace: r.PodNamespace,
+		PodName:      r.PodName,
+		CreatedAt:    adminTimestamp(r.CreatedAt),
+	}
+}
+
+// adminIPQuarantine converts a store quarantine to its proto representation.
+func adminIPQuarantine(q store.IPQuarantine) *adminv1.IPQuarantine {
+	return &adminv1.IPQuarantine{
+		Network:   q.Network,
+		Address:   q.Address,
+		Cidr:      q.CIDR,
+		IpFamily:  string(q.IPFamily),
+		Reason:    q.Reason,
+		CreatedAt: adminTimestamp(q.CreatedAt),
+	}
+}
+
+// validateQuarantineTarget validates that exactly one of the address and the
+// CIDR block of a quarantine request is set and valid.
+func validateQuarantineTarget(address, cidr string) error {
+	switch {
+	case (address == "") == (cidr == ""):
+		return status.Error(codes.InvalidArgument, "exactly one of address and cidr must be set")
+	case address != "":
+		if _, err := netip.ParseAddr(address); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid address %q", address)
+		}
+	default:
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil || prefix != prefix.Masked() {
+			return status.Errorf(codes.InvalidArgument, "invalid cidr %q", cidr)
+		}
+	}
+	return nil
+}
+
+// parseAdminFilters validates the IP family and CIDR block state filters of an admin request.
+func parseAdminFilters(ipFamily, state string) (store.IPFamily, store.CidrBlockState, error) {
+	switch store.IPFamily(ipFamily) {
+	case "", store.IPv4, store.IPv6:
+	default:
+		return "", "", status.Errorf(codes.InvalidArgument, "invalid ip_family %q, must be %q or %q", ipFamily, store.IPv4, store.IPv6)
+	}
+	switch store.CidrBlockState(state) {
+	case "", store.StateReady, store.StateDraining, store.StateDeleting:
+	default:
+		return "", "", status.Errorf(codes.InvalidArgument, "invalid state %q, must be one of %q, %q or %q", state, store.StateReady, store.StateDraining, store.StateDeleting)
+	}
+	return store.IPFamily(ipFamily), store.CidrBlockState(state), nil
+}
+
+// parseAdminPage converts the pagination fields of an admin request into a store page.
+// Page tokens are the ID of the last record of the previous page.
+func parseAdminPage(pageSize int32, pageToken string) (store.AdminPage, error) {
+	if pageSize < 0 {
+		return store.AdminPage{}, status.Errorf(codes.InvalidArgument, "page_size must not be negative, got %d", pageSize)
+	}
+	page := store.AdminPage{Limit: int(pageSize)}
+	if pageToken != "" {
+		afterID, err := strconv.ParseInt(pageToken, 10, 64)
+		if err != nil || afterID <= 0 {
+			return store.AdminPage{}, status.Errorf(codes.InvalidArgument, "invalid page_token %q", pageToken)
+		}
+		page.AfterID = afterID
+	}
+	return page, nil
+}
+
+// adminPageToken returns the page token for the page after the record with the given ID.
+func adminPageToken(afterID int64) string {
+	if afterID == 0 {
+		return ""
+	}
+	return strconv.FormatInt(afterID, 10)
+}
+
+// adminTimestamp converts a store timestamp to its proto representation, leaving unset timestamps nil.
+func adminTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
 }
@@ -0,0 +1,493 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	nncfake "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned/fake"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/metis/api/adaptiveipam/v1"
+	adminv1 "k8s.io/metis/api/admin/v1"
+	"k8s.io/metis/pkg/store"
+)
+
+func TestAdaptiveIpamServer_ListCIDRBlocks(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_admin_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+
+	for _, cidr := range []string{"10.0.0.0/28", "10.0.1.0/28", "10.0.2.0/28"} {
+		if err := storeInstance.AddCIDR(ctx, "test-network", cidr); err != nil {
+			t.Fatalf("Failed to add CIDR %s: %v", cidr, err)
+		}
+	}
+	s := newAdaptiveIpamServer(logger, storeInstance, "", 0, 0)
+
+	var got []string
+	req := &adminv1.ListCIDRBlocksRequest{Network: "test-network", IpFamily: "ipv4", PageSize: 2}
+	for pages := 0; ; pages++ {
+		if pages > 2 {
+			t.Fatal("Pagination did not terminate")
+		}
+		resp, err := s.ListCIDRBlocks(ctx, req)
+		if err != nil {
+			t.Fatalf("ListCIDRBlocks failed: %v", err)
+		}
+		for _, b := range resp.CidrBlocks {
+			if b.CreatedAt == nil || b.State != string(store.StateReady) {
+				t.Errorf("Unexpected CIDR block %v", b)
+			}
+			got = append(got, b.Cidr)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	if len(got) != 3 || got[0] != "10.0.0.0/28" || got[2] != "10.0.2.0/28" {
+		t.Errorf("Expected all 3 CIDR blocks in order, got %v", got)
+	}
+}
+
+func TestAdaptiveIpamServer_AdminInvalidArguments(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_admin_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+	s := newAdaptiveIpamServer(logger, storeInstance, "", 0, 0)
+
+	testCases := []struct {
+		name string
+		req  *adminv1.ListIPAddressesRequest
+	}{
+		{name: "invalid ip family", req: &adminv1.ListIPAddressesRequest{IpFamily: "ipv5"}},
+		{name: "invalid state", req: &adminv1.ListIPAddressesRequest{State: "Ready' OR '1'='1"}},
+		{name: "negative page size", req: &adminv1.ListIPAddressesRequest{PageSize: -1}},
+		{name: "invalid page token", req: &adminv1.ListIPAddressesRequest{PageToken: "abc"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.ListIPAddresses(ctx, tc.req)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("Expected InvalidArgument, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAdaptiveIpamServer_IPReservations(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_admin_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+	s := newAdaptiveIpamServer(logger, storeInstance, "", 0, 0)
+
+	added, err := s.AddIPReservation(ctx, &adminv1.AddIPReservationRequest{Address: "10.0.0.9", PodNamespace: "ns", PodName: "db-0"})
+	if err != nil {
+		t.Fatalf("AddIPReservation failed: %v", err)
+	}
+	if r := added.Reservation; r.Network != "default" || r.IpFamily != "ipv4" || r.CreatedAt == nil {
+		t.Errorf("Unexpected reservation %v", r)
+	}
+
+	errorCases := []struct {
+		name     string
+		req      *adminv1.AddIPReservationRequest
+		wantCode codes.Code
+	}{
+		{name: "invalid address", req: &adminv1.AddIPReservationRequest{Address: "10.0.0.300"}, wantCode: codes.InvalidArgument},
+		{name: "pod name without namespace", req: &adminv1.AddIPReservationRequest{Address: "10.0.0.10", PodName: "db-1"}, wantCode: codes.InvalidArgument},
+		{name: "duplicate address", req: &adminv1.AddIPReservationRequest{Network: "default", Address: "10.0.0.9"}, wantCode: codes.AlreadyExists},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.AddIPReservation(ctx, tc.req)
+			if status.Code(err) != tc.wantCode {
+				t.Errorf("Expected %v, got %v", tc.wantCode, err)
+			}
+		})
+	}
+
+	allocate := func(containerID string) (*adaptiveipam.AllocatePodIPResponse, error) {
+		return s.AllocatePodIP(ctx, &adaptiveipam.AllocatePodIPRequest{
+			PodNamespace: "ns",
+			PodName:      "db-0",
+			Ipv4Config: &adaptiveipam.IPConfig{
+				ContainerId:    containerID,
+				InterfaceName:  "eth0",
+				InitialPodCidr: "10.0.0.0/28",
+			},
+		})
+	}
+	resp, err := allocate("c1")
+	if err != nil {
+		t.Fatalf("AllocatePodIP failed: %v", err)
+	}
+	if got := resp.Ipv4.IpAddress; got != "10.0.0.9" {
+		t.Errorf("Expected the reserved address 10.0.0.9, got %s", got)
+	}
+	if _, err := allocate("c2"); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition while the reserved address is held, got %v", err)
+	}
+
+	list, err := s.ListIPReservations(ctx, &adminv1.ListIPReservationsRequest{})
+	if err != nil {
+		t.Fatalf("ListIPReservations failed: %v", err)
+	}
+	if len(list.Reservations) != 1 || list.Reservations[0].PodName != "db-0" {
+		t.Errorf("Unexpected reservations %v", list.Reservations)
+	}
+
+	if _, err := s.RemoveIPReservation(ctx, &adminv1.RemoveIPReservationRequest{Address: "10.0.0.9"}); err != nil {
+		t.Fatalf("RemoveIPReservation failed: %v", err)
+	}
+	if _, err := s.RemoveIPReservation(ctx, &adminv1.RemoveIPReservationRequest{Address: "10.0.0.9"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
+
+func TestAdaptiveIpamServer_IPQuarantines(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_admin_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+	s := newAdaptiveIpamServer(logger, storeInstance, "", time.Hour, 0)
+
+	allocate := func(containerID string) string {
+		t.Helper()
+		resp, err := s.AllocatePodIP(ctx, &adaptiveipam.AllocatePodIPRequest{
+			PodNamespace: "ns",
+			PodName:      containerID,
+			Ipv4Config: &adaptiveipam.IPConfig{
+				ContainerId:    containerID,
+				InterfaceName:  "eth0",
+				InitialPodCidr: "10.0.0.0/28",
+			},
+		})
+		if err != nil {
+			t.Fatalf("AllocatePodIP failed: %v", err)
+		}
+		return resp.Ipv4.IpAddress
+	}
+
+	// The first free address of the initial block is 10.0.0.2.
+	if _, err := s.AddIPQuarantine(ctx, &adminv1.AddIPQuarantineRequest{Address: "10.0.0.2", Reason: "test"}); err != nil {
+		t.Fatalf("AddIPQuarantine failed: %v", err)
+	}
+	errorCases := []struct {
+		name     string
+		req      *adminv1.AddIPQuarantineRequest
+		wantCode codes.Code
+	}{
+		{name: "no address or cidr", req: &adminv1.AddIPQuarantineRequest{}, wantCode: codes.InvalidArgument},
+		{name: "address and cidr", req: &adminv1.AddIPQuarantineRequest{Address: "10.0.0.3", Cidr: "10.0.0.0/28"}, wantCode: codes.InvalidArgument},
+		{name: "unmasked cidr", req: &adminv1.AddIPQuarantineRequest{Cidr: "10.0.0.1/28"}, wantCode: codes.InvalidArgument},
+		{name: "duplicate address", req: &adminv1.AddIPQuarantineRequest{Network: "default", Address: "10.0.0.2"}, wantCode: codes.AlreadyExists},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.AddIPQuarantine(ctx, tc.req)
+			if status.Code(err) != tc.wantCode {
+				t.Errorf("Expected %v, got %v", tc.wantCode, err)
+			}
+		})
+	}
+
+	ip := allocate("c1")
+	if ip != "10.0.0.3" {
+		t.Fatalf("Expected the quarantined address to be skipped, got %s", ip)
+	}
+
+	// A reported conflict quarantines the address and releases it without
+	// the release cooldown, and the retried allocation gets another address.
+	if _, err := s.ReportIPConflict(ctx, &adaptiveipam.ReportIPConflictRequest{ContainerId: "c1", InterfaceName: "eth0", IpAddress: ip}); err != nil {
+		t.Fatalf("ReportIPConflict failed: %v", err)
+	}
+	if _, err := s.ReportIPConflict(ctx, &adaptiveipam.ReportIPConflictRequest{ContainerId: "c1", InterfaceName: "eth0", IpAddress: ip}); err != nil {
+		t.Errorf("Expected a repeated ReportIPConflict to succeed, got %v", err)
+	}
+	if got := allocate("c1"); got != "10.0.0.4" {
+		t.Errorf("Expected the retried allocation to get 10.0.0.4, got %s", got)
+	}
+	if _, err := s.ReportIPConflict(ctx, &adaptiveipam.ReportIPConflictRequest{IpAddress: "bogus"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+
+	list, err := s.ListIPQuarantines(ctx, &adminv1.ListIPQuarantinesRequest{Network: "default"})
+	if err != nil {
+		t.Fatalf("ListIPQuarantines failed: %v", err)
+	}
+	if len(list.Quarantines) != 2 || list.Quarantines[0].Reason != "test" || list.Quarantines[1].Address != "10.0.0.3" || list.Quarantines[1].CreatedAt == nil {
+		t.Errorf("Unexpected quarantines %v", list.Quarantines)
+	}
+
+	if _, err := s.RemoveIPQuarantine(ctx, &adminv1.RemoveIPQuarantineRequest{Address: "10.0.0.2"}); err != nil {
+		t.Fatalf("RemoveIPQuarantine failed: %v", err)
+	}
+	if _, err := s.RemoveIPQuarantine(ctx, &adminv1.RemoveIPQuarantineRequest{Address: "10.0.0.2"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+	if got := allocate("c2"); got != "10.0.0.2" {
+		t.Errorf("Expected the released quarantine address 10.0.0.2, got %s", got)
+	}
+}
+
+func TestAdaptiveIpamServer_ListAllocationHistory(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_admin_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+	s := newAdaptiveIpamServer(logger, storeInstance, "", time.Hour, 0)
+
+	var addresses []string
+	for _, pod := range []string{"web-0", "web-1"} {
+		resp, err := s.AllocatePodIP(ctx, &adaptiveipam.AllocatePodIPRequest{
+			PodNamespace: "ns",
+			PodName:      pod,
+			Ipv4Config: &adaptiveipam.IPConfig{
+				ContainerId:    pod,
+				InterfaceName:  "eth0",
+				InitialPodCidr: "10.0.0.0/28",
+			},
+		})
+		if err != nil {
+			t.Fatalf("AllocatePodIP for %s failed: %v", pod, err)
+		}
+		addresses = append(addresses, resp.Ipv4.IpAddress)
+	}
+	if _, err := s.DeallocatePodIP(ctx, &adaptiveipam.DeallocatePodIPRequest{ContainerId: "web-0", InterfaceName: "eth0"}); err != nil {
+		t.Fatalf("DeallocatePodIP failed: %v", err)
+	}
+
+	resp, err := s.ListAllocationHistory(ctx, &adminv1.ListAllocationHistoryRequest{Address: addresses[0]})
+	if err != nil {
+		t.Fatalf("ListAllocationHistory failed: %v", err)
+	}
+	if len(resp.Records) != 2 || resp.Records[0].Event != "Allocated" || resp.Records[1].Event != "Released" {
+		t.Fatalf("Expected the allocation and release of %s, got %v", addresses[0], resp.Records)
+	}
+	if r := resp.Records[1]; r.PodName != "web-0" || r.Network != "default" || r.ReleaseAt == nil || r.OccurredAt == nil {
+		t.Errorf("Unexpected release record %v", r)
+	}
+
+	resp, err = s.ListAllocationHistory(ctx, &adminv1.ListAllocationHistoryRequest{
+		PodNamespace: "ns",
+		PodName:      "web-1",
+		Since:        timestamppb.New(time.Now().Add(-time.Hour)),
+	})
+	if err != nil {
+		t.Fatalf("ListAllocationHistory failed: %v", err)
+	}
+	if len(resp.Records) != 1 || resp.Records[0].Address != addresses[1] {
+		t.Errorf("Expected the allocation of %s to web-1, got %v", addresses[1], resp.Records)
+	}
+
+	for _, req := range []*adminv1.ListAllocationHistoryRequest{{Address: "10.0.0.300"}, {PageToken: "abc"}} {
+		if _, err := s.ListAllocationHistory(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for %v, got %v", req, err)
+		}
+	}
+}
+
+// fakeWatchStream is an adminv1.Admin_WatchEventsServer sending the events to a channel.
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *adminv1.WatchEvent
+}
+
+func (f *fakeWatchStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeWatchStream) Send(ev *adminv1.WatchEvent) error {
+	f.events <- ev
+	return nil
+}
+
+func TestAdaptiveIpamServer_RestoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	dir := t.TempDir()
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(dir, "metis_admin_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+
+	nodeName := "test-node"
+	kubeClient := kubefake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+		Spec:       corev1.NodeSpec{PodCIDRs: []string{"10.0.0.0/28"}},
+	})
+	s := newAdaptiveIpamServer(logger, storeInstance, "", 0, 0)
+	s.snapshots = NewSnapshotter(SnapshotterConfig{
+		Logger:     logger,
+		Store:      storeInstance,
+		KubeClient: kubeClient,
+		NNCClient:  nncfake.NewSimpleClientset(&nncv1.NodeNetworkConfig{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}),
+		NodeName:   nodeName,
+		Dir:        filepath.Join(dir, "snapshots"),
+	})
+
+	// allocate allocates an IP to a pod and reports it as running with it.
+	allocate := func(name string) string {
+		t.Helper()
+		resp, err := s.AllocatePodIP(ctx, &adaptiveipam.AllocatePodIPRequest{
+			PodNamespace: "ns",
+			PodName:      name,
+			Ipv4Config: &adaptiveipam.IPConfig{
+				ContainerId:    "c-" + name,
+				InterfaceName:  "eth0",
+				InitialPodCidr: "10.0.0.0/28",
+			},
+		})
+		if err != nil {
+			t.Fatalf("AllocatePodIP for %s failed: %v", name, err)
+		}
+		ip := resp.Ipv4.IpAddress
+		_, err = kubeClient.CoreV1().Pods("ns").Create(ctx, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, UID: types.UID("uid-" + name)},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIPs: []corev1.PodIP{{IP: ip}}},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("Failed to create pod %s: %v", name, err)
+		}
+		return ip
+	}
+
+	first := allocate("a")
+	snapshot, err := s.CreateSnapshot(ctx, &adminv1.CreateSnapshotRequest{})
+	if err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+	// The IP allocated after the snapshot is not in the restored store.
+	second := allocate("b")
+	if _, err := s.RestoreSnapshot(ctx, &adminv1.RestoreSnapshotRequest{Path: snapshot.Path}); err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+	if third := allocate("c"); third == first || third == second {
+		t.Errorf("Expected a new IP after the restore, got %s allocated before to %s", third, map[string]string{first: "a", second: "b"}[third])
+	}
+}
+
+func TestAdaptiveIpamServer_WatchEvents(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_admin_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+	s := newAdaptiveIpamServer(logger, storeInstance, "", time.Hour, 0)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream := &fakeWatchStream{ctx: watchCtx, events: make(chan *adminv1.WatchEvent, 10)}
+	done := make(chan error)
+	go func() {
+		done <- s.WatchEvents(&adminv1.WatchEventsRequest{
+			Network: "default",
+			Types:   []adminv1.WatchEvent_Type{adminv1.WatchEvent_IP_ALLOCATED, adminv1.WatchEvent_IP_RELEASED},
+		}, stream)
+	}()
+	// Wait for the stream to subscribe before making changes.
+	for {
+		s.events.mu.Lock()
+		n := len(s.events.subscribers)
+		s.events.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	s.events.Publish(WatchEvent{Type: WatchEventIPAllocated, Network: "other", Address: "10.1.0.2"})
+	resp, err := s.AllocatePodIP(ctx, &adaptiveipam.AllocatePodIPRequest{
+		PodNamespace: "ns",
+		PodName:      "web-0",
+		Ipv4Config: &adaptiveipam.IPConfig{
+			ContainerId:    "web-0",
+			InterfaceName:  "eth0",
+			InitialPodCidr: "10.0.0.0/28",
+		},
+	})
+	if err != nil {
+		t.Fatalf("AllocatePodIP failed: %v", err)
+	}
+	if _, err := s.DeallocatePodIP(ctx, &adaptiveipam.DeallocatePodIPRequest{
+		ContainerId:   "web-0",
+		InterfaceName: "eth0",
+		PodNamespace:  "ns",
+		PodName:       "web-0",
+	}); err != nil {
+		t.Fatalf("DeallocatePodIP failed: %v", err)
+	}
+
+	for _, want := range []adminv1.WatchEvent_Type{adminv1.WatchEvent_IP_ALLOCATED, adminv1.WatchEvent_IP_RELEASED} {
+		ev := <-stream.events
+		if ev.Type != want || ev.Address != resp.Ipv4.IpAddress || ev.Network != "default" || ev.IpFamily != string(store.IPv4) ||
+			ev.PodName != "web-0" || ev.ContainerId != "web-0" || ev.Time == nil {
+			t.Errorf("Unexpected %v event %v", want, ev)
+		}
+	}
+	select {
+	case ev := <-stream.events:
+		t.Errorf("Expected the other events to be filtered out, got %v", ev)
+	default:
+	}
+
+	s.stop()
+	if err := <-done; status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable once the server stops, got %v", err)
+	}
+
+	err = s.WatchEvents(&adminv1.WatchEventsRequest{Types: []adminv1.WatchEvent_Type{adminv1.WatchEvent_TYPE_UNSPECIFIED}}, stream)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an unspecified event type, got %v", err)
+	}
+}
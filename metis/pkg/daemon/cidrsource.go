@@ -0,0 +1,681 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	networkv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/network/v1"
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	nncclientset "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned"
+	nncinformers "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/informers/externalversions/nodenetworkconfig/v1"
+	nnclisters "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/listers/nodenetworkconfig/v1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/metis/pkg/store"
+	"k8s.io/metis/third_party/cidrset"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// CIDRSourceNNC, CIDRSourceClusterCIDR and CIDRSourceFile are the names
+	// of the built-in CIDR sources.
+	CIDRSourceNNC         = "nnc"
+	CIDRSourceClusterCIDR = "cluster-cidr"
+	CIDRSourceFile        = "file"
+
+	// CIDRBlockListAPIVersion and CIDRBlockListKind identify the version of
+	// the CIDR file format of the file CIDR source.
+	CIDRBlockListAPIVersion = "metis.networking.gke.io/v1alpha1"
+	CIDRBlockListKind       = "CIDRBlockList"
+
+	DefaultClusterCIDRBlockMaskSize     = 28
+	DefaultClusterCIDRIPv6BlockMaskSize = 120
+	DefaultCIDRFileReloadInterval       = 10 * time.Second
+
+	// maxClusterCIDRBlocks bounds the number of blocks carved from a single
+	// cluster CIDR, since all of them are added to the store.
+	maxClusterCIDRBlocks = 4096
+)
+
+// ValidateCIDRSourceName returns an error if name is not a built-in CIDR source.
+func ValidateCIDRSourceName(name string) error {
+	switch name {
+	case CIDRSourceNNC, CIDRSourceClusterCIDR, CIDRSourceFile:
+		return nil
+	}
+	return fmt.Errorf("unknown CIDR source %q, must be one of %q, %q or %q", name, CIDRSourceNNC, CIDRSourceClusterCIDR, CIDRSourceFile)
+}
+
+// SourceCIDR is a CIDR block a CIDRSource assigns to the node.
+type SourceCIDR struct {
+	Network string
+	CIDR    string
+	// Ready reports whether IPs can be allocated from the block. Blocks that
+	// are not ready are not added to the store, but a Deleting block of the
+	// store is only deleted once no source returns it anymore.
+	Ready bool
+}
+
+// CIDRSource provides the CIDR blocks the Watcher adds to the store. The
+// blocks of a network are the union of the blocks of all sources.
+type CIDRSource interface {
+	// Name identifies the source in logs and errors.
+	Name() string
+	// CIDRs returns the CIDR blocks currently assigned to the node.
+	CIDRs(ctx context.Context) ([]SourceCIDR, error)
+	// Run calls notify with the networks whose blocks may have changed. It
+	// blocks until ctx is done, or returns early if the blocks never change
+	// after the initial notifications.
+	Run(ctx context.Context, notify func(network string))
+}
+
+// nncCIDRSource returns the pod CIDRs of the NodeNetworkConfig status of the
+// node. These are allocated by the GCE control plane on the requests of the
+// Monitor.
+type nncCIDRSource struct {
+	client   nncclientset.Interface
+	informer nncinformers.NodeNetworkConfigInformer
+	lister   nnclisters.NodeNetworkConfigLister
+	nodeName string
+}
+
+// NewNNCCIDRSource creates the CIDR source of the NodeNetworkConfig of the
+// node. The informer is optional, without it or until it has synced the
+// NodeNetworkConfig is read from the API server. Without it, Run only returns
+// when ctx is done.
+func NewNNCCIDRSource(client nncclientset.Interface, informer nncinformers.NodeNetworkConfigInformer, nodeName string) CIDRSource {
+	s := &nncCIDRSource{
+		client:   client,
+		informer: informer,
+		nodeName: nodeName,
+	}
+	if informer != nil {
+		s.lister = informer.Lister()
+	}
+	return s
+}
+
+func (s *nncCIDRSource) Name() string {
+	return CIDRSourceNNC
+}
+
+func (s *nncCIDRSource) CIDRs(ctx context.Context) ([]SourceCIDR, error) {
+	// The state rebuild and the store restore read the blocks before the
+	// informer is started.
+	lister := s.lister
+	if s.informer != nil && !s.informer.Informer().HasSynced() {
+		lister = nil
+	}
+	nnc, err := getNodeNetworkConfig(ctx, lister, s.client, s.nodeName)
+	if err != nil {
+		return nil, err
+	}
+	cidrs := make([]SourceCIDR, 0, len(nnc.Status.PodCIDRs))
+	for _, podCIDR := range nnc.Status.PodCIDRs {
+		cidrs = append(cidrs, SourceCIDR{
+			Network: podCIDR.Network,
+			CIDR:    podCIDR.CIDR,
+			Ready:   podCIDR.Condition == nil || podCIDR.Condition.Status == metav1.ConditionTrue,
+		})
+	}
+	return cidrs, nil
+}
+
+// Run notifies the networks of the allocations of the NodeNetworkConfig
+// whenever it changes.
+func (s *nncCIDRSource) Run(ctx context.Context, notify func(network string)) {
+	if s.informer == nil {
+		<-ctx.Done()
+		return
+	}
+	notifyAllocations := func(nnc *nncv1.NodeNetworkConfig) {
+		if nnc.Name != s.nodeName {
+			return
+		}
+		for _, alloc := range nnc.Spec.Allocations {
+			notify(alloc.Network)
+		}
+	}
+	registration, err := s.informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if nnc, ok := obj.(*nncv1.NodeNetworkConfig); ok {
+				notifyAllocations(nnc)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldNNC, ok1 := oldObj.(*nncv1.NodeNetworkConfig)
+			newNNC, ok2 := newObj.(*nncv1.NodeNetworkConfig)
+			if !ok1 || !ok2 || oldNNC.ResourceVersion == newNNC.ResourceVersion {
+				return
+			}
+			notifyAllocations(newNNC)
+		},
+	})
+	if err != nil {
+		// AddEventHandler only fails once the informer was stopped.
+		return
+	}
+	<-ctx.Done()
+	_ = s.informer.Informer().RemoveEventHandler(registration)
+}
+
+// ClusterCIDRSourceConfig holds the configuration for the cluster CIDR source.
+type ClusterCIDRSourceConfig struct {
+	// Network is the network of the blocks, the default pod network if empty.
+	Network string
+	// ClusterCIDRs are the ranges the blocks are carved from, at most one
+	// per IP family.
+	ClusterCIDRs []string
+	// BlockMaskSize and IPv6BlockMaskSize are the prefix lengths of the IPv4
+	// and IPv6 blocks. Like for the node IPAM controller, IPv6 blocks can be at
+	// most 16 bits longer than their cluster CIDR.
+	BlockMaskSize     int
+	IPv6BlockMaskSize int
+	// MaxBlocks bounds the number of blocks carved from each cluster CIDR,
+	// which are all carved if <= 0.
+	MaxBlocks int
+}
+
+// SetDefaults applies default values to the ClusterCIDRSourceConfig fields if they are unset.
+func (c *ClusterCIDRSourceConfig) SetDefaults() {
+	if c.Network == "" {
+		c.Network = networkv1.DefaultPodNetworkName
+	}
+	if c.BlockMaskSize <= 0 {
+		c.BlockMaskSize = DefaultClusterCIDRBlockMaskSize
+	}
+	if c.IPv6BlockMaskSize <= 0 {
+		c.IPv6BlockMaskSize = DefaultClusterCIDRIPv6BlockMaskSize
+	}
+}
+
+// clusterCIDRSource assigns fixed-size blocks carved from a range reserved
+// for the node, e.g. the pod CIDR kind assigns to the node, without any
+// control plane.
+//
+// All blocks are assigned until the source is used as the CapacityProvider
+// of the Monitor. From then on it plays the part of the GCE control plane
+// for the NodeNetworkConfig: blocks are assigned from the carved pool when
+// the Monitor requests more pod capacity, and returned to the pool when the
+// Monitor releases them.
+type clusterCIDRSource struct {
+	network string
+	// blocks are the blocks carved from the cluster CIDRs, in address order
+	// within each IP family.
+	blocks []SourceCIDR
+
+	mu sync.Mutex
+	// store is set once the source assigns the blocks on the requests of the
+	// Monitor, and assigned holds the CIDRs of the assigned blocks.
+	store    *store.Store
+	assigned map[string]bool
+	// allocations and releasable are the last requests of the Monitor.
+	allocations []nncv1.Allocation
+	releasable  []nncv1.PodCIDR
+	notify      func(network string)
+}
+
+// NewClusterCIDRSource creates a CIDR source carving the blocks of cfg. The
+// blocks are carved in address order by the cidrset package of the node IPAM
+// controller, like the pod CIDRs of the nodes.
+func NewClusterCIDRSource(cfg ClusterCIDRSourceConfig) (CIDRSource, error) {
+	cfg.SetDefaults()
+	if len(cfg.ClusterCIDRs) == 0 {
+		return nil, fmt.Errorf("no cluster CIDR configured")
+	}
+	s := &clusterCIDRSource{network: cfg.Network}
+	families := map[bool]string{}
+	for _, clusterCIDR := range cfg.ClusterCIDRs {
+		prefix, err := netip.ParsePrefix(clusterCIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster CIDR %q: %w", clusterCIDR, err)
+		}
+		is6 := prefix.Addr().Is6()
+		if other, ok := families[is6]; ok {
+			return nil, fmt.Errorf("cluster CIDRs %s and %s are of the same IP family", other, clusterCIDR)
+		}
+		families[is6] = clusterCIDR
+		maskSize := cfg.BlockMaskSize
+		if is6 {
+			maskSize = cfg.IPv6BlockMaskSize
+		}
+		blocks, err := clusterCIDRBlocks(prefix.Masked(), maskSize, cfg.MaxBlocks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to carve blocks from cluster CIDR %s: %w", clusterCIDR, err)
+		}
+		for _, block := range blocks {
+			s.blocks = append(s.blocks, SourceCIDR{Network: cfg.Network, CIDR: block.String(), Ready: true})
+		}
+	}
+	return s, nil
+}
+
+func (s *clusterCIDRSource) Name() string {
+	return CIDRSourceClusterCIDR
+}
+
+func (s *clusterCIDRSource) CIDRs(_ context.Context) ([]SourceCIDR, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.store == nil {
+		return s.blocks, nil
+	}
+	var cidrs []SourceCIDR
+	for _, block := range s.blocks {
+		if s.assigned[block.CIDR] {
+			cidrs = append(cidrs, block)
+		}
+	}
+	return cidrs, nil
+}
+
+// Run notifies the network of the blocks, and again whenever the assigned
+// blocks change.
+func (s *clusterCIDRSource) Run(ctx context.Context, notify func(network string)) {
+	s.mu.Lock()
+	s.notify = notify
+	s.mu.Unlock()
+	notify(s.network)
+	<-ctx.Done()
+}
+
+// enableScaling makes the source assign its blocks on the requests of the
+// Monitor. The blocks already in the store stay assigned, and the first
+// block of each IP family is assigned if none is, as the initial capacity.
+func (s *clusterCIDRSource) enableScaling(ctx context.Context, st *store.Store) error {
+	existing, err := st.GetAllCIDRBlocks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list the CIDR blocks of the store: %w", err)
+	}
+	inStore := map[string]bool{}
+	for _, block := range existing {
+		if block.Network == s.network {
+			inStore[block.CIDR] = true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = st
+	s.assigned = map[string]bool{}
+	familyAssigned := map[store.IPFamily]bool{}
+	for _, block := range s.blocks {
+		if inStore[block.CIDR] {
+			s.assigned[block.CIDR] = true
+			familyAssigned[prefixFamily(block.CIDR)] = true
+		}
+	}
+	for _, block := range s.blocks {
+		if family := prefixFamily(block.CIDR); !familyAssigned[family] {
+			s.assigned[block.CIDR] = true
+			familyAssigned[family] = true
+		}
+	}
+	return nil
+}
+
+// ScalesNetwork reports whether network is the network of the blocks.
+func (s *clusterCIDRSource) ScalesNetwork(network string) bool {
+	return network == s.network
+}
+
+// NodeNetworkConfig returns the last requests of the Monitor in the spec,
+// and the assigned blocks as the pod CIDRs of the status.
+func (s *clusterCIDRSource) NodeNetworkConfig(_ context.Context) (*nncv1.NodeNetworkConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nnc := &nncv1.NodeNetworkConfig{
+		Spec: nncv1.NodeNetworkConfigSpec{
+			Allocations:     slices.Clone(s.allocations),
+			ReleasableCIDRs: slices.Clone(s.releasable),
+		},
+	}
+	for _, block := range s.blocks {
+		if s.assigned[block.CIDR] {
+			nnc.Status.PodCIDRs = append(nnc.Status.PodCIDRs, nncv1.PodCIDR{Network: block.Network, CIDR: block.CIDR})
+		}
+	}
+	return nnc, nil
+}
+
+// UpdateSpec returns the releasable blocks to the pool, then assigns blocks
+// of the pool in address order until the capacity of each IP family covers
+// the requested pods. Blocks still in the store, e.g. released blocks not
+// deleted by the Watcher yet, are not assigned again. The pool may run out,
+// in which case the network is left with the blocks it has.
+func (s *clusterCIDRSource) UpdateSpec(ctx context.Context, nnc *nncv1.NodeNetworkConfig) error {
+	s.mu.Lock()
+	changed := false
+	for _, releasable := range nnc.Spec.ReleasableCIDRs {
+		if releasable.Network == s.network && s.assigned[releasable.CIDR] {
+			delete(s.assigned, releasable.CIDR)
+			changed = true
+		}
+	}
+	s.allocations = slices.Clone(nnc.Spec.Allocations)
+	s.releasable = slices.Clone(nnc.Spec.ReleasableCIDRs)
+
+	pods := 0
+	for _, alloc := range s.allocations {
+		if alloc.Network == s.network {
+			pods = int(alloc.Pods)
+		}
+	}
+	capacity := map[store.IPFamily]int{}
+	for _, block := range s.blocks {
+		if s.assigned[block.CIDR] {
+			capacity[prefixFamily(block.CIDR)] += store.PrefixSize(netip.MustParsePrefix(block.CIDR))
+		}
+	}
+	var err error
+	for _, block := range s.blocks {
+		family := prefixFamily(block.CIDR)
+		if s.assigned[block.CIDR] || capacity[family] >= pods {
+			continue
+		}
+		var exists bool
+		if _, exists, err = s.store.GetCIDRBlock(ctx, block.CIDR, s.network); err != nil {
+			err = fmt.Errorf("failed to check if CIDR %s is in the store: %w", block.CIDR, err)
+			break
+		}
+		if exists {
+			continue
+		}
+		s.assigned[block.CIDR] = true
+		capacity[family] += store.PrefixSize(netip.MustParsePrefix(block.CIDR))
+		changed = true
+	}
+	notify := s.notify
+	s.mu.Unlock()
+
+	if changed && notify != nil {
+		notify(s.network)
+	}
+	return err
+}
+
+// clusterCIDRBlocks returns the first maxBlocks blocks with the prefix length
+// maskSize of prefix, or all of them if maxBlocks <= 0.
+func clusterCIDRBlocks(prefix netip.Prefix, maskSize, maxBlocks int) ([]*net.IPNet, error) {
+	if maskSize < prefix.Bits() || maskSize > prefix.Addr().BitLen() {
+		return nil, fmt.Errorf("block mask size %d must be between %d and %d", maskSize, prefix.Bits(), prefix.Addr().BitLen())
+	}
+	cidrSet, err := cidrset.NewCIDRSet(&net.IPNet{IP: prefix.Addr().AsSlice(), Mask: net.CIDRMask(prefix.Bits(), prefix.Addr().BitLen())}, maskSize)
+	if err != nil {
+		return nil, err
+	}
+	_, count := cidrSet.Usage()
+	if maxBlocks > 0 && maxBlocks < count {
+		count = maxBlocks
+	}
+	if count > maxClusterCIDRBlocks {
+		return nil, fmt.Errorf("more than %d blocks of size /%d, limit the number of blocks", maxClusterCIDRBlocks, maskSize)
+	}
+
+	blocks := make([]*net.IPNet, 0, count)
+	for range count {
+		block, err := cidrSet.AllocateNext()
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// CIDRBlockList is the CIDR file of the file CIDR source, e.g.:
+//
+//	apiVersion: metis.networking.gke.io/v1alpha1
+//	kind: CIDRBlockList
+//	blocks:
+//	- network: default
+//	  cidr: 10.244.1.0/26
+//	- network: default
+//	  cidr: fd00:10:244:1::/120
+//
+// The file can be written in YAML or JSON. Unknown fields are rejected.
+type CIDRBlockList struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Blocks     []CIDRBlockItem `json:"blocks,omitempty"`
+}
+
+// CIDRBlockItem is a CIDR block of a CIDRBlockList.
+type CIDRBlockItem struct {
+	// Network is the network of the block, the default pod network if empty.
+	Network string `json:"network,omitempty"`
+	CIDR    string `json:"cidr"`
+}
+
+// ParseCIDRBlockList parses and validates a CIDR file.
+func ParseCIDRBlockList(data []byte) (*CIDRBlockList, error) {
+	list := &CIDRBlockList{}
+	if err := yaml.UnmarshalStrict(data, list); err != nil {
+		return nil, fmt.Errorf("failed to parse CIDR block list: %w", err)
+	}
+	if err := list.Validate().ToAggregate(); err != nil {
+		return nil, fmt.Errorf("invalid CIDR block list: %w", err)
+	}
+	return list, nil
+}
+
+// Validate validates the fields of the CIDRBlockList.
+func (l *CIDRBlockList) Validate() field.ErrorList {
+	var allErrs field.ErrorList
+	if l.APIVersion != CIDRBlockListAPIVersion {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("apiVersion"), l.APIVersion, []string{CIDRBlockListAPIVersion}))
+	}
+	if l.Kind != CIDRBlockListKind {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("kind"), l.Kind, []string{CIDRBlockListKind}))
+	}
+	blocksPath := field.NewPath("blocks")
+	seen := map[string]bool{}
+	for i, block := range l.Blocks {
+		cidrPath := blocksPath.Index(i).Child("cidr")
+		prefix, err := netip.ParsePrefix(block.CIDR)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(cidrPath, block.CIDR, err.Error()))
+			continue
+		}
+		if prefix != prefix.Masked() {
+			allErrs = append(allErrs, field.Invalid(cidrPath, block.CIDR, "must not have host bits set"))
+			continue
+		}
+		key := block.Network + "/" + block.CIDR
+		if seen[key] {
+			allErrs = append(allErrs, field.Duplicate(cidrPath, block.CIDR))
+		}
+		seen[key] = true
+	}
+	return allErrs
+}
+
+// FileCIDRSourceConfig holds the configuration for the file CIDR source.
+type FileCIDRSourceConfig struct {
+	Logger logr.Logger
+	// Path is the path of the CIDRBlockList file.
+	Path string
+	// Interval is how often the file is checked for changes.
+	Interval time.Duration
+}
+
+// SetDefaults applies default values to the FileCIDRSourceConfig fields if they are unset (<= 0).
+func (c *FileCIDRSourceConfig) SetDefaults() {
+	if c.Interval <= 0 {
+		c.Interval = DefaultCIDRFileReloadInterval
+	}
+}
+
+// FileCIDRSource assigns the static CIDR blocks of a CIDRBlockList file. The
+// file is reloaded periodically, and blocks removed from it are deleted from
+// the store once they are drained.
+type FileCIDRSource struct {
+	path     string
+	interval time.Duration
+	logger   logr.Logger
+
+	mu    sync.Mutex
+	cidrs []SourceCIDR
+	// data is the content of the file that was last put into effect or
+	// rejected, so that an unchanged file is not parsed nor logged again.
+	data []byte
+}
+
+// NewFileCIDRSource creates a new FileCIDRSource. Load must be called before
+// its blocks are used.
+func NewFileCIDRSource(cfg FileCIDRSourceConfig) *FileCIDRSource {
+	cfg.SetDefaults()
+	return &FileCIDRSource{
+		path:     cfg.Path,
+		interval: cfg.Interval,
+		logger:   cfg.Logger,
+	}
+}
+
+// Load loads the CIDR file. Unlike the periodic reloads of Run, it fails if
+// the file cannot be loaded.
+func (s *FileCIDRSource) Load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read CIDR file %s: %w", s.path, err)
+	}
+	if _, err := s.apply(data); err != nil {
+		return fmt.Errorf("failed to load CIDR file %s: %w", s.path, err)
+	}
+	s.logger.Info("Loaded CIDR file", "path", s.path, "blocks", len(s.cidrs))
+	return nil
+}
+
+func (s *FileCIDRSource) Name() string {
+	return CIDRSourceFile
+}
+
+func (s *FileCIDRSource) CIDRs(_ context.Context) ([]SourceCIDR, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cidrs, nil
+}
+
+// Run notifies the networks of the loaded blocks, then reloads the CIDR file
+// periodically and notifies the networks of the blocks that changed.
+func (s *FileCIDRSource) Run(ctx context.Context, notify func(network string)) {
+	s.logger.Info("Starting CIDR file reloader", "path", s.path, "interval", s.interval)
+	defer s.logger.Info("Stopping CIDR file reloader")
+
+	for network := range cidrNetworks(s.cidrs) {
+		notify(network)
+	}
+	wait.UntilWithContext(ctx, func(_ context.Context) {
+		for _, network := range s.reload() {
+			notify(network)
+		}
+	}, s.interval)
+}
+
+// reload puts the CIDR file into effect if it changed, and returns the
+// networks whose blocks were added or removed.
+func (s *FileCIDRSource) reload() []string {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		s.logger.Error(err, "Failed to read CIDR file, keeping the current blocks", "path", s.path)
+		return nil
+	}
+	s.mu.Lock()
+	unchanged := bytes.Equal(data, s.data)
+	s.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+	changed, err := s.apply(data)
+	if err != nil {
+		s.logger.Error(err, "Ignoring invalid CIDR file, keeping the current blocks", "path", s.path)
+		return nil
+	}
+	s.logger.Info("Reloaded CIDR file", "path", s.path, "changedNetworks", changed)
+	return changed
+}
+
+// apply puts the content of the CIDR file into effect and returns the
+// networks whose blocks changed.
+func (s *FileCIDRSource) apply(data []byte) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = data
+	list, err := ParseCIDRBlockList(data)
+	if err != nil {
+		return nil, err
+	}
+	cidrs := make([]SourceCIDR, 0, len(list.Blocks))
+	for _, block := range list.Blocks {
+		network := block.Network
+		if network == "" {
+			network = networkv1.DefaultPodNetworkName
+		}
+		cidrs = append(cidrs, SourceCIDR{Network: network, CIDR: block.CIDR, Ready: true})
+	}
+
+	before, after := cidrNetworks(s.cidrs), cidrNetworks(cidrs)
+	var changed []string
+	for network, blocks := range after {
+		if !sameBlocks(blocks, before[network]) {
+			changed = append(changed, network)
+		}
+	}
+	for network := range before {
+		if _, ok := after[network]; !ok {
+			changed = append(changed, network)
+		}
+	}
+	s.cidrs = cidrs
+	return changed, nil
+}
+
+// cidrNetworks groups the blocks of cidrs by network.
+func cidrNetworks(cidrs []SourceCIDR) map[string]map[string]bool {
+	networks := map[string]map[string]bool{}
+	for _, c := range cidrs {
+		if networks[c.Network] == nil {
+			networks[c.Network] = map[string]bool{}
+		}
+		networks[c.Network][c.CIDR] = true
+	}
+	return networks
+}
+
+func sameBlocks(a, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for cidr := range a {
+		if !b[cidr] {
+			return false
+		}
+	}
+	return true
+}
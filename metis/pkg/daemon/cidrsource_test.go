@@ -0,0 +1,396 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	"github.com/go-logr/logr"
+	"k8s.io/metis/pkg/store"
+)
+
+func TestNewClusterCIDRSource(t *testing.T) {
+	tests := []struct {
+		desc    string
+		cfg     ClusterCIDRSourceConfig
+		want    []string
+		wantErr bool
+	}{
+		{
+			desc: "IPv4 blocks",
+			cfg:  ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.244.1.0/26"}},
+			want: []string{"10.244.1.0/28", "10.244.1.16/28", "10.244.1.32/28", "10.244.1.48/28"},
+		},
+		{
+			desc: "dual-stack blocks limited by MaxBlocks",
+			cfg: ClusterCIDRSourceConfig{
+				ClusterCIDRs:      []string{"10.244.1.0/24", "fd00:10:244:1::/64"},
+				BlockMaskSize:     26,
+				IPv6BlockMaskSize: 80,
+				MaxBlocks:         2,
+			},
+			want: []string{"10.244.1.0/26", "10.244.1.64/26", "fd00:10:244:1::/80", "fd00:10:244:1:1::/80"},
+		},
+		{
+			desc: "host bits of the cluster CIDR are ignored",
+			cfg:  ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.244.1.7/27"}},
+			want: []string{"10.244.1.0/28", "10.244.1.16/28"},
+		},
+		{
+			desc:    "no cluster CIDR",
+			wantErr: true,
+		},
+		{
+			desc:    "invalid cluster CIDR",
+			cfg:     ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.244.1.0"}},
+			wantErr: true,
+		},
+		{
+			desc:    "two cluster CIDRs of the same family",
+			cfg:     ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.244.1.0/24", "10.244.2.0/24"}},
+			wantErr: true,
+		},
+		{
+			desc:    "blocks larger than the cluster CIDR",
+			cfg:     ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.244.1.0/24"}, BlockMaskSize: 23},
+			wantErr: true,
+		},
+		{
+			desc: "IPv6 blocks of the default size",
+			cfg:  ClusterCIDRSourceConfig{ClusterCIDRs: []string{"fd00:10:244:1::/112"}, MaxBlocks: 2},
+			want: []string{"fd00:10:244:1::/120", "fd00:10:244:1::100/120"},
+		},
+		{
+			desc:    "too many blocks",
+			cfg:     ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.0.0.0/8"}},
+			wantErr: true,
+		},
+		{
+			desc:    "IPv6 blocks more than 16 bits longer than the cluster CIDR",
+			cfg:     ClusterCIDRSourceConfig{ClusterCIDRs: []string{"fd00:10:244:1::/64"}, MaxBlocks: 2},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			source, err := NewClusterCIDRSource(tc.cfg)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("Expected NewClusterCIDRSource to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClusterCIDRSource failed: %v", err)
+			}
+			cidrs, err := source.CIDRs(context.Background())
+			if err != nil {
+				t.Fatalf("CIDRs failed: %v", err)
+			}
+			var got []string
+			for _, cidr := range cidrs {
+				if cidr.Network != "default" || !cidr.Ready {
+					t.Errorf("Unexpected source CIDR %+v", cidr)
+				}
+				got = append(got, cidr.CIDR)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected blocks %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestClusterCIDRSource_Scaling(t *testing.T) {
+	ctx := context.Background()
+	network := "default"
+	storeInstance, err := store.NewStore(ctx, logr.Discard(), filepath.Join(t.TempDir(), "metis_cluster_cidr_scaling_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+
+	source, err := NewClusterCIDRSource(ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.244.1.0/26"}})
+	if err != nil {
+		t.Fatalf("NewClusterCIDRSource failed: %v", err)
+	}
+	s := source.(*clusterCIDRSource)
+	if err := s.enableScaling(ctx, storeInstance); err != nil {
+		t.Fatalf("enableScaling failed: %v", err)
+	}
+	notified := make(chan string, 10)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx, func(network string) { notified <- network })
+	}()
+	<-notified
+
+	assertAssigned := func(desc string, want ...string) {
+		t.Helper()
+		cidrs, err := s.CIDRs(ctx)
+		if err != nil {
+			t.Fatalf("%s: CIDRs failed: %v", desc, err)
+		}
+		var got []string
+		for _, cidr := range cidrs {
+			got = append(got, cidr.CIDR)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: expected assigned blocks %v, got %v", desc, want, got)
+		}
+		nnc, err := s.NodeNetworkConfig(ctx)
+		if err != nil {
+			t.Fatalf("%s: NodeNetworkConfig failed: %v", desc, err)
+		}
+		if len(nnc.Status.PodCIDRs) != len(want) {
+			t.Errorf("%s: expected %d pod CIDRs in the status, got %v", desc, len(want), nnc.Status.PodCIDRs)
+		}
+	}
+	assertAssigned("initial capacity", "10.244.1.0/28")
+
+	// Scale-up assigns blocks until the requested pods fit.
+	spec := &nncv1.NodeNetworkConfig{Spec: nncv1.NodeNetworkConfigSpec{
+		Allocations: []nncv1.Allocation{{Network: network, Pods: 40}},
+	}}
+	if err := s.UpdateSpec(ctx, spec); err != nil {
+		t.Fatalf("UpdateSpec failed: %v", err)
+	}
+	assertAssigned("scale-up", "10.244.1.0/28", "10.244.1.16/28", "10.244.1.32/28")
+	if len(notified) != 1 {
+		t.Errorf("expected the network to be notified of the scale-up, got %d notifications", len(notified))
+	}
+
+	// Releasable blocks are returned to the pool.
+	spec.Spec.Allocations[0].Pods = 32
+	spec.Spec.ReleasableCIDRs = []nncv1.PodCIDR{{Network: network, CIDR: "10.244.1.16/28"}}
+	if err := s.UpdateSpec(ctx, spec); err != nil {
+		t.Fatalf("UpdateSpec failed: %v", err)
+	}
+	assertAssigned("release", "10.244.1.0/28", "10.244.1.32/28")
+
+	// A returned block still in the store is not assigned again.
+	if err := storeInstance.AddCIDR(ctx, network, "10.244.1.16/28"); err != nil {
+		t.Fatalf("AddCIDR failed: %v", err)
+	}
+	spec.Spec.Allocations[0].Pods = 64
+	spec.Spec.ReleasableCIDRs = nil
+	if err := s.UpdateSpec(ctx, spec); err != nil {
+		t.Fatalf("UpdateSpec failed: %v", err)
+	}
+	assertAssigned("scale-up past a block in the store", "10.244.1.0/28", "10.244.1.32/28", "10.244.1.48/28")
+
+	// The blocks in the store stay assigned when scaling is enabled again,
+	// e.g. after a restart.
+	restarted, err := NewClusterCIDRSource(ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.244.1.0/26"}})
+	if err != nil {
+		t.Fatalf("NewClusterCIDRSource failed: %v", err)
+	}
+	s = restarted.(*clusterCIDRSource)
+	if err := s.enableScaling(ctx, storeInstance); err != nil {
+		t.Fatalf("enableScaling failed: %v", err)
+	}
+	assertAssigned("restart", "10.244.1.16/28")
+
+	cancel()
+	<-done
+}
+
+func TestParseCIDRBlockList(t *testing.T) {
+	header := "apiVersion: metis.networking.gke.io/v1alpha1\nkind: CIDRBlockList\n"
+	tests := []struct {
+		desc    string
+		data    string
+		wantErr bool
+	}{
+		{desc: "valid", data: header + "blocks:\n- cidr: 10.244.1.0/26\n- network: gpu-network\n  cidr: 10.245.0.0/28\n"},
+		{desc: "empty", data: header},
+		{desc: "wrong kind", data: "apiVersion: metis.networking.gke.io/v1alpha1\nkind: NetworkConfiguration\n", wantErr: true},
+		{desc: "unknown field", data: header + "blocks:\n- cidr: 10.244.1.0/26\n  pods: 4\n", wantErr: true},
+		{desc: "invalid CIDR", data: header + "blocks:\n- cidr: 10.244.1.0\n", wantErr: true},
+		{desc: "host bits set", data: header + "blocks:\n- cidr: 10.244.1.1/26\n", wantErr: true},
+		{desc: "duplicate block", data: header + "blocks:\n- cidr: 10.244.1.0/26\n- cidr: 10.244.1.0/26\n", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ParseCIDRBlockList([]byte(tc.data))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ParseCIDRBlockList() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileCIDRSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cidrs.yaml")
+	write := func(blocks string) {
+		t.Helper()
+		data := "apiVersion: metis.networking.gke.io/v1alpha1\nkind: CIDRBlockList\nblocks:\n" + blocks
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatalf("Failed to write CIDR file: %v", err)
+		}
+	}
+	cidrsOf := func(source *FileCIDRSource) []SourceCIDR {
+		t.Helper()
+		cidrs, err := source.CIDRs(context.Background())
+		if err != nil {
+			t.Fatalf("CIDRs failed: %v", err)
+		}
+		return cidrs
+	}
+
+	source := NewFileCIDRSource(FileCIDRSourceConfig{Logger: logr.Discard(), Path: path})
+	if err := source.Load(); err == nil {
+		t.Error("Expected Load of a missing file to fail")
+	}
+
+	write("- cidr: 10.244.1.0/26\n- network: gpu-network\n  cidr: 10.245.0.0/28\n")
+	if err := source.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := []SourceCIDR{
+		{Network: "default", CIDR: "10.244.1.0/26", Ready: true},
+		{Network: "gpu-network", CIDR: "10.245.0.0/28", Ready: true},
+	}
+	if got := cidrsOf(source); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected CIDRs %+v, got %+v", want, got)
+	}
+
+	if changed := source.reload(); len(changed) != 0 {
+		t.Errorf("Expected no changed networks for an unchanged file, got %v", changed)
+	}
+
+	// Only the networks whose blocks changed are reported.
+	write("- cidr: 10.244.1.0/26\n- cidr: 10.244.1.64/26\n")
+	changed := source.reload()
+	slices.Sort(changed)
+	if want := []string{"default", "gpu-network"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("Expected changed networks %v, got %v", want, changed)
+	}
+	if got := cidrsOf(source); len(got) != 2 || got[1].CIDR != "10.244.1.64/26" {
+		t.Errorf("Unexpected CIDRs after reload: %+v", got)
+	}
+
+	write("- cidr: 10.244.1.0/26\n- cidr: 10.244.1.64/26\n  pods: 4\n")
+	if changed := source.reload(); len(changed) != 0 {
+		t.Errorf("Expected an invalid file to be ignored, got changed networks %v", changed)
+	}
+	if got := cidrsOf(source); len(got) != 2 {
+		t.Errorf("Expected the blocks to be kept after an invalid reload, got %+v", got)
+	}
+}
+
+// staticCIDRSource is a CIDRSource with fixed blocks for tests.
+type staticCIDRSource struct {
+	name  string
+	cidrs []SourceCIDR
+}
+
+func (s *staticCIDRSource) Name() string { return s.name }
+
+func (s *staticCIDRSource) CIDRs(_ context.Context) ([]SourceCIDR, error) { return s.cidrs, nil }
+
+func (s *staticCIDRSource) Run(_ context.Context, _ func(network string)) {}
+
+func TestWatcher_SyncCIDRMultipleSources(t *testing.T) {
+	ctx := context.Background()
+	network := "default"
+	storeInstance, err := store.NewStore(ctx, logr.Discard(), filepath.Join(t.TempDir(), "metis_watcher_sources_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+
+	clusterCIDR, err := NewClusterCIDRSource(ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.244.1.0/27"}})
+	if err != nil {
+		t.Fatalf("NewClusterCIDRSource failed: %v", err)
+	}
+	file := &staticCIDRSource{name: "file", cidrs: []SourceCIDR{
+		{Network: network, CIDR: "10.244.2.0/28", Ready: true},
+		{Network: network, CIDR: "10.244.3.0/28"},
+		{Network: "other", CIDR: "10.244.4.0/28", Ready: true},
+	}}
+
+	var added []string
+	w := NewWatcher(WatcherConfig{
+		Logger:  logr.Discard(),
+		Sources: []CIDRSource{clusterCIDR, file},
+		Store:   storeInstance,
+		OnCIDRAdded: func(_ string, _ store.IPFamily, availableIPs int) {
+			added = append(added, "added")
+			if availableIPs != 16 {
+				t.Errorf("Expected 16 available IPs, got %d", availableIPs)
+			}
+		},
+	})
+	if err := w.syncCIDR(ctx, network); err != nil {
+		t.Fatalf("syncCIDR failed: %v", err)
+	}
+	if len(added) != 3 {
+		t.Errorf("Expected 3 added blocks, got %d", len(added))
+	}
+	for cidr, want := range map[string]bool{
+		"10.244.1.0/28":  true,
+		"10.244.1.16/28": true,
+		"10.244.2.0/28":  true,
+		"10.244.3.0/28":  false,
+		"10.244.4.0/28":  false,
+	} {
+		if _, exists, err := storeInstance.GetCIDRBlock(ctx, cidr, network); err != nil || exists != want {
+			t.Errorf("GetCIDRBlock(%s) = %v, %v, want %v", cidr, exists, err, want)
+		}
+	}
+
+	// A Deleting block is kept while a source still assigns it, and deleted
+	// once it is removed from the source.
+	blocks, err := storeInstance.GetReadyCIDRBlocksSorted(ctx, network, store.IPv4)
+	if err != nil {
+		t.Fatalf("GetReadyCIDRBlocksSorted failed: %v", err)
+	}
+	var fileBlockID int64
+	for _, block := range blocks {
+		if block.CIDR == "10.244.2.0/28" {
+			fileBlockID = block.ID
+		}
+	}
+	if err := storeInstance.MarkCIDRBlockAsDeletingForTest(ctx, fileBlockID); err != nil {
+		t.Fatalf("Failed to mark block as Deleting: %v", err)
+	}
+	if err := w.syncCIDR(ctx, network); err != nil {
+		t.Fatalf("syncCIDR failed: %v", err)
+	}
+	if _, exists, _ := storeInstance.GetCIDRBlock(ctx, "10.244.2.0/28", network); !exists {
+		t.Error("Expected the Deleting block still assigned by the file source to be kept")
+	}
+	file.cidrs = file.cidrs[1:]
+	if err := w.syncCIDR(ctx, network); err != nil {
+		t.Fatalf("syncCIDR failed: %v", err)
+	}
+	if _, exists, _ := storeInstance.GetCIDRBlock(ctx, "10.244.2.0/28", network); exists {
+		t.Error("Expected the Deleting block removed from the file source to be deleted")
+	}
+}
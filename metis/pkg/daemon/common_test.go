@@ -17,7 +17,10 @@ limitations under the License.
 package daemon
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"slices"
 
 	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
 	nncclientset "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned"
@@ -31,6 +34,9 @@ type mockNodeNetworkConfigInterface struct {
 	getFunc    func(ctx context.Context, name string, opts metav1.GetOptions) (*nncv1.NodeNetworkConfig, error)
 	updateFunc func(ctx context.Context, nnc *nncv1.NodeNetworkConfig, opts metav1.UpdateOptions) (*nncv1.NodeNetworkConfig, error)
 	patchFunc  func(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*nncv1.NodeNetworkConfig, error)
+	// patchStatusFunc receives the patches of the status subresource and of
+	// IPUtilizationAnnotation, which are ignored if it is nil.
+	patchStatusFunc func(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions) (*nncv1.NodeNetworkConfig, error)
 }
 
 func (m *mockNodeNetworkConfigInterface) Get(ctx context.Context, name string, opts metav1.GetOptions) (*nncv1.NodeNetworkConfig, error) {
@@ -48,12 +54,29 @@ func (m *mockNodeNetworkConfigInterface) Update(ctx context.Context, nnc *nncv1.
 }
 
 func (m *mockNodeNetworkConfigInterface) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*nncv1.NodeNetworkConfig, error) {
+	if slices.Equal(subresources, []string{"status"}) || isUtilizationAnnotationPatch(data) {
+		if m.patchStatusFunc != nil {
+			return m.patchStatusFunc(ctx, name, pt, data, opts)
+		}
+		return nil, nil
+	}
 	if m.patchFunc != nil {
 		return m.patchFunc(ctx, name, pt, data, opts, subresources...)
 	}
 	return nil, nil
 }
 
+// isUtilizationAnnotationPatch reports whether data is the patch of
+// IPUtilizationAnnotation published with the IP utilization.
+func isUtilizationAnnotationPatch(data []byte) bool {
+	var patch map[string]any
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return false
+	}
+	_, hasSpec := patch["spec"]
+	return !hasSpec && bytes.Contains(data, []byte(IPUtilizationAnnotation))
+}
+
 type mockNetworkingV1 struct {
 	nnctypedv1.NetworkingV1Interface
 	nncInterface nnctypedv1.NodeNetworkConfigInterface
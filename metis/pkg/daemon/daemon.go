@@ -19,12 +19,15 @@ package daemon
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
 	"time"
 
 	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
 	nncclientset "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned"
 	"github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/informers/externalversions"
+	nncinformers "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/informers/externalversions/nodenetworkconfig/v1"
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -48,6 +51,67 @@ type Config struct {
 	LowUtilizationThreshold         float64
 	TargetUtilizationAfterScaleUp   float64
 	CooldownPushbackThreshold       int
+	// ScalingPolicy is the name of the scaling policy of networks without a
+	// policy in NetworkScalingPolicies or the NodeNetworkConfig annotation.
+	ScalingPolicy string
+	// NetworkScalingPolicies maps network names to the name of their scaling policy.
+	NetworkScalingPolicies map[string]string
+	// MinFreeIPs is the number of free IPs kept by the min-free-ips policy.
+	MinFreeIPs int
+	// RateWindow is the window the rate-based policy measures the demand growth over.
+	RateWindow time.Duration
+	// RateLeadTime is how far ahead the rate-based policy pre-warms IPs for.
+	RateLeadTime time.Duration
+	// GCInterval is the period of the garbage collection passes releasing the
+	// IPs of pods that no longer exist. A pass also runs at startup.
+	GCInterval time.Duration
+	// GCCheckNetns enables releasing IPs whose sandbox network namespace no longer exists.
+	GCCheckNetns bool
+	// GCDryRun makes the garbage collection passes only log the stale IPs.
+	GCDryRun bool
+	// HistoryMaxAge and HistoryMaxRecords bound the allocation history kept
+	// in the store.
+	HistoryMaxAge     time.Duration
+	HistoryMaxRecords int
+	// SnapshotDir is the directory of the store snapshots, next to the
+	// database file if empty. The daemon restores the newest valid snapshot
+	// at startup if the database fails its integrity check.
+	SnapshotDir string
+	// SnapshotInterval is the period of the store snapshots written to
+	// SnapshotDir. Periodic snapshots are disabled if it is <= 0.
+	SnapshotInterval time.Duration
+	// SnapshotRetention is the number of snapshots kept in SnapshotDir.
+	SnapshotRetention int
+	// RebuildState forces the store state to be rebuilt from the
+	// NodeNetworkConfig and the running pods of the node at startup. The
+	// state is always rebuilt if the database was lost or restored from a
+	// snapshot.
+	RebuildState bool
+	// NetworkConfigFile is the path of an optional network configuration file
+	// overriding the settings above for all or specific networks. It is
+	// reloaded every NetworkConfigReloadInterval.
+	NetworkConfigFile           string
+	NetworkConfigReloadInterval time.Duration
+	// CIDRSources are the names of the sources of the CIDR blocks of the
+	// node, CIDRSourceNNC if empty. Without CIDRSourceNNC the daemon does
+	// not use the NodeNetworkConfig, and only the network of the
+	// CIDRSourceClusterCIDR source scales, within its cluster CIDRs.
+	CIDRSources []string
+	// ClusterCIDR configures the CIDRSourceClusterCIDR source.
+	ClusterCIDR ClusterCIDRSourceConfig
+	// CIDRFile is the CIDRBlockList file of the CIDRSourceFile source. It is
+	// reloaded every CIDRFileReloadInterval.
+	CIDRFile               string
+	CIDRFileReloadInterval time.Duration
+	// UtilizationReportInterval is the minimum interval between two updates
+	// of the IP utilization the monitor publishes in the NodeNetworkConfig status.
+	UtilizationReportInterval time.Duration
+	// MetricsBindAddress is the TCP address to serve Prometheus metrics on.
+	// The metrics listener is disabled if empty.
+	MetricsBindAddress string
+	// OTLPEndpoint is the address of an OTLP gRPC collector to export the
+	// spans of the gRPC requests to. Tracing is disabled if empty.
+	OTLPEndpoint string
 }
 
 // Daemon represents the metis daemon process.
@@ -81,14 +145,10 @@ func (d *Daemon) Run(ctx context.Context) error {
 	if dbPath == "" {
 		dbPath = pkg.DefaultDBPath
 	}
-
-	storeInstance, err := store.NewStore(ctx, logger, dbPath)
-	if err != nil {
-		return fmt.Errorf("failed to initialize sqlite store: %w", err)
+	snapshotDir := d.Config.SnapshotDir
+	if snapshotDir == "" {
+		snapshotDir = filepath.Join(filepath.Dir(dbPath), "snapshots")
 	}
-	defer storeInstance.Close()
-
-	server := newAdaptiveIpamServer(logger, storeInstance, d.Config.SocketPath, d.Config.ReleaseCooldown, store.DefaultBusyTimeout)
 
 	if d.NNCClient == nil || d.KubeClient == nil {
 		var err error
@@ -103,8 +163,19 @@ func (d *Daemon) Run(ctx context.Context) error {
 		return err
 	}
 
-	if err := d.ensureNodeNetworkConfig(ctx, nodeName, logger); err != nil {
-		return err
+	sourceNames := d.Config.CIDRSources
+	if len(sourceNames) == 0 {
+		sourceNames = []string{CIDRSourceNNC}
+	}
+	useNNC := false
+	for _, name := range sourceNames {
+		useNNC = useNNC || name == CIDRSourceNNC
+	}
+
+	if useNNC {
+		if err := d.ensureNodeNetworkConfig(ctx, nodeName, logger); err != nil {
+			return err
+		}
 	}
 
 	nncInformerFactory := externalversions.NewSharedInformerFactoryWithOptions(d.NNCClient, 0,
@@ -113,38 +184,211 @@ func (d *Daemon) Run(ctx context.Context) error {
 		}),
 	)
 	nncInformer := nncInformerFactory.Networking().V1().NodeNetworkConfigs()
+	sources, err := d.newCIDRSources(logger, sourceNames, nncInformer, nodeName)
+	if err != nil {
+		return err
+	}
+
+	restored, err := recoverStoreFile(ctx, logger, dbPath, snapshotDir)
+	if err != nil {
+		return fmt.Errorf("failed to check sqlite store: %w", err)
+	}
+	// A missing database is rebuilt, which is a no-op on a new node.
+	_, err = os.Stat(dbPath)
+	lost := os.IsNotExist(err)
 
-	watcher := NewWatcher(WatcherConfig{
+	storeInstance, err := store.NewStore(ctx, logger, dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize sqlite store: %w", err)
+	}
+	defer storeInstance.Close()
+
+	// Without the NodeNetworkConfig, the cluster CIDR source assigns the
+	// blocks its network scales with.
+	var capacity CapacityProvider
+	if !useNNC {
+		for _, source := range sources {
+			if clusterCIDR, ok := source.(*clusterCIDRSource); ok {
+				if err := clusterCIDR.enableScaling(ctx, storeInstance); err != nil {
+					return err
+				}
+				capacity = clusterCIDR
+			}
+		}
+	}
+
+	registerDaemonMetrics()
+
+	shutdownTracing, err := setupTracing(ctx, logger, d.Config.OTLPEndpoint)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+
+	server := newAdaptiveIpamServer(logger, storeInstance, d.Config.SocketPath, d.Config.ReleaseCooldown, store.DefaultBusyTimeout)
+
+	server.snapshots = NewSnapshotter(SnapshotterConfig{
+		Logger:     logger,
+		Store:      storeInstance,
+		KubeClient: d.KubeClient,
+		NNCClient:  d.NNCClient,
+		Sources:    sources,
+		NodeName:   nodeName,
+		Dir:        snapshotDir,
+		Interval:   d.Config.SnapshotInterval,
+		Retention:  d.Config.SnapshotRetention,
+	})
+	if restored {
+		if err := server.snapshots.deleteUnassignedCIDRBlocks(ctx); err != nil {
+			return err
+		}
+	}
+	if lost || restored || d.Config.RebuildState {
+		rebuilder := NewStateRebuilder(StateRebuilderConfig{
+			Logger:     logger,
+			KubeClient: d.KubeClient,
+			NNCClient:  d.NNCClient,
+			Sources:    sources,
+			Store:      storeInstance,
+			NodeName:   nodeName,
+		})
+		if err := rebuilder.Rebuild(ctx); err != nil {
+			return fmt.Errorf("failed to rebuild store state: %w", err)
+		}
+	}
+
+	watcherConfig := WatcherConfig{
 		Logger:      logger,
-		NNCClient:   d.NNCClient,
-		NNCInformer: nncInformer,
+		Sources:     sources,
 		Store:       storeInstance,
 		NodeName:    nodeName,
 		OnCIDRAdded: server.onCIDRAdded,
-	})
-	monitorInstance := NewMonitor(MonitorConfig{
-		Logger:                          logger,
-		NNCClient:                       d.NNCClient,
-		NNCInformer:                     nncInformer,
-		Store:                           storeInstance,
-		NodeName:                        nodeName,
-		GetPendingRequestsCount:         server.getPendingRequestsCount,
-		CooldownPushbackInterval:        DefaultCooldownPushbackInterval,
+		Events:      server.events,
+	}
+	if useNNC {
+		watcherConfig.NNCClient = d.NNCClient
+		watcherConfig.NNCInformer = nncInformer
+	}
+	watcher := NewWatcher(watcherConfig)
+	settings := NewNetworkSettingsSource(NetworkSettings{
+		ReleaseCooldown:                 d.Config.ReleaseCooldown,
 		DrainingExpiration:              d.Config.DrainingExpiration,
-		MonitorInterval:                 d.Config.MonitorInterval,
 		SustainedLowUtilizationDuration: d.Config.SustainedLowUtilizationDuration,
 		LowUtilizationThreshold:         d.Config.LowUtilizationThreshold,
 		TargetUtilizationAfterScaleUp:   d.Config.TargetUtilizationAfterScaleUp,
 		CooldownPushbackThreshold:       d.Config.CooldownPushbackThreshold,
+		CooldownPushbackInterval:        DefaultCooldownPushbackInterval,
+		ScalingPolicy:                   d.Config.ScalingPolicy,
+		MinFreeIPs:                      d.Config.MinFreeIPs,
+		RateWindow:                      d.Config.RateWindow,
+		RateLeadTime:                    d.Config.RateLeadTime,
+	}, d.Config.NetworkScalingPolicies)
+
+	monitorConfig := MonitorConfig{
+		Logger:                  logger,
+		NNCClient:               d.NNCClient,
+		Store:                   storeInstance,
+		NodeName:                nodeName,
+		GetPendingRequestsCount: server.getPendingRequestsCount,
+		MonitorInterval:         d.Config.MonitorInterval,
+		Settings:                settings,
+		Events:                  server.events,
+
+		UtilizationReportInterval: d.Config.UtilizationReportInterval,
+	}
+	if useNNC {
+		monitorConfig.NNCInformer = nncInformer
+	} else {
+		monitorConfig.CapacityProvider = capacity
+	}
+	monitorInstance := NewMonitor(monitorConfig)
+	// The file CIDR source assigns a fixed set of blocks, allocations fail
+	// fast once they are exhausted.
+	scaling := useNNC || capacity != nil
+
+	kubeEvents := NewKubeEventRecorder(KubeEventRecorderConfig{
+		Logger:      logger,
+		KubeClient:  d.KubeClient,
+		NNCClient:   d.NNCClient,
+		NNCInformer: nncInformer,
+		NodeName:    nodeName,
 	})
 
-	server.engine.SetMonitor(monitorInstance)
+	if scaling {
+		server.engine.SetMonitor(monitorInstance)
+	}
+	server.engine.SetNetworkSettings(settings)
+	server.engine.SetKubeEventRecorder(kubeEvents)
+
+	var reloader *NetworkConfigReloader
+	if d.Config.NetworkConfigFile != "" {
+		reloader = NewNetworkConfigReloader(NetworkConfigReloaderConfig{
+			Logger:   logger,
+			Path:     d.Config.NetworkConfigFile,
+			Interval: d.Config.NetworkConfigReloadInterval,
+			Settings: settings,
+			OnChange: monitorInstance.enqueue,
+		})
+		if err := reloader.Load(); err != nil {
+			return err
+		}
+	}
+
+	server.gc = NewGarbageCollector(GarbageCollectorConfig{
+		Logger:          logger,
+		KubeClient:      d.KubeClient,
+		Store:           storeInstance,
+		NodeName:        nodeName,
+		ReleaseCooldown: d.Config.ReleaseCooldown,
+		Settings:        settings,
+		Interval:        d.Config.GCInterval,
+		CheckNetns:      d.Config.GCCheckNetns,
+		DryRun:          d.Config.GCDryRun,
+		Events:          server.events,
+	})
+
+	historyPruner := NewHistoryPruner(HistoryPrunerConfig{
+		Logger:     logger,
+		Store:      storeInstance,
+		MaxAge:     d.Config.HistoryMaxAge,
+		MaxRecords: d.Config.HistoryMaxRecords,
+	})
+
+	// The metrics listener is bound before any goroutine is started, so that
+	// returning on a bind error does not close the store under them.
+	var metricsListener net.Listener
+	if d.Config.MetricsBindAddress != "" {
+		metricsListener, err = net.Listen("tcp", d.Config.MetricsBindAddress)
+		if err != nil {
+			return fmt.Errorf("failed to listen on metrics address %s: %w", d.Config.MetricsBindAddress, err)
+		}
+	}
 
-	// TODO: Replace with nncInformerFactory.StartWithContext(ctx) once the
-	// gke-networking-api library is updated to generate StartWithContext.
-	nncInformerFactory.Start(ctx.Done())
 	go watcher.Run(ctx, defaultWatcherWorkers)
-	go monitorInstance.Run(ctx)
+	if useNNC {
+		// TODO: Replace with nncInformerFactory.StartWithContext(ctx) once the
+		// gke-networking-api library is updated to generate StartWithContext.
+		nncInformerFactory.Start(ctx.Done())
+	}
+	if scaling {
+		go monitorInstance.Run(ctx)
+	}
+	go server.gc.Run(ctx)
+	go historyPruner.Run(ctx)
+	go server.snapshots.Run(ctx)
+	go kubeEvents.Run(ctx)
+	if reloader != nil {
+		go reloader.Run(ctx)
+	}
+
+	// metricsErrCh stays nil, and thus never ready, when metrics are disabled.
+	var metricsErrCh chan error
+	if metricsListener != nil {
+		metricsErrCh = make(chan error, 1)
+		go func() {
+			metricsErrCh <- serveMetrics(ctx, logger, metricsListener)
+		}()
+	}
 
 	errCh := make(chan error, 1)
 	go func() {
@@ -156,6 +400,12 @@ func (d *Daemon) Run(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("server failed: %w", err)
 		}
+	case err := <-metricsErrCh:
+		// The metrics server only returns without an error once ctx is done.
+		server.stop()
+		if err != nil {
+			return fmt.Errorf("metrics server failed: %w", err)
+		}
 	case <-ctx.Done():
 		logger.Info("Context cancelled, shutting down daemon")
 		server.stop()
@@ -164,6 +414,46 @@ func (d *Daemon) Run(ctx context.Context) error {
 	return nil
 }
 
+// newCIDRSources creates the CIDR sources of the node by name.
+func (d *Daemon) newCIDRSources(logger logr.Logger, names []string, nncInformer nncinformers.NodeNetworkConfigInformer, nodeName string) ([]CIDRSource, error) {
+	var sources []CIDRSource
+	seen := map[string]bool{}
+	for _, name := range names {
+		if err := ValidateCIDRSourceName(name); err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		switch name {
+		case CIDRSourceNNC:
+			sources = append(sources, NewNNCCIDRSource(d.NNCClient, nncInformer, nodeName))
+		case CIDRSourceClusterCIDR:
+			source, err := NewClusterCIDRSource(d.Config.ClusterCIDR)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create %s CIDR source: %w", name, err)
+			}
+			sources = append(sources, source)
+		case CIDRSourceFile:
+			if d.Config.CIDRFile == "" {
+				return nil, fmt.Errorf("no CIDR file configured for the %s CIDR source", name)
+			}
+			source := NewFileCIDRSource(FileCIDRSourceConfig{
+				Logger:   logger,
+				Path:     d.Config.CIDRFile,
+				Interval: d.Config.CIDRFileReloadInterval,
+			})
+			if err := source.Load(); err != nil {
+				return nil, err
+			}
+			sources = append(sources, source)
+		}
+	}
+	logger.Info("Using CIDR sources", "sources", names)
+	return sources, nil
+}
+
 // ensureNodeNetworkConfig creates the NodeNetworkConfig CR if it does not exist.
 func (d *Daemon) ensureNodeNetworkConfig(ctx context.Context, nodeName string, logger logr.Logger) error {
 	_, err := d.NNCClient.NetworkingV1().NodeNetworkConfigs().Get(ctx, nodeName, metav1.GetOptions{})
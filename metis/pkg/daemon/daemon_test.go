@@ -19,6 +19,7 @@ package daemon
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -83,6 +84,79 @@ func TestDaemon_Run(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "invalid network configuration file",
+			setupDaemon: func(t *testing.T, d *Daemon) {
+				d.NNCClient = nncfake.NewSimpleClientset(&nncv1.NodeNetworkConfig{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-node",
+					},
+				})
+				d.KubeClient = kubefake.NewSimpleClientset(&corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-node",
+					},
+				})
+				d.Config.NetworkConfigFile = filepath.Join(t.TempDir(), "network-config.yaml")
+				if err := os.WriteFile(d.Config.NetworkConfigFile, []byte("kind: NetworkConfiguration\n"), 0600); err != nil {
+					t.Fatalf("Failed to write network configuration: %v", err)
+				}
+			},
+			wantErr:     true,
+			errContains: "failed to load network configuration",
+		},
+		{
+			name: "successful run with a cluster CIDR source and no NodeNetworkConfig",
+			setupDaemon: func(_ *testing.T, d *Daemon) {
+				d.NNCClient = nncfake.NewSimpleClientset()
+				d.KubeClient = kubefake.NewSimpleClientset(&corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-node",
+					},
+				})
+				d.Config.CIDRSources = []string{CIDRSourceClusterCIDR}
+				d.Config.ClusterCIDR = ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.244.1.0/24"}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid cluster CIDR source",
+			setupDaemon: func(_ *testing.T, d *Daemon) {
+				d.NNCClient = nncfake.NewSimpleClientset()
+				d.KubeClient = kubefake.NewSimpleClientset(&corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-node",
+					},
+				})
+				d.Config.CIDRSources = []string{CIDRSourceClusterCIDR}
+				d.Config.ClusterCIDR = ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.244.1.0/24"}, BlockMaskSize: 20}
+			},
+			wantErr:     true,
+			errContains: "failed to create cluster-cidr CIDR source",
+		},
+		{
+			name: "metrics address in use",
+			setupDaemon: func(t *testing.T, d *Daemon) {
+				d.NNCClient = nncfake.NewSimpleClientset(&nncv1.NodeNetworkConfig{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-node",
+					},
+				})
+				d.KubeClient = kubefake.NewSimpleClientset(&corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-node",
+					},
+				})
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatalf("Failed to listen: %v", err)
+				}
+				t.Cleanup(func() { listener.Close() })
+				d.Config.MetricsBindAddress = listener.Addr().String()
+			},
+			wantErr:     true,
+			errContains: "failed to listen on metrics address",
+		},
 		{
 			name:        "both clients nil (initClients fails)",
 			setupDaemon: func(_ *testing.T, _ *Daemon) {},
@@ -20,15 +20,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/netip"
 	"sync"
 	"time"
 
 	networkv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/network/v1"
 	"github.com/go-logr/logr"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/metis/api/adaptiveipam/v1"
+	"k8s.io/metis/pkg"
 	"k8s.io/metis/pkg/store"
 )
 
@@ -38,11 +39,15 @@ const (
 	// It must be smaller than the CNI client-side RPC timeout (defaultRPCTimeout = 10s)
 	// to allow the server to return a clean error response before the client times out.
 	scaleUpWaitTimeout = 9 * time.Second
+	// defaultReleaseUnusedIPTimeout bounds the release of the IP of a failed
+	// dual-stack allocation, which outlives the context of the request.
+	defaultReleaseUnusedIPTimeout = 5 * time.Second
 )
 
 type cniClient struct {
 	containerID  string
 	network      string
+	ipFamily     store.IPFamily
 	podName      string
 	podNamespace string
 }
@@ -58,15 +63,29 @@ type IPAMEngine struct {
 	// to be dynamically allocated. It is organized per-network (outer map key is network name)
 	// to optimize lookups and avoid iterating over all waiting clients from other networks.
 	// The inner map associates each blocked cniClient to a channel that is closed to wake
-	// it up when new IPs become available.
+	// it up when new IPs of the client's IP family become available.
 	requestsMap map[string]map[cniClient]chan struct{}
 	requestsMu  sync.RWMutex
-	monitor     *Monitor
+	// storeMu is held for reading by the RPCs that allocate or release IPs,
+	// and for writing while the store is restored from a snapshot, so that no
+	// IP is allocated before the ownership of the IPs is rebuilt.
+	storeMu sync.RWMutex
+	monitor *Monitor
+	// events is optional and receives the IP and CIDR block changes made by the engine.
+	events *EventBus
+	// kubeEvents is optional and receives the allocations waiting for or
+	// failing for lack of pod IP capacity.
+	kubeEvents *KubeEventRecorder
+	// settings is optional and overrides releaseCooldown per network.
+	settings *NetworkSettingsSource
+	// allocateFamily allocates the IP of one IP family. It is overridden in
+	// tests to inject failures between the IP families of a dual-stack pod.
+	allocateFamily func(ctx context.Context, req *adaptiveipam.AllocatePodIPRequest, config *adaptiveipam.IPConfig, ipFamily store.IPFamily) (*adaptiveipam.PodIP, error)
 }
 
 // NewIPAMEngine constructs a new IPAMEngine instance.
 func NewIPAMEngine(logger logr.Logger, storeInstance *store.Store, releaseCooldown time.Duration, busyTimeout time.Duration, monitor *Monitor) *IPAMEngine {
-	return &IPAMEngine{
+	e := &IPAMEngine{
 		store:           storeInstance,
 		releaseCooldown: releaseCooldown,
 		busyTimeout:     busyTimeout,
@@ -74,6 +93,8 @@ func NewIPAMEngine(logger logr.Logger, storeInstance *store.Store, releaseCooldo
 		requestsMap:     map[string]map[cniClient]chan struct{}{},
 		monitor:         monitor,
 	}
+	e.allocateFamily = e.allocateIP
+	return e
 }
 
 // SetMonitor updates the monitor associated with the IPAMEngine.
@@ -83,13 +104,54 @@ func (e *IPAMEngine) SetMonitor(m *Monitor) {
 	e.monitor = m
 }
 
+// SetNetworkSettings makes the IPAMEngine use the release cooldown of the
+// settings of each network instead of its own.
+func (e *IPAMEngine) SetNetworkSettings(settings *NetworkSettingsSource) {
+	e.requestsMu.Lock()
+	defer e.requestsMu.Unlock()
+	e.settings = settings
+}
+
+// SetKubeEventRecorder makes the IPAMEngine emit Kubernetes Events for the
+// allocations waiting for or failing for lack of pod IP capacity.
+func (e *IPAMEngine) SetKubeEventRecorder(r *KubeEventRecorder) {
+	e.requestsMu.Lock()
+	defer e.requestsMu.Unlock()
+	e.kubeEvents = r
+}
+
+// loggerFor returns the logger of a request, with the request ID propagated
+// by the CNI plugin if there is one.
+func (e *IPAMEngine) loggerFor(ctx context.Context) logr.Logger {
+	if id := pkg.RequestIDFromContext(ctx); id != "" {
+		return e.logger.WithValues("requestID", id)
+	}
+	return e.logger
+}
+
+// releaseCooldownFor returns the release cooldown of a network.
+func (e *IPAMEngine) releaseCooldownFor(network string) time.Duration {
+	e.requestsMu.RLock()
+	settings := e.settings
+	e.requestsMu.RUnlock()
+	if settings == nil {
+		return e.releaseCooldown
+	}
+	return settings.ForNetwork(network).ReleaseCooldown
+}
+
 // AllocatePodIP allocates IPv4 and/or IPv6 addresses for a pod request.
+// Dual-stack allocations are all-or-nothing: if the IPv6 allocation fails, an
+// IPv4 address allocated by the request is released again without cooldown.
 func (e *IPAMEngine) AllocatePodIP(ctx context.Context, req *adaptiveipam.AllocatePodIPRequest) (*adaptiveipam.AllocatePodIPResponse, error) {
+	e.storeMu.RLock()
+	defer e.storeMu.RUnlock()
+	logger := e.loggerFor(ctx)
 	if req.Network == "" {
 		req.Network = networkv1.DefaultPodNetworkName
 	}
 
-	e.logger.Info("AllocatePodIP request received",
+	logger.Info("AllocatePodIP request received",
 		"network", req.Network,
 		"podName", req.PodName,
 		"podNamespace", req.PodNamespace,
@@ -97,11 +159,17 @@ func (e *IPAMEngine) AllocatePodIP(ctx context.Context, req *adaptiveipam.Alloca
 		"ipv6Config", fmt.Sprintf("%+v", req.Ipv6Config))
 
 	if req.Ipv4Config == nil && req.Ipv6Config == nil {
-		err := status.Errorf(codes.InvalidArgument, "both ipv4_config and ipv6_config are missing for pod %s/%s", req.PodNamespace, req.PodName)
-		e.logger.Error(err, "AllocatePodIP validation failed", "podName", req.PodName, "podNamespace", req.PodNamespace)
+		err := pkg.StatusErrorf(codes.InvalidArgument, pkg.ReasonInvalidArgument, "both ipv4_config and ipv6_config are missing for pod %s/%s", req.PodNamespace, req.PodName)
+		logger.Error(err, "AllocatePodIP validation failed", "podName", req.PodName, "podNamespace", req.PodNamespace)
 		return nil, err
 	}
 
+	for _, config := range []*adaptiveipam.IPConfig{req.Ipv4Config, req.Ipv6Config} {
+		if config != nil && (config.ContainerId == "" || config.InterfaceName == "") {
+			return nil, pkg.StatusErrorf(codes.InvalidArgument, pkg.ReasonInvalidArgument, "container_id and interface_name must not be empty")
+		}
+	}
+
 	// Enforce a server-side safety timeout ceiling for the entire allocation attempt.
 	// This must be shorter than the client CNI plugin's timeout to ensure the server
 	// fails gracefully and returns a structured gRPC error before the client gives up.
@@ -109,12 +177,19 @@ func (e *IPAMEngine) AllocatePodIP(ctx context.Context, req *adaptiveipam.Alloca
 	defer cancel()
 
 	var ipv4Alloc *adaptiveipam.PodIP
+	// rollbackIPv4 is set if the IPv4 address is allocated by this request
+	// rather than by an earlier ADD of the same container interface.
+	rollbackIPv4 := false
 	var err error
 	if req.Ipv4Config != nil {
-		if req.Ipv4Config.ContainerId == "" || req.Ipv4Config.InterfaceName == "" {
-			return nil, status.Error(codes.InvalidArgument, "container_id and interface_name must not be empty")
+		if req.Ipv6Config != nil {
+			held, err := e.store.HasAllocation(ctx, req.Network, req.Ipv4Config.ContainerId, req.Ipv4Config.InterfaceName, store.IPv4)
+			if err != nil {
+				return nil, pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "failed to check existing allocation for pod %s/%s: %v", req.PodNamespace, req.PodName, err)
+			}
+			rollbackIPv4 = !held
 		}
-		ipv4Alloc, err = e.allocateIP(ctx, req, req.Ipv4Config, store.IPv4)
+		ipv4Alloc, err = e.allocateFamily(ctx, req, req.Ipv4Config, store.IPv4)
 		if err != nil {
 			return nil, err
 		}
@@ -122,11 +197,11 @@ func (e *IPAMEngine) AllocatePodIP(ctx context.Context, req *adaptiveipam.Alloca
 
 	var ipv6Alloc *adaptiveipam.PodIP
 	if req.Ipv6Config != nil {
-		if req.Ipv6Config.ContainerId == "" || req.Ipv6Config.InterfaceName == "" {
-			return nil, status.Error(codes.InvalidArgument, "container_id and interface_name must not be empty")
-		}
-		ipv6Alloc, err = e.allocateIP(ctx, req, req.Ipv6Config, store.IPv6)
+		ipv6Alloc, err = e.allocateFamily(ctx, req, req.Ipv6Config, store.IPv6)
 		if err != nil {
+			if rollbackIPv4 {
+				e.releaseUnusedIP(ctx, req, req.Ipv4Config, ipv4Alloc)
+			}
 			return nil, err
 		}
 	}
@@ -138,6 +213,7 @@ func (e *IPAMEngine) AllocatePodIP(ctx context.Context, req *adaptiveipam.Alloca
 }
 
 func (e *IPAMEngine) allocateIP(ctx context.Context, req *adaptiveipam.AllocatePodIPRequest, config *adaptiveipam.IPConfig, ipFamily store.IPFamily) (*adaptiveipam.PodIP, error) {
+	logger := e.loggerFor(ctx)
 	if err := e.maybeAddInitialPodCidr(ctx, req.Network, config.InitialPodCidr); err != nil {
 		return nil, err
 	}
@@ -148,29 +224,46 @@ func (e *IPAMEngine) allocateIP(ctx context.Context, req *adaptiveipam.AllocateP
 	}
 
 	params := store.AllocateIPParams{
-		Network:       req.Network,
-		InterfaceName: config.InterfaceName,
-		ContainerID:   config.ContainerId,
-		IPFamily:      ipFamily,
+		Network:          req.Network,
+		InterfaceName:    config.InterfaceName,
+		ContainerID:      config.ContainerId,
+		IPFamily:         ipFamily,
+		PodName:          req.PodName,
+		PodNamespace:     req.PodNamespace,
+		Netns:            config.Netns,
+		RequestedAddress: config.RequestedIp,
 	}
 
 	// The loop is bounded by the cancellation or timeout of the context ctx.
 	for {
 		ip, cidr, err := e.allocateIPWithRetry(ctx, params, timeout)
 		if err != nil {
-			e.logger.Error(err, fmt.Sprintf("failed to allocate %s", ipFamily), "network", req.Network, "podName", req.PodName, "podNamespace", req.PodNamespace)
+			logger.Error(err, fmt.Sprintf("failed to allocate %s", ipFamily), "network", req.Network, "podName", req.PodName, "podNamespace", req.PodNamespace)
 
-			capacityAdded, allocErr := e.maybeDynamicAllocation(ctx, req, params, err)
+			capacityAdded, allocErr := e.maybeDynamicAllocation(ctx, req, config, params, err)
 			if allocErr != nil {
 				return nil, allocErr
 			}
 			if capacityAdded {
 				continue
 			}
-			// TODO: Refine status code to return a more specific code based on the error type instead of a fallback Unavailable.
-			return nil, status.Errorf(codes.Unavailable, "failed to allocate %s for pod %s/%s: %v", ipFamily, req.PodNamespace, req.PodName, err)
+			if errors.Is(err, store.ErrAddressUnavailable) {
+				return nil, pkg.StatusErrorf(codes.FailedPrecondition, pkg.ReasonAddressUnavailable, "failed to allocate %s for pod %s/%s: %v", ipFamily, req.PodNamespace, req.PodName, err)
+			}
+			return nil, pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "failed to allocate %s for pod %s/%s: %v", ipFamily, req.PodNamespace, req.PodName, err)
 		}
 
+		e.events.Publish(WatchEvent{
+			Type:          WatchEventIPAllocated,
+			Network:       req.Network,
+			IPFamily:      ipFamily,
+			CIDR:          cidr,
+			Address:       ip,
+			ContainerID:   config.ContainerId,
+			InterfaceName: config.InterfaceName,
+			PodNamespace:  req.PodNamespace,
+			PodName:       req.PodName,
+		})
 		return &adaptiveipam.PodIP{
 			IpAddress: ip,
 			Cidr:      cidr,
@@ -178,9 +271,30 @@ func (e *IPAMEngine) allocateIP(ctx context.Context, req *adaptiveipam.AllocateP
 	}
 }
 
+// releaseUnusedIP releases an IP allocated by a request that failed before the
+// IP was returned to the pod. It runs even if ctx is done, e.g. because the
+// failure was a timeout. A failure is only logged since the IP is still
+// released by the DEL the runtime sends for the failed ADD.
+func (e *IPAMEngine) releaseUnusedIP(ctx context.Context, req *adaptiveipam.AllocatePodIPRequest, config *adaptiveipam.IPConfig, alloc *adaptiveipam.PodIP) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultReleaseUnusedIPTimeout)
+	defer cancel()
+
+	logger := e.loggerFor(ctx).WithValues("network", req.Network, "containerID", config.ContainerId, "interfaceName", config.InterfaceName,
+		"podName", req.PodName, "podNamespace", req.PodNamespace, "address", alloc.IpAddress)
+	if err := e.store.ReleaseUnusedIP(ctx, req.Network, config.ContainerId, config.InterfaceName, alloc.IpAddress); err != nil {
+		logger.Error(err, "Failed to release the IP of a failed dual-stack allocation")
+		return
+	}
+	publishReleasedIPs(e.events, store.IPOwner{Network: req.Network, ContainerID: config.ContainerId, InterfaceName: config.InterfaceName,
+		PodNamespace: req.PodNamespace, PodName: req.PodName}, []string{alloc.IpAddress})
+	logger.Info("Released the IP of a failed dual-stack allocation")
+}
+
 func (e *IPAMEngine) allocateIPWithRetry(ctx context.Context, params store.AllocateIPParams, timeout time.Duration) (string, string, error) {
+	logger := e.loggerFor(ctx)
 	var ip, cidr string
 	var lastErr error
+	start := time.Now()
 
 	// The total timeout is set to align with the SQLite busy_timeout configured in the DSN.
 	// PollUntilContextTimeout creates a derived context with this timeout, but also respects
@@ -190,13 +304,14 @@ func (e *IPAMEngine) allocateIPWithRetry(ctx context.Context, params store.Alloc
 		if lastErr == nil {
 			return true, nil // Success
 		}
-		if errors.Is(lastErr, store.ErrNoAvailableIPs) {
+		if errors.Is(lastErr, store.ErrNoAvailableIPs) || errors.Is(lastErr, store.ErrAddressUnavailable) {
 			return true, lastErr // Stop immediately on non-retryable error
 		}
 		if ctx.Err() != nil {
 			return true, ctx.Err() // Stop immediately if context is done
 		}
-		e.logger.V(4).Info(fmt.Sprintf("Retrying %s allocation due to transient error", params.IPFamily), "err", lastErr, "network", params.Network)
+		logger.V(4).Info(fmt.Sprintf("Retrying %s allocation due to transient error", params.IPFamily), "err", lastErr, "network", params.Network)
+		storeAllocationRetries.WithLabelValues(string(params.IPFamily)).Inc()
 		return false, nil // Retry
 	})
 
@@ -204,83 +319,102 @@ func (e *IPAMEngine) allocateIPWithRetry(ctx context.Context, params store.Alloc
 		if wait.Interrupted(err) && lastErr != nil {
 			err = lastErr // Use last error if timed out
 		}
+		observeStoreAllocation(params.IPFamily, start, err)
 		return "", "", err
 	}
 
+	observeStoreAllocation(params.IPFamily, start, nil)
 	return ip, cidr, nil
 }
 
-func (e *IPAMEngine) handleDynamicAllocation(ctx context.Context, req *adaptiveipam.AllocatePodIPRequest) error {
+func (e *IPAMEngine) handleDynamicAllocation(ctx context.Context, req *adaptiveipam.AllocatePodIPRequest, config *adaptiveipam.IPConfig, ipFamily store.IPFamily) error {
+	logger := e.loggerFor(ctx)
 	clientKey := cniClient{
-		containerID:  req.Ipv4Config.ContainerId,
+		containerID:  config.ContainerId,
 		network:      req.Network,
+		ipFamily:     ipFamily,
 		podName:      req.PodName,
 		podNamespace: req.PodNamespace,
 	}
 
-	if e.monitor == nil {
-		e.logger.V(2).Info("No monitor available, failing fast on exhaustion", "network", req.Network)
-		return fmt.Errorf("failed to allocate ipv4 for pod %s/%s: %w", req.PodNamespace, req.PodName, store.ErrNoAvailableIPs)
+	e.requestsMu.RLock()
+	monitor, kubeEvents := e.monitor, e.kubeEvents
+	e.requestsMu.RUnlock()
+
+	if monitor == nil || !monitor.scalesNetwork(req.Network) {
+		logger.V(2).Info("No monitor available, failing fast on exhaustion", "network", req.Network, "ipFamily", ipFamily)
+		kubeEvents.allocationFailed(req.PodNamespace, req.PodName, req.Network, ipFamily)
+		return fmt.Errorf("failed to allocate %s for pod %s/%s: %w", ipFamily, req.PodNamespace, req.PodName, store.ErrNoAvailableIPs)
 	}
 
 	ch, ok := e.getOrCreatePendingRequest(clientKey, req.Network)
+	kubeEvents.allocationWaiting(req.PodNamespace, req.PodName, req.Network, ipFamily)
+	start := time.Now()
 
 	if !ok {
-		e.logger.Info("Local store IP exhaustion detected, requesting scale up", "network", req.Network, "podName", req.PodName, "podNamespace", req.PodNamespace)
+		logger.Info("Local store IP exhaustion detected, requesting scale up", "network", req.Network, "ipFamily", ipFamily, "podName", req.PodName, "podNamespace", req.PodNamespace)
 		// Enqueue the request to trigger the controller sync for dynamic allocation.
-		e.monitor.enqueue()
+		monitor.enqueue()
 	} else {
-		e.logger.Info("Dynamic allocation request already pending, waiting on existing request", "network", req.Network, "podName", req.PodName, "podNamespace", req.PodNamespace)
+		logger.Info("Dynamic allocation request already pending, waiting on existing request", "network", req.Network, "ipFamily", ipFamily, "podName", req.PodName, "podNamespace", req.PodNamespace)
 	}
 
 	select {
 	case <-ctx.Done():
 		e.removePendingRequest(clientKey, req.Network)
-		e.logger.Error(ctx.Err(), "Dynamic allocation wait timed out or cancelled", "network", req.Network, "podName", req.PodName, "podNamespace", req.PodNamespace)
-		return fmt.Errorf("failed to allocate ipv4 for pod %s/%s (timed out): %w", req.PodNamespace, req.PodName, store.ErrNoAvailableIPs)
+		logger.Error(ctx.Err(), "Dynamic allocation wait timed out or cancelled", "network", req.Network, "ipFamily", ipFamily, "podName", req.PodName, "podNamespace", req.PodNamespace)
+		kubeEvents.allocationExhausted(req.PodNamespace, req.PodName, req.Network, ipFamily, time.Since(start))
+		return fmt.Errorf("failed to allocate %s for pod %s/%s (timed out): %w", ipFamily, req.PodNamespace, req.PodName, store.ErrNoAvailableIPs)
 	case <-ch:
-		e.logger.Info("Woken up by CIDR watcher, retrying local allocation", "network", req.Network, "podName", req.PodName, "podNamespace", req.PodNamespace)
+		logger.Info("Woken up by CIDR watcher, retrying local allocation", "network", req.Network, "ipFamily", ipFamily, "podName", req.PodName, "podNamespace", req.PodNamespace)
 		return nil
 	}
 }
 
-func (e *IPAMEngine) maybeDynamicAllocation(ctx context.Context, req *adaptiveipam.AllocatePodIPRequest, params store.AllocateIPParams, err error) (bool, error) {
-	if params.IPFamily != store.IPv4 || !errors.Is(err, store.ErrNoAvailableIPs) {
+func (e *IPAMEngine) maybeDynamicAllocation(ctx context.Context, req *adaptiveipam.AllocatePodIPRequest, config *adaptiveipam.IPConfig, params store.AllocateIPParams, err error) (bool, error) {
+	logger := e.loggerFor(ctx)
+	if !errors.Is(err, store.ErrNoAvailableIPs) {
 		return false, nil
 	}
 
-	undrained, undrainErr := e.store.UndrainOneCIDRBlock(ctx, req.Network, store.IPv4)
+	undrained, undrainErr := e.store.UndrainOneCIDRBlock(ctx, req.Network, params.IPFamily)
 	if undrainErr == nil && undrained {
-		e.logger.Info("Successfully undrained one CIDR block, retrying local allocation", "network", req.Network, "podName", req.PodName, "podNamespace", req.PodNamespace)
+		logger.Info("Successfully undrained one CIDR block, retrying local allocation", "network", req.Network, "ipFamily", params.IPFamily, "podName", req.PodName, "podNamespace", req.PodNamespace)
 		return true, nil
 	}
 
-	if err := e.handleDynamicAllocation(ctx, req); err != nil {
-		return false, status.Errorf(codes.ResourceExhausted, "failed to allocate ipv4 for pod %s/%s: %v", req.PodNamespace, req.PodName, err)
+	if err := e.handleDynamicAllocation(ctx, req, config, params.IPFamily); err != nil {
+		return false, pkg.StatusErrorf(codes.ResourceExhausted, pkg.ReasonExhausted, "failed to allocate %s for pod %s/%s: %v", params.IPFamily, req.PodNamespace, req.PodName, err)
 	}
 
 	return true, nil
 }
 
 func (e *IPAMEngine) maybeAddInitialPodCidr(ctx context.Context, network string, initialPodCidr string) error {
+	logger := e.loggerFor(ctx)
 	if initialPodCidr == "" {
 		return nil
 	}
+	if _, err := netip.ParsePrefix(initialPodCidr); err != nil {
+		return pkg.StatusErrorf(codes.InvalidArgument, pkg.ReasonInvalidCIDR, "invalid initial_pod_cidr %q: %v", initialPodCidr, err)
+	}
 	// TODO: save a bool flag about whether we added the initial CIDR to the store to avoid calling store everytime to check if initial cidr is added
 	_, exists, err := e.store.GetCIDRBlock(ctx, initialPodCidr, network)
 
 	if err != nil {
-		e.logger.Error(err, "failed to check if initial cidr block exists", "network", network, "cidr", initialPodCidr)
-		return status.Errorf(codes.Unavailable, "failed to check if initial cidr block %s exists for network %s: %v", initialPodCidr, network, err)
+		logger.Error(err, "failed to check if initial cidr block exists", "network", network, "cidr", initialPodCidr)
+		return pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "failed to check if initial cidr block %s exists for network %s: %v", initialPodCidr, network, err)
 	}
 	if !exists {
 		if err := e.store.AddCIDR(ctx, network, initialPodCidr); err != nil {
 			if errors.Is(err, store.ErrCidrAlreadyExists) {
-				e.logger.V(4).Info("Initial CIDR block already added by another thread", "network", network, "cidr", initialPodCidr)
+				logger.V(4).Info("Initial CIDR block already added by another thread", "network", network, "cidr", initialPodCidr)
 			} else {
-				e.logger.Error(err, "failed to add initial cidr block", "network", network, "cidr", initialPodCidr)
-				return status.Errorf(codes.Unavailable, "failed to add initial cidr block %s for network %s: %v", initialPodCidr, network, err)
+				logger.Error(err, "failed to add initial cidr block", "network", network, "cidr", initialPodCidr)
+				return pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "failed to add initial cidr block %s for network %s: %v", initialPodCidr, network, err)
 			}
+		} else {
+			e.events.Publish(WatchEvent{Type: WatchEventBlockAdded, Network: network, IPFamily: prefixFamily(initialPodCidr), CIDR: initialPodCidr})
 		}
 	}
 	return nil
@@ -288,11 +422,14 @@ func (e *IPAMEngine) maybeAddInitialPodCidr(ctx context.Context, network string,
 
 // DeallocatePodIP releases IP reservations owned by a container and interface.
 func (e *IPAMEngine) DeallocatePodIP(ctx context.Context, req *adaptiveipam.DeallocatePodIPRequest) (*adaptiveipam.DeallocatePodIPResponse, error) {
+	e.storeMu.RLock()
+	defer e.storeMu.RUnlock()
+	logger := e.loggerFor(ctx)
 	if req.Network == "" {
 		req.Network = networkv1.DefaultPodNetworkName
 	}
 
-	e.logger.Info("DeallocatePodIP request received",
+	logger.Info("DeallocatePodIP request received",
 		"network", req.Network,
 		"containerID", req.ContainerId,
 		"interfaceName", req.InterfaceName,
@@ -300,41 +437,49 @@ func (e *IPAMEngine) DeallocatePodIP(ctx context.Context, req *adaptiveipam.Deal
 		"podNamespace", req.PodNamespace)
 
 	if req.ContainerId == "" || req.InterfaceName == "" {
-		return nil, status.Error(codes.InvalidArgument, "container_id and interface_name must not be empty")
+		return nil, pkg.StatusErrorf(codes.InvalidArgument, pkg.ReasonInvalidArgument, "container_id and interface_name must not be empty")
 	}
 
-	releasedIPs, err := e.store.ReleaseIPByOwner(ctx, req.Network, req.ContainerId, req.InterfaceName, e.releaseCooldown)
+	releasedIPs, err := e.store.ReleaseIPByOwner(ctx, req.Network, req.ContainerId, req.InterfaceName, e.releaseCooldownFor(req.Network))
 	if err != nil {
-		e.logger.Error(err, "failed to deallocate ips", "network", req.Network, "podName", req.PodName, "podNamespace", req.PodNamespace)
-		return nil, status.Errorf(codes.Unavailable, "failed to deallocate ips for pod %s/%s: %v", req.PodNamespace, req.PodName, err)
+		logger.Error(err, "failed to deallocate ips", "network", req.Network, "podName", req.PodName, "podNamespace", req.PodNamespace)
+		return nil, pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "failed to deallocate ips for pod %s/%s: %v", req.PodNamespace, req.PodName, err)
 	}
 
 	if len(releasedIPs) == 0 {
-		e.logger.V(4).Info("No IP addresses were released (likely already deallocated or didn't exist)", "network", req.Network, "podName", req.PodName, "podNamespace", req.PodNamespace)
+		logger.V(4).Info("No IP addresses were released (likely already deallocated or didn't exist)", "network", req.Network, "podName", req.PodName, "podNamespace", req.PodNamespace)
 	} else {
-		e.logger.Info("Successfully deallocated ips",
+		logger.Info("Successfully deallocated ips",
 			"network", req.Network,
 			"podName", req.PodName,
 			"podNamespace", req.PodNamespace,
 			"releasedIPs", releasedIPs,
 			"count", len(releasedIPs))
+		publishReleasedIPs(e.events, store.IPOwner{Network: req.Network, ContainerID: req.ContainerId, InterfaceName: req.InterfaceName,
+			PodNamespace: req.PodNamespace, PodName: req.PodName}, releasedIPs)
 	}
 
 	return &adaptiveipam.DeallocatePodIPResponse{}, nil
 }
 
-func (e *IPAMEngine) getPendingRequestsCount(network string) int {
+func (e *IPAMEngine) getPendingRequestsCount(network string, ipFamily store.IPFamily) int {
 	e.requestsMu.RLock()
 	defer e.requestsMu.RUnlock()
 
-	return len(e.requestsMap[network])
+	count := 0
+	for client := range e.requestsMap[network] {
+		if client.ipFamily == ipFamily {
+			count++
+		}
+	}
+	return count
 }
 
-func (e *IPAMEngine) onCIDRAdded(network string, availableIPs int) {
+func (e *IPAMEngine) onCIDRAdded(network string, ipFamily store.IPFamily, availableIPs int) {
 	e.requestsMu.Lock()
 	defer e.requestsMu.Unlock()
 
-	e.logger.Info("CIDR added, checking for waiting CNI requests to wake up", "network", network, "availableIPs", availableIPs)
+	e.logger.Info("CIDR added, checking for waiting CNI requests to wake up", "network", network, "ipFamily", ipFamily, "availableIPs", availableIPs)
 
 	netMap := e.requestsMap[network]
 	if len(netMap) == 0 {
@@ -344,6 +489,9 @@ func (e *IPAMEngine) onCIDRAdded(network string, availableIPs int) {
 	var awakenedClients []string
 	count := 0
 	for client, ch := range netMap {
+		if client.ipFamily != ipFamily {
+			continue
+		}
 		close(ch)
 		delete(netMap, client)
 		clientDetail := fmt.Sprintf("%s/%s", client.podNamespace, client.podName)
@@ -362,18 +510,19 @@ func (e *IPAMEngine) onCIDRAdded(network string, availableIPs int) {
 	}
 
 	if count > 0 {
-		e.logger.Info("Successfully woke up waiting CNI requests", "network", network, "count", count)
-		e.logger.V(4).Info("Awakened CNI client requests details", "network", network, "clients", awakenedClients)
+		e.logger.Info("Successfully woke up waiting CNI requests", "network", network, "ipFamily", ipFamily, "count", count)
+		e.logger.V(4).Info("Awakened CNI client requests details", "network", network, "ipFamily", ipFamily, "clients", awakenedClients)
 	}
 }
 
 // CheckPodIP verifies if an IP allocation exists for a pod.
 func (e *IPAMEngine) CheckPodIP(ctx context.Context, req *adaptiveipam.CheckPodIPRequest) (*adaptiveipam.CheckPodIPResponse, error) {
+	logger := e.loggerFor(ctx)
 	if req.Network == "" {
 		req.Network = networkv1.DefaultPodNetworkName
 	}
 
-	e.logger.Info("CheckPodIP request received",
+	logger.Info("CheckPodIP request received",
 		"network", req.Network,
 		"containerID", req.ContainerId,
 		"interfaceName", req.InterfaceName,
@@ -382,14 +531,132 @@ func (e *IPAMEngine) CheckPodIP(ctx context.Context, req *adaptiveipam.CheckPodI
 
 	err := e.store.CheckAllocation(ctx, req.Network, req.ContainerId, req.InterfaceName)
 	if err != nil {
-		e.logger.Error(err, "CheckPodIP failed", "network", req.Network, "containerID", req.ContainerId, "podName", req.PodName, "podNamespace", req.PodNamespace)
-		return nil, status.Errorf(codes.NotFound, "allocation check failed: %v", err)
+		logger.Error(err, "CheckPodIP failed", "network", req.Network, "containerID", req.ContainerId, "podName", req.PodName, "podNamespace", req.PodNamespace)
+		return nil, pkg.StatusErrorf(codes.NotFound, pkg.ReasonNotAllocated, "allocation check failed: %v", err)
 	}
 
-	e.logger.Info("CheckPodIP succeeded", "network", req.Network, "containerID", req.ContainerId, "podName", req.PodName, "podNamespace", req.PodNamespace)
+	logger.Info("CheckPodIP succeeded", "network", req.Network, "containerID", req.ContainerId, "podName", req.PodName, "podNamespace", req.PodNamespace)
 	return &adaptiveipam.CheckPodIPResponse{}, nil
 }
 
+// ReleaseStalePodIPs releases the IPs of the pod interfaces on a network that
+// are not among the valid attachments of the request. Interfaces allocated
+// less than DefaultGCMinAllocationAge ago are kept, so that a CNI GC does not
+// race with a CNI ADD the container runtime has not recorded yet. The IPs
+// claimed by a state rebuild are not CNI attachments and are kept. The IPs are
+// released with the release cooldown, like for a CNI DEL.
+func (e *IPAMEngine) ReleaseStalePodIPs(ctx context.Context, req *adaptiveipam.ReleaseStalePodIPsRequest) (*adaptiveipam.ReleaseStalePodIPsResponse, error) {
+	e.storeMu.RLock()
+	defer e.storeMu.RUnlock()
+	logger := e.loggerFor(ctx)
+	if req.Network == "" {
+		req.Network = networkv1.DefaultPodNetworkName
+	}
+
+	type attachment struct {
+		containerID   string
+		interfaceName string
+	}
+	valid := make(map[attachment]bool, len(req.ValidAttachments))
+	for _, a := range req.ValidAttachments {
+		valid[attachment{containerID: a.ContainerId, interfaceName: a.InterfaceName}] = true
+	}
+
+	owners, err := e.store.ListIPOwners(ctx)
+	if err != nil {
+		return nil, pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "failed to list IP owners: %v", err)
+	}
+
+	now := time.Now()
+	resp := &adaptiveipam.ReleaseStalePodIPsResponse{}
+	var errs []error
+	for _, owner := range owners {
+		if owner.Network != req.Network || valid[attachment{containerID: owner.ContainerID, interfaceName: owner.InterfaceName}] || isRecoveredOwner(owner) {
+			continue
+		}
+		if !owner.AllocatedAt.IsZero() && now.Sub(owner.AllocatedAt) < DefaultGCMinAllocationAge {
+			continue
+		}
+		released, err := e.store.ReleaseIPByOwner(ctx, owner.Network, owner.ContainerID, owner.InterfaceName, e.releaseCooldownFor(owner.Network))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to release IPs of container %s interface %s: %w", owner.ContainerID, owner.InterfaceName, err))
+			continue
+		}
+		logger.Info("Released IPs of attachment missing from CNI GC", "network", owner.Network, "containerID", owner.ContainerID, "interfaceName", owner.InterfaceName,
+			"podNamespace", owner.PodNamespace, "podName", owner.PodName, "releasedIPs", released)
+		gcReleasedIPs.WithLabelValues(owner.Network, gcReasonAttachmentNotValid).Add(float64(len(released)))
+		publishReleasedIPs(e.events, owner, released)
+		resp.ReleasedAttachments = append(resp.ReleasedAttachments, &adaptiveipam.PodAttachment{
+			ContainerId:   owner.ContainerID,
+			InterfaceName: owner.InterfaceName,
+		})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "failed to release stale pod IPs on network %s: %v", req.Network, err)
+	}
+	return resp, nil
+}
+
+// ReportIPConflict quarantines an IP of a pod interface that another host on
+// the network already uses, and releases it from the interface without the
+// release cooldown, since the pod never used it. An IP already quarantined, or
+// no longer allocated to the interface, is not an error, so that the report
+// can be retried.
+func (e *IPAMEngine) ReportIPConflict(ctx context.Context, req *adaptiveipam.ReportIPConflictRequest) (*adaptiveipam.ReportIPConflictResponse, error) {
+	e.storeMu.RLock()
+	defer e.storeMu.RUnlock()
+	if req.Network == "" {
+		req.Network = networkv1.DefaultPodNetworkName
+	}
+	logger := e.loggerFor(ctx).WithValues("network", req.Network, "containerID", req.ContainerId, "interfaceName", req.InterfaceName,
+		"podName", req.PodName, "podNamespace", req.PodNamespace, "address", req.IpAddress)
+	if _, err := netip.ParseAddr(req.IpAddress); err != nil {
+		return nil, pkg.StatusErrorf(codes.InvalidArgument, pkg.ReasonInvalidArgument, "invalid ip_address %q", req.IpAddress)
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "conflict reported by the CNI plugin"
+	}
+	_, err := e.store.AddIPQuarantine(ctx, store.IPQuarantine{Network: req.Network, Address: req.IpAddress, Reason: reason})
+	if err != nil && !errors.Is(err, store.ErrQuarantineExists) {
+		return nil, pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "failed to quarantine IP %s: %v", req.IpAddress, err)
+	}
+
+	err = e.store.ReleaseUnusedIP(ctx, req.Network, req.ContainerId, req.InterfaceName, req.IpAddress)
+	if errors.Is(err, store.ErrIPNotAllocated) {
+		logger.Info("Quarantined conflicting IP not allocated to the pod interface", "reason", reason)
+		return &adaptiveipam.ReportIPConflictResponse{}, nil
+	}
+	if err != nil {
+		return nil, pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "failed to release conflicting IP %s: %v", req.IpAddress, err)
+	}
+	publishReleasedIPs(e.events, store.IPOwner{Network: req.Network, ContainerID: req.ContainerId, InterfaceName: req.InterfaceName,
+		PodNamespace: req.PodNamespace, PodName: req.PodName}, []string{req.IpAddress})
+	logger.Info("Quarantined and released conflicting IP", "reason", reason)
+	return &adaptiveipam.ReportIPConflictResponse{}, nil
+}
+
+// GetNetworkStatus returns the number of IPs available for new pods on a network.
+func (e *IPAMEngine) GetNetworkStatus(ctx context.Context, req *adaptiveipam.GetNetworkStatusRequest) (*adaptiveipam.GetNetworkStatusResponse, error) {
+	if req.Network == "" {
+		req.Network = networkv1.DefaultPodNetworkName
+	}
+
+	ipv4, err := e.store.CountAvailableIPs(ctx, req.Network, store.IPv4)
+	if err != nil {
+		return nil, pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "failed to count available IPv4 addresses: %v", err)
+	}
+	ipv6, err := e.store.CountAvailableIPs(ctx, req.Network, store.IPv6)
+	if err != nil {
+		return nil, pkg.StatusErrorf(codes.Unavailable, pkg.ReasonStoreBusy, "failed to count available IPv6 addresses: %v", err)
+	}
+	return &adaptiveipam.GetNetworkStatusResponse{
+		AvailableIpv4: int64(ipv4),
+		AvailableIpv6: int64(ipv6),
+	}, nil
+}
+
 // getOrCreatePendingRequest retrieves the wakeup channel for a pending CNI client request,
 // creating it if it does not already exist. It returns the channel and a boolean indicating
 // whether the channel already existed.
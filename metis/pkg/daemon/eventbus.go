@@ -0,0 +1,229 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"errors"
+	"net/netip"
+	"sync"
+	"time"
+
+	"k8s.io/metis/pkg/store"
+)
+
+// defaultEventBufferSize is the number of events buffered for a subscriber
+// before it is considered too slow and unsubscribed.
+const defaultEventBufferSize = 1024
+
+// errSubscriberTooSlow is the error of a subscription that was closed because
+// its buffer was full.
+var errSubscriberTooSlow = errors.New("subscriber did not keep up with the events")
+
+// WatchEventType is the kind of a WatchEvent.
+type WatchEventType string
+
+const (
+	WatchEventIPAllocated      WatchEventType = "IPAllocated"
+	WatchEventIPReleased       WatchEventType = "IPReleased"
+	WatchEventBlockAdded       WatchEventType = "BlockAdded"
+	WatchEventBlockDraining    WatchEventType = "BlockDraining"
+	WatchEventBlockDeleted     WatchEventType = "BlockDeleted"
+	WatchEventScaleUpRequested WatchEventType = "ScaleUpRequested"
+	WatchEventNNCPatched       WatchEventType = "NNCPatched"
+)
+
+// WatchEvent is a change of the IPAM state of the node. Only the fields that
+// apply to the Type of the event are set.
+type WatchEvent struct {
+	Type     WatchEventType
+	Time     time.Time
+	Network  string
+	IPFamily store.IPFamily
+	// CIDR is the CIDR block of the event, if known.
+	CIDR string
+	// Address and the owner fields are set for IP events.
+	Address       string
+	ContainerID   string
+	InterfaceName string
+	PodNamespace  string
+	PodName       string
+	// TargetPods is the pod capacity requested for the network in the
+	// NodeNetworkConfig, set for ScaleUpRequested and NNCPatched events.
+	TargetPods int32
+}
+
+// EventBus fans out the WatchEvents published by the IPAMEngine, the Watcher,
+// the Monitor and the GarbageCollector to the admin watch streams.
+//
+// Publishing never blocks: a subscriber whose buffer is full is unsubscribed
+// and its subscription fails with errSubscriberTooSlow, so that a stuck watch
+// stream cannot slow down allocations. A nil EventBus discards all events.
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers map[*EventSubscription]struct{}
+	closed      bool
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+// EventSubscription receives the events published after it was created.
+type EventSubscription struct {
+	bus *EventBus
+	ch  chan WatchEvent
+	err error
+}
+
+// NewEventBus creates a new EventBus.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: map[*EventSubscription]struct{}{},
+		now:         time.Now,
+	}
+}
+
+// Publish sends ev to all subscribers, setting its Time if unset.
+func (b *EventBus) Publish(ev WatchEvent) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ev.Time.IsZero() {
+		ev.Time = b.now()
+	}
+	for sub := range b.subscribers {
+		select {
+		case sub.ch <- ev:
+		default:
+			sub.err = errSubscriberTooSlow
+			b.unsubscribeLocked(sub)
+		}
+	}
+}
+
+// Subscribe returns a new subscription buffering up to bufferSize events, or
+// defaultEventBufferSize if bufferSize <= 0. The subscription of a closed or
+// nil EventBus receives no events.
+func (b *EventBus) Subscribe(bufferSize int) *EventSubscription {
+	if bufferSize <= 0 {
+		bufferSize = defaultEventBufferSize
+	}
+	sub := &EventSubscription{bus: b, ch: make(chan WatchEvent, bufferSize)}
+	if b == nil {
+		close(sub.ch)
+		return sub
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.ch)
+		return sub
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Close ends all subscriptions, e.g. when the daemon is stopping.
+func (b *EventBus) Close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		b.unsubscribeLocked(sub)
+	}
+}
+
+func (b *EventBus) unsubscribeLocked(sub *EventSubscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.ch)
+}
+
+// Events returns the channel of the events of the subscription. It is closed
+// once the subscription ends.
+func (s *EventSubscription) Events() <-chan WatchEvent {
+	return s.ch
+}
+
+// Err returns why the subscription ended, or nil if it was closed by
+// Unsubscribe or by closing the EventBus. It must only be called after the
+// Events channel was closed.
+func (s *EventSubscription) Err() error {
+	if s.bus == nil {
+		return nil
+	}
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.err
+}
+
+// Unsubscribe ends the subscription.
+func (s *EventSubscription) Unsubscribe() {
+	if s.bus == nil {
+		return
+	}
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.unsubscribeLocked(s)
+}
+
+// addressFamily returns the IP family of an address, or an empty family if it
+// cannot be parsed.
+func addressFamily(address string) store.IPFamily {
+	addr, err := netip.ParseAddr(address)
+	switch {
+	case err != nil:
+		return ""
+	case addr.Unmap().Is4():
+		return store.IPv4
+	default:
+		return store.IPv6
+	}
+}
+
+// prefixFamily returns the IP family of a CIDR, or an empty family if it
+// cannot be parsed.
+func prefixFamily(cidr string) store.IPFamily {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return ""
+	}
+	return addressFamily(prefix.Addr().String())
+}
+
+// publishReleasedIPs publishes an IPReleased event for each of the addresses
+// released from the container interface.
+func publishReleasedIPs(bus *EventBus, owner store.IPOwner, addresses []string) {
+	for _, address := range addresses {
+		bus.Publish(WatchEvent{
+			Type:          WatchEventIPReleased,
+			Network:       owner.Network,
+			IPFamily:      addressFamily(address),
+			Address:       address,
+			ContainerID:   owner.ContainerID,
+			InterfaceName: owner.InterfaceName,
+			PodNamespace:  owner.PodNamespace,
+			PodName:       owner.PodName,
+		})
+	}
+}
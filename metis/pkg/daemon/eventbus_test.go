@@ -0,0 +1,71 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+	fast := bus.Subscribe(0)
+	slow := bus.Subscribe(1)
+
+	bus.Publish(WatchEvent{Type: WatchEventBlockAdded, CIDR: "10.0.0.0/28"})
+	bus.Publish(WatchEvent{Type: WatchEventIPAllocated, Address: "10.0.0.2"})
+
+	for _, want := range []WatchEventType{WatchEventBlockAdded, WatchEventIPAllocated} {
+		ev := <-fast.Events()
+		if ev.Type != want || ev.Time.IsZero() {
+			t.Errorf("Expected a timestamped %s event, got %+v", want, ev)
+		}
+	}
+	if ev := <-slow.Events(); ev.Type != WatchEventBlockAdded {
+		t.Errorf("Expected the slow subscriber to get the first event, got %+v", ev)
+	}
+	if _, ok := <-slow.Events(); ok {
+		t.Fatal("Expected the slow subscriber to be unsubscribed")
+	}
+	if err := slow.Err(); !errors.Is(err, errSubscriberTooSlow) {
+		t.Errorf("Expected errSubscriberTooSlow, got %v", err)
+	}
+
+	fast.Unsubscribe()
+	fast.Unsubscribe()
+	if _, ok := <-fast.Events(); ok || fast.Err() != nil {
+		t.Errorf("Expected the unsubscribed subscription to end without error, got %v", fast.Err())
+	}
+
+	open := bus.Subscribe(0)
+	bus.Close()
+	if _, ok := <-open.Events(); ok || open.Err() != nil {
+		t.Errorf("Expected Close to end the subscription without error, got %v", open.Err())
+	}
+	if _, ok := <-bus.Subscribe(0).Events(); ok {
+		t.Error("Expected subscriptions of a closed bus to be ended")
+	}
+
+	var nilBus *EventBus
+	nilBus.Publish(WatchEvent{Type: WatchEventIPReleased})
+	nilBus.Close()
+	closed := nilBus.Subscribe(0)
+	if _, ok := <-closed.Events(); ok || closed.Err() != nil {
+		t.Errorf("Expected the subscription of a nil bus to be ended without error, got %v", closed.Err())
+	}
+	closed.Unsubscribe()
+}
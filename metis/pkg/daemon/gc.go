@@ -0,0 +1,273 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/metis/pkg/store"
+)
+
+const (
+	DefaultGCInterval         = 10 * time.Minute
+	DefaultGCMinAllocationAge = 5 * time.Minute
+
+	// gcReasonPodNotFound, gcReasonPodTerminated, gcReasonPodRecreated and
+	// gcReasonNetnsNotFound describe why an IP owner is considered stale.
+	// gcReasonAttachmentNotValid is used for the owners released by a CNI GC.
+	gcReasonPodNotFound        = "PodNotFound"
+	gcReasonPodTerminated      = "PodTerminated"
+	gcReasonPodRecreated       = "PodRecreated"
+	gcReasonNetnsNotFound      = "NetnsNotFound"
+	gcReasonAttachmentNotValid = "AttachmentNotValid"
+)
+
+// StaleIPOwner is an IP owner whose pod or sandbox no longer exists.
+type StaleIPOwner struct {
+	store.IPOwner
+	// Reason is why the owner is considered stale, e.g. "PodNotFound".
+	Reason string
+}
+
+// GarbageCollector releases IPs whose CNI DEL was lost, e.g. because the node
+// crashed or the CNI plugin could not reach the daemon.
+//
+// An IP owner is stale if any of the following holds:
+//   - Its pod is not scheduled to the node anymore.
+//   - Its pod reached the Succeeded or Failed phase.
+//   - Its pod was created after the IPs were allocated, i.e. the allocation
+//     belongs to an earlier pod with the same name.
+//   - Netns checking is enabled and the network namespace of its sandbox no
+//     longer exists, e.g. after a node reboot.
+//
+// Owners allocated less than the minimum allocation age ago are never stale,
+// so that a pass does not race with a CNI ADD for a pod the API server has
+// not reported yet. Owners without a pod name are only checked by their netns.
+type GarbageCollector struct {
+	kubeClient       kubernetes.Interface
+	store            *store.Store
+	nodeName         string
+	logger           logr.Logger
+	releaseCooldown  time.Duration
+	settings         *NetworkSettingsSource
+	interval         time.Duration
+	minAllocationAge time.Duration
+	checkNetns       bool
+	dryRun           bool
+	events           *EventBus
+
+	// mu serializes the periodic passes and those requested through the admin API.
+	mu sync.Mutex
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+// GarbageCollectorConfig holds the configuration for the GarbageCollector.
+type GarbageCollectorConfig struct {
+	Logger     logr.Logger
+	KubeClient kubernetes.Interface
+	Store      *store.Store
+	NodeName   string
+	// ReleaseCooldown is applied to the released IPs like for a CNI DEL.
+	ReleaseCooldown time.Duration
+	// Settings is optional and overrides ReleaseCooldown per network.
+	Settings *NetworkSettingsSource
+	// Interval is the period of the passes run by Run.
+	Interval         time.Duration
+	MinAllocationAge time.Duration
+	// CheckNetns enables releasing the IPs of owners whose network namespace
+	// no longer exists. It requires the host netns directories to be visible
+	// to the daemon.
+	CheckNetns bool
+	// DryRun makes the passes run by Run only log the stale owners.
+	DryRun bool
+	// Events is optional and receives the released IPs.
+	Events *EventBus
+}
+
+// SetDefaults applies default values to the GarbageCollectorConfig fields if they are unset (<= 0).
+func (c *GarbageCollectorConfig) SetDefaults() {
+	if c.Interval <= 0 {
+		c.Interval = DefaultGCInterval
+	}
+	if c.MinAllocationAge <= 0 {
+		c.MinAllocationAge = DefaultGCMinAllocationAge
+	}
+}
+
+// NewGarbageCollector creates a new GarbageCollector.
+func NewGarbageCollector(cfg GarbageCollectorConfig) *GarbageCollector {
+	cfg.SetDefaults()
+	return &GarbageCollector{
+		kubeClient:       cfg.KubeClient,
+		store:            cfg.Store,
+		nodeName:         cfg.NodeName,
+		logger:           cfg.Logger,
+		releaseCooldown:  cfg.ReleaseCooldown,
+		settings:         cfg.Settings,
+		interval:         cfg.Interval,
+		minAllocationAge: cfg.MinAllocationAge,
+		checkNetns:       cfg.CheckNetns,
+		dryRun:           cfg.DryRun,
+		events:           cfg.Events,
+		now:              time.Now,
+	}
+}
+
+// Run runs a garbage collection pass immediately and then periodically until ctx is done.
+func (g *GarbageCollector) Run(ctx context.Context) {
+	g.logger.Info("Starting Metis Daemon garbage collector", "node", g.nodeName, "interval", g.interval, "checkNetns", g.checkNetns, "dryRun", g.dryRun)
+	defer g.logger.Info("Stopping Metis Daemon garbage collector")
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if _, err := g.Collect(ctx, g.dryRun); err != nil {
+			g.logger.Error(err, "Garbage collection pass failed")
+		}
+	}, g.interval)
+}
+
+// Collect finds the stale IP owners and, unless dryRun is set, releases their
+// IPs with the release cooldown. It returns the stale owners. If releasing
+// some owners fails, the remaining owners are still released and the returned
+// owners only include those that were released.
+func (g *GarbageCollector) Collect(ctx context.Context, dryRun bool) ([]StaleIPOwner, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Pods are listed before the owners so that an owner allocated after the
+	// list is always younger than the minimum allocation age.
+	pods, err := g.listPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := g.store.ListIPOwners(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IP owners: %w", err)
+	}
+
+	now := g.now()
+	var stale []StaleIPOwner
+	var errs []error
+	for _, owner := range owners {
+		reason := g.staleReason(owner, pods, now)
+		if reason == "" {
+			continue
+		}
+		logger := g.logger.WithValues("network", owner.Network, "containerID", owner.ContainerID, "interfaceName", owner.InterfaceName,
+			"podNamespace", owner.PodNamespace, "podName", owner.PodName, "addresses", owner.Addresses, "reason", reason)
+		if dryRun {
+			logger.Info("Found stale IP owner (dry run)")
+			stale = append(stale, StaleIPOwner{IPOwner: owner, Reason: reason})
+			continue
+		}
+
+		released, err := g.store.ReleaseIPByOwner(ctx, owner.Network, owner.ContainerID, owner.InterfaceName, g.releaseCooldownFor(owner.Network))
+		if err != nil {
+			logger.Error(err, "Failed to release IPs of stale owner")
+			errs = append(errs, fmt.Errorf("failed to release IPs of container %s interface %s on network %s: %w", owner.ContainerID, owner.InterfaceName, owner.Network, err))
+			continue
+		}
+		logger.Info("Released IPs of stale owner", "releasedIPs", released)
+		gcReleasedIPs.WithLabelValues(owner.Network, reason).Add(float64(len(released)))
+		publishReleasedIPs(g.events, owner, released)
+		stale = append(stale, StaleIPOwner{IPOwner: owner, Reason: reason})
+	}
+
+	g.logger.V(2).Info("Garbage collection pass completed", "owners", len(owners), "stale", len(stale), "dryRun", dryRun)
+	return stale, errors.Join(errs...)
+}
+
+// releaseCooldownFor returns the release cooldown of a network.
+func (g *GarbageCollector) releaseCooldownFor(network string) time.Duration {
+	if g.settings == nil {
+		return g.releaseCooldown
+	}
+	return g.settings.ForNetwork(network).ReleaseCooldown
+}
+
+// listPods returns the pods scheduled to the node by namespace and name.
+func (g *GarbageCollector) listPods(ctx context.Context) (map[types.NamespacedName]*corev1.Pod, error) {
+	podList, err := g.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", g.nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods on node %s: %w", g.nodeName, err)
+	}
+
+	pods := make(map[types.NamespacedName]*corev1.Pod, len(podList.Items))
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Spec.NodeName != g.nodeName {
+			continue
+		}
+		pods[types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}] = pod
+	}
+	return pods, nil
+}
+
+// staleReason returns why owner is stale, or an empty string if it is not.
+func (g *GarbageCollector) staleReason(owner store.IPOwner, pods map[types.NamespacedName]*corev1.Pod, now time.Time) string {
+	if !owner.AllocatedAt.IsZero() && now.Sub(owner.AllocatedAt) < g.minAllocationAge {
+		return ""
+	}
+	if g.checkNetns && netnsRemoved(owner.Netns) {
+		return gcReasonNetnsNotFound
+	}
+	if owner.PodName == "" {
+		return ""
+	}
+
+	pod, ok := pods[types.NamespacedName{Namespace: owner.PodNamespace, Name: owner.PodName}]
+	switch {
+	case !ok:
+		return gcReasonPodNotFound
+	case pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed:
+		return gcReasonPodTerminated
+	case !owner.AllocatedAt.IsZero() && pod.CreationTimestamp.Time.After(owner.AllocatedAt.Add(g.minAllocationAge)):
+		// The minimum allocation age absorbs clock skew between the node and the API server.
+		return gcReasonPodRecreated
+	}
+	return ""
+}
+
+// netnsRemoved reports whether the network namespace at path is known to no
+// longer exist. A missing parent directory means the netns mount is not
+// visible to the daemon, so nothing can be concluded from it.
+func netnsRemoved(path string) bool {
+	if path == "" {
+		return false
+	}
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		return false
+	}
+	_, err := os.Stat(path)
+	return errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	adminv1 "k8s.io/metis/api/admin/v1"
+	"k8s.io/metis/pkg/store"
+)
+
+const gcTestNode = "node-1"
+
+func gcTestPod(namespace, name, nodeName string, phase corev1.PodPhase, created time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, CreationTimestamp: metav1.NewTime(created)},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+// setupGCTestStore returns a store with a single IPv4 block and an allocation for each of params.
+func setupGCTestStore(t *testing.T, params ...store.AllocateIPParams) *store.Store {
+	t.Helper()
+	ctx := context.Background()
+	s, err := store.NewStore(ctx, logr.Discard(), filepath.Join(t.TempDir(), "metis_gc_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.AddCIDR(ctx, "default", "10.0.0.0/27"); err != nil {
+		t.Fatalf("Failed to add CIDR: %v", err)
+	}
+	for _, p := range params {
+		p.Network = "default"
+		p.InterfaceName = "eth0"
+		p.IPFamily = store.IPv4
+		if _, _, err := s.AllocateIP(ctx, p); err != nil {
+			t.Fatalf("AllocateIP(%s) failed: %v", p.ContainerID, err)
+		}
+	}
+	return s
+}
+
+func TestGarbageCollector_Collect(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	netnsDir := t.TempDir()
+	liveNetns := filepath.Join(netnsDir, "cni-live")
+	if err := os.WriteFile(liveNetns, nil, 0600); err != nil {
+		t.Fatalf("Failed to create netns file: %v", err)
+	}
+
+	s := setupGCTestStore(t,
+		store.AllocateIPParams{ContainerID: "running", PodNamespace: "ns", PodName: "running", Netns: liveNetns},
+		store.AllocateIPParams{ContainerID: "deleted", PodNamespace: "ns", PodName: "deleted"},
+		store.AllocateIPParams{ContainerID: "succeeded", PodNamespace: "ns", PodName: "succeeded"},
+		store.AllocateIPParams{ContainerID: "other-node", PodNamespace: "ns", PodName: "other-node"},
+		store.AllocateIPParams{ContainerID: "recreated", PodNamespace: "ns", PodName: "recreated"},
+		store.AllocateIPParams{ContainerID: "no-pod-name"},
+		store.AllocateIPParams{ContainerID: "netns-gone", PodNamespace: "ns", PodName: "netns-gone", Netns: filepath.Join(netnsDir, "cni-gone")},
+		store.AllocateIPParams{ContainerID: "netns-unknown", PodNamespace: "ns", PodName: "netns-unknown", Netns: "/nonexistent/netns/cni-unknown"},
+	)
+	kubeClient := kubefake.NewSimpleClientset(
+		gcTestPod("ns", "running", gcTestNode, corev1.PodRunning, now.Add(-time.Hour)),
+		gcTestPod("ns", "succeeded", gcTestNode, corev1.PodSucceeded, now.Add(-time.Hour)),
+		gcTestPod("ns", "other-node", "node-2", corev1.PodRunning, now.Add(-time.Hour)),
+		gcTestPod("ns", "recreated", gcTestNode, corev1.PodRunning, now.Add(30*time.Minute)),
+		gcTestPod("ns", "netns-gone", gcTestNode, corev1.PodRunning, now.Add(-time.Hour)),
+		gcTestPod("ns", "netns-unknown", gcTestNode, corev1.PodRunning, now.Add(-time.Hour)),
+	)
+
+	gc := NewGarbageCollector(GarbageCollectorConfig{
+		Logger:          logr.Discard(),
+		KubeClient:      kubeClient,
+		Store:           s,
+		NodeName:        gcTestNode,
+		ReleaseCooldown: time.Minute,
+		CheckNetns:      true,
+	})
+	gc.now = func() time.Time { return now.Add(time.Hour) }
+
+	wantStale := map[string]string{
+		"deleted":    gcReasonPodNotFound,
+		"succeeded":  gcReasonPodTerminated,
+		"other-node": gcReasonPodNotFound,
+		"recreated":  gcReasonPodRecreated,
+		"netns-gone": gcReasonNetnsNotFound,
+	}
+	wantKept := []string{"running", "no-pod-name", "netns-unknown"}
+
+	for _, dryRun := range []bool{true, false} {
+		stale, err := gc.Collect(ctx, dryRun)
+		if err != nil {
+			t.Fatalf("Collect(dryRun=%t) failed: %v", dryRun, err)
+		}
+		got := map[string]string{}
+		for _, o := range stale {
+			got[o.ContainerID] = o.Reason
+		}
+		if len(got) != len(wantStale) {
+			t.Errorf("Collect(dryRun=%t) returned stale owners %v, want %v", dryRun, got, wantStale)
+		}
+		for containerID, reason := range wantStale {
+			if got[containerID] != reason {
+				t.Errorf("Collect(dryRun=%t): expected %s to be stale with reason %q, got %q", dryRun, containerID, reason, got[containerID])
+			}
+			err := s.CheckAllocation(ctx, "default", containerID, "eth0")
+			if dryRun && err != nil {
+				t.Errorf("Expected dry run to keep the allocation of %s: %v", containerID, err)
+			}
+			if !dryRun && err == nil {
+				t.Errorf("Expected the allocation of %s to be released", containerID)
+			}
+		}
+	}
+
+	for _, containerID := range wantKept {
+		if err := s.CheckAllocation(ctx, "default", containerID, "eth0"); err != nil {
+			t.Errorf("Expected the allocation of %s to be kept: %v", containerID, err)
+		}
+	}
+
+	// Released IPs go through the normal release cooldown.
+	usage, err := s.GetIPUsage(ctx, "default", store.IPv4)
+	if err != nil {
+		t.Fatalf("GetIPUsage failed: %v", err)
+	}
+	if usage.Cooldown != len(wantStale) {
+		t.Errorf("Expected %d IPs in cooldown, got %d", len(wantStale), usage.Cooldown)
+	}
+}
+
+func TestGarbageCollector_CollectSkipsRecentAllocations(t *testing.T) {
+	ctx := context.Background()
+	s := setupGCTestStore(t, store.AllocateIPParams{ContainerID: "new", PodNamespace: "ns", PodName: "new"})
+	gc := NewGarbageCollector(GarbageCollectorConfig{
+		Logger:     logr.Discard(),
+		KubeClient: kubefake.NewSimpleClientset(),
+		Store:      s,
+		NodeName:   gcTestNode,
+	})
+
+	stale, err := gc.Collect(ctx, false)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(stale) != 0 {
+		t.Errorf("Expected no stale owners within the minimum allocation age, got %+v", stale)
+	}
+	if err := s.CheckAllocation(ctx, "default", "new", "eth0"); err != nil {
+		t.Errorf("Expected the allocation to be kept: %v", err)
+	}
+}
+
+func TestGarbageCollector_CollectPodListError(t *testing.T) {
+	ctx := context.Background()
+	s := setupGCTestStore(t, store.AllocateIPParams{ContainerID: "c1", PodNamespace: "ns", PodName: "pod-1"})
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("api server unavailable")
+	})
+	gc := NewGarbageCollector(GarbageCollectorConfig{
+		Logger:     logr.Discard(),
+		KubeClient: kubeClient,
+		Store:      s,
+		NodeName:   gcTestNode,
+	})
+	gc.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	if _, err := gc.Collect(ctx, false); err == nil {
+		t.Fatal("Expected Collect to fail")
+	}
+	if err := s.CheckAllocation(ctx, "default", "c1", "eth0"); err != nil {
+		t.Errorf("Expected no allocation to be released when pods cannot be listed: %v", err)
+	}
+}
+
+func TestAdaptiveIpamServer_GarbageCollect(t *testing.T) {
+	ctx := context.Background()
+	s := setupGCTestStore(t,
+		store.AllocateIPParams{ContainerID: "c1", PodNamespace: "ns", PodName: "pod-1"},
+		store.AllocateIPParams{ContainerID: "c2", PodNamespace: "ns", PodName: "pod-2"},
+	)
+	server := newAdaptiveIpamServer(logr.Discard(), s, "", 0, 0)
+
+	if _, err := server.GarbageCollect(ctx, &adminv1.GarbageCollectRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable without a garbage collector, got %v", err)
+	}
+
+	server.gc = NewGarbageCollector(GarbageCollectorConfig{
+		Logger:     logr.Discard(),
+		KubeClient: kubefake.NewSimpleClientset(gcTestPod("ns", "pod-1", gcTestNode, corev1.PodRunning, time.Now().Add(-time.Hour))),
+		Store:      s,
+		NodeName:   gcTestNode,
+	})
+	server.gc.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	resp, err := server.GarbageCollect(ctx, &adminv1.GarbageCollectRequest{DryRun: true})
+	if err != nil {
+		t.Fatalf("GarbageCollect failed: %v", err)
+	}
+	if !resp.DryRun || len(resp.StaleOwners) != 1 {
+		t.Fatalf("Unexpected response: %v", resp)
+	}
+	o := resp.StaleOwners[0]
+	if o.ContainerId != "c2" || o.PodName != "pod-2" || o.Reason != gcReasonPodNotFound || len(o.Addresses) != 1 || o.AllocatedAt == nil {
+		t.Errorf("Unexpected stale owner: %v", o)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/metis/pkg/store"
+)
+
+const (
+	DefaultHistoryMaxAge        = 24 * time.Hour
+	DefaultHistoryMaxRecords    = 100000
+	DefaultHistoryPruneInterval = time.Minute
+)
+
+// HistoryPruner maintains the allocation history of the store. Each pass
+// records the release cooldowns that ended since the previous pass, and
+// bounds the history to a maximum age and number of records.
+type HistoryPruner struct {
+	store      *store.Store
+	logger     logr.Logger
+	maxAge     time.Duration
+	maxRecords int
+	interval   time.Duration
+}
+
+// HistoryPrunerConfig holds the configuration for the HistoryPruner.
+type HistoryPrunerConfig struct {
+	Logger     logr.Logger
+	Store      *store.Store
+	MaxAge     time.Duration
+	MaxRecords int
+	Interval   time.Duration
+}
+
+// SetDefaults applies default values to the HistoryPrunerConfig fields if they are unset (<= 0).
+func (c *HistoryPrunerConfig) SetDefaults() {
+	if c.MaxAge <= 0 {
+		c.MaxAge = DefaultHistoryMaxAge
+	}
+	if c.MaxRecords <= 0 {
+		c.MaxRecords = DefaultHistoryMaxRecords
+	}
+	if c.Interval <= 0 {
+		c.Interval = DefaultHistoryPruneInterval
+	}
+}
+
+// NewHistoryPruner creates a new HistoryPruner.
+func NewHistoryPruner(cfg HistoryPrunerConfig) *HistoryPruner {
+	cfg.SetDefaults()
+	return &HistoryPruner{
+		store:      cfg.Store,
+		logger:     cfg.Logger,
+		maxAge:     cfg.MaxAge,
+		maxRecords: cfg.MaxRecords,
+		interval:   cfg.Interval,
+	}
+}
+
+// Run runs a pass every interval until ctx is done.
+func (p *HistoryPruner) Run(ctx context.Context) {
+	p.logger.Info("Starting allocation history pruner", "maxAge", p.maxAge, "maxRecords", p.maxRecords, "interval", p.interval)
+	defer p.logger.Info("Stopping allocation history pruner")
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := p.prune(ctx); err != nil {
+			p.logger.Error(err, "Allocation history pass failed")
+		}
+	}, p.interval)
+}
+
+func (p *HistoryPruner) prune(ctx context.Context) error {
+	expired, err := p.store.ExpireReleaseCooldowns(ctx)
+	if err != nil {
+		return err
+	}
+	pruned, err := p.store.PruneHistory(ctx, p.maxAge, p.maxRecords)
+	if err != nil {
+		return fmt.Errorf("failed to prune allocation history: %w", err)
+	}
+	if expired > 0 || pruned > 0 {
+		p.logger.V(4).Info("Updated allocation history", "expiredCooldowns", expired, "prunedRecords", pruned)
+	}
+	return nil
+}
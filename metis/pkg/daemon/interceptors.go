@@ -0,0 +1,128 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"k8s.io/metis/pkg"
+)
+
+// requestIDUnaryInterceptor makes the request ID sent by the CNI plugin, or a
+// new one if there is none, available to the handlers through
+// pkg.RequestIDFromContext. The ID is returned in the response header and
+// recorded on the span of the request, and failed requests are logged with
+// it.
+func requestIDUnaryInterceptor(logger logr.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		id := pkg.RequestIDFromContext(ctx)
+		if id == "" {
+			id = pkg.NewRequestID()
+		}
+		ctx = pkg.WithRequestID(ctx, id)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(pkg.RequestIDMetadataKey, id))
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("metis.request_id", id))
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		if err != nil {
+			logger.Info("gRPC request failed", "method", info.FullMethod, "requestID", id, "code", status.Code(err),
+				"reason", pkg.ErrorReason(err), "duration", time.Since(start), "err", err)
+		} else {
+			logger.V(4).Info("gRPC request succeeded", "method", info.FullMethod, "requestID", id, "duration", time.Since(start))
+		}
+		return resp, err
+	}
+}
+
+// recoveryUnaryInterceptor turns a panic of a handler into an Internal error,
+// so that a bug in one request does not crash the daemon and fail every
+// pod sandbox creation of the node.
+func recoveryUnaryInterceptor(logger logr.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error(nil, "Recovered from panic in gRPC handler", "method", info.FullMethod,
+					"requestID", pkg.RequestIDFromContext(ctx), "panic", r, "stack", string(debug.Stack()))
+				rpcPanics.WithLabelValues(info.FullMethod).Inc()
+				resp, err = nil, pkg.StatusErrorf(codes.Internal, pkg.ReasonInternal, "internal error in %s: %v", info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// requestIDStreamInterceptor is the requestIDUnaryInterceptor of the
+// streaming methods, like the WatchEvents of the admin API.
+func requestIDStreamInterceptor(logger logr.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		id := pkg.RequestIDFromContext(ctx)
+		if id == "" {
+			id = pkg.NewRequestID()
+		}
+		ctx = pkg.WithRequestID(ctx, id)
+		_ = ss.SetHeader(metadata.Pairs(pkg.RequestIDMetadataKey, id))
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("metis.request_id", id))
+
+		start := time.Now()
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		if err != nil {
+			logger.Info("gRPC stream failed", "method", info.FullMethod, "requestID", id, "code", status.Code(err),
+				"reason", pkg.ErrorReason(err), "duration", time.Since(start), "err", err)
+		} else {
+			logger.V(4).Info("gRPC stream ended", "method", info.FullMethod, "requestID", id, "duration", time.Since(start))
+		}
+		return err
+	}
+}
+
+// recoveryStreamInterceptor is the recoveryUnaryInterceptor of the streaming
+// methods.
+func recoveryStreamInterceptor(logger logr.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error(nil, "Recovered from panic in gRPC handler", "method", info.FullMethod,
+					"requestID", pkg.RequestIDFromContext(ss.Context()), "panic", r, "stack", string(debug.Stack()))
+				rpcPanics.WithLabelValues(info.FullMethod).Inc()
+				err = pkg.StatusErrorf(codes.Internal, pkg.ReasonInternal, "internal error in %s: %v", info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// serverStream overrides the context of a grpc.ServerStream, which has no
+// setter, to pass the request ID down the interceptor chain.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
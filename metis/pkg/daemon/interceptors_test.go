@@ -0,0 +1,137 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"k8s.io/metis/pkg"
+)
+
+func TestRequestIDUnaryInterceptor(t *testing.T) {
+	interceptor := requestIDUnaryInterceptor(logr.Discard())
+	info := &grpc.UnaryServerInfo{FullMethod: "/adaptiveipam.v1.AdaptiveIpam/AllocatePodIP"}
+
+	var got string
+	handler := func(ctx context.Context, _ any) (any, error) {
+		got = pkg.RequestIDFromContext(ctx)
+		return nil, nil
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(pkg.RequestIDMetadataKey, "from-cni"))
+	if _, err := interceptor(ctx, nil, info, handler); err != nil {
+		t.Fatalf("Interceptor failed: %v", err)
+	}
+	if got != "from-cni" {
+		t.Errorf("Expected the request ID of the CNI plugin, got %q", got)
+	}
+
+	if _, err := interceptor(context.Background(), nil, info, handler); err != nil {
+		t.Fatalf("Interceptor failed: %v", err)
+	}
+	if got == "" || got == "from-cni" {
+		t.Errorf("Expected a new request ID, got %q", got)
+	}
+}
+
+func TestRecoveryUnaryInterceptor(t *testing.T) {
+	registerDaemonMetrics()
+	interceptor := recoveryUnaryInterceptor(logr.Discard())
+	info := &grpc.UnaryServerInfo{FullMethod: "/adaptiveipam.v1.AdaptiveIpam/AllocatePodIP"}
+
+	resp, err := interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		panic("boom")
+	})
+	if resp != nil || status.Code(err) != codes.Internal || pkg.ErrorReason(err) != pkg.ReasonInternal {
+		t.Errorf("Expected an Internal error with reason %s, got %v, %v", pkg.ReasonInternal, resp, err)
+	}
+
+	resp, err = interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return "ok", nil
+	})
+	if resp != "ok" || err != nil {
+		t.Errorf("Expected the response of the handler, got %v, %v", resp, err)
+	}
+}
+
+// fakeServerStream is a grpc.ServerStream recording the header it is sent.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeServerStream) SetHeader(md metadata.MD) error {
+	f.header = metadata.Join(f.header, md)
+	return nil
+}
+
+func TestRequestIDStreamInterceptor(t *testing.T) {
+	interceptor := requestIDStreamInterceptor(logr.Discard())
+	info := &grpc.StreamServerInfo{FullMethod: "/admin.v1.Admin/WatchEvents", IsServerStream: true}
+
+	var got string
+	handler := func(_ any, ss grpc.ServerStream) error {
+		got = pkg.RequestIDFromContext(ss.Context())
+		return nil
+	}
+	ss := &fakeServerStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(pkg.RequestIDMetadataKey, "from-cli"))}
+	if err := interceptor(nil, ss, info, handler); err != nil {
+		t.Fatalf("Interceptor failed: %v", err)
+	}
+	if got != "from-cli" {
+		t.Errorf("Expected the request ID of the client, got %q", got)
+	}
+	if ids := ss.header.Get(pkg.RequestIDMetadataKey); len(ids) != 1 || ids[0] != "from-cli" {
+		t.Errorf("Expected the request ID in the header, got %v", ids)
+	}
+
+	ss = &fakeServerStream{ctx: context.Background()}
+	if err := interceptor(nil, ss, info, handler); err != nil {
+		t.Fatalf("Interceptor failed: %v", err)
+	}
+	if got == "" || got == "from-cli" {
+		t.Errorf("Expected a new request ID, got %q", got)
+	}
+}
+
+func TestRecoveryStreamInterceptor(t *testing.T) {
+	registerDaemonMetrics()
+	interceptor := recoveryStreamInterceptor(logr.Discard())
+	info := &grpc.StreamServerInfo{FullMethod: "/admin.v1.Admin/WatchEvents", IsServerStream: true}
+	ss := &fakeServerStream{ctx: context.Background()}
+
+	err := interceptor(nil, ss, info, func(any, grpc.ServerStream) error {
+		panic("boom")
+	})
+	if status.Code(err) != codes.Internal || pkg.ErrorReason(err) != pkg.ReasonInternal {
+		t.Errorf("Expected an Internal error with reason %s, got %v", pkg.ReasonInternal, err)
+	}
+
+	if err := interceptor(nil, ss, info, func(any, grpc.ServerStream) error { return nil }); err != nil {
+		t.Errorf("Expected the result of the handler, got %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	nncclientset "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned"
+	nncinformers "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/informers/externalversions/nodenetworkconfig/v1"
+	nnclisters "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/listers/nodenetworkconfig/v1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/metis/pkg/store"
+)
+
+const (
+	DefaultKubeEventThrottleInterval = time.Minute
+
+	// kubeEventComponent is the source component of the Kubernetes Events of the daemon.
+	kubeEventComponent = "metis"
+	// kubeEventLookupTimeout bounds the lookup of the object of an Event.
+	kubeEventLookupTimeout = 5 * time.Second
+
+	// eventReasonWaitingForIPCapacity is the reason of the Events emitted when
+	// an allocation waits for a new CIDR block.
+	eventReasonWaitingForIPCapacity = "WaitingForIPCapacity"
+	// eventReasonIPExhausted is the reason of the Events emitted when an
+	// allocation fails because no CIDR block was added in time.
+	eventReasonIPExhausted = "IPExhausted"
+)
+
+// KubeEventRecorder emits Kubernetes Events on the pods whose IP allocation
+// waits for, or fails for lack of, pod IP capacity, and on the
+// NodeNetworkConfig of the node. These are otherwise only visible in the logs
+// of the daemon and the CNI plugin, while users only see a failed sandbox
+// creation.
+//
+// Events are throttled per object, reason and network, since kubelet retries
+// the sandbox creation of a pod until an IP is available. A nil
+// KubeEventRecorder emits no Events.
+type KubeEventRecorder struct {
+	logger     logr.Logger
+	kubeClient kubernetes.Interface
+	nncClient  nncclientset.Interface
+	nncLister  nnclisters.NodeNetworkConfigLister
+	nodeName   string
+	throttle   time.Duration
+
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+
+	mu sync.Mutex
+	// lastEmitted is when an Event was last emitted per throttle key.
+	lastEmitted map[string]time.Time
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+// KubeEventRecorderConfig holds the configuration for the KubeEventRecorder.
+type KubeEventRecorderConfig struct {
+	Logger      logr.Logger
+	KubeClient  kubernetes.Interface
+	NNCClient   nncclientset.Interface
+	NNCInformer nncinformers.NodeNetworkConfigInformer
+	NodeName    string
+	// ThrottleInterval is the minimum interval between two Events with the
+	// same object, reason and network.
+	ThrottleInterval time.Duration
+	// Recorder is optional and primarily used to override the recorder
+	// writing the Events to the API server for testing.
+	Recorder record.EventRecorder
+}
+
+// SetDefaults applies default values to the KubeEventRecorderConfig fields if they are unset (<= 0).
+func (c *KubeEventRecorderConfig) SetDefaults() {
+	if c.ThrottleInterval <= 0 {
+		c.ThrottleInterval = DefaultKubeEventThrottleInterval
+	}
+}
+
+// NewKubeEventRecorder creates a new KubeEventRecorder. The Events are only
+// written to the API server once Run is called.
+func NewKubeEventRecorder(cfg KubeEventRecorderConfig) *KubeEventRecorder {
+	cfg.SetDefaults()
+	r := &KubeEventRecorder{
+		logger:      cfg.Logger,
+		kubeClient:  cfg.KubeClient,
+		nncClient:   cfg.NNCClient,
+		nodeName:    cfg.NodeName,
+		throttle:    cfg.ThrottleInterval,
+		recorder:    cfg.Recorder,
+		lastEmitted: map[string]time.Time{},
+		now:         time.Now,
+	}
+	if cfg.NNCInformer != nil {
+		r.nncLister = cfg.NNCInformer.Lister()
+	}
+	if r.recorder == nil {
+		r.broadcaster = record.NewBroadcaster()
+		r.recorder = r.broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: kubeEventComponent, Host: cfg.NodeName})
+	}
+	return r
+}
+
+// Run writes the Events to the API server until ctx is done.
+func (r *KubeEventRecorder) Run(ctx context.Context) {
+	if r.broadcaster == nil {
+		return
+	}
+	r.logger.Info("Starting Kubernetes event recorder", "throttleInterval", r.throttle)
+	defer r.logger.Info("Stopping Kubernetes event recorder")
+
+	r.broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: r.kubeClient.CoreV1().Events("")})
+	<-ctx.Done()
+	r.broadcaster.Shutdown()
+}
+
+// allocationWaiting records that the allocation of an IP of ipFamily for a
+// pod waits for a new CIDR block of network.
+func (r *KubeEventRecorder) allocationWaiting(podNamespace, podName, network string, ipFamily store.IPFamily) {
+	if r == nil {
+		return
+	}
+	r.podEvent(podNamespace, podName, network, corev1.EventTypeNormal, eventReasonWaitingForIPCapacity,
+		fmt.Sprintf("Waiting for additional pod IP capacity on network %s (%s)", network, ipFamily))
+	r.nncEvent(network, corev1.EventTypeNormal, eventReasonWaitingForIPCapacity,
+		fmt.Sprintf("Pods are waiting for additional pod IP capacity on network %s (%s)", network, ipFamily))
+}
+
+// allocationExhausted records that the allocation of an IP of ipFamily for a
+// pod failed after waiting for a new CIDR block of network for waited.
+func (r *KubeEventRecorder) allocationExhausted(podNamespace, podName, network string, ipFamily store.IPFamily, waited time.Duration) {
+	if r == nil {
+		return
+	}
+	waited = waited.Round(time.Second)
+	r.podEvent(podNamespace, podName, network, corev1.EventTypeWarning, eventReasonIPExhausted,
+		fmt.Sprintf("IP exhaustion on network %s (%s), scale-up pending for %s", network, ipFamily, waited))
+	r.nncEvent(network, corev1.EventTypeWarning, eventReasonIPExhausted,
+		fmt.Sprintf("IP exhaustion on network %s (%s), scale-up pending for %s, failed to allocate an IP for pod %s/%s",
+			network, ipFamily, waited, podNamespace, podName))
+}
+
+// allocationFailed records that the allocation of an IP of ipFamily for a
+// pod failed right away, since no CIDR block of network is requested on
+// exhaustion.
+func (r *KubeEventRecorder) allocationFailed(podNamespace, podName, network string, ipFamily store.IPFamily) {
+	if r == nil {
+		return
+	}
+	r.podEvent(podNamespace, podName, network, corev1.EventTypeWarning, eventReasonIPExhausted,
+		fmt.Sprintf("IP exhaustion on network %s (%s), no scale-up is requested for the network", network, ipFamily))
+	r.nncEvent(network, corev1.EventTypeWarning, eventReasonIPExhausted,
+		fmt.Sprintf("IP exhaustion on network %s (%s), no scale-up is requested for the network, failed to allocate an IP for pod %s/%s",
+			network, ipFamily, podNamespace, podName))
+}
+
+// podEvent emits an Event on a pod unless it is throttled. The pod is looked
+// up asynchronously, so that the Event refers to its UID without delaying the
+// allocation.
+func (r *KubeEventRecorder) podEvent(podNamespace, podName, network, eventType, reason, message string) {
+	if podName == "" || !r.allow("Pod/"+podNamespace+"/"+podName+"/"+reason+"/"+network) {
+		return
+	}
+	go func() {
+		ref := &corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: podNamespace, Name: podName}
+		if r.kubeClient != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), kubeEventLookupTimeout)
+			defer cancel()
+			pod, err := r.kubeClient.CoreV1().Pods(podNamespace).Get(ctx, podName, metav1.GetOptions{})
+			if err != nil {
+				r.logger.V(4).Info("Failed to get pod for Event, emitting it without UID", "pod", podNamespace+"/"+podName, "reason", reason, "err", err)
+			} else {
+				ref.UID, ref.ResourceVersion = pod.UID, pod.ResourceVersion
+			}
+		}
+		r.recorder.Event(ref, eventType, reason, message)
+	}()
+}
+
+// nncEvent emits an Event on the NodeNetworkConfig of the node unless it is throttled.
+func (r *KubeEventRecorder) nncEvent(network, eventType, reason, message string) {
+	if !r.allow("NodeNetworkConfig/" + reason + "/" + network) {
+		return
+	}
+	go func() {
+		ref := &corev1.ObjectReference{Kind: "NodeNetworkConfig", APIVersion: nncv1.SchemeGroupVersion.String(), Name: r.nodeName}
+		if r.nncLister != nil || r.nncClient != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), kubeEventLookupTimeout)
+			defer cancel()
+			nnc, err := getNodeNetworkConfig(ctx, r.nncLister, r.nncClient, r.nodeName)
+			if err != nil {
+				r.logger.V(4).Info("Failed to get NodeNetworkConfig for Event, emitting it without UID", "reason", reason, "err", err)
+			} else {
+				ref.UID, ref.ResourceVersion = nnc.UID, nnc.ResourceVersion
+			}
+		}
+		r.recorder.Event(ref, eventType, reason, message)
+	}()
+}
+
+// allow reports whether an Event with the given throttle key can be emitted,
+// and if so records that it was.
+func (r *KubeEventRecorder) allow(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if last, ok := r.lastEmitted[key]; ok && now.Sub(last) < r.throttle {
+		return false
+	}
+	// Forget the keys that are no longer throttled, e.g. of deleted pods.
+	for k, last := range r.lastEmitted {
+		if now.Sub(last) >= r.throttle {
+			delete(r.lastEmitted, k)
+		}
+	}
+	r.lastEmitted[key] = now
+	return true
+}
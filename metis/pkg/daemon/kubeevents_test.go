@@ -0,0 +1,222 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	nncfake "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned/fake"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/metis/api/adaptiveipam/v1"
+	"k8s.io/metis/pkg/store"
+)
+
+type recordedKubeEvent struct {
+	ref                        *corev1.ObjectReference
+	eventType, reason, message string
+}
+
+// fakeKubeEventRecorder is a record.EventRecorder sending the events and the
+// references of their objects to a channel.
+type fakeKubeEventRecorder struct {
+	events chan recordedKubeEvent
+}
+
+func (f *fakeKubeEventRecorder) Event(object runtime.Object, eventType, reason, message string) {
+	f.events <- recordedKubeEvent{ref: object.(*corev1.ObjectReference), eventType: eventType, reason: reason, message: message}
+}
+
+func (f *fakeKubeEventRecorder) Eventf(object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	f.Event(object, eventType, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (f *fakeKubeEventRecorder) AnnotatedEventf(object runtime.Object, _ map[string]string, eventType, reason, messageFmt string, args ...interface{}) {
+	f.Eventf(object, eventType, reason, messageFmt, args...)
+}
+
+// receiveKubeEvents receives n events, keyed by the kind of their object.
+func receiveKubeEvents(t *testing.T, recorder *fakeKubeEventRecorder, n int) map[string]recordedKubeEvent {
+	t.Helper()
+	events := map[string]recordedKubeEvent{}
+	for range n {
+		select {
+		case ev := <-recorder.events:
+			events[ev.ref.Kind] = ev
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for events, got %v", events)
+		}
+	}
+	return events
+}
+
+func TestKubeEventRecorder(t *testing.T) {
+	nodeName := "test-node"
+	kubeClient := kubefake.NewSimpleClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "web-0", UID: "pod-uid"}})
+	nncClient := nncfake.NewSimpleClientset(&nncv1.NodeNetworkConfig{ObjectMeta: metav1.ObjectMeta{Name: nodeName, UID: "nnc-uid"}})
+	fakeRecorder := &fakeKubeEventRecorder{events: make(chan recordedKubeEvent, 10)}
+	r := NewKubeEventRecorder(KubeEventRecorderConfig{
+		Logger:     logr.Discard(),
+		KubeClient: kubeClient,
+		NNCClient:  nncClient,
+		NodeName:   nodeName,
+		Recorder:   fakeRecorder,
+	})
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	r.allocationWaiting("ns", "web-0", "default", store.IPv4)
+	events := receiveKubeEvents(t, fakeRecorder, 2)
+	if ev := events["Pod"]; ev.ref.UID != "pod-uid" || ev.ref.Name != "web-0" || ev.eventType != corev1.EventTypeNormal ||
+		ev.reason != eventReasonWaitingForIPCapacity || !strings.Contains(ev.message, "network default (ipv4)") {
+		t.Errorf("Unexpected pod event %+v", ev)
+	}
+	if ev := events["NodeNetworkConfig"]; ev.ref.UID != "nnc-uid" || ev.ref.Name != nodeName || ev.reason != eventReasonWaitingForIPCapacity {
+		t.Errorf("Unexpected NodeNetworkConfig event %+v", ev)
+	}
+
+	// The pod of the second allocation no longer exists and is referred to
+	// by name, while the NodeNetworkConfig event of the network is throttled.
+	r.allocationWaiting("ns", "web-1", "default", store.IPv4)
+	if ev := receiveKubeEvents(t, fakeRecorder, 1)["Pod"]; ev.ref.Name != "web-1" || ev.ref.UID != "" {
+		t.Errorf("Unexpected pod event %+v", ev)
+	}
+	r.allocationWaiting("ns", "web-0", "default", store.IPv4)
+
+	r.allocationExhausted("ns", "web-0", "default", store.IPv4, 29600*time.Millisecond)
+	events = receiveKubeEvents(t, fakeRecorder, 2)
+	for _, ev := range events {
+		if ev.eventType != corev1.EventTypeWarning || ev.reason != eventReasonIPExhausted || !strings.Contains(ev.message, "scale-up pending for 30s") {
+			t.Errorf("Unexpected exhaustion event %+v", ev)
+		}
+	}
+
+	now = now.Add(DefaultKubeEventThrottleInterval)
+	r.allocationWaiting("ns", "web-0", "default", store.IPv4)
+	receiveKubeEvents(t, fakeRecorder, 2)
+	select {
+	case ev := <-fakeRecorder.events:
+		t.Errorf("Unexpected event %+v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	var nilRecorder *KubeEventRecorder
+	nilRecorder.allocationWaiting("ns", "web-0", "default", store.IPv4)
+	nilRecorder.allocationExhausted("ns", "web-0", "default", store.IPv4, time.Second)
+	nilRecorder.allocationFailed("ns", "web-0", "default", store.IPv4)
+}
+
+func TestAdaptiveIpamServer_AllocationExhaustedEvents(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_kubeevents_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+
+	server := newAdaptiveIpamServer(logger, storeInstance, "", 0, 0)
+	nodeName := "test-node"
+	nncClient := nncfake.NewSimpleClientset(&nncv1.NodeNetworkConfig{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+	server.engine.SetMonitor(NewMonitor(MonitorConfig{
+		Logger:          logger,
+		NNCClient:       nncClient,
+		Store:           storeInstance,
+		NodeName:        nodeName,
+		MonitorInterval: time.Second,
+	}))
+	fakeRecorder := &fakeKubeEventRecorder{events: make(chan recordedKubeEvent, 10)}
+	server.engine.SetKubeEventRecorder(NewKubeEventRecorder(KubeEventRecorderConfig{
+		Logger:    logger,
+		NNCClient: nncClient,
+		NodeName:  nodeName,
+		Recorder:  fakeRecorder,
+	}))
+
+	reqCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = server.AllocatePodIP(reqCtx, &adaptiveipam.AllocatePodIPRequest{
+		Network:      "test-network",
+		PodName:      "web-0",
+		PodNamespace: "ns",
+		Ipv4Config:   &adaptiveipam.IPConfig{InterfaceName: "eth0", ContainerId: "c1"},
+	})
+	if err == nil {
+		t.Fatal("Expected AllocatePodIP to fail without pod IP capacity")
+	}
+
+	// The events are emitted asynchronously and may arrive in any order.
+	reasons := map[string]int{}
+	for range 4 {
+		select {
+		case ev := <-fakeRecorder.events:
+			reasons[ev.reason]++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for events, got %v", reasons)
+		}
+	}
+	if reasons[eventReasonWaitingForIPCapacity] != 2 || reasons[eventReasonIPExhausted] != 2 {
+		t.Errorf("Expected a waiting and an exhaustion event on the pod and the NodeNetworkConfig, got %v", reasons)
+	}
+}
+
+func TestAdaptiveIpamServer_FailFastExhaustedEvents(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_kubeevents_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+
+	// Without a monitor the allocation fails right away instead of waiting
+	// for a new CIDR block.
+	server := newAdaptiveIpamServer(logger, storeInstance, "", 0, 0)
+	fakeRecorder := &fakeKubeEventRecorder{events: make(chan recordedKubeEvent, 10)}
+	server.engine.SetKubeEventRecorder(NewKubeEventRecorder(KubeEventRecorderConfig{
+		Logger:   logger,
+		NodeName: "test-node",
+		Recorder: fakeRecorder,
+	}))
+
+	_, err = server.AllocatePodIP(ctx, &adaptiveipam.AllocatePodIPRequest{
+		Network:      "test-network",
+		PodName:      "web-0",
+		PodNamespace: "ns",
+		Ipv4Config:   &adaptiveipam.IPConfig{InterfaceName: "eth0", ContainerId: "c1"},
+	})
+	if err == nil {
+		t.Fatal("Expected AllocatePodIP to fail without pod IP capacity")
+	}
+
+	events := receiveKubeEvents(t, fakeRecorder, 2)
+	for _, kind := range []string{"Pod", "NodeNetworkConfig"} {
+		if ev := events[kind]; ev.eventType != corev1.EventTypeWarning || ev.reason != eventReasonIPExhausted ||
+			!strings.Contains(ev.message, "network test-network (ipv4), no scale-up is requested") {
+			t.Errorf("Unexpected %s exhaustion event %+v", kind, ev)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/status"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/metis/pkg/store"
+)
+
+const (
+	metisNamespace  = "metis"
+	daemonSubsystem = "daemon"
+
+	// metricsPath is the HTTP path the metrics listener serves on.
+	metricsPath = "/metrics"
+	// metricsShutdownTimeout bounds how long the metrics listener waits for
+	// in-flight scrapes when the daemon is stopping.
+	metricsShutdownTimeout = 5 * time.Second
+
+	// ipStateAllocated, ipStateCooldown and ipStateDraining are the values of
+	// the "state" label of the ipAddresses gauge.
+	ipStateAllocated = "allocated"
+	ipStateCooldown  = "cooldown"
+	ipStateDraining  = "draining"
+
+	// allocationResultSuccess, allocationResultExhausted and allocationResultError
+	// are the values of the "result" label of storeAllocationDuration.
+	allocationResultSuccess   = "success"
+	allocationResultExhausted = "exhausted"
+	allocationResultError     = "error"
+
+	// reloadResultSuccess and reloadResultError are the values of the
+	// "result" label of networkConfigReloads.
+	reloadResultSuccess = "success"
+	reloadResultError   = "error"
+)
+
+var (
+	ipAddresses = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "ip_addresses",
+			Help:           "Gauge measuring the number of IP addresses in the local store by state, excluding CIDR blocks that are being deleted.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"network", "ip_family", "state"},
+	)
+	ipCapacity = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "ip_capacity",
+			Help:           "Gauge measuring the total number of IP addresses of the Ready and Draining CIDR blocks in the local store.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"network", "ip_family"},
+	)
+	ipUtilization = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "ip_utilization_ratio",
+			Help:           "Gauge measuring the ratio of allocated IP addresses plus pending requests to the IP capacity, as evaluated by the monitor.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"network", "ip_family"},
+	)
+	pendingAllocationRequests = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "pending_allocation_requests",
+			Help:           "Gauge measuring the number of CNI requests waiting for a dynamic CIDR allocation.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"network", "ip_family"},
+	)
+	scaleUpDecisions = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "scale_up_decisions_total",
+			Help:           "Counter measuring the number of times the monitor requested more pod capacity.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"network", "ip_family"},
+	)
+	scaleUpPushbacks = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "scale_up_pushbacks_total",
+			Help:           "Counter measuring the number of times the monitor held back a scale-up because too many IPs were in release cooldown.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"network", "ip_family"},
+	)
+	drainDecisions = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "drained_cidr_blocks_total",
+			Help:           "Counter measuring the number of CIDR blocks the monitor marked as Draining due to sustained low utilization.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"network", "ip_family"},
+	)
+	rpcDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "rpc_duration_seconds",
+			Help:           "Latency of the AdaptiveIpam RPCs handled by the daemon, including time spent waiting for dynamic allocation.",
+			StabilityLevel: metrics.ALPHA,
+			Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+		},
+		[]string{"method", "code"},
+	)
+	storeAllocationDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "store_allocation_duration_seconds",
+			Help:           "Latency of allocating an IP address from the local store, including retries on transient errors.",
+			StabilityLevel: metrics.ALPHA,
+			Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+		},
+		[]string{"ip_family", "result"},
+	)
+	storeAllocationRetries = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "store_allocation_retries_total",
+			Help:           "Counter measuring the number of IP allocations retried due to transient store errors such as SQLite busy timeouts.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"ip_family"},
+	)
+	gcReleasedIPs = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "gc_released_ips_total",
+			Help:           "Counter measuring the number of IP addresses released by the garbage collector because their pod or sandbox no longer exists.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"network", "reason"},
+	)
+	networkConfigReloads = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "network_config_reloads_total",
+			Help:           "Counter measuring the number of times a changed network configuration file was loaded, by whether it was put into effect.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+	rpcPanics = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metisNamespace,
+			Subsystem:      daemonSubsystem,
+			Name:           "rpc_panics_total",
+			Help:           "Counter measuring the number of gRPC requests whose handler panicked and was recovered.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"method"},
+	)
+)
+
+var registerMetrics sync.Once
+
+// registerDaemonMetrics registers the metis daemon metrics.
+func registerDaemonMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(ipAddresses)
+		legacyregistry.MustRegister(ipCapacity)
+		legacyregistry.MustRegister(ipUtilization)
+		legacyregistry.MustRegister(pendingAllocationRequests)
+		legacyregistry.MustRegister(scaleUpDecisions)
+		legacyregistry.MustRegister(scaleUpPushbacks)
+		legacyregistry.MustRegister(drainDecisions)
+		legacyregistry.MustRegister(rpcDuration)
+		legacyregistry.MustRegister(storeAllocationDuration)
+		legacyregistry.MustRegister(storeAllocationRetries)
+		legacyregistry.MustRegister(gcReleasedIPs)
+		legacyregistry.MustRegister(networkConfigReloads)
+		legacyregistry.MustRegister(rpcPanics)
+	})
+}
+
+// recordUtilization updates the per-network IP usage gauges from a monitor evaluation.
+func recordUtilization(network string, info *UtilizationInfo) {
+	family := string(info.IPFamily)
+	ipAddresses.WithLabelValues(network, family, ipStateAllocated).Set(float64(info.Usage.Allocated))
+	ipAddresses.WithLabelValues(network, family, ipStateCooldown).Set(float64(info.Usage.Cooldown))
+	ipAddresses.WithLabelValues(network, family, ipStateDraining).Set(float64(info.Usage.Draining))
+	ipCapacity.WithLabelValues(network, family).Set(float64(info.Usage.Total))
+	ipUtilization.WithLabelValues(network, family).Set(info.Utilization)
+	pendingAllocationRequests.WithLabelValues(network, family).Set(float64(info.PendingRequests))
+}
+
+// deleteUtilization removes the per-network IP usage gauges of a network that
+// is no longer managed by the daemon.
+func deleteUtilization(network string, ipFamily store.IPFamily) {
+	family := string(ipFamily)
+	for _, state := range []string{ipStateAllocated, ipStateCooldown, ipStateDraining} {
+		ipAddresses.DeleteLabelValues(network, family, state)
+	}
+	ipCapacity.DeleteLabelValues(network, family)
+	ipUtilization.DeleteLabelValues(network, family)
+	pendingAllocationRequests.DeleteLabelValues(network, family)
+}
+
+// observeRPC records the latency of an AdaptiveIpam RPC labeled by its gRPC status code.
+func observeRPC(method string, start time.Time, err error) {
+	rpcDuration.WithLabelValues(method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+}
+
+// observeStoreAllocation records the latency of a store allocation attempt.
+func observeStoreAllocation(ipFamily store.IPFamily, start time.Time, err error) {
+	result := allocationResultSuccess
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrNoAvailableIPs):
+		result = allocationResultExhausted
+	default:
+		result = allocationResultError
+	}
+	storeAllocationDuration.WithLabelValues(string(ipFamily), result).Observe(time.Since(start).Seconds())
+}
+
+// serveMetrics serves the registered metrics on listener until ctx is cancelled.
+func serveMetrics(ctx context.Context, logger logr.Logger, listener net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, legacyregistry.Handler())
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error(err, "Failed to shut down metrics server")
+		}
+	}()
+
+	logger.Info("Metrics server is listening", "address", listener.Addr().String(), "path", metricsPath)
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
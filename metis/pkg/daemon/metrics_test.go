@@ -0,0 +1,237 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	metricsutil "k8s.io/component-base/metrics/testutil"
+	"k8s.io/metis/api/adaptiveipam/v1"
+	"k8s.io/metis/pkg/store"
+)
+
+func TestMonitor_Metrics(t *testing.T) {
+	registerDaemonMetrics()
+	ipAddresses.Reset()
+	ipCapacity.Reset()
+	ipUtilization.Reset()
+	pendingAllocationRequests.Reset()
+	scaleUpDecisions.Reset()
+
+	logger := logr.Discard()
+	network := "test-network"
+	nodeName := "test-node"
+	ctx := context.Background()
+
+	dbPath := filepath.Join(t.TempDir(), "metis_monitor_metrics_test.sqlite")
+	storeInstance, err := store.NewStore(ctx, logger, dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+
+	cidr := "10.0.1.0/28"
+	if err := storeInstance.AddCIDR(ctx, network, cidr); err != nil {
+		t.Fatalf("Failed to add CIDR: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, _, err := storeInstance.AllocateIP(ctx, store.AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: fmt.Sprintf("container-%d", i), IPFamily: store.IPv4}); err != nil {
+			t.Fatalf("Failed to allocate IP: %v", err)
+		}
+	}
+
+	mockNNC := &nncv1.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+		Spec: nncv1.NodeNetworkConfigSpec{
+			Allocations: []nncv1.Allocation{{Network: network, Pods: 16}},
+		},
+		Status: nncv1.NodeNetworkConfigStatus{
+			PodCIDRs: []nncv1.PodCIDR{{CIDR: cidr, Network: network}},
+		},
+	}
+	mockInterface := &mockNodeNetworkConfigInterface{
+		getFunc: func(_ context.Context, _ string, _ metav1.GetOptions) (*nncv1.NodeNetworkConfig, error) {
+			return mockNNC, nil
+		},
+		patchFunc: func(_ context.Context, _ string, _ types.PatchType, _ []byte, _ metav1.PatchOptions, _ ...string) (*nncv1.NodeNetworkConfig, error) {
+			return mockNNC, nil
+		},
+	}
+
+	m := NewMonitor(MonitorConfig{
+		Logger:    logger,
+		NNCClient: &mockClientset{networkingV1: &mockNetworkingV1{nncInterface: mockInterface}},
+		Store:     storeInstance,
+		NodeName:  nodeName,
+		GetPendingRequestsCount: func(_ string, ipFamily store.IPFamily) int {
+			if ipFamily == store.IPv4 {
+				return 2
+			}
+			return 0
+		},
+	})
+
+	if err := m.syncAll(ctx); err != nil {
+		t.Fatalf("syncAll failed: %v", err)
+	}
+
+	// 10 allocated + 3 reserved IPs out of 16, with 2 pending requests.
+	expectedGauges := []struct {
+		name  string
+		gauge func() (float64, error)
+		want  float64
+	}{
+		{
+			name: "allocated",
+			gauge: func() (float64, error) {
+				return metricsutil.GetGaugeMetricValue(ipAddresses.WithLabelValues(network, "ipv4", ipStateAllocated))
+			},
+			want: 13,
+		},
+		{
+			name: "capacity",
+			gauge: func() (float64, error) {
+				return metricsutil.GetGaugeMetricValue(ipCapacity.WithLabelValues(network, "ipv4"))
+			},
+			want: 16,
+		},
+		{
+			name: "utilization",
+			gauge: func() (float64, error) {
+				return metricsutil.GetGaugeMetricValue(ipUtilization.WithLabelValues(network, "ipv4"))
+			},
+			want: 15.0 / 16.0,
+		},
+		{
+			name: "pending",
+			gauge: func() (float64, error) {
+				return metricsutil.GetGaugeMetricValue(pendingAllocationRequests.WithLabelValues(network, "ipv4"))
+			},
+			want: 2,
+		},
+	}
+	for _, g := range expectedGauges {
+		got, err := g.gauge()
+		if err != nil {
+			t.Fatalf("Failed to get %s gauge: %v", g.name, err)
+		}
+		if got != g.want {
+			t.Errorf("Expected %s gauge to be %v, got %v", g.name, g.want, got)
+		}
+	}
+
+	scaleUps, err := metricsutil.GetCounterMetricValue(scaleUpDecisions.WithLabelValues(network, "ipv4"))
+	if err != nil {
+		t.Fatalf("Failed to get scale-up counter: %v", err)
+	}
+	if scaleUps != 1 {
+		t.Errorf("Expected 1 scale-up decision, got %v", scaleUps)
+	}
+
+	// Removing the network from the store deletes its gauges on the next sync.
+	id, _, err := storeInstance.GetCIDRBlock(ctx, cidr, network)
+	if err != nil {
+		t.Fatalf("GetCIDRBlock failed: %v", err)
+	}
+	if err := storeInstance.DeleteCIDRBlock(ctx, id); err != nil {
+		t.Fatalf("DeleteCIDRBlock failed: %v", err)
+	}
+	if err := m.syncAll(ctx); err != nil {
+		t.Fatalf("syncAll failed: %v", err)
+	}
+	metricsutil.AssertVectorCount(t, "metis_daemon_ip_capacity", map[string]string{"network": network}, 0)
+}
+
+func TestAdaptiveIpamServer_RPCMetrics(t *testing.T) {
+	registerDaemonMetrics()
+	rpcDuration.Reset()
+
+	logger := logr.Discard()
+	dbPath := filepath.Join(t.TempDir(), "metis_server_metrics_test.sqlite")
+	storeInstance, err := store.NewStore(context.Background(), logger, dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+
+	s := newAdaptiveIpamServer(logger, storeInstance, "", 0, 0)
+
+	if _, err := s.AllocatePodIP(context.Background(), &adaptiveipam.AllocatePodIPRequest{Network: "test-network"}); err == nil {
+		t.Fatal("Expected AllocatePodIP to fail without IP configs")
+	}
+	if _, err := s.DeallocatePodIP(context.Background(), &adaptiveipam.DeallocatePodIPRequest{Network: "test-network", ContainerId: "c1", InterfaceName: "eth0"}); err != nil {
+		t.Fatalf("DeallocatePodIP failed: %v", err)
+	}
+
+	metricsutil.AssertHistogramTotalCount(t, "metis_daemon_rpc_duration_seconds", map[string]string{"method": "AllocatePodIP", "code": "InvalidArgument"}, 1)
+	metricsutil.AssertHistogramTotalCount(t, "metis_daemon_rpc_duration_seconds", map[string]string{"method": "DeallocatePodIP", "code": "OK"}, 1)
+}
+
+func TestServeMetrics(t *testing.T) {
+	registerDaemonMetrics()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serveMetrics(ctx, logr.Discard(), listener)
+	}()
+
+	storeAllocationRetries.WithLabelValues("ipv4").Inc()
+
+	resp, err := http.Get(fmt.Sprintf("http://%s%s", listener.Addr().String(), metricsPath))
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to read metrics response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(string(body), "metis_daemon_store_allocation_retries_total") {
+		t.Errorf("Expected metrics response to contain metis_daemon_store_allocation_retries_total, got:\n%s", body)
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("serveMetrics returned error: %v", err)
+		}
+	case <-time.After(metricsShutdownTimeout):
+		t.Fatal("Timed out waiting for metrics server to stop")
+	}
+}
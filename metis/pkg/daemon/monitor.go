@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"slices"
 	"time"
 
 	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
@@ -58,8 +59,39 @@ const (
 	// allowing us to perform a single read-modify-write operation on the NodeNetworkConfig (NNC) custom resource,
 	// which avoids API write conflicts and drastically reduces Kubernetes API server I/O overhead.
 	syncKey = "sync"
+
+	// maxBoundedPodCapacity is the largest per-family IP capacity that is tracked
+	// through the NodeNetworkConfig Spec.Allocations[].Pods field (an int32).
+	// IP families whose capacity exceeds it (e.g. a /64 IPv6 pod range) are
+	// never exhausted by pods on a single node, so they still take part in
+	// draining and releasing but do not drive the requested pod count.
+	maxBoundedPodCapacity = math.MaxInt32
 )
 
+// ipFamilies lists the IP families reconciled by the Monitor, in evaluation order.
+var ipFamilies = []store.IPFamily{store.IPv4, store.IPv6}
+
+// networkFamily identifies the CIDR blocks of one IP family within a network.
+type networkFamily struct {
+	network  string
+	ipFamily store.IPFamily
+}
+
+// CapacityProvider assigns CIDR blocks to the node on the requests of the
+// Monitor in place of the NodeNetworkConfig of the API server, e.g. from a
+// local pool of blocks. Like with the NodeNetworkConfig, the requests are the
+// Spec.Allocations and Spec.ReleasableCIDRs, and the assigned blocks are the
+// Status.PodCIDRs.
+type CapacityProvider interface {
+	// NodeNetworkConfig returns the current requests and assigned blocks.
+	NodeNetworkConfig(ctx context.Context) (*nncv1.NodeNetworkConfig, error)
+	// UpdateSpec updates the requests to the spec of nnc.
+	UpdateSpec(ctx context.Context, nnc *nncv1.NodeNetworkConfig) error
+	// ScalesNetwork reports whether the provider assigns the blocks of a
+	// network. The other networks are not scaled by the Monitor.
+	ScalesNetwork(network string) bool
+}
+
 // Monitor manages the dynamic scaling (up and down) of IP CIDR block capacity
 // for each network on a node.
 //
@@ -68,20 +100,29 @@ const (
 //
 // Key Behaviors:
 //
+// Both IPv4 and IPv6 blocks are evaluated independently per network. The
+// requested pod count of a network is the largest target computed for any of
+// its IP families whose capacity is bounded (see maxBoundedPodCapacity).
+// How much capacity to request and when capacity is in excess is decided by
+// the ScalingPolicy of the network, the utilization policy being the default.
+// The policy and the thresholds and durations below can be set per network
+// through the NetworkSettingsSource, and changes take effect on the next sync.
+//
 // 1. Dynamic Scale-Up (Prefetching):
 //   - Calculates utilization as: (AllocatedIPs + PendingRequests) / TotalCapacity.
-//   - If utilization exceeds the target, it increases requested pod capacity in the
-//     NodeNetworkConfig (NNC) CRD (modifying Spec.Allocations[].Pods) aiming for a
-//     target utilization (default 75%).
+//   - Increases requested pod capacity in the NodeNetworkConfig (NNC) CRD
+//     (modifying Spec.Allocations[].Pods) to the desired pod count of the scaling
+//     policy. The utilization policy aims for a target utilization (default 75%).
 //   - Pushes back and defers new requests if the number of IPs currently in a release
 //     cooldown state exceeds a pushback threshold (default 10), preventing premature
 //     capacity requests.
 //
 // 2. Scale-Down (Draining & Releasing):
-//   - Draining: If utilization remains below a low threshold (default 50%) for a
-//     sustained period (default 8 hours), it marks non-initial ready CIDR blocks as
-//     'Draining' one by one until simulated utilization of remaining ready blocks
-//     recovers above the threshold.
+//   - Draining: If the scaling policy reports excess capacity for a sustained
+//     period (default 8 hours), it marks non-initial ready CIDR blocks as
+//     'Draining' one by one until the excess free IPs are drained. The utilization
+//     policy reports excess capacity while utilization is below a low threshold
+//     (default 50%).
 //   - Draining blocks are excluded from new allocations, but can be transitioned
 //     back to 'Ready' by the gRPC server to quickly reclaim capacity during sudden
 //     allocation bursts.
@@ -92,6 +133,11 @@ const (
 //     These 'Deleting' blocks are fully deleted from the local store once the CCM
 //     Dynamic IPAM controller removes them from the NNC Status.
 //
+// 3. Reporting:
+//   - Publishes the IP usage of each network and the time of its last scale-up
+//     in the IPUtilizationConditionType condition of the NNC Status, at most
+//     once per report interval (default 30s).
+//
 // The Monitor uses a rate-limiting workqueue to coordinate and serialize network
 // sync requests, benefiting from deduplication and automatic backoff retries.
 type Monitor struct {
@@ -102,31 +148,50 @@ type Monitor struct {
 	nodeName                string
 	store                   *store.Store
 	logger                  logr.Logger
-	lowUtilizationTimers    map[string]time.Time
-	GetPendingRequestsCount func(network string) int
-
-	// drainingExpiration is the duration after which a draining CIDR block is considered expired and candidate for release.
-	drainingExpiration time.Duration
+	GetPendingRequestsCount func(network string, ipFamily store.IPFamily) int
+	// capacity is optional and takes the place of the NodeNetworkConfig.
+	capacity CapacityProvider
+
+	// excessCapacityTimers tracks since when the scaling policy has been
+	// reporting excess capacity for an IP family of a network.
+	excessCapacityTimers map[networkFamily]time.Time
+
+	// settings resolves the scaling and draining settings of each network.
+	settings *NetworkSettingsSource
+	// customScalingPolicies are the scaling policies by name that take
+	// precedence over the built-in policies.
+	customScalingPolicies map[string]ScalingPolicy
+	// builtinScalingPolicies caches the built-in scaling policy of each
+	// network, so that stateful policies keep their state across syncs until
+	// the policy or the settings of the network change.
+	builtinScalingPolicies map[string]cachedScalingPolicy
+
+	// reportedUtilization tracks the networks and IP families whose usage
+	// gauges were set by the last sync, so that gauges of networks removed
+	// from the store can be deleted.
+	reportedUtilization map[networkFamily]bool
+
+	// usageReport is the IP usage of each network and IP family collected by
+	// the current sync, and lastScaleUp the time of the last scale-up request
+	// of each network, seeded from the store by the first sync once
+	// lastScaleUpSeeded. They are published in the NodeNetworkConfig status.
+	usageReport       map[networkFamily]store.NetworkIPUsage
+	lastScaleUp       map[string]time.Time
+	lastScaleUpSeeded bool
+	// publishedUtilization is the last utilization summary published at
+	// utilizationPublishedAt, at most every utilizationReportInterval.
+	publishedUtilization      string
+	utilizationPublishedAt    time.Time
+	utilizationReportInterval time.Duration
+	// now is overridden in tests.
+	now func() time.Time
+
+	// events is optional and receives the scale-up requests, drained and
+	// deleted CIDR blocks and NodeNetworkConfig patches of the monitor.
+	events *EventBus
 
 	// monitorInterval is how often the monitor evaluates network utilization (pre-fetch) and checks for expired draining blocks.
 	monitorInterval time.Duration
-
-	// lowUtilizationThreshold is the threshold (e.g., 0.50) below which a network is considered under-utilized.
-	lowUtilizationThreshold float64
-
-	// targetUtilizationAfterScaleUp is the desired utilization level (e.g., 0.75) aimed for after scaling up.
-	targetUtilizationAfterScaleUp float64
-
-	// cooldownPushbackThreshold is the maximum number of IPs allowed to be in cooldown
-	// before we hold off on sending new outgoing requests for more CIDR blocks.
-	// This prevents requesting more capacity when we have many IPs about to become available.
-	cooldownPushbackThreshold int
-
-	// cooldownPushbackInterval is how long to wait before re-evaluating a network when held back by cooldown threshold.
-	cooldownPushbackInterval time.Duration
-
-	// sustainedLowUtilizationDuration is the duration utilization must remain low before triggering a drain.
-	sustainedLowUtilizationDuration time.Duration
 }
 
 // MonitorConfig holds the configuration for the Monitor.
@@ -136,7 +201,7 @@ type MonitorConfig struct {
 	NNCInformer                     nncinformers.NodeNetworkConfigInformer
 	Store                           *store.Store
 	NodeName                        string
-	GetPendingRequestsCount         func(network string) int
+	GetPendingRequestsCount         func(network string, ipFamily store.IPFamily) int
 	CooldownPushbackInterval        time.Duration
 	DrainingExpiration              time.Duration
 	MonitorInterval                 time.Duration
@@ -144,6 +209,34 @@ type MonitorConfig struct {
 	LowUtilizationThreshold         float64
 	TargetUtilizationAfterScaleUp   float64
 	CooldownPushbackThreshold       int
+	// ScalingPolicy is the name of the scaling policy of networks without a
+	// policy in NetworkScalingPolicies or the NodeNetworkConfig annotation.
+	ScalingPolicy string
+	// NetworkScalingPolicies maps network names to the name of their scaling policy.
+	NetworkScalingPolicies map[string]string
+	// MinFreeIPs is the number of free IPs kept by the min-free-ips policy.
+	MinFreeIPs int
+	// RateWindow is the window the rate-based policy measures the demand growth over.
+	RateWindow time.Duration
+	// RateLeadTime is how far ahead the rate-based policy pre-warms IPs for.
+	RateLeadTime time.Duration
+	// ScalingPolicies are additional scaling policies by name. They take
+	// precedence over the built-in policies of the same name.
+	ScalingPolicies map[string]ScalingPolicy
+	// Settings is optional and resolves the settings of each network, e.g.
+	// from a network configuration file. If set, it takes the place of the
+	// fields above from CooldownPushbackInterval to RateLeadTime, except for
+	// MonitorInterval.
+	Settings *NetworkSettingsSource
+	// Events is optional and receives the changes made by the monitor.
+	Events *EventBus
+	// UtilizationReportInterval is the minimum interval between two updates
+	// of the IP utilization published in the NodeNetworkConfig status.
+	UtilizationReportInterval time.Duration
+	// CapacityProvider is optional and takes the place of the
+	// NodeNetworkConfig of NNCClient and NNCInformer. No IP utilization is
+	// published with it.
+	CapacityProvider CapacityProvider
 	// RateLimiter is optional and primarily used to override the queue's rate limiter for testing.
 	RateLimiter workqueue.TypedRateLimiter[string]
 }
@@ -171,6 +264,21 @@ func (c *MonitorConfig) SetDefaults() {
 	if c.CooldownPushbackThreshold <= 0 {
 		c.CooldownPushbackThreshold = DefaultCooldownPushbackThreshold
 	}
+	if c.ScalingPolicy == "" {
+		c.ScalingPolicy = DefaultScalingPolicy
+	}
+	if c.MinFreeIPs <= 0 {
+		c.MinFreeIPs = DefaultMinFreeIPs
+	}
+	if c.RateWindow <= 0 {
+		c.RateWindow = DefaultRateWindow
+	}
+	if c.RateLeadTime <= 0 {
+		c.RateLeadTime = DefaultRateLeadTime
+	}
+	if c.UtilizationReportInterval <= 0 {
+		c.UtilizationReportInterval = DefaultUtilizationReportInterval
+	}
 	if c.RateLimiter == nil {
 		c.RateLimiter = workqueue.DefaultTypedControllerRateLimiter[string]()
 	}
@@ -195,23 +303,44 @@ func NewMonitor(cfg MonitorConfig) *Monitor {
 		nncSynced = cfg.NNCInformer.Informer().HasSynced
 	}
 
+	settings := cfg.Settings
+	if settings == nil {
+		settings = NewNetworkSettingsSource(NetworkSettings{
+			DrainingExpiration:              cfg.DrainingExpiration,
+			SustainedLowUtilizationDuration: cfg.SustainedLowUtilizationDuration,
+			LowUtilizationThreshold:         cfg.LowUtilizationThreshold,
+			TargetUtilizationAfterScaleUp:   cfg.TargetUtilizationAfterScaleUp,
+			CooldownPushbackThreshold:       cfg.CooldownPushbackThreshold,
+			CooldownPushbackInterval:        cfg.CooldownPushbackInterval,
+			ScalingPolicy:                   cfg.ScalingPolicy,
+			MinFreeIPs:                      cfg.MinFreeIPs,
+			RateWindow:                      cfg.RateWindow,
+			RateLeadTime:                    cfg.RateLeadTime,
+		}, cfg.NetworkScalingPolicies)
+	}
+
 	return &Monitor{
-		queue:                           queue,
-		nncClient:                       cfg.NNCClient,
-		nncLister:                       nncLister,
-		nncSynced:                       nncSynced,
-		nodeName:                        cfg.NodeName,
-		store:                           cfg.Store,
-		logger:                          cfg.Logger,
-		lowUtilizationTimers:            map[string]time.Time{},
-		GetPendingRequestsCount:         cfg.GetPendingRequestsCount,
-		cooldownPushbackInterval:        cfg.CooldownPushbackInterval,
-		drainingExpiration:              cfg.DrainingExpiration,
-		monitorInterval:                 cfg.MonitorInterval,
-		lowUtilizationThreshold:         cfg.LowUtilizationThreshold,
-		targetUtilizationAfterScaleUp:   cfg.TargetUtilizationAfterScaleUp,
-		cooldownPushbackThreshold:       cfg.CooldownPushbackThreshold,
-		sustainedLowUtilizationDuration: cfg.SustainedLowUtilizationDuration,
+		queue:                   queue,
+		nncClient:               cfg.NNCClient,
+		nncLister:               nncLister,
+		nncSynced:               nncSynced,
+		nodeName:                cfg.NodeName,
+		store:                   cfg.Store,
+		logger:                  cfg.Logger,
+		excessCapacityTimers:    map[networkFamily]time.Time{},
+		settings:                settings,
+		customScalingPolicies:   cfg.ScalingPolicies,
+		builtinScalingPolicies:  map[string]cachedScalingPolicy{},
+		reportedUtilization:     map[networkFamily]bool{},
+		usageReport:             map[networkFamily]store.NetworkIPUsage{},
+		lastScaleUp:             map[string]time.Time{},
+		GetPendingRequestsCount: cfg.GetPendingRequestsCount,
+		capacity:                cfg.CapacityProvider,
+		events:                  cfg.Events,
+		monitorInterval:         cfg.MonitorInterval,
+
+		utilizationReportInterval: cfg.UtilizationReportInterval,
+		now:                       time.Now,
 	}
 }
 
@@ -278,6 +407,11 @@ func (m *Monitor) enqueue() {
 	m.queue.Add(syncKey)
 }
 
+// scalesNetwork reports whether the monitor can add capacity to a network.
+func (m *Monitor) scalesNetwork(network string) bool {
+	return m.capacity == nil || m.capacity.ScalesNetwork(network)
+}
+
 // syncAll evaluates and reconciles IP capacity and release states across all networks
 // on the node. It calculates target pod allocations (scaling up or down), marks excess
 // CIDRs for draining, and transitions fully drained CIDRs to the releasable state.
@@ -287,7 +421,7 @@ func (m *Monitor) syncAll(ctx context.Context) error {
 	m.logger.V(4).Info("Daemon monitor starting synchronization: evaluating IP usage and reconciling capacity for dynamic allocation on node", "node", m.nodeName)
 
 	// Retrieve the latest NodeNetworkConfig (NNC) resource for this node.
-	nnc, err := getNodeNetworkConfig(ctx, m.nncLister, m.nncClient, m.nodeName)
+	nnc, err := m.getNodeNetworkConfig(ctx)
 	if err != nil {
 		m.logger.Error(err, "failed to get NodeNetworkConfig")
 		return err
@@ -302,64 +436,71 @@ func (m *Monitor) syncAll(ctx context.Context) error {
 		return err
 	}
 
+	if !m.lastScaleUpSeeded {
+		if err := m.seedLastScaleUp(ctx); err != nil {
+			m.logger.Error(err, "failed to seed the last scale-ups, retrying on the next sync")
+		} else {
+			m.lastScaleUpSeeded = true
+		}
+	}
+
 	updated := false
 	var allNewReleasables []nncv1.PodCIDR
+	reported := map[networkFamily]bool{}
+	m.usageReport = map[networkFamily]store.NetworkIPUsage{}
 
 	// Reconcile capacity and release state for each network individually.
 	for _, network := range networks {
-		info, err := m.getUtilizationInfo(ctx, network, nncCopy)
-		if err != nil {
-			return err
-		}
-
-		// If the total IP capacity is 0, the initial CIDR has not yet been allocated
-		// or the network is not initialized. Skip dynamic allocation.
-		if info.Usage.Total == 0 {
-			m.logger.V(4).Info("Total IPs is 0, skipping dynamic allocation", "network", network)
+		if !m.scalesNetwork(network) {
 			continue
 		}
+		targetPods := -1
+		currentAllocation := getAllocationForNetwork(nncCopy, network)
+		settings := m.settings.ForNetwork(network)
+		policy := m.scalingPolicyFor(nncCopy, network, settings)
 
-		crdSpecAllocatedPods := 0
-		if info.CurrentAllocation != nil {
-			crdSpecAllocatedPods = int(info.CurrentAllocation.Pods)
-		}
-
-		m.logger.V(4).Info("Evaluating IP utilization and capacity requirements",
-			"network", network,
-			"crdSpecAllocatedPods", crdSpecAllocatedPods,
-			"dbAllocatedIPs", info.Usage.Allocated,
-			"dbCooldownIPs", info.Usage.Cooldown,
-			"dbTotalIPs", info.Usage.Total,
-			"inMemPendingRequests", info.PendingRequests,
-			"calculatedUtilization", fmt.Sprintf("%.2f%%", info.Utilization*100),
-		)
-
-		// Scale-Up: Calculate desired pod capacity based on current utilization and target threshold.
-		desiredPods := m.maybeScaleUp(network, info)
-
-		// Scale-Down (Draining): Mark excess CIDR blocks as draining if utilization is low.
-		if m.maybeDrainExcessive(ctx, network, info) {
-			m.logger.Info("Scale-down triggered: one or more blocks are marked for draining", "network", network)
+		for _, ipFamily := range ipFamilies {
+			familyTarget, newReleasables, err := m.syncNetworkFamily(ctx, network, ipFamily, nncCopy, settings, policy)
+			if err != nil {
+				return err
+			}
+			reported[networkFamily{network: network, ipFamily: ipFamily}] = true
+			allNewReleasables = append(allNewReleasables, newReleasables...)
+			// The IP families share the requested pod count, and each family
+			// target is derived from the demand of the family, so the largest
+			// one covers the demand of all of them.
+			targetPods = max(targetPods, familyTarget)
 		}
 
-		// Releasing: Reconcile CIDRs that are deleting/releasing. This returns the updated
-		// list of releasable CIDRs for this network and the reduction in pod capacity (reducePods)
-		// resulting from the blocks being released.
-		newReleasables, reducePods, err := m.reconcileDeletingBlocks(ctx, network, info.CurrentReleasables, info.CurrentStatus)
-		if err != nil {
-			return err
+		// None of the IP families of this network has a bounded capacity to
+		// track, so leave the requested pod count untouched.
+		if targetPods < 0 {
+			continue
 		}
-		allNewReleasables = append(allNewReleasables, newReleasables...)
-
-		// Adjust the desired pod allocation by subtracting the capacity of released blocks.
-		finalTargetPods := max(0, desiredPods-reducePods)
 
 		// Update the NNC allocations spec for this network if the target pod count changed.
-		if m.updateAllocationPods(nncCopy, network, info.CurrentAllocation, finalTargetPods) {
+		if m.updateAllocationPods(nncCopy, network, currentAllocation, targetPods) {
 			updated = true
 		}
 	}
 
+	for key := range m.reportedUtilization {
+		if !reported[key] {
+			deleteUtilization(key.network, key.ipFamily)
+		}
+	}
+	m.reportedUtilization = reported
+	for network := range m.builtinScalingPolicies {
+		if !slices.Contains(networks, network) {
+			delete(m.builtinScalingPolicies, network)
+		}
+	}
+	for network := range m.lastScaleUp {
+		if !slices.Contains(networks, network) {
+			delete(m.lastScaleUp, network)
+		}
+	}
+
 	// If the global list of releasable CIDRs has changed, update the NNC spec.
 	if !reflect.DeepEqual(nncCopy.Spec.ReleasableCIDRs, allNewReleasables) {
 		nncCopy.Spec.ReleasableCIDRs = allNewReleasables
@@ -373,11 +514,103 @@ func (m *Monitor) syncAll(ctx context.Context) error {
 			return err
 		}
 	}
+	// The utilization report is best effort and retried by the next sync,
+	// it must not hold up the scaling of the networks.
+	if err := m.maybePublishUtilization(ctx); err != nil {
+		m.logger.Error(err, "Failed to publish IP utilization to NodeNetworkConfig status")
+	}
 	m.logger.V(4).Info("Daemon monitor synchronization done", "node", m.nodeName)
 	return nil
 }
 
+// syncNetworkFamily evaluates the CIDR blocks of a single IP family within a network.
+// It returns the target pod count for the family, or -1 if the family has no
+// capacity or its capacity is too large to be tracked in the NNC, together
+// with the releasable CIDRs of the family.
+func (m *Monitor) syncNetworkFamily(ctx context.Context, network string, ipFamily store.IPFamily, nncCopy *nncv1.NodeNetworkConfig, settings NetworkSettings, policy ScalingPolicy) (int, []nncv1.PodCIDR, error) {
+	info, err := m.getUtilizationInfo(ctx, network, ipFamily, nncCopy)
+	if err != nil {
+		return -1, nil, err
+	}
+	recordUtilization(network, info)
+	m.usageReport[networkFamily{network: network, ipFamily: ipFamily}] = info.Usage
+
+	// If the total IP capacity is 0, the initial CIDR has not yet been allocated
+	// or the network does not use this IP family. Skip dynamic allocation.
+	if info.Usage.Total == 0 {
+		m.logger.V(4).Info("Total IPs is 0, skipping dynamic allocation", "network", network, "ipFamily", ipFamily)
+		return -1, nil, nil
+	}
+
+	crdSpecAllocatedPods := 0
+	if info.CurrentAllocation != nil {
+		crdSpecAllocatedPods = int(info.CurrentAllocation.Pods)
+	}
+
+	m.logger.V(4).Info("Evaluating IP utilization and capacity requirements",
+		"network", network,
+		"ipFamily", ipFamily,
+		"crdSpecAllocatedPods", crdSpecAllocatedPods,
+		"dbAllocatedIPs", info.Usage.Allocated,
+		"dbCooldownIPs", info.Usage.Cooldown,
+		"dbTotalIPs", info.Usage.Total,
+		"inMemPendingRequests", info.PendingRequests,
+		"calculatedUtilization", fmt.Sprintf("%.2f%%", info.Utilization*100),
+	)
+
+	bounded := isBoundedCapacity(info.Usage.Total)
+
+	// Scale-Up: Calculate desired pod capacity as decided by the scaling policy.
+	desiredPods := -1
+	if bounded {
+		desiredPods = m.maybeScaleUp(network, info, settings, policy)
+	}
+
+	// Scale-Down (Draining): Mark excess CIDR blocks as draining if capacity is in excess.
+	if m.maybeDrainExcessive(ctx, network, info, settings, policy) {
+		m.logger.Info("Scale-down triggered: one or more blocks are marked for draining", "network", network, "ipFamily", ipFamily)
+	}
+
+	// Releasing: Reconcile CIDRs that are deleting/releasing. This returns the updated
+	// list of releasable CIDRs for this network and the reduction in pod capacity (reducePods)
+	// resulting from the blocks being released.
+	newReleasables, reducePods, err := m.reconcileDeletingBlocks(ctx, network, ipFamily, settings.DrainingExpiration, info.CurrentReleasables, info.CurrentStatus)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	if !bounded {
+		m.logger.V(4).Info("IP capacity is too large to be tracked in NodeNetworkConfig, skipping scale-up", "network", network, "ipFamily", ipFamily)
+		return -1, newReleasables, nil
+	}
+
+	// Adjust the desired pod allocation by subtracting the capacity of released blocks.
+	return max(0, desiredPods-reducePods), newReleasables, nil
+}
+
+// isBoundedCapacity reports whether an IP capacity can be expressed as a
+// requested pod count in the NodeNetworkConfig.
+func isBoundedCapacity(totalIPs int) bool {
+	return totalIPs <= maxBoundedPodCapacity
+}
+
+// getNodeNetworkConfig returns the NodeNetworkConfig of the node, or the one
+// of the CapacityProvider if set.
+func (m *Monitor) getNodeNetworkConfig(ctx context.Context) (*nncv1.NodeNetworkConfig, error) {
+	if m.capacity != nil {
+		return m.capacity.NodeNetworkConfig(ctx)
+	}
+	return getNodeNetworkConfig(ctx, m.nncLister, m.nncClient, m.nodeName)
+}
+
 func (m *Monitor) patchNNC(ctx context.Context, nncCopy *nncv1.NodeNetworkConfig) error {
+	if m.capacity != nil {
+		if err := m.capacity.UpdateSpec(ctx, nncCopy); err != nil {
+			return fmt.Errorf("failed to update capacity requests: %w", err)
+		}
+		m.logger.Info("Successfully updated capacity requests", "allocations", nncCopy.Spec.Allocations, "releasableCIDRs", nncCopy.Spec.ReleasableCIDRs)
+		return nil
+	}
 	// Include resourceVersion in the metadata of the patch payload to enforce
 	// optimistic concurrency control. This causes the patch to fail with a
 	// 409 Conflict if another controller (e.g. GCE) updated the NNC since we
@@ -401,6 +634,9 @@ func (m *Monitor) patchNNC(ctx context.Context, nncCopy *nncv1.NodeNetworkConfig
 		return fmt.Errorf("failed to patch NodeNetworkConfig: %w", err)
 	}
 	m.logger.Info("Successfully patched NodeNetworkConfig", "allocations", nncCopy.Spec.Allocations, "releasableCIDRs", nncCopy.Spec.ReleasableCIDRs)
+	for _, alloc := range nncCopy.Spec.Allocations {
+		m.events.Publish(WatchEvent{Type: WatchEventNNCPatched, Network: alloc.Network, TargetPods: alloc.Pods})
+	}
 	return nil
 }
 
@@ -434,6 +670,7 @@ func (m *Monitor) calculateUtilization(usedIPs, pendingRequests, totalRequestedC
 
 // UtilizationInfo holds details about IP utilization for a network.
 type UtilizationInfo struct {
+	IPFamily           store.IPFamily
 	Utilization        float64
 	Usage              store.NetworkIPUsage
 	PendingRequests    int
@@ -442,13 +679,13 @@ type UtilizationInfo struct {
 	CurrentAllocation  *nncv1.Allocation
 }
 
-func (m *Monitor) getUtilizationInfo(ctx context.Context, network string, nncCopy *nncv1.NodeNetworkConfig) (*UtilizationInfo, error) {
+func (m *Monitor) getUtilizationInfo(ctx context.Context, network string, ipFamily store.IPFamily, nncCopy *nncv1.NodeNetworkConfig) (*UtilizationInfo, error) {
 	// GetIPUsage returns IP usage details including allocated and cooldown counts,
 	// while ignoring CIDR blocks that are marked as Deleting.
 	// Note that this includes CIDR blocks in Draining status in both used and total counts.
 	// This ensures that processing prefetch (dynamic allocation) is not interfered with
 	// (triggered unnecessarily) while we are trying to remove excessive capacity by draining blocks.
-	usage, err := m.store.GetIPUsage(ctx, network, store.IPv4)
+	usage, err := m.store.GetIPUsage(ctx, network, ipFamily)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query IP usage: %w", err)
 	}
@@ -467,18 +704,20 @@ func (m *Monitor) getUtilizationInfo(ctx context.Context, network string, nncCop
 		}
 	}
 
-	usedIPs := usage.Allocated // Only allocated, not in cooldown
+	// Quarantined and reserved IPs cannot be allocated to arbitrary pods either, so they count as used.
+	usedIPs := usage.Allocated + usage.Quarantined + usage.Reserved // Not in cooldown
 
 	pendingRequests := 0
 	if m.GetPendingRequestsCount != nil {
-		pendingRequests = m.GetPendingRequestsCount(network)
+		pendingRequests = m.GetPendingRequestsCount(network, ipFamily)
 	}
 
 	utilization := m.calculateUtilization(usedIPs, pendingRequests, usage.Total)
 
-	m.logger.V(4).Info("Calculated utilization", "network", network, "used", usedIPs, "pending", pendingRequests, "total", usage.Total, "utilization", utilization)
+	m.logger.V(4).Info("Calculated utilization", "network", network, "ipFamily", ipFamily, "used", usedIPs, "pending", pendingRequests, "total", usage.Total, "utilization", utilization)
 
 	return &UtilizationInfo{
+		IPFamily:           ipFamily,
 		Utilization:        utilization,
 		Usage:              usage,
 		PendingRequests:    pendingRequests,
@@ -488,11 +727,7 @@ func (m *Monitor) getUtilizationInfo(ctx context.Context, network string, nncCop
 	}, nil
 }
 
-func (m *Monitor) maybeScaleUp(network string, info *UtilizationInfo) int {
-	usedIPs := info.Usage.Allocated
-	pendingRequests := info.PendingRequests
-	localTotal := info.Usage.Total
-
+func (m *Monitor) maybeScaleUp(network string, info *UtilizationInfo, settings NetworkSettings, policy ScalingPolicy) int {
 	currentPods := 0
 	if info.CurrentAllocation != nil {
 		currentPods = int(info.CurrentAllocation.Pods)
@@ -501,20 +736,19 @@ func (m *Monitor) maybeScaleUp(network string, info *UtilizationInfo) int {
 	// TODO: In a burst of release immediately after dynamic allocation is triggered,
 	// there may never be new CIDRs to wake up the blocking requests from the daemon server.
 	// So we need to callback onCIDR when we check there are enough available IPs.
-	if info.Usage.Cooldown > m.cooldownPushbackThreshold {
-		m.logger.V(4).Info("Too many IPs in cooldown, holding on sending outgoing requests", "network", network, "cooldownCount", info.Usage.Cooldown)
-		m.queue.AddAfter(syncKey, m.cooldownPushbackInterval)
+	if info.Usage.Cooldown > settings.CooldownPushbackThreshold {
+		m.logger.V(4).Info("Too many IPs in cooldown, holding on sending outgoing requests", "network", network, "ipFamily", info.IPFamily, "cooldownCount", info.Usage.Cooldown)
+		m.queue.AddAfter(syncKey, settings.CooldownPushbackInterval)
+		scaleUpPushbacks.WithLabelValues(network, string(info.IPFamily)).Inc()
 		return currentPods
 	}
 
-	// Base line is total local IPs + pending requests
-	baseLine := localTotal + pendingRequests
-	podsWithBuffer := int(math.Ceil(float64(usedIPs+pendingRequests) / m.targetUtilizationAfterScaleUp))
-
-	newPods := max(podsWithBuffer, baseLine)
-	desiredPods := max(newPods, currentPods)
+	desiredPods := max(policy.DesiredPods(network, info), currentPods)
 	if desiredPods > currentPods {
-		m.logger.Info("Scale-up triggered: capacity expansion requested", "network", network, "currentPods", currentPods, "desiredPods", desiredPods)
+		m.logger.Info("Scale-up triggered: capacity expansion requested", "network", network, "ipFamily", info.IPFamily, "currentPods", currentPods, "desiredPods", desiredPods)
+		scaleUpDecisions.WithLabelValues(network, string(info.IPFamily)).Inc()
+		m.lastScaleUp[network] = m.now()
+		m.events.Publish(WatchEvent{Type: WatchEventScaleUpRequested, Network: network, IPFamily: info.IPFamily, TargetPods: int32(desiredPods)})
 	}
 	return desiredPods
 }
@@ -522,66 +756,75 @@ func (m *Monitor) maybeScaleUp(network string, info *UtilizationInfo) int {
 // Note that utilization stats and store CIDR blocks might already be changed by the time we attempt to drain.
 // It is possible to drain a newly added block (less likely to happen due to small window), or drained more
 // or less blocks than strictly necessary, and that is still fine. The system will self-correct in subsequent cycles.
-func (m *Monitor) drainExcessive(ctx context.Context, network string, info *UtilizationInfo) (bool, error) {
-	usedIPs := info.Usage.Allocated // Only allocated, not in cooldown
-	m.logger.V(4).Info(fmt.Sprintf("Utilization falls below %d%% for %s, evaluating CIDR blocks to drain", int(m.lowUtilizationThreshold*100), m.sustainedLowUtilizationDuration), "network", network)
+// With strict set, only blocks whose free IPs are all in excess are drained.
+func (m *Monitor) drainExcessive(ctx context.Context, network string, info *UtilizationInfo, excessIPs float64, strict bool) (bool, error) {
 
-	readyBlocks, err := m.store.GetReadyCIDRBlocksSorted(ctx, network, store.IPv4)
+	readyBlocks, err := m.store.GetReadyCIDRBlocksSorted(ctx, network, info.IPFamily)
 	if err != nil {
 		return false, fmt.Errorf("failed to get ready blocks: %w", err)
 	}
 
 	if len(readyBlocks) <= 1 {
-		m.logger.V(4).Info("Only initial block or no blocks available, skipping draining", "network", network)
+		m.logger.V(4).Info("Only initial block or no blocks available, skipping draining", "network", network, "ipFamily", info.IPFamily)
 		return false, nil
 	}
 
+	// The running total is tracked as float64 because large IPv6 blocks are
+	// stored with a saturated total_ips and would overflow an int when summed.
 	blocksToMark := readyBlocks[:len(readyBlocks)-1]
-	totalPods := info.Usage.Total + info.PendingRequests
-	totalUsedIPs := usedIPs + info.PendingRequests
-	targetUsedIPs := int(m.lowUtilizationThreshold * float64(totalPods))
+	drainedIPs := 0.0
 
 	updated := false
 	for _, block := range blocksToMark {
-		if totalUsedIPs >= targetUsedIPs {
-			m.logger.V(4).Info("Target total used IPs reached, stopping marking blocks", "target", targetUsedIPs, "running", totalUsedIPs)
+		if drainedIPs >= excessIPs {
+			m.logger.V(4).Info("Excess free IPs drained, stopping marking blocks", "excessIPs", excessIPs, "drainedIPs", drainedIPs)
 			break
 		}
-		availableIPs := max(0, block.TotalIPs-block.AllocatedIPs)
+		availableIPs := max(0, float64(block.TotalIPs)-float64(block.AllocatedIPs))
+		if strict && availableIPs > excessIPs-drainedIPs {
+			m.logger.V(4).Info("Block has more free IPs than are in excess, skipping", "network", network, "ipFamily", info.IPFamily, "cidr", block.CIDR, "availableIPs", availableIPs, "excessIPs", excessIPs, "drainedIPs", drainedIPs)
+			continue
+		}
 
 		err = m.store.DrainCIDRBlock(ctx, block.ID)
 		if err != nil {
 			return false, fmt.Errorf("failed to drain block %d: %w", block.ID, err)
 		}
-		m.logger.Info("Marked CIDR block as Draining due to prolonged low utilization", "network", network, "cidr", block.CIDR)
+		m.logger.Info("Marked CIDR block as Draining due to prolonged excess capacity", "network", network, "ipFamily", info.IPFamily, "cidr", block.CIDR)
+		drainDecisions.WithLabelValues(network, string(info.IPFamily)).Inc()
+		m.events.Publish(WatchEvent{Type: WatchEventBlockDraining, Network: network, IPFamily: info.IPFamily, CIDR: block.CIDR})
 
-		totalUsedIPs += availableIPs
+		drainedIPs += availableIPs
 		updated = true
 	}
 	return updated, nil
 }
 
-func (m *Monitor) maybeDrainExcessive(ctx context.Context, network string, info *UtilizationInfo) bool {
-	if info.Utilization >= m.lowUtilizationThreshold {
-		if _, ok := m.lowUtilizationTimers[network]; ok {
-			delete(m.lowUtilizationTimers, network)
-			m.logger.V(4).Info("Utilization went above threshold, reset timer", "network", network, "utilization", info.Utilization)
+func (m *Monitor) maybeDrainExcessive(ctx context.Context, network string, info *UtilizationInfo, settings NetworkSettings, policy ScalingPolicy) bool {
+	timerKey := networkFamily{network: network, ipFamily: info.IPFamily}
+	excessIPs, excessive := policy.ExcessIPs(network, info)
+	if !excessive {
+		if _, ok := m.excessCapacityTimers[timerKey]; ok {
+			delete(m.excessCapacityTimers, timerKey)
+			m.logger.V(4).Info("Capacity no longer in excess, reset timer", "network", network, "ipFamily", info.IPFamily, "utilization", info.Utilization)
 		}
 	} else {
-		if _, ok := m.lowUtilizationTimers[network]; !ok {
-			m.lowUtilizationTimers[network] = time.Now()
-			m.logger.V(4).Info("Low utilization detected, started timer", "network", network, "utilization", info.Utilization)
+		if _, ok := m.excessCapacityTimers[timerKey]; !ok {
+			m.excessCapacityTimers[timerKey] = time.Now()
+			m.logger.V(4).Info("Excess capacity detected, started timer", "network", network, "ipFamily", info.IPFamily, "utilization", info.Utilization, "excessIPs", excessIPs)
 		}
 
-		if time.Since(m.lowUtilizationTimers[network]) > m.sustainedLowUtilizationDuration {
-			drained, err := m.drainExcessive(ctx, network, info)
+		if time.Since(m.excessCapacityTimers[timerKey]) > settings.SustainedLowUtilizationDuration {
+			m.logger.V(4).Info(fmt.Sprintf("Capacity has been in excess for %s, evaluating CIDR blocks to drain", settings.SustainedLowUtilizationDuration), "network", network, "ipFamily", info.IPFamily, "excessIPs", excessIPs)
+			strict, _ := policy.(StrictDrainPolicy)
+			drained, err := m.drainExcessive(ctx, network, info, excessIPs, strict != nil && strict.StrictDrain())
 			if err != nil {
-				m.logger.Error(err, "Failed to handle low utilization", "network", network)
+				m.logger.Error(err, "Failed to handle low utilization", "network", network, "ipFamily", info.IPFamily)
 				return false
 			}
 			if drained {
-				delete(m.lowUtilizationTimers, network)
-				m.logger.V(4).Info("Successfully drained CIDR blocks, resetting low utilization timer", "network", network)
+				delete(m.excessCapacityTimers, timerKey)
+				m.logger.V(4).Info("Successfully drained CIDR blocks, resetting excess capacity timer", "network", network, "ipFamily", info.IPFamily)
 			}
 			return drained
 		}
@@ -592,17 +835,19 @@ func (m *Monitor) maybeDrainExcessive(ctx context.Context, network string, info
 func (m *Monitor) reconcileDeletingBlocks(
 	ctx context.Context,
 	network string,
+	ipFamily store.IPFamily,
+	drainingExpiration time.Duration,
 	currentReleasables []nncv1.PodCIDR,
 	currentStatus []nncv1.PodCIDR,
 ) ([]nncv1.PodCIDR, int, error) {
 	// 1. Update local DB to mark expired draining blocks as deleting
-	_, err := m.store.ExpireDrainingCIDRBlocks(ctx, network, store.IPv4, m.drainingExpiration)
+	_, err := m.store.ExpireDrainingCIDRBlocks(ctx, network, ipFamily, drainingExpiration)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to expire draining CIDRs: %w", err)
 	}
 
-	// 2. Read all deleting CIDR blocks from local DB for this network
-	deletingBlocks, err := m.store.GetDeletingCIDRBlocks(ctx, network, store.IPv4)
+	// 2. Read all deleting CIDR blocks from local DB for this network and IP family
+	deletingBlocks, err := m.store.GetDeletingCIDRBlocks(ctx, network, ipFamily)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query deleting blocks: %w", err)
 	}
@@ -630,14 +875,19 @@ func (m *Monitor) reconcileDeletingBlocks(
 				return nil, 0, fmt.Errorf("failed to delete released CIDR block %d from store: %w", block.ID, err)
 			}
 			m.logger.Info("Deleted CIDR block from local DB as it was released by GCE (reconciliation)", "cidrBlockID", block.ID, "cidr", block.CIDR, "network", network)
+			m.events.Publish(WatchEvent{Type: WatchEventBlockDeleted, Network: network, IPFamily: ipFamily, CIDR: block.CIDR})
 		} else {
 			// Case B: Still in CR status -> keep it in ReleasableCIDRs
 			newReleasables = append(newReleasables, podCIDR)
 
 			if !releasableMap[block.CIDR] {
 				// Case B.1: Not in release section yet -> add to release section
-				m.logger.Info("CIDR block is fully drained; requesting release by adding to releasableCIDRs list", "network", network, "cidr", block.CIDR, "totalIPs", block.TotalIPs)
-				reducePods += block.TotalIPs
+				m.logger.Info("CIDR block is fully drained; requesting release by adding to releasableCIDRs list", "network", network, "ipFamily", ipFamily, "cidr", block.CIDR, "totalIPs", block.TotalIPs)
+				// Blocks too large to be tracked in the requested pod count never
+				// contributed to it, so releasing them does not reduce it either.
+				if isBoundedCapacity(block.TotalIPs) {
+					reducePods += block.TotalIPs
+				}
 				releasableMap[block.CIDR] = true
 			}
 		}
@@ -646,7 +896,7 @@ func (m *Monitor) reconcileDeletingBlocks(
 	// TODO: detect out of sync items that does not exist in status but exist in local DB. Emit metrics or log. This should never happen. If this happens, consider add another CIDR block state "unknown" so it won't be reused.
 
 	if reducePods > 0 {
-		m.logger.Info("Releasing capacity: blocks are fully drained and marked as releasable", "network", network, "capacityReducedBy", reducePods)
+		m.logger.Info("Releasing capacity: blocks are fully drained and marked as releasable", "network", network, "ipFamily", ipFamily, "capacityReducedBy", reducePods)
 	}
 
 	return newReleasables, reducePods, nil
@@ -19,6 +19,7 @@ package daemon
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"sync"
@@ -290,7 +291,7 @@ func TestMonitor_DynamicAllocation_ScaleUp(t *testing.T) {
 				NNCClient:                mockClient,
 				Store:                    storeInstance,
 				NodeName:                 nodeName,
-				GetPendingRequestsCount:  func(_ string) int { return tc.pendingRequests },
+				GetPendingRequestsCount:  func(_ string, _ store.IPFamily) int { return tc.pendingRequests },
 				CooldownPushbackInterval: 1 * time.Millisecond,
 			})
 
@@ -336,6 +337,245 @@ func TestMonitor_DynamicAllocation_ScaleUp(t *testing.T) {
 	}
 }
 
+func TestMonitor_DynamicAllocation_IPv6(t *testing.T) {
+	logger := logr.Discard()
+	network := "test-network"
+	nodeName := "test-node"
+
+	tests := []struct {
+		desc                string
+		blocks              []string
+		ipv4Allocations     int
+		ipv6Allocations     int
+		currentPods         int32
+		expectedPatchCalled bool
+		expectedPatchedPods int32
+	}{
+		{
+			desc:            "High IPv6 utilization triggers scale up",
+			blocks:          []string{"2001:db8::/124"},
+			ipv6Allocations: 14,
+			currentPods:     16,
+			// desired = ceil(14/0.75) = 19, min = 16 + 0 = 16.
+			expectedPatchCalled: true,
+			expectedPatchedPods: 19,
+		},
+		{
+			desc:                "Large IPv6 block does not drive the requested pod count",
+			blocks:              []string{"2001:db8::/64"},
+			ipv6Allocations:     100,
+			currentPods:         16,
+			expectedPatchCalled: false,
+		},
+		{
+			desc:            "Dual-stack requests the larger target of both families",
+			blocks:          []string{"10.0.1.0/28", "2001:db8::/124"},
+			ipv4Allocations: 2,
+			ipv6Allocations: 14,
+			currentPods:     16,
+			// IPv4: used = 2 + 3 (reserved), desired = max(ceil(5/0.75), 16) = 16.
+			// IPv6: desired = max(ceil(14/0.75), 16) = 19.
+			expectedPatchCalled: true,
+			expectedPatchedPods: 19,
+		},
+		{
+			desc:                "Dual-stack with large IPv6 block is driven by IPv4",
+			blocks:              []string{"10.0.1.0/28", "2001:db8::/64"},
+			ipv4Allocations:     10,
+			ipv6Allocations:     10,
+			currentPods:         16,
+			expectedPatchCalled: true,
+			// IPv4: used = 10 + 3 (reserved), desired = ceil(13/0.75) = 18.
+			expectedPatchedPods: 18,
+		},
+		{
+			desc:            "Dual-stack with a larger bounded IPv6 block does not grow the IPv4 capacity",
+			blocks:          []string{"10.0.1.0/28", "2001:db8::/120"},
+			ipv4Allocations: 2,
+			ipv6Allocations: 2,
+			currentPods:     16,
+			// IPv4: desired = ceil(5/0.75) = 7. IPv6: desired = ceil((2+reserved)/0.75) < 16.
+			// The 256 IPs of the IPv6 block are no demand.
+			expectedPatchCalled: false,
+		},
+		{
+			desc:            "Dual-stack with a larger bounded IPv6 block is driven by the demand",
+			blocks:          []string{"10.0.1.0/28", "2001:db8::/120"},
+			ipv4Allocations: 10,
+			ipv6Allocations: 10,
+			currentPods:     16,
+			// IPv4: used = 10 + 3 (reserved), desired = ceil(13/0.75) = 18.
+			expectedPatchCalled: true,
+			expectedPatchedPods: 18,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.Background()
+			dbPath := filepath.Join(t.TempDir(), "metis_monitor_ipv6_test.sqlite")
+			storeInstance, err := store.NewStore(ctx, logger, dbPath)
+			if err != nil {
+				t.Fatalf("Failed to create store: %v", err)
+			}
+			defer storeInstance.Close()
+
+			var podCIDRs []nncv1.PodCIDR
+			for _, cidr := range tc.blocks {
+				if err := storeInstance.AddCIDR(ctx, network, cidr); err != nil {
+					t.Fatalf("Failed to add CIDR: %v", err)
+				}
+				podCIDRs = append(podCIDRs, nncv1.PodCIDR{CIDR: cidr, Network: network})
+			}
+
+			allocate := func(ipFamily store.IPFamily, count int) {
+				for i := 0; i < count; i++ {
+					_, _, err := storeInstance.AllocateIP(ctx, store.AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: fmt.Sprintf("container-%d", i), IPFamily: ipFamily})
+					if err != nil {
+						t.Fatalf("Failed to allocate %s: %v", ipFamily, err)
+					}
+				}
+			}
+			allocate(store.IPv4, tc.ipv4Allocations)
+			allocate(store.IPv6, tc.ipv6Allocations)
+
+			mockNNC := &nncv1.NodeNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+				Spec: nncv1.NodeNetworkConfigSpec{
+					Allocations: []nncv1.Allocation{{Network: network, Pods: tc.currentPods}},
+				},
+				Status: nncv1.NodeNetworkConfigStatus{PodCIDRs: podCIDRs},
+			}
+
+			var patchedData []byte
+			mockInterface := &mockNodeNetworkConfigInterface{
+				getFunc: func(_ context.Context, _ string, _ metav1.GetOptions) (*nncv1.NodeNetworkConfig, error) {
+					return mockNNC, nil
+				},
+				patchFunc: func(_ context.Context, _ string, _ types.PatchType, data []byte, _ metav1.PatchOptions, _ ...string) (*nncv1.NodeNetworkConfig, error) {
+					patchedData = data
+					return mockNNC, nil
+				},
+			}
+
+			m := NewMonitor(MonitorConfig{
+				Logger:    logger,
+				NNCClient: &mockClientset{networkingV1: &mockNetworkingV1{nncInterface: mockInterface}},
+				Store:     storeInstance,
+				NodeName:  nodeName,
+			})
+
+			if err := m.syncAll(ctx); err != nil {
+				t.Fatalf("syncAll failed: %v", err)
+			}
+
+			if (patchedData != nil) != tc.expectedPatchCalled {
+				t.Fatalf("Expected patchCalled %v, got %v", tc.expectedPatchCalled, patchedData != nil)
+			}
+			if !tc.expectedPatchCalled {
+				return
+			}
+
+			var patch struct {
+				Spec nncv1.NodeNetworkConfigSpec `json:"spec"`
+			}
+			if err := json.Unmarshal(patchedData, &patch); err != nil {
+				t.Fatalf("Failed to unmarshal patch data: %v", err)
+			}
+			if len(patch.Spec.Allocations) != 1 || patch.Spec.Allocations[0].Pods != tc.expectedPatchedPods {
+				t.Errorf("Expected allocations of %d pods, got %+v", tc.expectedPatchedPods, patch.Spec.Allocations)
+			}
+		})
+	}
+}
+
+func TestMonitor_IPv6_DrainAndRelease(t *testing.T) {
+	logger := logr.Discard()
+	network := "test-network"
+	nodeName := "test-node"
+	ctx := context.Background()
+
+	dbPath := filepath.Join(t.TempDir(), "metis_monitor_ipv6_drain_test.sqlite")
+	storeInstance, err := store.NewStore(ctx, logger, dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+
+	for _, cidr := range []string{"2001:db8::/64", "2001:db8:0:1::/64"} {
+		if err := storeInstance.AddCIDR(ctx, network, cidr); err != nil {
+			t.Fatalf("Failed to add CIDR: %v", err)
+		}
+	}
+
+	mockNNC := &nncv1.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+		Spec: nncv1.NodeNetworkConfigSpec{
+			Allocations: []nncv1.Allocation{{Network: network, Pods: 32}},
+		},
+		Status: nncv1.NodeNetworkConfigStatus{
+			PodCIDRs: []nncv1.PodCIDR{
+				{CIDR: "2001:db8::/64", Network: network},
+				{CIDR: "2001:db8:0:1::/64", Network: network},
+			},
+		},
+	}
+
+	var patchedData []byte
+	mockInterface := &mockNodeNetworkConfigInterface{
+		getFunc: func(_ context.Context, _ string, _ metav1.GetOptions) (*nncv1.NodeNetworkConfig, error) {
+			return mockNNC, nil
+		},
+		patchFunc: func(_ context.Context, _ string, _ types.PatchType, data []byte, _ metav1.PatchOptions, _ ...string) (*nncv1.NodeNetworkConfig, error) {
+			patchedData = data
+			return mockNNC, nil
+		},
+	}
+
+	m := NewMonitor(MonitorConfig{
+		Logger:             logger,
+		NNCClient:          &mockClientset{networkingV1: &mockNetworkingV1{nncInterface: mockInterface}},
+		Store:              storeInstance,
+		NodeName:           nodeName,
+		DrainingExpiration: 1 * time.Millisecond,
+	})
+	m.excessCapacityTimers[networkFamily{network: network, ipFamily: store.IPv6}] = time.Now().Add(-9 * time.Hour)
+
+	// The first sync drains the newest block, the second one releases it.
+	if err := m.syncAll(ctx); err != nil {
+		t.Fatalf("syncAll failed: %v", err)
+	}
+	readyBlocks, err := storeInstance.GetReadyCIDRBlocksSorted(ctx, network, store.IPv6)
+	if err != nil {
+		t.Fatalf("GetReadyCIDRBlocksSorted failed: %v", err)
+	}
+	if len(readyBlocks) != 1 || readyBlocks[0].CIDR != "2001:db8::/64" {
+		t.Fatalf("Expected only the initial IPv6 block to stay Ready, got %+v", readyBlocks)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := m.syncAll(ctx); err != nil {
+		t.Fatalf("syncAll failed: %v", err)
+	}
+	if patchedData == nil {
+		t.Fatal("Expected NodeNetworkConfig to be patched")
+	}
+
+	var patch struct {
+		Spec nncv1.NodeNetworkConfigSpec `json:"spec"`
+	}
+	if err := json.Unmarshal(patchedData, &patch); err != nil {
+		t.Fatalf("Failed to unmarshal patch data: %v", err)
+	}
+	if len(patch.Spec.ReleasableCIDRs) != 1 || patch.Spec.ReleasableCIDRs[0].CIDR != "2001:db8:0:1::/64" {
+		t.Errorf("Expected releasable CIDR 2001:db8:0:1::/64, got %+v", patch.Spec.ReleasableCIDRs)
+	}
+	// The /64 blocks never contributed to the requested pod count, so it is left untouched.
+	if len(patch.Spec.Allocations) != 1 || patch.Spec.Allocations[0].Pods != 32 {
+		t.Errorf("Expected allocations of 32 pods, got %+v", patch.Spec.Allocations)
+	}
+}
+
 func TestMonitor_DynamicAllocation_drainExcessive(t *testing.T) {
 	logger := logr.Discard()
 	network := "test-network"
@@ -480,10 +720,10 @@ func TestMonitor_DynamicAllocation_drainExcessive(t *testing.T) {
 				NNCClient:               mockClient,
 				Store:                   storeInstance,
 				NodeName:                nodeName,
-				GetPendingRequestsCount: func(_ string) int { return tc.pendingRequests },
+				GetPendingRequestsCount: func(_ string, _ store.IPFamily) int { return tc.pendingRequests },
 			})
 
-			m.lowUtilizationTimers[network] = time.Now().Add(-9 * time.Hour)
+			m.excessCapacityTimers[networkFamily{network: network, ipFamily: store.IPv4}] = time.Now().Add(-9 * time.Hour)
 
 			err = m.syncAll(context.Background())
 			if err != nil {
@@ -590,21 +830,23 @@ func TestMonitor_MaybeDrainExcessive(t *testing.T) {
 			var startTime time.Time
 			if tc.setTimer {
 				startTime = time.Now().Add(tc.timerDuration)
-				m.lowUtilizationTimers[network] = startTime
+				m.excessCapacityTimers[networkFamily{network: network, ipFamily: store.IPv4}] = startTime
 			}
 
 			info := &UtilizationInfo{
+				IPFamily:    store.IPv4,
 				Utilization: tc.utilization,
 				Usage:       tc.usage,
 			}
 
-			drained := m.maybeDrainExcessive(ctx, network, info)
+			settings := m.settings.ForNetwork(network)
+			drained := m.maybeDrainExcessive(ctx, network, info, settings, m.scalingPolicyFor(&nncv1.NodeNetworkConfig{}, network, settings))
 
 			if drained != tc.expectedDrained {
 				t.Errorf("Expected drained %v, got %v", tc.expectedDrained, drained)
 			}
 
-			timer, ok := m.lowUtilizationTimers[network]
+			timer, ok := m.excessCapacityTimers[networkFamily{network: network, ipFamily: store.IPv4}]
 			if ok != tc.expectedTimerExists {
 				t.Errorf("Expected timer exists %v, got %v", tc.expectedTimerExists, ok)
 			}
@@ -854,7 +1096,7 @@ type monitorTestParams struct {
 	name             string
 	dbSetup          func(t *testing.T, storeInstance *store.Store, network string)
 	initialNNC       func(nodeName, rv string, network string) *nncv1.NodeNetworkConfig
-	getPendingCount  func(network string) int
+	getPendingCount  func(network string, ipFamily store.IPFamily) int
 	injectGetError   func(callCount int) error
 	injectPatchError func(callCount int) error
 	onGetCalled      func(callCount int, done func())
@@ -1070,7 +1312,7 @@ func TestMonitorRun(t *testing.T) {
 					},
 				}
 			},
-			getPendingCount: func(_ string) int { return 5 },
+			getPendingCount: func(_ string, _ store.IPFamily) int { return 5 },
 			onPatchCalled: func(_ int, nnc *nncv1.NodeNetworkConfig, done func()) {
 				if len(nnc.Spec.ReleasableCIDRs) == 1 &&
 					nnc.Spec.ReleasableCIDRs[0].CIDR == "10.0.2.0/28" &&
@@ -1177,7 +1419,7 @@ func TestMonitorRun(t *testing.T) {
 					},
 				}
 			},
-			getPendingCount: func(_ string) int { return 5 },
+			getPendingCount: func(_ string, _ store.IPFamily) int { return 5 },
 			injectPatchError: func(callCount int) error {
 				if callCount == 1 {
 					return apierrors.NewConflict(schema.GroupResource{Group: "networking.gke.io", Resource: "nodenetworkconfigs"}, "test-node", fmt.Errorf("conflict"))
@@ -1253,3 +1495,68 @@ func TestMonitorRun(t *testing.T) {
 func ptrInt32(v int32) *int32 {
 	return &v
 }
+
+func TestMonitor_CapacityProvider(t *testing.T) {
+	logger := logr.Discard()
+	network := "default"
+	ctx := context.Background()
+
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_monitor_capacity_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+
+	source, err := NewClusterCIDRSource(ClusterCIDRSourceConfig{ClusterCIDRs: []string{"10.244.1.0/26"}})
+	if err != nil {
+		t.Fatalf("NewClusterCIDRSource failed: %v", err)
+	}
+	clusterCIDR := source.(*clusterCIDRSource)
+	if err := clusterCIDR.enableScaling(ctx, storeInstance); err != nil {
+		t.Fatalf("enableScaling failed: %v", err)
+	}
+	w := NewWatcher(WatcherConfig{Logger: logger, Sources: []CIDRSource{clusterCIDR}, Store: storeInstance})
+	if err := w.syncCIDR(ctx, network); err != nil {
+		t.Fatalf("syncCIDR failed: %v", err)
+	}
+
+	m := NewMonitor(MonitorConfig{
+		Logger:           logger,
+		Store:            storeInstance,
+		NodeName:         "test-node",
+		CapacityProvider: clusterCIDR,
+	})
+
+	// Exhausting the initial block scales the network up from the pool.
+	allocated := 0
+	for ; ; allocated++ {
+		_, _, err := storeInstance.AllocateIP(ctx, store.AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: fmt.Sprintf("container-%d", allocated), IPFamily: store.IPv4})
+		if errors.Is(err, store.ErrNoAvailableIPs) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("AllocateIP failed: %v", err)
+		}
+	}
+	if err := m.syncAll(ctx); err != nil {
+		t.Fatalf("syncAll failed: %v", err)
+	}
+	if err := w.syncCIDR(ctx, network); err != nil {
+		t.Fatalf("syncCIDR failed: %v", err)
+	}
+	blocks, err := storeInstance.GetReadyCIDRBlocksSorted(ctx, network, store.IPv4)
+	if err != nil {
+		t.Fatalf("GetReadyCIDRBlocksSorted failed: %v", err)
+	}
+	if len(blocks) < 2 {
+		t.Fatalf("Expected the network to scale up from the cluster CIDR, got %+v", blocks)
+	}
+	if _, _, err := storeInstance.AllocateIP(ctx, store.AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: fmt.Sprintf("container-%d", allocated), IPFamily: store.IPv4}); err != nil {
+		t.Errorf("AllocateIP after scale-up failed: %v", err)
+	}
+
+	// Networks of other sources are not scaled.
+	if m.scalesNetwork("other") {
+		t.Error("Expected a network outside of the cluster CIDR not to be scaled")
+	}
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// NetworkConfigurationAPIVersion and NetworkConfigurationKind identify the
+	// version of the network configuration file format.
+	NetworkConfigurationAPIVersion = "metis.networking.gke.io/v1alpha1"
+	NetworkConfigurationKind       = "NetworkConfiguration"
+
+	DefaultNetworkConfigReloadInterval = 10 * time.Second
+)
+
+// NetworkConfiguration is the network configuration file of the daemon. It
+// overrides the settings of the daemon flags for all networks and for
+// specific networks, e.g.:
+//
+//	apiVersion: metis.networking.gke.io/v1alpha1
+//	kind: NetworkConfiguration
+//	defaults:
+//	  releaseCooldown: 30s
+//	networks:
+//	  gpu-network:
+//	    scalingPolicy: min-free-ips
+//	    minFreeIPs: 64
+//	    drainingExpiration: 1h
+//
+// The file can be written in YAML or JSON. Unknown fields are rejected.
+type NetworkConfiguration struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Defaults overrides the settings of all networks.
+	Defaults NetworkOverrides `json:"defaults,omitempty"`
+	// Networks overrides the settings of networks by name.
+	Networks map[string]NetworkOverrides `json:"networks,omitempty"`
+}
+
+// NetworkOverrides overrides the settings of a network. Unset fields keep the
+// value they would have otherwise. The monitor interval is not part of it
+// since a single monitor pass reconciles all networks.
+type NetworkOverrides struct {
+	ReleaseCooldown                 *metav1.Duration `json:"releaseCooldown,omitempty"`
+	DrainingExpiration              *metav1.Duration `json:"drainingExpiration,omitempty"`
+	SustainedLowUtilizationDuration *metav1.Duration `json:"sustainedLowUtilizationDuration,omitempty"`
+	LowUtilizationThreshold         *float64         `json:"lowUtilizationThreshold,omitempty"`
+	TargetUtilizationAfterScaleUp   *float64         `json:"targetUtilizationAfterScaleUp,omitempty"`
+	CooldownPushbackThreshold       *int             `json:"cooldownPushbackThreshold,omitempty"`
+	CooldownPushbackInterval        *metav1.Duration `json:"cooldownPushbackInterval,omitempty"`
+	// ScalingPolicy must be one of the built-in scaling policies. The
+	// ScalingPolicyAnnotation of the NodeNetworkConfig still takes precedence.
+	ScalingPolicy *string          `json:"scalingPolicy,omitempty"`
+	MinFreeIPs    *int             `json:"minFreeIPs,omitempty"`
+	RateWindow    *metav1.Duration `json:"rateWindow,omitempty"`
+	RateLeadTime  *metav1.Duration `json:"rateLeadTime,omitempty"`
+}
+
+// ParseNetworkConfiguration parses and validates a network configuration file.
+func ParseNetworkConfiguration(data []byte) (*NetworkConfiguration, error) {
+	cfg := &NetworkConfiguration{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse network configuration: %w", err)
+	}
+	if err := cfg.Validate().ToAggregate(); err != nil {
+		return nil, fmt.Errorf("invalid network configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// LoadNetworkConfiguration reads, parses and validates the network configuration file at path.
+func LoadNetworkConfiguration(path string) (*NetworkConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read network configuration: %w", err)
+	}
+	return ParseNetworkConfiguration(data)
+}
+
+// Validate validates the fields of the NetworkConfiguration.
+func (c *NetworkConfiguration) Validate() field.ErrorList {
+	var allErrs field.ErrorList
+	if c.APIVersion != NetworkConfigurationAPIVersion {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("apiVersion"), c.APIVersion, []string{NetworkConfigurationAPIVersion}))
+	}
+	if c.Kind != NetworkConfigurationKind {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("kind"), c.Kind, []string{NetworkConfigurationKind}))
+	}
+	allErrs = append(allErrs, c.Defaults.validate(field.NewPath("defaults"))...)
+	networksPath := field.NewPath("networks")
+	for network, overrides := range c.Networks {
+		if network == "" {
+			allErrs = append(allErrs, field.Required(networksPath.Key(network), "network name must not be empty"))
+			continue
+		}
+		allErrs = append(allErrs, overrides.validate(networksPath.Key(network))...)
+	}
+	return allErrs
+}
+
+func (o *NetworkOverrides) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	positiveDuration := func(name string, d *metav1.Duration) {
+		if d != nil && d.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(name), d.Duration.String(), "must be greater than 0"))
+		}
+	}
+	ratio := func(name string, v *float64) {
+		if v != nil && (*v <= 0 || *v > 1) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(name), *v, "must be greater than 0 and at most 1"))
+		}
+	}
+	positiveInt := func(name string, v *int) {
+		if v != nil && *v <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(name), *v, "must be greater than 0"))
+		}
+	}
+
+	if o.ReleaseCooldown != nil && o.ReleaseCooldown.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("releaseCooldown"), o.ReleaseCooldown.Duration.String(), "must not be negative"))
+	}
+	positiveDuration("drainingExpiration", o.DrainingExpiration)
+	positiveDuration("sustainedLowUtilizationDuration", o.SustainedLowUtilizationDuration)
+	ratio("lowUtilizationThreshold", o.LowUtilizationThreshold)
+	ratio("targetUtilizationAfterScaleUp", o.TargetUtilizationAfterScaleUp)
+	positiveInt("cooldownPushbackThreshold", o.CooldownPushbackThreshold)
+	positiveDuration("cooldownPushbackInterval", o.CooldownPushbackInterval)
+	if o.ScalingPolicy != nil {
+		if err := ValidateScalingPolicyName(*o.ScalingPolicy); err != nil {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("scalingPolicy"), *o.ScalingPolicy,
+				[]string{ScalingPolicyUtilization, ScalingPolicyMinFreeIPs, ScalingPolicyRateBased}))
+		}
+	}
+	positiveInt("minFreeIPs", o.MinFreeIPs)
+	positiveDuration("rateWindow", o.RateWindow)
+	positiveDuration("rateLeadTime", o.RateLeadTime)
+	return allErrs
+}
+
+// applyTo overrides the fields of settings that are set in o.
+func (o *NetworkOverrides) applyTo(settings *NetworkSettings) {
+	if o.ReleaseCooldown != nil {
+		settings.ReleaseCooldown = o.ReleaseCooldown.Duration
+	}
+	if o.DrainingExpiration != nil {
+		settings.DrainingExpiration = o.DrainingExpiration.Duration
+	}
+	if o.SustainedLowUtilizationDuration != nil {
+		settings.SustainedLowUtilizationDuration = o.SustainedLowUtilizationDuration.Duration
+	}
+	if o.LowUtilizationThreshold != nil {
+		settings.LowUtilizationThreshold = *o.LowUtilizationThreshold
+	}
+	if o.TargetUtilizationAfterScaleUp != nil {
+		settings.TargetUtilizationAfterScaleUp = *o.TargetUtilizationAfterScaleUp
+	}
+	if o.CooldownPushbackThreshold != nil {
+		settings.CooldownPushbackThreshold = *o.CooldownPushbackThreshold
+	}
+	if o.CooldownPushbackInterval != nil {
+		settings.CooldownPushbackInterval = o.CooldownPushbackInterval.Duration
+	}
+	if o.ScalingPolicy != nil {
+		settings.ScalingPolicy = *o.ScalingPolicy
+	}
+	if o.MinFreeIPs != nil {
+		settings.MinFreeIPs = *o.MinFreeIPs
+	}
+	if o.RateWindow != nil {
+		settings.RateWindow = o.RateWindow.Duration
+	}
+	if o.RateLeadTime != nil {
+		settings.RateLeadTime = o.RateLeadTime.Duration
+	}
+}
+
+// NetworkConfigReloader keeps the NetworkConfiguration of a
+// NetworkSettingsSource in sync with a network configuration file.
+//
+// The file is polled rather than watched, since files projected from a
+// ConfigMap are replaced through a symlink swap that inotify watches on the
+// file itself do not observe. A file that fails to load or to validate is
+// logged and ignored, and the previous configuration stays in effect.
+type NetworkConfigReloader struct {
+	path     string
+	interval time.Duration
+	settings *NetworkSettingsSource
+	logger   logr.Logger
+	onChange func()
+
+	// data is the content of the file that was last put into effect or
+	// rejected, so that an unchanged file is not parsed nor logged again.
+	data []byte
+}
+
+// NetworkConfigReloaderConfig holds the configuration for the NetworkConfigReloader.
+type NetworkConfigReloaderConfig struct {
+	Logger   logr.Logger
+	Path     string
+	Interval time.Duration
+	Settings *NetworkSettingsSource
+	// OnChange is called after a changed configuration was put into effect.
+	OnChange func()
+}
+
+// SetDefaults applies default values to the NetworkConfigReloaderConfig fields if they are unset (<= 0).
+func (c *NetworkConfigReloaderConfig) SetDefaults() {
+	if c.Interval <= 0 {
+		c.Interval = DefaultNetworkConfigReloadInterval
+	}
+}
+
+// NewNetworkConfigReloader creates a new NetworkConfigReloader.
+func NewNetworkConfigReloader(cfg NetworkConfigReloaderConfig) *NetworkConfigReloader {
+	cfg.SetDefaults()
+	return &NetworkConfigReloader{
+		path:     cfg.Path,
+		interval: cfg.Interval,
+		settings: cfg.Settings,
+		logger:   cfg.Logger,
+		onChange: cfg.OnChange,
+	}
+}
+
+// Load loads the network configuration file and puts it into effect. Unlike
+// the periodic reloads of Run, it fails if the file cannot be loaded.
+func (r *NetworkConfigReloader) Load() error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read network configuration %s: %w", r.path, err)
+	}
+	if err := r.apply(data); err != nil {
+		return fmt.Errorf("failed to load network configuration %s: %w", r.path, err)
+	}
+	r.logger.Info("Loaded network configuration", "path", r.path, "networks", len(r.settings.Configuration().Networks))
+	return nil
+}
+
+// Run reloads the network configuration file periodically until ctx is done.
+func (r *NetworkConfigReloader) Run(ctx context.Context) {
+	r.logger.Info("Starting network configuration reloader", "path", r.path, "interval", r.interval)
+	defer r.logger.Info("Stopping network configuration reloader")
+
+	wait.UntilWithContext(ctx, func(_ context.Context) {
+		r.reload()
+	}, r.interval)
+}
+
+// reload puts the network configuration file into effect if it changed, and
+// reports whether it did.
+func (r *NetworkConfigReloader) reload() bool {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		r.logger.Error(err, "Failed to read network configuration, keeping the current configuration", "path", r.path)
+		return false
+	}
+	if bytes.Equal(data, r.data) {
+		return false
+	}
+	if err := r.apply(data); err != nil {
+		r.logger.Error(err, "Ignoring invalid network configuration, keeping the current configuration", "path", r.path)
+		networkConfigReloads.WithLabelValues(reloadResultError).Inc()
+		return false
+	}
+	r.logger.Info("Reloaded network configuration", "path", r.path, "networks", len(r.settings.Configuration().Networks))
+	networkConfigReloads.WithLabelValues(reloadResultSuccess).Inc()
+	if r.onChange != nil {
+		r.onChange()
+	}
+	return true
+}
+
+func (r *NetworkConfigReloader) apply(data []byte) error {
+	r.data = data
+	cfg, err := ParseNetworkConfiguration(data)
+	if err != nil {
+		return err
+	}
+	return r.settings.SetConfiguration(cfg)
+}
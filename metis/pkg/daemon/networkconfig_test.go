@@ -0,0 +1,281 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/metis/api/adaptiveipam/v1"
+	"k8s.io/metis/pkg/store"
+)
+
+const testNetworkConfig = `
+apiVersion: metis.networking.gke.io/v1alpha1
+kind: NetworkConfiguration
+defaults:
+  releaseCooldown: 30s
+networks:
+  gpu-network:
+    releaseCooldown: 0s
+    scalingPolicy: min-free-ips
+    minFreeIPs: 64
+    drainingExpiration: 1h
+`
+
+func TestParseNetworkConfiguration(t *testing.T) {
+	tests := []struct {
+		desc    string
+		data    string
+		wantErr []string
+	}{
+		{
+			desc: "valid YAML",
+			data: testNetworkConfig,
+		},
+		{
+			desc: "valid JSON",
+			data: `{"apiVersion": "metis.networking.gke.io/v1alpha1", "kind": "NetworkConfiguration", "networks": {"default": {"lowUtilizationThreshold": 0.2}}}`,
+		},
+		{
+			desc:    "unknown field",
+			data:    "apiVersion: metis.networking.gke.io/v1alpha1\nkind: NetworkConfiguration\nnetworks:\n  default:\n    releaseDelay: 1m\n",
+			wantErr: []string{`unknown field "releaseDelay"`},
+		},
+		{
+			desc:    "unsupported version",
+			data:    "apiVersion: metis.networking.gke.io/v2\nkind: NetworkConfiguration\n",
+			wantErr: []string{"apiVersion: Unsupported value"},
+		},
+		{
+			desc: "invalid values",
+			data: `
+apiVersion: metis.networking.gke.io/v1alpha1
+kind: NetworkConfiguration
+defaults:
+  releaseCooldown: -1s
+networks:
+  gpu-network:
+    lowUtilizationThreshold: 1.5
+    minFreeIPs: 0
+    drainingExpiration: 0s
+    scalingPolicy: fastest
+`,
+			wantErr: []string{
+				"defaults.releaseCooldown: Invalid value",
+				"networks[gpu-network].lowUtilizationThreshold: Invalid value",
+				"networks[gpu-network].minFreeIPs: Invalid value",
+				"networks[gpu-network].drainingExpiration: Invalid value",
+				`networks[gpu-network].scalingPolicy: Unsupported value: "fastest"`,
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ParseNetworkConfiguration([]byte(tc.data))
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			for _, want := range tc.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected error to contain %q, got: %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestNetworkSettingsSource(t *testing.T) {
+	source := NewNetworkSettingsSource(NetworkSettings{ReleaseCooldown: time.Minute}, map[string]string{
+		"gpu-network":  ScalingPolicyRateBased,
+		"blue-network": ScalingPolicyRateBased,
+	})
+
+	if got := source.ForNetwork("default"); got.ReleaseCooldown != time.Minute || got.DrainingExpiration != DefaultDrainingExpiration || got.ScalingPolicy != DefaultScalingPolicy {
+		t.Errorf("Unexpected settings without a configuration: %+v", got)
+	}
+
+	cfg, err := ParseNetworkConfiguration([]byte(testNetworkConfig))
+	if err != nil {
+		t.Fatalf("ParseNetworkConfiguration failed: %v", err)
+	}
+	if err := source.SetConfiguration(cfg); err != nil {
+		t.Fatalf("SetConfiguration failed: %v", err)
+	}
+
+	tests := []struct {
+		network                string
+		wantReleaseCooldown    time.Duration
+		wantDrainingExpiration time.Duration
+		wantScalingPolicy      string
+	}{
+		{network: "default", wantReleaseCooldown: 30 * time.Second, wantDrainingExpiration: DefaultDrainingExpiration, wantScalingPolicy: DefaultScalingPolicy},
+		{network: "blue-network", wantReleaseCooldown: 30 * time.Second, wantDrainingExpiration: DefaultDrainingExpiration, wantScalingPolicy: ScalingPolicyRateBased},
+		{network: "gpu-network", wantReleaseCooldown: 0, wantDrainingExpiration: time.Hour, wantScalingPolicy: ScalingPolicyMinFreeIPs},
+	}
+	for _, tc := range tests {
+		got := source.ForNetwork(tc.network)
+		if got.ReleaseCooldown != tc.wantReleaseCooldown || got.DrainingExpiration != tc.wantDrainingExpiration || got.ScalingPolicy != tc.wantScalingPolicy {
+			t.Errorf("Unexpected settings for network %s: %+v", tc.network, got)
+		}
+	}
+	if got := source.ForNetwork("gpu-network").MinFreeIPs; got != 64 {
+		t.Errorf("Expected 64 min free IPs for gpu-network, got %d", got)
+	}
+
+	// Thresholds that are valid on their own but not together are rejected,
+	// and the previous configuration stays in effect.
+	invalid, err := ParseNetworkConfiguration([]byte(`
+apiVersion: metis.networking.gke.io/v1alpha1
+kind: NetworkConfiguration
+networks:
+  gpu-network:
+    lowUtilizationThreshold: 0.8
+`))
+	if err != nil {
+		t.Fatalf("ParseNetworkConfiguration failed: %v", err)
+	}
+	err = source.SetConfiguration(invalid)
+	if err == nil || !strings.Contains(err.Error(), "networks[gpu-network].lowUtilizationThreshold") {
+		t.Errorf("Expected a lowUtilizationThreshold error, got %v", err)
+	}
+	if source.Configuration() != cfg {
+		t.Errorf("Expected the previous configuration to stay in effect")
+	}
+
+	if err := source.SetConfiguration(nil); err != nil {
+		t.Fatalf("SetConfiguration(nil) failed: %v", err)
+	}
+	if got := source.ForNetwork("gpu-network"); got.ReleaseCooldown != time.Minute || got.ScalingPolicy != ScalingPolicyRateBased {
+		t.Errorf("Unexpected settings after removing the configuration: %+v", got)
+	}
+}
+
+func TestNetworkConfigReloader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "network-config.yaml")
+	writeConfig := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatalf("Failed to write network configuration: %v", err)
+		}
+	}
+
+	source := NewNetworkSettingsSource(NetworkSettings{ReleaseCooldown: time.Minute}, nil)
+	changes := 0
+	reloader := NewNetworkConfigReloader(NetworkConfigReloaderConfig{
+		Logger:   logr.Discard(),
+		Path:     path,
+		Settings: source,
+		OnChange: func() { changes++ },
+	})
+
+	if err := reloader.Load(); err == nil {
+		t.Fatal("Expected Load to fail without a file")
+	}
+
+	writeConfig(testNetworkConfig)
+	if err := reloader.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := source.ForNetwork("gpu-network").DrainingExpiration; got != time.Hour {
+		t.Errorf("Expected draining expiration %s, got %s", time.Hour, got)
+	}
+	if reloader.reload() {
+		t.Error("Expected an unchanged file not to be reloaded")
+	}
+
+	writeConfig(strings.ReplaceAll(testNetworkConfig, "drainingExpiration: 1h", "drainingExpiration: 2h"))
+	if !reloader.reload() {
+		t.Error("Expected the changed file to be reloaded")
+	}
+	if got := source.ForNetwork("gpu-network").DrainingExpiration; got != 2*time.Hour {
+		t.Errorf("Expected draining expiration %s after reload, got %s", 2*time.Hour, got)
+	}
+
+	writeConfig("kind: NetworkConfiguration\nnetworks: [")
+	if reloader.reload() {
+		t.Error("Expected an invalid file not to be reloaded")
+	}
+	if got := source.ForNetwork("gpu-network").DrainingExpiration; got != 2*time.Hour {
+		t.Errorf("Expected the previous configuration to stay in effect, got draining expiration %s", got)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove network configuration: %v", err)
+	}
+	if reloader.reload() {
+		t.Error("Expected a removed file not to be reloaded")
+	}
+	if changes != 1 {
+		t.Errorf("Expected OnChange to be called once, got %d", changes)
+	}
+}
+
+func TestIPAMEngine_PerNetworkReleaseCooldown(t *testing.T) {
+	ctx := context.Background()
+	s, err := store.NewStore(ctx, logr.Discard(), filepath.Join(t.TempDir(), "metis_settings_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	engine := NewIPAMEngine(logr.Discard(), s, time.Minute, 0, nil)
+	source := NewNetworkSettingsSource(NetworkSettings{ReleaseCooldown: time.Minute}, nil)
+	cfg, err := ParseNetworkConfiguration([]byte(testNetworkConfig))
+	if err != nil {
+		t.Fatalf("ParseNetworkConfiguration failed: %v", err)
+	}
+	if err := source.SetConfiguration(cfg); err != nil {
+		t.Fatalf("SetConfiguration failed: %v", err)
+	}
+	engine.SetNetworkSettings(source)
+
+	for network, wantCooldown := range map[string]int{"default": 1, "gpu-network": 0} {
+		_, err := engine.AllocatePodIP(ctx, &adaptiveipam.AllocatePodIPRequest{
+			Network: network,
+			Ipv4Config: &adaptiveipam.IPConfig{
+				ContainerId:    "c1",
+				InterfaceName:  "eth0",
+				InitialPodCidr: "10.0.0.0/28",
+			},
+		})
+		if err != nil {
+			t.Fatalf("AllocatePodIP on network %s failed: %v", network, err)
+		}
+		if _, err := engine.DeallocatePodIP(ctx, &adaptiveipam.DeallocatePodIPRequest{Network: network, ContainerId: "c1", InterfaceName: "eth0"}); err != nil {
+			t.Fatalf("DeallocatePodIP on network %s failed: %v", network, err)
+		}
+		usage, err := s.GetIPUsage(ctx, network, store.IPv4)
+		if err != nil {
+			t.Fatalf("GetIPUsage failed: %v", err)
+		}
+		if usage.Cooldown != wantCooldown {
+			t.Errorf("Expected %d IPs in cooldown on network %s, got %d", wantCooldown, network, usage.Cooldown)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	networkv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/network/v1"
+	nncclientset "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/metis/pkg/store"
+)
+
+const (
+	// recoveredContainerIDPrefix prefixes the pod UID used as the container ID
+	// of the IPs claimed by a state rebuild, since the sandbox ID the CNI
+	// plugin allocated them for is not known to the API server.
+	recoveredContainerIDPrefix = "metis-recovered-"
+	// recoveredInterfaceName is the interface name of the claimed IPs.
+	recoveredInterfaceName = "eth0"
+)
+
+// isRecoveredOwner reports whether the IPs of owner were claimed by a state
+// rebuild. No CNI DEL or GC refers to these owners, their IPs are released by
+// the GarbageCollector once their pod is gone.
+func isRecoveredOwner(owner store.IPOwner) bool {
+	return strings.HasPrefix(owner.ContainerID, recoveredContainerIDPrefix)
+}
+
+// StateRebuilder rebuilds the state of the store after data loss, e.g. when
+// the database was corrupted without a valid snapshot, or restored from a
+// snapshot older than the latest allocations.
+//
+// The CIDR blocks are rebuilt from the ready blocks of the CIDR sources, by
+// default the pod CIDRs of the NodeNetworkConfig status, and the pod CIDRs of
+// the node, and the ownership of
+// their IPs from the pod IPs of the running pods of the node. Each pod IP is
+// claimed on the network of the CIDR block containing it, preferring the
+// default pod network, so that it is not allocated to another pod. The
+// rebuild only adds missing CIDR blocks and claims free IPs, the state already
+// in the store is kept.
+type StateRebuilder struct {
+	logger     logr.Logger
+	kubeClient kubernetes.Interface
+	sources    []CIDRSource
+	store      *store.Store
+	nodeName   string
+}
+
+// StateRebuilderConfig holds the configuration for the StateRebuilder.
+type StateRebuilderConfig struct {
+	Logger     logr.Logger
+	KubeClient kubernetes.Interface
+	NNCClient  nncclientset.Interface
+	// Sources are the CIDR sources of the node. If empty, the blocks come
+	// from the NodeNetworkConfig of NNCClient.
+	Sources  []CIDRSource
+	Store    *store.Store
+	NodeName string
+}
+
+// NewStateRebuilder creates a new StateRebuilder.
+func NewStateRebuilder(cfg StateRebuilderConfig) *StateRebuilder {
+	sources := cfg.Sources
+	if len(sources) == 0 {
+		sources = []CIDRSource{NewNNCCIDRSource(cfg.NNCClient, nil, cfg.NodeName)}
+	}
+	return &StateRebuilder{
+		logger:     cfg.Logger,
+		kubeClient: cfg.KubeClient,
+		sources:    sources,
+		store:      cfg.Store,
+		nodeName:   cfg.NodeName,
+	}
+}
+
+// rebuildBlock is a CIDR block the store is rebuilt with.
+type rebuildBlock struct {
+	network string
+	cidr    string
+	prefix  netip.Prefix
+}
+
+// Rebuild adds the missing CIDR blocks to the store and claims the IPs of the
+// running pods of the node that have no owner in the store. It must run
+// before allocations are served.
+func (r *StateRebuilder) Rebuild(ctx context.Context) error {
+	r.logger.Info("Rebuilding store state from the CIDR sources and the pods of the node", "node", r.nodeName)
+
+	blocks, err := r.blocks(ctx)
+	if err != nil {
+		return err
+	}
+	addedBlocks := 0
+	for _, block := range blocks {
+		added, err := r.addBlock(ctx, block)
+		if err != nil {
+			return err
+		}
+		if added {
+			addedBlocks++
+		}
+	}
+
+	pods, err := r.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", r.nodeName).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods on node %s: %w", r.nodeName, err)
+	}
+	owners, err := r.store.ListIPOwners(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list IP owners: %w", err)
+	}
+	owned := map[string]bool{}
+	for _, owner := range owners {
+		for _, address := range owner.Addresses {
+			owned[address] = true
+		}
+	}
+
+	claimedIPs, ownedIPs, skippedIPs := 0, 0, 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName != r.nodeName || pod.Spec.HostNetwork ||
+			pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, podIP := range pod.Status.PodIPs {
+			if owned[podIP.IP] {
+				ownedIPs++
+				continue
+			}
+			claimed, err := r.claimPodIP(ctx, pod, podIP.IP, blocks)
+			if err != nil {
+				return err
+			}
+			if claimed {
+				claimedIPs++
+			} else {
+				skippedIPs++
+			}
+		}
+	}
+
+	r.logger.Info("Rebuilt store state", "addedBlocks", addedBlocks, "claimedIPs", claimedIPs, "ownedIPs", ownedIPs, "skippedIPs", skippedIPs)
+	return nil
+}
+
+// blocks returns the pod CIDRs of the node followed by the ready blocks of the
+// CIDR sources. The pod CIDRs of the node are the initial
+// CIDR blocks of the default pod network and come first, like when they are
+// added by the first allocation.
+func (r *StateRebuilder) blocks(ctx context.Context) ([]rebuildBlock, error) {
+	node, err := r.kubeClient.CoreV1().Nodes().Get(ctx, r.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node to rebuild the store: %w", err)
+	}
+
+	var blocks []rebuildBlock
+	add := func(network, cidr string) {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			r.logger.Error(err, "Skipping invalid CIDR", "network", network, "cidr", cidr)
+			return
+		}
+		blocks = append(blocks, rebuildBlock{network: network, cidr: cidr, prefix: prefix})
+	}
+	for _, cidr := range node.Spec.PodCIDRs {
+		add(networkv1.DefaultPodNetworkName, cidr)
+	}
+	for _, source := range r.sources {
+		cidrs, err := source.CIDRs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get CIDR blocks from source %s to rebuild the store: %w", source.Name(), err)
+		}
+		for _, cidr := range cidrs {
+			if cidr.Ready {
+				add(cidr.Network, cidr.CIDR)
+			}
+		}
+	}
+	return blocks, nil
+}
+
+// addBlock adds a CIDR block to the store and reports whether it was missing.
+func (r *StateRebuilder) addBlock(ctx context.Context, block rebuildBlock) (bool, error) {
+	cidr := block.cidr
+	if _, exists, err := r.store.GetCIDRBlock(ctx, cidr, block.network); err != nil {
+		return false, fmt.Errorf("failed to check if CIDR exists in store: %w", err)
+	} else if exists {
+		return false, nil
+	}
+	if err := r.store.AddCIDR(ctx, block.network, cidr); err != nil {
+		if errors.Is(err, store.ErrCidrAlreadyExists) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to add CIDR %s of network %s to store: %w", cidr, block.network, err)
+	}
+	r.logger.Info("Rebuilt CIDR block", "network", block.network, "cidr", cidr)
+	return true, nil
+}
+
+// claimPodIP claims a pod IP on the network of the CIDR block containing it,
+// and reports whether it was claimed. IPs outside of the blocks, or already
+// allocated, are skipped.
+func (r *StateRebuilder) claimPodIP(ctx context.Context, pod *corev1.Pod, ip string, blocks []rebuildBlock) (bool, error) {
+	logger := r.logger.WithValues("pod", pod.Namespace+"/"+pod.Name, "ip", ip)
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		logger.Error(err, "Skipping invalid pod IP")
+		return false, nil
+	}
+	addr = addr.Unmap()
+
+	network := ""
+	for _, block := range blocks {
+		if !block.prefix.Contains(addr) {
+			continue
+		}
+		if network == "" || block.network == networkv1.DefaultPodNetworkName {
+			network = block.network
+		}
+	}
+	if network == "" {
+		logger.V(2).Info("Skipping pod IP outside of the CIDR blocks of the node")
+		return false, nil
+	}
+
+	ipFamily := store.IPv4
+	if addr.Is6() {
+		ipFamily = store.IPv6
+	}
+	_, err = r.store.ClaimIP(ctx, store.AllocateIPParams{
+		Network:       network,
+		InterfaceName: recoveredInterfaceName,
+		ContainerID:   recoveredContainerIDPrefix + string(pod.UID),
+		IPFamily:      ipFamily,
+		PodName:       pod.Name,
+		PodNamespace:  pod.Namespace,
+	}, addr.String())
+	if errors.Is(err, store.ErrAddressUnavailable) {
+		logger.Info("Skipping pod IP that cannot be claimed", "network", network, "reason", err.Error())
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim IP %s of pod %s/%s: %w", ip, pod.Namespace, pod.Name, err)
+	}
+	logger.V(2).Info("Claimed pod IP", "network", network)
+	return true, nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	nncfake "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned/fake"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/metis/api/adaptiveipam/v1"
+	"k8s.io/metis/pkg/store"
+)
+
+func TestStateRebuilder(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_rebuild_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+
+	nodeName := "test-node"
+	pod := func(name string, uid types.UID, ips ...string) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, UID: uid},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		for _, ip := range ips {
+			p.Status.PodIPs = append(p.Status.PodIPs, corev1.PodIP{IP: ip})
+		}
+		return p
+	}
+	hostNetwork := pod("host", "uid-host", "10.0.0.9")
+	hostNetwork.Spec.HostNetwork = true
+	succeeded := pod("done", "uid-done", "10.0.0.10")
+	succeeded.Status.Phase = corev1.PodSucceeded
+	otherNode := pod("elsewhere", "uid-elsewhere", "10.0.0.11")
+	otherNode.Spec.NodeName = "other-node"
+
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+			Spec:       corev1.NodeSpec{PodCIDRs: []string{"10.0.0.0/28"}},
+		},
+		pod("web-0", "uid-web-0", "10.0.0.5", "2001:db8::5"),
+		pod("web-1", "uid-web-1", "10.0.1.3"),
+		pod("db-0", "uid-db-0", "10.1.0.4"),
+		pod("unknown", "uid-unknown", "172.16.0.1"),
+		hostNetwork, succeeded, otherNode,
+	)
+	nncClient := nncfake.NewSimpleClientset(&nncv1.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+		Status: nncv1.NodeNetworkConfigStatus{PodCIDRs: []nncv1.PodCIDR{
+			{Network: "default", CIDR: "10.0.1.0/28"},
+			{Network: "default", CIDR: "2001:db8::/64"},
+			{Network: "other", CIDR: "10.1.0.0/28"},
+			{Network: "default", CIDR: "10.0.2.0/28", Condition: &metav1.Condition{Status: metav1.ConditionFalse}},
+		}},
+	})
+	rebuilder := NewStateRebuilder(StateRebuilderConfig{
+		Logger:     logger,
+		KubeClient: kubeClient,
+		NNCClient:  nncClient,
+		Store:      storeInstance,
+		NodeName:   nodeName,
+	})
+	if err := rebuilder.Rebuild(ctx); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+	// A repeated rebuild keeps the state.
+	if err := rebuilder.Rebuild(ctx); err != nil {
+		t.Fatalf("Repeated Rebuild failed: %v", err)
+	}
+
+	blocks, err := storeInstance.GetAllCIDRBlocks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllCIDRBlocks failed: %v", err)
+	}
+	var gotBlocks []string
+	for _, b := range blocks {
+		gotBlocks = append(gotBlocks, b.Network+"/"+b.CIDR)
+	}
+	wantBlocks := []string{"default/10.0.0.0/28", "default/10.0.1.0/28", "default/2001:db8::/64", "other/10.1.0.0/28"}
+	if len(gotBlocks) != len(wantBlocks) {
+		t.Fatalf("Expected CIDR blocks %v, got %v", wantBlocks, gotBlocks)
+	}
+	for i := range wantBlocks {
+		if gotBlocks[i] != wantBlocks[i] {
+			t.Errorf("Expected CIDR blocks %v, got %v", wantBlocks, gotBlocks)
+			break
+		}
+	}
+
+	owners, err := storeInstance.ListIPOwners(ctx)
+	if err != nil {
+		t.Fatalf("ListIPOwners failed: %v", err)
+	}
+	got := map[string]store.IPOwner{}
+	for _, o := range owners {
+		if !isRecoveredOwner(o) || o.InterfaceName != recoveredInterfaceName {
+			t.Errorf("Unexpected owner %+v", o)
+		}
+		got[o.Network+"/"+o.PodName] = o
+	}
+	if len(got) != 3 {
+		t.Errorf("Expected 3 recovered owners, got %+v", owners)
+	}
+	if o := got["default/web-0"]; len(o.Addresses) != 2 || o.Addresses[0] != "10.0.0.5" || o.Addresses[1] != "2001:db8::5" {
+		t.Errorf("Unexpected owner of web-0 %+v", o)
+	}
+	if o := got["default/web-1"]; len(o.Addresses) != 1 || o.Addresses[0] != "10.0.1.3" {
+		t.Errorf("Unexpected owner of web-1 %+v", o)
+	}
+	if o := got["other/db-0"]; len(o.Addresses) != 1 || o.Addresses[0] != "10.1.0.4" || o.ContainerID != recoveredContainerIDPrefix+"uid-db-0" {
+		t.Errorf("Unexpected owner of db-0 %+v", o)
+	}
+
+	// The recovered IPs are not released by a CNI GC, since they are no CNI
+	// attachments, but by the garbage collector once their pod is gone.
+	engine := NewIPAMEngine(logger, storeInstance, 0, 0, nil)
+	for _, o := range owners {
+		if _, err := storeInstance.DB().ExecContext(ctx, "UPDATE ip_addresses SET allocated_at = ? WHERE container_id = ?",
+			time.Now().Add(-time.Hour).UnixMilli(), o.ContainerID); err != nil {
+			t.Fatalf("Failed to age allocation: %v", err)
+		}
+	}
+	resp, err := engine.ReleaseStalePodIPs(ctx, &adaptiveipam.ReleaseStalePodIPsRequest{Network: "default"})
+	if err != nil || len(resp.ReleasedAttachments) != 0 {
+		t.Errorf("Expected a CNI GC to keep the recovered IPs, got %v, %v", resp, err)
+	}
+	if err := kubeClient.CoreV1().Pods("ns").Delete(ctx, "web-1", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete pod: %v", err)
+	}
+	gc := NewGarbageCollector(GarbageCollectorConfig{Logger: logger, KubeClient: kubeClient, Store: storeInstance, NodeName: nodeName})
+	stale, err := gc.Collect(ctx, false)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(stale) != 1 || stale[0].PodName != "web-1" || stale[0].Reason != gcReasonPodNotFound {
+		t.Errorf("Expected the recovered IPs of web-1 to be released, got %+v", stale)
+	}
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+)
+
+const (
+	// ScalingPolicyUtilization scales up to a target utilization and drains
+	// capacity after a sustained period of low utilization.
+	ScalingPolicyUtilization = "utilization"
+	// ScalingPolicyMinFreeIPs keeps a minimum number of free IPs warm.
+	ScalingPolicyMinFreeIPs = "min-free-ips"
+	// ScalingPolicyRateBased pre-warms capacity from the growth rate of the IP demand.
+	ScalingPolicyRateBased = "rate-based"
+
+	// ScalingPolicyAnnotation selects the scaling policies of a node on its
+	// NodeNetworkConfig. The value is a comma-separated list of
+	// <network>=<policy> entries, plus optionally a bare <policy> applying to
+	// all other networks, e.g. "rate-based,gpu-network=min-free-ips".
+	// It takes precedence over the daemon flags and network configuration file.
+	ScalingPolicyAnnotation = "metis.networking.gke.io/scaling-policy"
+
+	DefaultScalingPolicy = ScalingPolicyUtilization
+	DefaultMinFreeIPs    = 16
+	DefaultRateWindow    = 1 * time.Minute
+	DefaultRateLeadTime  = 30 * time.Second
+)
+
+// ScalingPolicy decides how much pod capacity the Monitor requests for an IP
+// family of a network and how much of its capacity is in excess.
+//
+// The Monitor calls the policy once per sync for each IP family of a network
+// with bounded capacity, and takes care of the cooldown pushback, of only
+// ever increasing the requested pod count, and of waiting for the excess to
+// be sustained before draining CIDR blocks. Policies are only called from the
+// Monitor worker and do not need to be safe for concurrent use.
+type ScalingPolicy interface {
+	// DesiredPods returns the pod capacity to request for the IP family of info.
+	DesiredPods(network string, info *UtilizationInfo) int
+	// ExcessIPs reports whether the IP family of info has more free IPs than
+	// the policy wants to keep and, if so, how many free IPs can be drained.
+	// CIDR blocks are drained oldest first until at least that many free IPs
+	// were drained, so draining may overshoot by up to one block, unless the
+	// policy is a StrictDrainPolicy.
+	ExcessIPs(network string, info *UtilizationInfo) (float64, bool)
+}
+
+// StrictDrainPolicy is implemented by scaling policies whose excess IPs are a
+// hard bound, e.g. because they keep a fixed number of free IPs. The Monitor
+// then only drains the CIDR blocks whose free IPs are all in excess, so that
+// draining never goes below what the policy keeps and triggers a scale-up.
+type StrictDrainPolicy interface {
+	ScalingPolicy
+	// StrictDrain reports whether draining must not overshoot the excess IPs.
+	StrictDrain() bool
+}
+
+// ValidateScalingPolicyName returns an error if name is not a built-in scaling policy.
+func ValidateScalingPolicyName(name string) error {
+	switch name {
+	case ScalingPolicyUtilization, ScalingPolicyMinFreeIPs, ScalingPolicyRateBased:
+		return nil
+	}
+	return fmt.Errorf("unknown scaling policy %q, must be one of %q, %q or %q", name, ScalingPolicyUtilization, ScalingPolicyMinFreeIPs, ScalingPolicyRateBased)
+}
+
+// parseScalingPolicyAnnotation parses the value of ScalingPolicyAnnotation
+// into the policy of all networks and the policies of specific networks.
+func parseScalingPolicyAnnotation(value string) (string, map[string]string, error) {
+	var defaultPolicy string
+	networkPolicies := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		network, policy, found := strings.Cut(entry, "=")
+		if !found {
+			if defaultPolicy != "" {
+				return "", nil, fmt.Errorf("more than one policy for all networks in %q", value)
+			}
+			defaultPolicy = network
+			continue
+		}
+		network, policy = strings.TrimSpace(network), strings.TrimSpace(policy)
+		if network == "" || policy == "" {
+			return "", nil, fmt.Errorf("invalid entry %q, must be <network>=<policy>", entry)
+		}
+		networkPolicies[network] = policy
+	}
+	return defaultPolicy, networkPolicies, nil
+}
+
+// scalingPolicyFor returns the scaling policy of a network. The policy named in
+// the NodeNetworkConfig annotation wins over the policy of the network settings.
+func (m *Monitor) scalingPolicyFor(nnc *nncv1.NodeNetworkConfig, network string, settings NetworkSettings) ScalingPolicy {
+	name := settings.ScalingPolicy
+	if value, ok := nnc.Annotations[ScalingPolicyAnnotation]; ok {
+		defaultPolicy, networkPolicies, err := parseScalingPolicyAnnotation(value)
+		switch {
+		case err != nil:
+			m.logger.Error(err, "Ignoring invalid scaling policy annotation", "annotation", ScalingPolicyAnnotation, "value", value)
+		case networkPolicies[network] != "":
+			name = networkPolicies[network]
+		case defaultPolicy != "":
+			name = defaultPolicy
+		}
+	}
+
+	if policy, ok := m.customScalingPolicies[name]; ok {
+		return policy
+	}
+	if err := ValidateScalingPolicyName(name); err != nil {
+		m.logger.Error(nil, "Unknown scaling policy, falling back to the default policy", "network", network, "policy", name, "defaultPolicy", DefaultScalingPolicy)
+		name = DefaultScalingPolicy
+	}
+
+	settings.ScalingPolicy = name
+	if cached, ok := m.builtinScalingPolicies[network]; ok && cached.settings == settings {
+		return cached.policy
+	}
+	policy := newBuiltinScalingPolicy(settings)
+	m.builtinScalingPolicies[network] = cachedScalingPolicy{settings: settings, policy: policy}
+	return policy
+}
+
+// cachedScalingPolicy is a built-in scaling policy with the settings it was created from.
+type cachedScalingPolicy struct {
+	settings NetworkSettings
+	policy   ScalingPolicy
+}
+
+// newBuiltinScalingPolicy creates the built-in scaling policy named in settings.
+func newBuiltinScalingPolicy(settings NetworkSettings) ScalingPolicy {
+	utilization := utilizationPolicy{
+		lowUtilizationThreshold:       settings.LowUtilizationThreshold,
+		targetUtilizationAfterScaleUp: settings.TargetUtilizationAfterScaleUp,
+	}
+	switch settings.ScalingPolicy {
+	case ScalingPolicyMinFreeIPs:
+		return &minFreeIPsPolicy{minFreeIPs: settings.MinFreeIPs}
+	case ScalingPolicyRateBased:
+		return &rateBasedPolicy{
+			utilizationPolicy: utilization,
+			window:            settings.RateWindow,
+			leadTime:          settings.RateLeadTime,
+			samples:           map[networkFamily][]rateSample{},
+			now:               time.Now,
+		}
+	}
+	return &utilization
+}
+
+// pendingBaseline returns the pod capacity the IP family of info needs for its
+// pending requests, which wait for IPs it does not have, or 0 if there are none.
+//
+// The pod count requested for a network is shared by its IP families, so the
+// desired pods are otherwise derived from the demand of the IP family alone and
+// not from its capacity: a larger block of one family, e.g. an IPv6 /120 next to
+// an IPv4 /28, would otherwise have the capacity of the other family grown to
+// match it without any demand.
+func pendingBaseline(info *UtilizationInfo) int {
+	if info.PendingRequests == 0 {
+		return 0
+	}
+	return info.Usage.Total + info.PendingRequests
+}
+
+// utilizationPolicy requests enough capacity to bring the utilization down to
+// targetUtilization, and considers capacity in excess while the utilization is
+// below lowUtilizationThreshold.
+type utilizationPolicy struct {
+	lowUtilizationThreshold       float64
+	targetUtilizationAfterScaleUp float64
+}
+
+func (p *utilizationPolicy) DesiredPods(_ string, info *UtilizationInfo) int {
+	demand := info.Usage.Allocated + info.PendingRequests
+	podsWithBuffer := int(math.Ceil(float64(demand) / p.targetUtilizationAfterScaleUp))
+	return max(podsWithBuffer, pendingBaseline(info))
+}
+
+func (p *utilizationPolicy) ExcessIPs(_ string, info *UtilizationInfo) (float64, bool) {
+	if info.Utilization >= p.lowUtilizationThreshold {
+		return 0, false
+	}
+	// The totals are float64 because large IPv6 blocks are stored with a
+	// saturated total_ips and would overflow an int when summed.
+	targetUsedIPs := p.lowUtilizationThreshold * (float64(info.Usage.Total) + float64(info.PendingRequests))
+	return targetUsedIPs - float64(info.Usage.Allocated+info.PendingRequests), true
+}
+
+// minFreeIPsPolicy keeps at least minFreeIPs free IPs on top of the allocated
+// IPs and pending requests, and considers any free IPs beyond that in excess.
+// It is a StrictDrainPolicy, so draining never leaves fewer than minFreeIPs
+// Ready IPs.
+type minFreeIPsPolicy struct {
+	minFreeIPs int
+}
+
+func (p *minFreeIPsPolicy) DesiredPods(_ string, info *UtilizationInfo) int {
+	demand := info.Usage.Allocated + info.PendingRequests
+	return max(demand+p.minFreeIPs, pendingBaseline(info))
+}
+
+func (p *minFreeIPsPolicy) ExcessIPs(_ string, info *UtilizationInfo) (float64, bool) {
+	freeIPs := float64(info.Usage.Total) - float64(info.Usage.Allocated+info.PendingRequests)
+	excess := freeIPs - float64(p.minFreeIPs)
+	return excess, excess > 0
+}
+
+func (p *minFreeIPsPolicy) StrictDrain() bool {
+	return true
+}
+
+// rateSample is the IP demand of an IP family of a network at a point in time.
+type rateSample struct {
+	at     time.Time
+	demand int
+}
+
+// rateBasedPolicy extends the utilization policy by pre-warming the IPs the
+// demand is expected to grow by within leadTime, based on its growth rate
+// over the last window. Capacity is never considered in excess while the
+// demand is growing.
+type rateBasedPolicy struct {
+	utilizationPolicy
+	window   time.Duration
+	leadTime time.Duration
+	samples  map[networkFamily][]rateSample
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+// growthRate records the current demand of the IP family of info and returns
+// its growth rate in IPs per second over the window, or 0 if it is not growing.
+func (p *rateBasedPolicy) growthRate(network string, info *UtilizationInfo) float64 {
+	key := networkFamily{network: network, ipFamily: info.IPFamily}
+	now := p.now()
+	demand := info.Usage.Allocated + info.PendingRequests
+
+	samples := p.samples[key]
+	for len(samples) > 0 && now.Sub(samples[0].at) > p.window {
+		samples = samples[1:]
+	}
+	samples = append(samples, rateSample{at: now, demand: demand})
+	p.samples[key] = samples
+
+	oldest := samples[0]
+	elapsed := now.Sub(oldest.at).Seconds()
+	if elapsed < 1 || demand <= oldest.demand {
+		return 0
+	}
+	return float64(demand-oldest.demand) / elapsed
+}
+
+func (p *rateBasedPolicy) DesiredPods(network string, info *UtilizationInfo) int {
+	desired := p.utilizationPolicy.DesiredPods(network, info)
+	rate := p.growthRate(network, info)
+	if rate == 0 {
+		return desired
+	}
+	expectedDemand := float64(info.Usage.Allocated+info.PendingRequests) + rate*p.leadTime.Seconds()
+	return max(desired, int(math.Ceil(expectedDemand/p.targetUtilizationAfterScaleUp)))
+}
+
+func (p *rateBasedPolicy) ExcessIPs(network string, info *UtilizationInfo) (float64, bool) {
+	key := networkFamily{network: network, ipFamily: info.IPFamily}
+	if samples := p.samples[key]; len(samples) > 0 && samples[len(samples)-1].demand > samples[0].demand {
+		return 0, false
+	}
+	return p.utilizationPolicy.ExcessIPs(network, info)
+}
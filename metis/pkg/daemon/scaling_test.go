@@ -0,0 +1,406 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metis/pkg/store"
+)
+
+func TestParseScalingPolicyAnnotation(t *testing.T) {
+	tests := []struct {
+		value               string
+		wantDefault         string
+		wantNetworkPolicies map[string]string
+		wantErr             bool
+	}{
+		{value: "rate-based", wantDefault: "rate-based", wantNetworkPolicies: map[string]string{}},
+		{
+			value:               "rate-based, gpu-network=min-free-ips,default=utilization",
+			wantDefault:         "rate-based",
+			wantNetworkPolicies: map[string]string{"gpu-network": "min-free-ips", "default": "utilization"},
+		},
+		{value: "", wantNetworkPolicies: map[string]string{}},
+		{value: "rate-based,utilization", wantErr: true},
+		{value: "gpu-network=", wantErr: true},
+		{value: "=min-free-ips", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			gotDefault, gotNetworkPolicies, err := parseScalingPolicyAnnotation(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got default %q and network policies %v", gotDefault, gotNetworkPolicies)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if gotDefault != tc.wantDefault || !reflect.DeepEqual(gotNetworkPolicies, tc.wantNetworkPolicies) {
+				t.Errorf("Expected default %q and network policies %v, got %q and %v", tc.wantDefault, tc.wantNetworkPolicies, gotDefault, gotNetworkPolicies)
+			}
+		})
+	}
+}
+
+func TestMonitor_ScalingPolicyFor(t *testing.T) {
+	custom := &minFreeIPsPolicy{minFreeIPs: 1}
+	m := NewMonitor(MonitorConfig{
+		Logger:                 logr.Discard(),
+		ScalingPolicy:          ScalingPolicyRateBased,
+		NetworkScalingPolicies: map[string]string{"net-a": ScalingPolicyMinFreeIPs, "net-b": "custom"},
+		ScalingPolicies:        map[string]ScalingPolicy{"custom": custom},
+	})
+	withAnnotation := func(value string) *nncv1.NodeNetworkConfig {
+		return &nncv1.NodeNetworkConfig{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{ScalingPolicyAnnotation: value},
+		}}
+	}
+	policyName := func(policy ScalingPolicy) string {
+		if policy == custom {
+			return "custom"
+		}
+		switch policy.(type) {
+		case *utilizationPolicy:
+			return ScalingPolicyUtilization
+		case *minFreeIPsPolicy:
+			return ScalingPolicyMinFreeIPs
+		case *rateBasedPolicy:
+			return ScalingPolicyRateBased
+		}
+		return fmt.Sprintf("%T", policy)
+	}
+
+	tests := []struct {
+		desc    string
+		nnc     *nncv1.NodeNetworkConfig
+		network string
+		want    string
+	}{
+		{desc: "default flag", nnc: &nncv1.NodeNetworkConfig{}, network: "other", want: ScalingPolicyRateBased},
+		{desc: "network flag", nnc: &nncv1.NodeNetworkConfig{}, network: "net-a", want: ScalingPolicyMinFreeIPs},
+		{desc: "custom policy", nnc: &nncv1.NodeNetworkConfig{}, network: "net-b", want: "custom"},
+		{desc: "annotation for all networks", nnc: withAnnotation("utilization"), network: "net-a", want: ScalingPolicyUtilization},
+		{desc: "annotation for another network", nnc: withAnnotation("net-b=utilization"), network: "net-a", want: ScalingPolicyMinFreeIPs},
+		{desc: "annotation for the network", nnc: withAnnotation("min-free-ips,net-a=utilization"), network: "net-a", want: ScalingPolicyUtilization},
+		{desc: "invalid annotation", nnc: withAnnotation("utilization,min-free-ips"), network: "net-a", want: ScalingPolicyMinFreeIPs},
+		{desc: "unknown policy", nnc: withAnnotation("net-a=unknown"), network: "net-a", want: DefaultScalingPolicy},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := m.scalingPolicyFor(tc.nnc, tc.network, m.settings.ForNetwork(tc.network))
+			if name := policyName(got); name != tc.want {
+				t.Errorf("Expected policy %q, got %q", tc.want, name)
+			}
+		})
+	}
+}
+
+func TestMonitor_ScalingPolicyForKeepsStateUntilSettingsChange(t *testing.T) {
+	m := NewMonitor(MonitorConfig{Logger: logr.Discard(), ScalingPolicy: ScalingPolicyRateBased})
+	nnc := &nncv1.NodeNetworkConfig{}
+	settings := m.settings.ForNetwork("net-a")
+
+	first := m.scalingPolicyFor(nnc, "net-a", settings)
+	if got := m.scalingPolicyFor(nnc, "net-a", settings); got != first {
+		t.Errorf("Expected the policy of unchanged settings to be reused")
+	}
+	if got := m.scalingPolicyFor(nnc, "net-b", m.settings.ForNetwork("net-b")); got == first {
+		t.Errorf("Expected each network to have its own policy")
+	}
+
+	settings.RateLeadTime = time.Minute
+	got, ok := m.scalingPolicyFor(nnc, "net-a", settings).(*rateBasedPolicy)
+	if !ok || got == first {
+		t.Fatalf("Expected a new rate-based policy after the settings changed, got %#v", got)
+	}
+	if got.leadTime != time.Minute {
+		t.Errorf("Expected the new policy to have lead time %s, got %s", time.Minute, got.leadTime)
+	}
+}
+
+func TestScalingPolicies(t *testing.T) {
+	utilization := utilizationPolicy{
+		lowUtilizationThreshold:       DefaultLowUtilizationThreshold,
+		targetUtilizationAfterScaleUp: DefaultTargetUtilizationAfterScaleUp,
+	}
+	info := func(allocated, pending, total int) *UtilizationInfo {
+		return &UtilizationInfo{
+			IPFamily:        store.IPv4,
+			Usage:           store.NetworkIPUsage{Allocated: allocated, Total: total},
+			PendingRequests: pending,
+			Utilization:     float64(allocated+pending) / float64(total),
+		}
+	}
+
+	tests := []struct {
+		desc          string
+		policy        ScalingPolicy
+		info          *UtilizationInfo
+		wantPods      int
+		wantExcess    float64
+		wantExcessive bool
+	}{
+		{
+			desc:     "utilization scales up to the target utilization",
+			policy:   &utilization,
+			info:     info(45, 10, 48),
+			wantPods: 74, // ceil(55 / 0.75)
+		},
+		{
+			desc:          "utilization reports excess below the low threshold",
+			policy:        &utilization,
+			info:          info(10, 0, 64),
+			wantPods:      14, // ceil(10 / 0.75), unused capacity is no demand
+			wantExcess:    22, // 0.5 * 64 - 10
+			wantExcessive: true,
+		},
+		{
+			desc:     "min-free-ips keeps free IPs on top of the demand",
+			policy:   &minFreeIPsPolicy{minFreeIPs: 16},
+			info:     info(40, 4, 48),
+			wantPods: 60,
+		},
+		{
+			desc:          "min-free-ips reports free IPs beyond the minimum as excess",
+			policy:        &minFreeIPsPolicy{minFreeIPs: 16},
+			info:          info(10, 0, 64),
+			wantPods:      26,
+			wantExcess:    38,
+			wantExcessive: true,
+		},
+		{
+			desc:     "pending requests need more than the capacity",
+			policy:   &utilization,
+			info:     info(40, 2, 64),
+			wantPods: 66, // the capacity plus the pending requests
+		},
+		{
+			desc:     "min-free-ips reports no excess at the minimum",
+			policy:   &minFreeIPsPolicy{minFreeIPs: 16},
+			info:     info(48, 0, 64),
+			wantPods: 64,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := tc.policy.DesiredPods("net", tc.info); got != tc.wantPods {
+				t.Errorf("Expected desired pods %d, got %d", tc.wantPods, got)
+			}
+			excess, excessive := tc.policy.ExcessIPs("net", tc.info)
+			if excessive != tc.wantExcessive || (excessive && excess != tc.wantExcess) {
+				t.Errorf("Expected excess IPs (%v, %t), got (%v, %t)", tc.wantExcess, tc.wantExcessive, excess, excessive)
+			}
+		})
+	}
+}
+
+func TestRateBasedPolicy(t *testing.T) {
+	now := time.Now()
+	p := &rateBasedPolicy{
+		utilizationPolicy: utilizationPolicy{
+			lowUtilizationThreshold:       DefaultLowUtilizationThreshold,
+			targetUtilizationAfterScaleUp: DefaultTargetUtilizationAfterScaleUp,
+		},
+		window:   time.Minute,
+		leadTime: 30 * time.Second,
+		samples:  map[networkFamily][]rateSample{},
+		now:      func() time.Time { return now },
+	}
+	info := func(allocated int) *UtilizationInfo {
+		return &UtilizationInfo{
+			IPFamily:    store.IPv4,
+			Usage:       store.NetworkIPUsage{Allocated: allocated, Total: 128},
+			Utilization: float64(allocated) / 128,
+		}
+	}
+
+	// Without a demand history it behaves like the utilization policy.
+	if got := p.DesiredPods("net", info(10)); got != 14 {
+		t.Errorf("Expected desired pods 14 without history, got %d", got)
+	}
+	if _, excessive := p.ExcessIPs("net", info(10)); !excessive {
+		t.Error("Expected excess capacity at a stable low demand")
+	}
+
+	// The demand grows by 20 IPs in 10s, i.e. 2 IPs/s or 60 IPs within the lead
+	// time: ceil((30 + 60) / 0.75) = 120.
+	now = now.Add(10 * time.Second)
+	if got := p.DesiredPods("net", info(30)); got != 120 {
+		t.Errorf("Expected desired pods 120, got %d", got)
+	}
+	now = now.Add(10 * time.Second)
+	// 60 IPs in 20s is 3 IPs/s: ceil((70 + 90) / 0.75) = 214.
+	if got := p.DesiredPods("net", info(70)); got != 214 {
+		t.Errorf("Expected desired pods 214 while the demand grows, got %d", got)
+	}
+	if _, excessive := p.ExcessIPs("net", info(70)); excessive {
+		t.Error("Expected no excess capacity while the demand grows")
+	}
+
+	// Once the growth is out of the window the pre-warming stops: ceil(70 / 0.75) = 94.
+	now = now.Add(2 * time.Minute)
+	if got := p.DesiredPods("net", info(70)); got != 94 {
+		t.Errorf("Expected desired pods 94 after the growth stopped, got %d", got)
+	}
+}
+
+func TestMonitor_SyncAllWithScalingPolicyAnnotation(t *testing.T) {
+	logger := logr.Discard()
+	network := "test-network"
+	nodeName := "test-node"
+	ctx := context.Background()
+
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_scaling_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+	if err := storeInstance.AddCIDR(ctx, network, "10.0.1.0/28"); err != nil {
+		t.Fatalf("Failed to add CIDR: %v", err)
+	}
+
+	mockNNC := &nncv1.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        nodeName,
+			Annotations: map[string]string{ScalingPolicyAnnotation: network + "=" + ScalingPolicyMinFreeIPs},
+		},
+		Spec: nncv1.NodeNetworkConfigSpec{
+			Allocations: []nncv1.Allocation{{Network: network, Pods: 16}},
+		},
+		Status: nncv1.NodeNetworkConfigStatus{
+			PodCIDRs: []nncv1.PodCIDR{{CIDR: "10.0.1.0/28", Network: network}},
+		},
+	}
+	var patchedData []byte
+	mockInterface := &mockNodeNetworkConfigInterface{
+		getFunc: func(_ context.Context, _ string, _ metav1.GetOptions) (*nncv1.NodeNetworkConfig, error) {
+			return mockNNC, nil
+		},
+		patchFunc: func(_ context.Context, _ string, _ types.PatchType, data []byte, _ metav1.PatchOptions, _ ...string) (*nncv1.NodeNetworkConfig, error) {
+			patchedData = data
+			return mockNNC, nil
+		},
+	}
+
+	m := NewMonitor(MonitorConfig{
+		Logger:     logger,
+		NNCClient:  &mockClientset{networkingV1: &mockNetworkingV1{nncInterface: mockInterface}},
+		Store:      storeInstance,
+		NodeName:   nodeName,
+		MinFreeIPs: 8,
+	})
+	if err := m.syncAll(ctx); err != nil {
+		t.Fatalf("syncAll failed: %v", err)
+	}
+
+	// The utilization policy would not scale up at 3/16 used IPs, while the
+	// min-free-ips policy requests 3 reserved + 8 free IPs = 11, below the
+	// current 16 pods, i.e. no change.
+	if patchedData != nil {
+		t.Fatalf("Expected no patch, got %s", patchedData)
+	}
+
+	for i := range 10 {
+		if _, _, err := storeInstance.AllocateIP(ctx, store.AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: fmt.Sprintf("c%d", i), IPFamily: store.IPv4}); err != nil {
+			t.Fatalf("AllocateIP failed: %v", err)
+		}
+	}
+	if err := m.syncAll(ctx); err != nil {
+		t.Fatalf("syncAll failed: %v", err)
+	}
+	var patch struct {
+		Spec nncv1.NodeNetworkConfigSpec `json:"spec"`
+	}
+	if err := json.Unmarshal(patchedData, &patch); err != nil {
+		t.Fatalf("Failed to unmarshal patch data %q: %v", patchedData, err)
+	}
+	// 13 used IPs + 8 free IPs.
+	if len(patch.Spec.Allocations) != 1 || patch.Spec.Allocations[0].Pods != 21 {
+		t.Errorf("Expected allocations of 21 pods, got %+v", patch.Spec.Allocations)
+	}
+}
+
+func TestMonitor_MinFreeIPsDrainDoesNotOvershoot(t *testing.T) {
+	logger := logr.Discard()
+	network := "test-network"
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		desc       string
+		minFreeIPs int
+		wantReady  int
+	}{
+		// Fewer free IPs than the /28 block holds are in excess, draining it
+		// would go below the free IPs kept.
+		{desc: "excess smaller than a block", minFreeIPs: 16, wantReady: 2},
+		{desc: "excess covers a block", minFreeIPs: 4, wantReady: 1},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_scaling_test.sqlite"))
+			if err != nil {
+				t.Fatalf("Failed to create store: %v", err)
+			}
+			defer storeInstance.Close()
+			for _, cidr := range []string{"10.0.1.0/28", "10.0.2.0/28"} {
+				if err := storeInstance.AddCIDR(ctx, network, cidr); err != nil {
+					t.Fatalf("Failed to add CIDR: %v", err)
+				}
+			}
+
+			m := NewMonitor(MonitorConfig{
+				Logger:   logger,
+				Store:    storeInstance,
+				NodeName: "test-node",
+			})
+			info, err := m.getUtilizationInfo(ctx, network, store.IPv4, &nncv1.NodeNetworkConfig{})
+			if err != nil {
+				t.Fatalf("getUtilizationInfo failed: %v", err)
+			}
+			policy := &minFreeIPsPolicy{minFreeIPs: tc.minFreeIPs}
+			m.excessCapacityTimers[networkFamily{network: network, ipFamily: store.IPv4}] = time.Now().Add(-time.Hour)
+			m.maybeDrainExcessive(ctx, network, info, NetworkSettings{SustainedLowUtilizationDuration: time.Minute}, policy)
+
+			readyBlocks, err := storeInstance.GetReadyCIDRBlocksSorted(ctx, network, store.IPv4)
+			if err != nil {
+				t.Fatalf("GetReadyCIDRBlocksSorted failed: %v", err)
+			}
+			if len(readyBlocks) != tc.wantReady {
+				t.Fatalf("Expected %d ready blocks, got %+v", tc.wantReady, readyBlocks)
+			}
+			free := 0
+			for _, block := range readyBlocks {
+				free += block.TotalIPs - block.AllocatedIPs
+			}
+			if free < tc.minFreeIPs {
+				t.Errorf("Expected at least %d free IPs in ready blocks, got %d", tc.minFreeIPs, free)
+			}
+		})
+	}
+}
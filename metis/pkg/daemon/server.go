@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
@@ -38,6 +39,9 @@ type adaptiveIpamServer struct {
 	adminv1.UnimplementedAdminServer
 	engine     *IPAMEngine
 	store      *store.Store
+	gc         *GarbageCollector
+	events     *EventBus
+	snapshots  *Snapshotter
 	sockPath   string
 	grpcServer *grpc.Server
 	logger     logr.Logger
@@ -45,32 +49,64 @@ type adaptiveIpamServer struct {
 
 func newAdaptiveIpamServer(logger logr.Logger, storeInstance *store.Store, socketPath string, releaseCooldown time.Duration, busyTimeout time.Duration) *adaptiveIpamServer {
 	engine := NewIPAMEngine(logger, storeInstance, releaseCooldown, busyTimeout, nil)
+	engine.events = NewEventBus()
 	return &adaptiveIpamServer{
 		engine:   engine,
 		store:    storeInstance,
+		events:   engine.events,
 		sockPath: socketPath,
 		logger:   logger,
 	}
 }
 
 func (s *adaptiveIpamServer) AllocatePodIP(ctx context.Context, req *adaptiveipam.AllocatePodIPRequest) (*adaptiveipam.AllocatePodIPResponse, error) {
-	return s.engine.AllocatePodIP(ctx, req)
+	start := time.Now()
+	resp, err := s.engine.AllocatePodIP(ctx, req)
+	observeRPC("AllocatePodIP", start, err)
+	return resp, err
 }
 
 func (s *adaptiveIpamServer) DeallocatePodIP(ctx context.Context, req *adaptiveipam.DeallocatePodIPRequest) (*adaptiveipam.DeallocatePodIPResponse, error) {
-	return s.engine.DeallocatePodIP(ctx, req)
+	start := time.Now()
+	resp, err := s.engine.DeallocatePodIP(ctx, req)
+	observeRPC("DeallocatePodIP", start, err)
+	return resp, err
 }
 
 func (s *adaptiveIpamServer) CheckPodIP(ctx context.Context, req *adaptiveipam.CheckPodIPRequest) (*adaptiveipam.CheckPodIPResponse, error) {
-	return s.engine.CheckPodIP(ctx, req)
+	start := time.Now()
+	resp, err := s.engine.CheckPodIP(ctx, req)
+	observeRPC("CheckPodIP", start, err)
+	return resp, err
 }
 
-func (s *adaptiveIpamServer) getPendingRequestsCount(network string) int {
-	return s.engine.getPendingRequestsCount(network)
+func (s *adaptiveIpamServer) ReleaseStalePodIPs(ctx context.Context, req *adaptiveipam.ReleaseStalePodIPsRequest) (*adaptiveipam.ReleaseStalePodIPsResponse, error) {
+	start := time.Now()
+	resp, err := s.engine.ReleaseStalePodIPs(ctx, req)
+	observeRPC("ReleaseStalePodIPs", start, err)
+	return resp, err
 }
 
-func (s *adaptiveIpamServer) onCIDRAdded(network string, availableIPs int) {
-	s.engine.onCIDRAdded(network, availableIPs)
+func (s *adaptiveIpamServer) GetNetworkStatus(ctx context.Context, req *adaptiveipam.GetNetworkStatusRequest) (*adaptiveipam.GetNetworkStatusResponse, error) {
+	start := time.Now()
+	resp, err := s.engine.GetNetworkStatus(ctx, req)
+	observeRPC("GetNetworkStatus", start, err)
+	return resp, err
+}
+
+func (s *adaptiveIpamServer) ReportIPConflict(ctx context.Context, req *adaptiveipam.ReportIPConflictRequest) (*adaptiveipam.ReportIPConflictResponse, error) {
+	start := time.Now()
+	resp, err := s.engine.ReportIPConflict(ctx, req)
+	observeRPC("ReportIPConflict", start, err)
+	return resp, err
+}
+
+func (s *adaptiveIpamServer) getPendingRequestsCount(network string, ipFamily store.IPFamily) int {
+	return s.engine.getPendingRequestsCount(network, ipFamily)
+}
+
+func (s *adaptiveIpamServer) onCIDRAdded(network string, ipFamily store.IPFamily, availableIPs int) {
+	s.engine.onCIDRAdded(network, ipFamily, availableIPs)
 }
 
 func (s *adaptiveIpamServer) start() error {
@@ -95,7 +131,18 @@ func (s *adaptiveIpamServer) start() error {
 		return fmt.Errorf("failed to set permissions on socket %s: %w", sockPath, err)
 	}
 
-	s.grpcServer = grpc.NewServer()
+	s.grpcServer = grpc.NewServer(
+		// The stats handler traces the unary and the streaming calls.
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			requestIDUnaryInterceptor(s.logger),
+			recoveryUnaryInterceptor(s.logger),
+		),
+		grpc.ChainStreamInterceptor(
+			requestIDStreamInterceptor(s.logger),
+			recoveryStreamInterceptor(s.logger),
+		),
+	)
 	adaptiveipam.RegisterAdaptiveIpamServer(s.grpcServer, s)
 	adminv1.RegisterAdminServer(s.grpcServer, s)
 
@@ -108,6 +155,9 @@ func (s *adaptiveIpamServer) start() error {
 }
 
 func (s *adaptiveIpamServer) stop() {
+	// Watch streams only end with their subscription, and GracefulStop
+	// waits for all streams to end.
+	s.events.Close()
 	if s.grpcServer != nil {
 		s.logger.Info("Stopping gRPC server gracefully")
 		s.grpcServer.GracefulStop()
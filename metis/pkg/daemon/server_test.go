@@ -33,10 +33,12 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/metis/api/adaptiveipam/v1"
+	"k8s.io/metis/pkg"
 	"k8s.io/metis/pkg/store"
 )
 
@@ -100,6 +102,19 @@ func TestAdaptiveIpamServer_withGrpcClient(t *testing.T) {
 	if resp.Ipv4 == nil || resp.Ipv4.IpAddress == "" {
 		t.Errorf("Expected valid IP address from gRPC client, got response: %v", resp)
 	}
+
+	// 4. The request ID of the client is echoed and the reason of an error
+	// reaches the client.
+	req.Ipv4Config.ContainerId = "test-container-invalid-cidr"
+	req.Ipv4Config.InitialPodCidr = "10.0.2.0/33"
+	var header metadata.MD
+	_, err = client.AllocatePodIP(pkg.WithRequestID(ctx, "test-request-id"), req, grpc.Header(&header))
+	if status.Code(err) != codes.InvalidArgument || pkg.ErrorReason(err) != pkg.ReasonInvalidCIDR {
+		t.Errorf("Expected InvalidArgument with reason %s, got %v", pkg.ReasonInvalidCIDR, err)
+	}
+	if got := header.Get(pkg.RequestIDMetadataKey); len(got) != 1 || got[0] != "test-request-id" {
+		t.Errorf("Expected the request ID in the response header, got %v", got)
+	}
 }
 
 func TestAdaptiveIpamServer_AllocatePodIP(t *testing.T) {
@@ -426,6 +441,9 @@ func TestAdaptiveIpamServer_AllocatePodIP_NoRetryOnExhaustion(t *testing.T) {
 	if st.Code() != codes.ResourceExhausted {
 		t.Errorf("Expected status code ResourceExhausted, got %v", st.Code())
 	}
+	if reason := pkg.ErrorReason(err); reason != pkg.ReasonExhausted {
+		t.Errorf("Expected reason %s, got %q", pkg.ReasonExhausted, reason)
+	}
 	if !strings.Contains(st.Message(), store.ErrNoAvailableIPs.Error()) {
 		t.Errorf("Expected status message to contain '%v', got: %s", store.ErrNoAvailableIPs, st.Message())
 	}
@@ -608,6 +626,113 @@ func TestAdaptiveIpamServer_AllocatePodIP_DualStack(t *testing.T) {
 	}
 }
 
+func TestAdaptiveIpamServer_AllocatePodIP_DualStackRollback(t *testing.T) {
+	network := "test-network"
+	newRequest := func(containerID string) *adaptiveipam.AllocatePodIPRequest {
+		return &adaptiveipam.AllocatePodIPRequest{
+			Network:      network,
+			PodName:      "test-pod",
+			PodNamespace: "default",
+			Ipv4Config:   &adaptiveipam.IPConfig{InterfaceName: "eth0", ContainerId: containerID, InitialPodCidr: "10.0.1.0/24"},
+			Ipv6Config:   &adaptiveipam.IPConfig{InterfaceName: "eth0", ContainerId: containerID, InitialPodCidr: "2001:db8::/64"},
+		}
+	}
+
+	testCases := []struct {
+		name string
+		// ipv6Err is returned by the IPv6 allocation. If nil, the IPv6
+		// allocation blocks until the request times out.
+		ipv6Err  error
+		wantCode codes.Code
+	}{
+		{name: "exhausted", ipv6Err: status.Error(codes.ResourceExhausted, "no IPv6 addresses"), wantCode: codes.ResourceExhausted},
+		{name: "store error", ipv6Err: status.Error(codes.Unavailable, "database is locked"), wantCode: codes.Unavailable},
+		{name: "timed out", wantCode: codes.DeadlineExceeded},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			storeInstance, err := store.NewStore(ctx, logr.Discard(), filepath.Join(t.TempDir(), "metis_server_rollback_test.sqlite"))
+			if err != nil {
+				t.Fatalf("Failed to create store: %v", err)
+			}
+			defer storeInstance.Close()
+			server := newAdaptiveIpamServer(logr.Discard(), storeInstance, "", time.Minute, 0)
+
+			engine := server.engine
+			engine.allocateFamily = func(ctx context.Context, req *adaptiveipam.AllocatePodIPRequest, config *adaptiveipam.IPConfig, ipFamily store.IPFamily) (*adaptiveipam.PodIP, error) {
+				if ipFamily == store.IPv4 {
+					return engine.allocateIP(ctx, req, config, ipFamily)
+				}
+				if tc.ipv6Err != nil {
+					return nil, tc.ipv6Err
+				}
+				<-ctx.Done()
+				return nil, status.FromContextError(ctx.Err()).Err()
+			}
+
+			reqCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			_, err = server.AllocatePodIP(reqCtx, newRequest("c1"))
+			if status.Code(err) != tc.wantCode {
+				t.Fatalf("Expected %v, got %v", tc.wantCode, err)
+			}
+
+			held, err := storeInstance.HasAllocation(ctx, network, "c1", "eth0", store.IPv4)
+			if err != nil {
+				t.Fatalf("HasAllocation failed: %v", err)
+			}
+			if held {
+				t.Error("Expected the IPv4 address to be released after the IPv6 allocation failed")
+			}
+			usage, err := storeInstance.GetIPUsage(ctx, network, store.IPv4)
+			if err != nil {
+				t.Fatalf("GetIPUsage failed: %v", err)
+			}
+			if usage.Cooldown != 0 {
+				t.Errorf("Expected the unused IPv4 address to skip the release cooldown, got %d IPs in cooldown", usage.Cooldown)
+			}
+		})
+	}
+
+	t.Run("ipv4 held by an earlier request", func(t *testing.T) {
+		ctx := context.Background()
+		storeInstance, err := store.NewStore(ctx, logr.Discard(), filepath.Join(t.TempDir(), "metis_server_rollback_test.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		defer storeInstance.Close()
+		server := newAdaptiveIpamServer(logr.Discard(), storeInstance, "", time.Minute, 0)
+
+		// An earlier ADD only requested IPv4.
+		req := newRequest("c1")
+		ipv6Config := req.Ipv6Config
+		req.Ipv6Config = nil
+		if _, err := server.AllocatePodIP(ctx, req); err != nil {
+			t.Fatalf("AllocatePodIP failed: %v", err)
+		}
+
+		engine := server.engine
+		engine.allocateFamily = func(ctx context.Context, req *adaptiveipam.AllocatePodIPRequest, config *adaptiveipam.IPConfig, ipFamily store.IPFamily) (*adaptiveipam.PodIP, error) {
+			if ipFamily == store.IPv4 {
+				return engine.allocateIP(ctx, req, config, ipFamily)
+			}
+			return nil, status.Error(codes.ResourceExhausted, "no IPv6 addresses")
+		}
+		req.Ipv6Config = ipv6Config
+		if _, err := server.AllocatePodIP(ctx, req); status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("Expected ResourceExhausted, got %v", err)
+		}
+		held, err := storeInstance.HasAllocation(ctx, network, "c1", "eth0", store.IPv4)
+		if err != nil {
+			t.Fatalf("HasAllocation failed: %v", err)
+		}
+		if !held {
+			t.Error("Expected the IPv4 address of the earlier request to stay allocated")
+		}
+	})
+}
+
 func TestAdaptiveIpamServer_AllocatePodIP_DynamicAllocation(t *testing.T) {
 	logger := klog.Background()
 
@@ -626,7 +751,7 @@ func TestAdaptiveIpamServer_AllocatePodIP_DynamicAllocation(t *testing.T) {
 				if err := storeInstance.AddCIDR(ctx, network, "10.0.1.0/24"); err != nil {
 					return err
 				}
-				server.onCIDRAdded(network, 256)
+				server.onCIDRAdded(network, store.IPv4, 256)
 				return nil
 			},
 			wantErr: false,
@@ -821,8 +946,8 @@ func TestAdaptiveIpamServer_AllocatePodIP_DynamicAllocation_MultipleRequests(t *
 	time.Sleep(200 * time.Millisecond)
 
 	// Verify that there are pending requests in map (5 should be pending as 5 succeeded from initial block)
-	if server.getPendingRequestsCount(network) != 5 {
-		t.Errorf("Expected 5 pending requests, got %d", server.getPendingRequestsCount(network))
+	if server.getPendingRequestsCount(network, store.IPv4) != 5 {
+		t.Errorf("Expected 5 pending requests, got %d", server.getPendingRequestsCount(network, store.IPv4))
 	}
 
 	// Now simulate the controller adding a CIDR (/29 has 8 IPs)
@@ -832,7 +957,7 @@ func TestAdaptiveIpamServer_AllocatePodIP_DynamicAllocation_MultipleRequests(t *
 	}
 
 	// Wake up first 8 requests (matching the block size)
-	server.onCIDRAdded(network, 8)
+	server.onCIDRAdded(network, store.IPv4, 8)
 
 	// Wait for all to finish
 	wg.Wait()
@@ -850,8 +975,8 @@ func TestAdaptiveIpamServer_AllocatePodIP_DynamicAllocation_MultipleRequests(t *
 		t.Errorf("Expected %d successful allocations at the end, got %d", numRequests, successCount)
 	}
 
-	if server.getPendingRequestsCount(network) != 0 {
-		t.Errorf("Expected 0 pending requests left, got %d", server.getPendingRequestsCount(network))
+	if server.getPendingRequestsCount(network, store.IPv4) != 0 {
+		t.Errorf("Expected 0 pending requests left, got %d", server.getPendingRequestsCount(network, store.IPv4))
 	}
 }
 
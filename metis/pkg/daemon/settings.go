@@ -0,0 +1,159 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// NetworkSettings are the tuning parameters the daemon applies to a network.
+type NetworkSettings struct {
+	// ReleaseCooldown is how long released IPs stay unavailable for new allocations.
+	ReleaseCooldown                 time.Duration
+	DrainingExpiration              time.Duration
+	SustainedLowUtilizationDuration time.Duration
+	LowUtilizationThreshold         float64
+	TargetUtilizationAfterScaleUp   float64
+	CooldownPushbackThreshold       int
+	CooldownPushbackInterval        time.Duration
+	ScalingPolicy                   string
+	MinFreeIPs                      int
+	RateWindow                      time.Duration
+	RateLeadTime                    time.Duration
+}
+
+// SetDefaults applies default values to the NetworkSettings fields if they are
+// unset (<= 0). The release cooldown is left as is since 0 disables it.
+func (s *NetworkSettings) SetDefaults() {
+	if s.DrainingExpiration <= 0 {
+		s.DrainingExpiration = DefaultDrainingExpiration
+	}
+	if s.SustainedLowUtilizationDuration <= 0 {
+		s.SustainedLowUtilizationDuration = DefaultSustainedLowUtilizationDuration
+	}
+	if s.LowUtilizationThreshold <= 0 {
+		s.LowUtilizationThreshold = DefaultLowUtilizationThreshold
+	}
+	if s.TargetUtilizationAfterScaleUp <= 0 {
+		s.TargetUtilizationAfterScaleUp = DefaultTargetUtilizationAfterScaleUp
+	}
+	if s.CooldownPushbackThreshold <= 0 {
+		s.CooldownPushbackThreshold = DefaultCooldownPushbackThreshold
+	}
+	if s.CooldownPushbackInterval <= 0 {
+		s.CooldownPushbackInterval = DefaultCooldownPushbackInterval
+	}
+	if s.ScalingPolicy == "" {
+		s.ScalingPolicy = DefaultScalingPolicy
+	}
+	if s.MinFreeIPs <= 0 {
+		s.MinFreeIPs = DefaultMinFreeIPs
+	}
+	if s.RateWindow <= 0 {
+		s.RateWindow = DefaultRateWindow
+	}
+	if s.RateLeadTime <= 0 {
+		s.RateLeadTime = DefaultRateLeadTime
+	}
+}
+
+// validate returns the errors of settings whose fields are individually valid
+// but inconsistent with each other.
+func (s *NetworkSettings) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if s.LowUtilizationThreshold >= s.TargetUtilizationAfterScaleUp {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("lowUtilizationThreshold"), s.LowUtilizationThreshold,
+			"must be less than targetUtilizationAfterScaleUp, otherwise capacity is drained right after being scaled up"))
+	}
+	return allErrs
+}
+
+// NetworkSettingsSource resolves the settings of each network from the daemon
+// flags and, once set, a NetworkConfiguration. It is safe for concurrent use,
+// and a new NetworkConfiguration takes effect for all subsequent lookups
+// without affecting the allocations in flight.
+//
+// The settings of a network are resolved from, in increasing precedence:
+//   - The defaults from the daemon flags.
+//   - The defaults of the NetworkConfiguration.
+//   - The scaling policy of the network from the daemon flags.
+//   - The overrides of the network in the NetworkConfiguration.
+type NetworkSettingsSource struct {
+	defaults               NetworkSettings
+	networkScalingPolicies map[string]string
+	config                 atomic.Pointer[NetworkConfiguration]
+}
+
+// NewNetworkSettingsSource creates a NetworkSettingsSource from the settings
+// of all networks and the names of the scaling policies of specific networks.
+func NewNetworkSettingsSource(defaults NetworkSettings, networkScalingPolicies map[string]string) *NetworkSettingsSource {
+	defaults.SetDefaults()
+	return &NetworkSettingsSource{
+		defaults:               defaults,
+		networkScalingPolicies: networkScalingPolicies,
+	}
+}
+
+// ForNetwork returns the settings of a network.
+func (s *NetworkSettingsSource) ForNetwork(network string) NetworkSettings {
+	return s.resolve(s.config.Load(), network)
+}
+
+// Configuration returns the NetworkConfiguration in effect, or nil if none was set.
+func (s *NetworkSettingsSource) Configuration() *NetworkConfiguration {
+	return s.config.Load()
+}
+
+// SetConfiguration validates the settings cfg resolves to and, if they are
+// valid, puts cfg into effect. A nil cfg removes the NetworkConfiguration.
+// cfg must not be modified afterwards.
+func (s *NetworkSettingsSource) SetConfiguration(cfg *NetworkConfiguration) error {
+	if cfg != nil {
+		// Networks without overrides resolve like the defaults, except for
+		// their scaling policy which does not take part in the validation.
+		defaults := s.resolve(cfg, "")
+		allErrs := defaults.validate(field.NewPath("defaults"))
+		for network := range cfg.Networks {
+			settings := s.resolve(cfg, network)
+			allErrs = append(allErrs, settings.validate(field.NewPath("networks").Key(network))...)
+		}
+		if err := allErrs.ToAggregate(); err != nil {
+			return err
+		}
+	}
+	s.config.Store(cfg)
+	return nil
+}
+
+func (s *NetworkSettingsSource) resolve(cfg *NetworkConfiguration, network string) NetworkSettings {
+	settings := s.defaults
+	if cfg != nil {
+		cfg.Defaults.applyTo(&settings)
+	}
+	if policy, ok := s.networkScalingPolicies[network]; ok {
+		settings.ScalingPolicy = policy
+	}
+	if cfg != nil {
+		if overrides, ok := cfg.Networks[network]; ok {
+			overrides.applyTo(&settings)
+		}
+	}
+	return settings
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	networkv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/network/v1"
+	nncclientset "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/metis/pkg/store"
+)
+
+const (
+	DefaultSnapshotRetention = 5
+
+	// snapshotPrefix and snapshotSuffix surround the creation time of the
+	// snapshots written to the snapshot directory, so that their names sort
+	// in creation order.
+	snapshotPrefix     = "metis-"
+	snapshotSuffix     = ".sqlite"
+	snapshotTimeFormat = "20060102T150405.000Z"
+)
+
+// Snapshotter writes snapshots of the store to a snapshot directory, keeping
+// the most recent ones, and restores the store from snapshots.
+//
+// A restored store may refer to CIDR blocks that were released since the
+// snapshot was taken, and possibly assigned to another node. These blocks are
+// deleted from the store after a restore by cross-checking it against the
+// blocks of the CIDR sources, by default the status of the NodeNetworkConfig,
+// and the pod CIDRs of the node. It also lacks the IPs allocated since the
+// snapshot was taken, which are claimed again from the pod IPs of the running
+// pods of the node by a StateRebuilder.
+type Snapshotter struct {
+	store      *store.Store
+	logger     logr.Logger
+	kubeClient kubernetes.Interface
+	sources    []CIDRSource
+	rebuilder  *StateRebuilder
+	nodeName   string
+	dir        string
+	interval   time.Duration
+	retention  int
+
+	// mu serializes the snapshots and restores.
+	mu sync.Mutex
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+// SnapshotterConfig holds the configuration for the Snapshotter.
+type SnapshotterConfig struct {
+	Logger     logr.Logger
+	Store      *store.Store
+	KubeClient kubernetes.Interface
+	NNCClient  nncclientset.Interface
+	// Sources are the CIDR sources of the node. If empty, the blocks come
+	// from the NodeNetworkConfig of NNCClient.
+	Sources  []CIDRSource
+	NodeName string
+	// Dir is the directory the snapshots are written to.
+	Dir string
+	// Interval is the period of the snapshots written by Run. Periodic
+	// snapshots are disabled if it is <= 0.
+	Interval time.Duration
+	// Retention is the number of snapshots kept in Dir.
+	Retention int
+}
+
+// SetDefaults applies default values to the SnapshotterConfig fields if they are unset (<= 0).
+func (c *SnapshotterConfig) SetDefaults() {
+	if c.Retention <= 0 {
+		c.Retention = DefaultSnapshotRetention
+	}
+}
+
+// NewSnapshotter creates a new Snapshotter.
+func NewSnapshotter(cfg SnapshotterConfig) *Snapshotter {
+	cfg.SetDefaults()
+	sources := cfg.Sources
+	if len(sources) == 0 {
+		sources = []CIDRSource{NewNNCCIDRSource(cfg.NNCClient, nil, cfg.NodeName)}
+	}
+	rebuilder := NewStateRebuilder(StateRebuilderConfig{
+		Logger:     cfg.Logger,
+		KubeClient: cfg.KubeClient,
+		Sources:    sources,
+		Store:      cfg.Store,
+		NodeName:   cfg.NodeName,
+	})
+	return &Snapshotter{
+		store:      cfg.Store,
+		logger:     cfg.Logger,
+		kubeClient: cfg.KubeClient,
+		sources:    sources,
+		rebuilder:  rebuilder,
+		nodeName:   cfg.NodeName,
+		dir:        cfg.Dir,
+		interval:   cfg.Interval,
+		retention:  cfg.Retention,
+		now:        time.Now,
+	}
+}
+
+// Run writes a snapshot every interval until ctx is done, if periodic
+// snapshots are enabled.
+func (s *Snapshotter) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+	s.logger.Info("Starting store snapshotter", "dir", s.dir, "interval", s.interval, "retention", s.retention)
+	defer s.logger.Info("Stopping store snapshotter")
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if _, err := s.Snapshot(ctx, ""); err != nil {
+			s.logger.Error(err, "Periodic store snapshot failed")
+		}
+	}, s.interval)
+}
+
+// Snapshot writes a snapshot of the store to path and returns its path. If
+// path is empty, the snapshot is written to the snapshot directory and the
+// oldest snapshots beyond the retention are deleted.
+func (s *Snapshotter) Snapshot(ctx context.Context, path string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rotate := path == ""
+	if rotate {
+		path = filepath.Join(s.dir, snapshotPrefix+s.now().UTC().Format(snapshotTimeFormat)+snapshotSuffix)
+	}
+	if err := s.store.Backup(ctx, path); err != nil {
+		return "", err
+	}
+	s.logger.Info("Wrote store snapshot", "path", path)
+	if rotate {
+		if err := s.rotate(); err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// rotate deletes the oldest snapshots of the snapshot directory beyond the retention.
+func (s *Snapshotter) rotate() error {
+	snapshots, err := listSnapshots(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, path := range snapshots[min(len(snapshots), s.retention):] {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to delete old snapshot: %w", err)
+		}
+		s.logger.V(2).Info("Deleted old store snapshot", "path", path)
+	}
+	return nil
+}
+
+// Restore replaces the content of the store with the snapshot at path,
+// deletes the restored CIDR blocks that are no longer assigned to the node,
+// and rebuilds the ownership of the IPs of the running pods of the node. No
+// IPs must be allocated until it returns.
+func (s *Snapshotter) Restore(ctx context.Context, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.store.Restore(ctx, path); err != nil {
+		return err
+	}
+	if err := s.deleteUnassignedCIDRBlocks(ctx); err != nil {
+		return err
+	}
+	if err := s.rebuilder.Rebuild(ctx); err != nil {
+		return fmt.Errorf("failed to rebuild store state after restore: %w", err)
+	}
+	return nil
+}
+
+// deleteUnassignedCIDRBlocks deletes the CIDR blocks of the store that are
+// neither assigned by a CIDR source nor a pod CIDR of the node, with the IPs
+// allocated from them.
+func (s *Snapshotter) deleteUnassignedCIDRBlocks(ctx context.Context) error {
+	assigned := map[string]bool{}
+	for _, source := range s.sources {
+		cidrs, err := source.CIDRs(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get CIDR blocks from source %s to cross-check the restored store: %w", source.Name(), err)
+		}
+		for _, cidr := range cidrs {
+			assigned[cidr.Network+"/"+cidr.CIDR] = true
+		}
+	}
+	node, err := s.kubeClient.CoreV1().Nodes().Get(ctx, s.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node to cross-check the restored store: %w", err)
+	}
+
+	// The pod CIDRs of the node are added by the engine as the initial CIDR
+	// blocks of the default network.
+	for _, cidr := range node.Spec.PodCIDRs {
+		assigned[networkv1.DefaultPodNetworkName+"/"+cidr] = true
+	}
+
+	blocks, err := s.store.GetAllCIDRBlocks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list CIDR blocks of the restored store: %w", err)
+	}
+	for _, block := range blocks {
+		if assigned[block.Network+"/"+block.CIDR] {
+			continue
+		}
+		if err := s.store.MarkCIDRBlockDeleting(ctx, block.ID); err != nil {
+			return fmt.Errorf("failed to mark unassigned CIDR block %s of the restored store as Deleting: %w", block.CIDR, err)
+		}
+		if err := s.store.DeleteCIDRBlock(ctx, block.ID); err != nil {
+			return fmt.Errorf("failed to delete unassigned CIDR block %s of the restored store: %w", block.CIDR, err)
+		}
+		s.logger.Info("Deleted restored CIDR block no longer assigned to the node", "network", block.Network, "cidr", block.CIDR,
+			"allocatedIPs", block.AllocatedIPs)
+	}
+	return nil
+}
+
+// recoverStoreFile checks the integrity of the database file at dbPath before
+// it is opened. If the check fails, the file is replaced with the newest
+// snapshot of snapshotDir that passes the check, or moved aside so that the
+// daemon starts with an empty store if there is none. It reports whether the
+// file was restored from a snapshot.
+func recoverStoreFile(ctx context.Context, logger logr.Logger, dbPath, snapshotDir string) (bool, error) {
+	if _, err := os.Stat(dbPath); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	err := store.CheckIntegrity(ctx, dbPath)
+	if err == nil || !errors.Is(err, store.ErrIntegrityCheckFailed) {
+		return false, err
+	}
+	logger.Error(err, "Store failed the integrity check, restoring it from the newest valid snapshot", "path", dbPath, "snapshotDir", snapshotDir)
+
+	snapshots, err := listSnapshots(snapshotDir)
+	if err != nil {
+		return false, err
+	}
+	for _, snapshot := range snapshots {
+		if err := store.CheckIntegrity(ctx, snapshot); err != nil {
+			logger.Error(err, "Skipping invalid store snapshot", "path", snapshot)
+			continue
+		}
+		if err := store.RestoreFile(ctx, logger, snapshot, dbPath); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	corruptPath, err := store.MoveAside(dbPath)
+	if err != nil {
+		return false, err
+	}
+	logger.Error(nil, "No valid store snapshot found, starting with an empty store", "corruptedStore", corruptPath)
+	return false, nil
+}
+
+// listSnapshots returns the paths of the snapshots of dir, newest first.
+func listSnapshots(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	var snapshots []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, snapshotPrefix) && strings.HasSuffix(name, snapshotSuffix) {
+			snapshots = append(snapshots, filepath.Join(dir, name))
+		}
+	}
+	slices.Sort(snapshots)
+	slices.Reverse(snapshots)
+	return snapshots, nil
+}
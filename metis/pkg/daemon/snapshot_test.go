@@ -0,0 +1,173 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	nncfake "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned/fake"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/metis/pkg/store"
+)
+
+func TestSnapshotter(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	storeInstance, err := store.NewStore(ctx, logger, filepath.Join(t.TempDir(), "metis_snapshot_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+	for _, block := range []struct{ network, cidr string }{
+		{"default", "10.0.0.0/28"},
+		{"default", "10.0.1.0/28"},
+		{"other", "10.1.0.0/28"},
+	} {
+		if err := storeInstance.AddCIDR(ctx, block.network, block.cidr); err != nil {
+			t.Fatalf("Failed to add CIDR %s: %v", block.cidr, err)
+		}
+	}
+
+	nodeName := "test-node"
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	s := NewSnapshotter(SnapshotterConfig{
+		Logger: logger,
+		Store:  storeInstance,
+		KubeClient: kubefake.NewSimpleClientset(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+			Spec:       corev1.NodeSpec{PodCIDRs: []string{"10.0.0.0/28"}},
+		}),
+		NNCClient: nncfake.NewSimpleClientset(&nncv1.NodeNetworkConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+			Status: nncv1.NodeNetworkConfigStatus{PodCIDRs: []nncv1.PodCIDR{
+				{Network: "other", CIDR: "10.1.0.0/28"},
+			}},
+		}),
+		NodeName:  nodeName,
+		Dir:       dir,
+		Retention: 2,
+	})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	var written []string
+	for range 3 {
+		path, err := s.Snapshot(ctx, "")
+		if err != nil {
+			t.Fatalf("Snapshot failed: %v", err)
+		}
+		written = append(written, path)
+		now = now.Add(time.Hour)
+	}
+	snapshots, err := listSnapshots(dir)
+	if err != nil {
+		t.Fatalf("listSnapshots failed: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0] != written[2] || snapshots[1] != written[1] {
+		t.Errorf("Expected the 2 newest snapshots %v to be kept, got %v", written[1:], snapshots)
+	}
+
+	// 10.0.1.0/28 is neither a pod CIDR of the node nor in the
+	// NodeNetworkConfig status, and is deleted by the restore.
+	if err := storeInstance.AddCIDR(ctx, "default", "10.0.2.0/28"); err != nil {
+		t.Fatalf("Failed to add CIDR: %v", err)
+	}
+	if err := s.Restore(ctx, snapshots[0]); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	blocks, err := storeInstance.GetAllCIDRBlocks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllCIDRBlocks failed: %v", err)
+	}
+	var got []string
+	for _, b := range blocks {
+		got = append(got, b.Network+"/"+b.CIDR)
+	}
+	if len(got) != 2 || got[0] != "default/10.0.0.0/28" || got[1] != "other/10.1.0.0/28" {
+		t.Errorf("Expected only the CIDR blocks assigned to the node to be restored, got %v", got)
+	}
+}
+
+func TestRecoverStoreFile(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "metis.sqlite")
+	snapshotDir := filepath.Join(dir, "snapshots")
+
+	if restored, err := recoverStoreFile(ctx, logger, dbPath, snapshotDir); err != nil || restored {
+		t.Errorf("Expected a missing store to be left alone, got %v, %v", restored, err)
+	}
+
+	storeInstance, err := store.NewStore(ctx, logger, dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if err := storeInstance.AddCIDR(ctx, "default", "10.0.0.0/28"); err != nil {
+		t.Fatalf("Failed to add CIDR: %v", err)
+	}
+	snapshotPath := filepath.Join(snapshotDir, snapshotPrefix+"20260101T000000.000Z"+snapshotSuffix)
+	if err := storeInstance.Backup(ctx, snapshotPath); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if err := storeInstance.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+	if restored, err := recoverStoreFile(ctx, logger, dbPath, snapshotDir); err != nil || restored {
+		t.Errorf("Expected a valid store to be left alone, got %v, %v", restored, err)
+	}
+
+	// A newer corrupted snapshot is skipped.
+	corrupt := func(path string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("not a database"), 0644); err != nil {
+			t.Fatalf("Failed to corrupt %s: %v", path, err)
+		}
+	}
+	corrupt(dbPath)
+	corrupt(filepath.Join(snapshotDir, snapshotPrefix+"20260102T000000.000Z"+snapshotSuffix))
+	if restored, err := recoverStoreFile(ctx, logger, dbPath, snapshotDir); err != nil || !restored {
+		t.Fatalf("Expected the store to be restored from the valid snapshot, got %v, %v", restored, err)
+	}
+	restoredStore, err := store.NewStore(ctx, logger, dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open restored store: %v", err)
+	}
+	if _, exists, err := restoredStore.GetCIDRBlock(ctx, "10.0.0.0/28", "default"); err != nil || !exists {
+		t.Errorf("Expected the CIDR block of the snapshot to be restored, got %v, %v", exists, err)
+	}
+	if err := restoredStore.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	corrupt(dbPath)
+	corrupt(snapshotPath)
+	if restored, err := recoverStoreFile(ctx, logger, dbPath, snapshotDir); err != nil || restored {
+		t.Errorf("Expected the store to be moved aside without a valid snapshot, got %v, %v", restored, err)
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the corrupted store to be moved aside, got %v", err)
+	}
+}
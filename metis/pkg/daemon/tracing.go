@@ -0,0 +1,69 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+const (
+	// tracingServiceName is the service name of the exported spans.
+	tracingServiceName = "metis-daemon"
+	// tracingShutdownTimeout bounds flushing the buffered spans at shutdown.
+	tracingShutdownTimeout = 5 * time.Second
+)
+
+// setupTracing installs the global OpenTelemetry tracer provider exporting the
+// spans of the gRPC requests to an OTLP gRPC collector at endpoint, e.g. a
+// collector on the node at localhost:4317. The connection to the collector is
+// not encrypted. Tracing stays disabled if endpoint is empty. The returned
+// function flushes the buffered spans and must be called at shutdown.
+func setupTracing(ctx context.Context, logger logr.Logger, endpoint string) (func(), error) {
+	if endpoint == "" {
+		return func() {}, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter for %s: %w", endpoint, err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(tracingServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	logger.Info("Exporting traces", "endpoint", endpoint)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tracingShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			logger.Error(err, "Failed to flush traces", "endpoint", endpoint)
+		}
+	}, nil
+}
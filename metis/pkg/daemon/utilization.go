@@ -0,0 +1,197 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metis/pkg/store"
+)
+
+const (
+	// IPUtilizationConditionType is the type of the NodeNetworkConfig status
+	// condition the Monitor publishes the IP utilization of the node in. The
+	// utilization of all nodes can be listed with:
+	//
+	//	kubectl get nodenetworkconfigs -o custom-columns='NODE:.metadata.name,IP UTILIZATION:.status.conditions[?(@.type=="MetisIPUtilization")].message'
+	IPUtilizationConditionType = "MetisIPUtilization"
+	// IPUtilizationAnnotation is the NodeNetworkConfig annotation the Monitor
+	// publishes the allocated and total IPs of each network and IP family of
+	// the node in, e.g. "default/ipv4=12/64,gpu-network/ipv4=0/16". The
+	// NodeNetworkConfig CRD is defined by gke-networking-api and prunes status
+	// fields it does not know of, and printer columns only take simple JSON
+	// paths, so the annotation is what a printer column of the CRD targets:
+	//
+	//	additionalPrinterColumns:
+	//	- name: IP Utilization
+	//	  type: string
+	//	  jsonPath: .metadata.annotations.metis\.networking\.gke\.io/ip-utilization
+	IPUtilizationAnnotation = "metis.networking.gke.io/ip-utilization"
+	// ipUtilizationReportedReason is the reason of the IPUtilizationConditionType condition.
+	ipUtilizationReportedReason = "UtilizationReported"
+
+	DefaultUtilizationReportInterval = 30 * time.Second
+)
+
+// utilizationColumn formats the allocated and total IPs of the networks of
+// the node as the value of IPUtilizationAnnotation. IP families without
+// capacity are omitted.
+func utilizationColumn(usage map[networkFamily]store.NetworkIPUsage) string {
+	keys := sortedUsageKeys(usage)
+	entries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		u := usage[key]
+		entries = append(entries, fmt.Sprintf("%s/%s=%d/%d", key.network, key.ipFamily, u.Allocated, u.Total))
+	}
+	return strings.Join(entries, ",")
+}
+
+// sortedUsageKeys returns the network families of usage with capacity, sorted
+// by network and IP family.
+func sortedUsageKeys(usage map[networkFamily]store.NetworkIPUsage) []networkFamily {
+	keys := make([]networkFamily, 0, len(usage))
+	for key, u := range usage {
+		if u.Total > 0 {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b networkFamily) int {
+		if c := strings.Compare(a.network, b.network); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.ipFamily), string(b.ipFamily))
+	})
+	return keys
+}
+
+// utilizationSummary formats the IP usage of the networks of the node as the
+// message of the IPUtilizationConditionType condition, e.g.:
+//
+//	default/ipv4 total=64 allocated=12 cooldown=2 draining=16 lastScaleUp=2026-10-16T12:00:00Z; gpu-network/ipv4 total=16 allocated=0 cooldown=0 draining=0 lastScaleUp=never
+//
+// IP families without capacity are omitted. lastScaleUp is "never" if the
+// network was not scaled up since the daemon started and the ip_history
+// has no record of an earlier scale-up (see seedLastScaleUp).
+func utilizationSummary(usage map[networkFamily]store.NetworkIPUsage, lastScaleUp map[string]time.Time) string {
+	keys := sortedUsageKeys(usage)
+	entries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		u := usage[key]
+		scaleUp := "never"
+		if t, ok := lastScaleUp[key.network]; ok {
+			scaleUp = t.UTC().Format(time.RFC3339)
+		}
+		entries = append(entries, fmt.Sprintf("%s/%s total=%d allocated=%d cooldown=%d draining=%d lastScaleUp=%s",
+			key.network, key.ipFamily, u.Total, u.Allocated, u.Cooldown, u.Draining, scaleUp))
+	}
+	if len(entries) == 0 {
+		return "no IP capacity"
+	}
+	return strings.Join(entries, "; ")
+}
+
+// maybePublishUtilization publishes the IP usage collected by the last sync
+// in the IPUtilizationConditionType condition of the NodeNetworkConfig status
+// and in IPUtilizationAnnotation, unless it is unchanged or was published
+// less than the report interval ago.
+func (m *Monitor) maybePublishUtilization(ctx context.Context) error {
+	if m.capacity != nil {
+		return nil
+	}
+	message := utilizationSummary(m.usageReport, m.lastScaleUp)
+	now := m.now()
+	if message == m.publishedUtilization || now.Sub(m.utilizationPublishedAt) < m.utilizationReportInterval {
+		return nil
+	}
+
+	// The annotation is patched first: the summary is only recorded as
+	// published once the status is patched too, so a failure of either patch
+	// is retried by the next sync.
+	annotationPatch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]string{
+				IPUtilizationAnnotation: utilizationColumn(m.usageReport),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal annotation patch: %w", err)
+	}
+	if _, err := m.nncClient.NetworkingV1().NodeNetworkConfigs().Patch(ctx, m.nodeName, types.MergePatchType, annotationPatch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch NodeNetworkConfig annotations: %w", err)
+	}
+
+	// The lister may not have observed the patch of this sync yet, and the
+	// resource version must be current for the conditions of other writers
+	// not to be overwritten.
+	nnc, err := m.nncClient.NetworkingV1().NodeNetworkConfigs().Get(ctx, m.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get NodeNetworkConfig: %w", err)
+	}
+	conditions := slices.Clone(nnc.Status.Conditions)
+	meta.SetStatusCondition(&conditions, metav1.Condition{
+		Type:               IPUtilizationConditionType,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: nnc.Generation,
+		Reason:             ipUtilizationReportedReason,
+		Message:            message,
+	})
+	// Merge patches replace lists, so the patch carries all conditions and
+	// the resource version for optimistic concurrency.
+	patchBytes, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"resourceVersion": nnc.ResourceVersion,
+		},
+		"status": map[string]any{
+			"conditions": conditions,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal status patch: %w", err)
+	}
+	if _, err := m.nncClient.NetworkingV1().NodeNetworkConfigs().Patch(ctx, m.nodeName, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status"); err != nil {
+		return fmt.Errorf("failed to patch NodeNetworkConfig status: %w", err)
+	}
+	m.logger.V(2).Info("Published IP utilization to NodeNetworkConfig status", "utilization", message)
+	m.publishedUtilization = message
+	m.utilizationPublishedAt = now
+	return nil
+}
+
+// seedLastScaleUp sets the time of the last scale-up of the networks that
+// were not scaled up since the daemon started from the ip_history, so that
+// it survives restarts as far as the history goes back.
+func (m *Monitor) seedLastScaleUp(ctx context.Context) error {
+	scaleUps, err := m.store.LastScaleUps(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the last scale-ups from the store: %w", err)
+	}
+	for network, t := range scaleUps {
+		if _, ok := m.lastScaleUp[network]; !ok {
+			m.lastScaleUp[network] = t
+		}
+	}
+	return nil
+}
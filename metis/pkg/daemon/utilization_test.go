@@ -0,0 +1,193 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	nncfake "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned/fake"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metis/pkg/store"
+)
+
+func TestUtilizationSummary(t *testing.T) {
+	scaleUp := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	usage := map[networkFamily]store.NetworkIPUsage{
+		{network: "gpu-network", ipFamily: store.IPv4}: {Total: 16},
+		{network: "default", ipFamily: store.IPv6}:     {Total: 256, Allocated: 3},
+		{network: "default", ipFamily: store.IPv4}:     {Total: 64, Allocated: 12, Cooldown: 2, Draining: 16},
+		{network: "gpu-network", ipFamily: store.IPv6}: {},
+	}
+	got := utilizationSummary(usage, map[string]time.Time{"default": scaleUp})
+	want := "default/ipv4 total=64 allocated=12 cooldown=2 draining=16 lastScaleUp=2026-10-16T12:00:00Z; " +
+		"default/ipv6 total=256 allocated=3 cooldown=0 draining=0 lastScaleUp=2026-10-16T12:00:00Z; " +
+		"gpu-network/ipv4 total=16 allocated=0 cooldown=0 draining=0 lastScaleUp=never"
+	if got != want {
+		t.Errorf("utilizationSummary() =\n%s\nwant\n%s", got, want)
+	}
+	if got := utilizationSummary(nil, nil); got != "no IP capacity" {
+		t.Errorf("utilizationSummary() of no networks = %q", got)
+	}
+
+	wantColumn := "default/ipv4=12/64,default/ipv6=3/256,gpu-network/ipv4=0/16"
+	if got := utilizationColumn(usage); got != wantColumn {
+		t.Errorf("utilizationColumn() = %q, want %q", got, wantColumn)
+	}
+}
+
+func TestMonitor_PublishUtilization(t *testing.T) {
+	ctx := context.Background()
+	nodeName := "test-node"
+	network := "test-network"
+	storeInstance, err := store.NewStore(ctx, logr.Discard(), filepath.Join(t.TempDir(), "metis_utilization_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+	if err := storeInstance.AddCIDR(ctx, network, "10.0.1.0/28"); err != nil {
+		t.Fatalf("Failed to add CIDR: %v", err)
+	}
+	allocated := 0
+	allocate := func(n int) {
+		t.Helper()
+		for range n {
+			allocated++
+			if _, _, err := storeInstance.AllocateIP(ctx, store.AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: fmt.Sprintf("container-%d", allocated), IPFamily: store.IPv4}); err != nil {
+				t.Fatalf("Failed to allocate IP: %v", err)
+			}
+		}
+	}
+	// The first block of the network has 3 reserved addresses, counted as allocated.
+	allocate(2)
+
+	otherCondition := metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Ready", LastTransitionTime: metav1.Now()}
+	nncClient := nncfake.NewSimpleClientset(&nncv1.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+		Spec: nncv1.NodeNetworkConfigSpec{
+			Allocations: []nncv1.Allocation{{Network: network, Pods: 16}},
+		},
+		Status: nncv1.NodeNetworkConfigStatus{
+			PodCIDRs:   []nncv1.PodCIDR{{CIDR: "10.0.1.0/28", Network: network}},
+			Conditions: []metav1.Condition{otherCondition},
+		},
+	})
+	m := NewMonitor(MonitorConfig{
+		Logger:                    logr.Discard(),
+		NNCClient:                 nncClient,
+		Store:                     storeInstance,
+		NodeName:                  nodeName,
+		UtilizationReportInterval: time.Minute,
+	})
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	// published returns the condition message and the annotation.
+	published := func() string {
+		t.Helper()
+		nnc, err := nncClient.NetworkingV1().NodeNetworkConfigs().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get NodeNetworkConfig: %v", err)
+		}
+		if meta.FindStatusCondition(nnc.Status.Conditions, "Ready") == nil {
+			t.Errorf("Expected the other conditions to be kept, got %+v", nnc.Status.Conditions)
+		}
+		cond := meta.FindStatusCondition(nnc.Status.Conditions, IPUtilizationConditionType)
+		if cond == nil {
+			t.Fatalf("Expected a %s condition, got %+v", IPUtilizationConditionType, nnc.Status.Conditions)
+		}
+		if cond.Status != metav1.ConditionTrue || cond.Reason != ipUtilizationReportedReason {
+			t.Errorf("Unexpected condition %+v", cond)
+		}
+		return cond.Message + " | " + nnc.Annotations[IPUtilizationAnnotation]
+	}
+
+	if err := m.syncAll(ctx); err != nil {
+		t.Fatalf("syncAll failed: %v", err)
+	}
+	want := "test-network/ipv4 total=16 allocated=5 cooldown=0 draining=0 lastScaleUp=never | test-network/ipv4=5/16"
+	if got := published(); got != want {
+		t.Errorf("Expected utilization %q, got %q", want, got)
+	}
+
+	// Changes are published at most once per report interval.
+	allocate(10)
+	if err := m.syncAll(ctx); err != nil {
+		t.Fatalf("syncAll failed: %v", err)
+	}
+	if got := published(); got != want {
+		t.Errorf("Expected utilization %q within the report interval, got %q", want, got)
+	}
+
+	now = now.Add(time.Minute)
+	if err := m.syncAll(ctx); err != nil {
+		t.Fatalf("syncAll failed: %v", err)
+	}
+	want = "test-network/ipv4 total=16 allocated=15 cooldown=0 draining=0 lastScaleUp=2026-10-16T12:00:00Z | test-network/ipv4=15/16"
+	if got := published(); got != want {
+		t.Errorf("Expected utilization %q after the report interval, got %q", want, got)
+	}
+}
+
+func TestMonitor_SeedLastScaleUp(t *testing.T) {
+	ctx := context.Background()
+	storeInstance, err := store.NewStore(ctx, logr.Discard(), filepath.Join(t.TempDir(), "metis_seed_scale_up_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer storeInstance.Close()
+	// The first block of each IP family is the initial capacity, only the
+	// blocks added on top of it are scale-ups.
+	for _, b := range []struct{ network, cidr string }{
+		{"scaled", "10.0.1.0/28"},
+		{"scaled", "10.0.2.0/28"},
+		{"unscaled", "10.0.3.0/28"},
+		{"unscaled", "2001:db8::/64"},
+	} {
+		if err := storeInstance.AddCIDR(ctx, b.network, b.cidr); err != nil {
+			t.Fatalf("Failed to add CIDR: %v", err)
+		}
+	}
+
+	m := NewMonitor(MonitorConfig{Logger: logr.Discard(), Store: storeInstance, NodeName: "test-node"})
+	if err := m.seedLastScaleUp(ctx); err != nil {
+		t.Fatalf("seedLastScaleUp failed: %v", err)
+	}
+	if _, ok := m.lastScaleUp["unscaled"]; ok {
+		t.Errorf("Expected no last scale-up for a network with only its first blocks, got %v", m.lastScaleUp)
+	}
+	seeded, ok := m.lastScaleUp["scaled"]
+	if !ok || time.Since(seeded) > time.Minute {
+		t.Errorf("Expected the last scale-up of the scaled network to be seeded from the history, got %v", m.lastScaleUp)
+	}
+
+	// Scale-ups since the daemon started are kept.
+	now := time.Now().Add(time.Hour)
+	m.lastScaleUp["scaled"] = now
+	if err := m.seedLastScaleUp(ctx); err != nil {
+		t.Fatalf("seedLastScaleUp failed: %v", err)
+	}
+	if !m.lastScaleUp["scaled"].Equal(now) {
+		t.Errorf("Expected the last scale-up to be kept, got %v", m.lastScaleUp["scaled"])
+	}
+}
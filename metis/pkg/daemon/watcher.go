@@ -23,12 +23,9 @@ import (
 	"net/netip"
 	"time"
 
-	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
 	nncclientset "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned"
 	nncinformers "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/informers/externalversions/nodenetworkconfig/v1"
-	nnclisters "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/listers/nodenetworkconfig/v1"
 	"github.com/go-logr/logr"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
@@ -37,25 +34,26 @@ import (
 
 const defaultWatcherWorkers = 4
 
-// Watcher monitors the NodeNetworkConfig (NNC) resource for the local node where this daemon runs.
-// It synchronizes the local database with the control plane's CIDR allocations:
-//  1. Adds newly allocated CIDRs to the local database, triggering the OnCIDRAdded callback to wake up
+// Watcher synchronizes the local database with the CIDR blocks assigned to
+// the node by its CIDR sources, by default the NodeNetworkConfig (NNC)
+// resource of the node where this daemon runs:
+//  1. Adds newly assigned CIDRs to the local database, triggering the OnCIDRAdded callback to wake up
 //     blocked CNI requests.
-//  2. Safely deletes CIDR blocks from the local database once they are successfully released by GCE/controller
-//     and removed from the NNC Status.
+//  2. Safely deletes CIDR blocks from the local database once they are successfully released, e.g. by
+//     GCE/controller and removed from the NNC Status, and no source assigns them anymore.
 type Watcher struct {
 	queue workqueue.TypedRateLimitingInterface[string]
 	// syncHandler is the function called to sync a network work item. Decoupling this
 	// via a field function pointer allows unit tests to easily mock/override the sync
 	// logic without requiring full clients, informers, or stores.
 	syncHandler func(ctx context.Context, network string) error
-	nncClient   nncclientset.Interface
+	sources     []CIDRSource
 	nodeName    string
-	nncLister   nnclisters.NodeNetworkConfigLister
 	nncSynced   cache.InformerSynced
 	store       *store.Store
 	logger      logr.Logger
-	OnCIDRAdded func(network string, availableIPs int)
+	events      *EventBus
+	OnCIDRAdded func(network string, ipFamily store.IPFamily, availableIPs int)
 }
 
 // WatcherConfig holds the configuration for the Watcher.
@@ -63,9 +61,14 @@ type WatcherConfig struct {
 	Logger      logr.Logger
 	NNCClient   nncclientset.Interface
 	NNCInformer nncinformers.NodeNetworkConfigInformer
+	// Sources are the CIDR sources of the node. If empty, the blocks come
+	// from the NodeNetworkConfig of NNCClient and NNCInformer.
+	Sources     []CIDRSource
 	Store       *store.Store
 	NodeName    string
-	OnCIDRAdded func(network string, availableIPs int)
+	OnCIDRAdded func(network string, ipFamily store.IPFamily, availableIPs int)
+	// Events is optional and receives the added and deleted CIDR blocks.
+	Events *EventBus
 	// RateLimiter is optional and primarily used to override the queue's rate limiter for testing.
 	RateLimiter workqueue.TypedRateLimiter[string]
 }
@@ -80,58 +83,27 @@ func NewWatcher(cfg WatcherConfig) *Watcher {
 		Name: "metis-nnc-watcher",
 	})
 
-	var nncLister nnclisters.NodeNetworkConfigLister
+	sources := cfg.Sources
+	if len(sources) == 0 {
+		sources = []CIDRSource{NewNNCCIDRSource(cfg.NNCClient, cfg.NNCInformer, cfg.NodeName)}
+	}
 	var nncSynced cache.InformerSynced
-	// nncInformer is is never nil. This is for UT.
+	// nncInformer is never nil unless the NodeNetworkConfig is not a CIDR source. This is also for UT.
 	if cfg.NNCInformer != nil {
-		nncLister = cfg.NNCInformer.Lister()
 		nncSynced = cfg.NNCInformer.Informer().HasSynced
 	}
 
 	w := &Watcher{
 		queue:       queue,
-		nncClient:   cfg.NNCClient,
+		sources:     sources,
 		nodeName:    cfg.NodeName,
-		nncLister:   nncLister,
 		nncSynced:   nncSynced,
 		store:       cfg.Store,
 		logger:      cfg.Logger,
+		events:      cfg.Events,
 		OnCIDRAdded: cfg.OnCIDRAdded,
 	}
 	w.syncHandler = w.syncCIDR
-
-	// nncInformer is never nil. This is for UT.
-	if cfg.NNCInformer != nil {
-		cfg.NNCInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj any) {
-				nnc, ok := obj.(*nncv1.NodeNetworkConfig)
-				if !ok {
-					return
-				}
-				if nnc.Name == cfg.NodeName {
-					for _, alloc := range nnc.Spec.Allocations {
-						w.queue.Add(alloc.Network)
-					}
-				}
-			},
-			UpdateFunc: func(oldObj, newObj any) {
-				oldNNC, ok1 := oldObj.(*nncv1.NodeNetworkConfig)
-				newNNC, ok2 := newObj.(*nncv1.NodeNetworkConfig)
-				if !ok1 || !ok2 {
-					return
-				}
-				if oldNNC.ResourceVersion == newNNC.ResourceVersion {
-					return
-				}
-				if newNNC.Name == cfg.NodeName {
-					for _, alloc := range newNNC.Spec.Allocations {
-						w.queue.Add(alloc.Network)
-					}
-				}
-			},
-		})
-	}
-
 	return w
 }
 
@@ -148,6 +120,10 @@ func (w *Watcher) Run(ctx context.Context, workers int) {
 		}
 	}
 
+	for _, source := range w.sources {
+		go source.Run(ctx, w.queue.Add)
+	}
+
 	for i := 0; i < workers; i++ {
 		// The 1s period acts as a restart backoff/heartbeat delay to recover from crashes
 		// rather than a polling interval for the worker (which runs continuously and blocks on the queue).
@@ -187,18 +163,26 @@ func (w *Watcher) processNextWorkItem(ctx context.Context) bool {
 }
 
 func (w *Watcher) syncCIDR(ctx context.Context, network string) error {
-	w.logger.V(4).Info("Daemon watcher starting synchronization: reconciling CIDR blocks with NodeNetworkConfig status", "node", w.nodeName, "network", network)
+	w.logger.V(4).Info("Daemon watcher starting synchronization: reconciling CIDR blocks with the CIDR sources", "node", w.nodeName, "network", network)
 
-	nnc, err := getNodeNetworkConfig(ctx, w.nncLister, w.nncClient, w.nodeName)
-	if err != nil {
-		return err
+	var cidrs []SourceCIDR
+	for _, source := range w.sources {
+		sourceCIDRs, err := source.CIDRs(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get CIDR blocks from source %s: %w", source.Name(), err)
+		}
+		for _, cidr := range sourceCIDRs {
+			if cidr.Network == network {
+				cidrs = append(cidrs, cidr)
+			}
+		}
 	}
 
-	if err := w.addCIDR(ctx, nnc, network); err != nil {
+	if err := w.addCIDR(ctx, cidrs, network); err != nil {
 		return err
 	}
 
-	if err := w.maybeDeleteCIDRs(ctx, nnc, network); err != nil {
+	if err := w.maybeDeleteCIDRs(ctx, cidrs, network); err != nil {
 		return err
 	}
 
@@ -206,29 +190,25 @@ func (w *Watcher) syncCIDR(ctx context.Context, network string) error {
 	return nil
 }
 
-func (w *Watcher) addCIDR(ctx context.Context, nnc *nncv1.NodeNetworkConfig, network string) error {
-	for _, podCIDR := range nnc.Status.PodCIDRs {
-		if podCIDR.Network != network {
-			continue
-		}
-		if podCIDR.Condition != nil && podCIDR.Condition.Status != metav1.ConditionTrue {
+func (w *Watcher) addCIDR(ctx context.Context, cidrs []SourceCIDR, network string) error {
+	for _, podCIDR := range cidrs {
+		if !podCIDR.Ready {
 			w.logger.V(4).Info("PodCIDR not ready, skipping", "cidr", podCIDR.CIDR, "network", podCIDR.Network)
 			continue
 		}
-
 		prefix, err := netip.ParsePrefix(podCIDR.CIDR)
 		if err != nil {
 			w.logger.Error(err, "failed to parse CIDR", "cidr", podCIDR.CIDR)
 			continue
 		}
 
+		// IPv6 blocks are only registered here; their addresses are populated
+		// lazily by the store on allocation, so even a /64 is never fully stored.
+		ipFamily := store.IPv4
 		if prefix.Addr().Is6() {
-			w.logger.V(4).Info("Ignoring IPv6 CIDR, not supported in dynamic allocation path", "cidr", podCIDR.CIDR)
-			continue
+			ipFamily = store.IPv6
 		}
-
-		bits := prefix.Bits()
-		availableIPs := 1 << (32 - bits)
+		availableIPs := store.PrefixSize(prefix)
 
 		_, exists, err := w.store.GetCIDRBlock(ctx, podCIDR.CIDR, network)
 		if err != nil {
@@ -239,11 +219,12 @@ func (w *Watcher) addCIDR(ctx context.Context, nnc *nncv1.NodeNetworkConfig, net
 			continue
 		}
 
-		w.logger.Info("Watcher adding new ready podCIDR to local DB", "cidr", podCIDR.CIDR, "network", podCIDR.Network, "availableIPs", availableIPs)
+		w.logger.Info("Watcher adding new ready podCIDR to local DB", "cidr", podCIDR.CIDR, "network", podCIDR.Network, "ipFamily", ipFamily, "availableIPs", availableIPs)
 		err = w.store.AddCIDR(ctx, podCIDR.Network, podCIDR.CIDR)
 		if err == nil {
+			w.events.Publish(WatchEvent{Type: WatchEventBlockAdded, Network: podCIDR.Network, IPFamily: ipFamily, CIDR: podCIDR.CIDR})
 			if w.OnCIDRAdded != nil {
-				w.OnCIDRAdded(podCIDR.Network, availableIPs)
+				w.OnCIDRAdded(podCIDR.Network, ipFamily, availableIPs)
 			}
 		} else {
 			if errors.Is(err, store.ErrCidrAlreadyExists) {
@@ -256,18 +237,20 @@ func (w *Watcher) addCIDR(ctx context.Context, nnc *nncv1.NodeNetworkConfig, net
 	return nil
 }
 
-func (w *Watcher) maybeDeleteCIDRs(ctx context.Context, nnc *nncv1.NodeNetworkConfig, network string) error {
-	toBeDeletedBlocks, err := w.store.GetDeletingCIDRBlocks(ctx, network, store.IPv4)
-	if err != nil {
-		return fmt.Errorf("failed to query deleting cidr blocks: %w", err)
+func (w *Watcher) maybeDeleteCIDRs(ctx context.Context, cidrs []SourceCIDR, network string) error {
+	var toBeDeletedBlocks []store.CIDRBlock
+	for _, ipFamily := range []store.IPFamily{store.IPv4, store.IPv6} {
+		blocks, err := w.store.GetDeletingCIDRBlocks(ctx, network, ipFamily)
+		if err != nil {
+			return fmt.Errorf("failed to query deleting %s cidr blocks: %w", ipFamily, err)
+		}
+		toBeDeletedBlocks = append(toBeDeletedBlocks, blocks...)
 	}
 
-	// Create a map for quick lookup of CIDRs in NNC status for the current network
+	// Create a map for quick lookup of the CIDRs the sources assign for the current network
 	statusCIDRs := map[string]bool{}
-	for _, podCIDR := range nnc.Status.PodCIDRs {
-		if podCIDR.Network == network {
-			statusCIDRs[podCIDR.CIDR] = true
-		}
+	for _, podCIDR := range cidrs {
+		statusCIDRs[podCIDR.CIDR] = true
 	}
 
 	var blocksToDelete []store.CIDRBlock
@@ -278,11 +261,12 @@ func (w *Watcher) maybeDeleteCIDRs(ctx context.Context, nnc *nncv1.NodeNetworkCo
 	}
 
 	for _, block := range blocksToDelete {
-		err = w.store.DeleteCIDRBlock(ctx, block.ID)
+		err := w.store.DeleteCIDRBlock(ctx, block.ID)
 		if err != nil {
 			return fmt.Errorf("failed to delete cidr block %d from store: %w", block.ID, err)
 		}
-		w.logger.Info("Watcher deleted CIDR block from local DB as GCE has released it", "cidrBlockID", block.ID, "cidr", block.CIDR, "network", network)
+		w.logger.Info("Watcher deleted CIDR block from local DB as no CIDR source assigns it anymore", "cidrBlockID", block.ID, "cidr", block.CIDR, "network", network)
+		w.events.Publish(WatchEvent{Type: WatchEventBlockDeleted, Network: network, IPFamily: prefixFamily(block.CIDR), CIDR: block.CIDR})
 	}
 
 	return nil
@@ -21,6 +21,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net/netip"
 	"path/filepath"
 	"sync"
 	"testing"
@@ -280,6 +281,21 @@ func TestWatcher_SyncCIDR(t *testing.T) {
 			expectedExists:      true,
 			expectedOnCIDRAdded: true,
 		},
+		{
+			desc:   "Add new IPv6 CIDR from NNC status",
+			blocks: nil,
+			mockNNC: &nncv1.NodeNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+				Status: nncv1.NodeNetworkConfigStatus{
+					PodCIDRs: []nncv1.PodCIDR{
+						{CIDR: "2001:db8:0:1::/64", Network: network, Condition: &metav1.Condition{Status: metav1.ConditionTrue}},
+					},
+				},
+			},
+			cidrToCheck:         "2001:db8:0:1::/64",
+			expectedExists:      true,
+			expectedOnCIDRAdded: true,
+		},
 		{
 			desc:   "Ignore unready CIDR from NNC status",
 			blocks: nil,
@@ -316,6 +332,24 @@ func TestWatcher_SyncCIDR(t *testing.T) {
 			expectedExists:      false,
 			expectedOnCIDRAdded: false,
 		},
+		{
+			desc: "Cleanup deleting IPv6 CIDR not in NNC status",
+			blocks: []struct {
+				cidr  string
+				state string
+			}{
+				{"2001:db8:0:1::/120", "Deleting"},
+			},
+			mockNNC: &nncv1.NodeNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+				Status: nncv1.NodeNetworkConfigStatus{
+					PodCIDRs: []nncv1.PodCIDR{}, // Empty status
+				},
+			},
+			cidrToCheck:         "2001:db8:0:1::/120",
+			expectedExists:      false,
+			expectedOnCIDRAdded: false,
+		},
 		{
 			desc:                "API server error on Get",
 			injectErr:           fmt.Errorf("api server error"),
@@ -388,7 +422,11 @@ func TestWatcher_SyncCIDR(t *testing.T) {
 					t.Fatalf("Failed to add CIDR: %v", err)
 				}
 				if b.state == "Deleting" {
-					blocks, err := storeInstance.GetReadyCIDRBlocksSorted(context.Background(), network, store.IPv4)
+					ipFamily := store.IPv4
+					if netip.MustParsePrefix(b.cidr).Addr().Is6() {
+						ipFamily = store.IPv6
+					}
+					blocks, err := storeInstance.GetReadyCIDRBlocksSorted(context.Background(), network, ipFamily)
 					if err != nil || len(blocks) == 0 {
 						t.Fatalf("Failed to get block ID: %v", err)
 					}
@@ -416,7 +454,7 @@ func TestWatcher_SyncCIDR(t *testing.T) {
 				NNCClient: mockClient,
 				Store:     storeInstance,
 				NodeName:  nodeName,
-				OnCIDRAdded: func(_ string, _ int) {
+				OnCIDRAdded: func(_ string, _ store.IPFamily, _ int) {
 					onCIDRAddedCalled = true
 				},
 			})
@@ -0,0 +1,78 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkg
+
+import (
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo details of the errors
+// returned by the daemon.
+const ErrorDomain = "metis.k8s.io"
+
+// The reasons of the google.rpc.ErrorInfo details of the errors returned by
+// the daemon. The CNI plugin maps them to CNI error codes.
+const (
+	// ReasonExhausted is returned if no IP is free and no capacity was added
+	// before the request timed out.
+	ReasonExhausted = "EXHAUSTED"
+	// ReasonStoreBusy is returned if the store failed or stayed locked by
+	// other writers. The request can be retried.
+	ReasonStoreBusy = "STORE_BUSY"
+	// ReasonInvalidCIDR is returned if a CIDR of the request cannot be parsed.
+	ReasonInvalidCIDR = "INVALID_CIDR"
+	// ReasonInvalidArgument is returned if a required field of the request is
+	// missing or invalid.
+	ReasonInvalidArgument = "INVALID_ARGUMENT"
+	// ReasonAddressUnavailable is returned if the address reserved for or
+	// requested by the pod is held by another pod.
+	ReasonAddressUnavailable = "ADDRESS_UNAVAILABLE"
+	// ReasonNotAllocated is returned if the pod interface holds no IP.
+	ReasonNotAllocated = "NOT_ALLOCATED"
+	// ReasonInternal is returned if the daemon failed unexpectedly.
+	ReasonInternal = "INTERNAL"
+)
+
+// StatusErrorf returns a gRPC status error with a google.rpc.ErrorInfo detail
+// of the reason in ErrorDomain.
+func StatusErrorf(code codes.Code, reason, format string, args ...any) error {
+	st := status.New(code, fmt.Sprintf(format, args...))
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: ErrorDomain})
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// ErrorReason returns the reason of the google.rpc.ErrorInfo detail in
+// ErrorDomain of a gRPC status error, or an empty string if there is none.
+func ErrorReason(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return ""
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == ErrorDomain {
+			return info.Reason
+		}
+	}
+	return ""
+}
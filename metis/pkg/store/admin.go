@@ -18,80 +18,228 @@ package store
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 )
 
-// AdminListCIDRBlocks fetches cidr_blocks records natively formatted as
-// generic text output, optionally filtered.
-func (s *Store) AdminListCIDRBlocks(ctx context.Context, filter string) ([]string, [][]string, error) {
-	return s.adminQueryTable(ctx, "cidr_blocks", filter)
+const (
+	// DefaultAdminPageSize is the number of records returned by the admin
+	// list queries when no limit is requested.
+	DefaultAdminPageSize = 500
+	// MaxAdminPageSize caps the number of records returned by a single admin list query.
+	MaxAdminPageSize = 5000
+)
+
+// AdminCIDRBlockFilter selects the cidr_blocks records returned by
+// AdminListCIDRBlocks. Empty fields match all records.
+type AdminCIDRBlockFilter struct {
+	Network  string
+	IPFamily IPFamily
+	State    CidrBlockState
 }
 
-// AdminListIPAddresses fetches ip_addresses records natively formatted as
-// generic text output, optionally filtered.
-func (s *Store) AdminListIPAddresses(ctx context.Context, filter string) ([]string, [][]string, error) {
-	return s.adminQueryTable(ctx, "ip_addresses", filter)
+// AdminIPAddressFilter selects the ip_addresses records returned by
+// AdminListIPAddresses. Empty fields match all records.
+type AdminIPAddressFilter struct {
+	Network      string
+	IPFamily     IPFamily
+	State        CidrBlockState
+	ContainerID  string
+	PodNamespace string
+	PodName      string
+	// AllocatedOnly restricts the result to IPs currently assigned to a pod.
+	AllocatedOnly bool
 }
 
-// adminQueryTable fetches the entire contents of a given table natively
-// using SQLite column mapping.
-func (s *Store) adminQueryTable(ctx context.Context, tableName string, filter string) ([]string, [][]string, error) {
-	query := fmt.Sprintf("SELECT * FROM %s", tableName)
-	if filter != "" {
-		query = fmt.Sprintf("SELECT * FROM %s WHERE %s", tableName, filter)
-	}
+// AdminPage selects a page of records ordered by ID.
+type AdminPage struct {
+	// AfterID only returns records with an ID greater than AfterID.
+	AfterID int64
+	// Limit is the maximum number of records to return. Values <= 0 select
+	// DefaultAdminPageSize, and values above MaxAdminPageSize are capped.
+	Limit int
+}
+
+// AdminCIDRBlock is a record of the cidr_blocks table.
+type AdminCIDRBlock struct {
+	ID           int64
+	CIDR         string
+	Network      string
+	IPFamily     IPFamily
+	TotalIPs     int64
+	AllocatedIPs int64
+	State        CidrBlockState
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
 
-	rows, err := s.db.QueryContext(ctx, query)
+// AdminIPAddress is a record of the ip_addresses table joined with the
+// network and IP family of its CIDR block.
+type AdminIPAddress struct {
+	ID            int64
+	Address       string
+	CIDRBlockID   int64
+	Network       string
+	IPFamily      IPFamily
+	ContainerID   string
+	PodName       string
+	PodNamespace  string
+	InterfaceName string
+	IsAllocated   bool
+	// ReleaseAt, AllocatedAt and UpdatedAt are zero if unset.
+	ReleaseAt   time.Time
+	AllocatedAt time.Time
+	UpdatedAt   time.Time
+}
+
+// AdminListCIDRBlocks fetches a page of cidr_blocks records matching filter.
+// The returned ID is the AfterID of the next page, or 0 if there are no more records.
+func (s *Store) AdminListCIDRBlocks(ctx context.Context, filter AdminCIDRBlockFilter, page AdminPage) ([]AdminCIDRBlock, int64, error) {
+	var where conditions
+	where.add("c.id > ?", page.AfterID)
+	where.addIfSet("c.network = ?", filter.Network)
+	where.addIfSet("c.ip_family = ?", string(filter.IPFamily))
+	where.addIfSet("c.state = ?", string(filter.State))
+
+	limit := page.limit()
+	query := `
+		SELECT c.id, c.cidr, c.network, c.ip_family, c.total_ips, c.allocated_ips, c.state, c.created_at, c.updated_at
+		FROM cidr_blocks c
+		WHERE ` + where.String() + `
+		ORDER BY c.id
+		LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, append(where.args, limit+1)...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to query table %s: %w", tableName, err)
+		return nil, 0, fmt.Errorf("failed to query cidr_blocks: %w", err)
 	}
 	defer rows.Close()
 
-	cols, err := rows.Columns()
+	var result []AdminCIDRBlock
+	for rows.Next() {
+		var r AdminCIDRBlock
+		var createdAt, updatedAt sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.CIDR, &r.Network, &r.IPFamily, &r.TotalIPs, &r.AllocatedIPs, &r.State, &createdAt, &updatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+		r.CreatedAt = unixMilliOrZero(createdAt)
+		r.UpdatedAt = unixMilliOrZero(updatedAt)
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	if len(result) > limit {
+		result = result[:limit]
+		return result, result[limit-1].ID, nil
+	}
+	return result, 0, nil
+}
+
+// AdminListIPAddresses fetches a page of ip_addresses records matching filter.
+// The returned ID is the AfterID of the next page, or 0 if there are no more records.
+func (s *Store) AdminListIPAddresses(ctx context.Context, filter AdminIPAddressFilter, page AdminPage) ([]AdminIPAddress, int64, error) {
+	var where conditions
+	where.add("i.id > ?", page.AfterID)
+	where.addIfSet("c.network = ?", filter.Network)
+	where.addIfSet("c.ip_family = ?", string(filter.IPFamily))
+	where.addIfSet("c.state = ?", string(filter.State))
+	where.addIfSet("i.container_id = ?", filter.ContainerID)
+	where.addIfSet("i.pod_namespace = ?", filter.PodNamespace)
+	where.addIfSet("i.pod_name = ?", filter.PodName)
+	if filter.AllocatedOnly {
+		where.add("i.is_allocated = TRUE")
+	}
+
+	limit := page.limit()
+	query := `
+		SELECT i.id, i.address, i.cidr_block_id, c.network, c.ip_family, i.container_id, i.pod_name, i.pod_namespace,
+			i.interface_name, i.is_allocated, i.release_at, i.allocated_at, i.updated_at
+		FROM ip_addresses i
+		JOIN cidr_blocks c ON i.cidr_block_id = c.id
+		WHERE ` + where.String() + `
+		ORDER BY i.id
+		LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, append(where.args, limit+1)...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get columns: %w", err)
+		return nil, 0, fmt.Errorf("failed to query ip_addresses: %w", err)
 	}
+	defer rows.Close()
 
-	var results [][]string
+	var result []AdminIPAddress
 	for rows.Next() {
-		columns := make([]any, len(cols))
-		columnPointers := make([]any, len(cols))
-		for i := range columns {
-			columnPointers[i] = &columns[i]
+		var r AdminIPAddress
+		var containerID, podName, podNamespace, interfaceName sql.NullString
+		var isAllocated sql.NullBool
+		var releaseAt, allocatedAt, updatedAt sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.Address, &r.CIDRBlockID, &r.Network, &r.IPFamily, &containerID, &podName, &podNamespace,
+			&interfaceName, &isAllocated, &releaseAt, &allocatedAt, &updatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan row: %w", err)
 		}
+		r.ContainerID = containerID.String
+		r.PodName = podName.String
+		r.PodNamespace = podNamespace.String
+		r.InterfaceName = interfaceName.String
+		r.IsAllocated = isAllocated.Bool
+		r.ReleaseAt = unixMilliOrZero(releaseAt)
+		r.AllocatedAt = unixMilliOrZero(allocatedAt)
+		r.UpdatedAt = unixMilliOrZero(updatedAt)
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate rows: %w", err)
+	}
 
-		if err := rows.Scan(columnPointers...); err != nil {
-			return nil, nil, fmt.Errorf("failed to scan row: %w", err)
-		}
+	if len(result) > limit {
+		result = result[:limit]
+		return result, result[limit-1].ID, nil
+	}
+	return result, 0, nil
+}
 
-		var rowData []string
-		for i, col := range columns {
-			if col == nil {
-				rowData = append(rowData, "NULL")
-			} else {
-				colName := cols[i]
-				switch v := col.(type) {
-				case []byte:
-					rowData = append(rowData, string(v))
-				case time.Time:
-					rowData = append(rowData, v.Format(time.RFC3339))
-				case int64:
-					if colName == "created_at" || colName == "updated_at" || colName == "release_at" || colName == "allocated_at" {
-						t := time.UnixMilli(v)
-						rowData = append(rowData, t.Format(time.RFC3339))
-					} else {
-						rowData = append(rowData, fmt.Sprintf("%v", v))
-					}
-				default:
-					rowData = append(rowData, fmt.Sprintf("%v", v))
-				}
-			}
-		}
-		results = append(results, rowData)
+func (p AdminPage) limit() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultAdminPageSize
+	case p.Limit > MaxAdminPageSize:
+		return MaxAdminPageSize
+	default:
+		return p.Limit
 	}
-	if err := rows.Err(); err != nil {
-		return nil, nil, fmt.Errorf("failed to iterate rows: %w", err)
+}
+
+// conditions builds a parameterized SQL WHERE clause. Only the constant
+// clause strings become part of the query, values are always bound as arguments.
+type conditions struct {
+	clauses []string
+	args    []any
+}
+
+func (c *conditions) add(clause string, args ...any) {
+	c.clauses = append(c.clauses, clause)
+	c.args = append(c.args, args...)
+}
+
+// addIfSet adds clause bound to value unless value is empty.
+func (c *conditions) addIfSet(clause string, value string) {
+	if value != "" {
+		c.add(clause, value)
+	}
+}
+
+func (c *conditions) String() string {
+	if len(c.clauses) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(c.clauses, " AND ")
+}
+
+// unixMilliOrZero converts a nullable Unix epoch timestamp in milliseconds to a time.Time.
+func unixMilliOrZero(v sql.NullInt64) time.Time {
+	if !v.Valid {
+		return time.Time{}
 	}
-	return cols, results, nil
+	return time.UnixMilli(v.Int64)
 }
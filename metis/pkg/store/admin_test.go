@@ -0,0 +1,221 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestStore_AdminListCIDRBlocks(t *testing.T) {
+	ctx := context.Background()
+	s := setupStoreWithCIDRs(t, "net-a", "10.0.0.0/28", "10.0.1.0/28", "2001:db8::/120")
+	if err := s.AddCIDR(ctx, "net-b", "10.1.0.0/28"); err != nil {
+		t.Fatalf("AddCIDR failed: %v", err)
+	}
+	drainID, _, err := s.GetCIDRBlock(ctx, "10.0.1.0/28", "net-a")
+	if err != nil {
+		t.Fatalf("GetCIDRBlock failed: %v", err)
+	}
+	if err := s.DrainCIDRBlock(ctx, drainID); err != nil {
+		t.Fatalf("DrainCIDRBlock failed: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		filter    AdminCIDRBlockFilter
+		wantCIDRs []string
+	}{
+		{
+			name:      "no filter",
+			wantCIDRs: []string{"10.0.0.0/28", "10.0.1.0/28", "2001:db8::/120", "10.1.0.0/28"},
+		},
+		{
+			name:      "network",
+			filter:    AdminCIDRBlockFilter{Network: "net-b"},
+			wantCIDRs: []string{"10.1.0.0/28"},
+		},
+		{
+			name:      "network and ip family",
+			filter:    AdminCIDRBlockFilter{Network: "net-a", IPFamily: IPv6},
+			wantCIDRs: []string{"2001:db8::/120"},
+		},
+		{
+			name:      "state",
+			filter:    AdminCIDRBlockFilter{State: StateDraining},
+			wantCIDRs: []string{"10.0.1.0/28"},
+		},
+		{
+			name:   "values are not interpreted as SQL",
+			filter: AdminCIDRBlockFilter{Network: "net-a' OR '1'='1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			blocks, nextID, err := s.AdminListCIDRBlocks(ctx, tc.filter, AdminPage{})
+			if err != nil {
+				t.Fatalf("AdminListCIDRBlocks failed: %v", err)
+			}
+			if nextID != 0 {
+				t.Errorf("Expected no next page, got next ID %d", nextID)
+			}
+			var gotCIDRs []string
+			for _, b := range blocks {
+				gotCIDRs = append(gotCIDRs, b.CIDR)
+			}
+			if fmt.Sprint(gotCIDRs) != fmt.Sprint(tc.wantCIDRs) {
+				t.Errorf("Expected CIDRs %v, got %v", tc.wantCIDRs, gotCIDRs)
+			}
+		})
+	}
+
+	blocks, _, err := s.AdminListCIDRBlocks(ctx, AdminCIDRBlockFilter{Network: "net-b"}, AdminPage{})
+	if err != nil {
+		t.Fatalf("AdminListCIDRBlocks failed: %v", err)
+	}
+	b := blocks[0]
+	if b.IPFamily != IPv4 || b.State != StateReady || b.TotalIPs != 16 || b.AllocatedIPs != 3 {
+		t.Errorf("Unexpected CIDR block record: %+v", b)
+	}
+	if b.CreatedAt.IsZero() || b.UpdatedAt.IsZero() {
+		t.Errorf("Expected timestamps to be set, got %+v", b)
+	}
+}
+
+func TestStore_AdminListIPAddresses(t *testing.T) {
+	ctx := context.Background()
+	s := setupStoreWithCIDRs(t, "net-a", "10.0.0.0/28", "2001:db8::/120")
+
+	for i, params := range []AllocateIPParams{
+		{Network: "net-a", InterfaceName: "eth0", ContainerID: "c1", IPFamily: IPv4, PodName: "pod-1", PodNamespace: "ns-1"},
+		{Network: "net-a", InterfaceName: "eth0", ContainerID: "c1", IPFamily: IPv6, PodName: "pod-1", PodNamespace: "ns-1"},
+		{Network: "net-a", InterfaceName: "eth0", ContainerID: "c2", IPFamily: IPv4, PodName: "pod-2", PodNamespace: "ns-2"},
+	} {
+		if _, _, err := s.AllocateIP(ctx, params); err != nil {
+			t.Fatalf("AllocateIP %d failed: %v", i, err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		filter        AdminIPAddressFilter
+		wantAddresses []string
+	}{
+		{
+			name:          "container id",
+			filter:        AdminIPAddressFilter{ContainerID: "c1"},
+			wantAddresses: []string{"10.0.0.2", "2001:db8::"},
+		},
+		{
+			name:          "pod namespace and name",
+			filter:        AdminIPAddressFilter{PodNamespace: "ns-2", PodName: "pod-2"},
+			wantAddresses: []string{"10.0.0.3"},
+		},
+		{
+			// The first two and the last address of the first block are reserved.
+			name:          "allocated ipv4 only",
+			filter:        AdminIPAddressFilter{Network: "net-a", IPFamily: IPv4, AllocatedOnly: true},
+			wantAddresses: []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.15"},
+		},
+		{
+			name:   "unknown network",
+			filter: AdminIPAddressFilter{Network: "net-b", AllocatedOnly: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			addresses, _, err := s.AdminListIPAddresses(ctx, tc.filter, AdminPage{})
+			if err != nil {
+				t.Fatalf("AdminListIPAddresses failed: %v", err)
+			}
+			var got []string
+			for _, a := range addresses {
+				got = append(got, a.Address)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.wantAddresses) {
+				t.Errorf("Expected addresses %v, got %v", tc.wantAddresses, got)
+			}
+		})
+	}
+
+	addresses, _, err := s.AdminListIPAddresses(ctx, AdminIPAddressFilter{ContainerID: "c2"}, AdminPage{})
+	if err != nil {
+		t.Fatalf("AdminListIPAddresses failed: %v", err)
+	}
+	a := addresses[0]
+	if a.Network != "net-a" || a.IPFamily != IPv4 || a.InterfaceName != "eth0" || !a.IsAllocated || a.AllocatedAt.IsZero() || !a.ReleaseAt.IsZero() {
+		t.Errorf("Unexpected IP address record: %+v", a)
+	}
+}
+
+func TestStore_AdminListIPAddresses_Pagination(t *testing.T) {
+	ctx := context.Background()
+	// A /28 populates 16 addresses.
+	s := setupStoreWithCIDRs(t, "net-a", "10.0.0.0/28")
+
+	var got []string
+	page := AdminPage{Limit: 5}
+	for pages := 0; ; pages++ {
+		if pages > 4 {
+			t.Fatal("Pagination did not terminate")
+		}
+		addresses, nextID, err := s.AdminListIPAddresses(ctx, AdminIPAddressFilter{}, page)
+		if err != nil {
+			t.Fatalf("AdminListIPAddresses failed: %v", err)
+		}
+		if len(addresses) > page.Limit {
+			t.Fatalf("Expected at most %d addresses, got %d", page.Limit, len(addresses))
+		}
+		for _, a := range addresses {
+			got = append(got, a.Address)
+		}
+		if nextID == 0 {
+			break
+		}
+		page.AfterID = nextID
+	}
+
+	if len(got) != 16 {
+		t.Fatalf("Expected 16 addresses across all pages, got %d: %v", len(got), got)
+	}
+	seen := map[string]bool{}
+	for _, a := range got {
+		if seen[a] {
+			t.Errorf("Address %s returned on more than one page", a)
+		}
+		seen[a] = true
+	}
+}
+
+func TestAdminPage_Limit(t *testing.T) {
+	for _, tc := range []struct {
+		limit int
+		want  int
+	}{
+		{limit: 0, want: DefaultAdminPageSize},
+		{limit: -1, want: DefaultAdminPageSize},
+		{limit: 10, want: 10},
+		{limit: MaxAdminPageSize + 1, want: MaxAdminPageSize},
+	} {
+		if got := (AdminPage{Limit: tc.limit}).limit(); got != tc.want {
+			t.Errorf("AdminPage{Limit: %d}.limit() = %d, want %d", tc.limit, got, tc.want)
+		}
+	}
+}
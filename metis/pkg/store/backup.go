@@ -0,0 +1,272 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/mattn/go-sqlite3"
+)
+
+// backupRetryInterval is how long a backup waits before retrying a step that
+// could not lock the source or destination database.
+const backupRetryInterval = 10 * time.Millisecond
+
+// Backup writes a consistent snapshot of the database to destPath using the
+// SQLite online backup API, while the store remains usable. The snapshot is
+// written to a temporary file first, so that destPath is either the previous
+// file or a complete snapshot.
+func (s *Store) Backup(ctx context.Context, destPath string) error {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmpPath := destPath + ".tmp"
+	if err := removeDBFiles(tmpPath); err != nil {
+		return err
+	}
+
+	dest, err := sql.Open("sqlite3", tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot %s: %w", tmpPath, err)
+	}
+	err = backupDB(ctx, dest, s.db)
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = syncFile(tmpPath)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, destPath)
+	}
+	if err != nil {
+		_ = removeDBFiles(tmpPath)
+		return fmt.Errorf("failed to write snapshot %s: %w", destPath, err)
+	}
+	return nil
+}
+
+// Restore replaces the content of the database with the snapshot at srcPath
+// using the SQLite online backup API, and migrates it to the current schema.
+// The snapshot is checked with CheckIntegrity first. Other operations of the
+// store wait for the restore to complete.
+func (s *Store) Restore(ctx context.Context, srcPath string) error {
+	if err := CheckIntegrity(ctx, srcPath); err != nil {
+		return err
+	}
+	src, err := openReadOnly(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := backupDB(ctx, s.db, src); err != nil {
+		return fmt.Errorf("failed to restore snapshot %s: %w", srcPath, err)
+	}
+	s.log.Info("Restored database from snapshot", "path", s.dbPath, "snapshot", srcPath)
+	return s.initSchema(ctx)
+}
+
+// CheckIntegrity runs PRAGMA integrity_check on the database file at path,
+// and verifies that its schema is not newer than supported. It returns an
+// error wrapping ErrIntegrityCheckFailed if the file is corrupted.
+func CheckIntegrity(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("failed to check database %s: %w", path, err)
+	}
+	db, err := openReadOnly(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrIntegrityCheckFailed, path, err)
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		if problem != "ok" {
+			problems = append(problems, problem)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrIntegrityCheckFailed, path, err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s: %s", ErrIntegrityCheckFailed, path, strings.Join(problems, "; "))
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to check schema version: %w", err)
+	}
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("%w: %s is at version %d, this binary supports up to version %d", ErrSchemaTooNew, path, version, len(migrations))
+	}
+	return nil
+}
+
+// RestoreFile replaces the database file at dbPath, which must not be open,
+// with a copy of the snapshot at srcPath. The previous database file, if any,
+// is kept next to it with a ".corrupt-<timestamp>" suffix for inspection.
+func RestoreFile(ctx context.Context, log logr.Logger, srcPath, dbPath string) error {
+	if err := CheckIntegrity(ctx, srcPath); err != nil {
+		return err
+	}
+	corruptPath, err := MoveAside(dbPath)
+	if err != nil {
+		return err
+	}
+	if corruptPath != "" {
+		log.Info("Moved aside previous database", "path", dbPath, "movedTo", corruptPath)
+	}
+
+	src, err := openReadOnly(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dest, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database %s: %w", dbPath, err)
+	}
+	err = backupDB(ctx, dest, src)
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot %s to %s: %w", srcPath, dbPath, err)
+	}
+	log.Info("Restored database from snapshot", "path", dbPath, "snapshot", srcPath)
+	return nil
+}
+
+// MoveAside renames the database file at dbPath, which must not be open, and
+// its WAL and shared memory files with a ".corrupt-<timestamp>" suffix. It
+// returns the new path of the database file, or an empty path if there is
+// no database file.
+func MoveAside(dbPath string) (string, error) {
+	if _, err := os.Stat(dbPath); errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to check database %s: %w", dbPath, err)
+	}
+	corruptPath := fmt.Sprintf("%s.corrupt-%s", dbPath, time.Now().UTC().Format("20060102T150405Z"))
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Rename(dbPath+suffix, corruptPath+suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to move aside database file %s: %w", dbPath+suffix, err)
+		}
+	}
+	return corruptPath, nil
+}
+
+// backupDB copies the main database of src to dest with the SQLite online
+// backup API, retrying while either database is locked until ctx is done.
+func backupDB(ctx context.Context, dest, src *sql.DB) error {
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriverConn any) error {
+		return srcConn.Raw(func(srcDriverConn any) error {
+			destSQLite, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", destDriverConn)
+			}
+			srcSQLite, ok := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", srcDriverConn)
+			}
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return fmt.Errorf("failed to start backup: %w", err)
+			}
+			for {
+				// Copying all pages in a single step keeps the snapshot
+				// consistent without restarting on concurrent writes.
+				done, err := backup.Step(-1)
+				if err != nil {
+					_ = backup.Finish()
+					return fmt.Errorf("failed to copy pages: %w", err)
+				}
+				if done {
+					return backup.Finish()
+				}
+				select {
+				case <-ctx.Done():
+					_ = backup.Finish()
+					return ctx.Err()
+				case <-time.After(backupRetryInterval):
+				}
+			}
+		})
+	})
+}
+
+// openReadOnly opens the database file at path without creating or modifying it.
+func openReadOnly(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// removeDBFiles removes a database file and its WAL and shared memory files.
+func removeDBFiles(path string) error {
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		if err := os.Remove(path + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", path+suffix, err)
+		}
+	}
+	return nil
+}
+
+// syncFile flushes the file at path to disk.
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
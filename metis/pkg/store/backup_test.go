@@ -0,0 +1,93 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestStore_BackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	network := "test-network"
+	s := setupStoreWithCIDRs(t, network, "10.0.1.0/28")
+	ip, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c1", IPFamily: IPv4})
+	if err != nil {
+		t.Fatalf("AllocateIP failed: %v", err)
+	}
+
+	snapshot := filepath.Join(t.TempDir(), "snapshots", "snapshot.sqlite")
+	if err := s.Backup(ctx, snapshot); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if err := CheckIntegrity(ctx, snapshot); err != nil {
+		t.Fatalf("CheckIntegrity of the snapshot failed: %v", err)
+	}
+
+	// Changes made after the snapshot are undone by restoring it.
+	if err := s.AddCIDR(ctx, network, "10.0.2.0/28"); err != nil {
+		t.Fatalf("AddCIDR failed: %v", err)
+	}
+	if _, err := s.ReleaseIPByOwner(ctx, network, "c1", "eth0", 0); err != nil {
+		t.Fatalf("ReleaseIPByOwner failed: %v", err)
+	}
+	if err := s.Restore(ctx, snapshot); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	blocks, err := s.GetAllCIDRBlocks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllCIDRBlocks failed: %v", err)
+	}
+	if len(blocks) != 1 || blocks[0].CIDR != "10.0.1.0/28" {
+		t.Errorf("Expected only the CIDR block of the snapshot, got %+v", blocks)
+	}
+	if err := s.CheckAllocation(ctx, network, "c1", "eth0"); err != nil {
+		t.Errorf("Expected %s to be allocated to c1 again, got %v", ip, err)
+	}
+
+	// A restored database file is usable by a new store.
+	dbPath := filepath.Join(t.TempDir(), "metis.sqlite")
+	if err := os.WriteFile(dbPath, []byte("not a database"), 0644); err != nil {
+		t.Fatalf("Failed to write corrupted database: %v", err)
+	}
+	if err := CheckIntegrity(ctx, dbPath); !errors.Is(err, ErrIntegrityCheckFailed) {
+		t.Errorf("Expected ErrIntegrityCheckFailed for a corrupted database, got %v", err)
+	}
+	if err := RestoreFile(ctx, logr.Discard(), snapshot, dbPath); err != nil {
+		t.Fatalf("RestoreFile failed: %v", err)
+	}
+	if matches, _ := filepath.Glob(dbPath + ".corrupt-*"); len(matches) != 1 {
+		t.Errorf("Expected the corrupted database to be moved aside, got %v", matches)
+	}
+	restored, err := NewStore(ctx, logr.Discard(), dbPath)
+	if err != nil {
+		t.Fatalf("NewStore of the restored database failed: %v", err)
+	}
+	defer restored.Close()
+	if err := restored.CheckAllocation(ctx, network, "c1", "eth0"); err != nil {
+		t.Errorf("Expected %s to be allocated to c1 in the restored database, got %v", ip, err)
+	}
+
+	if err := s.Restore(ctx, dbPath+".corrupt-missing"); err == nil {
+		t.Error("Expected Restore of a missing snapshot to fail")
+	}
+}
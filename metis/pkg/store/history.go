@@ -0,0 +1,273 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// HistoryEvent is the kind of an ip_history record.
+type HistoryEvent string
+
+const (
+	// HistoryAllocated is recorded when an address is assigned to a pod.
+	HistoryAllocated HistoryEvent = "Allocated"
+	// HistoryReleased is recorded when an address is released by its pod.
+	HistoryReleased HistoryEvent = "Released"
+	// HistoryCooldownExpired is recorded when the release cooldown of an
+	// address ends. It is dated when the cooldown ended, which may be before
+	// it was recorded.
+	HistoryCooldownExpired HistoryEvent = "CooldownExpired"
+	// HistoryBlockAdded is recorded when a CIDR block is added to the store.
+	HistoryBlockAdded HistoryEvent = "BlockAdded"
+	// HistoryBlockReady, HistoryBlockDraining and HistoryBlockDeleting are
+	// recorded when a CIDR block transitions to the corresponding state.
+	HistoryBlockReady    HistoryEvent = "BlockReady"
+	HistoryBlockDraining HistoryEvent = "BlockDraining"
+	HistoryBlockDeleting HistoryEvent = "BlockDeleting"
+	// HistoryBlockDeleted is recorded when a CIDR block is removed from the store.
+	HistoryBlockDeleted HistoryEvent = "BlockDeleted"
+)
+
+// HistoryRecord is a record of the ip_history table. The address and owner
+// fields are empty for CIDR block events.
+type HistoryRecord struct {
+	ID            int64
+	Event         HistoryEvent
+	Network       string
+	CIDR          string
+	IPFamily      IPFamily
+	Address       string
+	ContainerID   string
+	InterfaceName string
+	PodNamespace  string
+	PodName       string
+	// ReleaseAt is when the release cooldown of a Released address ends,
+	// zero if it was released without cooldown or for other events.
+	ReleaseAt  time.Time
+	OccurredAt time.Time
+}
+
+// HistoryFilter selects the ip_history records returned by ListHistory.
+// Empty fields match all records.
+type HistoryFilter struct {
+	Network      string
+	Address      string
+	ContainerID  string
+	PodNamespace string
+	PodName      string
+	// Since only selects the events that occurred at or after Since.
+	Since time.Time
+}
+
+// ListHistory fetches a page of ip_history records matching filter, in the
+// order they were recorded. The returned ID is the AfterID of the next page,
+// or 0 if there are no more records.
+func (s *Store) ListHistory(ctx context.Context, filter HistoryFilter, page AdminPage) ([]HistoryRecord, int64, error) {
+	if addr, err := netip.ParseAddr(filter.Address); err == nil {
+		filter.Address = addr.Unmap().String()
+	}
+
+	var where conditions
+	where.add("id > ?", page.AfterID)
+	where.addIfSet("network = ?", filter.Network)
+	where.addIfSet("address = ?", filter.Address)
+	where.addIfSet("container_id = ?", filter.ContainerID)
+	where.addIfSet("pod_namespace = ?", filter.PodNamespace)
+	where.addIfSet("pod_name = ?", filter.PodName)
+	if !filter.Since.IsZero() {
+		where.add("occurred_at >= ?", filter.Since.UnixMilli())
+	}
+
+	limit := page.limit()
+	query := `
+		SELECT id, event, network, cidr, ip_family, address, container_id, interface_name, pod_namespace, pod_name,
+			release_at, occurred_at
+		FROM ip_history
+		WHERE ` + where.String() + `
+		ORDER BY id
+		LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, append(where.args, limit+1)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query ip_history: %w", err)
+	}
+	defer rows.Close()
+
+	var result []HistoryRecord
+	for rows.Next() {
+		var r HistoryRecord
+		var releaseAt, occurredAt sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.Event, &r.Network, &r.CIDR, &r.IPFamily, &r.Address, &r.ContainerID, &r.InterfaceName,
+			&r.PodNamespace, &r.PodName, &releaseAt, &occurredAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+		r.ReleaseAt = unixMilliOrZero(releaseAt)
+		r.OccurredAt = unixMilliOrZero(occurredAt)
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	if len(result) > limit {
+		result = result[:limit]
+		return result, result[limit-1].ID, nil
+	}
+	return result, 0, nil
+}
+
+// LastScaleUps returns, for each network, when the last CIDR block was added
+// to it while an earlier block of the same IP family was recorded, i.e. the
+// last time the network was scaled up beyond its first blocks. Networks
+// without such a record in the retained ip_history are omitted.
+func (s *Store) LastScaleUps(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT h.network, MAX(h.occurred_at)
+		FROM ip_history h
+		WHERE h.event = ? AND EXISTS (
+			SELECT 1 FROM ip_history f
+			WHERE f.event = h.event AND f.network = h.network AND f.ip_family = h.ip_family AND f.id < h.id
+		)
+		GROUP BY h.network
+	`, HistoryBlockAdded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ip_history: %w", err)
+	}
+	defer rows.Close()
+
+	result := map[string]time.Time{}
+	for rows.Next() {
+		var network string
+		var occurredAt sql.NullInt64
+		if err := rows.Scan(&network, &occurredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result[network] = unixMilliOrZero(occurredAt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return result, nil
+}
+
+// ExpireReleaseCooldowns clears the release_at of the released addresses
+// whose cooldown has passed and records their CooldownExpired events. It
+// does not change which addresses can be allocated, and returns the number of
+// expired cooldowns.
+func (s *Store) ExpireReleaseCooldowns(ctx context.Context) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	nowMilli := time.Now().UTC().UnixMilli()
+	if err := recordCooldownsExpiredTx(ctx, tx, "i.is_allocated = FALSE AND i.release_at <= ?", nowMilli); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE ip_addresses
+		SET release_at = NULL
+		WHERE is_allocated = FALSE AND release_at <= ?
+	`, nowMilli)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire release cooldowns: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired rows: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return n, nil
+}
+
+// recordAddressEventTx records an Allocated or Released event of the
+// ip_addresses row id, with the owner and release_at the row has after the
+// change. The address events are written by the store rather than by
+// triggers, which would be compiled into every allocation statement.
+func recordAddressEventTx(ctx context.Context, tx *sql.Tx, event HistoryEvent, id int64) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ip_history (event, network, cidr, ip_family, address, container_id, interface_name, pod_namespace, pod_name, release_at)
+		SELECT ?, c.network, c.cidr, c.ip_family, i.address, IFNULL(i.container_id, ''), IFNULL(i.interface_name, ''),
+			IFNULL(i.pod_namespace, ''), IFNULL(i.pod_name, ''), i.release_at
+		FROM ip_addresses i
+		JOIN cidr_blocks c ON i.cidr_block_id = c.id
+		WHERE i.id = ?
+	`, event, id)
+	if err != nil {
+		return fmt.Errorf("failed to record %s event of ip %d: %w", event, id, err)
+	}
+	return nil
+}
+
+// recordCooldownsExpiredTx records a CooldownExpired event for the
+// ip_addresses rows matching where that are in release cooldown. It must be
+// called before their release_at is cleared, and dates the events when the
+// cooldowns actually ended.
+func recordCooldownsExpiredTx(ctx context.Context, tx *sql.Tx, where string, args ...any) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ip_history (event, network, cidr, ip_family, address, container_id, interface_name, pod_namespace, pod_name, occurred_at)
+		SELECT ?, c.network, c.cidr, c.ip_family, i.address, IFNULL(i.container_id, ''), IFNULL(i.interface_name, ''),
+			IFNULL(i.pod_namespace, ''), IFNULL(i.pod_name, ''), MIN(i.release_at, ?)
+		FROM ip_addresses i
+		JOIN cidr_blocks c ON i.cidr_block_id = c.id
+		WHERE i.release_at IS NOT NULL AND `+where+`
+		ORDER BY i.id
+	`, append([]any{HistoryCooldownExpired, time.Now().UTC().UnixMilli()}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to record %s events: %w", HistoryCooldownExpired, err)
+	}
+	return nil
+}
+
+// PruneHistory deletes the ip_history records that occurred more than maxAge
+// ago, and the oldest records beyond the maxRecords most recent ones. A value
+// <= 0 disables the corresponding limit. It returns the number of deleted records.
+func (s *Store) PruneHistory(ctx context.Context, maxAge time.Duration, maxRecords int) (int64, error) {
+	var clauses []string
+	var args []any
+	if maxAge > 0 {
+		clauses = append(clauses, "occurred_at < ?")
+		args = append(args, time.Now().UTC().Add(-maxAge).UnixMilli())
+	}
+	if maxRecords > 0 {
+		// IDs increase in the order the records were written, which keeps at
+		// most the maxRecords most recent records without counting them.
+		clauses = append(clauses, "id <= (SELECT MAX(id) FROM ip_history) - ?")
+		args = append(args, maxRecords)
+	}
+	if len(clauses) == 0 {
+		return 0, nil
+	}
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM ip_history WHERE "+strings.Join(clauses, " OR "), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune ip_history: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get deleted rows: %w", err)
+	}
+	return n, nil
+}
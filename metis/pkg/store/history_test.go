@@ -0,0 +1,252 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func historyEvents(records []HistoryRecord) []HistoryEvent {
+	var events []HistoryEvent
+	for _, r := range records {
+		events = append(events, r.Event)
+	}
+	return events
+}
+
+func TestStore_History(t *testing.T) {
+	ctx := context.Background()
+	network := "test-network"
+	s := setupStoreWithCIDRs(t, network, "10.0.1.0/29") // .2 to .6 are available
+
+	backdateReleaseAt := func(address string, releaseAt time.Time) {
+		t.Helper()
+		if _, err := s.db.Exec(`UPDATE ip_addresses SET release_at = ? WHERE address = ?`, releaseAt.UnixMilli(), address); err != nil {
+			t.Fatalf("Failed to backdate release_at: %v", err)
+		}
+	}
+	allocate := func(containerID, podName string) string {
+		t.Helper()
+		ip, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: containerID, IPFamily: IPv4,
+			PodNamespace: "ns", PodName: podName, RequestedAddress: "10.0.1.2"})
+		if err != nil {
+			t.Fatalf("AllocateIP for %s failed: %v", containerID, err)
+		}
+		return ip
+	}
+	release := func(containerID string) {
+		t.Helper()
+		if _, err := s.ReleaseIPByOwner(ctx, network, containerID, "eth0", time.Hour); err != nil {
+			t.Fatalf("ReleaseIPByOwner for %s failed: %v", containerID, err)
+		}
+	}
+
+	// The cooldown of the first release is expired by the daemon, the
+	// cooldown of the second one by the next regular allocation, which picks
+	// the address again as the first one of the block.
+	expiredAt := time.Now().Add(-2 * time.Hour)
+	allocate("c1", "a")
+	release("c1")
+	backdateReleaseAt("10.0.1.2", expiredAt)
+	if n, err := s.ExpireReleaseCooldowns(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected 1 expired cooldown, got %d, %v", n, err)
+	}
+	allocate("c2", "b")
+	release("c2")
+	backdateReleaseAt("10.0.1.2", expiredAt)
+	ip, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c3", IPFamily: IPv4, PodNamespace: "ns", PodName: "c"})
+	if err != nil || ip != "10.0.1.2" {
+		t.Fatalf("Expected c3 to get 10.0.1.2, got %q, %v", ip, err)
+	}
+	if n, err := s.ExpireReleaseCooldowns(ctx); err != nil || n != 0 {
+		t.Errorf("Expected no expired cooldowns, got %d, %v", n, err)
+	}
+
+	id, _, err := s.GetCIDRBlock(ctx, "10.0.1.0/29", network)
+	if err != nil {
+		t.Fatalf("GetCIDRBlock failed: %v", err)
+	}
+	if err := s.DrainCIDRBlock(ctx, id); err != nil {
+		t.Fatalf("DrainCIDRBlock failed: %v", err)
+	}
+	if err := s.DrainCIDRBlock(ctx, id); err != nil {
+		t.Fatalf("DrainCIDRBlock failed: %v", err)
+	}
+	if err := s.MarkCIDRBlockAsDeletingForTest(ctx, id); err != nil {
+		t.Fatalf("MarkCIDRBlockAsDeletingForTest failed: %v", err)
+	}
+	if err := s.DeleteCIDRBlock(ctx, id); err != nil {
+		t.Fatalf("DeleteCIDRBlock failed: %v", err)
+	}
+
+	all, next, err := s.ListHistory(ctx, HistoryFilter{}, AdminPage{})
+	if err != nil {
+		t.Fatalf("ListHistory failed: %v", err)
+	}
+	if next != 0 {
+		t.Errorf("Expected a single page, got next ID %d", next)
+	}
+	want := []HistoryEvent{
+		HistoryBlockAdded,
+		HistoryAllocated, HistoryReleased, HistoryCooldownExpired,
+		HistoryAllocated, HistoryReleased, HistoryCooldownExpired, HistoryAllocated,
+		HistoryBlockDraining, HistoryBlockDeleting, HistoryBlockDeleted,
+	}
+	if got := historyEvents(all); !slices.Equal(got, want) {
+		t.Fatalf("Expected events %v, got %v", want, got)
+	}
+	for _, r := range all {
+		if r.Network != network || r.CIDR != "10.0.1.0/29" || r.IPFamily != IPv4 || r.OccurredAt.IsZero() {
+			t.Errorf("Unexpected record: %+v", r)
+		}
+	}
+	if r := all[2]; r.PodName != "a" || r.ContainerID != "c1" || r.InterfaceName != "eth0" || r.ReleaseAt.Before(time.Now()) {
+		t.Errorf("Unexpected Released record: %+v", r)
+	}
+	for _, r := range []HistoryRecord{all[3], all[6]} {
+		if r.OccurredAt.UnixMilli() != expiredAt.UnixMilli() {
+			t.Errorf("Expected the CooldownExpired record to occur at %v, got %+v", expiredAt, r)
+		}
+	}
+
+	pod, _, err := s.ListHistory(ctx, HistoryFilter{PodNamespace: "ns", PodName: "b"}, AdminPage{})
+	if err != nil {
+		t.Fatalf("ListHistory failed: %v", err)
+	}
+	if got, want := historyEvents(pod), []HistoryEvent{HistoryAllocated, HistoryReleased, HistoryCooldownExpired}; !slices.Equal(got, want) {
+		t.Errorf("Expected events %v for pod ns/b, got %v", want, got)
+	}
+
+	page, next, err := s.ListHistory(ctx, HistoryFilter{Address: "::ffff:10.0.1.2"}, AdminPage{Limit: 5})
+	if err != nil {
+		t.Fatalf("ListHistory failed: %v", err)
+	}
+	if len(page) != 5 || next != page[4].ID {
+		t.Fatalf("Expected a first page of 5 records, got %d with next ID %d", len(page), next)
+	}
+	page, next, err = s.ListHistory(ctx, HistoryFilter{Address: "10.0.1.2"}, AdminPage{AfterID: next, Limit: 5})
+	if err != nil {
+		t.Fatalf("ListHistory failed: %v", err)
+	}
+	if len(page) != 2 || next != 0 || page[1].Event != HistoryAllocated || page[1].PodName != "c" {
+		t.Errorf("Unexpected second page with next ID %d: %+v", next, page)
+	}
+
+	recent, _, err := s.ListHistory(ctx, HistoryFilter{Since: time.Now().Add(-time.Hour)}, AdminPage{})
+	if err != nil {
+		t.Fatalf("ListHistory failed: %v", err)
+	}
+	if len(recent) != len(all)-2 {
+		t.Errorf("Expected all but the 2 CooldownExpired records in the last hour, got %v", historyEvents(recent))
+	}
+}
+
+func TestStore_HistoryOfClaimedAndUnusedIPs(t *testing.T) {
+	ctx := context.Background()
+	network := "test-network"
+	s := setupStoreWithCIDRs(t, network, "10.0.1.0/29")
+
+	params := AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c1", IPFamily: IPv4, PodNamespace: "ns", PodName: "a"}
+	if _, _, err := s.AllocateIP(ctx, params); err != nil {
+		t.Fatalf("AllocateIP failed: %v", err)
+	}
+	if _, err := s.ReleaseIPByOwner(ctx, network, "c1", "eth0", time.Hour); err != nil {
+		t.Fatalf("ReleaseIPByOwner failed: %v", err)
+	}
+	// ClaimIP ignores the release cooldown, which ends when it is claimed.
+	params.ContainerID, params.PodName = "c2", "b"
+	if _, err := s.ClaimIP(ctx, params, "10.0.1.2"); err != nil {
+		t.Fatalf("ClaimIP failed: %v", err)
+	}
+	if err := s.ReleaseUnusedIP(ctx, network, "c2", "eth0", "10.0.1.2"); err != nil {
+		t.Fatalf("ReleaseUnusedIP failed: %v", err)
+	}
+
+	records, _, err := s.ListHistory(ctx, HistoryFilter{Address: "10.0.1.2"}, AdminPage{})
+	if err != nil {
+		t.Fatalf("ListHistory failed: %v", err)
+	}
+	want := []HistoryEvent{HistoryAllocated, HistoryReleased, HistoryCooldownExpired, HistoryAllocated, HistoryReleased}
+	if got := historyEvents(records); !slices.Equal(got, want) {
+		t.Fatalf("Expected events %v, got %v", want, got)
+	}
+	if r := records[2]; r.PodName != "a" || r.OccurredAt.After(time.Now()) {
+		t.Errorf("Expected the cooldown of pod a to end when the address was claimed, got %+v", r)
+	}
+	if r := records[4]; r.PodName != "b" || !r.ReleaseAt.IsZero() {
+		t.Errorf("Expected pod b to release the address without cooldown, got %+v", r)
+	}
+}
+
+func TestStore_PruneHistory(t *testing.T) {
+	ctx := context.Background()
+	network := "test-network"
+	s := setupStoreWithCIDRs(t, network, "10.0.1.0/28", "10.0.2.0/28", "10.0.3.0/28", "10.0.4.0/28")
+	if _, err := s.db.Exec(`UPDATE ip_history SET occurred_at = ? WHERE cidr = '10.0.1.0/28'`, time.Now().Add(-2*time.Hour).UnixMilli()); err != nil {
+		t.Fatalf("Failed to backdate history: %v", err)
+	}
+
+	if n, err := s.PruneHistory(ctx, 0, 0); err != nil || n != 0 {
+		t.Errorf("Expected nothing to be pruned without limits, got %d, %v", n, err)
+	}
+	if n, err := s.PruneHistory(ctx, time.Hour, 10); err != nil || n != 1 {
+		t.Errorf("Expected 1 record older than 1h to be pruned, got %d, %v", n, err)
+	}
+	if n, err := s.PruneHistory(ctx, time.Hour, 2); err != nil || n != 1 {
+		t.Errorf("Expected 1 record beyond the 2 most recent ones to be pruned, got %d, %v", n, err)
+	}
+
+	var plan string
+	if err := s.db.QueryRow(`EXPLAIN QUERY PLAN DELETE FROM ip_history WHERE occurred_at < 0`).Scan(new(int), new(int), new(int), &plan); err != nil {
+		t.Fatalf("Failed to explain the pruning by age: %v", err)
+	}
+	if !strings.Contains(plan, "idx_ip_history_occurred_at") {
+		t.Errorf("Expected the pruning by age to use idx_ip_history_occurred_at, got plan %q", plan)
+	}
+
+	records, _, err := s.ListHistory(ctx, HistoryFilter{}, AdminPage{})
+	if err != nil {
+		t.Fatalf("ListHistory failed: %v", err)
+	}
+	if len(records) != 2 || records[0].CIDR != "10.0.3.0/28" || records[1].CIDR != "10.0.4.0/28" {
+		t.Errorf("Expected the 2 most recent records to be kept, got %+v", records)
+	}
+}
+
+func BenchmarkAllocateIP(b *testing.B) {
+	ctx := context.Background()
+	network := "test-network"
+	s := setupStoreWithCIDRs(b, network, "10.0.0.0/24")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		containerID := fmt.Sprintf("c-%d", i)
+		if _, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: containerID, IPFamily: IPv4, PodNamespace: "ns", PodName: containerID}); err != nil {
+			b.Fatalf("AllocateIP failed: %v", err)
+		}
+		b.StopTimer()
+		if _, err := s.ReleaseIPByOwner(ctx, network, containerID, "eth0", 0); err != nil {
+			b.Fatalf("ReleaseIPByOwner failed: %v", err)
+		}
+		b.StartTimer()
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationsFS holds the versioned schema migrations. Each file is named
+// "<version>_<description>.sql", versions start at 1 and have no gaps.
+// Migrations are append-only: a released migration must never be edited,
+// schema changes are made by adding a new file.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migration is a single versioned schema change.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations returns the embedded migrations ordered by version.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	var migrations []migration
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok || path.Ext(name) != ".sql" {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version in migration file name %q: %w", name, err)
+		}
+		content, err := migrationsFS.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", name, err)
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("migration %q has version %d, expected %d", m.name, m.version, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// migrate applies the migrations newer than the database's user_version,
+// each in its own transaction together with the user_version bump. An
+// existing database is backed up before it is changed, and a database
+// newer than the latest migration is refused.
+func (s *Store) migrate(ctx context.Context, migrations []migration) error {
+	var currentVersion int
+	if err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&currentVersion); err != nil {
+		return fmt.Errorf("failed to check schema version: %w", err)
+	}
+
+	targetVersion := len(migrations)
+	if currentVersion > targetVersion {
+		return fmt.Errorf("%w: database is at version %d, this binary supports up to version %d", ErrSchemaTooNew, currentVersion, targetVersion)
+	}
+	if currentVersion == targetVersion {
+		s.log.V(4).Info("Database schema already up to date", "version", currentVersion)
+		return nil
+	}
+
+	s.log.Info("Migrating DB schema", "currentVersion", currentVersion, "targetVersion", targetVersion)
+
+	if currentVersion > 0 {
+		backupPath, err := s.backup(ctx, currentVersion)
+		if err != nil {
+			return fmt.Errorf("failed to back up database before migration: %w", err)
+		}
+		s.log.Info("Backed up database before migration", "path", backupPath)
+	}
+
+	for _, m := range migrations[currentVersion:] {
+		if err := s.applyMigration(ctx, m); err != nil {
+			return err
+		}
+		s.log.Info("Applied DB schema migration", "version", m.version, "name", m.name)
+	}
+	return nil
+}
+
+// applyMigration executes a migration and sets user_version to its version atomically.
+func (s *Store) applyMigration(ctx context.Context, m migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for migration %q: %w", m.name, err)
+	}
+	// Safe to defer; Rollback does nothing if Commit() is successful
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+		return fmt.Errorf("failed to execute migration %q: %w", m.name, err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d;", m.version)); err != nil {
+		return fmt.Errorf("failed to set user_version for migration %q: %w", m.name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %q: %w", m.name, err)
+	}
+	return nil
+}
+
+// backup writes a consistent copy of the database next to it, named after
+// the schema version it was taken at, and returns its path. An older backup
+// of the same version is replaced.
+func (s *Store) backup(ctx context.Context, version int) (string, error) {
+	backupPath := fmt.Sprintf("%s.v%d.bak", s.dbPath, version)
+	if err := os.Remove(backupPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to remove previous backup %s: %w", backupPath, err)
+	}
+	// VACUUM INTO produces a transactionally consistent snapshot that
+	// includes the content of the WAL.
+	if _, err := s.db.ExecContext(ctx, "VACUUM INTO ?", backupPath); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
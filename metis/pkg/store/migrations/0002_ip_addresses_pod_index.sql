@@ -0,0 +1,3 @@
+-- Index to look up the IPs held by a pod, e.g. for the admin API pod filters.
+CREATE INDEX IF NOT EXISTS idx_ip_pod
+    ON ip_addresses(pod_namespace, pod_name);
@@ -0,0 +1,5 @@
+-- The network namespace path of the pod sandbox holding the IP, as passed in
+-- CNI_NETNS. The garbage collector uses it to detect sandboxes that were torn
+-- down without a CNI DEL.
+-- Example: '/var/run/netns/cni-1c5e4f3a-...'
+ALTER TABLE ip_addresses ADD COLUMN netns TEXT;
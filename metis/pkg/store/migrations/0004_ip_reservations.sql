@@ -0,0 +1,35 @@
+-- ip_reservations tracks the addresses that are never handed out by the
+-- regular allocation path: addresses pinned to a pod so that it gets the same
+-- IP back when it restarts on the node, and addresses blocked for other uses,
+-- e.g. node-local agents.
+CREATE TABLE IF NOT EXISTS ip_reservations (
+    -- Unique identifier for the reservation.
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- The logical network of the reserved address.
+    -- Example: 'gke-pod-network'
+    network TEXT NOT NULL,
+
+    -- The reserved IP address, in its canonical text form.
+    -- Example: '10.0.1.2'
+    address TEXT NOT NULL,
+
+    -- The protocol family of the reserved address.
+    -- Example: 'ipv4' or 'ipv6'
+    ip_family TEXT NOT NULL,
+
+    -- The Kubernetes Pod Namespace and Name the address is reserved for.
+    -- Both are empty for addresses that must never be assigned to a pod.
+    pod_namespace TEXT NOT NULL DEFAULT '',
+    pod_name TEXT NOT NULL DEFAULT '',
+
+    -- Unix epoch timestamp in milliseconds when the reservation was created.
+    created_at INTEGER DEFAULT (CAST(unixepoch('subsec') * 1000 AS INTEGER)),
+
+    UNIQUE(network, address)
+);
+
+-- A pod holds at most one reservation per network and IP family.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ip_reservations_pod
+    ON ip_reservations(network, pod_namespace, pod_name, ip_family)
+    WHERE pod_name != '';
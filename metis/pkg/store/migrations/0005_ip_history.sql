@@ -0,0 +1,96 @@
+-- ip_history is an append-only log of the allocations and releases of IP
+-- addresses and of the lifecycle of CIDR blocks, to answer questions such as
+-- "who held 10.0.1.17 in the last hour?" after ip_addresses was overwritten.
+--
+-- The records are written by the triggers below in the statement that makes
+-- the change, so that every code path is covered without extra round trips.
+-- The table is pruned by the daemon to a maximum age and number of records.
+CREATE TABLE IF NOT EXISTS ip_history (
+    -- Unique identifier of the record, increasing in the order the events were recorded.
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- The kind of event.
+    -- Expected values: 'Allocated', 'Released', 'CooldownExpired', 'BlockAdded',
+    -- 'BlockReady', 'BlockDraining', 'BlockDeleting', 'BlockDeleted'
+    event TEXT NOT NULL,
+
+    -- The network, CIDR and IP family of the CIDR block of the event. They are
+    -- copied rather than referenced since the block may be deleted since.
+    network TEXT NOT NULL,
+    cidr TEXT NOT NULL,
+    ip_family TEXT NOT NULL,
+
+    -- The IP address of the event. Empty for CIDR block events.
+    address TEXT NOT NULL DEFAULT '',
+
+    -- The owner of the IP address: the CNI_CONTAINER_ID, CNI_IFNAME and
+    -- Kubernetes Pod Namespace and Name. Empty for CIDR block events.
+    container_id TEXT NOT NULL DEFAULT '',
+    interface_name TEXT NOT NULL DEFAULT '',
+    pod_namespace TEXT NOT NULL DEFAULT '',
+    pod_name TEXT NOT NULL DEFAULT '',
+
+    -- For 'Released' events, the Unix epoch timestamp in milliseconds when the
+    -- release cooldown ends. NULL if the address was released without cooldown.
+    release_at INTEGER,
+
+    -- Unix epoch timestamp in milliseconds when the event occurred.
+    occurred_at INTEGER NOT NULL DEFAULT (CAST(unixepoch('subsec') * 1000 AS INTEGER))
+);
+
+-- Indexes for the lookups of the history of an address and of a pod.
+CREATE INDEX IF NOT EXISTS idx_ip_history_address
+    ON ip_history(address, id);
+CREATE INDEX IF NOT EXISTS idx_ip_history_pod
+    ON ip_history(pod_namespace, pod_name, id)
+    WHERE pod_name != '';
+
+CREATE TRIGGER IF NOT EXISTS record_ip_allocated
+    AFTER UPDATE OF is_allocated ON ip_addresses FOR EACH ROW
+    WHEN NEW.is_allocated = TRUE AND OLD.is_allocated = FALSE BEGIN
+        INSERT INTO ip_history (event, network, cidr, ip_family, address, container_id, interface_name, pod_namespace, pod_name)
+        SELECT 'Allocated', c.network, c.cidr, c.ip_family, NEW.address, IFNULL(NEW.container_id, ''),
+            IFNULL(NEW.interface_name, ''), IFNULL(NEW.pod_namespace, ''), IFNULL(NEW.pod_name, '')
+        FROM cidr_blocks c WHERE c.id = NEW.cidr_block_id;
+    END;
+
+CREATE TRIGGER IF NOT EXISTS record_ip_released
+    AFTER UPDATE OF is_allocated ON ip_addresses FOR EACH ROW
+    WHEN NEW.is_allocated = FALSE AND OLD.is_allocated = TRUE BEGIN
+        INSERT INTO ip_history (event, network, cidr, ip_family, address, container_id, interface_name, pod_namespace, pod_name, release_at)
+        SELECT 'Released', c.network, c.cidr, c.ip_family, NEW.address, IFNULL(NEW.container_id, ''),
+            IFNULL(NEW.interface_name, ''), IFNULL(NEW.pod_namespace, ''), IFNULL(NEW.pod_name, ''), NEW.release_at
+        FROM cidr_blocks c WHERE c.id = NEW.cidr_block_id;
+    END;
+
+-- The release cooldown of an address ends when its release_at is cleared,
+-- either by the daemon once it passed or by the next allocation of the
+-- address. The event is dated when the cooldown actually ended.
+CREATE TRIGGER IF NOT EXISTS record_ip_cooldown_expired
+    AFTER UPDATE OF release_at ON ip_addresses FOR EACH ROW
+    WHEN OLD.release_at IS NOT NULL AND NEW.release_at IS NULL BEGIN
+        INSERT INTO ip_history (event, network, cidr, ip_family, address, container_id, interface_name, pod_namespace, pod_name, occurred_at)
+        SELECT 'CooldownExpired', c.network, c.cidr, c.ip_family, NEW.address, IFNULL(OLD.container_id, ''),
+            IFNULL(OLD.interface_name, ''), IFNULL(OLD.pod_namespace, ''), IFNULL(OLD.pod_name, ''),
+            MIN(OLD.release_at, CAST(unixepoch('subsec') * 1000 AS INTEGER))
+        FROM cidr_blocks c WHERE c.id = NEW.cidr_block_id;
+    END;
+
+CREATE TRIGGER IF NOT EXISTS record_cidr_block_added
+    AFTER INSERT ON cidr_blocks FOR EACH ROW BEGIN
+        INSERT INTO ip_history (event, network, cidr, ip_family)
+        VALUES ('BlockAdded', NEW.network, NEW.cidr, NEW.ip_family);
+    END;
+
+CREATE TRIGGER IF NOT EXISTS record_cidr_block_state
+    AFTER UPDATE OF state ON cidr_blocks FOR EACH ROW
+    WHEN NEW.state != OLD.state BEGIN
+        INSERT INTO ip_history (event, network, cidr, ip_family)
+        VALUES ('Block' || NEW.state, NEW.network, NEW.cidr, NEW.ip_family);
+    END;
+
+CREATE TRIGGER IF NOT EXISTS record_cidr_block_deleted
+    AFTER DELETE ON cidr_blocks FOR EACH ROW BEGIN
+        INSERT INTO ip_history (event, network, cidr, ip_family)
+        VALUES ('BlockDeleted', OLD.network, OLD.cidr, OLD.ip_family);
+    END;
@@ -0,0 +1,36 @@
+-- ip_quarantines tracks the addresses and CIDR blocks that must not be handed
+-- out until they are explicitly released, e.g. after a duplicate address was
+-- detected on the network or a conflict was reported by the cloud provider.
+-- Unlike the release cooldown, a quarantine never expires.
+CREATE TABLE IF NOT EXISTS ip_quarantines (
+    -- Unique identifier for the quarantine.
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- The logical network of the quarantined address or CIDR block.
+    -- Example: 'gke-pod-network'
+    network TEXT NOT NULL,
+
+    -- The quarantined IP address, in its canonical text form. Empty if a
+    -- whole CIDR block is quarantined.
+    -- Example: '10.0.1.2'
+    address TEXT NOT NULL DEFAULT '',
+
+    -- The quarantined CIDR block, matching cidr_blocks.cidr. Empty if a
+    -- single address is quarantined.
+    -- Example: '10.0.1.0/28'
+    cidr TEXT NOT NULL DEFAULT '',
+
+    -- The protocol family of the address or CIDR block.
+    -- Example: 'ipv4' or 'ipv6'
+    ip_family TEXT NOT NULL,
+
+    -- Why the address or CIDR block was quarantined, for operators.
+    -- Example: 'duplicate address detected by ARP probe'
+    reason TEXT NOT NULL DEFAULT '',
+
+    -- Unix epoch timestamp in milliseconds when the quarantine was added.
+    created_at INTEGER DEFAULT (CAST(unixepoch('subsec') * 1000 AS INTEGER)),
+
+    CHECK ((address = '') != (cidr = '')),
+    UNIQUE(network, address, cidr)
+);
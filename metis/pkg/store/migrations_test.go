@@ -0,0 +1,228 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+// createV1Fixture writes a database at schema version 1 from testdata and returns its path.
+func createV1Fixture(t *testing.T) string {
+	t.Helper()
+	fixture, err := os.ReadFile(filepath.Join("testdata", "v1_fixture.sql"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "metis.sqlite")
+	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("Failed to open fixture database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(fixture)); err != nil {
+		t.Fatalf("Failed to load fixture: %v", err)
+	}
+	return dbPath
+}
+
+func userVersion(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatalf("Failed to read user_version: %v", err)
+	}
+	return version
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations failed: %v", err)
+	}
+	if len(migrations) < 2 {
+		t.Fatalf("Expected at least 2 migrations, got %d", len(migrations))
+	}
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("Expected migration %d to have version %d, got %d (%s)", i, i+1, m.version, m.name)
+		}
+		if m.sql == "" {
+			t.Errorf("Migration %s is empty", m.name)
+		}
+	}
+}
+
+func TestStore_MigrateV1Fixture(t *testing.T) {
+	ctx := context.Background()
+	dbPath := createV1Fixture(t)
+
+	s, err := NewStore(ctx, logr.Discard(), dbPath)
+	if err != nil {
+		t.Fatalf("NewStore failed to migrate v1 fixture: %v", err)
+	}
+	defer s.Close()
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations failed: %v", err)
+	}
+	if got := userVersion(t, s.db); got != len(migrations) {
+		t.Errorf("Expected user_version %d after migration, got %d", len(migrations), got)
+	}
+	var name string
+	if err := s.db.QueryRow("SELECT name FROM sqlite_master WHERE type='index' AND name='idx_ip_pod'").Scan(&name); err != nil {
+		t.Errorf("Expected index idx_ip_pod to be created: %v", err)
+	}
+
+	// Pod IP ownership records must survive the upgrade.
+	for _, owner := range []struct {
+		containerID string
+		network     string
+	}{
+		{containerID: "container-a", network: "default"},
+		{containerID: "container-b", network: "default"},
+		{containerID: "container-d", network: "default"},
+	} {
+		if err := s.CheckAllocation(ctx, owner.network, owner.containerID, "eth0"); err != nil {
+			t.Errorf("CheckAllocation(%s) failed after migration: %v", owner.containerID, err)
+		}
+	}
+	ip, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: "default", InterfaceName: "eth0", ContainerID: "container-a", IPFamily: IPv4})
+	if err != nil {
+		t.Fatalf("AllocateIP failed after migration: %v", err)
+	}
+	if ip != "10.0.0.2" {
+		t.Errorf("Expected idempotent allocation to return 10.0.0.2, got %s", ip)
+	}
+	// 10.0.0.4 is in release cooldown, so the next free address is 10.0.0.5.
+	ip, _, err = s.AllocateIP(ctx, AllocateIPParams{Network: "default", InterfaceName: "eth0", ContainerID: "container-e", IPFamily: IPv4})
+	if err != nil {
+		t.Fatalf("AllocateIP failed after migration: %v", err)
+	}
+	if ip != "10.0.0.5" {
+		t.Errorf("Expected new allocation to return 10.0.0.5, got %s", ip)
+	}
+	usage, err := s.GetIPUsage(ctx, "default", IPv4)
+	if err != nil {
+		t.Fatalf("GetIPUsage failed after migration: %v", err)
+	}
+	if usage.Allocated != 7 {
+		t.Errorf("Expected 7 allocated IPv4 addresses after migration, got %d", usage.Allocated)
+	}
+
+	// The pre-migration backup holds the original v1 database.
+	backup, err := sql.Open("sqlite3", dbPath+".v1.bak")
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer backup.Close()
+	if got := userVersion(t, backup); got != 1 {
+		t.Errorf("Expected backup to be at user_version 1, got %d", got)
+	}
+	var count int
+	if err := backup.QueryRow("SELECT COUNT(*) FROM ip_addresses WHERE is_allocated = TRUE AND container_id != ''").Scan(&count); err != nil {
+		t.Fatalf("Failed to query backup: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("Expected 4 owned IPs in backup, got %d", count)
+	}
+}
+
+func TestStore_MigrateFreshDatabaseSkipsBackup(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "metis.sqlite")
+	s, err := NewStore(context.Background(), logr.Discard(), dbPath)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	defer s.Close()
+
+	matches, err := filepath.Glob(dbPath + ".v*.bak")
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("Expected no backup for a fresh database, got %v", matches)
+	}
+}
+
+func TestStore_MigrateRefusesNewerSchema(t *testing.T) {
+	dbPath := createV1Fixture(t)
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations failed: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open fixture database: %v", err)
+	}
+	newerVersion := len(migrations) + 1
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", newerVersion)); err != nil {
+		t.Fatalf("Failed to set user_version: %v", err)
+	}
+	db.Close()
+
+	_, err = NewStore(context.Background(), logr.Discard(), dbPath)
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("Expected ErrSchemaTooNew, got %v", err)
+	}
+
+	db, err = sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen fixture database: %v", err)
+	}
+	defer db.Close()
+	if got := userVersion(t, db); got != newerVersion {
+		t.Errorf("Expected user_version to stay at %d, got %d", newerVersion, got)
+	}
+}
+
+func TestStore_MigrateRollsBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	s := setupTestStore(t)
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations failed: %v", err)
+	}
+
+	broken := append(migrations, migration{
+		version: len(migrations) + 1,
+		name:    "broken.sql",
+		sql:     "CREATE TABLE partial (id INTEGER); INSERT INTO missing_table VALUES (1);",
+	})
+	if err := s.migrate(ctx, broken); err == nil {
+		t.Fatal("Expected migrate to fail")
+	}
+
+	if got := userVersion(t, s.db); got != len(migrations) {
+		t.Errorf("Expected user_version to stay at %d, got %d", len(migrations), got)
+	}
+	var name string
+	err = s.db.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name='partial'").Scan(&name)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected the failed migration to be rolled back, got table lookup error %v", err)
+	}
+}
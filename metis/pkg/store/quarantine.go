@@ -0,0 +1,170 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// IPQuarantine is an address, or a whole CIDR block, that is never handed out
+// until the quarantine is removed, e.g. because another host on the network
+// uses the address. Exactly one of Address and CIDR is set. A quarantined
+// address that is currently allocated stays allocated until it is released.
+type IPQuarantine struct {
+	Network   string
+	Address   string
+	CIDR      string
+	IPFamily  IPFamily
+	Reason    string
+	CreatedAt time.Time
+}
+
+// AddIPQuarantine quarantines an address or a CIDR block. Neither needs to be
+// part of the store yet, a quarantined CIDR block is matched against the CIDR
+// of the blocks of the network. It returns the quarantine as stored, with the
+// address or CIDR in its canonical form, and ErrQuarantineExists if the
+// address or CIDR block is already quarantined.
+func (s *Store) AddIPQuarantine(ctx context.Context, q IPQuarantine) (IPQuarantine, error) {
+	var err error
+	q, err = canonicalQuarantine(q)
+	if err != nil {
+		return IPQuarantine{}, err
+	}
+
+	var createdAt int64
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO ip_quarantines (network, address, cidr, ip_family, reason)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING created_at
+	`, q.Network, q.Address, q.CIDR, q.IPFamily, q.Reason).Scan(&createdAt)
+	if err != nil {
+		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return IPQuarantine{}, fmt.Errorf("%w: %v", ErrQuarantineExists, err)
+		}
+		return IPQuarantine{}, fmt.Errorf("failed to insert ip_quarantine: %w", err)
+	}
+	q.CreatedAt = time.UnixMilli(createdAt)
+	return q, nil
+}
+
+// RemoveIPQuarantine removes the quarantine of an address or a CIDR block, of
+// which exactly one must be set. It returns ErrQuarantineNotFound if it is not
+// quarantined.
+func (s *Store) RemoveIPQuarantine(ctx context.Context, network, address, cidr string) error {
+	q, err := canonicalQuarantine(IPQuarantine{Network: network, Address: address, CIDR: cidr})
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM ip_quarantines WHERE network = ? AND address = ? AND cidr = ?
+	`, q.Network, q.Address, q.CIDR)
+	if err != nil {
+		return fmt.Errorf("failed to delete ip_quarantine: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get deleted rows: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s%s on network %s", ErrQuarantineNotFound, q.Address, q.CIDR, network)
+	}
+	return nil
+}
+
+// ListIPQuarantines returns the quarantines of a network, or of all networks
+// if network is empty, ordered by creation.
+func (s *Store) ListIPQuarantines(ctx context.Context, network string) ([]IPQuarantine, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT network, address, cidr, ip_family, reason, created_at
+		FROM ip_quarantines
+		WHERE ? = '' OR network = ?
+		ORDER BY id ASC
+	`, network, network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ip_quarantines: %w", err)
+	}
+	defer rows.Close()
+
+	var result []IPQuarantine
+	for rows.Next() {
+		var q IPQuarantine
+		var createdAt sql.NullInt64
+		if err := rows.Scan(&q.Network, &q.Address, &q.CIDR, &q.IPFamily, &q.Reason, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ip_quarantine: %w", err)
+		}
+		q.CreatedAt = unixMilliOrZero(createdAt)
+		result = append(result, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return result, nil
+}
+
+// canonicalQuarantine validates that exactly one of the address and the CIDR
+// of q is set, and converts it to its canonical form.
+func canonicalQuarantine(q IPQuarantine) (IPQuarantine, error) {
+	switch {
+	case (q.Address == "") == (q.CIDR == ""):
+		return IPQuarantine{}, fmt.Errorf("exactly one of address and cidr must be set, got %q and %q", q.Address, q.CIDR)
+	case q.Address != "":
+		addr, err := netip.ParseAddr(q.Address)
+		if err != nil {
+			return IPQuarantine{}, fmt.Errorf("invalid address %q: %w", q.Address, err)
+		}
+		addr = addr.Unmap()
+		q.Address = addr.String()
+		q.IPFamily = IPv4
+		if addr.Is6() {
+			q.IPFamily = IPv6
+		}
+	default:
+		prefix, err := netip.ParsePrefix(q.CIDR)
+		if err != nil {
+			return IPQuarantine{}, fmt.Errorf("invalid cidr %q: %w", q.CIDR, err)
+		}
+		if prefix != prefix.Masked() {
+			return IPQuarantine{}, fmt.Errorf("invalid cidr %q: host bits are set, did you mean %s", q.CIDR, prefix.Masked())
+		}
+		q.CIDR = prefix.String()
+		q.IPFamily = IPv4
+		if prefix.Addr().Is6() {
+			q.IPFamily = IPv6
+		}
+	}
+	return q, nil
+}
+
+// isQuarantinedTx reports whether an address of a network, or the CIDR block
+// containing it, is quarantined.
+func isQuarantinedTx(ctx context.Context, tx *sql.Tx, network, address, cidr string) (bool, error) {
+	var count int
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM ip_quarantines
+		WHERE network = ? AND ((address = ? AND address != '') OR (cidr = ? AND cidr != ''))
+	`, network, address, cidr).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to query ip_quarantines: %w", err)
+	}
+	return count > 0, nil
+}
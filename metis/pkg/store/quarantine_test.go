@@ -0,0 +1,112 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestStore_IPQuarantines(t *testing.T) {
+	ctx := context.Background()
+	network := "default"
+	// The first block of a network has 3 reserved addresses, leaving
+	// 10.0.0.2-10.0.0.6 free.
+	s := setupStoreWithCIDRs(t, network, "10.0.0.0/29", "10.0.1.0/30")
+
+	q, err := s.AddIPQuarantine(ctx, IPQuarantine{Network: network, Address: "::ffff:10.0.0.3", Reason: "duplicate address"})
+	if err != nil {
+		t.Fatalf("AddIPQuarantine failed: %v", err)
+	}
+	if q.Address != "10.0.0.3" || q.IPFamily != IPv4 || q.CreatedAt.IsZero() {
+		t.Errorf("Unexpected quarantine: %+v", q)
+	}
+	if _, err := s.AddIPQuarantine(ctx, IPQuarantine{Network: network, CIDR: "10.0.1.0/30"}); err != nil {
+		t.Fatalf("AddIPQuarantine of a CIDR block failed: %v", err)
+	}
+	if _, err := s.AddIPQuarantine(ctx, IPQuarantine{Network: network, Address: "10.0.0.3"}); !errors.Is(err, ErrQuarantineExists) {
+		t.Errorf("Expected ErrQuarantineExists, got %v", err)
+	}
+	for _, q := range []IPQuarantine{
+		{Network: network},
+		{Network: network, Address: "10.0.0.4", CIDR: "10.0.0.0/29"},
+		{Network: network, Address: "10.0.0.300"},
+		{Network: network, CIDR: "10.0.0.1/29"},
+	} {
+		if _, err := s.AddIPQuarantine(ctx, q); err == nil {
+			t.Errorf("Expected AddIPQuarantine(%+v) to fail", q)
+		}
+	}
+
+	usage, err := s.GetIPUsage(ctx, network, IPv4)
+	if err != nil {
+		t.Fatalf("GetIPUsage failed: %v", err)
+	}
+	if usage.Quarantined != 5 {
+		t.Errorf("Expected 5 quarantined IPs, got %d", usage.Quarantined)
+	}
+	if n, err := s.CountAvailableIPs(ctx, network, IPv4); err != nil || n != 4 {
+		t.Errorf("CountAvailableIPs = %d, %v, want 4", n, err)
+	}
+
+	// The quarantined address is skipped, also when requested, and the
+	// quarantined block is never allocated from.
+	var allocated []string
+	for i := range 4 {
+		params := AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: fmt.Sprintf("c%d", i), IPFamily: IPv4}
+		if i == 0 {
+			params.RequestedAddress = "10.0.0.3"
+		}
+		ip, _, err := s.AllocateIP(ctx, params)
+		if err != nil {
+			t.Fatalf("AllocateIP failed: %v", err)
+		}
+		allocated = append(allocated, ip)
+	}
+	want := []string{"10.0.0.2", "10.0.0.4", "10.0.0.5", "10.0.0.6"}
+	for i := range want {
+		if allocated[i] != want[i] {
+			t.Fatalf("Expected allocations %v, got %v", want, allocated)
+		}
+	}
+	if _, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c4", IPFamily: IPv4}); !errors.Is(err, ErrNoAvailableIPs) {
+		t.Errorf("Expected ErrNoAvailableIPs, got %v", err)
+	}
+
+	quarantines, err := s.ListIPQuarantines(ctx, "")
+	if err != nil || len(quarantines) != 2 || quarantines[0].Reason != "duplicate address" || quarantines[1].CIDR != "10.0.1.0/30" {
+		t.Errorf("Unexpected quarantines %+v, %v", quarantines, err)
+	}
+
+	if err := s.RemoveIPQuarantine(ctx, network, "10.0.0.3", ""); err != nil {
+		t.Fatalf("RemoveIPQuarantine failed: %v", err)
+	}
+	if err := s.RemoveIPQuarantine(ctx, network, "10.0.0.3", ""); !errors.Is(err, ErrQuarantineNotFound) {
+		t.Errorf("Expected ErrQuarantineNotFound, got %v", err)
+	}
+	if ip, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c4", IPFamily: IPv4}); err != nil || ip != "10.0.0.3" {
+		t.Errorf("AllocateIP = %s, %v, want the released quarantined address 10.0.0.3", ip, err)
+	}
+	if err := s.RemoveIPQuarantine(ctx, network, "", "10.0.1.0/30"); err != nil {
+		t.Fatalf("RemoveIPQuarantine of a CIDR block failed: %v", err)
+	}
+	if ip, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c5", IPFamily: IPv4}); err != nil || ip != "10.0.1.0" {
+		t.Errorf("AllocateIP = %s, %v, want 10.0.1.0", ip, err)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// maxIPv6ClaimPopulation bounds the number of IPv6 entries ClaimIP populates
+// to reach an address that is not in the table yet. The addresses of an IPv6
+// block are allocated in order, so an address in use is expected to be close
+// to the populated ones.
+const maxIPv6ClaimPopulation = 1 << 16
+
+// ClaimIP allocates address, which is known to be in use, to the owner of
+// params, e.g. to rebuild the ownership of the IPs of running pods after the
+// database was lost. Unlike AllocateIP, the release cooldown and the
+// reservations of the address are ignored, and the address may be in a
+// Draining CIDR block. It returns the CIDR of the block of the address, and
+// ErrAddressUnavailable if the address is not in a Ready or Draining CIDR
+// block of the network or is already allocated.
+//
+// The IPv6 entries up to the address are populated if needed, like
+// successive allocations would have.
+func (s *Store) ClaimIP(ctx context.Context, params AllocateIPParams, address string) (string, error) {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid address %q: %v", ErrAddressUnavailable, address, err)
+	}
+	addr = addr.Unmap()
+	address = addr.String()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, existingCIDR, found, err := s.existingAllocationTx(ctx, tx, params)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		if existing != address {
+			return "", fmt.Errorf("%w: owner already holds %s", ErrAddressUnavailable, existing)
+		}
+		return existingCIDR, nil
+	}
+
+	cidrBlockID, prefix, err := s.cidrBlockOfAddressTx(ctx, tx, params.Network, params.IPFamily, addr)
+	if err != nil {
+		return "", err
+	}
+
+	var id int64
+	var isAllocated bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, is_allocated FROM ip_addresses WHERE cidr_block_id = ? AND address = ?
+	`, cidrBlockID, address).Scan(&id, &isAllocated)
+	if err == sql.ErrNoRows && params.IPFamily == IPv6 {
+		id, err = s.populateIPv6UpToTx(ctx, tx, cidrBlockID, prefix, addr)
+	}
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("%w: %s is not in the table of cidr block %s", ErrAddressUnavailable, address, prefix)
+	}
+	if err != nil {
+		return "", err
+	}
+	if isAllocated {
+		return "", fmt.Errorf("%w: %s is allocated", ErrAddressUnavailable, address)
+	}
+
+	if err := recordCooldownsExpiredTx(ctx, tx, "i.id = ?", id); err != nil {
+		return "", err
+	}
+	nowMilli := time.Now().UTC().UnixMilli()
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ip_addresses
+		SET is_allocated = TRUE, container_id = ?, interface_name = ?, pod_name = ?, pod_namespace = ?, netns = ?, allocated_at = ?, release_at = NULL
+		WHERE id = ?
+	`, params.ContainerID, params.InterfaceName, params.PodName, params.PodNamespace, params.Netns, nowMilli, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to claim ip %s: %w", address, err)
+	}
+	if err := recordAddressEventTx(ctx, tx, HistoryAllocated, id); err != nil {
+		return "", err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE cidr_blocks
+		SET allocated_ips = allocated_ips + 1
+		WHERE id = ?
+	`, cidrBlockID)
+	if err != nil {
+		return "", fmt.Errorf("failed to update allocated_ips in cidr_blocks: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return prefix.String(), nil
+}
+
+// cidrBlockOfAddressTx returns the Ready or Draining CIDR block of a network
+// containing addr.
+func (s *Store) cidrBlockOfAddressTx(ctx context.Context, tx *sql.Tx, network string, ipFamily IPFamily, addr netip.Addr) (int64, netip.Prefix, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, cidr FROM cidr_blocks
+		WHERE network = ? AND ip_family = ? AND state IN ('Ready', 'Draining')
+		ORDER BY id ASC
+	`, network, ipFamily)
+	if err != nil {
+		return 0, netip.Prefix{}, fmt.Errorf("failed to query cidr blocks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var cidr string
+		if err := rows.Scan(&id, &cidr); err != nil {
+			return 0, netip.Prefix{}, fmt.Errorf("failed to scan row: %w", err)
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return 0, netip.Prefix{}, fmt.Errorf("failed to parse cidr %s: %w", cidr, err)
+		}
+		if prefix.Contains(addr) {
+			return id, prefix, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, netip.Prefix{}, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return 0, netip.Prefix{}, fmt.Errorf("%w: %s is not in a Ready or Draining %s cidr block of network %s", ErrAddressUnavailable, addr, ipFamily, network)
+}
+
+// populateIPv6UpToTx populates the entries of an IPv6 CIDR block from the
+// last populated one up to addr, and returns the ID of the entry of addr.
+func (s *Store) populateIPv6UpToTx(ctx context.Context, tx *sql.Tx, cidrBlockID int64, prefix netip.Prefix, addr netip.Addr) (int64, error) {
+	start, err := s.getNextIPv6StartAddr(ctx, tx, cidrBlockID, prefix)
+	if err != nil {
+		return 0, err
+	}
+	if addr.Less(start) {
+		return 0, fmt.Errorf("%w: %s is below the populated addresses of cidr block %s but not in the table", ErrAddressUnavailable, addr, prefix)
+	}
+
+	var addrs []netip.Addr
+	for curr := start; !addr.Less(curr); curr = curr.Next() {
+		if len(addrs) == maxIPv6ClaimPopulation {
+			return 0, fmt.Errorf("%w: %s is more than %d addresses beyond the populated addresses of cidr block %s", ErrAddressUnavailable, addr, maxIPv6ClaimPopulation, prefix)
+		}
+		addrs = append(addrs, curr)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO ip_addresses (cidr_block_id, address, is_allocated, container_id, interface_name)
+		VALUES (?, ?, FALSE, '', '')
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var id int64
+	for _, a := range addrs {
+		res, err := stmt.ExecContext(ctx, cidrBlockID, a.String())
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert ip_address %s: %w", a, err)
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return 0, fmt.Errorf("failed to get last inserted id: %w", err)
+		}
+	}
+	return id, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStore_ClaimIP(t *testing.T) {
+	ctx := context.Background()
+	network := "default"
+	s := setupStoreWithCIDRs(t, network, "10.0.0.0/28", "2001:db8::/64")
+
+	pod := func(containerID string, ipFamily IPFamily) AllocateIPParams {
+		return AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: containerID, IPFamily: ipFamily, PodNamespace: "ns", PodName: containerID}
+	}
+
+	cidr, err := s.ClaimIP(ctx, pod("web-0", IPv4), "10.0.0.7")
+	if err != nil || cidr != "10.0.0.0/28" {
+		t.Fatalf("ClaimIP = %q, %v, want 10.0.0.0/28", cidr, err)
+	}
+	// Claiming the same address again for the same owner is a no-op.
+	if _, err := s.ClaimIP(ctx, pod("web-0", IPv4), "10.0.0.7"); err != nil {
+		t.Errorf("Repeated ClaimIP failed: %v", err)
+	}
+	// An address far beyond the populated IPv6 addresses is populated up to.
+	if cidr, err := s.ClaimIP(ctx, pod("web-0", IPv6), "2001:db8::100"); err != nil || cidr != "2001:db8::/64" {
+		t.Fatalf("ClaimIP = %q, %v, want 2001:db8::/64", cidr, err)
+	}
+
+	// An address in release cooldown or reserved for another pod is claimed.
+	if _, _, err := s.AllocateIP(ctx, pod("web-1", IPv4)); err != nil {
+		t.Fatalf("AllocateIP failed: %v", err)
+	}
+	if _, err := s.ReleaseIPByOwner(ctx, network, "web-1", "eth0", time.Hour); err != nil {
+		t.Fatalf("ReleaseIPByOwner failed: %v", err)
+	}
+	if _, err := s.AddIPReservation(ctx, IPReservation{Network: network, Address: "10.0.0.2", PodNamespace: "ns", PodName: "db-0"}); err != nil {
+		t.Fatalf("AddIPReservation failed: %v", err)
+	}
+	if _, err := s.ClaimIP(ctx, pod("web-2", IPv4), "10.0.0.2"); err != nil {
+		t.Errorf("ClaimIP of an address in cooldown and reserved failed: %v", err)
+	}
+
+	unavailable := []struct {
+		params  AllocateIPParams
+		address string
+	}{
+		{pod("web-3", IPv4), "10.0.0.7"},      // allocated to web-0
+		{pod("web-3", IPv4), "10.0.0.0"},      // reserved network address
+		{pod("web-3", IPv4), "10.0.1.1"},      // not in a cidr block
+		{pod("web-0", IPv4), "10.0.0.8"},      // web-0 holds 10.0.0.7
+		{pod("web-3", IPv6), "2001:db8::2:0"}, // too far beyond the populated addresses
+		{pod("web-3", IPv4), "not-an-ip"},
+	}
+	for _, tc := range unavailable {
+		if _, err := s.ClaimIP(ctx, tc.params, tc.address); !errors.Is(err, ErrAddressUnavailable) {
+			t.Errorf("ClaimIP(%s, %s) = %v, want ErrAddressUnavailable", tc.params.ContainerID, tc.address, err)
+		}
+	}
+
+	owners, err := s.ListIPOwners(ctx)
+	if err != nil {
+		t.Fatalf("ListIPOwners failed: %v", err)
+	}
+	got := map[string][]string{}
+	for _, o := range owners {
+		got[o.ContainerID] = o.Addresses
+	}
+	if len(got) != 2 || len(got["web-0"]) != 2 || got["web-0"][0] != "10.0.0.7" || got["web-0"][1] != "2001:db8::100" ||
+		len(got["web-2"]) != 1 || got["web-2"][0] != "10.0.0.2" {
+		t.Errorf("Unexpected IP owners %v", got)
+	}
+	usage, err := s.GetIPUsage(ctx, network, IPv4)
+	if err != nil {
+		t.Fatalf("GetIPUsage failed: %v", err)
+	}
+	// 3 reserved addresses, and the addresses of web-0 and web-2.
+	if usage.Allocated != 5 {
+		t.Errorf("Expected 5 allocated IPv4 addresses, got %d", usage.Allocated)
+	}
+
+	// The next IPv6 allocation continues after the claimed address.
+	ip, _, err := s.AllocateIP(ctx, pod("web-4", IPv6))
+	if err != nil {
+		t.Fatalf("AllocateIP failed: %v", err)
+	}
+	if ip == "2001:db8::100" {
+		t.Errorf("Expected the claimed address not to be allocated again")
+	}
+}
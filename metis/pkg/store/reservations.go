@@ -0,0 +1,281 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// IPReservation is an address that the regular allocation path never hands
+// out. If PodNamespace and PodName are set, the address is assigned to that
+// pod whenever it allocates an IP of the address family on the network.
+// Otherwise the address is never assigned to any pod.
+type IPReservation struct {
+	Network      string
+	Address      string
+	IPFamily     IPFamily
+	PodNamespace string
+	PodName      string
+	CreatedAt    time.Time
+}
+
+// AddIPReservation reserves an address. The address does not need to be part
+// of a CIDR block of the store yet. If it is currently assigned to a pod, the
+// reservation takes effect once the address is released. It returns the
+// reservation as stored, with the address in its canonical form.
+func (s *Store) AddIPReservation(ctx context.Context, r IPReservation) (IPReservation, error) {
+	addr, err := netip.ParseAddr(r.Address)
+	if err != nil {
+		return IPReservation{}, fmt.Errorf("invalid address %q: %w", r.Address, err)
+	}
+	if (r.PodNamespace == "") != (r.PodName == "") {
+		return IPReservation{}, fmt.Errorf("pod namespace and name must be both set or both empty, got %q and %q", r.PodNamespace, r.PodName)
+	}
+	r.Address = addr.Unmap().String()
+	r.IPFamily = IPv4
+	if addr.Unmap().Is6() {
+		r.IPFamily = IPv6
+	}
+
+	var createdAt int64
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO ip_reservations (network, address, ip_family, pod_namespace, pod_name)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING created_at
+	`, r.Network, r.Address, r.IPFamily, r.PodNamespace, r.PodName).Scan(&createdAt)
+	if err != nil {
+		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return IPReservation{}, fmt.Errorf("%w: %v", ErrReservationExists, err)
+		}
+		return IPReservation{}, fmt.Errorf("failed to insert ip_reservation: %w", err)
+	}
+	r.CreatedAt = time.UnixMilli(createdAt)
+	return r, nil
+}
+
+// RemoveIPReservation removes the reservation of an address. It returns
+// ErrReservationNotFound if the address is not reserved.
+func (s *Store) RemoveIPReservation(ctx context.Context, network, address string) error {
+	if addr, err := netip.ParseAddr(address); err == nil {
+		address = addr.Unmap().String()
+	}
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM ip_reservations WHERE network = ? AND address = ?
+	`, network, address)
+	if err != nil {
+		return fmt.Errorf("failed to delete ip_reservation: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get deleted rows: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s on network %s", ErrReservationNotFound, address, network)
+	}
+	return nil
+}
+
+// ListIPReservations returns the reservations of a network, or of all
+// networks if network is empty, ordered by creation.
+func (s *Store) ListIPReservations(ctx context.Context, network string) ([]IPReservation, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT network, address, ip_family, pod_namespace, pod_name, created_at
+		FROM ip_reservations
+		WHERE ? = '' OR network = ?
+		ORDER BY id ASC
+	`, network, network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ip_reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var result []IPReservation
+	for rows.Next() {
+		var r IPReservation
+		var createdAt sql.NullInt64
+		if err := rows.Scan(&r.Network, &r.Address, &r.IPFamily, &r.PodNamespace, &r.PodName, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ip_reservation: %w", err)
+		}
+		r.CreatedAt = unixMilliOrZero(createdAt)
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return result, nil
+}
+
+// reservedAddressForPod returns the address reserved for the pod of params, if any.
+func (s *Store) reservedAddressForPod(ctx context.Context, params AllocateIPParams) (string, bool, error) {
+	if params.PodName == "" {
+		return "", false, nil
+	}
+	var address string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT address FROM ip_reservations
+		WHERE network = ? AND pod_namespace = ? AND pod_name = ? AND ip_family = ?
+	`, params.Network, params.PodNamespace, params.PodName, params.IPFamily).Scan(&address)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to query ip_reservations: %w", err)
+	}
+	return address, true, nil
+}
+
+// tryAllocateAddress attempts to allocate a specific address to the owner of
+// params. It returns ErrAddressUnavailable if the address is not a free
+// address of a Ready CIDR block of the network. Addresses in release cooldown
+// or reserved are only allocated if reserved is set, i.e. the address is
+// reserved for the pod of params. Quarantined addresses are never allocated.
+// The IPv6 entries up to the address are populated if needed.
+func (s *Store) tryAllocateAddress(ctx context.Context, params AllocateIPParams, address string, reserved bool) (string, string, error) {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: invalid address %q: %v", ErrAddressUnavailable, address, err)
+	}
+	address = addr.Unmap().String()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, existingCIDR, found, err := s.existingAllocationTx(ctx, tx, params)
+	if err != nil {
+		return "", "", err
+	}
+	if found {
+		return existing, existingCIDR, nil
+	}
+
+	var id, cidrBlockID int64
+	var cidrRange string
+	var isAllocated bool
+	var releaseAt sql.NullInt64
+	query := `
+		SELECT i.id, i.cidr_block_id, c.cidr, i.is_allocated, i.release_at
+		FROM ip_addresses i
+		JOIN cidr_blocks c ON i.cidr_block_id = c.id
+		WHERE c.network = ? AND c.ip_family = ? AND c.state = 'Ready' AND i.address = ?
+	`
+	err = tx.QueryRowContext(ctx, query, params.Network, params.IPFamily, address).Scan(&id, &cidrBlockID, &cidrRange, &isAllocated, &releaseAt)
+	if err == sql.ErrNoRows && params.IPFamily == IPv6 {
+		// IPv6 entries are populated lazily, so the address may not be in
+		// the table yet. Populate the entries up to it like ClaimIP does.
+		if err = s.populateReadyIPv6UpToTx(ctx, tx, params.Network, addr.Unmap()); err == nil {
+			err = tx.QueryRowContext(ctx, query, params.Network, params.IPFamily, address).Scan(&id, &cidrBlockID, &cidrRange, &isAllocated, &releaseAt)
+		}
+	}
+	if err == sql.ErrNoRows {
+		return "", "", fmt.Errorf("%w: %s is not in a Ready %s cidr block of network %s", ErrAddressUnavailable, address, params.IPFamily, params.Network)
+	}
+	if errors.Is(err, ErrAddressUnavailable) {
+		return "", "", err
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to query ip_address %s: %w", address, err)
+	}
+
+	quarantined, err := isQuarantinedTx(ctx, tx, params.Network, address, cidrRange)
+	if err != nil {
+		return "", "", err
+	}
+
+	nowMilli := time.Now().UTC().UnixMilli()
+	switch {
+	case isAllocated:
+		return "", "", fmt.Errorf("%w: %s is allocated", ErrAddressUnavailable, address)
+	case quarantined:
+		return "", "", fmt.Errorf("%w: %s is quarantined", ErrAddressUnavailable, address)
+	case !reserved && releaseAt.Valid && releaseAt.Int64 > nowMilli:
+		return "", "", fmt.Errorf("%w: %s is in release cooldown", ErrAddressUnavailable, address)
+	}
+	if !reserved {
+		var count int
+		err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM ip_reservations WHERE network = ? AND address = ?
+		`, params.Network, address).Scan(&count)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to query ip_reservations: %w", err)
+		}
+		if count > 0 {
+			return "", "", fmt.Errorf("%w: %s is reserved", ErrAddressUnavailable, address)
+		}
+	}
+
+	if releaseAt.Valid {
+		if err := recordCooldownsExpiredTx(ctx, tx, "i.id = ?", id); err != nil {
+			return "", "", err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ip_addresses
+		SET is_allocated = TRUE, container_id = ?, interface_name = ?, pod_name = ?, pod_namespace = ?, netns = ?, allocated_at = ?, release_at = NULL
+		WHERE id = ?
+	`, params.ContainerID, params.InterfaceName, params.PodName, params.PodNamespace, params.Netns, nowMilli, id)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to allocate ip %s: %w", address, err)
+	}
+	if err := recordAddressEventTx(ctx, tx, HistoryAllocated, id); err != nil {
+		return "", "", err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE cidr_blocks
+		SET allocated_ips = allocated_ips + 1
+		WHERE id = ?
+	`, cidrBlockID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to update allocated_ips in cidr_blocks: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return address, cidrRange, nil
+}
+
+// populateReadyIPv6UpToTx populates the entries of the Ready IPv6 CIDR block
+// of a network containing addr up to addr. It returns sql.ErrNoRows if no
+// Ready block contains addr.
+func (s *Store) populateReadyIPv6UpToTx(ctx context.Context, tx *sql.Tx, network string, addr netip.Addr) error {
+	cidrBlockID, prefix, err := s.cidrBlockOfAddressTx(ctx, tx, network, IPv6, addr)
+	if errors.Is(err, ErrAddressUnavailable) {
+		return sql.ErrNoRows
+	}
+	if err != nil {
+		return err
+	}
+	var state CidrBlockState
+	if err := tx.QueryRowContext(ctx, "SELECT state FROM cidr_blocks WHERE id = ?", cidrBlockID).Scan(&state); err != nil {
+		return fmt.Errorf("failed to query state of cidr block %s: %w", prefix, err)
+	}
+	if state != StateReady {
+		return sql.ErrNoRows
+	}
+	_, err = s.populateIPv6UpToTx(ctx, tx, cidrBlockID, prefix, addr)
+	return err
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStore_IPReservations(t *testing.T) {
+	ctx := context.Background()
+	s := setupTestStore(t)
+
+	r, err := s.AddIPReservation(ctx, IPReservation{Network: "default", Address: "::ffff:10.0.1.5", PodNamespace: "ns", PodName: "db-0"})
+	if err != nil {
+		t.Fatalf("AddIPReservation failed: %v", err)
+	}
+	if r.Address != "10.0.1.5" || r.IPFamily != IPv4 || r.CreatedAt.IsZero() {
+		t.Errorf("Unexpected reservation: %+v", r)
+	}
+	if _, err := s.AddIPReservation(ctx, IPReservation{Network: "default", Address: "2001:db8::5"}); err != nil {
+		t.Fatalf("AddIPReservation failed: %v", err)
+	}
+	if _, err := s.AddIPReservation(ctx, IPReservation{Network: "other", Address: "10.0.1.5"}); err != nil {
+		t.Fatalf("AddIPReservation of the same address on another network failed: %v", err)
+	}
+
+	invalid := []IPReservation{
+		{Network: "default", Address: "10.0.1.300"},
+		{Network: "default", Address: "10.0.1.6", PodName: "db-1"},
+	}
+	for _, r := range invalid {
+		if _, err := s.AddIPReservation(ctx, r); err == nil {
+			t.Errorf("Expected AddIPReservation(%+v) to fail", r)
+		}
+	}
+	duplicates := []IPReservation{
+		{Network: "default", Address: "10.0.1.5"},
+		{Network: "default", Address: "10.0.1.6", PodNamespace: "ns", PodName: "db-0"},
+	}
+	for _, r := range duplicates {
+		if _, err := s.AddIPReservation(ctx, r); !errors.Is(err, ErrReservationExists) {
+			t.Errorf("Expected ErrReservationExists for %+v, got %v", r, err)
+		}
+	}
+
+	all, err := s.ListIPReservations(ctx, "")
+	if err != nil {
+		t.Fatalf("ListIPReservations failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("Expected 3 reservations, got %d", len(all))
+	}
+	defaults, err := s.ListIPReservations(ctx, "default")
+	if err != nil {
+		t.Fatalf("ListIPReservations failed: %v", err)
+	}
+	if len(defaults) != 2 || defaults[0].PodName != "db-0" || defaults[1].Address != "2001:db8::5" {
+		t.Errorf("Unexpected reservations of network default: %+v", defaults)
+	}
+
+	if err := s.RemoveIPReservation(ctx, "default", "10.0.1.5"); err != nil {
+		t.Fatalf("RemoveIPReservation failed: %v", err)
+	}
+	if err := s.RemoveIPReservation(ctx, "default", "10.0.1.5"); !errors.Is(err, ErrReservationNotFound) {
+		t.Errorf("Expected ErrReservationNotFound, got %v", err)
+	}
+}
+
+func TestStore_AllocateIP_Reservations(t *testing.T) {
+	ctx := context.Background()
+	network := "test-network"
+	s := setupStoreWithCIDRs(t, network, "10.0.1.0/29") // .2 to .6 are available
+
+	// 10.0.1.2 must not be assigned to anyone, 10.0.1.4 only to ns/db-0.
+	if _, err := s.AddIPReservation(ctx, IPReservation{Network: network, Address: "10.0.1.2"}); err != nil {
+		t.Fatalf("AddIPReservation failed: %v", err)
+	}
+	if _, err := s.AddIPReservation(ctx, IPReservation{Network: network, Address: "10.0.1.4", PodNamespace: "ns", PodName: "db-0"}); err != nil {
+		t.Fatalf("AddIPReservation failed: %v", err)
+	}
+
+	var got []string
+	for _, container := range []string{"c1", "c2", "c3"} {
+		ip, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: container, IPFamily: IPv4, PodNamespace: "ns", PodName: container})
+		if err != nil {
+			t.Fatalf("AllocateIP for %s failed: %v", container, err)
+		}
+		got = append(got, ip)
+	}
+	if want := []string{"10.0.1.3", "10.0.1.5", "10.0.1.6"}; !slices.Equal(got, want) {
+		t.Errorf("Expected unreserved IPs %v, got %v", want, got)
+	}
+	if _, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c4", IPFamily: IPv4}); !errors.Is(err, ErrNoAvailableIPs) {
+		t.Errorf("Expected ErrNoAvailableIPs with only reserved IPs left, got %v", err)
+	}
+	usage, err := s.GetIPUsage(ctx, network, IPv4)
+	if err != nil {
+		t.Fatalf("GetIPUsage failed: %v", err)
+	}
+	if usage.Reserved != 2 {
+		t.Errorf("Expected the 2 reserved IPs left to count as reserved, got %d", usage.Reserved)
+	}
+
+	// The pod gets its reserved address, and gets it back right after a
+	// restart even though the address is in release cooldown.
+	db0 := AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "db0-a", IPFamily: IPv4, PodNamespace: "ns", PodName: "db-0", RequestedAddress: "10.0.1.6"}
+	ip, cidr, err := s.AllocateIP(ctx, db0)
+	if err != nil {
+		t.Fatalf("AllocateIP for the reserved pod failed: %v", err)
+	}
+	if ip != "10.0.1.4" || cidr != "10.0.1.0/29" {
+		t.Errorf("Expected reserved IP 10.0.1.4 in 10.0.1.0/29, got %s in %s", ip, cidr)
+	}
+	if _, err := s.ReleaseIPByOwner(ctx, network, "db0-a", "eth0", time.Hour); err != nil {
+		t.Fatalf("ReleaseIPByOwner failed: %v", err)
+	}
+	db0.ContainerID = "db0-b"
+	if ip, _, err := s.AllocateIP(ctx, db0); err != nil || ip != "10.0.1.4" {
+		t.Errorf("Expected the restarted pod to get 10.0.1.4 again, got %q, %v", ip, err)
+	}
+	if ip, _, err := s.AllocateIP(ctx, db0); err != nil || ip != "10.0.1.4" {
+		t.Errorf("Expected an idempotent allocation of 10.0.1.4, got %q, %v", ip, err)
+	}
+
+	// A second sandbox of the pod cannot take the reserved address while it is held.
+	db0.ContainerID = "db0-c"
+	if _, _, err := s.AllocateIP(ctx, db0); !errors.Is(err, ErrAddressUnavailable) {
+		t.Errorf("Expected ErrAddressUnavailable while the reserved IP is held, got %v", err)
+	}
+
+	usage, err = s.GetIPUsage(ctx, network, IPv4)
+	if err != nil {
+		t.Fatalf("GetIPUsage failed: %v", err)
+	}
+	// The 3 addresses reserved by the first block count as allocated.
+	if usage.Allocated != 7 {
+		t.Errorf("Expected 7 allocated IPs, got %d", usage.Allocated)
+	}
+}
+
+func TestStore_AllocateIP_RequestedAddress(t *testing.T) {
+	ctx := context.Background()
+	network := "test-network"
+	s := setupStoreWithCIDRs(t, network, "10.0.1.0/29") // .2 to .6 are available
+	if _, err := s.AddIPReservation(ctx, IPReservation{Network: network, Address: "10.0.1.6"}); err != nil {
+		t.Fatalf("AddIPReservation failed: %v", err)
+	}
+	if _, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c0", IPFamily: IPv4}); err != nil {
+		t.Fatalf("AllocateIP failed: %v", err)
+	}
+	if _, err := s.ReleaseIPByOwner(ctx, network, "c0", "eth0", time.Hour); err != nil {
+		t.Fatalf("ReleaseIPByOwner failed: %v", err)
+	}
+
+	tests := []struct {
+		requested string
+		want      string
+	}{
+		{requested: "10.0.1.5", want: "10.0.1.5"},
+		// Already allocated.
+		{requested: "10.0.1.5", want: "10.0.1.3"},
+		// In release cooldown.
+		{requested: "10.0.1.2", want: "10.0.1.4"},
+		// Reserved.
+		{requested: "10.0.1.6", want: ""},
+		// Outside the CIDR blocks of the network.
+		{requested: "10.0.2.1", want: ""},
+	}
+	for i, tc := range tests {
+		params := AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: fmt.Sprintf("container-%d", i), IPFamily: IPv4, RequestedAddress: tc.requested}
+		ip, _, err := s.AllocateIP(ctx, params)
+		if tc.want == "" {
+			if !errors.Is(err, ErrNoAvailableIPs) {
+				t.Errorf("Requested %s: expected ErrNoAvailableIPs, got %q, %v", tc.requested, ip, err)
+			}
+			continue
+		}
+		if err != nil || ip != tc.want {
+			t.Errorf("Requested %s: expected %s, got %q, %v", tc.requested, tc.want, ip, err)
+		}
+	}
+}
+
+func TestStore_AllocateIP_IPv6RequestedAddress(t *testing.T) {
+	ctx := context.Background()
+	network := "test-network"
+	s := setupStoreWithCIDRs(t, network, "2001:db8:1::/64")
+
+	// Addresses beyond the lazily populated entries can be reserved and requested.
+	if _, err := s.AddIPReservation(ctx, IPReservation{Network: network, Address: "2001:db8:1::200", PodNamespace: "ns", PodName: "db-0"}); err != nil {
+		t.Fatalf("AddIPReservation failed: %v", err)
+	}
+	ip, cidr, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "db0", IPFamily: IPv6, PodNamespace: "ns", PodName: "db-0"})
+	if err != nil || ip != "2001:db8:1::200" || cidr != "2001:db8:1::/64" {
+		t.Errorf("Expected reserved IP 2001:db8:1::200 in 2001:db8:1::/64, got %q in %q, %v", ip, cidr, err)
+	}
+	if ip, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c0", IPFamily: IPv6, RequestedAddress: "2001:db8:1::300"}); err != nil || ip != "2001:db8:1::300" {
+		t.Errorf("Expected requested IP 2001:db8:1::300, got %q, %v", ip, err)
+	}
+
+	// Regular allocations keep using the entries populated on the way.
+	ip, _, err = s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c2", IPFamily: IPv6})
+	if err != nil {
+		t.Fatalf("AllocateIP failed: %v", err)
+	}
+	if addr := netip.MustParseAddr(ip); !addr.Less(netip.MustParseAddr("2001:db8:1::300")) {
+		t.Errorf("Expected an address below the requested ones, got %s", ip)
+	}
+	usage, err := s.GetIPUsage(ctx, network, IPv6)
+	if err != nil {
+		t.Fatalf("GetIPUsage failed: %v", err)
+	}
+	if usage.Allocated != 3 {
+		t.Errorf("Expected 3 allocated IPs, got %d", usage.Allocated)
+	}
+}
@@ -19,38 +19,38 @@ package store
 import (
 	"context"
 	"database/sql"
-	_ "embed"
 	"errors"
 	"fmt"
+	"math"
 	"net/netip"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/mattn/go-sqlite3" // SQLite driver
 )
 
-//go:embed schema.sql
-var schemaSQL string
-
 const (
-	// dbSchemaVersion tracks the SQLite schema version to allow safe local
-	// migrations and prevent state corruption across daemon restarts.
-	dbSchemaVersion = 1
-	maxOpenConns    = 10
-	maxIdleConns    = 10
+	maxOpenConns = 10
+	maxIdleConns = 10
 	// DefaultBusyTimeout is the default timeout for SQLite busy handler.
 	DefaultBusyTimeout = 5000 * time.Millisecond
 	// ipv6PopulationBatchSize is the number of IPv6 addresses to populate at once
 	// when a CIDR block has no available IPs in the table.
 	ipv6PopulationBatchSize = 64
+	// MaxTotalIPs is the saturated total_ips recorded for IPv6 ranges too large
+	// to be counted exactly (/66 and larger on 64-bit platforms). Such blocks are
+	// only ever lazily populated by expandIPv6Block.
+	MaxTotalIPs = math.MaxInt
 )
 
 // Store manages database operations for IPAM.
 type Store struct {
-	db  *sql.DB
-	log logr.Logger
+	db     *sql.DB
+	log    logr.Logger
+	dbPath string
 }
 
 // NewStore creates a new Store instance and initializes the database.
@@ -112,8 +112,9 @@ func NewStore(ctx context.Context, log logr.Logger, dbPath string) (*Store, erro
 	db.SetConnMaxLifetime(0)
 
 	store := &Store{
-		db:  db,
-		log: log,
+		db:     db,
+		log:    log,
+		dbPath: dbPath,
 	}
 
 	// Only a single process enters this execution block at a time.
@@ -127,47 +128,13 @@ func NewStore(ctx context.Context, log logr.Logger, dbPath string) (*Store, erro
 	return store, nil
 }
 
-// initSchema creates the necessary tables if they don't exist.
+// initSchema brings the database schema up to date by applying the embedded migrations.
 func (s *Store) initSchema(ctx context.Context) error {
-	var currentVersion int
-	err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&currentVersion)
+	migrations, err := loadMigrations()
 	if err != nil {
-		return fmt.Errorf("failed to check schema version: %w", err)
-	}
-
-	if currentVersion == dbSchemaVersion {
-		s.log.V(4).Info("Database schema already initialized", "version", currentVersion)
-		return nil
-	}
-
-	s.log.Info("Initializing DB schema", "currentVersion", currentVersion, "expectedVersion", dbSchemaVersion)
-
-	// 1. Begin an atomic transaction
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	// Safe to defer; Rollback does nothing if Commit() is successful
-	defer tx.Rollback()
-
-	// 2. Execute the embedded schema.sql file
-	if _, err := tx.ExecContext(ctx, schemaSQL); err != nil {
-		return fmt.Errorf("failed to execute schema.sql: %w", err)
-	}
-
-	// 3. Set User Version
-	setVersion := fmt.Sprintf("PRAGMA user_version = %d;", dbSchemaVersion)
-	if _, err := tx.ExecContext(ctx, setVersion); err != nil {
-		return fmt.Errorf("failed to set user_version: %w", err)
-	}
-
-	// 4. Commit everything atomically
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit schema transaction: %w", err)
+		return err
 	}
-
-	s.log.Info("Database schema initialized or updated successfully")
-	return nil
+	return s.migrate(ctx, migrations)
 }
 
 // Close safely closes the database connection and releases any file locks.
@@ -193,10 +160,27 @@ type AllocateIPParams struct {
 	InterfaceName string
 	ContainerID   string
 	IPFamily      IPFamily
+	// PodName and PodNamespace identify the pod the IP is allocated to. They
+	// are recorded for debugging only and are not part of the allocation key.
+	PodName      string
+	PodNamespace string
+	// Netns is the network namespace path of the pod sandbox. It is used by
+	// the garbage collector to detect sandboxes that no longer exist.
+	Netns string
+	// RequestedAddress is an optional address to allocate if it is free in a
+	// Ready CIDR block of the network. Otherwise another address is allocated.
+	RequestedAddress string
 }
 
 // AllocateIP finds the first available IP from Ready CIDR blocks for a given network and allocates it.
 // It decides which path to take (IPv4 or IPv6) based on the IPFamily in params.
+//
+// If an address of the IP family is reserved for the pod, that address is
+// allocated even if it is in release cooldown, and ErrAddressUnavailable is
+// returned if it is not a free address of a Ready CIDR block. Otherwise the
+// requested address of params is allocated if it is free and not reserved.
+// Reserved addresses are never allocated to other pods, and quarantined
+// addresses and CIDR blocks are never allocated.
 func (s *Store) AllocateIP(ctx context.Context, params AllocateIPParams) (string, string, error) {
 	return s.allocateIP(ctx, params)
 }
@@ -232,18 +216,7 @@ func (s *Store) AddCIDR(ctx context.Context, network, cidr string) error {
 		isIPv6 = true
 	}
 
-	var totalIPs int64
-	bits := prefix.Bits()
-	totalBits := 32
-	if isIPv6 {
-		totalBits = 128
-	}
-	freeBits := totalBits - bits
-	if freeBits >= 62 {
-		totalIPs = 0x7fffffffffffffff // Max int64 for large IPv6 ranges
-	} else {
-		totalIPs = 1 << freeBits
-	}
+	totalIPs := PrefixSize(prefix)
 
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -337,7 +310,7 @@ func (s *Store) AddCIDR(ctx context.Context, network, cidr string) error {
 		// For IPv6, populate the first batch of IPs immediately.
 		var ips []string
 		addr := prefix.Addr()
-		for i := 0; i < ipv6PopulationBatchSize; i++ {
+		for i := 0; i < ipv6PopulationBatchSize && prefix.Contains(addr); i++ {
 			ips = append(ips, addr.String())
 			addr = addr.Next()
 		}
@@ -367,6 +340,16 @@ func (s *Store) AddCIDR(ctx context.Context, network, cidr string) error {
 	return nil
 }
 
+// PrefixSize returns the number of addresses contained in prefix, saturating at
+// MaxTotalIPs for large IPv6 ranges that cannot be represented as an int.
+func PrefixSize(prefix netip.Prefix) int {
+	freeBits := prefix.Addr().BitLen() - prefix.Bits()
+	if freeBits >= strconv.IntSize-2 {
+		return MaxTotalIPs
+	}
+	return 1 << freeBits
+}
+
 // ReleaseIPByOwner updates all IP addresses matching the network, container id and interface name to be is_allocated = FALSE, and sets release_at timestamp to be now + releaseCooldown. It also decrements allocated_ips count in cidr_blocks.
 func (s *Store) ReleaseIPByOwner(ctx context.Context, network, containerID, interfaceName string, releaseCooldown time.Duration) ([]string, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
@@ -422,6 +405,9 @@ func (s *Store) ReleaseIPByOwner(ctx context.Context, network, containerID, inte
 		if err != nil {
 			return nil, fmt.Errorf("failed to release IP %d: %w", r.id, err)
 		}
+		if err := recordAddressEventTx(ctx, tx, HistoryReleased, r.id); err != nil {
+			return nil, err
+		}
 
 		_, err = tx.ExecContext(ctx, `
 			UPDATE cidr_blocks
@@ -441,6 +427,70 @@ func (s *Store) ReleaseIPByOwner(ctx context.Context, network, containerID, inte
 	return releasedIPs, nil
 }
 
+// HasAllocation reports whether the container interface holds an IP of the IP family on the network.
+func (s *Store) HasAllocation(ctx context.Context, network, containerID, interfaceName string, ipFamily IPFamily) (bool, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT i.id
+		FROM ip_addresses i
+		JOIN cidr_blocks c ON i.cidr_block_id = c.id
+		WHERE c.network = ? AND c.ip_family = ? AND i.container_id = ? AND i.interface_name = ? AND i.is_allocated = TRUE
+		LIMIT 1
+	`, network, ipFamily, containerID, interfaceName).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check allocation: %w", err)
+	}
+	return true, nil
+}
+
+// ReleaseUnusedIP releases an IP allocated to a container interface that was
+// never handed out to the pod, e.g. because the allocation of the other IP
+// family failed. Unlike ReleaseIPByOwner, it only releases the given address
+// and skips the release cooldown. It returns ErrIPNotAllocated if the address
+// is not allocated to the container interface.
+func (s *Store) ReleaseUnusedIP(ctx context.Context, network, containerID, interfaceName, address string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id, cidrBlockID int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE ip_addresses
+		SET is_allocated = FALSE, release_at = NULL
+		WHERE address = ? AND container_id = ? AND interface_name = ? AND is_allocated = TRUE
+			AND cidr_block_id IN (SELECT id FROM cidr_blocks WHERE network = ?)
+		RETURNING id, cidr_block_id
+	`, address, containerID, interfaceName, network).Scan(&id, &cidrBlockID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %s is not allocated to container %s interface %s on network %s", ErrIPNotAllocated, address, containerID, interfaceName, network)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release IP %s: %w", address, err)
+	}
+	if err := recordAddressEventTx(ctx, tx, HistoryReleased, id); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE cidr_blocks
+		SET allocated_ips = allocated_ips - 1
+		WHERE id = ?
+	`, cidrBlockID)
+	if err != nil {
+		return fmt.Errorf("failed to update cidr_block %d count: %w", cidrBlockID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit release transaction: %w", err)
+	}
+	return nil
+}
+
 // CIDRBlock holds the metadata for a CIDR block.
 type CIDRBlock struct {
 	ID           int64
@@ -489,6 +539,14 @@ func (s *Store) DeleteCIDRBlock(ctx context.Context, id int64) error {
 	return nil
 }
 
+// MarkCIDRBlockDeleting transitions a CIDR block to the Deleting state, e.g.
+// when it is known to be released by GCE, so that it can be deleted.
+func (s *Store) MarkCIDRBlockDeleting(ctx context.Context, id int64) error {
+	nowMilli := time.Now().UTC().UnixMilli()
+	_, err := s.db.ExecContext(ctx, "UPDATE cidr_blocks SET state = ?, updated_at = ? WHERE id = ?", StateDeleting, nowMilli, id)
+	return err
+}
+
 // DrainCIDRBlock transitions a CIDR block to the Draining state.
 func (s *Store) DrainCIDRBlock(ctx context.Context, id int64) error {
 	nowMilli := time.Now().UTC().UnixMilli()
@@ -553,12 +611,16 @@ func (s *Store) ExpireDrainingCIDRBlocks(ctx context.Context, network string, ip
 
 // allocateIPTx is a helper that executes the IP allocation within an existing transaction.
 // It returns sql.ErrNoRows if the CIDR block is full or not found, allowing the caller to try another block.
-func (s *Store) allocateIPTx(ctx context.Context, tx *sql.Tx, cidrBlockID int64, interfaceName, containerID string) (string, string, error) {
+func (s *Store) allocateIPTx(ctx context.Context, tx *sql.Tx, cidrBlockID int64, params AllocateIPParams) (string, string, error) {
 	// 1. Fetch CIDR range for the given ID and verify it is not full
 	var cidrRange string
 	err := tx.QueryRowContext(ctx, `
-		SELECT cidr FROM cidr_blocks
+		SELECT cidr FROM cidr_blocks c
 		WHERE id = ? AND total_ips > allocated_ips AND state = 'Ready'
+			AND NOT EXISTS (
+				SELECT 1 FROM ip_quarantines q
+				WHERE q.network = c.network AND q.cidr = c.cidr
+			)
 	`, cidrBlockID).Scan(&cidrRange)
 
 	if err != nil {
@@ -568,27 +630,50 @@ func (s *Store) allocateIPTx(ctx context.Context, tx *sql.Tx, cidrBlockID int64,
 		return "", "", fmt.Errorf("failed to query cidr_block: %w", err)
 	}
 
-	// 2. Find the first available entry and mark it as allocated
+	// 2. Find the first available entry
+	var id int64
 	var address string
+	var releaseAt sql.NullInt64
 	nowMilli := time.Now().UTC().UnixMilli()
 	err = tx.QueryRowContext(ctx, `
-		UPDATE ip_addresses
-		SET is_allocated = TRUE, container_id = ?, interface_name = ?, allocated_at = ?
-		WHERE id = (
-			SELECT id FROM ip_addresses
-			WHERE cidr_block_id = ? AND is_allocated = FALSE AND (release_at IS NULL OR release_at <= ?)
-			ORDER BY id ASC
-			LIMIT 1
-		)
-		RETURNING address
-	`, containerID, interfaceName, nowMilli, cidrBlockID, nowMilli).Scan(&address)
+		SELECT id, address, release_at FROM ip_addresses
+		WHERE cidr_block_id = ? AND is_allocated = FALSE AND (release_at IS NULL OR release_at <= ?)
+			AND NOT EXISTS (
+				SELECT 1 FROM ip_reservations r
+				WHERE r.network = ? AND r.address = ip_addresses.address
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM ip_quarantines q
+				WHERE q.network = ? AND q.address = ip_addresses.address
+			)
+		ORDER BY id ASC
+		LIMIT 1
+	`, cidrBlockID, nowMilli, params.Network, params.Network).Scan(&id, &address, &releaseAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "", "", sql.ErrNoRows
 		}
+		return "", "", fmt.Errorf("failed to find available ip: %w", err)
+	}
+
+	// 3. Mark it as allocated, which ends the release cooldown it may still have
+	if releaseAt.Valid {
+		if err := recordCooldownsExpiredTx(ctx, tx, "i.id = ?", id); err != nil {
+			return "", "", err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ip_addresses
+		SET is_allocated = TRUE, container_id = ?, interface_name = ?, pod_name = ?, pod_namespace = ?, netns = ?, allocated_at = ?, release_at = NULL
+		WHERE id = ?
+	`, params.ContainerID, params.InterfaceName, params.PodName, params.PodNamespace, params.Netns, nowMilli, id)
+	if err != nil {
 		return "", "", fmt.Errorf("failed to allocate ip: %w", err)
 	}
+	if err := recordAddressEventTx(ctx, tx, HistoryAllocated, id); err != nil {
+		return "", "", err
+	}
 
 	// Also increment allocated_ips in cidr_blocks to keep it in sync
 	_, err = tx.ExecContext(ctx, `
@@ -625,10 +710,37 @@ func (s *Store) allocateIP(ctx context.Context, params AllocateIPParams) (string
 		return "", "", fmt.Errorf("failed during fast-path idempotency check: %w", err)
 	}
 
-	// 2. Query available CIDRs in order of block ID (oldest first for defragmentation)
+	// 2. Allocate the address reserved for the pod, or else the requested address if it is free.
+	reservedAddress, reserved, err := s.reservedAddressForPod(ctx, params)
+	if err != nil {
+		return "", "", err
+	}
+	if reserved {
+		ip, cidr, err := s.tryAllocateAddress(ctx, params, reservedAddress, true)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to allocate address %s reserved for pod %s/%s: %w", reservedAddress, params.PodNamespace, params.PodName, err)
+		}
+		return ip, cidr, nil
+	}
+	if params.RequestedAddress != "" {
+		ip, cidr, err := s.tryAllocateAddress(ctx, params, params.RequestedAddress, false)
+		if err == nil {
+			return ip, cidr, nil
+		}
+		if !errors.Is(err, ErrAddressUnavailable) {
+			return "", "", err
+		}
+		s.log.Info("Requested address is unavailable, allocating another address", "network", params.Network, "containerID", params.ContainerID, "requestedAddress", params.RequestedAddress, "reason", err.Error())
+	}
+
+	// 3. Query available CIDRs in order of block ID (oldest first for defragmentation)
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id FROM cidr_blocks
+		SELECT id FROM cidr_blocks c
 		WHERE network = ? AND ip_family = ? AND total_ips > allocated_ips AND state = 'Ready'
+			AND NOT EXISTS (
+				SELECT 1 FROM ip_quarantines q
+				WHERE q.network = c.network AND q.cidr = c.cidr
+			)
 		ORDER BY id ASC
 	`, params.Network, params.IPFamily)
 	if err != nil {
@@ -652,7 +764,7 @@ func (s *Store) allocateIP(ctx context.Context, params AllocateIPParams) (string
 		return "", "", fmt.Errorf("%w: no available cidr blocks found for network %s", ErrNoAvailableIPs, params.Network)
 	}
 
-	// 3. Loop and try to allocate with short transactions
+	// 4. Loop and try to allocate with short transactions
 	for _, cidrBlockID := range cidrBlockIDs {
 		ip, cidr, err := s.tryAllocateIPInBlock(ctx, params, cidrBlockID)
 		if err == nil {
@@ -667,9 +779,11 @@ func (s *Store) allocateIP(ctx context.Context, params AllocateIPParams) (string
 
 	if params.IPFamily == IPv6 && len(cidrBlockIDs) > 0 {
 		// No IPs found in any block, try to expand one of them.
+		expanded := false
 		for _, cidrBlockID := range cidrBlockIDs {
 			err := s.expandIPv6Block(ctx, cidrBlockID)
 			if err == nil {
+				expanded = true
 				break // Successfully expanded one block!
 			}
 			if errors.Is(err, ErrCidrBlockExhausted) {
@@ -679,6 +793,13 @@ func (s *Store) allocateIP(ctx context.Context, params AllocateIPParams) (string
 			return "", "", fmt.Errorf("failed to expand IPv6 block %d: %w", cidrBlockID, err)
 		}
 
+		if !expanded {
+			// Every block is either fully populated or only has addresses in
+			// release cooldown. Retrying would loop forever, so surface the
+			// exhaustion and let the caller trigger a dynamic scale-up.
+			return "", "", fmt.Errorf("%w: all ipv6 cidr blocks exhausted for network %s", ErrNoAvailableIPs, params.Network)
+		}
+
 		// Whether we expanded it ourselves or another concurrent worker did,
 		// we must retry the allocation once more.
 		return s.allocateIP(ctx, params)
@@ -696,8 +817,32 @@ func (s *Store) tryAllocateIPInBlock(ctx context.Context, params AllocateIPParam
 	}
 	defer tx.Rollback()
 
+	address, cidrRange, found, err := s.existingAllocationTx(ctx, tx, params)
+	if err != nil {
+		return "", "", err
+	}
+	if found {
+		return address, cidrRange, nil
+	}
+
+	ip, cidr, err := s.allocateIPTx(ctx, tx, cidrBlockID, params)
+	if err != nil {
+		return "", "", err // Propagates sql.ErrNoRows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return ip, cidr, nil
+}
+
+// existingAllocationTx is the slow-path idempotency check run within the
+// allocation transaction. It returns the address already allocated to the
+// owner of params, if any.
+func (s *Store) existingAllocationTx(ctx context.Context, tx *sql.Tx, params AllocateIPParams) (string, string, bool, error) {
 	var address, cidrRange string
-	err = tx.QueryRowContext(ctx, `
+	err := tx.QueryRowContext(ctx, `
 		SELECT i.address, c.cidr
 		FROM ip_addresses i
 		JOIN cidr_blocks c ON i.cidr_block_id = c.id
@@ -707,22 +852,12 @@ func (s *Store) tryAllocateIPInBlock(ctx context.Context, params AllocateIPParam
 
 	if err == nil {
 		s.log.V(4).Info("Idempotency check hit (slow path), returning existing allocation", "containerID", params.ContainerID, "interfaceName", params.InterfaceName, "address", address, "cidr", cidrRange)
-		return address, cidrRange, nil
+		return address, cidrRange, true, nil
 	}
 	if err != sql.ErrNoRows {
-		return "", "", fmt.Errorf("failed during slow-path idempotency check: %w", err)
+		return "", "", false, fmt.Errorf("failed during slow-path idempotency check: %w", err)
 	}
-
-	ip, cidr, err := s.allocateIPTx(ctx, tx, cidrBlockID, params.InterfaceName, params.ContainerID)
-	if err != nil {
-		return "", "", err // Propagates sql.ErrNoRows
-	}
-
-	if err := tx.Commit(); err != nil {
-		return "", "", fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	return ip, cidr, nil
+	return "", "", false, nil
 }
 
 // getNextIPv6StartAddr finds the last inserted IP for a CIDR block and returns the next address to use.
@@ -842,18 +977,30 @@ func (s *Store) MarkCIDRBlockAsDeletingForTest(ctx context.Context, id int64) er
 	return err
 }
 
-// NetworkIPUsage holds the allocated, cooldown, total, and draining IP counts for a network.
+// NetworkIPUsage holds the allocated, cooldown, total, draining, and quarantined IP counts for a network.
 type NetworkIPUsage struct {
 	Allocated int
 	Cooldown  int
 	Total     int
 	Draining  int
+	// Quarantined is the number of IPs of Ready CIDR blocks that are neither
+	// allocated nor in release cooldown, but cannot be allocated because they
+	// or their CIDR block are quarantined.
+	Quarantined int
+	// Reserved is the number of IPs of Ready CIDR blocks that are neither
+	// allocated, in release cooldown nor quarantined, but are only allocated
+	// to the pod they are reserved for, if any.
+	Reserved int
 }
 
-// GetIPUsage fetches the allocated, cooldown, total, and draining IP counts for a specific network and IP family.
+// GetIPUsage fetches the allocated, cooldown, total, draining, quarantined, and reserved IP counts for a specific network and IP family.
 // CIDR blocks marked as Deleting are excluded from all counts since they are scheduled for removal by GCE.
+// Large IPv6 blocks are stored with a saturated total_ips, so the total and draining counts are summed
+// as floating point values and clamped to MaxTotalIPs instead of overflowing.
 func (s *Store) GetIPUsage(ctx context.Context, network string, ipFamily IPFamily) (NetworkIPUsage, error) {
 	var usage NetworkIPUsage
+	var total, draining, quarantinedBlocks float64
+	var quarantinedAddresses int
 	nowMilli := time.Now().UTC().UnixMilli()
 	err := s.db.QueryRowContext(ctx, `
 		SELECT
@@ -864,11 +1011,35 @@ func (s *Store) GetIPUsage(ctx context.Context, network string, ipFamily IPFamil
 				JOIN cidr_blocks cb ON i.cidr_block_id = cb.id
 				WHERE cb.network = ? AND cb.state != ? AND cb.ip_family = ? AND i.is_allocated = FALSE AND i.release_at > ?
 			) AS cooldown,
-			IFNULL(SUM(total_ips), 0) AS total_ips,
-			IFNULL(SUM(CASE WHEN state = ? THEN total_ips ELSE 0 END), 0) AS draining_ips
+			TOTAL(total_ips) AS total_ips,
+			TOTAL(CASE WHEN state = ? THEN total_ips ELSE 0 END) AS draining_ips,
+			TOTAL(CASE WHEN state = ? AND EXISTS (
+				SELECT 1 FROM ip_quarantines q WHERE q.network = c.network AND q.cidr = c.cidr
+			) THEN total_ips - allocated_ips ELSE 0 END) AS quarantined_block_ips,
+			(
+				SELECT COUNT(i.id)
+				FROM ip_addresses i
+				JOIN cidr_blocks cb ON i.cidr_block_id = cb.id
+				JOIN ip_quarantines q ON q.network = cb.network AND q.address = i.address
+				WHERE cb.network = ? AND cb.state = ? AND cb.ip_family = ? AND i.is_allocated = FALSE
+					AND (i.release_at IS NULL OR i.release_at <= ?)
+					AND NOT EXISTS (SELECT 1 FROM ip_quarantines qb WHERE qb.network = cb.network AND qb.cidr = cb.cidr)
+			) AS quarantined_addresses,
+			(
+				SELECT COUNT(i.id)
+				FROM ip_addresses i
+				JOIN cidr_blocks cb ON i.cidr_block_id = cb.id
+				JOIN ip_reservations r ON r.network = cb.network AND r.address = i.address
+				WHERE cb.network = ? AND cb.state = ? AND cb.ip_family = ? AND i.is_allocated = FALSE
+					AND (i.release_at IS NULL OR i.release_at <= ?)
+					AND NOT EXISTS (SELECT 1 FROM ip_quarantines qb WHERE qb.network = cb.network AND qb.cidr = cb.cidr)
+					AND NOT EXISTS (SELECT 1 FROM ip_quarantines qa WHERE qa.network = cb.network AND qa.address = i.address)
+			) AS reserved
 		FROM cidr_blocks c
 		WHERE network = ? AND ip_family = ? AND c.state != ?
-	`, network, StateDeleting, ipFamily, nowMilli, StateDraining, network, ipFamily, StateDeleting).Scan(&usage.Allocated, &usage.Cooldown, &usage.Total, &usage.Draining)
+	`, network, StateDeleting, ipFamily, nowMilli, StateDraining, StateReady, network, StateReady, ipFamily, nowMilli,
+		network, StateReady, ipFamily, nowMilli,
+		network, ipFamily, StateDeleting).Scan(&usage.Allocated, &usage.Cooldown, &total, &draining, &quarantinedBlocks, &quarantinedAddresses, &usage.Reserved)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -876,9 +1047,55 @@ func (s *Store) GetIPUsage(ctx context.Context, network string, ipFamily IPFamil
 		}
 		return NetworkIPUsage{}, fmt.Errorf("failed to query IP usage for network %s: %w", network, err)
 	}
+	usage.Total = clampTotalIPs(total)
+	usage.Draining = clampTotalIPs(draining)
+	usage.Quarantined = min(clampTotalIPs(quarantinedBlocks)+quarantinedAddresses, MaxTotalIPs)
 	return usage, nil
 }
 
+// CountAvailableIPs returns the number of IPs of a network and IP family that
+// can be allocated without adding capacity, i.e. the IPs of Ready CIDR blocks
+// that are neither allocated, in release cooldown, reserved nor quarantined.
+// Like the total in GetIPUsage, the count saturates at MaxTotalIPs.
+func (s *Store) CountAvailableIPs(ctx context.Context, network string, ipFamily IPFamily) (int, error) {
+	var free float64
+	var unavailable int
+	nowMilli := time.Now().UTC().UnixMilli()
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			(
+				SELECT TOTAL(total_ips - allocated_ips)
+				FROM cidr_blocks c
+				WHERE network = ? AND ip_family = ? AND state = ?
+					AND NOT EXISTS (SELECT 1 FROM ip_quarantines q WHERE q.network = c.network AND q.cidr = c.cidr)
+			) AS free,
+			(
+				SELECT COUNT(i.id)
+				FROM ip_addresses i
+				JOIN cidr_blocks c ON i.cidr_block_id = c.id
+				WHERE c.network = ? AND c.ip_family = ? AND c.state = ? AND i.is_allocated = FALSE
+					AND NOT EXISTS (SELECT 1 FROM ip_quarantines q WHERE q.network = c.network AND q.cidr = c.cidr)
+					AND (i.release_at > ? OR EXISTS (
+						SELECT 1 FROM ip_reservations r WHERE r.network = c.network AND r.address = i.address
+					) OR EXISTS (
+						SELECT 1 FROM ip_quarantines q WHERE q.network = c.network AND q.address = i.address
+					))
+			) AS unavailable
+	`, network, ipFamily, StateReady, network, ipFamily, StateReady, nowMilli).Scan(&free, &unavailable)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count available IPs for network %s: %w", network, err)
+	}
+	return max(clampTotalIPs(free)-unavailable, 0), nil
+}
+
+// clampTotalIPs converts a floating point IP count into an int, saturating at MaxTotalIPs.
+func clampTotalIPs(v float64) int {
+	if v >= float64(MaxTotalIPs) {
+		return MaxTotalIPs
+	}
+	return int(v)
+}
+
 // GetReadyCIDRBlocksSorted fetches all Ready CIDR blocks for a network and IP family, sorted by created_at DESC.
 func (s *Store) GetReadyCIDRBlocksSorted(ctx context.Context, network string, ipFamily IPFamily) ([]CIDRBlock, error) {
 	rows, err := s.db.QueryContext(ctx, "SELECT id, total_ips, allocated_ips, cidr FROM cidr_blocks WHERE network = ? AND ip_family = ? AND state = 'Ready' ORDER BY created_at DESC, id DESC", network, ipFamily)
@@ -902,6 +1119,28 @@ func (s *Store) GetReadyCIDRBlocksSorted(ctx context.Context, network string, ip
 	return result, nil
 }
 
+// GetAllCIDRBlocks fetches all CIDR blocks of all networks, in any state.
+func (s *Store) GetAllCIDRBlocks(ctx context.Context) ([]CIDRBlock, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, total_ips, allocated_ips, cidr, network FROM cidr_blocks ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []CIDRBlock
+	for rows.Next() {
+		var r CIDRBlock
+		if err := rows.Scan(&r.ID, &r.TotalIPs, &r.AllocatedIPs, &r.CIDR, &r.Network); err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return result, nil
+}
+
 // GetAllNetworks fetches all unique networks from cidr_blocks, excluding those in Deleting state.
 func (s *Store) GetAllNetworks(ctx context.Context) ([]string, error) {
 	rows, err := s.db.QueryContext(ctx, "SELECT DISTINCT network FROM cidr_blocks WHERE state != 'Deleting'")
@@ -943,3 +1182,78 @@ func (s *Store) CheckAllocation(ctx context.Context, network, containerID, inter
 	}
 	return nil
 }
+
+// IPOwner is a container interface holding one or more allocated IPs of a network.
+type IPOwner struct {
+	Network       string
+	ContainerID   string
+	InterfaceName string
+	PodNamespace  string
+	PodName       string
+	Netns         string
+	// Addresses are the IPs held by the owner, ordered by ID.
+	Addresses []string
+	// AllocatedAt is the time the oldest of the IPs was allocated, zero if unknown.
+	AllocatedAt time.Time
+}
+
+// ListIPOwners returns all container interfaces currently holding an IP, ordered
+// by the ID of their first IP. The reserved addresses of IPv4 blocks, which are
+// allocated without a container ID, are not included.
+func (s *Store) ListIPOwners(ctx context.Context) ([]IPOwner, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.network, i.container_id, i.interface_name, i.pod_namespace, i.pod_name, i.netns, i.address, i.allocated_at
+		FROM ip_addresses i
+		JOIN cidr_blocks c ON i.cidr_block_id = c.id
+		WHERE i.is_allocated = TRUE AND i.container_id != ''
+		ORDER BY i.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query IP owners: %w", err)
+	}
+	defer rows.Close()
+
+	type ownerKey struct {
+		network, containerID, interfaceName string
+	}
+	var owners []IPOwner
+	index := map[ownerKey]int{}
+	for rows.Next() {
+		var network, containerID, address string
+		var interfaceName, podNamespace, podName, netns sql.NullString
+		var allocatedAt sql.NullInt64
+		if err := rows.Scan(&network, &containerID, &interfaceName, &podNamespace, &podName, &netns, &address, &allocatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		key := ownerKey{network: network, containerID: containerID, interfaceName: interfaceName.String}
+		i, ok := index[key]
+		if !ok {
+			i = len(owners)
+			index[key] = i
+			owners = append(owners, IPOwner{
+				Network:       network,
+				ContainerID:   containerID,
+				InterfaceName: interfaceName.String,
+			})
+		}
+		owner := &owners[i]
+		owner.Addresses = append(owner.Addresses, address)
+		// Dual-stack IPs of an owner are allocated by separate requests, keep
+		// whichever identifies the pod.
+		if owner.PodNamespace == "" && owner.PodName == "" {
+			owner.PodNamespace = podNamespace.String
+			owner.PodName = podName.String
+		}
+		if owner.Netns == "" {
+			owner.Netns = netns.String
+		}
+		if t := unixMilliOrZero(allocatedAt); !t.IsZero() && (owner.AllocatedAt.IsZero() || t.Before(owner.AllocatedAt)) {
+			owner.AllocatedAt = t
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return owners, nil
+}
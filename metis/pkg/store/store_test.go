@@ -21,7 +21,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net/netip"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -133,7 +135,7 @@ func TestNewStore_SchemaVerification(t *testing.T) {
 
 	// Define the expected schema components.
 	expectedTables := []string{"cidr_blocks", "ip_addresses"}
-	expectedIndexes := []string{"idx_available_ips", "idx_ip_idempotency"}
+	expectedIndexes := []string{"idx_available_ips", "idx_ip_idempotency", "idx_ip_pod"}
 	expectedTriggers := []string{"update_cidr_blocks_updated_at", "update_ip_addresses_updated_at"}
 
 	// Verify Tables.
@@ -658,6 +660,51 @@ func TestStore_ReleaseIPByOwner(t *testing.T) {
 	}
 }
 
+func TestStore_ReleaseUnusedIP(t *testing.T) {
+	ctx := context.Background()
+	network := "test-network"
+	s := setupStoreWithCIDRs(t, network, "10.0.1.0/29")
+
+	params := AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "container-1", IPFamily: IPv4}
+	ip, _, err := s.AllocateIP(ctx, params)
+	if err != nil {
+		t.Fatalf("AllocateIP failed: %v", err)
+	}
+	if held, err := s.HasAllocation(ctx, network, "container-1", "eth0", IPv4); err != nil || !held {
+		t.Fatalf("Expected container-1 to hold an IPv4 address, got %t, %v", held, err)
+	}
+	if held, err := s.HasAllocation(ctx, network, "container-1", "eth0", IPv6); err != nil || held {
+		t.Fatalf("Expected container-1 not to hold an IPv6 address, got %t, %v", held, err)
+	}
+
+	if err := s.ReleaseUnusedIP(ctx, network, "container-2", "eth0", ip); !errors.Is(err, ErrIPNotAllocated) {
+		t.Errorf("Expected ErrIPNotAllocated for another container, got %v", err)
+	}
+	if err := s.ReleaseUnusedIP(ctx, network, "container-1", "eth0", ip); err != nil {
+		t.Fatalf("ReleaseUnusedIP failed: %v", err)
+	}
+	if held, err := s.HasAllocation(ctx, network, "container-1", "eth0", IPv4); err != nil || held {
+		t.Errorf("Expected container-1 not to hold an IPv4 address after the release, got %t, %v", held, err)
+	}
+
+	// The address skipped the cooldown and is allocated again right away.
+	params.ContainerID = "container-3"
+	again, _, err := s.AllocateIP(ctx, params)
+	if err != nil {
+		t.Fatalf("AllocateIP failed: %v", err)
+	}
+	if again != ip {
+		t.Errorf("Expected the released IP %s to be allocated again, got %s", ip, again)
+	}
+	usage, err := s.GetIPUsage(ctx, network, IPv4)
+	if err != nil {
+		t.Fatalf("GetIPUsage failed: %v", err)
+	}
+	if usage.Allocated != 4 || usage.Cooldown != 0 {
+		t.Errorf("Expected 4 allocated IPs and none in cooldown, got %+v", usage)
+	}
+}
+
 func TestStore_AllocateIPv4_FallbackAndCooldown(t *testing.T) {
 	network := "test-network"
 	cidr1 := "10.0.1.0/29" // 5 available addresses (.2 to .6)
@@ -1844,7 +1891,7 @@ func TestStore_GetIPUsage(t *testing.T) {
 	}
 }
 
-func setupTestStore(t *testing.T) *Store {
+func setupTestStore(t testing.TB) *Store {
 	t.Helper()
 	dbPath := filepath.Join(t.TempDir(), "test.sqlite")
 	s, err := NewStore(context.Background(), logr.Discard(), dbPath)
@@ -1859,7 +1906,7 @@ func setupTestStore(t *testing.T) *Store {
 	return s
 }
 
-func setupStoreWithCIDRs(t *testing.T, network string, cidrs ...string) *Store {
+func setupStoreWithCIDRs(t testing.TB, network string, cidrs ...string) *Store {
 	t.Helper()
 	s := setupTestStore(t)
 	for _, cidr := range cidrs {
@@ -1869,3 +1916,148 @@ func setupStoreWithCIDRs(t *testing.T, network string, cidrs ...string) *Store {
 	}
 	return s
 }
+
+func TestStore_AllocateIPv6_ExhaustedWithCooldown(t *testing.T) {
+	network := "test-net"
+	ctx := context.Background()
+	s := setupStoreWithCIDRs(t, network, "2001:db8:1::/126") // 4 IPs
+
+	var populated int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM ip_addresses`).Scan(&populated); err != nil {
+		t.Fatalf("Failed to count ip_addresses: %v", err)
+	}
+	if populated != 4 {
+		t.Fatalf("Expected 4 populated IPs for /126, got %d", populated)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: fmt.Sprintf("c-%d", i), IPFamily: IPv6}); err != nil {
+			t.Fatalf("Allocation failed at index %d: %v", i, err)
+		}
+	}
+	if _, err := s.ReleaseIPByOwner(ctx, network, "c-0", "eth0", 10*time.Second); err != nil {
+		t.Fatalf("ReleaseIPByOwner failed: %v", err)
+	}
+
+	// The only free IP is in cooldown and the block is fully populated, so
+	// there is nothing left to expand.
+	_, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c-new", IPFamily: IPv6})
+	if !errors.Is(err, ErrNoAvailableIPs) {
+		t.Fatalf("Expected ErrNoAvailableIPs, got %v", err)
+	}
+}
+
+func TestStore_CountAvailableIPs(t *testing.T) {
+	ctx := context.Background()
+	network := "test-network"
+	s := setupStoreWithCIDRs(t, network, "10.0.1.0/29", "10.0.2.0/29") // 5 + 8 available addresses
+
+	if _, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c1", IPFamily: IPv4}); err != nil {
+		t.Fatalf("AllocateIP failed: %v", err)
+	}
+	if _, _, err := s.AllocateIP(ctx, AllocateIPParams{Network: network, InterfaceName: "eth0", ContainerID: "c2", IPFamily: IPv4}); err != nil {
+		t.Fatalf("AllocateIP failed: %v", err)
+	}
+	if _, err := s.ReleaseIPByOwner(ctx, network, "c2", "eth0", time.Hour); err != nil {
+		t.Fatalf("ReleaseIPByOwner failed: %v", err)
+	}
+	if _, err := s.AddIPReservation(ctx, IPReservation{Network: network, Address: "10.0.1.6"}); err != nil {
+		t.Fatalf("AddIPReservation failed: %v", err)
+	}
+	blockID, _, err := s.GetCIDRBlock(ctx, "10.0.2.0/29", network)
+	if err != nil {
+		t.Fatalf("GetCIDRBlock failed: %v", err)
+	}
+	if err := s.DrainCIDRBlock(ctx, blockID); err != nil {
+		t.Fatalf("DrainCIDRBlock failed: %v", err)
+	}
+
+	// Of the first block, one IP is allocated, one in cooldown and one reserved.
+	got, err := s.CountAvailableIPs(ctx, network, IPv4)
+	if err != nil {
+		t.Fatalf("CountAvailableIPs failed: %v", err)
+	}
+	if got != 2 {
+		t.Errorf("Expected 2 available IPs, got %d", got)
+	}
+
+	if got, err := s.CountAvailableIPs(ctx, network, IPv6); err != nil || got != 0 {
+		t.Errorf("Expected no available IPv6 addresses, got %d, %v", got, err)
+	}
+}
+
+func TestStore_GetIPUsage_LargeIPv6(t *testing.T) {
+	network := "test-net"
+	s := setupStoreWithCIDRs(t, network, "2001:db8:1::/64", "2001:db8:2::/64", "2001:db8:3::/120")
+
+	usage, err := s.GetIPUsage(context.Background(), network, IPv6)
+	if err != nil {
+		t.Fatalf("GetIPUsage failed: %v", err)
+	}
+	if usage.Total != MaxTotalIPs {
+		t.Errorf("Expected IPv6 Total to saturate at %d, got %d", MaxTotalIPs, usage.Total)
+	}
+}
+
+func TestPrefixSize(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   int
+	}{
+		{prefix: "10.0.0.0/24", want: 256},
+		{prefix: "10.0.0.0/32", want: 1},
+		{prefix: "2001:db8::/120", want: 256},
+		// The largest prefix counted exactly, /67 on 64-bit platforms.
+		{prefix: fmt.Sprintf("2001:db8::/%d", 128-(strconv.IntSize-3)), want: 1 << (strconv.IntSize - 3)},
+		{prefix: "2001:db8::/66", want: MaxTotalIPs},
+		{prefix: "2001:db8::/64", want: MaxTotalIPs},
+	}
+	for _, tc := range tests {
+		t.Run(tc.prefix, func(t *testing.T) {
+			if got := PrefixSize(netip.MustParsePrefix(tc.prefix)); got != tc.want {
+				t.Errorf("PrefixSize(%s) = %d, want %d", tc.prefix, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStore_ListIPOwners(t *testing.T) {
+	ctx := context.Background()
+	s := setupStoreWithCIDRs(t, "net-a", "10.0.0.0/28", "2001:db8::/120")
+
+	for i, params := range []AllocateIPParams{
+		{Network: "net-a", InterfaceName: "eth0", ContainerID: "c1", IPFamily: IPv4, PodName: "pod-1", PodNamespace: "ns-1", Netns: "/var/run/netns/cni-1"},
+		{Network: "net-a", InterfaceName: "eth0", ContainerID: "c1", IPFamily: IPv6, PodName: "pod-1", PodNamespace: "ns-1", Netns: "/var/run/netns/cni-1"},
+		{Network: "net-a", InterfaceName: "eth0", ContainerID: "c2", IPFamily: IPv4},
+		{Network: "net-a", InterfaceName: "eth0", ContainerID: "c3", IPFamily: IPv4, PodName: "pod-3", PodNamespace: "ns-3"},
+	} {
+		if _, _, err := s.AllocateIP(ctx, params); err != nil {
+			t.Fatalf("AllocateIP %d failed: %v", i, err)
+		}
+	}
+	if _, err := s.ReleaseIPByOwner(ctx, "net-a", "c3", "eth0", time.Minute); err != nil {
+		t.Fatalf("ReleaseIPByOwner failed: %v", err)
+	}
+
+	owners, err := s.ListIPOwners(ctx)
+	if err != nil {
+		t.Fatalf("ListIPOwners failed: %v", err)
+	}
+	// Reserved and released addresses are not owned.
+	if len(owners) != 2 {
+		t.Fatalf("Expected 2 owners, got %d: %+v", len(owners), owners)
+	}
+	c1 := owners[0]
+	if c1.ContainerID != "c1" || c1.Network != "net-a" || c1.InterfaceName != "eth0" || c1.PodNamespace != "ns-1" || c1.PodName != "pod-1" || c1.Netns != "/var/run/netns/cni-1" {
+		t.Errorf("Unexpected owner: %+v", c1)
+	}
+	if fmt.Sprint(c1.Addresses) != "[10.0.0.2 2001:db8::]" {
+		t.Errorf("Expected the dual-stack addresses of c1, got %v", c1.Addresses)
+	}
+	if c1.AllocatedAt.IsZero() {
+		t.Errorf("Expected AllocatedAt to be set, got %+v", c1)
+	}
+	if c2 := owners[1]; c2.ContainerID != "c2" || c2.PodName != "" || c2.Netns != "" || fmt.Sprint(c2.Addresses) != "[10.0.0.3]" {
+		t.Errorf("Unexpected owner: %+v", c2)
+	}
+}
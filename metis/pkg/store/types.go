@@ -29,6 +29,36 @@ var (
 
 	// ErrCidrBlockExhausted is returned when an IPv6 CIDR block cannot be expanded further.
 	ErrCidrBlockExhausted = errors.New("ipv6 cidr block exhausted and cannot be expanded")
+
+	// ErrSchemaTooNew is returned when the database was migrated by a newer
+	// binary than the running one and cannot be opened safely.
+	ErrSchemaTooNew = errors.New("database schema is newer than supported")
+
+	// ErrAddressUnavailable is returned when a specific address, e.g. one
+	// reserved for a pod, cannot be allocated.
+	ErrAddressUnavailable = errors.New("address is not available")
+
+	// ErrReservationExists is returned when an address or the pod of a new
+	// reservation is already reserved.
+	ErrReservationExists = errors.New("ip reservation already exists")
+
+	// ErrReservationNotFound is returned when removing an address that is not reserved.
+	ErrReservationNotFound = errors.New("ip reservation not found")
+
+	// ErrIPNotAllocated is returned when releasing an address that is not
+	// allocated to the given container interface.
+	ErrIPNotAllocated = errors.New("ip is not allocated")
+
+	// ErrIntegrityCheckFailed is returned when PRAGMA integrity_check reports
+	// problems with a database or snapshot file.
+	ErrIntegrityCheckFailed = errors.New("database integrity check failed")
+
+	// ErrQuarantineExists is returned when an address or CIDR block is already quarantined.
+	ErrQuarantineExists = errors.New("ip quarantine already exists")
+
+	// ErrQuarantineNotFound is returned when releasing an address or CIDR
+	// block that is not quarantined.
+	ErrQuarantineNotFound = errors.New("ip quarantine not found")
 )
 
 // IPFamily represents the IP protocol family.
@@ -49,11 +79,11 @@ const (
 
 	// StateDraining indicates the CIDR block is marked for scale-down. No new allocations
 	// are allowed, but existing allocations remain active.
-	// This state is part of the dynamic allocation lifecycle and is only applicable to IPv4.
+	// This state is part of the dynamic allocation lifecycle and applies to both IPv4 and IPv6.
 	StateDraining CidrBlockState = "Draining"
 
 	// StateDeleting indicates the CIDR block has no active allocations and is waiting
 	// to be removed from the local store once it is removed from the NodeNetworkConfig CRD status.
-	// This state is part of the dynamic allocation lifecycle and is only applicable to IPv4.
+	// This state is part of the dynamic allocation lifecycle and applies to both IPv4 and IPv6.
 	StateDeleting CidrBlockState = "Deleting"
 )
@@ -62,6 +62,7 @@ revive \
   -set_exit_status=1 \
   -exclude "**/*.pb.go" \
   -exclude "**/*_grpc.pb.go" \
+  -exclude "third_party/..." \
   -formatter plain \
   -config tools/revive.toml \
   ./... || res=1
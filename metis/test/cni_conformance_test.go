@@ -18,7 +18,9 @@ package test
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -29,6 +31,8 @@ import (
 	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
 	nncfake "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned/fake"
 	"github.com/containernetworking/cni/libcni"
+	"github.com/containernetworking/cni/pkg/types"
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -37,79 +41,11 @@ import (
 )
 
 func TestLibcniConformance(t *testing.T) {
-	// 1. Create an isolated playground for the test lifecycle
-	tempDir, err := os.MkdirTemp("", "libcni-test-")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	binDir := filepath.Join(tempDir, "bin")
-	if err := os.MkdirAll(binDir, 0755); err != nil {
-		t.Fatal(err)
-	}
-	binPath := filepath.Join(binDir, "metis")
-	socketPath := filepath.Join(tempDir, "metis.sock")
-	dbPath := filepath.Join(tempDir, "metis.sqlite")
-
-	// 2. Build the binary automatically inside the test to guarantee synchronization
-	cmd := exec.Command("go", "build", "-o", binPath, "k8s.io/metis/cmd")
-	if output, err := cmd.CombinedOutput(); err != nil {
-		t.Fatalf("Failed to build metis CNI binary: %v\nOutput: %s", err, string(output))
-	}
-	// 3. Spin up the localized backend daemon in-process
-	t.Setenv("NODE_NAME", "test-node")
-
-	daemonConfig := daemon.Config{
-		DBPath:          dbPath,
-		SocketPath:      socketPath,
-		MonitorInterval: 2 * time.Second,
-		ReleaseCooldown: 1 * time.Minute,
-	}
-	daemonCtx, daemonCancel := context.WithCancel(context.Background())
-	defer daemonCancel()
-
-	d := daemon.NewDaemon(daemonConfig)
-	// Pre-populate with fake clients to satisfy the initialization requirements in Run() (they are not actually used by this test).
-	d.NNCClient = nncfake.NewSimpleClientset(&nncv1.NodeNetworkConfig{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "test-node",
-		},
-	})
-	d.KubeClient = kubefake.NewSimpleClientset(&corev1.Node{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "test-node",
-		},
-	})
-
-	go func() {
-		if err := d.Run(daemonCtx); err != nil {
-			t.Logf("Daemon exited with error: %v", err)
-		}
-	}()
-
-	defer func() {
-		if t.Failed() {
-			if cniLogBytes, err := os.ReadFile(filepath.Join(tempDir, "metis-cni.log")); err == nil {
-				t.Logf("=== CNI LOGS (FAILED) ===\n%s", string(cniLogBytes))
-			}
-		}
-	}()
+	tempDir, binDir, socketPath := startConformanceDaemon(t)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Wait until the domain socket successfully mounts
-	err = wait.PollUntilContextTimeout(ctx, 100*time.Millisecond, 5*time.Second, true, func(_ context.Context) (bool, error) {
-		if _, err := os.Stat(socketPath); err == nil {
-			return true, nil
-		}
-		return false, nil
-	})
-	if err != nil {
-		t.Fatalf("Metis Daemon did not become ready: %v", err)
-	}
-
 	// 4. Trigger rigorous validation natively via libcni
 	cniLib := libcni.NewCNIConfig([]string{binDir}, nil)
 
@@ -191,3 +127,183 @@ func TestLibcniConformance(t *testing.T) {
 		t.Logf("=== CNI LOGS ===\n%s", string(cniLogBytes))
 	}
 }
+
+func TestLibcniConformance_GCAndStatus(t *testing.T) {
+	tempDir, binDir, socketPath := startConformanceDaemon(t)
+	dbPath := filepath.Join(tempDir, "metis.sqlite")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cniLib := libcni.NewCNIConfigWithCacheDir([]string{binDir}, filepath.Join(tempDir, "cache"), nil)
+	confList := func(daemonSocket, dbPath string) *libcni.NetworkConfigList {
+		t.Helper()
+		list, err := libcni.ConfListFromBytes([]byte(fmt.Sprintf(`{
+			"cniVersion": "1.1.0",
+			"name": "metis-network",
+			"plugins": [{
+				"type": "metis",
+				"daemonSocket": "%s",
+				"dbPath": "%s",
+				"logFile": "%s",
+				"ipam": {
+					"type": "metis",
+					"ranges": [
+						[{"subnet": "10.240.0.0/24"}]
+					]
+				}
+			}]
+		}`, daemonSocket, dbPath, filepath.Join(tempDir, "metis-cni.log"))))
+		if err != nil {
+			t.Fatalf("Failed to decode libcni config list: %v", err)
+		}
+		return list
+	}
+	list := confList(socketPath, dbPath)
+
+	runtimeConf := func(containerID, cacheDir string) *libcni.RuntimeConf {
+		return &libcni.RuntimeConf{
+			ContainerID: containerID,
+			NetNS:       "/var/run/netns/" + containerID,
+			IfName:      "eth0",
+			CacheDir:    cacheDir,
+			Args: [][2]string{
+				{"K8S_POD_NAME", containerID},
+				{"K8S_POD_NAMESPACE", "default"},
+			},
+		}
+	}
+	// "valid" and "deleted" are cached by libcni, which issues a DEL for the
+	// cached attachments that are not valid. "leaked" is not cached, as if its
+	// cache entry was lost, so only the plugin GC can release its IP.
+	for _, rt := range []*libcni.RuntimeConf{
+		runtimeConf("valid", ""),
+		runtimeConf("deleted", ""),
+		runtimeConf("leaked", filepath.Join(tempDir, "lost-cache")),
+	} {
+		if _, err := cniLib.AddNetworkList(ctx, list, rt); err != nil {
+			t.Fatalf("libcni.AddNetworkList for %s failed: %v", rt.ContainerID, err)
+		}
+	}
+
+	// Validate STATUS while the daemon is up
+	if err := cniLib.GetStatusNetworkList(ctx, list); err != nil {
+		t.Fatalf("libcni.GetStatusNetworkList failed: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open the daemon database: %v", err)
+	}
+	defer db.Close()
+	// The plugin GC keeps recent allocations that may race with an ADD, so
+	// age all allocations past the grace period.
+	if _, err := db.ExecContext(ctx, "UPDATE ip_addresses SET allocated_at = allocated_at - ? WHERE is_allocated = TRUE", (10 * time.Minute).Milliseconds()); err != nil {
+		t.Fatalf("Failed to age allocations: %v", err)
+	}
+
+	// Validate GC
+	gcArgs := &libcni.GCArgs{ValidAttachments: []types.GCAttachment{{ContainerID: "valid", IfName: "eth0"}}}
+	if err := cniLib.GCNetworkList(ctx, list, gcArgs); err != nil {
+		t.Fatalf("libcni.GCNetworkList failed: %v", err)
+	}
+	for containerID, wantAllocated := range map[string]int{"valid": 1, "deleted": 0, "leaked": 0} {
+		var allocated int
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ip_addresses WHERE container_id = ? AND is_allocated = TRUE", containerID).Scan(&allocated); err != nil {
+			t.Fatalf("Failed to query allocations of %s: %v", containerID, err)
+		}
+		if allocated != wantAllocated {
+			t.Errorf("Expected %d IPs allocated to %s after GC, got %d", wantAllocated, containerID, allocated)
+		}
+	}
+
+	// Validate STATUS without the daemon: ready while the store has free IPs,
+	// not ready once it has none.
+	missingSocket := filepath.Join(tempDir, "missing.sock")
+	if err := cniLib.GetStatusNetworkList(ctx, confList(missingSocket, dbPath)); err != nil {
+		t.Errorf("Expected STATUS to succeed without the daemon while IPs are free, got %v", err)
+	}
+	err = cniLib.GetStatusNetworkList(ctx, confList(missingSocket, filepath.Join(tempDir, "empty.sqlite")))
+	var cniErr *types.Error
+	if !errors.As(err, &cniErr) || cniErr.Code != 50 {
+		t.Errorf("Expected STATUS to fail with code 50 without the daemon and free IPs, got %v", err)
+	}
+}
+
+// startConformanceDaemon builds the metis binary and runs a daemon in-process
+// until the test ends. It returns the test directory, the directory of the
+// binary and the daemon socket path.
+func startConformanceDaemon(t *testing.T) (string, string, string) {
+	t.Helper()
+
+	// 1. Create an isolated playground for the test lifecycle
+	tempDir := t.TempDir()
+	binDir := filepath.Join(tempDir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	binPath := filepath.Join(binDir, "metis")
+	socketPath := filepath.Join(tempDir, "metis.sock")
+	dbPath := filepath.Join(tempDir, "metis.sqlite")
+
+	// 2. Build the binary automatically inside the test to guarantee synchronization
+	cmd := exec.Command("go", "build", "-o", binPath, "k8s.io/metis/cmd")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build metis CNI binary: %v\nOutput: %s", err, string(output))
+	}
+	// 3. Spin up the localized backend daemon in-process
+	t.Setenv("NODE_NAME", "test-node")
+
+	daemonConfig := daemon.Config{
+		DBPath:          dbPath,
+		SocketPath:      socketPath,
+		MonitorInterval: 2 * time.Second,
+		ReleaseCooldown: 1 * time.Minute,
+	}
+	daemonCtx, daemonCancel := context.WithCancel(context.Background())
+	daemonDone := make(chan struct{})
+	t.Cleanup(func() {
+		daemonCancel()
+		<-daemonDone
+	})
+
+	d := daemon.NewDaemon(daemonConfig)
+	// Pre-populate with fake clients to satisfy the initialization requirements in Run() (they are not actually used by this test).
+	d.NNCClient = nncfake.NewSimpleClientset(&nncv1.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-node",
+		},
+	})
+	d.KubeClient = kubefake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-node",
+		},
+	})
+
+	go func() {
+		defer close(daemonDone)
+		if err := d.Run(daemonCtx); err != nil {
+			t.Logf("Daemon exited with error: %v", err)
+		}
+	}()
+
+	t.Cleanup(func() {
+		if t.Failed() {
+			if cniLogBytes, err := os.ReadFile(filepath.Join(tempDir, "metis-cni.log")); err == nil {
+				t.Logf("=== CNI LOGS (FAILED) ===\n%s", string(cniLogBytes))
+			}
+		}
+	})
+
+	// Wait until the domain socket successfully mounts
+	err := wait.PollUntilContextTimeout(context.Background(), 100*time.Millisecond, 5*time.Second, true, func(_ context.Context) (bool, error) {
+		if _, err := os.Stat(socketPath); err == nil {
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("Metis Daemon did not become ready: %v", err)
+	}
+	return tempDir, binDir, socketPath
+}
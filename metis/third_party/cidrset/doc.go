@@ -0,0 +1,22 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cidrset is a vendored copy of the cidrset package of the node IPAM
+// controller, k8s.io/cloud-provider-gcp/pkg/controller/nodeipam/ipam/cidrset,
+// which carves node pod CIDRs out of cluster CIDRs. The metis module is built
+// on its own and can not import it. The copy must not be edited, it is kept in
+// sync with `make verify-cidrset`.
+package cidrset
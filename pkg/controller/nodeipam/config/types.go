@@ -16,6 +16,10 @@ limitations under the License.
 
 package config
 
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 // NodeIPAMControllerConfiguration contains elements describing NodeIPAMController.
 type NodeIPAMControllerConfiguration struct {
 	// ServiceCIDR is CIDR Range for Services in cluster.
@@ -40,4 +44,65 @@ type NodeIPAMControllerConfiguration struct {
 	// when the cluster-level "enable-multi-networking" flag is true to enable
 	// the multi-networking related logics such as multi-networking IPAM.
 	EnableMultiNetworking bool
+	// PodSecondaryRangeNames are the names of the subnetwork secondary ranges
+	// the pod alias IP ranges of the nodes' default network interface are taken
+	// from. It is used by the cloud CIDR allocator to publish the pod alias IP
+	// ranges of a node beyond its first one.
+	PodSecondaryRangeNames []string
+	// AdditionalPodCIDRsResyncPeriod is the period the cloud CIDR allocator resyncs
+	// all nodes at to discover the alias IP ranges added to their instances, as
+	// these produce no node events. Each resync costs one GCE instance lookup per
+	// node. Zero uses the default of 10 minutes and a negative period disables the
+	// resync, so that only node events discover additional pod CIDRs.
+	AdditionalPodCIDRsResyncPeriod metav1.Duration
+	// NodeNetworkConfigPodCIDRMaskSize is the mask size of the alias IP ranges the
+	// nodenetworkconfig controller adds to nodes out of the cluster CIDR, or zero if
+	// that controller is not enabled. The cloud CIDR allocator does not publish these
+	// as additional pod CIDRs. It is not bound to a flag of its own, the cloud
+	// controller manager sets it from --node-network-config-pod-cidr-mask-size.
+	NodeNetworkConfigPodCIDRMaskSize int32
+	// AdditionalClusterCIDRs are the cluster CIDRs node CIDRs are allocated from once
+	// the cluster CIDR of the same IP family is exhausted. The CIDRs of an IP family are
+	// used in the order they appear in the list, independently of the CIDRs of the other
+	// IP family. It is only used by the range allocator.
+	AdditionalClusterCIDRs []ClusterCIDR
+	// ClusterCIDRsConfigMap is the "namespace/name" of a ConfigMap whose "clusterCIDRs"
+	// key lists further additional cluster CIDRs as comma-separated "CIDR[=mask size]".
+	// Changes to it are applied without restarting the controller. It is only used by
+	// the range allocator.
+	ClusterCIDRsConfigMap string
+	// NodeCIDRPoolsConfigMap is the "namespace/name" of a ConfigMap whose
+	// "nodeCIDRPools" key lists the pools of CIDRs the node CIDRs of the nodes selected
+	// by their node selector are allocated from, with their own node CIDR mask sizes.
+	// Changes to it are applied without restarting the controller, and pools that can
+	// not be applied do not affect the cluster CIDRs. It is only used by the range
+	// allocator.
+	NodeCIDRPoolsConfigMap string
+	// AllocatorStateConfigMap is the "namespace/name" of a ConfigMap the state of the
+	// range allocator is checkpointed to. When set, the range allocator restores its
+	// state from the ConfigMap on startup and validates it against the nodes once they
+	// are synced, instead of listing all nodes before it starts. As the state may miss
+	// the allocations made after its last checkpoint, a few node CIDRs of each IP family
+	// are kept out of the allocations and checkpointed as reserved. Until the state is
+	// validated, only these are allocated, to the nodes delivered by the node informer
+	// that are not selected by a node CIDR pool. It is only used by the range allocator.
+	AllocatorStateConfigMap string
+	// CIDRReleaseQuarantine is how long the node CIDRs of deleted nodes are kept from
+	// being allocated to other nodes, as they may still be routed. Zero releases them
+	// immediately. It is only used by the range allocator.
+	CIDRReleaseQuarantine metav1.Duration
+	// CIDRUtilizationAlertThresholds are the cluster CIDR utilization percentages at
+	// which a warning event is recorded on the node allocated the CIDR that crossed
+	// them, and on the cluster. They are not used by the cloud allocator, which does
+	// not allocate from cluster CIDRs.
+	CIDRUtilizationAlertThresholds []float64
+}
+
+// ClusterCIDR is a cluster CIDR node CIDRs are allocated from.
+type ClusterCIDR struct {
+	// CIDR is the cluster CIDR, e.g. "10.8.0.0/14".
+	CIDR string
+	// NodeCIDRMaskSize is the mask size of the node CIDRs allocated from CIDR. Zero
+	// uses the node CIDR mask size of the IP family of CIDR.
+	NodeCIDRMaskSize int32
 }
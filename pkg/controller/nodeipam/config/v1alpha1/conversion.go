@@ -35,6 +35,8 @@ func Convert_v1alpha1_NodeIPAMControllerConfiguration_To_config_NodeIPAMControll
 }
 
 // Convert_config_NodeIPAMControllerConfiguration_To_v1alpha1_NodeIPAMControllerConfiguration is an autogenerated conversion function.
+// The fields of the internal type without a peer in the external type, e.g. the
+// AdditionalClusterCIDRs, are dropped.
 func Convert_config_NodeIPAMControllerConfiguration_To_v1alpha1_NodeIPAMControllerConfiguration(in *config.NodeIPAMControllerConfiguration, out *v1alpha1.NodeIPAMControllerConfiguration, s conversion.Scope) error {
 	return autoConvert_config_NodeIPAMControllerConfiguration_To_v1alpha1_NodeIPAMControllerConfiguration(in, out, s)
 }
@@ -31,5 +31,6 @@ import (
 // run it in your wrapper struct of this type in its `SetDefaults_` method.
 func RecommendedDefaultNodeIPAMControllerConfiguration(obj *kubectrlmgrconfigv1alpha1.NodeIPAMControllerConfiguration) {
 	// The default mask size is not set here because we need to determine the cluster cidr family before setting the
-	// appropriate mask size.
+	// appropriate mask size. The fields of the internal type without a peer in this
+	// external type, e.g. AdditionalClusterCIDRs, are defaulted by their flags.
 }
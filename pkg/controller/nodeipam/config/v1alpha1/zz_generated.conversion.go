@@ -96,5 +96,16 @@ func autoConvert_config_NodeIPAMControllerConfiguration_To_v1alpha1_NodeIPAMCont
 	out.NodeCIDRMaskSize = in.NodeCIDRMaskSize
 	out.NodeCIDRMaskSizeIPv4 = in.NodeCIDRMaskSizeIPv4
 	out.NodeCIDRMaskSizeIPv6 = in.NodeCIDRMaskSizeIPv6
+	// WARNING: in.EnableMultiSubnetCluster requires manual conversion: does not exist in peer-type
+	// WARNING: in.EnableMultiNetworking requires manual conversion: does not exist in peer-type
+	// WARNING: in.PodSecondaryRangeNames requires manual conversion: does not exist in peer-type
+	// WARNING: in.AdditionalPodCIDRsResyncPeriod requires manual conversion: does not exist in peer-type
+	// WARNING: in.NodeNetworkConfigPodCIDRMaskSize requires manual conversion: does not exist in peer-type
+	// WARNING: in.AdditionalClusterCIDRs requires manual conversion: does not exist in peer-type
+	// WARNING: in.ClusterCIDRsConfigMap requires manual conversion: does not exist in peer-type
+	// WARNING: in.NodeCIDRPoolsConfigMap requires manual conversion: does not exist in peer-type
+	// WARNING: in.AllocatorStateConfigMap requires manual conversion: does not exist in peer-type
+	// WARNING: in.CIDRReleaseQuarantine requires manual conversion: does not exist in peer-type
+	// WARNING: in.CIDRUtilizationAlertThresholds requires manual conversion: does not exist in peer-type
 	return nil
 }
@@ -21,9 +21,42 @@ limitations under the License.
 
 package config
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCIDR) DeepCopyInto(out *ClusterCIDR) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCIDR.
+func (in *ClusterCIDR) DeepCopy() *ClusterCIDR {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCIDR)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeIPAMControllerConfiguration) DeepCopyInto(out *NodeIPAMControllerConfiguration) {
 	*out = *in
+	if in.PodSecondaryRangeNames != nil {
+		in, out := &in.PodSecondaryRangeNames, &out.PodSecondaryRangeNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.AdditionalPodCIDRsResyncPeriod = in.AdditionalPodCIDRsResyncPeriod
+	if in.AdditionalClusterCIDRs != nil {
+		in, out := &in.AdditionalClusterCIDRs, &out.AdditionalClusterCIDRs
+		*out = make([]ClusterCIDR, len(*in))
+		copy(*out, *in)
+	}
+	out.CIDRReleaseQuarantine = in.CIDRReleaseQuarantine
+	if in.CIDRUtilizationAlertThresholds != nil {
+		in, out := &in.CIDRUtilizationAlertThresholds, &out.CIDRUtilizationAlertThresholds
+		*out = make([]float64, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
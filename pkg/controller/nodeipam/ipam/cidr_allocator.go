@@ -82,6 +82,26 @@ const (
 
 	// The duration of periodic reconciliation on the nodetopology CR
 	nodeTopologyReconcileInterval = 10 * time.Minute
+
+	// DefaultAdditionalPodCIDRsResyncPeriod is the default duration of periodic
+	// resync of all nodes by the cloud allocator to discover alias IP ranges
+	// added to their instances
+	DefaultAdditionalPodCIDRsResyncPeriod = 10 * time.Minute
+
+	// The duration of periodic checkpoints of the range allocator state
+	allocatorStateCheckpointPeriod = 10 * time.Second
+
+	// The duration between retries of the validation of the restored range
+	// allocator state
+	allocatorStateValidationRetryPeriod = 5 * time.Second
+
+	// The number of node cidrs of each IP family the range allocator keeps out of
+	// its allocations and checkpoints, to allocate them while the state restored on
+	// startup is not validated yet
+	allocatorStateReserveSize = 8
+
+	// The duration of periodic releases of the quarantined cidrs whose quarantine is over
+	cidrQuarantineResyncPeriod = 30 * time.Second
 )
 
 // nodePollInterval is used in listing node
@@ -112,13 +132,53 @@ type CIDRAllocatorParams struct {
 	SecondaryServiceCIDR *net.IPNet
 	// NodeCIDRMaskSizes is list of node cidr mask sizes
 	NodeCIDRMaskSizes []int
+	// PodSecondaryRangeNames is list of the subnetwork secondary ranges of pod
+	// alias IP ranges, only used by the cloud allocator
+	PodSecondaryRangeNames []string
+	// AdditionalPodCIDRsResyncPeriod is the period the cloud allocator resyncs all
+	// nodes at, each resync costing one GCE instance lookup per node, when
+	// PodSecondaryRangeNames is set. Zero uses DefaultAdditionalPodCIDRsResyncPeriod
+	// and a negative period disables the resync.
+	AdditionalPodCIDRsResyncPeriod time.Duration
+	// NodeNetworkConfigPodCIDRMaskSize is the mask size of the alias IP ranges the
+	// nodenetworkconfig controller adds to nodes out of the IPv4 cluster cidr, or zero
+	// if that controller is not enabled. The cloud allocator does not publish these as
+	// additional pod CIDRs, as the controller publishes and releases them itself.
+	NodeNetworkConfigPodCIDRMaskSize int
+	// AdditionalClusterCIDRs is list of cluster cidrs node cidrs are allocated from,
+	// in priority order, once the cluster cidr of the same IP family is exhausted.
+	// Only used by the range allocator.
+	AdditionalClusterCIDRs []ClusterCIDRRange
+	// ClusterCIDRsConfigMap is the "namespace/name" of a ConfigMap further additional
+	// cluster cidrs are added from at runtime. Only used by the range allocator.
+	ClusterCIDRsConfigMap string
+	// NodeCIDRPoolsConfigMap is the "namespace/name" of a ConfigMap the node cidr pools
+	// are set from at runtime. Only used by the range allocator.
+	NodeCIDRPoolsConfigMap string
+	// AllocatorStateConfigMap is the "namespace/name" of a ConfigMap the allocator
+	// state is checkpointed to and restored from. Only used by the range allocator.
+	AllocatorStateConfigMap string
+	// CIDRReleaseQuarantine is how long the cidrs of deleted nodes are kept from being
+	// allocated again. Only used by the range allocator.
+	CIDRReleaseQuarantine time.Duration
+	// UtilizationAlertThresholds are the cluster cidr utilization percentages warning
+	// events are recorded at. Not used by the cloud allocator, which does not allocate
+	// from cluster cidrs.
+	UtilizationAlertThresholds []float64
 }
 
 // New creates a new CIDR range allocator.
 func New(kubeClient clientset.Interface, cloud cloudprovider.Interface, nodeInformer informers.NodeInformer, nwInformer networkinformer.NetworkInformer, gnpInformer networkinformer.GKENetworkParamSetInformer, nodeTopologyClient nodetopologyclientset.Interface, enableMultiSubnetCluster bool, enableMultiNetworking bool, allocatorType CIDRAllocatorType, allocatorParams CIDRAllocatorParams) (CIDRAllocator, error) {
-	nodeList, err := listNodes(kubeClient)
-	if err != nil {
-		return nil, err
+	var nodeList *v1.NodeList
+	// The range allocator validates the state restored from its checkpoint against the
+	// nodes once they are synced, instead of listing them before it starts. Nodes the
+	// informer delivers meanwhile get the node cidrs reserved in the checkpoint.
+	if allocatorType != RangeAllocatorType || allocatorParams.AllocatorStateConfigMap == "" {
+		var err error
+		nodeList, err = listNodes(kubeClient)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	switch allocatorType {
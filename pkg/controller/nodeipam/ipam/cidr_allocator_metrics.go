@@ -0,0 +1,145 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	nodeutil "k8s.io/cloud-provider-gcp/pkg/util"
+)
+
+// CIDRUtilizationHighReason is the reason of the events recorded when the utilization
+// of a cluster CIDR crosses a utilization alert threshold.
+const CIDRUtilizationHighReason = "CIDRUtilizationHigh"
+
+// The per cluster cidr gauges of total, used and free node cidrs are maintained by
+// cidrset.CidrSet, for the allocators allocating from cluster cidrs.
+var (
+	cidrAllocationDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      nodeIpamSubsystem,
+			Name:           "cidr_allocation_duration_seconds",
+			Help:           "Histogram measuring the duration of successful node CIDR allocations, by allocator.",
+			StabilityLevel: metrics.ALPHA,
+			Buckets:        metrics.ExponentialBuckets(0.01, 2, 14),
+		},
+		[]string{"allocator"},
+	)
+	cidrAllocationFailures = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      nodeIpamSubsystem,
+			Name:           "cidr_allocation_failures_total",
+			Help:           "Counter measuring total number of failed node CIDR allocations, by allocator and reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"allocator", "reason"},
+	)
+)
+
+var registerAllocatorMetrics sync.Once
+
+// registerCIDRAllocatorMetrics registers the metrics common to all CIDR allocators.
+func registerCIDRAllocatorMetrics() {
+	registerAllocatorMetrics.Do(func() {
+		legacyregistry.MustRegister(cidrAllocationDuration)
+		legacyregistry.MustRegister(cidrAllocationFailures)
+	})
+}
+
+// observeCIDRAllocation records a successful node cidr allocation by allocatorType that
+// took duration.
+func observeCIDRAllocation(allocatorType CIDRAllocatorType, duration time.Duration) {
+	cidrAllocationDuration.WithLabelValues(string(allocatorType)).Observe(duration.Seconds())
+}
+
+// recordCIDRAllocationFailure records a node status change event with reason on node,
+// and counts the failed node cidr allocation by allocatorType.
+func recordCIDRAllocationFailure(recorder record.EventRecorder, node *v1.Node, allocatorType CIDRAllocatorType, reason string) {
+	nodeutil.RecordNodeStatusChange(recorder, node, reason)
+	cidrAllocationFailures.WithLabelValues(string(allocatorType), reason).Inc()
+}
+
+// clusterEventRef is the cluster-scoped object the cluster cidr utilization events
+// are recorded on.
+var clusterEventRef = &v1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: metav1.NamespaceSystem}
+
+// cidrUtilizationAlerter records warning events when the utilization of cluster cidrs
+// crosses utilization alert thresholds. An alert is recorded once per threshold, and
+// re-armed once the utilization drops below it.
+type cidrUtilizationAlerter struct {
+	// thresholds are the utilization percentages to alert at, in ascending order
+	thresholds []float64
+	recorder   record.EventRecorder
+	lock       sync.Mutex
+	// crossed is the number of thresholds crossed, by cluster cidr
+	crossed map[string]int
+}
+
+// newCIDRUtilizationAlerter returns an alerter for the utilization percentages in
+// thresholds, or nil if there are none.
+func newCIDRUtilizationAlerter(thresholds []float64, recorder record.EventRecorder) *cidrUtilizationAlerter {
+	if len(thresholds) == 0 {
+		return nil
+	}
+	thresholds = slices.Clone(thresholds)
+	slices.Sort(thresholds)
+	return &cidrUtilizationAlerter{
+		thresholds: thresholds,
+		recorder:   recorder,
+		crossed:    map[string]int{},
+	}
+}
+
+// observe records the utilization of clusterCIDR, allocated node cidrs of max, after
+// a node cidr was allocated to node, or released if node is nil. If it crossed a
+// threshold, a warning event is recorded on node, if not nil, and on the cluster.
+// observe is a no-op on a nil alerter.
+func (a *cidrUtilizationAlerter) observe(clusterCIDR string, allocated, max int, node *v1.Node) {
+	if a == nil || max == 0 {
+		return
+	}
+	utilization := 100 * float64(allocated) / float64(max)
+	crossed := 0
+	for crossed < len(a.thresholds) && utilization >= a.thresholds[crossed] {
+		crossed++
+	}
+
+	a.lock.Lock()
+	previous := a.crossed[clusterCIDR]
+	a.crossed[clusterCIDR] = crossed
+	a.lock.Unlock()
+	if crossed <= previous {
+		return
+	}
+
+	threshold := a.thresholds[crossed-1]
+	klog.Warningf("Utilization of cluster CIDR %s is %.1f%% (%d of %d node CIDRs allocated), above the %g%% threshold", clusterCIDR, utilization, allocated, max, threshold)
+	if node != nil {
+		ref := &v1.ObjectReference{APIVersion: "v1", Kind: "Node", Name: node.Name, UID: node.UID}
+		a.recorder.Eventf(ref, v1.EventTypeWarning, CIDRUtilizationHighReason, "Node CIDR allocated from cluster CIDR %s, whose utilization is now %.1f%%, above the %g%% threshold", clusterCIDR, utilization, threshold)
+	}
+	a.recorder.Eventf(clusterEventRef, v1.EventTypeWarning, CIDRUtilizationHighReason, "Utilization of cluster CIDR %s is %.1f%% (%d of %d node CIDRs allocated), above the %g%% threshold", clusterCIDR, utilization, allocated, max, threshold)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/cloud-provider-gcp/pkg/controller/testutil"
+)
+
+func TestCIDRUtilizationAlerter(t *testing.T) {
+	if alerter := newCIDRUtilizationAlerter(nil, testutil.NewFakeRecorder()); alerter != nil {
+		t.Fatalf("expected no alerter without thresholds, got %v", alerter)
+	}
+	// observe is a no-op on a nil alerter.
+	var nilAlerter *cidrUtilizationAlerter
+	nilAlerter.observe("10.0.0.0/16", 256, 256, nil)
+
+	recorder := testutil.NewFakeRecorder()
+	alerter := newCIDRUtilizationAlerter([]float64{95, 80}, recorder)
+	node := testutil.NewNode("node0")
+
+	for _, step := range []struct {
+		desc      string
+		allocated int
+		// want are the names of the objects events are recorded on
+		want []string
+	}{
+		{desc: "below thresholds", allocated: 100},
+		{desc: "crosses 80%", allocated: 205, want: []string{"node0", "kube-system"}},
+		{desc: "stays above 80%", allocated: 210},
+		{desc: "crosses 95%", allocated: 250, want: []string{"node0", "kube-system"}},
+		{desc: "drops below 80%", allocated: 200},
+		{desc: "crosses 80% again", allocated: 210, want: []string{"node0", "kube-system"}},
+	} {
+		recorder.Events = recorder.Events[:0]
+		alerter.observe("10.0.0.0/16", step.allocated, 256, node)
+		var got []string
+		for _, event := range recorder.Events {
+			if event.Reason != CIDRUtilizationHighReason {
+				t.Errorf("%v: unexpected event reason %v", step.desc, event.Reason)
+			}
+			got = append(got, event.InvolvedObject.Name)
+		}
+		if !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%v: expected events on %v, got %v", step.desc, step.want, got)
+		}
+	}
+
+	// Cluster CIDRs are alerted on independently.
+	recorder.Events = recorder.Events[:0]
+	alerter.observe("10.1.0.0/16", 250, 256, nil)
+	if len(recorder.Events) != 1 || recorder.Events[0].InvolvedObject.Name != "kube-system" {
+		t.Errorf("expected a single cluster event for a second cluster CIDR, got %v", recorder.Events)
+	}
+}
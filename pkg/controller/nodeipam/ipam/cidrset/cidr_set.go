@@ -88,14 +88,33 @@ func NewCIDRSet(clusterCIDR *net.IPNet, subNetMaskSize int) (*CidrSet, error) {
 	registerCidrsetMetrics()
 
 	maxCIDRs = 1 << uint32(subNetMaskSize-clusterMaskSize)
-	return &CidrSet{
+	s := &CidrSet{
 		clusterCIDR:     clusterCIDR,
 		nodeMask:        net.CIDRMask(subNetMaskSize, bits),
 		clusterMaskSize: clusterMaskSize,
 		maxCIDRs:        maxCIDRs,
 		nodeMaskSize:    subNetMaskSize,
 		label:           clusterCIDR.String(),
-	}, nil
+	}
+	cidrSetMaxCIDRs.WithLabelValues(s.label).Set(float64(maxCIDRs))
+	s.updateUsageMetrics()
+	return s, nil
+}
+
+// updateUsageMetrics updates the gauges of allocated CIDRs. Callers must hold the lock
+// of s, if it may be in use.
+func (s *CidrSet) updateUsageMetrics() {
+	cidrSetAllocatedCIDRs.WithLabelValues(s.label).Set(float64(s.allocatedCIDRs))
+	cidrSetFreeCIDRs.WithLabelValues(s.label).Set(float64(s.maxCIDRs - s.allocatedCIDRs))
+	cidrSetUsage.WithLabelValues(s.label).Set(float64(s.allocatedCIDRs) / float64(s.maxCIDRs))
+}
+
+// Usage returns the number of CIDRs allocated from s, and the maximum number of CIDRs
+// that can be allocated from it.
+func (s *CidrSet) Usage() (allocated, max int) {
+	s.Lock()
+	defer s.Unlock()
+	return s.allocatedCIDRs, s.maxCIDRs
 }
 
 func (s *CidrSet) indexToCIDRBlock(index int) *net.IPNet {
@@ -169,7 +188,7 @@ func (s *CidrSet) AllocateNext() (*net.IPNet, error) {
 	// Update metrics
 	cidrSetAllocations.WithLabelValues(s.label).Inc()
 	cidrSetAllocationTriesPerRequest.WithLabelValues(s.label).Observe(float64(i))
-	cidrSetUsage.WithLabelValues(s.label).Set(float64(s.allocatedCIDRs) / float64(s.maxCIDRs))
+	s.updateUsageMetrics()
 
 	return s.indexToCIDRBlock(candidate), nil
 }
@@ -241,7 +260,7 @@ func (s *CidrSet) Release(cidr *net.IPNet) error {
 		}
 	}
 
-	cidrSetUsage.WithLabelValues(s.label).Set(float64(s.allocatedCIDRs) / float64(s.maxCIDRs))
+	s.updateUsageMetrics()
 	return nil
 }
 
@@ -264,7 +283,7 @@ func (s *CidrSet) Occupy(cidr *net.IPNet) (err error) {
 		}
 	}
 
-	cidrSetUsage.WithLabelValues(s.label).Set(float64(s.allocatedCIDRs) / float64(s.maxCIDRs))
+	s.updateUsageMetrics()
 	return nil
 }
 
@@ -293,3 +312,48 @@ func (s *CidrSet) getIndexForIP(ip net.IP) (int, error) {
 
 	return 0, fmt.Errorf("invalid IP: %v", ip)
 }
+
+// Snapshot returns the bitmap of the CIDR ranges used in s, to be passed to Restore.
+func (s *CidrSet) Snapshot() []byte {
+	s.Lock()
+	defer s.Unlock()
+	return s.used.Bytes()
+}
+
+// Restore marks the CIDR ranges used in snapshot, a bitmap returned by Snapshot of a
+// CidrSet with the same cluster CIDR and node mask size, as used, in addition to those
+// already used in s.
+func (s *CidrSet) Restore(snapshot []byte) error {
+	var used big.Int
+	used.SetBytes(snapshot)
+	if used.BitLen() > s.maxCIDRs {
+		return fmt.Errorf("snapshot of %d CIDRs does not fit in cluster cidr %v with node mask size %d", used.BitLen(), s.clusterCIDR, s.nodeMaskSize)
+	}
+	s.Lock()
+	defer s.Unlock()
+	for i := 0; i < used.BitLen(); i++ {
+		// Only change the counters if we change the bit to prevent
+		// double counting.
+		if used.Bit(i) != 0 && s.used.Bit(i) == 0 {
+			s.used.SetBit(&s.used, i, 1)
+			s.allocatedCIDRs++
+			cidrSetAllocations.WithLabelValues(s.label).Inc()
+		}
+	}
+
+	s.updateUsageMetrics()
+	return nil
+}
+
+// UsedCIDRs returns the node CIDR ranges used in s.
+func (s *CidrSet) UsedCIDRs() []*net.IPNet {
+	s.Lock()
+	defer s.Unlock()
+	var cidrs []*net.IPNet
+	for i := 0; i < s.used.BitLen(); i++ {
+		if s.used.Bit(i) != 0 {
+			cidrs = append(cidrs, s.indexToCIDRBlock(i))
+		}
+	}
+	return cidrs
+}
@@ -22,6 +22,7 @@ import (
 	"reflect"
 	"testing"
 
+	"k8s.io/component-base/metrics"
 	"k8s.io/component-base/metrics/testutil"
 	"k8s.io/klog/v2"
 )
@@ -832,6 +833,56 @@ func TestCIDRSetv6(t *testing.T) {
 	}
 }
 
+func TestSnapshotRestore(t *testing.T) {
+	_, clusterCIDR, _ := net.ParseCIDR("2001:beef:1234::/56")
+	a, err := NewCIDRSet(clusterCIDR, 64)
+	if err != nil {
+		t.Fatalf("unexpected error creating CidrSet: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := a.AllocateNext(); err != nil {
+			t.Fatalf("unexpected error allocating a new CIDR: %v", err)
+		}
+	}
+	_, released, _ := net.ParseCIDR("2001:beef:1234:1::/64")
+	if err := a.Release(released); err != nil {
+		t.Fatalf("unexpected error releasing CIDR: %v", err)
+	}
+
+	b, err := NewCIDRSet(clusterCIDR, 64)
+	if err != nil {
+		t.Fatalf("unexpected error creating CidrSet: %v", err)
+	}
+	_, occupied, _ := net.ParseCIDR("2001:beef:1234:ff::/64")
+	if err := b.Occupy(occupied); err != nil {
+		t.Fatalf("unexpected error occupying CIDR: %v", err)
+	}
+	if err := b.Restore(a.Snapshot()); err != nil {
+		t.Fatalf("unexpected error restoring snapshot: %v", err)
+	}
+	var got []string
+	for _, cidr := range b.UsedCIDRs() {
+		got = append(got, cidr.String())
+	}
+	expected := []string{"2001:beef:1234::/64", "2001:beef:1234:2::/64", "2001:beef:1234:ff::/64"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected used CIDRs %v, got %v", expected, got)
+	}
+	if b.allocatedCIDRs != 3 {
+		t.Errorf("expected 3 allocated CIDRs, got %d", b.allocatedCIDRs)
+	}
+
+	// A snapshot of a larger range does not fit.
+	_, smallCIDR, _ := net.ParseCIDR("2001:beef:1234::/62")
+	c, err := NewCIDRSet(smallCIDR, 64)
+	if err != nil {
+		t.Fatalf("unexpected error creating CidrSet: %v", err)
+	}
+	if err := c.Restore(b.Snapshot()); err == nil {
+		t.Errorf("expected an error restoring a snapshot of a larger range")
+	}
+}
+
 func TestCidrSetMetrics(t *testing.T) {
 	cidr := "10.0.0.0/16"
 	_, clusterCIDR, _ := net.ParseCIDR(cidr)
@@ -970,6 +1021,46 @@ func TestCidrSetMetricsDual(t *testing.T) {
 
 }
 
+func TestCidrSetMetricsAllocatedAndMax(t *testing.T) {
+	cidr := "10.1.0.0/16"
+	_, clusterCIDR, _ := net.ParseCIDR(cidr)
+	expectGauge := func(gauge *metrics.GaugeVec, want float64) {
+		t.Helper()
+		got, err := testutil.GetGaugeMetricValue(gauge.WithLabelValues(cidr))
+		if err != nil {
+			t.Fatalf("failed to get %s value, err: %v", gauge.Name, err)
+		}
+		if got != want {
+			t.Errorf("metric %s: expected %v, received %v", gauge.Name, want, got)
+		}
+	}
+
+	// The gauges are set for a new range before anything is allocated from it.
+	a, err := NewCIDRSet(clusterCIDR, 24)
+	if err != nil {
+		t.Fatalf("unexpected error creating CidrSet: %v", err)
+	}
+	expectGauge(cidrSetMaxCIDRs, 256)
+	expectGauge(cidrSetAllocatedCIDRs, 0)
+	expectGauge(cidrSetFreeCIDRs, 256)
+	expectGauge(cidrSetUsage, 0)
+
+	_, halfClusterCIDR, _ := net.ParseCIDR("10.1.0.0/17")
+	a.Occupy(halfClusterCIDR)
+	expectGauge(cidrSetAllocatedCIDRs, 128)
+	if _, err := a.AllocateNext(); err != nil {
+		t.Fatalf("unexpected error allocating a new CIDR: %v", err)
+	}
+	expectGauge(cidrSetAllocatedCIDRs, 129)
+	expectGauge(cidrSetFreeCIDRs, 127)
+	if allocated, max := a.Usage(); allocated != 129 || max != 256 {
+		t.Errorf("expected usage of 129 of 256 CIDRs, got %d of %d", allocated, max)
+	}
+	a.Release(clusterCIDR)
+	expectGauge(cidrSetAllocatedCIDRs, 0)
+	expectGauge(cidrSetMaxCIDRs, 256)
+}
+
 // Metrics helpers
 func clearMetrics(labels map[string]string) {
 	cidrSetAllocations.Delete(labels)
@@ -53,6 +53,33 @@ var (
 		},
 		[]string{"clusterCIDR"},
 	)
+	cidrSetAllocatedCIDRs = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      nodeIpamSubsystem,
+			Name:           "cidrset_allocated_cidrs",
+			Help:           "Gauge measuring number of allocated CIDRs.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"clusterCIDR"},
+	)
+	cidrSetFreeCIDRs = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      nodeIpamSubsystem,
+			Name:           "cidrset_free_cidrs",
+			Help:           "Gauge measuring number of CIDRs that are left to be allocated.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"clusterCIDR"},
+	)
+	cidrSetMaxCIDRs = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      nodeIpamSubsystem,
+			Name:           "cidrset_max_cidrs",
+			Help:           "Gauge measuring maximum number of CIDRs that can be allocated.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"clusterCIDR"},
+	)
 	cidrSetAllocationTriesPerRequest = metrics.NewHistogramVec(
 		&metrics.HistogramOpts{
 			Subsystem:      nodeIpamSubsystem,
@@ -73,6 +100,9 @@ func registerCidrsetMetrics() {
 		legacyregistry.MustRegister(cidrSetAllocations)
 		legacyregistry.MustRegister(cidrSetReleases)
 		legacyregistry.MustRegister(cidrSetUsage)
+		legacyregistry.MustRegister(cidrSetAllocatedCIDRs)
+		legacyregistry.MustRegister(cidrSetFreeCIDRs)
+		legacyregistry.MustRegister(cidrSetMaxCIDRs)
 		legacyregistry.MustRegister(cidrSetAllocationTriesPerRequest)
 	})
 }
@@ -23,7 +23,9 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/netip"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 
@@ -41,6 +43,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	informers "k8s.io/client-go/informers/core/v1"
@@ -107,6 +110,18 @@ type cloudCIDRAllocator struct {
 	stackType clusterStackType
 
 	enableMultiNetworking bool
+
+	// podSecondaryRangeNames are the secondary ranges the pod alias IP ranges
+	// of the nodes are taken from, see additionalPodCIDRs.
+	podSecondaryRangeNames []string
+	// additionalPodCIDRsResyncPeriod is the period all nodes are resynced at
+	// to discover additional pod CIDRs, disabled if not positive.
+	additionalPodCIDRsResyncPeriod time.Duration
+	// nodeNetworkConfigPodCIDRs is the IPv4 cluster cidr the nodenetworkconfig
+	// controller adds alias IP ranges of nodeNetworkConfigPodCIDRMaskSize out of,
+	// nil if the controller is not enabled. These are not additional pod CIDRs.
+	nodeNetworkConfigPodCIDRs        *net.IPNet
+	nodeNetworkConfigPodCIDRMaskSize int
 }
 
 var _ CIDRAllocator = (*cloudCIDRAllocator)(nil)
@@ -161,8 +176,24 @@ func NewCloudCIDRAllocator(client clientset.Interface, cloud cloudprovider.Inter
 			),
 			workqueue.RateLimitingQueueConfig{Name: workqueueName},
 		),
-		stackType:             stackType,
-		enableMultiNetworking: enableMultiNetworking,
+		stackType:              stackType,
+		enableMultiNetworking:  enableMultiNetworking,
+		podSecondaryRangeNames: allocatorParams.PodSecondaryRangeNames,
+	}
+	if allocatorParams.NodeNetworkConfigPodCIDRMaskSize > 0 {
+		for _, clusterCIDR := range allocatorParams.ClusterCIDRs {
+			if netutils.IsIPv4CIDR(clusterCIDR) {
+				ca.nodeNetworkConfigPodCIDRs = clusterCIDR
+				ca.nodeNetworkConfigPodCIDRMaskSize = allocatorParams.NodeNetworkConfigPodCIDRMaskSize
+				break
+			}
+		}
+	}
+	switch {
+	case allocatorParams.AdditionalPodCIDRsResyncPeriod == 0:
+		ca.additionalPodCIDRsResyncPeriod = DefaultAdditionalPodCIDRsResyncPeriod
+	case allocatorParams.AdditionalPodCIDRsResyncPeriod > 0:
+		ca.additionalPodCIDRsResyncPeriod = allocatorParams.AdditionalPodCIDRsResyncPeriod
 	}
 
 	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -276,6 +307,7 @@ func NewCloudCIDRAllocator(client clientset.Interface, cloud cloudprovider.Inter
 
 	// register Cloud CIDR Allocator metrics
 	registerCloudCidrAllocatorMetrics()
+	registerCIDRAllocatorMetrics()
 
 	klog.V(0).Infof("Using cloud CIDR allocator (provider: %v)", cloud.ProviderName())
 	return ca, nil
@@ -314,6 +346,12 @@ func (ca *cloudCIDRAllocator) Run(stopCh <-chan struct{}) {
 		go wait.UntilWithContext(ctx, ca.runWorker, time.Second)
 	}
 
+	if len(ca.podSecondaryRangeNames) > 0 && ca.additionalPodCIDRsResyncPeriod > 0 {
+		// Alias IP ranges attached to the instances of existing nodes do not
+		// trigger node events, so nodes are resynced periodically to discover them.
+		go wait.Until(ca.resyncNodes, ca.additionalPodCIDRsResyncPeriod, stopCh)
+	}
+
 	if enableNodeTopology {
 		if ca.nodeTopologyQueue != nil {
 			defer ca.nodeTopologyQueue.Shutdown()
@@ -395,7 +433,7 @@ func (ca *cloudCIDRAllocator) handleErr(err error, key interface{}) {
 
 // updateCIDRAllocation assigns CIDR to Node and sends an update to the API server.
 // Operate on the `node` object if any changes have to be done to it in the API.
-func (ca *cloudCIDRAllocator) updateCIDRAllocation(nodeName string) error {
+func (ca *cloudCIDRAllocator) updateCIDRAllocation(nodeName string) (err error) {
 	oldNode, err := ca.nodeLister.Get(nodeName)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -405,13 +443,21 @@ func (ca *cloudCIDRAllocator) updateCIDRAllocation(nodeName string) error {
 		return err
 	}
 	node := oldNode.DeepCopy()
+	if len(oldNode.Spec.PodCIDRs) == 0 {
+		start := time.Now()
+		defer func() {
+			if err == nil && len(node.Spec.PodCIDRs) > 0 {
+				observeCIDRAllocation(CloudAllocatorType, time.Since(start))
+			}
+		}()
+	}
 
 	if node.Spec.ProviderID == "" {
 		return fmt.Errorf("node %s doesn't have providerID", nodeName)
 	}
 	instance, err := ca.cloud.InstanceByProviderID(node.Spec.ProviderID)
 	if err != nil {
-		nodeutil.RecordNodeStatusChange(ca.recorder, node, "CIDRNotAvailable")
+		recordCIDRAllocationFailure(ca.recorder, node, CloudAllocatorType, "CIDRNotAvailable")
 		return fmt.Errorf("failed to get instance from provider: %v", err)
 	}
 
@@ -424,7 +470,7 @@ func (ca *cloudCIDRAllocator) updateCIDRAllocation(nodeName string) error {
 			len(instance.NetworkInterfaces[0].AliasIpRanges) == 0 &&
 			ca.cloud.GetIPV6Address(instance.NetworkInterfaces[0]) == nil) {
 
-		nodeutil.RecordNodeStatusChange(ca.recorder, node, "CIDRNotAvailable")
+		recordCIDRAllocationFailure(ca.recorder, node, CloudAllocatorType, "CIDRNotAvailable")
 		return fmt.Errorf("failed to allocate cidr: Node %v has no ranges from which CIDRs can be allocated", node.Name)
 	}
 
@@ -477,7 +523,7 @@ func (ca *cloudCIDRAllocator) updateCIDRAllocation(nodeName string) error {
 		// if there's no node label get the cidrStrings with the old way by comparing the default Network and GNP
 		cidrStrings, err = ca.performMultiNetworkCIDRAllocation(node, instance.NetworkInterfaces, hasNodeLabels)
 		if err != nil {
-			nodeutil.RecordNodeStatusChange(ca.recorder, node, "AnnotationsNotAvailable")
+			recordCIDRAllocationFailure(ca.recorder, node, CloudAllocatorType, "AnnotationsNotAvailable")
 			return fmt.Errorf("failed to perform node annotations for multi-networking: %v", err)
 		}
 		if hasNodeLabels {
@@ -490,6 +536,10 @@ func (ca *cloudCIDRAllocator) updateCIDRAllocation(nodeName string) error {
 		return err
 	}
 
+	if err = ca.updateAdditionalPodCIDRs(node, ca.additionalPodCIDRs(instance.NetworkInterfaces, cidrStrings)); err != nil {
+		return err
+	}
+
 	if !reflect.DeepEqual(node.Annotations, oldNode.Annotations) || !reflect.DeepEqual(node.Status.Capacity, oldNode.Status.Capacity) {
 		// retain old north interfaces annotation
 		var oldNorthInterfacesAnnotation networkv1.NorthInterfacesAnnotation
@@ -501,7 +551,7 @@ func (ca *cloudCIDRAllocator) updateCIDRAllocation(nodeName string) error {
 		}
 
 		if err = utilnode.PatchNodeMultiNetwork(ca.client, node); err != nil {
-			nodeutil.RecordNodeStatusChange(ca.recorder, node, "CIDRAssignmentFailed")
+			recordCIDRAllocationFailure(ca.recorder, node, CloudAllocatorType, "CIDRAssignmentFailed")
 			klog.ErrorS(err, "Failed to update the node annotations and capacity for multi-networking", "nodeName", node.Name)
 			return err
 		}
@@ -529,7 +579,7 @@ func (ca *cloudCIDRAllocator) updateCIDRAllocation(nodeName string) error {
 // returns error if cidrStrings is not valid or fails to update the Node object
 func (ca *cloudCIDRAllocator) updateNodePodCIDRWithCidrStrings(oldNode *v1.Node, node *v1.Node, cidrStrings []string) error {
 	if len(cidrStrings) == 0 {
-		nodeutil.RecordNodeStatusChange(ca.recorder, node, "CIDRNotAvailable")
+		recordCIDRAllocationFailure(ca.recorder, node, CloudAllocatorType, "CIDRNotAvailable")
 		return fmt.Errorf("failed to allocate cidr: Node %v has no CIDRs", node.Name)
 	}
 	// Can have at most 2 ips (one for v4 and one for v6)
@@ -553,6 +603,111 @@ func (ca *cloudCIDRAllocator) updateNodePodCIDRWithCidrStrings(oldNode *v1.Node,
 	return ca.updateNodeCIDR(node, oldNode)
 }
 
+// additionalPodCIDRs returns the alias IP ranges of the network interface
+// holding the IPv4 pod CIDR among podCIDRs that belong to the pod secondary
+// ranges of the cluster, other than the pod CIDR itself and the ranges owned
+// by the nodenetworkconfig controller. The ranges are ordered by the position
+// of their secondary range in podSecondaryRangeNames and then by address, so
+// that the order does not depend on GCE.
+//
+// Alias IP ranges are IPv4 only and Node.Spec.PodCIDRs holds at most one CIDR
+// per IP family, so there are no additional pod CIDRs if the pod CIDRs of the
+// cluster stack type have no IPv4 CIDR.
+func (ca *cloudCIDRAllocator) additionalPodCIDRs(interfaces []*compute.NetworkInterface, podCIDRs []string) []string {
+	if len(ca.podSecondaryRangeNames) == 0 {
+		return nil
+	}
+	podCIDR := ""
+	for _, cidr := range podCIDRs {
+		if netutils.IsIPv4CIDRString(cidr) {
+			podCIDR = cidr
+			break
+		}
+	}
+	if podCIDR == "" {
+		return nil
+	}
+
+	rangeIndex := make(map[string]int, len(ca.podSecondaryRangeNames))
+	for i, name := range ca.podSecondaryRangeNames {
+		rangeIndex[name] = i
+	}
+	type podRange struct {
+		index  int
+		prefix netip.Prefix
+	}
+	for _, nic := range interfaces {
+		if !slices.ContainsFunc(nic.AliasIpRanges, func(r *compute.AliasIpRange) bool { return r.IpCidrRange == podCIDR }) {
+			continue
+		}
+		var ranges []podRange
+		for _, aliasRange := range nic.AliasIpRanges {
+			index, ok := rangeIndex[aliasRange.SubnetworkRangeName]
+			if !ok || aliasRange.IpCidrRange == podCIDR {
+				continue
+			}
+			prefix, err := netip.ParsePrefix(aliasRange.IpCidrRange)
+			if err != nil || !prefix.Addr().Is4() {
+				klog.Warningf("Ignoring invalid alias IP range %q of secondary range %q", aliasRange.IpCidrRange, aliasRange.SubnetworkRangeName)
+				continue
+			}
+			if ca.isNodeNetworkConfigPodCIDR(prefix) {
+				continue
+			}
+			ranges = append(ranges, podRange{index: index, prefix: prefix.Masked()})
+		}
+		slices.SortFunc(ranges, func(a, b podRange) int {
+			if a.index != b.index {
+				return a.index - b.index
+			}
+			return a.prefix.Addr().Compare(b.prefix.Addr())
+		})
+		cidrs := make([]string, 0, len(ranges))
+		for _, r := range ranges {
+			cidrs = append(cidrs, r.prefix.String())
+		}
+		return slices.Compact(cidrs)
+	}
+	return nil
+}
+
+// isNodeNetworkConfigPodCIDR returns whether prefix is an alias IP range the
+// nodenetworkconfig controller added out of the cluster cidr. The controller
+// publishes these in the NodeNetworkConfig of the node and removes them from the
+// instance once they are released, which it does not do for additional pod CIDRs.
+func (ca *cloudCIDRAllocator) isNodeNetworkConfigPodCIDR(prefix netip.Prefix) bool {
+	return ca.nodeNetworkConfigPodCIDRs != nil &&
+		prefix.Bits() == ca.nodeNetworkConfigPodCIDRMaskSize &&
+		ca.nodeNetworkConfigPodCIDRs.Contains(prefix.Addr().AsSlice())
+}
+
+// updateAdditionalPodCIDRs publishes cidrs in the additional pod CIDRs
+// annotation of the node if they changed.
+func (ca *cloudCIDRAllocator) updateAdditionalPodCIDRs(node *v1.Node, cidrs []string) error {
+	if slices.Equal(utilnode.GetAdditionalPodCIDRs(node), cidrs) {
+		return nil
+	}
+	if err := utilnode.PatchNodeAdditionalPodCIDRs(ca.client, types.NodeName(node.Name), cidrs); err != nil {
+		recordCIDRAllocationFailure(ca.recorder, node, CloudAllocatorType, "CIDRAssignmentFailed")
+		klog.ErrorS(err, "Failed to update the node additional pod CIDRs", "nodeName", node.Name, "cidrStrings", cidrs)
+		return err
+	}
+	klog.InfoS("Set the node additional pod CIDRs", "nodeName", node.Name, "cidrStrings", cidrs)
+	return nil
+}
+
+// resyncNodes puts all nodes into the work queue.
+func (ca *cloudCIDRAllocator) resyncNodes() {
+	nodes, err := ca.nodeLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Error listing nodes for resync: %v", err)
+		return
+	}
+	for _, node := range nodes {
+		ca.queue.Add(node.Name)
+	}
+}
+
 func (ca *cloudCIDRAllocator) setNetworkCondition(node *v1.Node) {
 	cond := v1.NodeCondition{
 		Type:               v1.NodeNetworkUnavailable,
@@ -581,7 +736,7 @@ func (ca *cloudCIDRAllocator) updateNodeCIDR(node, oldNode *v1.Node) error {
 	if !reflect.DeepEqual(node.Spec, oldNode.Spec) {
 		err = utilnode.PatchNodeCIDRs(ca.client, types.NodeName(node.Name), node.Spec.PodCIDRs)
 		if err != nil {
-			nodeutil.RecordNodeStatusChange(ca.recorder, node, "CIDRAssignmentFailed")
+			recordCIDRAllocationFailure(ca.recorder, node, CloudAllocatorType, "CIDRAssignmentFailed")
 			klog.ErrorS(err, "Failed to update the node PodCIDR after multiple attempts", "nodeName", node.Name, "cidrStrings", node.Spec.PodCIDRs)
 			return err
 		}
@@ -23,18 +23,24 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	networkv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/network/v1"
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
 	ntv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodetopology/v1"
 	clSetFake "github.com/GoogleCloudPlatform/gke-networking-api/client/network/clientset/versioned/fake"
 	networkinformers "github.com/GoogleCloudPlatform/gke-networking-api/client/network/informers/externalversions"
+	nncfake "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned/fake"
+	nncinformers "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/informers/externalversions"
 	ntfakeclient "github.com/GoogleCloudPlatform/gke-networking-api/client/nodetopology/clientset/versioned/fake"
+	"github.com/GoogleCloudPlatform/k8s-cloud-provider/pkg/cloud"
 	"github.com/GoogleCloudPlatform/k8s-cloud-provider/pkg/cloud/meta"
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/time/rate"
+	computebeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -43,9 +49,11 @@ import (
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/util/workqueue"
+	"k8s.io/cloud-provider-gcp/pkg/controller/nodenetworkconfig"
 	"k8s.io/cloud-provider-gcp/pkg/controller/testutil"
 	utilnode "k8s.io/cloud-provider-gcp/pkg/util/node"
 	"k8s.io/cloud-provider-gcp/providers/gce"
+	"k8s.io/component-base/metrics/prometheus/controllers"
 	metricsUtil "k8s.io/component-base/metrics/testutil"
 )
 
@@ -236,6 +244,155 @@ func TestNodeTopologyQueuePeriodicSync(t *testing.T) {
 	}
 }
 
+func TestAdditionalPodCIDRsResyncPeriod(t *testing.T) {
+	fakeGCE := gce.NewFakeGCECloud(gce.DefaultTestClusterValues())
+	nwInfFactory := networkinformers.NewSharedInformerFactory(clSetFake.NewSimpleClientset(), noResyncPeriodFunc()).Networking()
+	fakeNodeInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Core().V1().Nodes()
+
+	for _, tc := range []struct {
+		desc   string
+		period time.Duration
+		want   time.Duration
+	}{
+		{desc: "default", period: 0, want: DefaultAdditionalPodCIDRsResyncPeriod},
+		{desc: "configured", period: time.Hour, want: time.Hour},
+		{desc: "disabled", period: -1, want: 0},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			allocatorParams := CIDRAllocatorParams{PodSecondaryRangeNames: []string{"pods"}, AdditionalPodCIDRsResyncPeriod: tc.period}
+			allocator, err := NewCloudCIDRAllocator(fake.NewSimpleClientset(), fakeGCE, nwInfFactory.V1().Networks(), nwInfFactory.V1().GKENetworkParamSets(), nil, false, false, fakeNodeInformer, allocatorParams)
+			if err != nil {
+				t.Fatalf("NewCloudCIDRAllocator failed: %v", err)
+			}
+			if got := allocator.(*cloudCIDRAllocator).additionalPodCIDRsResyncPeriod; got != tc.want {
+				t.Errorf("expected resync period %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+// TestAdditionalPodCIDRsWithNodeNetworkConfigController runs the cloud allocator next
+// to the nodenetworkconfig controller, which adds alias IP ranges out of the cluster
+// CIDR from the same secondary range as the node pod CIDR, and checks that these are
+// not published as additional pod CIDRs, so that the controller still releases them.
+func TestAdditionalPodCIDRsWithNodeNetworkConfigController(t *testing.T) {
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	testClusterValues := gce.DefaultTestClusterValues()
+	testClusterValues.SecondaryRangeName = defaultSecondaryRangeA
+	fakeGCE := gce.NewFakeGCECloud(testClusterValues)
+	instance := &compute.Instance{
+		Name: "test",
+		Zone: testClusterValues.ZoneName,
+		NetworkInterfaces: []*compute.NetworkInterface{{
+			AliasIpRanges: []*compute.AliasIpRange{
+				{IpCidrRange: "10.100.0.0/24", SubnetworkRangeName: defaultSecondaryRangeA},
+				{IpCidrRange: "10.200.0.0/24", SubnetworkRangeName: defaultSecondaryRangeB},
+			},
+		}},
+	}
+	if err := fakeGCE.Compute().Instances().Insert(ctx, meta.ZonalKey(instance.Name, testClusterValues.ZoneName), instance); err != nil {
+		t.Fatalf("error setting up the test for fakeGCE: %v", err)
+	}
+	mbi := fakeGCE.Compute().(*cloud.MockGCE).BetaInstances().(*cloud.MockBetaInstances)
+	mbi.UpdateNetworkInterfaceHook = func(_ context.Context, key *meta.Key, _ string, iface *computebeta.NetworkInterface, m *cloud.MockBetaInstances, _ ...cloud.Option) error {
+		m.Lock.Lock()
+		defer m.Lock.Unlock()
+		instance := m.Objects[*key].ToBeta()
+		instance.NetworkInterfaces[0].AliasIpRanges = iface.AliasIpRanges
+		m.Objects[*key] = &cloud.MockInstancesObj{Obj: instance}
+		return nil
+	}
+	aliases := func() []string {
+		ranges, err := fakeGCE.AliasRangesByProviderID("gce://test-project/us-central1-b/test")
+		if err != nil {
+			t.Fatalf("AliasRangesByProviderID() failed: %v", err)
+		}
+		return ranges
+	}
+
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: v1.NodeSpec{
+			ProviderID: "gce://test-project/us-central1-b/test",
+			PodCIDR:    "10.100.0.0/24",
+			PodCIDRs:   []string{"10.100.0.0/24"},
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeNetworkUnavailable, Status: v1.ConditionFalse, Reason: "RouteCreated"}},
+		},
+	}
+	client := fake.NewSimpleClientset(node)
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	nodeInformer := informerFactory.Core().V1().Nodes()
+
+	nncClient := nncfake.NewSimpleClientset(&nncv1.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec:       nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "default", Pods: 270}}},
+	})
+	nncInformerFactory := nncinformers.NewSharedInformerFactory(nncClient, 0)
+	_, clusterCIDR, _ := net.ParseCIDR("10.100.0.0/16")
+	nncController, err := nodenetworkconfig.NewNodeNetworkConfigController(nodeInformer, nncClient, nncInformerFactory.Networking().V1().NodeNetworkConfigs(), fakeGCE, nncInformerFactory, []*net.IPNet{clusterCIDR}, nodenetworkconfig.DefaultPodCIDRMaskSize)
+	if err != nil {
+		t.Fatalf("NewNodeNetworkConfigController failed: %v", err)
+	}
+	informerFactory.Start(ctx.Done())
+	go nncController.Run(1, ctx.Done(), controllers.NewControllerManagerMetrics("test"))
+
+	// The controller adds a /28 out of the cluster CIDR for the requested pods.
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return len(aliases()) == 3, nil
+	}); err != nil {
+		t.Fatalf("timed out waiting for the nodenetworkconfig controller to add an alias IP range, got %v", aliases())
+	}
+
+	nwInfFactory := networkinformers.NewSharedInformerFactory(clSetFake.NewSimpleClientset(), noResyncPeriodFunc()).Networking()
+	allocatorParams := CIDRAllocatorParams{
+		ClusterCIDRs:                     []*net.IPNet{clusterCIDR},
+		PodSecondaryRangeNames:           []string{defaultSecondaryRangeA, defaultSecondaryRangeB},
+		AdditionalPodCIDRsResyncPeriod:   -1,
+		NodeNetworkConfigPodCIDRMaskSize: nodenetworkconfig.DefaultPodCIDRMaskSize,
+	}
+	allocator, err := NewCloudCIDRAllocator(client, fakeGCE, nwInfFactory.V1().Networks(), nwInfFactory.V1().GKENetworkParamSets(), nil, false, false, nodeInformer, allocatorParams)
+	if err != nil {
+		t.Fatalf("NewCloudCIDRAllocator failed: %v", err)
+	}
+	if err := allocator.(*cloudCIDRAllocator).updateCIDRAllocation("test"); err != nil {
+		t.Fatalf("updateCIDRAllocation failed: %v", err)
+	}
+	updated, err := client.CoreV1().Nodes().Get(ctx, "test", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	if got := updated.Annotations[utilnode.AdditionalPodCIDRsAnnotationKey]; got != "10.200.0.0/24" {
+		t.Errorf("expected only the operator added alias IP range to be an additional pod CIDR, got %q", got)
+	}
+
+	// The /28 is released by the controller once the daemon lists it as releasable,
+	// after the controller published the additional pod CIDR.
+	var nnc *nncv1.NodeNetworkConfig
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(ctx context.Context) (bool, error) {
+		nnc, err = nncClient.NetworkingV1().NodeNetworkConfigs().Get(ctx, "test", metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return slices.ContainsFunc(nnc.Status.PodCIDRs, func(podCIDR nncv1.PodCIDR) bool { return podCIDR.CIDR == "10.200.0.0/24" }), nil
+	}); err != nil {
+		t.Fatalf("timed out waiting for the additional pod CIDR to be published: %v", err)
+	}
+	nnc.Spec.Allocations[0].Pods = 200
+	nnc.Spec.ReleasableCIDRs = []nncv1.PodCIDR{{Network: "default", CIDR: "10.100.1.0/28"}}
+	if _, err := nncClient.NetworkingV1().NodeNetworkConfigs().Update(ctx, nnc, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update NodeNetworkConfig: %v", err)
+	}
+	want := []string{"10.100.0.0/24", "10.200.0.0/24"}
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return cmp.Equal(aliases(), want), nil
+	}); err != nil {
+		t.Errorf("timed out waiting for the released alias IP range to be removed, expected %v, got %v", want, aliases())
+	}
+}
+
 func TestNodeTopologyCR_AddOrUpdateNode(t *testing.T) {
 	testClusterValues := gce.DefaultTestClusterValues()
 	testClusterValues.SubnetworkURL = exampleSubnetURL
@@ -551,9 +708,11 @@ func TestUpdateCIDRAllocation(t *testing.T) {
 		gceInstance           []*compute.Instance
 		stackType             *clusterStackType
 		enableMultiNetworking bool
-		expectErr             bool
-		expectErrMsg          string
-		expectedUpdate        bool
+		// podSecondaryRangeNames is optional, see cloudCIDRAllocator.podSecondaryRangeNames
+		podSecondaryRangeNames []string
+		expectErr              bool
+		expectErrMsg           string
+		expectedUpdate         bool
 		// expectedMetrics is optional if you'd also like to assert a metric
 		expectedMetrics map[string]float64
 	}{
@@ -859,6 +1018,196 @@ func TestUpdateCIDRAllocation(t *testing.T) {
 			},
 			expectedUpdate: true,
 		},
+		{
+			name: "additional pod ranges, single stack ipv4 cluster",
+			fakeNodeHandler: &testutil.FakeNodeHandler{
+				Existing: []*v1.Node{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "test",
+						},
+						Spec: v1.NodeSpec{
+							ProviderID: "gce://test-project/us-central1-b/test",
+						},
+					},
+				},
+				Clientset: fake.NewSimpleClientset(),
+			},
+			gceInstance: []*compute.Instance{
+				{
+					Name: "test",
+					NetworkInterfaces: []*compute.NetworkInterface{
+						{
+							AliasIpRanges: []*compute.AliasIpRange{
+								{IpCidrRange: "192.168.1.0/24", SubnetworkRangeName: defaultSecondaryRangeA},
+								{IpCidrRange: "10.12.0.0/24", SubnetworkRangeName: defaultSecondaryRangeB},
+								{IpCidrRange: "172.16.0.0/24", SubnetworkRangeName: "other"},
+								{IpCidrRange: "192.168.9.0/24", SubnetworkRangeName: defaultSecondaryRangeA},
+								{IpCidrRange: "10.11.0.0/24", SubnetworkRangeName: defaultSecondaryRangeB},
+							},
+						},
+					},
+				},
+			},
+			podSecondaryRangeNames: []string{defaultSecondaryRangeA, defaultSecondaryRangeB},
+			nodeChanges: func(node *v1.Node) {
+				node.Annotations = map[string]string{
+					utilnode.AdditionalPodCIDRsAnnotationKey: "192.168.9.0/24,10.11.0.0/24,10.12.0.0/24",
+				}
+				node.Spec.PodCIDR = "192.168.1.0/24"
+				node.Spec.PodCIDRs = []string{"192.168.1.0/24"}
+				node.Status.Conditions = []v1.NodeCondition{
+					{
+						Type:    "NetworkUnavailable",
+						Status:  "False",
+						Reason:  "RouteCreated",
+						Message: "NodeController create implicit route",
+					},
+				}
+			},
+			expectedUpdate: true,
+		},
+		{
+			name: "additional pod ranges, IPv6IPv4 cluster",
+			fakeNodeHandler: &testutil.FakeNodeHandler{
+				Existing: []*v1.Node{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "test",
+						},
+						Spec: v1.NodeSpec{
+							ProviderID: "gce://test-project/us-central1-b/test",
+						},
+					},
+				},
+				Clientset: fake.NewSimpleClientset(),
+			},
+			gceInstance: []*compute.Instance{
+				{
+					Name: "test",
+					NetworkInterfaces: []*compute.NetworkInterface{
+						{
+							Ipv6Address: "2001:db9::110",
+							AliasIpRanges: []*compute.AliasIpRange{
+								{IpCidrRange: "192.168.1.0/24", SubnetworkRangeName: defaultSecondaryRangeA},
+								{IpCidrRange: "10.11.0.0/24", SubnetworkRangeName: defaultSecondaryRangeB},
+							},
+						},
+					},
+				},
+			},
+			stackType:              &ipv6ipv4Stack,
+			podSecondaryRangeNames: []string{defaultSecondaryRangeA, defaultSecondaryRangeB},
+			nodeChanges: func(node *v1.Node) {
+				node.Annotations = map[string]string{
+					utilnode.AdditionalPodCIDRsAnnotationKey: "10.11.0.0/24",
+				}
+				node.Spec.PodCIDR = "2001:db9::/112"
+				node.Spec.PodCIDRs = []string{"2001:db9::/112", "192.168.1.0/24"}
+				node.Status.Conditions = []v1.NodeCondition{
+					{
+						Type:    "NetworkUnavailable",
+						Status:  "False",
+						Reason:  "RouteCreated",
+						Message: "NodeController create implicit route",
+					},
+				}
+			},
+			expectedUpdate: true,
+		},
+		{
+			name: "no additional pod ranges in single stack ipv6 cluster",
+			fakeNodeHandler: &testutil.FakeNodeHandler{
+				Existing: []*v1.Node{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "test",
+						},
+						Spec: v1.NodeSpec{
+							ProviderID: "gce://test-project/us-central1-b/test",
+						},
+					},
+				},
+				Clientset: fake.NewSimpleClientset(),
+			},
+			gceInstance: []*compute.Instance{
+				{
+					Name: "test",
+					NetworkInterfaces: []*compute.NetworkInterface{
+						{
+							Ipv6Address: "2001:db9::110",
+							AliasIpRanges: []*compute.AliasIpRange{
+								{IpCidrRange: "192.168.1.0/24", SubnetworkRangeName: defaultSecondaryRangeA},
+								{IpCidrRange: "10.11.0.0/24", SubnetworkRangeName: defaultSecondaryRangeB},
+							},
+						},
+					},
+				},
+			},
+			stackType:              &ipv6Stack,
+			podSecondaryRangeNames: []string{defaultSecondaryRangeA, defaultSecondaryRangeB},
+			nodeChanges: func(node *v1.Node) {
+				node.Spec.PodCIDR = "2001:db9::/112"
+				node.Spec.PodCIDRs = []string{"2001:db9::/112"}
+				node.Status.Conditions = []v1.NodeCondition{
+					{
+						Type:    "NetworkUnavailable",
+						Status:  "False",
+						Reason:  "RouteCreated",
+						Message: "NodeController create implicit route",
+					},
+				}
+			},
+			expectedUpdate: true,
+		},
+		{
+			name: "removed additional pod range",
+			fakeNodeHandler: &testutil.FakeNodeHandler{
+				Existing: []*v1.Node{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "test",
+							Annotations: map[string]string{
+								utilnode.AdditionalPodCIDRsAnnotationKey: "10.11.0.0/24",
+							},
+						},
+						Spec: v1.NodeSpec{
+							PodCIDR:    "192.168.1.0/24",
+							PodCIDRs:   []string{"192.168.1.0/24"},
+							ProviderID: "gce://test-project/us-central1-b/test",
+						},
+						Status: v1.NodeStatus{
+							Conditions: []v1.NodeCondition{
+								{
+									Type:    "NetworkUnavailable",
+									Status:  "False",
+									Reason:  "RouteCreated",
+									Message: "NodeController create implicit route",
+								},
+							},
+						},
+					},
+				},
+				Clientset: fake.NewSimpleClientset(),
+			},
+			gceInstance: []*compute.Instance{
+				{
+					Name: "test",
+					NetworkInterfaces: []*compute.NetworkInterface{
+						{
+							AliasIpRanges: []*compute.AliasIpRange{
+								{IpCidrRange: "192.168.1.0/24", SubnetworkRangeName: defaultSecondaryRangeA},
+							},
+						},
+					},
+				},
+			},
+			podSecondaryRangeNames: []string{defaultSecondaryRangeA, defaultSecondaryRangeB},
+			nodeChanges: func(node *v1.Node) {
+				node.Annotations = map[string]string{}
+			},
+			expectedUpdate: true,
+		},
 		{
 			name: "want error - incorrect ipv6 cidr instead of address",
 			fakeNodeHandler: &testutil.FakeNodeHandler{
@@ -2503,16 +2852,17 @@ func TestUpdateCIDRAllocation(t *testing.T) {
 			nodetopologyQueue := NewTaskQueue("nodetopologgTaskQueueForTest", "nodetopologyCRD", 1, nodeTopologyKeyFun, nodeTopologySyncer.sync)
 
 			ca := &cloudCIDRAllocator{
-				client:                tc.fakeNodeHandler,
-				cloud:                 fakeGCE,
-				recorder:              testutil.NewFakeRecorder(),
-				nodeLister:            fakeNodeInformer.Lister(),
-				nodesSynced:           fakeNodeInformer.Informer().HasSynced,
-				networksLister:        nwInformer.Lister(),
-				gnpLister:             gnpInformer.Lister(),
-				stackType:             stackType,
-				nodeTopologyQueue:     nodetopologyQueue,
-				enableMultiNetworking: tc.enableMultiNetworking,
+				client:                 tc.fakeNodeHandler,
+				cloud:                  fakeGCE,
+				recorder:               testutil.NewFakeRecorder(),
+				nodeLister:             fakeNodeInformer.Lister(),
+				nodesSynced:            fakeNodeInformer.Informer().HasSynced,
+				networksLister:         nwInformer.Lister(),
+				gnpLister:              gnpInformer.Lister(),
+				stackType:              stackType,
+				nodeTopologyQueue:      nodetopologyQueue,
+				enableMultiNetworking:  tc.enableMultiNetworking,
+				podSecondaryRangeNames: tc.podSecondaryRangeNames,
 			}
 
 			// test
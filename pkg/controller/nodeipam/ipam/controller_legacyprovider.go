@@ -20,6 +20,7 @@ limitations under the License.
 package ipam
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"sync"
@@ -48,6 +49,9 @@ type Config struct {
 	InitialRetry time.Duration
 	// Mode to use to synchronize.
 	Mode nodesync.NodeSyncMode
+	// UtilizationAlertThresholds are the cluster CIDR utilization percentages
+	// warning events are recorded at.
+	UtilizationAlertThresholds []float64
 }
 
 // Controller is the controller for synchronizing cluster and cloud node
@@ -59,7 +63,9 @@ type Controller struct {
 	lock    sync.Mutex
 	syncers map[string]*nodesync.NodeSync
 
-	set *cidrset.CidrSet
+	set         *cidrset.CidrSet
+	clusterCIDR *net.IPNet
+	alerter     *cidrUtilizationAlerter
 }
 
 // NewController returns a new instance of the IPAM controller.
@@ -85,11 +91,14 @@ func NewController(
 	}
 
 	c := &Controller{
-		config:  config,
-		adapter: newAdapter(kubeClient, gceCloud),
-		syncers: make(map[string]*nodesync.NodeSync),
-		set:     set,
+		config:      config,
+		adapter:     newAdapter(kubeClient, gceCloud),
+		syncers:     make(map[string]*nodesync.NodeSync),
+		set:         set,
+		clusterCIDR: clusterCIDR,
 	}
+	c.alerter = newCIDRUtilizationAlerter(config.UtilizationAlertThresholds, c.adapter.recorder)
+	registerCIDRAllocatorMetrics()
 
 	if err := occupyServiceCIDR(c.set, clusterCIDR, serviceCIDR); err != nil {
 		return nil, err
@@ -162,6 +171,7 @@ func occupyServiceCIDR(set *cidrset.CidrSet, clusterCIDR, serviceCIDR *net.IPNet
 
 type nodeState struct {
 	t Timeout
+	c *Controller
 }
 
 func (ns *nodeState) ReportResult(err error) {
@@ -172,13 +182,31 @@ func (ns *nodeState) ResyncTimeout() time.Duration {
 	return ns.t.Next()
 }
 
+func (ns *nodeState) ReportAllocation(node *v1.Node, start time.Time, err error) {
+	allocatorType := CIDRAllocatorType(IPAMFromClusterAllocatorType)
+	if ns.c.config.Mode == nodesync.SyncFromCloud {
+		allocatorType = IPAMFromCloudAllocatorType
+	}
+	switch {
+	case errors.Is(err, cidrset.ErrCIDRRangeNoCIDRsRemaining):
+		cidrAllocationFailures.WithLabelValues(string(allocatorType), "CIDRNotAvailable").Inc()
+	case err != nil:
+		cidrAllocationFailures.WithLabelValues(string(allocatorType), "CIDRAssignmentFailed").Inc()
+	default:
+		observeCIDRAllocation(allocatorType, time.Since(start))
+		allocated, max := ns.c.set.Usage()
+		ns.c.alerter.observe(ns.c.clusterCIDR.String(), allocated, max, node)
+	}
+}
+
 func (c *Controller) newSyncer(name string) *nodesync.NodeSync {
 	ns := &nodeState{
-		Timeout{
+		t: Timeout{
 			Resync:       c.config.Resync,
 			MaxBackoff:   c.config.MaxBackoff,
 			InitialRetry: c.config.InitialRetry,
 		},
+		c: c,
 	}
 	return nodesync.New(ns, c.adapter, c.adapter, c.config.Mode, name, c.set)
 }
@@ -17,9 +17,13 @@ limitations under the License.
 package ipam
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
@@ -28,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	informers "k8s.io/client-go/informers/core/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -38,6 +43,7 @@ import (
 	"k8s.io/cloud-provider-gcp/pkg/controller/nodeipam/ipam/cidrset"
 	nodeutil "k8s.io/cloud-provider-gcp/pkg/util"
 	utilnode "k8s.io/cloud-provider-gcp/pkg/util/node"
+	"k8s.io/utils/clock"
 )
 
 // cidrs are reserved, then node resource is patched with them
@@ -45,14 +51,74 @@ import (
 type nodeReservedCIDRs struct {
 	allocatedCIDRs []*net.IPNet
 	nodeName       string
+	// reservedAt is when the cidrs were reserved, to measure the allocation duration
+	reservedAt time.Time
+}
+
+// clusterCIDRRange is a cluster cidr node cidrs of its IP family are allocated from
+type clusterCIDRRange struct {
+	cidr             *net.IPNet
+	nodeCIDRMaskSize int
+	// cidrSet maintains a list of what is used and what is not
+	cidrSet *cidrset.CidrSet
 }
 
 type rangeAllocator struct {
 	client clientset.Interface
 	// cluster cidrs as passed in during controller creation
 	clusterCIDRs []*net.IPNet
-	// for each entry in clusterCIDRs we maintain a list of what is used and what is not
-	cidrSets []*cidrset.CidrSet
+	// for each entry in clusterCIDRs we maintain the cluster cidr ranges of its IP family
+	// in priority order, starting with the entry itself. Ranges are only ever appended.
+	cidrRanges [][]*clusterCIDRRange
+	// pools are the node cidr pools, which nodes selected by them get their cidrs from
+	// instead of cidrRanges
+	pools []*nodeCIDRPool
+	// rangesLock guards cidrRanges and pools, which change when the cluster cidrs
+	// ConfigMap is updated at runtime
+	rangesLock sync.RWMutex
+	// nodeCIDRMaskSizes are the node cidr mask sizes of clusterCIDRs, used by default
+	// for the additional cluster cidrs of the same IP family
+	nodeCIDRMaskSizes []int
+	// serviceCIDRs are filtered out of all cluster cidr ranges
+	serviceCIDRs []*net.IPNet
+	// clusterCIDRsConfigMap is the "namespace/name" of the ConfigMap additional
+	// cluster cidrs are read from, if any
+	clusterCIDRsConfigMap string
+	// nodeCIDRPoolsConfigMap is the "namespace/name" of the ConfigMap node cidr pools
+	// are read from, if any
+	nodeCIDRPoolsConfigMap string
+	// poolEvents are the reason and message of the last node cidr pool event
+	// recorded on each node, as allocations are retried on every node update
+	poolEvents     map[string]string
+	poolEventsLock sync.Mutex
+	// allocatorStateConfigMap is the "namespace/name" of the ConfigMap the allocator
+	// state is checkpointed to, if any
+	allocatorStateConfigMap string
+	// lastCheckpoint is the allocator state last read from or written to
+	// allocatorStateConfigMap
+	lastCheckpoint string
+	// reserved are, for each entry in clusterCIDRs, the node cidrs of its IP family
+	// kept out of the allocations and checkpointed as reserved. They are only
+	// allocated while restoring, as the checkpoint accounts for them even if it
+	// misses the allocations made after it.
+	reserved [][]*net.IPNet
+	// allocatedReserved are the reserved cidrs allocated while restoring
+	allocatedReserved sets.String
+	// checkpointLock guards lastCheckpoint, reserved and allocatedReserved
+	checkpointLock sync.Mutex
+	// restoring is set until the allocator state restored on startup is validated
+	// against the nodes. Only the reserved cidrs are allocated meanwhile.
+	restoring atomic.Bool
+	// cidrReleaseQuarantine is how long the cidrs of deleted nodes stay quarantined
+	cidrReleaseQuarantine time.Duration
+	// quarantined are the released cidrs, by cidr, that are not allocated again until
+	// their quarantine is over. They stay used in their cidrSet meanwhile.
+	quarantined    map[string]*quarantinedCIDR
+	quarantineLock sync.Mutex
+	clock          clock.Clock
+	// utilizationAlerter alerts when the utilization of the cluster cidr ranges crosses
+	// the utilization alert thresholds, if any
+	utilizationAlerter *cidrUtilizationAlerter
 	// nodeLister is able to list/get nodes and is populated by the shared informer passed to controller
 	nodeLister corelisters.NodeLister
 	// nodesSynced returns true if the node shared informer has been synced at least once.
@@ -84,38 +150,76 @@ func NewCIDRRangeAllocator(client clientset.Interface, nodeInformer informers.No
 
 	// create a cidrSet for each cidr we operate on
 	// cidrSet are mapped to clusterCIDR by index
-	cidrSets := make([]*cidrset.CidrSet, len(allocatorParams.ClusterCIDRs))
+	cidrRanges := make([][]*clusterCIDRRange, len(allocatorParams.ClusterCIDRs))
 	for idx, cidr := range allocatorParams.ClusterCIDRs {
 		cidrSet, err := cidrset.NewCIDRSet(cidr, allocatorParams.NodeCIDRMaskSizes[idx])
 		if err != nil {
 			return nil, err
 		}
-		cidrSets[idx] = cidrSet
+		cidrRanges[idx] = []*clusterCIDRRange{{cidr: cidr, nodeCIDRMaskSize: allocatorParams.NodeCIDRMaskSizes[idx], cidrSet: cidrSet}}
 	}
 
 	ra := &rangeAllocator{
-		client:                client,
-		clusterCIDRs:          allocatorParams.ClusterCIDRs,
-		cidrSets:              cidrSets,
-		nodeLister:            nodeInformer.Lister(),
-		nodesSynced:           nodeInformer.Informer().HasSynced,
-		nodeCIDRUpdateChannel: make(chan nodeReservedCIDRs, cidrUpdateQueueSize),
-		recorder:              recorder,
-		nodesInProcessing:     sets.NewString(),
+		client:                  client,
+		clusterCIDRs:            allocatorParams.ClusterCIDRs,
+		cidrRanges:              cidrRanges,
+		nodeCIDRMaskSizes:       allocatorParams.NodeCIDRMaskSizes,
+		clusterCIDRsConfigMap:   allocatorParams.ClusterCIDRsConfigMap,
+		nodeCIDRPoolsConfigMap:  allocatorParams.NodeCIDRPoolsConfigMap,
+		poolEvents:              map[string]string{},
+		allocatorStateConfigMap: allocatorParams.AllocatorStateConfigMap,
+		cidrReleaseQuarantine:   allocatorParams.CIDRReleaseQuarantine,
+		reserved:                make([][]*net.IPNet, len(allocatorParams.ClusterCIDRs)),
+		allocatedReserved:       sets.NewString(),
+		quarantined:             map[string]*quarantinedCIDR{},
+		clock:                   clock.RealClock{},
+		nodeLister:              nodeInformer.Lister(),
+		nodesSynced:             nodeInformer.Informer().HasSynced,
+		nodeCIDRUpdateChannel:   make(chan nodeReservedCIDRs, cidrUpdateQueueSize),
+		recorder:                recorder,
+		nodesInProcessing:       sets.NewString(),
+		utilizationAlerter:      newCIDRUtilizationAlerter(allocatorParams.UtilizationAlertThresholds, recorder),
 	}
+	registerCIDRAllocatorMetrics()
 
 	if allocatorParams.ServiceCIDR != nil {
+		ra.serviceCIDRs = append(ra.serviceCIDRs, allocatorParams.ServiceCIDR)
 		ra.filterOutServiceRange(allocatorParams.ServiceCIDR)
 	} else {
 		klog.V(0).Info("No Service CIDR provided. Skipping filtering out service addresses.")
 	}
 
 	if allocatorParams.SecondaryServiceCIDR != nil {
+		ra.serviceCIDRs = append(ra.serviceCIDRs, allocatorParams.SecondaryServiceCIDR)
 		ra.filterOutServiceRange(allocatorParams.SecondaryServiceCIDR)
 	} else {
 		klog.V(0).Info("No Secondary Service CIDR provided. Skipping filtering out secondary service addresses.")
 	}
 
+	// Additional cluster cidrs must be known before existing nodes' cidrs are occupied,
+	// as nodes may have been allocated cidrs from them before a restart.
+	if _, err := ra.addClusterCIDRs(allocatorParams.AdditionalClusterCIDRs); err != nil {
+		return nil, err
+	}
+	if ra.clusterCIDRsConfigMap != "" {
+		if err := ra.loadClusterCIDRsConfigMap(); err != nil {
+			return nil, err
+		}
+	}
+	if ra.nodeCIDRPoolsConfigMap != "" {
+		if err := ra.loadNodeCIDRPoolsConfigMap(); err != nil {
+			return nil, err
+		}
+	}
+	// The checkpointed state is restored once all cluster cidr ranges are known, and
+	// validated against the nodes in Run.
+	if ra.allocatorStateConfigMap != "" {
+		if err := ra.loadAllocatorState(); err != nil {
+			return nil, err
+		}
+		ra.restoring.Store(true)
+	}
+
 	if nodeList != nil {
 		for _, node := range nodeList.Items {
 			if len(node.Spec.PodCIDRs) == 0 {
@@ -172,12 +276,48 @@ func (r *rangeAllocator) Run(stopCh <-chan struct{}) {
 	klog.Infof("Starting range CIDR allocator")
 	defer klog.Infof("Shutting down range CIDR allocator")
 
+	// While the restored allocator state is validated, the nodes delivered by the
+	// informer get the reserved cidrs before all nodes are synced.
+	restoring := r.restoring.Load()
+	if restoring {
+		for i := 0; i < cidrUpdateWorkers; i++ {
+			go r.worker(stopCh)
+		}
+	}
+
 	if !cache.WaitForNamedCacheSync("cidrallocator", stopCh, r.nodesSynced) {
 		return
 	}
 
-	for i := 0; i < cidrUpdateWorkers; i++ {
-		go r.worker(stopCh)
+	if !restoring {
+		for i := 0; i < cidrUpdateWorkers; i++ {
+			go r.worker(stopCh)
+		}
+	}
+
+	if r.clusterCIDRsConfigMap != "" {
+		go r.watchConfigMap(r.clusterCIDRsConfigMap, r.syncClusterCIDRsConfigMap, stopCh)
+	}
+	if r.nodeCIDRPoolsConfigMap != "" {
+		go r.watchConfigMap(r.nodeCIDRPoolsConfigMap, r.syncNodeCIDRPoolsConfigMap, stopCh)
+	}
+
+	if r.restoring.Load() {
+		// Allocations beyond the reserved cidrs are deferred until the validation
+		// succeeds, so it is retried until then.
+		_ = wait.PollUntilContextCancel(wait.ContextForChannel(stopCh), allocatorStateValidationRetryPeriod, true, func(context.Context) (bool, error) {
+			if err := r.validateAllocatorState(); err != nil {
+				klog.Errorf("Failed to validate the allocator state, retrying: %v", err)
+				return false, nil
+			}
+			return true, nil
+		})
+	}
+	if r.cidrReleaseQuarantine > 0 {
+		go wait.Until(r.syncQuarantinedCIDRs, cidrQuarantineResyncPeriod, stopCh)
+	}
+	if r.allocatorStateConfigMap != "" {
+		go wait.Until(r.checkpointAllocatorState, allocatorStateCheckpointPeriod, stopCh)
 	}
 
 	<-stopCh
@@ -231,11 +371,15 @@ func (r *rangeAllocator) occupyCIDRs(node *v1.Node) error {
 		// If node has a pre allocate cidr that does not exist in our cidrs.
 		// This will happen if cluster went from dualstack(multi cidrs) to non-dualstack
 		// then we have now way of locking it
-		if idx >= len(r.cidrSets) {
+		if idx >= len(r.clusterCIDRs) {
 			return fmt.Errorf("node:%s has an allocated cidr: %v at index:%v that does not exist in cluster cidrs configuration", node.Name, cidr, idx)
 		}
 
-		if err := r.cidrSets[idx].Occupy(podCIDR); err != nil {
+		cidrSet, err := r.cidrSetFor(idx, podCIDR)
+		if err == nil {
+			err = cidrSet.Occupy(podCIDR)
+		}
+		if err != nil {
 			return fmt.Errorf("failed to mark cidr[%v] at idx [%v] as occupied for node: %v: %v", podCIDR, idx, node.Name, err)
 		}
 	}
@@ -257,20 +401,58 @@ func (r *rangeAllocator) AllocateOrOccupyCIDR(node *v1.Node) error {
 	if len(node.Spec.PodCIDRs) > 0 {
 		return r.occupyCIDRs(node)
 	}
+	pool, err := r.nodeCIDRPoolFor(node)
+	if err != nil {
+		r.removeNodeFromProcessing(node.Name)
+		// nodeCIDRPoolFor records the event once per conflict.
+		cidrAllocationFailures.WithLabelValues(string(RangeAllocatorType), "NodeCIDRPoolConflict").Inc()
+		return err
+	}
 	// allocate and queue the assignment
 	allocated := nodeReservedCIDRs{
 		nodeName:       node.Name,
-		allocatedCIDRs: make([]*net.IPNet, len(r.cidrSets)),
+		allocatedCIDRs: make([]*net.IPNet, len(r.clusterCIDRs)),
+		reservedAt:     r.clock.Now(),
+	}
+	if r.restoring.Load() {
+		// Nodes of node cidr pools, and nodes coming once the reserved cidrs are
+		// exhausted, get cidrs from validateAllocatorState once the restored state is
+		// validated.
+		var reserved []*net.IPNet
+		if pool == nil {
+			reserved, err = r.allocateReserved()
+		}
+		if reserved == nil {
+			if err != nil {
+				klog.Errorf("Failed to allocate reserved CIDRs to node %v, deferring its allocation until the allocator state is validated: %v", node.Name, err)
+			} else {
+				klog.V(2).Infof("Deferring CIDR allocation of node %v until the allocator state is validated", node.Name)
+			}
+			r.removeNodeFromProcessing(node.Name)
+			if !r.restoring.Load() {
+				// The state was validated meanwhile, and validateAllocatorState may
+				// have skipped the node as it was being processed.
+				return r.AllocateOrOccupyCIDR(node)
+			}
+			return nil
+		}
+		allocated.allocatedCIDRs = reserved
+		klog.V(4).Infof("Putting node %s with reserved CIDR %v into the work queue", node.Name, allocated.allocatedCIDRs)
+		r.nodeCIDRUpdateChannel <- allocated
+		return nil
 	}
 
-	for idx := range r.cidrSets {
-		podCIDR, err := r.cidrSets[idx].AllocateNext()
+	for idx := range r.clusterCIDRs {
+		podCIDR, err := r.allocateNext(idx, pool)
 		if err != nil {
+			// release the cidrs already reserved for the other IP families
+			r.releaseCIDRs(allocated.allocatedCIDRs[:idx])
 			r.removeNodeFromProcessing(node.Name)
-			nodeutil.RecordNodeStatusChange(r.recorder, node, "CIDRNotAvailable")
+			recordCIDRAllocationFailure(r.recorder, node, RangeAllocatorType, "CIDRNotAvailable")
 			return fmt.Errorf("failed to allocate cidr from cluster cidr at idx:%v: %v", idx, err)
 		}
 		allocated.allocatedCIDRs[idx] = podCIDR
+		r.observeUtilization(idx, podCIDR, node)
 	}
 
 	//queue the assignment
@@ -281,7 +463,11 @@ func (r *rangeAllocator) AllocateOrOccupyCIDR(node *v1.Node) error {
 
 // ReleaseCIDR marks node.podCIDRs[...] as unused in our tracked cidrSets
 func (r *rangeAllocator) ReleaseCIDR(node *v1.Node) error {
-	if node == nil || len(node.Spec.PodCIDRs) == 0 {
+	if node == nil {
+		return nil
+	}
+	r.forgetNodeCIDRPoolEvent(node.Name)
+	if len(node.Spec.PodCIDRs) == 0 {
 		return nil
 	}
 
@@ -294,14 +480,23 @@ func (r *rangeAllocator) ReleaseCIDR(node *v1.Node) error {
 		// If node has a pre allocate cidr that does not exist in our cidrs.
 		// This will happen if cluster went from dualstack(multi cidrs) to non-dualstack
 		// then we have now way of locking it
-		if idx >= len(r.cidrSets) {
+		if idx >= len(r.clusterCIDRs) {
 			return fmt.Errorf("node:%s has an allocated cidr: %v at index:%v that does not exist in cluster cidrs configuration", node.Name, cidr, idx)
 		}
 
 		klog.V(4).Infof("release CIDR %s for node:%v", cidr, node.Name)
-		if err = r.cidrSets[idx].Release(podCIDR); err != nil {
+		cidrSet, err := r.cidrSetFor(idx, podCIDR)
+		if err == nil {
+			if r.cidrReleaseQuarantine > 0 {
+				r.quarantineCIDR(idx, podCIDR, r.clock.Now())
+			} else {
+				err = cidrSet.Release(podCIDR)
+			}
+		}
+		if err != nil {
 			return fmt.Errorf("error when releasing CIDR %v: %v", cidr, err)
 		}
+		r.observeUtilization(idx, podCIDR, nil)
 	}
 	return nil
 }
@@ -314,15 +509,84 @@ func (r *rangeAllocator) filterOutServiceRange(serviceCIDR *net.IPNet) {
 	// clusterCIDR's Mask applied (this means that clusterCIDR contains
 	// serviceCIDR) or vice versa (which means that serviceCIDR contains
 	// clusterCIDR).
-	for idx, cidr := range r.clusterCIDRs {
-		// if they don't overlap then ignore the filtering
-		if !cidr.Contains(serviceCIDR.IP.Mask(cidr.Mask)) && !serviceCIDR.Contains(cidr.IP.Mask(serviceCIDR.Mask)) {
+	for idx, ranges := range r.cidrRanges {
+		for _, cidrRange := range ranges {
+			filterOutServiceRange(cidrRange, idx, serviceCIDR)
+		}
+	}
+}
+
+// filterOutServiceRange marks the cidrs of cidrRange that overlap with serviceCIDR as used.
+func filterOutServiceRange(cidrRange *clusterCIDRRange, idx int, serviceCIDR *net.IPNet) {
+	cidr := cidrRange.cidr
+	// if they don't overlap then ignore the filtering
+	if !cidr.Contains(serviceCIDR.IP.Mask(cidr.Mask)) && !serviceCIDR.Contains(cidr.IP.Mask(serviceCIDR.Mask)) {
+		return
+	}
+
+	if err := cidrRange.cidrSet.Occupy(serviceCIDR); err != nil {
+		klog.Errorf("Error filtering out service cidr out cluster cidr:%v (index:%v) %v: %v", cidr, idx, serviceCIDR, err)
+	}
+}
+
+// allocateNext allocates the next free cidr of the IP family of clusterCIDRs[idx],
+// from the first of the ranges of pool that is not exhausted. If pool is nil, or has
+// no ranges of the IP family, the cluster cidr ranges are used.
+func (r *rangeAllocator) allocateNext(idx int, pool *nodeCIDRPool) (*net.IPNet, error) {
+	r.rangesLock.RLock()
+	defer r.rangesLock.RUnlock()
+	ranges := r.cidrRanges[idx]
+	if pool != nil && len(pool.cidrRanges[idx]) > 0 {
+		ranges = pool.cidrRanges[idx]
+	}
+	for _, cidrRange := range ranges {
+		podCIDR, err := cidrRange.cidrSet.AllocateNext()
+		if errors.Is(err, cidrset.ErrCIDRRangeNoCIDRsRemaining) {
 			continue
 		}
+		return podCIDR, err
+	}
+	return nil, cidrset.ErrCIDRRangeNoCIDRsRemaining
+}
+
+// observeUtilization alerts if the utilization of the cluster cidr or node cidr pool
+// range of the IP family of clusterCIDRs[idx] podCIDR was allocated to node from
+// crossed a utilization alert threshold. It is called with a nil node once podCIDR is
+// released, so that the thresholds the utilization dropped below alert again.
+func (r *rangeAllocator) observeUtilization(idx int, podCIDR *net.IPNet, node *v1.Node) {
+	r.rangesLock.RLock()
+	defer r.rangesLock.RUnlock()
+	for _, cidrRange := range r.allCIDRRanges(idx) {
+		if cidrRange.cidr.Contains(podCIDR.IP) {
+			allocated, max := cidrRange.cidrSet.Usage()
+			r.utilizationAlerter.observe(cidrRange.cidr.String(), allocated, max, node)
+			return
+		}
+	}
+}
+
+// cidrSetFor returns the cidrSet of the cluster cidr or node cidr pool range of the
+// IP family of clusterCIDRs[idx] that contains podCIDR.
+func (r *rangeAllocator) cidrSetFor(idx int, podCIDR *net.IPNet) (*cidrset.CidrSet, error) {
+	r.rangesLock.RLock()
+	defer r.rangesLock.RUnlock()
+	for _, cidrRange := range r.allCIDRRanges(idx) {
+		if cidrRange.cidr.Contains(podCIDR.IP) {
+			return cidrRange.cidrSet, nil
+		}
+	}
+	return nil, fmt.Errorf("CIDR %v is not in any cluster CIDR of the IP family of %v", podCIDR, r.clusterCIDRs[idx])
+}
 
-		// at this point, len(cidrSet) == len(clusterCidr)
-		if err := r.cidrSets[idx].Occupy(serviceCIDR); err != nil {
-			klog.Errorf("Error filtering out service cidr out cluster cidr:%v (index:%v) %v: %v", cidr, idx, serviceCIDR, err)
+// releaseCIDRs releases cidrs reserved for a node, indexed like clusterCIDRs.
+func (r *rangeAllocator) releaseCIDRs(cidrs []*net.IPNet) {
+	for idx, cidr := range cidrs {
+		cidrSet, err := r.cidrSetFor(idx, cidr)
+		if err == nil {
+			err = cidrSet.Release(cidr)
+		}
+		if err != nil {
+			klog.Errorf("Error when releasing CIDR idx:%v value: %v err:%v", idx, cidr, err)
 		}
 	}
 }
@@ -359,11 +623,7 @@ func (r *rangeAllocator) updateCIDRsAllocation(data nodeReservedCIDRs) error {
 	// node has cidrs, release the reserved
 	if len(node.Spec.PodCIDRs) != 0 {
 		klog.Errorf("Node %v already has a CIDR allocated %v. Releasing the new one.", node.Name, node.Spec.PodCIDRs)
-		for idx, cidr := range data.allocatedCIDRs {
-			if releaseErr := r.cidrSets[idx].Release(cidr); releaseErr != nil {
-				klog.Errorf("Error when releasing CIDR idx:%v value: %v err:%v", idx, cidr, releaseErr)
-			}
-		}
+		r.releaseCIDRs(data.allocatedCIDRs)
 		return nil
 	}
 
@@ -371,22 +631,19 @@ func (r *rangeAllocator) updateCIDRsAllocation(data nodeReservedCIDRs) error {
 	for i := 0; i < cidrUpdateRetries; i++ {
 		if err = utilnode.PatchNodeCIDRs(r.client, types.NodeName(node.Name), cidrsString); err == nil {
 			klog.Infof("Set node %v PodCIDR to %v", node.Name, cidrsString)
+			observeCIDRAllocation(RangeAllocatorType, r.clock.Since(data.reservedAt))
 			return nil
 		}
 	}
 	// failed release back to the pool
 	klog.Errorf("Failed to update node %v PodCIDR to %v after multiple attempts: %v", node.Name, cidrsString, err)
-	nodeutil.RecordNodeStatusChange(r.recorder, node, "CIDRAssignmentFailed")
+	recordCIDRAllocationFailure(r.recorder, node, RangeAllocatorType, "CIDRAssignmentFailed")
 	// We accept the fact that we may leak CIDRs here. This is safer than releasing
 	// them in case when we don't know if request went through.
 	// NodeController restart will return all falsely allocated CIDRs to the pool.
 	if !apierrors.IsServerTimeout(err) {
 		klog.Errorf("CIDR assignment for node %v failed: %v. Releasing allocated CIDR", node.Name, err)
-		for idx, cidr := range data.allocatedCIDRs {
-			if releaseErr := r.cidrSets[idx].Release(cidr); releaseErr != nil {
-				klog.Errorf("Error releasing allocated CIDR for node %v: %v", node.Name, releaseErr)
-			}
-		}
+		r.releaseCIDRs(data.allocatedCIDRs)
 	}
 	return err
 }
@@ -0,0 +1,262 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"k8s.io/cloud-provider-gcp/pkg/controller/nodeipam/ipam/cidrset"
+)
+
+// ClusterCIDRsConfigMapKey is the key of the data of the cluster cidrs ConfigMap
+// holding additional cluster cidrs, in the format parsed by ParseClusterCIDRRanges.
+const ClusterCIDRsConfigMapKey = "clusterCIDRs"
+
+// ClusterCIDRRange is an additional cluster cidr node cidrs are allocated from by
+// the range allocator.
+type ClusterCIDRRange struct {
+	// CIDR is the cluster cidr
+	CIDR *net.IPNet
+	// NodeCIDRMaskSize is the mask size of the node cidrs allocated from CIDR. If
+	// zero, the node cidr mask size of the cluster cidr of the same IP family is used.
+	NodeCIDRMaskSize int
+}
+
+// ParseClusterCIDRRanges parses a list of cluster cidr ranges separated by commas or
+// whitespace, in priority order. Each range is a cidr, optionally followed by "=" and
+// the mask size of the node cidrs allocated from it, e.g. "10.8.0.0/14=24,10.12.0.0/16".
+func ParseClusterCIDRRanges(s string) ([]ClusterCIDRRange, error) {
+	var ranges []ClusterCIDRRange
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' }) {
+		cidrString, maskString, hasMask := strings.Cut(entry, "=")
+		_, cidr, err := net.ParseCIDR(cidrString)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster CIDR %q: %v", entry, err)
+		}
+		clusterCIDRRange := ClusterCIDRRange{CIDR: cidr}
+		if hasMask {
+			clusterCIDRRange.NodeCIDRMaskSize, err = strconv.Atoi(maskString)
+			if err != nil || clusterCIDRRange.NodeCIDRMaskSize <= 0 {
+				return nil, fmt.Errorf("invalid node CIDR mask size of cluster CIDR %q", entry)
+			}
+		}
+		ranges = append(ranges, clusterCIDRRange)
+	}
+	return ranges, nil
+}
+
+// addClusterCIDRs appends ranges to the cluster cidr ranges of their IP family. Ranges
+// that were already added are skipped, and ranges that can not be added do not prevent
+// the others from being added. It returns the number of ranges added.
+func (r *rangeAllocator) addClusterCIDRs(ranges []ClusterCIDRRange) (int, error) {
+	var errs []error
+	added := 0
+	for _, clusterRange := range ranges {
+		ok, err := r.addClusterCIDR(clusterRange)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			added++
+		}
+	}
+	return added, errors.Join(errs...)
+}
+
+// addClusterCIDR appends clusterRange to the cluster cidr ranges of its IP family. It
+// returns false if it was already added.
+func (r *rangeAllocator) addClusterCIDR(clusterRange ClusterCIDRRange) (bool, error) {
+	r.rangesLock.Lock()
+	defer r.rangesLock.Unlock()
+	return r.addCIDRRange(r.cidrRanges, clusterRange)
+}
+
+// addCIDRRange appends clusterRange to the ranges of its IP family in cidrRanges, which
+// are either the cluster cidr ranges or the ranges of a node cidr pool. It returns false
+// if it was already added to them. Callers must hold rangesLock.
+func (r *rangeAllocator) addCIDRRange(cidrRanges [][]*clusterCIDRRange, clusterRange ClusterCIDRRange) (bool, error) {
+	cidr := clusterRange.CIDR
+	idx := -1
+	for i, clusterCIDR := range r.clusterCIDRs {
+		if (clusterCIDR.IP.To4() == nil) == (cidr.IP.To4() == nil) {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return false, fmt.Errorf("cluster CIDR %v has no cluster CIDR of the same IP family", cidr)
+	}
+
+	nodeCIDRMaskSize := clusterRange.NodeCIDRMaskSize
+	if nodeCIDRMaskSize == 0 {
+		nodeCIDRMaskSize = r.nodeCIDRMaskSizes[idx]
+	}
+	clusterMaskSize, bits := cidr.Mask.Size()
+	if nodeCIDRMaskSize < clusterMaskSize || nodeCIDRMaskSize > bits {
+		return false, fmt.Errorf("node CIDR mask size %d is invalid for cluster CIDR %v", nodeCIDRMaskSize, cidr)
+	}
+
+	for _, existing := range cidrRanges[idx] {
+		if existing.cidr.String() == cidr.String() {
+			if existing.nodeCIDRMaskSize != nodeCIDRMaskSize {
+				return false, fmt.Errorf("cluster CIDR %v was already added with node CIDR mask size %d", cidr, existing.nodeCIDRMaskSize)
+			}
+			return false, nil
+		}
+	}
+	for _, existing := range r.allCIDRRanges(idx) {
+		if existing.cidr.Contains(cidr.IP) || cidr.Contains(existing.cidr.IP) {
+			return false, fmt.Errorf("cluster CIDR %v overlaps with cluster CIDR %v", cidr, existing.cidr)
+		}
+	}
+
+	cidrSet, err := cidrset.NewCIDRSet(cidr, nodeCIDRMaskSize)
+	if err != nil {
+		return false, fmt.Errorf("failed to add cluster CIDR %v: %v", cidr, err)
+	}
+	cidrRange := &clusterCIDRRange{cidr: cidr, nodeCIDRMaskSize: nodeCIDRMaskSize, cidrSet: cidrSet}
+	for _, serviceCIDR := range r.serviceCIDRs {
+		filterOutServiceRange(cidrRange, idx, serviceCIDR)
+	}
+	cidrRanges[idx] = append(cidrRanges[idx], cidrRange)
+	klog.Infof("Added cluster CIDR %v with node CIDR mask size %d", cidr, nodeCIDRMaskSize)
+	return true, nil
+}
+
+// allCIDRRanges returns the cluster cidr ranges and the ranges of all node cidr pools
+// of the IP family of clusterCIDRs[idx]. Callers must hold rangesLock.
+func (r *rangeAllocator) allCIDRRanges(idx int) []*clusterCIDRRange {
+	ranges := slices.Clone(r.cidrRanges[idx])
+	for _, pool := range r.pools {
+		ranges = append(ranges, pool.cidrRanges[idx]...)
+	}
+	return ranges
+}
+
+// loadClusterCIDRsConfigMap adds the cluster cidrs of the cluster cidrs ConfigMap, if it exists.
+func (r *rangeAllocator) loadClusterCIDRsConfigMap() error {
+	configMap, err := r.getConfigMap(r.clusterCIDRsConfigMap)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster CIDRs ConfigMap %s: %v", r.clusterCIDRsConfigMap, err)
+	}
+	if configMap == nil {
+		klog.V(2).Infof("Cluster CIDRs ConfigMap %s not found, no cluster CIDRs added from it", r.clusterCIDRsConfigMap)
+		return nil
+	}
+	r.applyClusterCIDRsConfigMap(configMap)
+	return nil
+}
+
+// getConfigMap returns the ConfigMap key, as "namespace/name", or nil if it does not exist.
+func (r *rangeAllocator) getConfigMap(key string) (*v1.ConfigMap, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, err
+	}
+	configMap, err := r.client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return configMap, err
+}
+
+// watchConfigMap calls sync with the ConfigMap key, as "namespace/name", whenever it is
+// created or updated, until stopCh is closed.
+func (r *rangeAllocator) watchConfigMap(key string, sync func(*v1.ConfigMap), stopCh <-chan struct{}) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		klog.Errorf("Invalid ConfigMap %q: %v", key, err)
+		return
+	}
+	informer := informers.NewFilteredConfigMapInformer(r.client, namespace, 0, cache.Indexers{}, func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+	})
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if configMap, ok := obj.(*v1.ConfigMap); ok {
+				sync(configMap)
+			}
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			if configMap, ok := newObj.(*v1.ConfigMap); ok {
+				sync(configMap)
+			}
+		},
+	})
+	informer.Run(stopCh)
+}
+
+// syncClusterCIDRsConfigMap applies the cluster cidrs ConfigMap and, if any cluster
+// cidr was added, allocates cidrs to the nodes that could not get any so far. Cluster
+// cidrs removed from the ConfigMap are not removed from the allocator, as nodes may
+// still use them.
+func (r *rangeAllocator) syncClusterCIDRsConfigMap(configMap *v1.ConfigMap) {
+	if r.applyClusterCIDRsConfigMap(configMap) {
+		r.allocatePendingNodes()
+	}
+}
+
+// applyClusterCIDRsConfigMap adds the cluster cidrs of configMap, recording a warning
+// event on it for those that can not be added. It returns whether any cluster cidr
+// was added.
+func (r *rangeAllocator) applyClusterCIDRsConfigMap(configMap *v1.ConfigMap) bool {
+	ranges, err := ParseClusterCIDRRanges(configMap.Data[ClusterCIDRsConfigMapKey])
+	if err != nil {
+		klog.Errorf("Failed to parse cluster CIDRs ConfigMap %s: %v", r.clusterCIDRsConfigMap, err)
+		r.recorder.Eventf(configMap, v1.EventTypeWarning, "InvalidClusterCIDRs", "Failed to parse %s: %v", ClusterCIDRsConfigMapKey, err)
+		return false
+	}
+	added, err := r.addClusterCIDRs(ranges)
+	if err != nil {
+		klog.Errorf("Failed to add cluster CIDRs of ConfigMap %s: %v", r.clusterCIDRsConfigMap, err)
+		r.recorder.Eventf(configMap, v1.EventTypeWarning, "InvalidClusterCIDRs", "Failed to add cluster CIDRs: %v", err)
+	}
+	return added > 0
+}
+
+// allocatePendingNodes allocates cidrs to the nodes without any.
+func (r *rangeAllocator) allocatePendingNodes() {
+	nodes, err := r.nodeLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Failed to list nodes: %v", err)
+		return
+	}
+	for _, node := range nodes {
+		if len(node.Spec.PodCIDRs) > 0 {
+			continue
+		}
+		if err := r.AllocateOrOccupyCIDR(node); err != nil {
+			klog.Errorf("Failed to allocate CIDRs to node %v: %v", node.Name, err)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// NodeCIDRPoolsConfigMapKey is the key of the data of the node cidr pools ConfigMap
+// holding the node cidr pools, as a YAML or JSON list of NodeCIDRPool, e.g.:
+//
+//	nodeCIDRPools: |
+//	  - name: edge
+//	    nodeSelector:
+//	      matchLabels:
+//	        cloud.google.com/gke-nodepool: edge
+//	    cidrs:
+//	    - 10.40.0.0/16
+//	    nodeCIDRMaskSizeIPv4: 26
+const NodeCIDRPoolsConfigMapKey = "nodeCIDRPools"
+
+// NodeCIDRPool is a pool of cidrs the range allocator allocates the node cidrs of the
+// nodes matching its node selector from, instead of the cluster cidrs.
+type NodeCIDRPool struct {
+	// Name identifies the pool.
+	Name string `json:"name"`
+	// NodeSelector selects the nodes of the pool. An empty selector selects all nodes.
+	NodeSelector metav1.LabelSelector `json:"nodeSelector"`
+	// CIDRs are the cidrs of the pool, in priority order. They must not overlap with the
+	// cluster cidrs or the cidrs of other pools. The node cidrs of IP families the pool
+	// has no cidrs of are allocated from the cluster cidrs.
+	CIDRs []string `json:"cidrs"`
+	// NodeCIDRMaskSizeIPv4 is the mask size of the IPv4 node cidrs allocated from the
+	// pool. If zero, the node cidr mask size of the IPv4 cluster cidr is used.
+	NodeCIDRMaskSizeIPv4 int `json:"nodeCIDRMaskSizeIPv4,omitempty"`
+	// NodeCIDRMaskSizeIPv6 is the mask size of the IPv6 node cidrs allocated from the
+	// pool. If zero, the node cidr mask size of the IPv6 cluster cidr is used.
+	NodeCIDRMaskSizeIPv6 int `json:"nodeCIDRMaskSizeIPv6,omitempty"`
+}
+
+// ParseNodeCIDRPools parses a YAML or JSON list of node cidr pools.
+func ParseNodeCIDRPools(data string) ([]NodeCIDRPool, error) {
+	var pools []NodeCIDRPool
+	if err := yaml.UnmarshalStrict([]byte(data), &pools); err != nil {
+		return nil, err
+	}
+	names := sets.NewString()
+	for _, pool := range pools {
+		if pool.Name == "" {
+			return nil, fmt.Errorf("node CIDR pool without a name")
+		}
+		if names.Has(pool.Name) {
+			return nil, fmt.Errorf("duplicate node CIDR pool %s", pool.Name)
+		}
+		names.Insert(pool.Name)
+		if _, err := metav1.LabelSelectorAsSelector(&pool.NodeSelector); err != nil {
+			return nil, fmt.Errorf("invalid node selector of node CIDR pool %s: %v", pool.Name, err)
+		}
+		if len(pool.CIDRs) == 0 {
+			return nil, fmt.Errorf("node CIDR pool %s has no CIDRs", pool.Name)
+		}
+		for _, cidr := range pool.CIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q of node CIDR pool %s: %v", cidr, pool.Name, err)
+			}
+		}
+	}
+	return pools, nil
+}
+
+// loadNodeCIDRPoolsConfigMap sets the node cidr pools of the node cidr pools ConfigMap,
+// if it exists.
+func (r *rangeAllocator) loadNodeCIDRPoolsConfigMap() error {
+	configMap, err := r.getConfigMap(r.nodeCIDRPoolsConfigMap)
+	if err != nil {
+		return fmt.Errorf("failed to get node CIDR pools ConfigMap %s: %v", r.nodeCIDRPoolsConfigMap, err)
+	}
+	if configMap == nil {
+		klog.V(2).Infof("Node CIDR pools ConfigMap %s not found, no node CIDR pools set from it", r.nodeCIDRPoolsConfigMap)
+		return nil
+	}
+	r.applyNodeCIDRPoolsConfigMap(configMap)
+	return nil
+}
+
+// syncNodeCIDRPoolsConfigMap applies the node cidr pools ConfigMap and, if any pool
+// changed, allocates cidrs to the nodes that could not get any so far.
+func (r *rangeAllocator) syncNodeCIDRPoolsConfigMap(configMap *v1.ConfigMap) {
+	if r.applyNodeCIDRPoolsConfigMap(configMap) {
+		r.allocatePendingNodes()
+	}
+}
+
+// applyNodeCIDRPoolsConfigMap sets the node cidr pools of configMap, recording a warning
+// event on it for those that can not be set. The pools are kept as they are if
+// configMap can not be parsed. It returns whether any pool changed.
+func (r *rangeAllocator) applyNodeCIDRPoolsConfigMap(configMap *v1.ConfigMap) bool {
+	pools, err := ParseNodeCIDRPools(configMap.Data[NodeCIDRPoolsConfigMapKey])
+	if err != nil {
+		klog.Errorf("Failed to parse node CIDR pools ConfigMap %s: %v", r.nodeCIDRPoolsConfigMap, err)
+		r.recorder.Eventf(configMap, v1.EventTypeWarning, "InvalidNodeCIDRPools", "Failed to parse %s: %v", NodeCIDRPoolsConfigMapKey, err)
+		return false
+	}
+	changed, err := r.setNodeCIDRPools(pools)
+	if err != nil {
+		klog.Errorf("Failed to set node CIDR pools of ConfigMap %s: %v", r.nodeCIDRPoolsConfigMap, err)
+		r.recorder.Eventf(configMap, v1.EventTypeWarning, "InvalidNodeCIDRPools", "Failed to set node CIDR pools: %v", err)
+	}
+	return changed
+}
+
+// nodeCIDRPool is a node cidr pool of the range allocator.
+type nodeCIDRPool struct {
+	name string
+	// selector selects the nodes of the pool. It is nil once the pool is removed, as
+	// its ranges are kept for the nodes that still use them.
+	selector labels.Selector
+	// cidrRanges are the ranges of the pool for each entry in the cluster cidrs, in
+	// priority order. Ranges are only ever appended.
+	cidrRanges [][]*clusterCIDRRange
+}
+
+// setNodeCIDRPools sets the node cidr pools of the allocator to pools. The cidrs of pools
+// that were set before are added to them, and pools that are not in pools no longer
+// select any node. Pools that can not be set do not prevent the others from being set.
+// It returns whether any pool changed.
+func (r *rangeAllocator) setNodeCIDRPools(pools []NodeCIDRPool) (bool, error) {
+	r.rangesLock.Lock()
+	defer r.rangesLock.Unlock()
+
+	var errs []error
+	changed := false
+	names := sets.NewString()
+	for _, p := range pools {
+		names.Insert(p.Name)
+		var pool *nodeCIDRPool
+		for _, existing := range r.pools {
+			if existing.name == p.Name {
+				pool = existing
+				break
+			}
+		}
+		if pool == nil {
+			pool = &nodeCIDRPool{name: p.Name, cidrRanges: make([][]*clusterCIDRRange, len(r.clusterCIDRs))}
+			r.pools = append(r.pools, pool)
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&p.NodeSelector)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid node selector of node CIDR pool %s: %v", p.Name, err))
+			changed = changed || pool.selector != nil
+			pool.selector = nil
+			continue
+		}
+		changed = changed || pool.selector == nil || pool.selector.String() != selector.String()
+		pool.selector = selector
+
+		for _, cidrString := range p.CIDRs {
+			_, cidr, err := net.ParseCIDR(cidrString)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid CIDR %q of node CIDR pool %s: %v", cidrString, p.Name, err))
+				continue
+			}
+			clusterRange := ClusterCIDRRange{CIDR: cidr, NodeCIDRMaskSize: p.NodeCIDRMaskSizeIPv4}
+			if cidr.IP.To4() == nil {
+				clusterRange.NodeCIDRMaskSize = p.NodeCIDRMaskSizeIPv6
+			}
+			added, err := r.addCIDRRange(pool.cidrRanges, clusterRange)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("node CIDR pool %s: %v", p.Name, err))
+			}
+			changed = changed || added
+		}
+	}
+	for _, pool := range r.pools {
+		if !names.Has(pool.name) && pool.selector != nil {
+			pool.selector = nil
+			changed = true
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// nodeCIDRPoolFor returns the node cidr pool that selects node, or nil if there is none.
+// It records a warning event on nodes that are selected by none of the pools, which get
+// cidrs from the cluster cidrs, and fails for nodes that are selected by several pools.
+func (r *rangeAllocator) nodeCIDRPoolFor(node *v1.Node) (*nodeCIDRPool, error) {
+	matching, hasPools := r.nodeCIDRPoolsSelecting(node)
+	switch {
+	case len(matching) > 1:
+		names := make([]string, 0, len(matching))
+		for _, pool := range matching {
+			names = append(names, pool.name)
+		}
+		r.recordNodeCIDRPoolEvent(node, "NodeCIDRPoolConflict", fmt.Sprintf("Node is selected by node CIDR pools %s, no CIDRs are allocated until it is selected by at most one", strings.Join(names, ", ")))
+		return nil, fmt.Errorf("node %s is selected by several node CIDR pools: %s", node.Name, strings.Join(names, ", "))
+	case len(matching) == 1:
+		r.forgetNodeCIDRPoolEvent(node.Name)
+		return matching[0], nil
+	case hasPools:
+		r.recordNodeCIDRPoolEvent(node, "NodeCIDRPoolNotMatched", "Node is selected by no node CIDR pool, CIDRs are allocated from the cluster CIDRs")
+	default:
+		r.forgetNodeCIDRPoolEvent(node.Name)
+	}
+	return nil, nil
+}
+
+// recordNodeCIDRPoolEvent records a warning event on node, unless the last node cidr
+// pool event recorded on it has the same reason and message. Allocations are retried
+// on every update of the node, the event is only recorded again once the node or the
+// pools change.
+func (r *rangeAllocator) recordNodeCIDRPoolEvent(node *v1.Node, reason, message string) {
+	r.poolEventsLock.Lock()
+	defer r.poolEventsLock.Unlock()
+	if r.poolEvents[node.Name] == reason+": "+message {
+		return
+	}
+	r.poolEvents[node.Name] = reason + ": " + message
+	r.recorder.Event(node, v1.EventTypeWarning, reason, message)
+}
+
+// forgetNodeCIDRPoolEvent forgets the last node cidr pool event recorded on node.
+func (r *rangeAllocator) forgetNodeCIDRPoolEvent(nodeName string) {
+	r.poolEventsLock.Lock()
+	defer r.poolEventsLock.Unlock()
+	delete(r.poolEvents, nodeName)
+}
+
+// nodeCIDRPoolsSelecting returns the node cidr pools that select node, and whether
+// there are any pools selecting nodes.
+func (r *rangeAllocator) nodeCIDRPoolsSelecting(node *v1.Node) ([]*nodeCIDRPool, bool) {
+	r.rangesLock.RLock()
+	defer r.rangesLock.RUnlock()
+	var matching []*nodeCIDRPool
+	hasPools := false
+	for _, pool := range r.pools {
+		if pool.selector == nil {
+			continue
+		}
+		hasPools = true
+		if pool.selector.Matches(labels.Set(node.Labels)) {
+			matching = append(matching, pool)
+		}
+	}
+	return matching, hasPools
+}
@@ -0,0 +1,465 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// AllocatorStateConfigMapKey is the key of the data of the allocator state ConfigMap
+// holding the checkpoint of the range allocator state.
+const AllocatorStateConfigMapKey = "rangeAllocatorState"
+
+// allocatorState is the checkpoint of the state of the range allocator.
+type allocatorState struct {
+	// Ranges are the used node cidrs of the cluster cidr and node cidr pool ranges.
+	Ranges []allocatorStateRange `json:"ranges"`
+	// Quarantined are the released node cidrs that are not allocated again yet.
+	Quarantined []allocatorStateQuarantinedCIDR `json:"quarantined,omitempty"`
+	// Reserved are the node cidrs kept out of the allocations, which are allocated
+	// while the state is restored.
+	Reserved []string `json:"reserved,omitempty"`
+}
+
+// allocatorStateRange is the checkpoint of a cluster cidr or node cidr pool range.
+type allocatorStateRange struct {
+	CIDR             string `json:"cidr"`
+	NodeCIDRMaskSize int    `json:"nodeCIDRMaskSize"`
+	// Used is the bitmap of the used node cidrs of the range, see cidrset.CidrSet.Snapshot.
+	Used []byte `json:"used"`
+}
+
+// allocatorStateQuarantinedCIDR is the checkpoint of a quarantined node cidr.
+type allocatorStateQuarantinedCIDR struct {
+	CIDR       string      `json:"cidr"`
+	ReleasedAt metav1.Time `json:"releasedAt"`
+}
+
+// quarantinedCIDR is a released node cidr that is not allocated again until its
+// quarantine is over, as it may still be routed to the deleted node.
+type quarantinedCIDR struct {
+	// idx is the index of the cluster cidr of the IP family of cidr
+	idx        int
+	cidr       *net.IPNet
+	releasedAt time.Time
+}
+
+// loadAllocatorState restores the allocator state from the allocator state ConfigMap,
+// if it has any. A state that can not be read is ignored, as the state is rebuilt from
+// the nodes anyway.
+func (r *rangeAllocator) loadAllocatorState() error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(r.allocatorStateConfigMap)
+	if err != nil {
+		return err
+	}
+	configMap, err := r.client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		klog.Infof("Allocator state ConfigMap %s not found, the allocator state is rebuilt from the nodes", r.allocatorStateConfigMap)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get allocator state ConfigMap %s: %v", r.allocatorStateConfigMap, err)
+	}
+	data, ok := configMap.Data[AllocatorStateConfigMapKey]
+	if !ok {
+		return nil
+	}
+	var state allocatorState
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		klog.Errorf("Failed to parse allocator state ConfigMap %s, the allocator state is rebuilt from the nodes: %v", r.allocatorStateConfigMap, err)
+		r.recorder.Eventf(configMap, v1.EventTypeWarning, "InvalidAllocatorState", "Failed to parse %s: %v", AllocatorStateConfigMapKey, err)
+		return nil
+	}
+	r.restoreAllocatorState(&state)
+	r.checkpointLock.Lock()
+	r.lastCheckpoint = data
+	r.checkpointLock.Unlock()
+	return nil
+}
+
+// restoreAllocatorState marks the node cidrs used in state as used, and quarantines and
+// reserves the node cidrs quarantined and reserved in it. Ranges that are no longer known
+// are skipped.
+func (r *rangeAllocator) restoreAllocatorState(state *allocatorState) {
+	for _, stateRange := range state.Ranges {
+		cidrRange := r.cidrRangeFor(stateRange.CIDR)
+		if cidrRange == nil || cidrRange.nodeCIDRMaskSize != stateRange.NodeCIDRMaskSize {
+			klog.Warningf("Skipping allocator state of cluster CIDR %s with node CIDR mask size %d, which is not configured", stateRange.CIDR, stateRange.NodeCIDRMaskSize)
+			continue
+		}
+		if err := cidrRange.cidrSet.Restore(stateRange.Used); err != nil {
+			klog.Errorf("Failed to restore allocator state of cluster CIDR %s: %v", stateRange.CIDR, err)
+		}
+	}
+	for _, quarantined := range state.Quarantined {
+		_, cidr, err := net.ParseCIDR(quarantined.CIDR)
+		if err != nil {
+			klog.Errorf("Skipping invalid quarantined CIDR %q: %v", quarantined.CIDR, err)
+			continue
+		}
+		idx := r.clusterCIDRIndexFor(cidr)
+		if idx == -1 {
+			klog.Warningf("Skipping quarantined CIDR %v, which has no cluster CIDR of its IP family", cidr)
+			continue
+		}
+		r.quarantineCIDR(idx, cidr, quarantined.ReleasedAt.Time)
+	}
+	r.checkpointLock.Lock()
+	defer r.checkpointLock.Unlock()
+	for _, reserved := range state.Reserved {
+		_, cidr, err := net.ParseCIDR(reserved)
+		if err != nil {
+			klog.Errorf("Skipping invalid reserved CIDR %q: %v", reserved, err)
+			continue
+		}
+		idx := r.clusterCIDRIndexFor(cidr)
+		if idx == -1 {
+			klog.Warningf("Skipping reserved CIDR %v, which has no cluster CIDR of its IP family", cidr)
+			continue
+		}
+		cidrSet, err := r.cidrSetFor(idx, cidr)
+		if err == nil {
+			err = cidrSet.Occupy(cidr)
+		}
+		if err != nil {
+			klog.Warningf("Skipping reserved CIDR %v: %v", cidr, err)
+			continue
+		}
+		r.reserved[idx] = append(r.reserved[idx], cidr)
+	}
+	klog.Infof("Restored allocator state from ConfigMap %s", r.allocatorStateConfigMap)
+}
+
+// cidrRangeFor returns the cluster cidr or node cidr pool range of cidr, or nil.
+func (r *rangeAllocator) cidrRangeFor(cidr string) *clusterCIDRRange {
+	r.rangesLock.RLock()
+	defer r.rangesLock.RUnlock()
+	for idx := range r.clusterCIDRs {
+		for _, cidrRange := range r.allCIDRRanges(idx) {
+			if cidrRange.cidr.String() == cidr {
+				return cidrRange
+			}
+		}
+	}
+	return nil
+}
+
+// clusterCIDRIndexFor returns the index of the cluster cidr of the IP family of cidr,
+// or -1.
+func (r *rangeAllocator) clusterCIDRIndexFor(cidr *net.IPNet) int {
+	for idx, clusterCIDR := range r.clusterCIDRs {
+		if (clusterCIDR.IP.To4() == nil) == (cidr.IP.To4() == nil) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// validateAllocatorState validates the allocator state restored on startup against the
+// synced nodes, then allocates cidrs to the nodes without any. The cidrs of the nodes
+// are marked as used, and the cidrs used in the restored state that no node, service
+// cidr, quarantine or reserved cidr accounts for, such as those of nodes deleted while
+// the allocator was down, are quarantined. Nothing is changed if the nodes can not be
+// listed, as the cidrs of all nodes would be quarantined otherwise.
+func (r *rangeAllocator) validateAllocatorState() error {
+	nodes, err := r.nodeLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list nodes to validate the allocator state: %v", err)
+	}
+	nodeCIDRs := sets.NewString()
+	for _, node := range nodes {
+		for idx, cidr := range node.Spec.PodCIDRs {
+			_, podCIDR, err := net.ParseCIDR(cidr)
+			if err != nil || idx >= len(r.clusterCIDRs) {
+				klog.Errorf("Node %v has an invalid CIDR %s at index %v", node.Name, cidr, idx)
+				continue
+			}
+			cidrSet, err := r.cidrSetFor(idx, podCIDR)
+			if err == nil {
+				err = cidrSet.Occupy(podCIDR)
+			}
+			if err != nil {
+				klog.Errorf("Failed to mark CIDR %v of node %v as occupied: %v", podCIDR, node.Name, err)
+				continue
+			}
+			nodeCIDRs.Insert(podCIDR.String())
+		}
+	}
+
+	// checkpointLock is held until the state is validated, so that no reserved cidrs
+	// are allocated meanwhile.
+	r.checkpointLock.Lock()
+	// The reserved cidrs allocated while restoring may not be set on their nodes yet.
+	reservedCIDRs := sets.NewString(r.allocatedReserved.UnsortedList()...)
+	for idx, reserved := range r.reserved {
+		// A reserved cidr used by a node is no longer kept out of the allocations.
+		r.reserved[idx] = slices.DeleteFunc(reserved, func(cidr *net.IPNet) bool { return nodeCIDRs.Has(cidr.String()) })
+		for _, cidr := range r.reserved[idx] {
+			reservedCIDRs.Insert(cidr.String())
+		}
+	}
+
+	r.rangesLock.RLock()
+	var leaked []*quarantinedCIDR
+	for idx := range r.clusterCIDRs {
+		for _, cidrRange := range r.allCIDRRanges(idx) {
+			for _, cidr := range cidrRange.cidrSet.UsedCIDRs() {
+				if !nodeCIDRs.Has(cidr.String()) && !reservedCIDRs.Has(cidr.String()) && !r.isQuarantined(cidr) && !r.overlapsServiceCIDR(cidr) {
+					leaked = append(leaked, &quarantinedCIDR{idx: idx, cidr: cidr})
+				}
+			}
+		}
+	}
+	r.rangesLock.RUnlock()
+	now := r.clock.Now()
+	for _, cidr := range leaked {
+		klog.Infof("CIDR %v is used in the allocator state but by no node, quarantining it", cidr.cidr)
+		r.quarantineCIDR(cidr.idx, cidr.cidr, now)
+	}
+
+	r.restoring.Store(false)
+	r.checkpointLock.Unlock()
+	klog.Infof("Validated allocator state against %d nodes", len(nodes))
+	r.releaseQuarantinedCIDRs()
+	r.allocatePendingNodes()
+	return nil
+}
+
+// overlapsServiceCIDR returns whether cidr overlaps with a service cidr.
+func (r *rangeAllocator) overlapsServiceCIDR(cidr *net.IPNet) bool {
+	for _, serviceCIDR := range r.serviceCIDRs {
+		if serviceCIDR.Contains(cidr.IP) || cidr.Contains(serviceCIDR.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// quarantineCIDR quarantines cidr, of the IP family of clusterCIDRs[idx], released at
+// releasedAt. It stays used in its cidrSet until releaseQuarantinedCIDRs releases it.
+func (r *rangeAllocator) quarantineCIDR(idx int, cidr *net.IPNet, releasedAt time.Time) {
+	cidrSet, err := r.cidrSetFor(idx, cidr)
+	if err == nil {
+		err = cidrSet.Occupy(cidr)
+	}
+	if err != nil {
+		klog.Errorf("Failed to quarantine CIDR %v: %v", cidr, err)
+		return
+	}
+	r.quarantineLock.Lock()
+	defer r.quarantineLock.Unlock()
+	if _, ok := r.quarantined[cidr.String()]; !ok {
+		r.quarantined[cidr.String()] = &quarantinedCIDR{idx: idx, cidr: cidr, releasedAt: releasedAt}
+	}
+}
+
+// isQuarantined returns whether cidr is quarantined.
+func (r *rangeAllocator) isQuarantined(cidr *net.IPNet) bool {
+	r.quarantineLock.Lock()
+	defer r.quarantineLock.Unlock()
+	_, ok := r.quarantined[cidr.String()]
+	return ok
+}
+
+// releaseQuarantinedCIDRs releases the quarantined cidrs whose quarantine is over. It
+// returns the number of cidrs released.
+func (r *rangeAllocator) releaseQuarantinedCIDRs() int {
+	r.quarantineLock.Lock()
+	var expired []*quarantinedCIDR
+	for key, quarantined := range r.quarantined {
+		if r.clock.Since(quarantined.releasedAt) >= r.cidrReleaseQuarantine {
+			expired = append(expired, quarantined)
+			delete(r.quarantined, key)
+		}
+	}
+	r.quarantineLock.Unlock()
+
+	for _, quarantined := range expired {
+		cidrSet, err := r.cidrSetFor(quarantined.idx, quarantined.cidr)
+		if err == nil {
+			err = cidrSet.Release(quarantined.cidr)
+		}
+		if err != nil {
+			klog.Errorf("Failed to release quarantined CIDR %v: %v", quarantined.cidr, err)
+			continue
+		}
+		klog.V(2).Infof("Released CIDR %v after its quarantine", quarantined.cidr)
+		r.observeUtilization(quarantined.idx, quarantined.cidr, nil)
+	}
+	return len(expired)
+}
+
+// syncQuarantinedCIDRs releases the quarantined cidrs whose quarantine is over and, if
+// any, allocates cidrs to the nodes that could not get any so far.
+func (r *rangeAllocator) syncQuarantinedCIDRs() {
+	if r.releaseQuarantinedCIDRs() > 0 {
+		r.allocatePendingNodes()
+	}
+}
+
+// allocatorState returns the checkpoint of the allocator state. checkpointLock must be
+// held.
+func (r *rangeAllocator) allocatorState() *allocatorState {
+	state := &allocatorState{}
+	r.rangesLock.RLock()
+	for idx := range r.clusterCIDRs {
+		for _, cidrRange := range r.allCIDRRanges(idx) {
+			state.Ranges = append(state.Ranges, allocatorStateRange{
+				CIDR:             cidrRange.cidr.String(),
+				NodeCIDRMaskSize: cidrRange.nodeCIDRMaskSize,
+				Used:             cidrRange.cidrSet.Snapshot(),
+			})
+		}
+	}
+	r.rangesLock.RUnlock()
+
+	r.quarantineLock.Lock()
+	for key, quarantined := range r.quarantined {
+		state.Quarantined = append(state.Quarantined, allocatorStateQuarantinedCIDR{CIDR: key, ReleasedAt: metav1.NewTime(quarantined.releasedAt)})
+	}
+	r.quarantineLock.Unlock()
+	sort.Slice(state.Quarantined, func(i, j int) bool { return state.Quarantined[i].CIDR < state.Quarantined[j].CIDR })
+	state.Reserved = r.reservedCIDRs()
+	return state
+}
+
+// reservedCIDRs returns the reserved cidrs of all IP families. checkpointLock must be
+// held.
+func (r *rangeAllocator) reservedCIDRs() []string {
+	var reserved []string
+	for _, cidrs := range r.reserved {
+		reserved = append(reserved, cidrsAsString(cidrs)...)
+	}
+	return reserved
+}
+
+// checkpointAllocatorState tops up the reserved cidrs, then writes the allocator state
+// to the allocator state ConfigMap, if it changed since it was last written. The state
+// is not written until the state restored on startup is validated.
+func (r *rangeAllocator) checkpointAllocatorState() {
+	if r.restoring.Load() {
+		return
+	}
+	r.checkpointLock.Lock()
+	defer r.checkpointLock.Unlock()
+	for idx := range r.clusterCIDRs {
+		for len(r.reserved[idx]) < allocatorStateReserveSize {
+			cidr, err := r.allocateNext(idx, nil)
+			if err != nil {
+				break
+			}
+			r.reserved[idx] = append(r.reserved[idx], cidr)
+		}
+	}
+	data, err := json.Marshal(r.allocatorState())
+	if err != nil {
+		klog.Errorf("Failed to marshal allocator state: %v", err)
+		return
+	}
+	if string(data) == r.lastCheckpoint {
+		return
+	}
+	if err := r.writeAllocatorState(string(data)); err != nil {
+		klog.Errorf("Failed to checkpoint allocator state to ConfigMap %s: %v", r.allocatorStateConfigMap, err)
+		return
+	}
+	r.lastCheckpoint = string(data)
+}
+
+// allocateReserved takes a reserved cidr of each IP family while the restored state is
+// not validated yet. It returns nil if there is none left or the state is validated. The reserved cidrs left are
+// written to the restored checkpoint before the cidrs are returned, so that they are
+// not allocated twice if the allocator restarts before it writes a checkpoint.
+func (r *rangeAllocator) allocateReserved() ([]*net.IPNet, error) {
+	r.checkpointLock.Lock()
+	defer r.checkpointLock.Unlock()
+	if !r.restoring.Load() {
+		return nil, nil
+	}
+	cidrs := make([]*net.IPNet, len(r.reserved))
+	for idx, reserved := range r.reserved {
+		if len(reserved) == 0 {
+			return nil, nil
+		}
+		cidrs[idx] = reserved[0]
+	}
+	var state allocatorState
+	if err := json.Unmarshal([]byte(r.lastCheckpoint), &state); err != nil {
+		return nil, fmt.Errorf("failed to parse the restored allocator state: %v", err)
+	}
+	for idx := range r.reserved {
+		r.reserved[idx] = r.reserved[idx][1:]
+	}
+	state.Reserved = r.reservedCIDRs()
+	data, err := json.Marshal(&state)
+	if err == nil {
+		err = r.writeAllocatorState(string(data))
+	}
+	if err != nil {
+		// The cidrs stay used but are no longer reserved, as the ConfigMap may have
+		// been updated anyway. validateAllocatorState quarantines them.
+		return nil, fmt.Errorf("failed to checkpoint the reserved CIDRs to ConfigMap %s: %v", r.allocatorStateConfigMap, err)
+	}
+	r.lastCheckpoint = string(data)
+	for _, cidr := range cidrs {
+		r.allocatedReserved.Insert(cidr.String())
+	}
+	return cidrs, nil
+}
+
+// writeAllocatorState writes data to the allocator state ConfigMap, creating it if it
+// does not exist.
+func (r *rangeAllocator) writeAllocatorState(data string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(r.allocatorStateConfigMap)
+	if err != nil {
+		return err
+	}
+	configMaps := r.client.CoreV1().ConfigMaps(namespace)
+	configMap, err := configMaps.Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		configMap = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Data:       map[string]string{AllocatorStateConfigMapKey: data},
+		}
+		_, err = configMaps.Create(context.TODO(), configMap, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	configMap = configMap.DeepCopy()
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[AllocatorStateConfigMapKey] = data
+	_, err = configMaps.Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	return err
+}
@@ -18,17 +18,25 @@ package ipam
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
+	"reflect"
 	"testing"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/cloud-provider-gcp/pkg/controller/testutil"
+	metricsutil "k8s.io/component-base/metrics/testutil"
+	testingclock "k8s.io/utils/clock/testing"
 )
 
 const testNodePollInterval = 10 * time.Millisecond
@@ -560,7 +568,7 @@ func TestAllocateOrOccupyCIDRSuccess(t *testing.T) {
 				if err != nil {
 					t.Fatalf("%v: unexpected error when parsing CIDR %v: %v", tc.description, allocated, err)
 				}
-				if err = rangeAllocator.cidrSets[idx].Occupy(cidr); err != nil {
+				if err = rangeAllocator.cidrRanges[idx][0].cidrSet.Occupy(cidr); err != nil {
 					t.Fatalf("%v: unexpected error when occupying CIDR %v: %v", tc.description, allocated, err)
 				}
 			}
@@ -657,7 +665,7 @@ func TestAllocateOrOccupyCIDRFailure(t *testing.T) {
 				if err != nil {
 					t.Fatalf("%v: unexpected error when parsing CIDR %v: %v", tc.description, cidr, err)
 				}
-				err = rangeAllocator.cidrSets[setIdx].Occupy(cidr)
+				err = rangeAllocator.cidrRanges[setIdx][0].cidrSet.Occupy(cidr)
 				if err != nil {
 					t.Fatalf("%v: unexpected error when occupying CIDR %v: %v", tc.description, cidr, err)
 				}
@@ -799,7 +807,7 @@ func TestReleaseCIDRSuccess(t *testing.T) {
 				if err != nil {
 					t.Fatalf("%v: unexpected error when parsing CIDR %v: %v", tc.description, allocated, err)
 				}
-				err = rangeAllocator.cidrSets[setIdx].Occupy(cidr)
+				err = rangeAllocator.cidrRanges[setIdx][0].cidrSet.Occupy(cidr)
 				if err != nil {
 					t.Fatalf("%v: unexpected error when occupying CIDR %v: %v", tc.description, allocated, err)
 				}
@@ -865,3 +873,589 @@ func TestReleaseCIDRSuccess(t *testing.T) {
 		testFunc(tc)
 	}
 }
+
+func TestParseClusterCIDRRanges(t *testing.T) {
+	testCases := []struct {
+		description string
+		ranges      string
+		expected    []string
+		expectErr   bool
+	}{
+		{
+			description: "empty",
+			ranges:      "",
+		},
+		{
+			description: "ranges with and without node mask sizes",
+			ranges:      "10.20.0.0/16=26, 10.30.0.0/16\nfd00:20::/48=64",
+			expected:    []string{"10.20.0.0/16=26", "10.30.0.0/16=0", "fd00:20::/48=64"},
+		},
+		{
+			description: "invalid cidr",
+			ranges:      "10.20.0.0",
+			expectErr:   true,
+		},
+		{
+			description: "invalid node mask size",
+			ranges:      "10.20.0.0/16=x",
+			expectErr:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ranges, err := ParseClusterCIDRRanges(tc.ranges)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("ParseClusterCIDRRanges(%q) error = %v, expectErr %v", tc.ranges, err, tc.expectErr)
+			}
+			var got []string
+			for _, r := range ranges {
+				got = append(got, fmt.Sprintf("%v=%d", r.CIDR, r.NodeCIDRMaskSize))
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("ParseClusterCIDRRanges(%q) = %v, expected %v", tc.ranges, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAllocateFromAdditionalClusterCIDRs(t *testing.T) {
+	mustParseCIDR := func(s string) *net.IPNet {
+		_, cidr, err := net.ParseCIDR(s)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing CIDR %v: %v", s, err)
+		}
+		return cidr
+	}
+	node := func(name string, podCIDRs ...string) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       v1.NodeSpec{PodCIDRs: podCIDRs},
+		}
+	}
+	allocatorParams := CIDRAllocatorParams{
+		ClusterCIDRs:      []*net.IPNet{mustParseCIDR("10.10.0.0/23")},
+		NodeCIDRMaskSizes: []int{24},
+		AdditionalClusterCIDRs: []ClusterCIDRRange{
+			{CIDR: mustParseCIDR("10.20.0.0/24"), NodeCIDRMaskSize: 25},
+		},
+		ClusterCIDRsConfigMap: "kube-system/cluster-cidrs",
+	}
+	// The cidrs of existing nodes are occupied from the range that contains them.
+	fakeNodeHandler := &testutil.FakeNodeHandler{
+		Existing: []*v1.Node{
+			node("node0", "10.10.0.0/24"),
+			node("node1", "10.20.0.0/25"),
+			node("node2", "10.30.0.0/24"),
+		},
+		Clientset: fake.NewSimpleClientset(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "cluster-cidrs"},
+			// The overlapping range is skipped without preventing the others from being added.
+			Data: map[string]string{ClusterCIDRsConfigMapKey: "10.20.0.0/16,10.30.0.0/23"},
+		}),
+	}
+	nodeList, _ := fakeNodeHandler.List(context.TODO(), metav1.ListOptions{})
+	allocator, err := NewCIDRRangeAllocator(fakeNodeHandler, getFakeNodeInformer(fakeNodeHandler), allocatorParams, nodeList)
+	if err != nil {
+		t.Fatalf("failed to create CIDRRangeAllocator with error %v", err)
+	}
+	rangeAllocator := allocator.(*rangeAllocator)
+
+	// Ranges are filled in priority order.
+	for _, expected := range []string{"10.10.1.0/24", "10.20.0.128/25", "10.30.1.0/24"} {
+		podCIDR, err := rangeAllocator.allocateNext(0, nil)
+		if err != nil {
+			t.Fatalf("unexpected error allocating CIDR %v: %v", expected, err)
+		}
+		if podCIDR.String() != expected {
+			t.Errorf("expected to allocate CIDR %v, got %v", expected, podCIDR)
+		}
+	}
+	if podCIDR, err := rangeAllocator.allocateNext(0, nil); err == nil {
+		t.Errorf("expected all cluster CIDRs to be exhausted, got CIDR %v", podCIDR)
+	}
+
+	// Released cidrs are allocated again, starting with the range of the highest priority.
+	if err := allocator.ReleaseCIDR(fakeNodeHandler.Existing[2]); err != nil {
+		t.Fatalf("unexpected error releasing CIDR: %v", err)
+	}
+	if err := allocator.ReleaseCIDR(fakeNodeHandler.Existing[1]); err != nil {
+		t.Fatalf("unexpected error releasing CIDR: %v", err)
+	}
+	podCIDR, err := rangeAllocator.allocateNext(0, nil)
+	if err != nil || podCIDR.String() != "10.20.0.0/25" {
+		t.Errorf("expected to allocate CIDR 10.20.0.0/25, got %v, %v", podCIDR, err)
+	}
+
+	// Overlapping additional cluster cidrs given at creation are an error.
+	allocatorParams.AdditionalClusterCIDRs = append(allocatorParams.AdditionalClusterCIDRs, ClusterCIDRRange{CIDR: mustParseCIDR("10.10.0.0/16")})
+	if _, err := NewCIDRRangeAllocator(fakeNodeHandler, getFakeNodeInformer(fakeNodeHandler), allocatorParams, nodeList); err == nil {
+		t.Errorf("expected creating a CIDRRangeAllocator with overlapping cluster CIDRs to fail")
+	}
+}
+
+func TestUtilizationAlertsAfterRelease(t *testing.T) {
+	_, clusterCIDR, _ := net.ParseCIDR("10.10.0.0/22")
+	fakeNodeHandler := &testutil.FakeNodeHandler{
+		Existing:  []*v1.Node{testutil.NewNode("node0"), testutil.NewNode("node1"), testutil.NewNode("node2")},
+		Clientset: fake.NewSimpleClientset(),
+	}
+	allocatorParams := CIDRAllocatorParams{
+		ClusterCIDRs:      []*net.IPNet{clusterCIDR},
+		NodeCIDRMaskSizes: []int{24},
+	}
+	allocator, err := NewCIDRRangeAllocator(fakeNodeHandler, getFakeNodeInformer(fakeNodeHandler), allocatorParams, nil)
+	if err != nil {
+		t.Fatalf("failed to create CIDRRangeAllocator with error %v", err)
+	}
+	rangeAllocator := allocator.(*rangeAllocator)
+	recorder := testutil.NewFakeRecorder()
+	rangeAllocator.utilizationAlerter = newCIDRUtilizationAlerter([]float64{75}, recorder)
+
+	clusterAlerts := func() int {
+		alerts := 0
+		for _, event := range recorder.Events {
+			if event.Reason == CIDRUtilizationHighReason && event.InvolvedObject.Kind == "Namespace" {
+				alerts++
+			}
+		}
+		return alerts
+	}
+	allocate := func(node *v1.Node) {
+		if err := allocator.AllocateOrOccupyCIDR(node); err != nil {
+			t.Fatalf("unexpected error in AllocateOrOccupyCIDR of node %v: %v", node.Name, err)
+		}
+		allocated := <-rangeAllocator.nodeCIDRUpdateChannel
+		rangeAllocator.removeNodeFromProcessing(node.Name)
+		node.Spec.PodCIDRs = []string{allocated.allocatedCIDRs[0].String()}
+	}
+
+	for _, node := range fakeNodeHandler.Existing {
+		allocate(node)
+	}
+	if alerts := clusterAlerts(); alerts != 1 {
+		t.Fatalf("expected a single alert once 3 of 4 node CIDRs are allocated, got %v", alerts)
+	}
+	// Releasing a node cidr drops the utilization below the threshold, which alerts again
+	// once it is crossed again.
+	if err := allocator.ReleaseCIDR(fakeNodeHandler.Existing[2]); err != nil {
+		t.Fatalf("unexpected error releasing CIDR: %v", err)
+	}
+	allocate(testutil.NewNode("node3"))
+	if alerts := clusterAlerts(); alerts != 2 {
+		t.Errorf("expected an alert once the threshold is crossed again after a release, got %v alerts", alerts)
+	}
+}
+
+func TestClusterCIDRsConfigMapAddedAtRuntime(t *testing.T) {
+	_, clusterCIDR, _ := net.ParseCIDR("10.10.0.0/24")
+	fakeNodeHandler := &testutil.FakeNodeHandler{
+		Existing: []*v1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node0"},
+				Spec:       v1.NodeSpec{PodCIDRs: []string{"10.10.0.0/24"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			},
+		},
+		Clientset: fake.NewSimpleClientset(),
+	}
+	allocatorParams := CIDRAllocatorParams{
+		ClusterCIDRs:          []*net.IPNet{clusterCIDR},
+		NodeCIDRMaskSizes:     []int{24},
+		ClusterCIDRsConfigMap: "kube-system/cluster-cidrs",
+	}
+	nodeList, _ := fakeNodeHandler.List(context.TODO(), metav1.ListOptions{})
+	allocator, err := NewCIDRRangeAllocator(fakeNodeHandler, getFakeNodeInformer(fakeNodeHandler), allocatorParams, nodeList)
+	if err != nil {
+		t.Fatalf("failed to create CIDRRangeAllocator with error %v", err)
+	}
+	rangeAllocator := allocator.(*rangeAllocator)
+	rangeAllocator.nodesSynced = alwaysReady
+	rangeAllocator.recorder = testutil.NewFakeRecorder()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go allocator.Run(stopCh)
+
+	if err := allocator.AllocateOrOccupyCIDR(fakeNodeHandler.Existing[1]); err == nil {
+		t.Fatalf("expected allocation to fail with the cluster CIDR exhausted")
+	}
+
+	// The node that could not get a cidr gets one once a cluster cidr is added.
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "cluster-cidrs"},
+		Data:       map[string]string{ClusterCIDRsConfigMapKey: "10.20.0.0/24"},
+	}
+	if _, err := fakeNodeHandler.Clientset.CoreV1().ConfigMaps("kube-system").Create(context.TODO(), configMap, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create ConfigMap: %v", err)
+	}
+	if err := waitForUpdatedNodeWithTimeout(fakeNodeHandler, 1, wait.ForeverTestTimeout); err != nil {
+		t.Fatalf("timeout while waiting for Node update: %v", err)
+	}
+	updatedNodes := fakeNodeHandler.GetUpdatedNodesCopy()
+	if updatedNodes[0].Name != "node1" || !reflect.DeepEqual(updatedNodes[0].Spec.PodCIDRs, []string{"10.20.0.0/24"}) {
+		t.Errorf("expected node1 to be allocated CIDR 10.20.0.0/24, got %v with %v", updatedNodes[0].Name, updatedNodes[0].Spec.PodCIDRs)
+	}
+}
+
+func TestParseNodeCIDRPools(t *testing.T) {
+	testCases := []struct {
+		description string
+		pools       string
+		expectErr   bool
+	}{
+		{
+			description: "empty",
+		},
+		{
+			description: "YAML pools",
+			pools:       "- name: edge\n  nodeSelector:\n    matchLabels:\n      pool: edge\n  cidrs: [10.40.0.0/16, fd00:40::/48]\n  nodeCIDRMaskSizeIPv4: 26\n",
+		},
+		{
+			description: "JSON pools",
+			pools:       `[{"name": "all", "nodeSelector": {}, "cidrs": ["10.40.0.0/16"]}]`,
+		},
+		{
+			description: "unknown field",
+			pools:       "- name: edge\n  cidrs: [10.40.0.0/16]\n  nodeCIDRMaskSize: 26\n",
+			expectErr:   true,
+		},
+		{
+			description: "no name",
+			pools:       "- cidrs: [10.40.0.0/16]\n",
+			expectErr:   true,
+		},
+		{
+			description: "duplicate name",
+			pools:       "- name: edge\n  cidrs: [10.40.0.0/16]\n- name: edge\n  cidrs: [10.41.0.0/16]\n",
+			expectErr:   true,
+		},
+		{
+			description: "invalid node selector",
+			pools:       "- name: edge\n  nodeSelector:\n    matchExpressions:\n    - {key: pool, operator: Has}\n  cidrs: [10.40.0.0/16]\n",
+			expectErr:   true,
+		},
+		{
+			description: "no cidrs",
+			pools:       "- name: edge\n",
+			expectErr:   true,
+		},
+		{
+			description: "invalid cidr",
+			pools:       "- name: edge\n  cidrs: [10.40.0.0]\n",
+			expectErr:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if _, err := ParseNodeCIDRPools(tc.pools); (err != nil) != tc.expectErr {
+				t.Errorf("ParseNodeCIDRPools() error = %v, expectErr %v", err, tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestAllocateFromNodeCIDRPools(t *testing.T) {
+	_, clusterCIDR, _ := net.ParseCIDR("10.10.0.0/16")
+	node := func(name string, nodeLabels map[string]string, podCIDRs ...string) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels},
+			Spec:       v1.NodeSpec{PodCIDRs: podCIDRs},
+		}
+	}
+	pools := `
+- name: edge
+  nodeSelector:
+    matchLabels:
+      pool: edge
+  cidrs: [10.40.0.0/24]
+  nodeCIDRMaskSizeIPv4: 26
+- name: gpu
+  nodeSelector:
+    matchLabels:
+      gpu: "true"
+  cidrs: [10.50.0.0/24]
+`
+	fakeNodeHandler := &testutil.FakeNodeHandler{
+		Existing: []*v1.Node{
+			// The cidr of an existing node is occupied from the range of its pool.
+			node("edge-0", map[string]string{"pool": "edge"}, "10.40.0.0/26"),
+			node("edge-1", map[string]string{"pool": "edge"}),
+			node("general", nil),
+			node("edge-gpu", map[string]string{"pool": "edge", "gpu": "true"}),
+		},
+		Clientset: fake.NewSimpleClientset(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "node-cidr-pools"},
+			Data:       map[string]string{NodeCIDRPoolsConfigMapKey: pools},
+		}),
+	}
+	allocatorParams := CIDRAllocatorParams{
+		ClusterCIDRs:           []*net.IPNet{clusterCIDR},
+		NodeCIDRMaskSizes:      []int{24},
+		NodeCIDRPoolsConfigMap: "kube-system/node-cidr-pools",
+	}
+	nodeList, _ := fakeNodeHandler.List(context.TODO(), metav1.ListOptions{})
+	allocator, err := NewCIDRRangeAllocator(fakeNodeHandler, getFakeNodeInformer(fakeNodeHandler), allocatorParams, nodeList)
+	if err != nil {
+		t.Fatalf("failed to create CIDRRangeAllocator with error %v", err)
+	}
+	rangeAllocator := allocator.(*rangeAllocator)
+	rangeAllocator.nodesSynced = alwaysReady
+	recorder := testutil.NewFakeRecorder()
+	rangeAllocator.recorder = recorder
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go allocator.Run(stopCh)
+
+	for _, node := range fakeNodeHandler.Existing[1:3] {
+		if err := allocator.AllocateOrOccupyCIDR(node); err != nil {
+			t.Errorf("unexpected error in AllocateOrOccupyCIDR of node %v: %v", node.Name, err)
+		}
+	}
+	// Nodes selected by several pools get no cidrs, and count as failed allocations.
+	conflicts := cidrAllocationFailures.WithLabelValues(string(RangeAllocatorType), "NodeCIDRPoolConflict")
+	before, _ := metricsutil.GetCounterMetricValue(conflicts)
+	for i := 0; i < 2; i++ {
+		if err := allocator.AllocateOrOccupyCIDR(fakeNodeHandler.Existing[3]); err == nil {
+			t.Errorf("expected allocation to fail for a node selected by several pools")
+		}
+	}
+	if after, _ := metricsutil.GetCounterMetricValue(conflicts); after != before+2 {
+		t.Errorf("expected the node CIDR pool conflicts to be counted as failed allocations, got %v failures, previously %v", after, before)
+	}
+	if err := waitForUpdatedNodeWithTimeout(fakeNodeHandler, 2, wait.ForeverTestTimeout); err != nil {
+		t.Fatalf("timeout while waiting for Node update: %v", err)
+	}
+	expected := map[string][]string{
+		"edge-1":  {"10.40.0.64/26"},
+		"general": {"10.10.0.0/24"},
+	}
+	for _, updatedNode := range fakeNodeHandler.GetUpdatedNodesCopy() {
+		if !reflect.DeepEqual(updatedNode.Spec.PodCIDRs, expected[updatedNode.Name]) {
+			t.Errorf("expected node %v to be allocated CIDRs %v, got %v", updatedNode.Name, expected[updatedNode.Name], updatedNode.Spec.PodCIDRs)
+		}
+	}
+
+	// The events are recorded once per node, however often the allocation is retried.
+	if err := allocator.AllocateOrOccupyCIDR(node("general", nil)); err != nil {
+		t.Errorf("unexpected error in AllocateOrOccupyCIDR of node general: %v", err)
+	}
+	reasons := map[string][]string{}
+	for _, event := range recorder.Events {
+		reasons[event.InvolvedObject.Name] = append(reasons[event.InvolvedObject.Name], event.Reason)
+	}
+	expectedReasons := map[string][]string{
+		"general":  {"NodeCIDRPoolNotMatched"},
+		"edge-gpu": {"NodeCIDRPoolConflict"},
+	}
+	if !reflect.DeepEqual(reasons, expectedReasons) {
+		t.Errorf("expected node events %v, got %v", expectedReasons, reasons)
+	}
+
+	// Pools that can not be parsed are kept as they are, and do not prevent cluster cidrs
+	// from being added.
+	invalid := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "node-cidr-pools"},
+		Data:       map[string]string{NodeCIDRPoolsConfigMapKey: "- name: edge\n"},
+	}
+	if rangeAllocator.applyNodeCIDRPoolsConfigMap(invalid) {
+		t.Errorf("expected invalid node CIDR pools not to change the pools")
+	}
+	if pool, err := rangeAllocator.nodeCIDRPoolFor(fakeNodeHandler.Existing[1]); err != nil || pool == nil || pool.name != "edge" {
+		t.Errorf("expected node edge-1 to stay selected by pool edge, got %v, %v", pool, err)
+	}
+	clusterCIDRs := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "cluster-cidrs"},
+		Data:       map[string]string{ClusterCIDRsConfigMapKey: "10.20.0.0/16"},
+	}
+	if !rangeAllocator.applyClusterCIDRsConfigMap(clusterCIDRs) {
+		t.Errorf("expected cluster CIDR 10.20.0.0/16 to be added")
+	}
+
+	// Pools removed from the ConfigMap no longer select nodes, but their ranges are kept
+	// for the nodes using them.
+	if _, err := rangeAllocator.setNodeCIDRPools(nil); err != nil {
+		t.Fatalf("unexpected error setting node CIDR pools: %v", err)
+	}
+	if pool, err := rangeAllocator.nodeCIDRPoolFor(fakeNodeHandler.Existing[3]); pool != nil || err != nil {
+		t.Errorf("expected no node CIDR pool once pools are removed, got %v, %v", pool, err)
+	}
+	if err := allocator.ReleaseCIDR(fakeNodeHandler.Existing[0]); err != nil {
+		t.Errorf("unexpected error releasing the CIDR of a removed pool: %v", err)
+	}
+}
+
+func TestAllocatorStateCheckpointAndQuarantine(t *testing.T) {
+	_, clusterCIDR, _ := net.ParseCIDR("10.10.0.0/22")
+	node := func(name string, podCIDRs ...string) *v1.Node {
+		return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: v1.NodeSpec{PodCIDRs: podCIDRs}}
+	}
+	clientset := fake.NewSimpleClientset()
+	allocatorParams := CIDRAllocatorParams{
+		ClusterCIDRs:            []*net.IPNet{clusterCIDR},
+		NodeCIDRMaskSizes:       []int{24},
+		AllocatorStateConfigMap: "kube-system/ipam-state",
+		CIDRReleaseQuarantine:   time.Hour,
+	}
+	releasedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newAllocator := func(nodes ...*v1.Node) *rangeAllocator {
+		t.Helper()
+		fakeNodeHandler := &testutil.FakeNodeHandler{Existing: nodes, Clientset: clientset}
+		allocator, err := NewCIDRRangeAllocator(fakeNodeHandler, getFakeNodeInformer(fakeNodeHandler), allocatorParams, nil)
+		if err != nil {
+			t.Fatalf("failed to create CIDRRangeAllocator with error %v", err)
+		}
+		rangeAllocator := allocator.(*rangeAllocator)
+		rangeAllocator.clock = testingclock.NewFakeClock(releasedAt)
+		return rangeAllocator
+	}
+
+	// Without a checkpoint, the state is rebuilt from the nodes.
+	nodes := []*v1.Node{node("node0", "10.10.0.0/24"), node("node1", "10.10.1.0/24")}
+	first := newAllocator(nodes...)
+	if err := first.validateAllocatorState(); err != nil {
+		t.Fatalf("unexpected error validating the allocator state: %v", err)
+	}
+	// The cidr of a deleted node is quarantined, and a cidr reserved for a node that
+	// is not updated yet is used.
+	if err := first.ReleaseCIDR(nodes[1]); err != nil {
+		t.Fatalf("unexpected error releasing CIDR: %v", err)
+	}
+	if podCIDR, err := first.allocateNext(0, nil); err != nil || podCIDR.String() != "10.10.2.0/24" {
+		t.Fatalf("expected to allocate CIDR 10.10.2.0/24, got %v, %v", podCIDR, err)
+	}
+	// The last free cidr is reserved.
+	first.checkpointAllocatorState()
+	if reserved := first.allocatorState().Reserved; !reflect.DeepEqual(reserved, []string{"10.10.3.0/24"}) {
+		t.Fatalf("expected reserved CIDRs [10.10.3.0/24], got %v", reserved)
+	}
+
+	// Until the restored state is validated against the nodes, the reserved cidrs are
+	// allocated once they are removed from the checkpoint, and further allocations
+	// are deferred.
+	second := newAllocator(nodes[0], node("node3"), node("node4"))
+	if err := second.AllocateOrOccupyCIDR(node("node3")); err != nil {
+		t.Fatalf("unexpected error allocating a reserved CIDR: %v", err)
+	}
+	if allocated := <-second.nodeCIDRUpdateChannel; !reflect.DeepEqual(cidrsAsString(allocated.allocatedCIDRs), []string{"10.10.3.0/24"}) {
+		t.Errorf("expected node3 to be allocated the reserved CIDR 10.10.3.0/24, got %v", allocated.allocatedCIDRs)
+	}
+	if reserved := checkpointedAllocatorState(t, clientset).Reserved; len(reserved) != 0 {
+		t.Errorf("expected no reserved CIDRs left in the checkpoint, got %v", reserved)
+	}
+	if err := second.AllocateOrOccupyCIDR(node("node4")); err != nil || second.nodesInProcessing.Has("node4") {
+		t.Errorf("expected the allocation to be deferred until the state is validated, got %v, %v", second.nodesInProcessing, err)
+	}
+	second.clock.(*testingclock.FakeClock).Step(30 * time.Minute)
+	// Nothing is quarantined if the nodes can not be listed.
+	nodeLister := second.nodeLister
+	second.nodeLister = failingNodeLister{nodeLister}
+	if err := second.validateAllocatorState(); err == nil || !second.restoring.Load() || len(second.allocatorState().Quarantined) != 1 {
+		t.Errorf("expected the validation to fail without quarantining CIDRs, got %v, %v", err, second.allocatorState().Quarantined)
+	}
+	second.nodeLister = nodeLister
+	if err := second.validateAllocatorState(); err != nil {
+		t.Fatalf("unexpected error validating the allocator state: %v", err)
+	}
+	var quarantined []string
+	for _, cidr := range second.allocatorState().Quarantined {
+		quarantined = append(quarantined, cidr.CIDR)
+	}
+	if expected := []string{"10.10.1.0/24", "10.10.2.0/24"}; !reflect.DeepEqual(quarantined, expected) {
+		t.Errorf("expected quarantined CIDRs %v, got %v", expected, quarantined)
+	}
+	if podCIDR, err := second.allocateNext(0, nil); err == nil {
+		t.Errorf("expected quarantined CIDRs not to be allocated, got %v", podCIDR)
+	}
+
+	// Quarantined cidrs are released once their quarantine is over.
+	second.clock.(*testingclock.FakeClock).Step(30 * time.Minute)
+	if released := second.releaseQuarantinedCIDRs(); released != 1 {
+		t.Errorf("expected 1 CIDR to be released, got %d", released)
+	}
+	if podCIDR, err := second.allocateNext(0, nil); err != nil || podCIDR.String() != "10.10.1.0/24" {
+		t.Errorf("expected to allocate CIDR 10.10.1.0/24, got %v, %v", podCIDR, err)
+	}
+}
+
+func TestAllocatorStateReservedCIDRsBeforeNodeSync(t *testing.T) {
+	_, clusterCIDR, _ := net.ParseCIDR("10.10.0.0/20")
+	clientset := fake.NewSimpleClientset()
+	allocatorParams := CIDRAllocatorParams{
+		ClusterCIDRs:            []*net.IPNet{clusterCIDR},
+		NodeCIDRMaskSizes:       []int{24},
+		AllocatorStateConfigMap: "kube-system/ipam-state",
+	}
+	newAllocator := func(nodes ...*v1.Node) (*rangeAllocator, *testutil.FakeNodeHandler) {
+		t.Helper()
+		fakeNodeHandler := &testutil.FakeNodeHandler{Existing: nodes, Clientset: clientset}
+		allocator, err := NewCIDRRangeAllocator(fakeNodeHandler, getFakeNodeInformer(fakeNodeHandler), allocatorParams, nil)
+		if err != nil {
+			t.Fatalf("failed to create CIDRRangeAllocator with error %v", err)
+		}
+		return allocator.(*rangeAllocator), fakeNodeHandler
+	}
+
+	existing := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node0"}, Spec: v1.NodeSpec{PodCIDRs: []string{"10.10.0.0/24"}}}
+	first, _ := newAllocator(existing)
+	if err := first.validateAllocatorState(); err != nil {
+		t.Fatalf("unexpected error validating the allocator state: %v", err)
+	}
+	first.checkpointAllocatorState()
+	if reserved := checkpointedAllocatorState(t, clientset).Reserved; len(reserved) != allocatorStateReserveSize || reserved[0] != "10.10.1.0/24" {
+		t.Fatalf("expected %d reserved CIDRs from 10.10.1.0/24, got %v", allocatorStateReserveSize, reserved)
+	}
+
+	// The nodes never sync, so the restored state is never validated.
+	added := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	second, fakeNodeHandler := newAllocator(existing, added)
+	second.nodesSynced = func() bool { return false }
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go second.Run(stopCh)
+
+	if err := second.AllocateOrOccupyCIDR(added); err != nil {
+		t.Fatalf("unexpected error in AllocateOrOccupyCIDR: %v", err)
+	}
+	if err := waitForUpdatedNodeWithTimeout(fakeNodeHandler, 1, wait.ForeverTestTimeout); err != nil {
+		t.Fatalf("timeout while waiting for Node update: %v", err)
+	}
+	if podCIDRs := fakeNodeHandler.GetUpdatedNodesCopy()[0].Spec.PodCIDRs; !reflect.DeepEqual(podCIDRs, []string{"10.10.1.0/24"}) {
+		t.Errorf("expected node1 to be allocated the reserved CIDR 10.10.1.0/24 before the nodes are synced, got %v", podCIDRs)
+	}
+	if !second.restoring.Load() {
+		t.Errorf("expected the allocator state not to be validated")
+	}
+
+	// An allocator restarted meanwhile does not allocate the reserved cidr again.
+	third, _ := newAllocator(existing)
+	third.checkpointLock.Lock()
+	defer third.checkpointLock.Unlock()
+	if reserved := third.reservedCIDRs(); len(reserved) != allocatorStateReserveSize-1 || reserved[0] != "10.10.2.0/24" {
+		t.Errorf("expected %d reserved CIDRs from 10.10.2.0/24, got %v", allocatorStateReserveSize-1, reserved)
+	}
+}
+
+// checkpointedAllocatorState returns the allocator state checkpointed to the
+// kube-system/ipam-state ConfigMap.
+func checkpointedAllocatorState(t *testing.T, clientset *fake.Clientset) *allocatorState {
+	t.Helper()
+	configMap, err := clientset.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), "ipam-state", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get the allocator state ConfigMap: %v", err)
+	}
+	var state allocatorState
+	if err := json.Unmarshal([]byte(configMap.Data[AllocatorStateConfigMapKey]), &state); err != nil {
+		t.Fatalf("failed to parse the allocator state: %v", err)
+	}
+	return &state
+}
+
+// failingNodeLister is a NodeLister failing to list nodes.
+type failingNodeLister struct {
+	corelisters.NodeLister
+}
+
+func (failingNodeLister) List(labels.Selector) ([]*v1.Node, error) {
+	return nil, errors.New("failed to list nodes")
+}
@@ -68,6 +68,9 @@ type controller interface {
 	// ResyncTimeout returns the amount of time to wait before retrying
 	// a sync with a node.
 	ResyncTimeout() time.Duration
+	// ReportAllocation updates the controller with the result of the
+	// allocation of a CIDR range to node, started at start.
+	ReportAllocation(node *v1.Node, start time.Time, err error)
 }
 
 // NodeSyncMode is the mode the cloud CIDR allocator runs in.
@@ -318,8 +321,10 @@ func (op *updateOp) allocateRange(ctx context.Context, sync *NodeSync, node *v1.
 		return fmt.Errorf("controller cannot allocate CIDRS in mode %q", sync.mode)
 	}
 
+	start := time.Now()
 	cidrRange, err := sync.set.AllocateNext()
 	if err != nil {
+		sync.c.ReportAllocation(node, start, err)
 		return err
 	}
 	// If addAlias returns a hard error, cidrRange will be leaked as there
@@ -327,13 +332,16 @@ func (op *updateOp) allocateRange(ctx context.Context, sync *NodeSync, node *v1.
 	// recovered on the next restart of the controller.
 	if err := sync.cloudAlias.AddAlias(ctx, node, cidrRange); err != nil {
 		klog.Errorf("Could not add alias %v for node %q: %v", cidrRange, node.Name, err)
+		sync.c.ReportAllocation(node, start, err)
 		return err
 	}
 
 	if err := sync.kubeAPI.UpdateNodePodCIDR(ctx, node, cidrRange); err != nil {
 		klog.Errorf("Could not update node %q PodCIDR to %v: %v", node.Name, cidrRange, err)
+		sync.c.ReportAllocation(node, start, err)
 		return err
 	}
+	sync.c.ReportAllocation(node, start, nil)
 
 	if err := sync.kubeAPI.UpdateNodeNetworkUnavailable(node.Name, false); err != nil {
 		klog.Errorf("Could not update node NetworkUnavailable status to false: %v", err)
@@ -18,6 +18,7 @@ package sync
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"reflect"
@@ -34,6 +35,8 @@ import (
 
 var (
 	_, clusterCIDRRange, _ = net.ParseCIDR("10.1.0.0/16")
+
+	errAddAlias = errors.New("add alias failed")
 )
 
 type fakeEvent struct {
@@ -53,9 +56,10 @@ type fakeAPIs struct {
 
 	updateNodeNetworkUnavailableErr error
 
-	calls   []string
-	events  []fakeEvent
-	results []error
+	calls       []string
+	events      []fakeEvent
+	results     []error
+	allocations []error
 }
 
 func (f *fakeAPIs) Alias(ctx context.Context, node *v1.Node) (*net.IPNet, error) {
@@ -95,6 +99,11 @@ func (f *fakeAPIs) ReportResult(err error) {
 	}
 }
 
+func (f *fakeAPIs) ReportAllocation(node *v1.Node, start time.Time, err error) {
+	klog.V(2).Infof("ReportAllocation %q %v", node.Name, err)
+	f.allocations = append(f.allocations, err)
+}
+
 func (f *fakeAPIs) ResyncTimeout() time.Duration {
 	if f.resyncTimeout == 0 {
 		return time.Second * 10000
@@ -126,8 +135,9 @@ func TestNodeSyncUpdate(t *testing.T) {
 		node *v1.Node
 		fake fakeAPIs
 
-		events    []fakeEvent
-		wantError bool
+		events      []fakeEvent
+		allocations []error
+		wantError   bool
 	}{
 		{
 			desc: "validate range ==",
@@ -180,9 +190,18 @@ func TestNodeSyncUpdate(t *testing.T) {
 			wantError: true,
 		},
 		{
-			desc: "allocate range",
-			mode: SyncFromCluster,
-			node: nodeWithoutCIDRRange,
+			desc:        "allocate range",
+			mode:        SyncFromCluster,
+			node:        nodeWithoutCIDRRange,
+			allocations: []error{nil},
+		},
+		{
+			desc:        "allocate range, add alias fails",
+			mode:        SyncFromCluster,
+			node:        nodeWithoutCIDRRange,
+			fake:        fakeAPIs{addAliasErr: errAddAlias},
+			allocations: []error{errAddAlias},
+			wantError:   true,
 		},
 		{
 			desc: "update with node==nil",
@@ -208,6 +227,9 @@ func TestNodeSyncUpdate(t *testing.T) {
 		if !reflect.DeepEqual(tc.fake.events, tc.events) {
 			t.Errorf("%v, %v; fake.events = %#v, want %#v", tc.desc, tc.mode, tc.fake.events, tc.events)
 		}
+		if !reflect.DeepEqual(tc.fake.allocations, tc.allocations) {
+			t.Errorf("%v, %v; fake.allocations = %v, want %v", tc.desc, tc.mode, tc.fake.allocations, tc.allocations)
+		}
 
 		var hasError bool
 		for _, r := range tc.fake.results {
@@ -39,11 +39,14 @@ func startLegacyIPAM(
 	clusterCIDRs []*net.IPNet,
 	serviceCIDR *net.IPNet,
 	nodeCIDRMaskSizes []int,
+	cidrUtilizationAlertThresholds []float64,
 ) {
 	cfg := &ipam.Config{
 		Resync:       ipamResyncInterval,
 		MaxBackoff:   ipamMaxBackoff,
 		InitialRetry: ipamInitialBackoff,
+
+		UtilizationAlertThresholds: cidrUtilizationAlertThresholds,
 	}
 	switch ic.allocatorType {
 	case ipam.IPAMFromClusterAllocatorType:
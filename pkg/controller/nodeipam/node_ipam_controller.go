@@ -77,6 +77,8 @@ func (c *Controller) Name() string {
 // This method returns an error if it is unable to initialize the CIDR bitmap with
 // podCIDRs it has already allocated to nodes. Since we don't allow podCIDR changes
 // currently, this should be handled as a fatal error.
+// allocatorParams holds the options of the CIDR allocator; its cluster cidrs,
+// service cidrs and node cidr mask sizes are set from the other parameters.
 func NewNodeIpamController(
 	nodeInformer coreinformers.NodeInformer,
 	cloud cloudprovider.Interface,
@@ -90,6 +92,7 @@ func NewNodeIpamController(
 	serviceCIDR *net.IPNet,
 	secondaryServiceCIDR *net.IPNet,
 	nodeCIDRMaskSizes []int,
+	allocatorParams ipam.CIDRAllocatorParams,
 	allocatorType ipam.CIDRAllocatorType) (*Controller, error) {
 
 	if kubeClient == nil {
@@ -131,16 +134,14 @@ func NewNodeIpamController(
 
 	// TODO: Abstract this check into a generic controller manager should run method.
 	if ic.allocatorType == ipam.IPAMFromClusterAllocatorType || ic.allocatorType == ipam.IPAMFromCloudAllocatorType {
-		startLegacyIPAM(ic, nodeInformer, cloud, kubeClient, clusterCIDRs, serviceCIDR, nodeCIDRMaskSizes)
+		startLegacyIPAM(ic, nodeInformer, cloud, kubeClient, clusterCIDRs, serviceCIDR, nodeCIDRMaskSizes, allocatorParams.UtilizationAlertThresholds)
 	} else {
 		var err error
 
-		allocatorParams := ipam.CIDRAllocatorParams{
-			ClusterCIDRs:         clusterCIDRs,
-			ServiceCIDR:          ic.serviceCIDR,
-			SecondaryServiceCIDR: ic.secondaryServiceCIDR,
-			NodeCIDRMaskSizes:    nodeCIDRMaskSizes,
-		}
+		allocatorParams.ClusterCIDRs = clusterCIDRs
+		allocatorParams.ServiceCIDR = ic.serviceCIDR
+		allocatorParams.SecondaryServiceCIDR = ic.secondaryServiceCIDR
+		allocatorParams.NodeCIDRMaskSizes = nodeCIDRMaskSizes
 
 		ic.cidrAllocator, err = ipam.New(kubeClient, cloud, nodeInformer, nwInformer, gnpInformer, nodeTopologyClient, enableMultiSubnetCluster, enableMultiNetworking, ic.allocatorType, allocatorParams)
 		if err != nil {
@@ -36,6 +36,7 @@ func startLegacyIPAM(
 	clusterCIDRs []*net.IPNet,
 	serviceCIDR *net.IPNet,
 	nodeCIDRMaskSizes []int,
+	cidrUtilizationAlertThresholds []float64,
 ) {
 	klog.Fatal("Error trying to Init(): legacy cloud provider support disabled at build time")
 }
@@ -9,6 +9,7 @@ import (
 
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 
 	networkinformer "github.com/GoogleCloudPlatform/gke-networking-api/client/network/informers/externalversions/network/v1"
 	nodetopologyclientset "github.com/GoogleCloudPlatform/gke-networking-api/client/nodetopology/clientset/versioned"
@@ -106,6 +107,26 @@ func StartNodeIpamController(
 		return nil, false, err
 	}
 
+	additionalClusterCIDRs, err := clusterCIDRRanges(nodeIPAMConfig.AdditionalClusterCIDRs)
+	if err != nil {
+		return nil, false, err
+	}
+	if nodeIPAMConfig.ClusterCIDRsConfigMap != "" {
+		if namespace, name, err := cache.SplitMetaNamespaceKey(nodeIPAMConfig.ClusterCIDRsConfigMap); err != nil || namespace == "" || name == "" {
+			return nil, false, fmt.Errorf("invalid cluster CIDRs ConfigMap %q, must be namespace/name", nodeIPAMConfig.ClusterCIDRsConfigMap)
+		}
+	}
+	if nodeIPAMConfig.NodeCIDRPoolsConfigMap != "" {
+		if namespace, name, err := cache.SplitMetaNamespaceKey(nodeIPAMConfig.NodeCIDRPoolsConfigMap); err != nil || namespace == "" || name == "" {
+			return nil, false, fmt.Errorf("invalid node CIDR pools ConfigMap %q, must be namespace/name", nodeIPAMConfig.NodeCIDRPoolsConfigMap)
+		}
+	}
+	if nodeIPAMConfig.AllocatorStateConfigMap != "" {
+		if namespace, name, err := cache.SplitMetaNamespaceKey(nodeIPAMConfig.AllocatorStateConfigMap); err != nil || namespace == "" || name == "" {
+			return nil, false, fmt.Errorf("invalid allocator state ConfigMap %q, must be namespace/name", nodeIPAMConfig.AllocatorStateConfigMap)
+		}
+	}
+
 	nodeIpamController, err := NewNodeIpamController(
 		nodeInformer,
 		cloud,
@@ -119,6 +140,17 @@ func StartNodeIpamController(
 		serviceCIDR,
 		secondaryServiceCIDR,
 		nodeCIDRMaskSizes,
+		ipam.CIDRAllocatorParams{
+			PodSecondaryRangeNames:           nodeIPAMConfig.PodSecondaryRangeNames,
+			AdditionalPodCIDRsResyncPeriod:   nodeIPAMConfig.AdditionalPodCIDRsResyncPeriod.Duration,
+			NodeNetworkConfigPodCIDRMaskSize: int(nodeIPAMConfig.NodeNetworkConfigPodCIDRMaskSize),
+			AdditionalClusterCIDRs:           additionalClusterCIDRs,
+			ClusterCIDRsConfigMap:            nodeIPAMConfig.ClusterCIDRsConfigMap,
+			NodeCIDRPoolsConfigMap:           nodeIPAMConfig.NodeCIDRPoolsConfigMap,
+			AllocatorStateConfigMap:          nodeIPAMConfig.AllocatorStateConfigMap,
+			CIDRReleaseQuarantine:            nodeIPAMConfig.CIDRReleaseQuarantine.Duration,
+			UtilizationAlertThresholds:       nodeIPAMConfig.CIDRUtilizationAlertThresholds,
+		},
 		cidrAllocatorType,
 	)
 	if err != nil {
@@ -149,6 +181,20 @@ func ProcessCIDRs(cidrsList string) ([]*net.IPNet, bool, error) {
 	return cidrs, dualstack, nil
 }
 
+// clusterCIDRRanges converts the additional cluster CIDRs of the configuration, which
+// are validated with the configuration, to the cluster cidr ranges of the allocator.
+func clusterCIDRRanges(clusterCIDRs []nodeipamconfig.ClusterCIDR) ([]ipam.ClusterCIDRRange, error) {
+	var ranges []ipam.ClusterCIDRRange
+	for _, clusterCIDR := range clusterCIDRs {
+		_, cidr, err := netutils.ParseCIDRSloppy(clusterCIDR.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid additional cluster CIDR %q: %v", clusterCIDR.CIDR, err)
+		}
+		ranges = append(ranges, ipam.ClusterCIDRRange{CIDR: cidr, NodeCIDRMaskSize: int(clusterCIDR.NodeCIDRMaskSize)})
+	}
+	return ranges, nil
+}
+
 // setNodeCIDRMaskSizes returns the IPv4 and IPv6 node cidr mask sizes to the value provided
 // for --node-cidr-mask-size-ipv4 and --node-cidr-mask-size-ipv6 respectively. If value not provided,
 // then it will return default IPv4 and IPv6 cidr mask sizes.
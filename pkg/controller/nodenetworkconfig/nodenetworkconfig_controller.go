@@ -0,0 +1,803 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodenetworkconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	networkv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/network/v1"
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	nncclientset "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned"
+	nncinformers "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/informers/externalversions"
+	nncinformer "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/informers/externalversions/nodenetworkconfig/v1"
+	nnclisters "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/listers/nodenetworkconfig/v1"
+	"google.golang.org/api/googleapi"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/cloud-provider-gcp/pkg/controller/nodeipam/ipam/cidrset"
+	"k8s.io/cloud-provider-gcp/pkg/controllermetrics"
+	utilnode "k8s.io/cloud-provider-gcp/pkg/util/node"
+	"k8s.io/cloud-provider-gcp/providers/gce"
+	controllersmetrics "k8s.io/component-base/metrics/prometheus/controllers"
+	"k8s.io/klog/v2"
+	netutils "k8s.io/utils/net"
+)
+
+const (
+	// ControllerName is the name of the NodeNetworkConfig controller.
+	ControllerName = "nodenetworkconfig"
+	workqueueName  = "nodenetworkconfig"
+
+	// DefaultPodCIDRMaskSize is the default mask size of the alias IP ranges
+	// added to a node for dynamic pod IP allocation.
+	DefaultPodCIDRMaskSize = 28
+
+	// podCIDRAllocationFailedReason is the Ready condition reason used when
+	// a pod CIDR could not be allocated for reasons other than a GCE API error,
+	// e.g. the cluster CIDR is exhausted.
+	podCIDRAllocationFailedReason = "PodCIDRAllocationFailed"
+	// ipv6PodCIDRsUnsupportedReason is the Ready condition reason used when
+	// the NodeNetworkConfig asks for IPv6 pod CIDRs to be allocated or released.
+	ipv6PodCIDRsUnsupportedReason = "IPv6PodCIDRsUnsupported"
+)
+
+// errIPv6PodCIDRsUnsupported is returned for requests the controller cannot
+// satisfy because they concern IPv6 pod CIDRs.
+var errIPv6PodCIDRsUnsupported = errors.New("IPv6 pod CIDRs are not supported, alias IP ranges are carved out of the IPv4 cluster CIDR only")
+
+// Controller reconciles NodeNetworkConfig objects written by the metis daemon.
+//
+// The metis daemon requests pod IP capacity for a node by raising
+// Spec.Allocations[].Pods and gives capacity back by listing drained ranges in
+// Spec.ReleasableCIDRs. The controller satisfies these requests by adding
+// alias IP ranges carved out of the cluster CIDR (the node's secondary range)
+// to the node's instance and publishing them in Status.PodCIDRs with a Ready
+// condition, and by removing released ranges from both the instance and
+// Status.PodCIDRs.
+//
+// The additional pod CIDRs the cloud CIDR allocator publishes in the
+// AdditionalPodCIDRsAnnotationKey annotation of the node, i.e. alias IP ranges
+// from further pod secondary ranges, are published in Status.PodCIDRs too.
+// They are attached to the instance by the operator and never released by
+// the controller: listed in Spec.ReleasableCIDRs, they stay in Status.PodCIDRs
+// until the operator removes them from the instance. Ranges of the size the
+// controller allocates in the cluster CIDR are its own, even if listed there.
+//
+// Only the default pod network is supported, as alias IP ranges are added
+// from the secondary range configured for the cloud provider. Only IPv4 is
+// supported: IPv6 pod CIDRs listed in Spec.ReleasableCIDRs that are not
+// additional pod CIDRs of the node are not released, and pods requested for a
+// node without an IPv4 pod CIDR are not allocated. Both set
+// the Ready condition to False with the IPv6PodCIDRsUnsupported reason.
+type Controller struct {
+	nncClientset       nncclientset.Interface
+	nncInformer        nncinformer.NodeNetworkConfigInformer
+	nncLister          nnclisters.NodeNetworkConfigLister
+	nncInformerFactory nncinformers.SharedInformerFactory
+	gceCloud           *gce.Cloud
+	queue              workqueue.RateLimitingInterface
+
+	nodeLister         corelisters.NodeLister
+	nodeInformerSynced cache.InformerSynced
+
+	// clusterCIDR is the IPv4 range alias IP ranges are carved out of.
+	clusterCIDR *net.IPNet
+	// podCIDRMaskSize is the mask size of the alias IP ranges.
+	podCIDRMaskSize int
+	// cidrSet tracks the ranges of clusterCIDR that are used by node pod
+	// CIDRs and by the alias IP ranges published in NodeNetworkConfigs.
+	cidrSet *cidrset.CidrSet
+
+	lock sync.Mutex
+	// orphanedPodCIDRs are the pod CIDRs of deleted NodeNetworkConfigs whose
+	// node still exists, by node. They stay used until they are removed from
+	// the node's instance.
+	orphanedPodCIDRs map[string]sets.Set[string]
+}
+
+// NewNodeNetworkConfigController returns a new NodeNetworkConfig controller
+// that carves alias IP ranges of size podCIDRMaskSize out of the IPv4 cluster CIDR.
+func NewNodeNetworkConfigController(
+	nodeInformer coreinformers.NodeInformer,
+	nncClientset nncclientset.Interface,
+	nncInformer nncinformer.NodeNetworkConfigInformer,
+	gceCloud *gce.Cloud,
+	nncInformerFactory nncinformers.SharedInformerFactory,
+	clusterCIDRs []*net.IPNet,
+	podCIDRMaskSize int,
+) (*Controller, error) {
+	var clusterCIDR *net.IPNet
+	for _, cidr := range clusterCIDRs {
+		if netutils.IsIPv4CIDR(cidr) {
+			clusterCIDR = cidr
+			break
+		}
+	}
+	if clusterCIDR == nil {
+		return nil, fmt.Errorf("an IPv4 cluster CIDR is required, got %v", clusterCIDRs)
+	}
+
+	cidrSet, err := cidrset.NewCIDRSet(clusterCIDR, podCIDRMaskSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CIDR set for cluster CIDR %s with mask size %d: %w", clusterCIDR, podCIDRMaskSize, err)
+	}
+
+	c := &Controller{
+		nncClientset:       nncClientset,
+		nncInformer:        nncInformer,
+		nncLister:          nncInformer.Lister(),
+		nncInformerFactory: nncInformerFactory,
+		gceCloud:           gceCloud,
+		queue:              workqueue.NewRateLimitingQueueWithConfig(workqueue.DefaultControllerRateLimiter(), workqueue.RateLimitingQueueConfig{Name: workqueueName}),
+		nodeLister:         nodeInformer.Lister(),
+		nodeInformerSynced: nodeInformer.Informer().HasSynced,
+		clusterCIDR:        clusterCIDR,
+		podCIDRMaskSize:    podCIDRMaskSize,
+		cidrSet:            cidrSet,
+		orphanedPodCIDRs:   map[string]sets.Set[string]{},
+	}
+
+	nncInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			key, err := cache.MetaNamespaceKeyFunc(obj)
+			if err == nil {
+				c.queue.Add(key)
+			}
+		},
+		UpdateFunc: func(old interface{}, new interface{}) {
+			key, err := cache.MetaNamespaceKeyFunc(new)
+			if err == nil {
+				c.queue.Add(key)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			nnc, ok := obj.(*nncv1.NodeNetworkConfig)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					return
+				}
+				if nnc, ok = tombstone.Obj.(*nncv1.NodeNetworkConfig); !ok {
+					return
+				}
+			}
+			c.orphanPodCIDRs(nnc)
+		},
+	})
+
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		// The pod CIDRs of new nodes are marked as used as soon as the nodes
+		// appear, so that they are not handed out to other nodes before the
+		// NodeNetworkConfigs of the new nodes are reconciled.
+		AddFunc: func(obj interface{}) {
+			c.occupyNodePodCIDRs(obj.(*v1.Node))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldNode := old.(*v1.Node)
+			newNode := new.(*v1.Node)
+			if !slices.Equal(oldNode.Spec.PodCIDRs, newNode.Spec.PodCIDRs) {
+				c.occupyNodePodCIDRs(newNode)
+				return
+			}
+			if oldNode.Spec.ProviderID != newNode.Spec.ProviderID ||
+				oldNode.Annotations[utilnode.AdditionalPodCIDRsAnnotationKey] != newNode.Annotations[utilnode.AdditionalPodCIDRsAnnotationKey] {
+				c.queue.Add(newNode.Name)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			node, ok := obj.(*v1.Node)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					return
+				}
+				if node, ok = tombstone.Obj.(*v1.Node); !ok {
+					return
+				}
+			}
+			for _, podCIDR := range node.Spec.PodCIDRs {
+				c.releaseCIDR(podCIDR)
+			}
+			// The orphaned pod CIDRs of the node went away with its instance.
+			c.queue.Add(node.Name)
+		},
+	})
+
+	return c, nil
+}
+
+// Run starts an asynchronous loop that reconciles NodeNetworkConfigs in the cluster.
+func (c *Controller) Run(numWorkers int, stopCh <-chan struct{}, controllerManagerMetrics *controllersmetrics.ControllerManagerMetrics) {
+	defer utilruntime.HandleCrash()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	defer c.queue.ShutDown()
+
+	klog.Infof("Starting nodenetworkconfig controller")
+	defer klog.Infof("Shutting down nodenetworkconfig controller")
+	controllerManagerMetrics.ControllerStarted(ControllerName)
+	defer controllerManagerMetrics.ControllerStopped(ControllerName)
+
+	c.nncInformerFactory.Start(stopCh)
+
+	if !cache.WaitForNamedCacheSync(ControllerName, stopCh, c.nncInformer.Informer().HasSynced, c.nodeInformerSynced) {
+		return
+	}
+
+	if err := c.occupyExistingCIDRs(); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to mark existing pod CIDRs as used: %w", err))
+		return
+	}
+
+	for i := 0; i < numWorkers; i++ {
+		go wait.UntilWithContext(ctx, c.runWorker, time.Second)
+	}
+
+	<-stopCh
+}
+
+// occupyExistingCIDRs marks the pod CIDRs of all nodes and the alias IP ranges
+// published in all NodeNetworkConfigs as used, so that they are not handed out again.
+func (c *Controller) occupyExistingCIDRs() error {
+	nodes, err := c.nodeLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		for _, podCIDR := range node.Spec.PodCIDRs {
+			c.occupyCIDR(podCIDR)
+		}
+	}
+
+	nncs, err := c.nncLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, nnc := range nncs {
+		for _, podCIDR := range nnc.Status.PodCIDRs {
+			c.occupyCIDR(podCIDR.CIDR)
+		}
+	}
+	return nil
+}
+
+// worker pattern adapted from https://github.com/kubernetes/client-go/blob/master/examples/workqueue/main.go
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextItem(ctx context.Context) bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+
+	defer c.queue.Done(key)
+
+	err := c.reconcile(ctx, key.(string))
+	c.handleErr(err, key)
+	return true
+}
+
+// handleErr checks if an error happened and makes sure we will retry later.
+func (c *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		c.queue.Forget(key)
+		return
+	}
+
+	// This controller retries 5 times if something goes wrong. After that, it stops trying.
+	if c.queue.NumRequeues(key) < 5 {
+		klog.Warningf("Error while reconciling NodeNetworkConfig %q, retrying: %v", key, err)
+		c.queue.AddRateLimited(key)
+		return
+	}
+
+	c.queue.Forget(key)
+	utilruntime.HandleError(err)
+	klog.Errorf("Dropping NodeNetworkConfig %q out of the queue: %v", key, err)
+	controllermetrics.WorkqueueDroppedObjects.WithLabelValues(workqueueName).Inc()
+}
+
+func (c *Controller) reconcile(ctx context.Context, key string) error {
+	nnc, err := c.nncLister.Get(key)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return c.removeOrphanedPodCIDRs(key)
+		}
+		return err
+	}
+	// The pod CIDRs orphaned by an earlier NodeNetworkConfig of the node are
+	// still assigned to its instance and used, the new one adopts them.
+	c.lock.Lock()
+	delete(c.orphanedPodCIDRs, key)
+	c.lock.Unlock()
+
+	// NodeNetworkConfigs are named after their node.
+	node, err := c.nodeLister.Get(nnc.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(2).Infof("Node %q of NodeNetworkConfig not found, skipping", nnc.Name)
+			return nil
+		}
+		return err
+	}
+	if node.Spec.ProviderID == "" {
+		return fmt.Errorf("node %s doesn't have providerID", node.Name)
+	}
+
+	for _, podCIDR := range node.Spec.PodCIDRs {
+		c.occupyCIDR(podCIDR)
+	}
+
+	updated := nnc.DeepCopy()
+	c.syncAdditionalPodCIDRs(node, updated)
+	err = errors.Join(c.releasePodCIDRs(node, updated), c.allocatePodCIDRs(node, updated))
+	meta.SetStatusCondition(&updated.Status.Conditions, readyCondition(err))
+
+	if !equality.Semantic.DeepEqual(nnc.Status, updated.Status) {
+		if _, updateErr := c.nncClientset.NetworkingV1().NodeNetworkConfigs().UpdateStatus(ctx, updated, metav1.UpdateOptions{}); updateErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to update NodeNetworkConfig %s status: %w", nnc.Name, updateErr))
+		}
+	}
+	return err
+}
+
+// occupyNodePodCIDRs marks the pod CIDRs of node as used and queues its
+// NodeNetworkConfig.
+func (c *Controller) occupyNodePodCIDRs(node *v1.Node) {
+	for _, podCIDR := range node.Spec.PodCIDRs {
+		c.occupyCIDR(podCIDR)
+	}
+	c.queue.Add(node.Name)
+}
+
+// orphanPodCIDRs releases the pod CIDRs published in a deleted
+// NodeNetworkConfig. The alias IP ranges go away together with the node's
+// instance, so the ranges of deleted nodes are released right away. The ranges
+// allocated from the cluster CIDR to nodes that still exist are first removed
+// from their instances by removeOrphanedPodCIDRs.
+func (c *Controller) orphanPodCIDRs(nnc *nncv1.NodeNetworkConfig) {
+	node, err := c.nodeLister.Get(nnc.Name)
+	if err != nil {
+		for _, podCIDR := range nnc.Status.PodCIDRs {
+			c.releaseCIDR(podCIDR.CIDR)
+		}
+		return
+	}
+
+	orphaned := sets.New[string]()
+	for _, podCIDR := range nnc.Status.PodCIDRs {
+		_, cidr, err := netutils.ParseCIDRSloppy(podCIDR.CIDR)
+		// Additional pod CIDRs outside of the cluster CIDR are not allocated
+		// by the controller and stay assigned to the instance.
+		if err != nil || !c.clusterCIDR.Contains(cidr.IP) || slices.Contains(node.Spec.PodCIDRs, cidr.String()) {
+			continue
+		}
+		orphaned.Insert(cidr.String())
+	}
+	if orphaned.Len() == 0 {
+		return
+	}
+	c.lock.Lock()
+	c.orphanedPodCIDRs[nnc.Name] = orphaned.Union(c.orphanedPodCIDRs[nnc.Name])
+	c.lock.Unlock()
+	c.queue.Add(nnc.Name)
+}
+
+// removeOrphanedPodCIDRs removes the orphaned pod CIDRs of a node from its
+// instance and releases them, or only releases them if the node is gone.
+func (c *Controller) removeOrphanedPodCIDRs(name string) error {
+	c.lock.Lock()
+	orphaned := sets.List(c.orphanedPodCIDRs[name])
+	c.lock.Unlock()
+	if len(orphaned) == 0 {
+		return nil
+	}
+
+	node, err := c.nodeLister.Get(name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	var errs []error
+	removed := sets.New[string]()
+	for _, podCIDR := range orphaned {
+		if node != nil {
+			if node.Spec.ProviderID == "" {
+				return fmt.Errorf("node %s doesn't have providerID", node.Name)
+			}
+			_, cidr, _ := netutils.ParseCIDRSloppy(podCIDR)
+			if err := c.gceCloud.RemoveAliasFromInstanceByProviderID(node.Spec.ProviderID, cidr); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove alias %s from node %s: %w", cidr, node.Name, err))
+				continue
+			}
+			klog.Infof("Removed pod CIDR %s of deleted NodeNetworkConfig from node %s", cidr, node.Name)
+		}
+		c.releaseCIDR(podCIDR)
+		removed.Insert(podCIDR)
+	}
+
+	c.lock.Lock()
+	if remaining := c.orphanedPodCIDRs[name].Difference(removed); remaining.Len() > 0 {
+		c.orphanedPodCIDRs[name] = remaining
+	} else {
+		delete(c.orphanedPodCIDRs, name)
+	}
+	c.lock.Unlock()
+	return errors.Join(errs...)
+}
+
+// syncAdditionalPodCIDRs publishes the additional pod CIDRs of the node in
+// Status.PodCIDRs of the default network, unless they are being released, and
+// drops the default network pod CIDRs outside of the cluster CIDR that are no
+// longer additional pod CIDRs of the node. Pod CIDRs allocated by the
+// controller are always in the cluster CIDR.
+func (c *Controller) syncAdditionalPodCIDRs(node *v1.Node, nnc *nncv1.NodeNetworkConfig) {
+	var additional []*net.IPNet
+	isAdditional := map[string]bool{}
+	for _, cidr := range utilnode.GetAdditionalPodCIDRs(node) {
+		_, ipNet, err := netutils.ParseCIDRSloppy(cidr)
+		if err != nil {
+			klog.Warningf("Ignoring invalid additional pod CIDR %q of node %s: %v", cidr, node.Name, err)
+			continue
+		}
+		if c.isOwnPodCIDR(ipNet) {
+			klog.V(2).Infof("Ignoring additional pod CIDR %s of node %s, it is allocated by the controller", ipNet, node.Name)
+			continue
+		}
+		additional = append(additional, ipNet)
+		isAdditional[ipNet.String()] = true
+	}
+
+	var kept []nncv1.PodCIDR
+	published := map[string]bool{}
+	for _, podCIDR := range nnc.Status.PodCIDRs {
+		if networkv1.IsDefaultNetwork(networkv1.DefaultNetworkIfEmpty(podCIDR.Network)) && !isAdditional[podCIDR.CIDR] {
+			if _, ipNet, err := netutils.ParseCIDRSloppy(podCIDR.CIDR); err == nil && !c.clusterCIDR.Contains(ipNet.IP) {
+				klog.Infof("Dropping pod CIDR %s that is no longer an additional pod CIDR of node %s", podCIDR.CIDR, node.Name)
+				continue
+			}
+		}
+		kept = append(kept, podCIDR)
+		published[podCIDR.CIDR] = true
+	}
+
+	releasable := map[string]bool{}
+	for _, podCIDR := range nnc.Spec.ReleasableCIDRs {
+		releasable[podCIDR.CIDR] = true
+	}
+	for _, cidr := range additional {
+		if published[cidr.String()] || releasable[cidr.String()] {
+			continue
+		}
+		published[cidr.String()] = true
+		c.occupyCIDR(cidr.String())
+		kept = append(kept, newReadyPodCIDR(networkv1.DefaultPodNetworkName, cidr))
+		klog.Infof("Published additional pod CIDR %s of node %s", cidr, node.Name)
+	}
+	nnc.Status.PodCIDRs = kept
+}
+
+// isOwnPodCIDR returns whether cidr is of the size of the alias IP ranges the
+// controller carves out of the cluster CIDR, and in it. Such ranges are released
+// by the controller even if they are listed as additional pod CIDRs of the node.
+func (c *Controller) isOwnPodCIDR(cidr *net.IPNet) bool {
+	ones, _ := cidr.Mask.Size()
+	return ones == c.podCIDRMaskSize && c.clusterCIDR.Contains(cidr.IP)
+}
+
+// releasePodCIDRs removes the pod CIDRs listed in Spec.ReleasableCIDRs from the
+// node's instance and from Status.PodCIDRs. The additional pod CIDRs of the
+// node are kept, as their alias IP ranges were not added by the controller.
+func (c *Controller) releasePodCIDRs(node *v1.Node, nnc *nncv1.NodeNetworkConfig) error {
+	releasable := map[string]bool{}
+	for _, podCIDR := range nnc.Spec.ReleasableCIDRs {
+		releasable[podCIDR.CIDR] = true
+	}
+	for _, cidr := range utilnode.GetAdditionalPodCIDRs(node) {
+		if _, ipNet, err := netutils.ParseCIDRSloppy(cidr); err == nil && releasable[ipNet.String()] && !c.isOwnPodCIDR(ipNet) {
+			klog.V(2).Infof("Not releasing additional pod CIDR %s of node %s, it is removed from the instance by the operator", ipNet, node.Name)
+			delete(releasable, ipNet.String())
+		}
+	}
+
+	var errs []error
+	for _, podCIDR := range nnc.Spec.ReleasableCIDRs {
+		if _, ipNet, err := netutils.ParseCIDRSloppy(podCIDR.CIDR); err == nil && releasable[podCIDR.CIDR] && !netutils.IsIPv4CIDR(ipNet) {
+			errs = append(errs, fmt.Errorf("failed to release pod CIDR %s of node %s: %w", ipNet, node.Name, errIPv6PodCIDRsUnsupported))
+			delete(releasable, podCIDR.CIDR)
+		}
+	}
+	var kept []nncv1.PodCIDR
+	for _, podCIDR := range nnc.Status.PodCIDRs {
+		if !releasable[podCIDR.CIDR] {
+			kept = append(kept, podCIDR)
+			continue
+		}
+
+		_, cidr, err := netutils.ParseCIDRSloppy(podCIDR.CIDR)
+		if err != nil {
+			// A range that cannot be parsed was not added by this controller.
+			klog.Warningf("Dropping invalid pod CIDR %q from NodeNetworkConfig %s: %v", podCIDR.CIDR, nnc.Name, err)
+			continue
+		}
+		if err := c.gceCloud.RemoveAliasFromInstanceByProviderID(node.Spec.ProviderID, cidr); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove alias %s from node %s: %w", cidr, node.Name, err))
+			kept = append(kept, podCIDR)
+			continue
+		}
+		c.releaseCIDR(podCIDR.CIDR)
+		klog.Infof("Released pod CIDR %s of network %q from node %s", cidr, podCIDR.Network, node.Name)
+	}
+	nnc.Status.PodCIDRs = kept
+	return errors.Join(errs...)
+}
+
+// allocatePodCIDRs adds alias IP ranges to the node's instance until the
+// capacity of the default network covers its requested pod count.
+func (c *Controller) allocatePodCIDRs(node *v1.Node, nnc *nncv1.NodeNetworkConfig) error {
+	for _, allocation := range nnc.Spec.Allocations {
+		network := networkv1.DefaultNetworkIfEmpty(allocation.Network)
+		if !networkv1.IsDefaultNetwork(network) {
+			klog.V(4).Infof("Skipping allocation of network %q for NodeNetworkConfig %s: only the default network is supported", network, nnc.Name)
+			continue
+		}
+
+		if c.capacity(node, nnc) >= int(allocation.Pods) {
+			continue
+		}
+		if !hasIPv4PodCIDR(node) {
+			return fmt.Errorf("failed to allocate pod CIDRs for IPv6-only node %s: %w", node.Name, errIPv6PodCIDRsUnsupported)
+		}
+
+		// Adopt ranges that were added to the instance by an earlier
+		// reconcile whose status update did not go through.
+		if err := c.adoptInstanceAliases(node, nnc, network); err != nil {
+			return err
+		}
+
+		if err := c.addPodCIDRs(node, nnc, network, int(allocation.Pods)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addPodCIDRs allocates pod CIDRs from the cluster CIDR and adds them as alias
+// IP ranges to the node's instance until the node has capacity for pods.
+func (c *Controller) addPodCIDRs(node *v1.Node, nnc *nncv1.NodeNetworkConfig, network string, pods int) error {
+	releasable := map[string]bool{}
+	for _, podCIDR := range nnc.Spec.ReleasableCIDRs {
+		releasable[podCIDR.CIDR] = true
+	}
+	// Ranges the node is releasing must not be handed straight back to it,
+	// they are set aside and only returned to the CIDR set once done.
+	var skipped []*net.IPNet
+	defer func() {
+		for _, cidr := range skipped {
+			c.releaseCIDR(cidr.String())
+		}
+	}()
+
+	for c.capacity(node, nnc) < pods {
+		cidr, err := c.cidrSet.AllocateNext()
+		if err != nil {
+			return fmt.Errorf("failed to allocate pod CIDR for node %s: %w", node.Name, err)
+		}
+		if releasable[cidr.String()] {
+			skipped = append(skipped, cidr)
+			continue
+		}
+		if err := c.gceCloud.AppendAliasToInstanceByProviderID(node.Spec.ProviderID, cidr); err != nil {
+			c.releaseCIDR(cidr.String())
+			return fmt.Errorf("failed to add alias %s to node %s: %w", cidr, node.Name, err)
+		}
+		nnc.Status.PodCIDRs = append(nnc.Status.PodCIDRs, newReadyPodCIDR(network, cidr))
+		klog.Infof("Allocated pod CIDR %s of network %q to node %s (requested pods: %d)", cidr, network, node.Name, pods)
+	}
+	return nil
+}
+
+// adoptInstanceAliases publishes the alias IP ranges of the node's instance
+// that belong to the cluster CIDR, are of the allocated size and are neither
+// the node's own pod CIDR nor published in Status.PodCIDRs yet.
+func (c *Controller) adoptInstanceAliases(node *v1.Node, nnc *nncv1.NodeNetworkConfig, network string) error {
+	aliases, err := c.gceCloud.AliasRangesByProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return fmt.Errorf("failed to get alias ranges of node %s: %w", node.Name, err)
+	}
+
+	known := map[string]bool{}
+	for _, podCIDR := range node.Spec.PodCIDRs {
+		known[podCIDR] = true
+	}
+	for _, podCIDR := range nnc.Status.PodCIDRs {
+		known[podCIDR.CIDR] = true
+	}
+
+	for _, alias := range aliases {
+		_, cidr, err := netutils.ParseCIDRSloppy(alias)
+		if err != nil || known[cidr.String()] || !c.clusterCIDR.Contains(cidr.IP) {
+			continue
+		}
+		if maskSize, _ := cidr.Mask.Size(); maskSize != c.podCIDRMaskSize {
+			continue
+		}
+		c.occupyCIDR(cidr.String())
+		nnc.Status.PodCIDRs = append(nnc.Status.PodCIDRs, newReadyPodCIDR(network, cidr))
+		klog.Infof("Adopted alias %s of node %s as pod CIDR of network %q", cidr, node.Name, network)
+	}
+	return nil
+}
+
+// capacity returns the number of IPv4 addresses available to the default
+// network on the node, i.e. the node's own pod CIDR plus the Ready pod CIDRs
+// published for the default network that are not being released.
+func (c *Controller) capacity(node *v1.Node, nnc *nncv1.NodeNetworkConfig) int {
+	releasable := map[string]bool{}
+	for _, podCIDR := range nnc.Spec.ReleasableCIDRs {
+		releasable[podCIDR.CIDR] = true
+	}
+
+	total := 0
+	for _, podCIDR := range node.Spec.PodCIDRs {
+		total += ipv4Size(podCIDR)
+	}
+	for _, podCIDR := range nnc.Status.PodCIDRs {
+		if !networkv1.IsDefaultNetwork(networkv1.DefaultNetworkIfEmpty(podCIDR.Network)) || releasable[podCIDR.CIDR] {
+			continue
+		}
+		if podCIDR.Condition != nil && podCIDR.Condition.Status != metav1.ConditionTrue {
+			continue
+		}
+		total += ipv4Size(podCIDR.CIDR)
+	}
+	return total
+}
+
+// occupyCIDR marks cidr as used if it belongs to the cluster CIDR.
+func (c *Controller) occupyCIDR(cidr string) {
+	_, ipNet, err := netutils.ParseCIDRSloppy(cidr)
+	if err != nil || !c.clusterCIDR.Contains(ipNet.IP) {
+		return
+	}
+	if err := c.cidrSet.Occupy(ipNet); err != nil {
+		klog.Warningf("Failed to mark pod CIDR %s as used: %v", cidr, err)
+	}
+}
+
+// releaseCIDR marks cidr as free if it belongs to the cluster CIDR.
+func (c *Controller) releaseCIDR(cidr string) {
+	_, ipNet, err := netutils.ParseCIDRSloppy(cidr)
+	if err != nil || !c.clusterCIDR.Contains(ipNet.IP) {
+		return
+	}
+	if err := c.cidrSet.Release(ipNet); err != nil {
+		klog.Warningf("Failed to release pod CIDR %s: %v", cidr, err)
+	}
+}
+
+// hasIPv4PodCIDR returns whether node has an IPv4 pod CIDR. Nodes without pod
+// CIDRs are assumed to be IPv4 nodes waiting for theirs.
+func hasIPv4PodCIDR(node *v1.Node) bool {
+	if len(node.Spec.PodCIDRs) == 0 {
+		return true
+	}
+	for _, podCIDR := range node.Spec.PodCIDRs {
+		if _, ipNet, err := netutils.ParseCIDRSloppy(podCIDR); err == nil && netutils.IsIPv4CIDR(ipNet) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipv4Size returns the number of addresses of an IPv4 CIDR, or 0 for invalid
+// and IPv6 CIDRs which are not backed by alias IP ranges.
+func ipv4Size(cidr string) int {
+	_, ipNet, err := netutils.ParseCIDRSloppy(cidr)
+	if err != nil || !netutils.IsIPv4CIDR(ipNet) {
+		return 0
+	}
+	ones, bits := ipNet.Mask.Size()
+	return 1 << (bits - ones)
+}
+
+// newReadyPodCIDR returns a Ready pod CIDR of the given network.
+func newReadyPodCIDR(network string, cidr *net.IPNet) nncv1.PodCIDR {
+	return nncv1.PodCIDR{
+		Id:      podCIDRID(cidr),
+		Network: network,
+		CIDR:    cidr.String(),
+		Condition: &metav1.Condition{
+			Type:               string(nncv1.PodCIDRConditionReady),
+			Status:             metav1.ConditionTrue,
+			Reason:             string(nncv1.PodCIDRReadyConditionReady),
+			LastTransitionTime: metav1.Now(),
+		},
+	}
+}
+
+// podCIDRID returns a stable identifier for a pod CIDR, e.g. "10-0-1-16-28" for 10.0.1.16/28.
+func podCIDRID(cidr *net.IPNet) string {
+	return strings.NewReplacer(".", "-", ":", "-", "/", "-").Replace(cidr.String())
+}
+
+// readyCondition returns the NodeNetworkConfig Ready condition for the result of a reconcile.
+func readyCondition(err error) metav1.Condition {
+	if err == nil {
+		return metav1.Condition{
+			Type:    string(nncv1.NodeNetworkConfigConditionReady),
+			Status:  metav1.ConditionTrue,
+			Reason:  string(nncv1.NodeNetworkConfigReadyReason),
+			Message: "Pod CIDRs are up to date",
+		}
+	}
+
+	reason := podCIDRAllocationFailedReason
+	var apiErr *googleapi.Error
+	if errors.Is(err, errIPv6PodCIDRsUnsupported) {
+		reason = ipv6PodCIDRsUnsupportedReason
+	} else if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == http.StatusTooManyRequests || hasErrorReason(apiErr, "quotaExceeded", "rateLimitExceeded"):
+			reason = string(nncv1.NodeNetworkConfigQuotaExceededReason)
+		case apiErr.Code == http.StatusForbidden:
+			reason = string(nncv1.NodeNetworkConfigPermissionErrorsReason)
+		case apiErr.Code == http.StatusBadRequest:
+			reason = string(nncv1.NodeNetworkConfigInvalidParametersReason)
+		}
+	}
+	return metav1.Condition{
+		Type:    string(nncv1.NodeNetworkConfigConditionReady),
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: err.Error(),
+	}
+}
+
+// hasErrorReason reports whether any of the error items of apiErr has one of the given reasons.
+func hasErrorReason(apiErr *googleapi.Error, reasons ...string) bool {
+	for _, item := range apiErr.Errors {
+		for _, reason := range reasons {
+			if item.Reason == reason {
+				return true
+			}
+		}
+	}
+	return false
+}
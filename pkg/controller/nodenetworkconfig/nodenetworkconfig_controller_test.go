@@ -0,0 +1,547 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodenetworkconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	nncv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/nodenetworkconfig/v1"
+	nncfake "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/clientset/versioned/fake"
+	nncinformers "github.com/GoogleCloudPlatform/gke-networking-api/client/nodenetworkconfig/informers/externalversions"
+	"github.com/GoogleCloudPlatform/k8s-cloud-provider/pkg/cloud"
+	"github.com/GoogleCloudPlatform/k8s-cloud-provider/pkg/cloud/meta"
+	"github.com/google/go-cmp/cmp"
+	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/googleapi"
+	v1 "k8s.io/api/core/v1"
+	condmeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	utilnode "k8s.io/cloud-provider-gcp/pkg/util/node"
+	"k8s.io/cloud-provider-gcp/providers/gce"
+	"k8s.io/component-base/metrics/prometheus/controllers"
+)
+
+const (
+	testNodeName    = "test-node"
+	testClusterCIDR = "10.100.0.0/16"
+	testNodePodCIDR = "10.100.0.0/24"
+)
+
+type testNodeNetworkConfigController struct {
+	controller *Controller
+	nncClient  *nncfake.Clientset
+	cloud      *gce.Cloud
+	// aliasUpdates counts the alias IP range updates made to the instance.
+	aliasUpdates int
+}
+
+func setupNodeNetworkConfigController(t *testing.T, node *v1.Node, nnc *nncv1.NodeNetworkConfig, aliases []string) *testNodeNetworkConfigController {
+	t.Helper()
+	ctx := context.Background()
+
+	nncClient := nncfake.NewSimpleClientset(nnc)
+	nncInfFactory := nncinformers.NewSharedInformerFactory(nncClient, 0*time.Second)
+	nncInformer := nncInfFactory.Networking().V1().NodeNetworkConfigs()
+	if err := nncInformer.Informer().GetStore().Add(nnc); err != nil {
+		t.Fatalf("Failed to add NodeNetworkConfig to informer store: %v", err)
+	}
+
+	fakeInformerFactory := informers.NewSharedInformerFactory(&fake.Clientset{}, 0*time.Second)
+	fakeNodeInformer := fakeInformerFactory.Core().V1().Nodes()
+	if err := fakeNodeInformer.Informer().GetStore().Add(node); err != nil {
+		t.Fatalf("Failed to add node to informer store: %v", err)
+	}
+
+	testClusterValues := gce.DefaultTestClusterValues()
+	fakeGCE := gce.NewFakeGCECloud(testClusterValues)
+
+	_, clusterCIDR, _ := net.ParseCIDR(testClusterCIDR)
+	controller, err := NewNodeNetworkConfigController(
+		fakeNodeInformer,
+		nncClient,
+		nncInformer,
+		fakeGCE,
+		nncInfFactory,
+		[]*net.IPNet{clusterCIDR},
+		DefaultPodCIDRMaskSize,
+	)
+	if err != nil {
+		t.Fatalf("NewNodeNetworkConfigController() failed: %v", err)
+	}
+	controller.nodeInformerSynced = func() bool { return true }
+	if err := controller.occupyExistingCIDRs(); err != nil {
+		t.Fatalf("occupyExistingCIDRs() failed: %v", err)
+	}
+
+	testVals := &testNodeNetworkConfigController{
+		controller: controller,
+		nncClient:  nncClient,
+		cloud:      fakeGCE,
+	}
+
+	var aliasRanges []*computebeta.AliasIpRange
+	for _, alias := range aliases {
+		aliasRanges = append(aliasRanges, &computebeta.AliasIpRange{IpCidrRange: alias})
+	}
+	instance := &computebeta.Instance{
+		Name:              testNodeName,
+		Zone:              testClusterValues.ZoneName,
+		NetworkInterfaces: []*computebeta.NetworkInterface{{Name: "nic0", AliasIpRanges: aliasRanges}},
+	}
+	key := meta.ZonalKey(testNodeName, testClusterValues.ZoneName)
+	if err := fakeGCE.Compute().BetaInstances().Insert(ctx, key, instance); err != nil {
+		t.Fatalf("Failed to insert instance: %v", err)
+	}
+
+	mbi := fakeGCE.Compute().(*cloud.MockGCE).BetaInstances().(*cloud.MockBetaInstances)
+	mbi.UpdateNetworkInterfaceHook = func(_ context.Context, key *meta.Key, _ string, iface *computebeta.NetworkInterface, m *cloud.MockBetaInstances, _ ...cloud.Option) error {
+		m.Lock.Lock()
+		defer m.Lock.Unlock()
+		instance := m.Objects[*key].ToBeta()
+		instance.NetworkInterfaces[0].AliasIpRanges = iface.AliasIpRanges
+		m.Objects[*key] = &cloud.MockInstancesObj{Obj: instance}
+		testVals.aliasUpdates++
+		return nil
+	}
+	return testVals
+}
+
+func newTestNode() *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: testNodeName},
+		Spec: v1.NodeSpec{
+			ProviderID: fmt.Sprintf("gce://%s/%s/%s", gce.DefaultTestClusterValues().ProjectID, gce.DefaultTestClusterValues().ZoneName, testNodeName),
+			PodCIDR:    testNodePodCIDR,
+			PodCIDRs:   []string{testNodePodCIDR},
+		},
+	}
+}
+
+func readyPodCIDR(cidr string) nncv1.PodCIDR {
+	_, ipNet, _ := net.ParseCIDR(cidr)
+	return newReadyPodCIDR("default", ipNet)
+}
+
+func TestReconcile(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		spec    nncv1.NodeNetworkConfigSpec
+		status  []nncv1.PodCIDR
+		aliases []string
+		// additionalPodCIDRs is the additional pod CIDRs annotation of the node.
+		additionalPodCIDRs string
+		wantPodCIDRs       []string
+		wantAliases        []string
+		wantAliasUpdates   int
+	}{
+		{
+			desc:             "node pod CIDR covers requested pods",
+			spec:             nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "default", Pods: 200}}},
+			wantAliasUpdates: 0,
+		},
+		{
+			desc:             "allocates pod CIDRs until requested pods are covered",
+			spec:             nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "default", Pods: 300}}},
+			wantPodCIDRs:     []string{"10.100.1.0/28", "10.100.1.16/28", "10.100.1.32/28"},
+			wantAliases:      []string{"10.100.1.0/28", "10.100.1.16/28", "10.100.1.32/28"},
+			wantAliasUpdates: 3,
+		},
+		{
+			desc:             "empty network is the default network",
+			spec:             nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Pods: 260}}},
+			wantPodCIDRs:     []string{"10.100.1.0/28"},
+			wantAliases:      []string{"10.100.1.0/28"},
+			wantAliasUpdates: 1,
+		},
+		{
+			desc:             "existing pod CIDRs count towards capacity",
+			spec:             nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "default", Pods: 280}}},
+			status:           []nncv1.PodCIDR{readyPodCIDR("10.100.1.0/28")},
+			aliases:          []string{"10.100.1.0/28"},
+			wantPodCIDRs:     []string{"10.100.1.0/28", "10.100.1.16/28"},
+			wantAliases:      []string{"10.100.1.0/28", "10.100.1.16/28"},
+			wantAliasUpdates: 1,
+		},
+		{
+			desc:             "adopts alias ranges missing from status",
+			spec:             nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "default", Pods: 270}}},
+			aliases:          []string{"10.100.1.0/28"},
+			wantPodCIDRs:     []string{"10.100.1.0/28"},
+			wantAliases:      []string{"10.100.1.0/28"},
+			wantAliasUpdates: 0,
+		},
+		{
+			desc: "releases releasable pod CIDRs",
+			spec: nncv1.NodeNetworkConfigSpec{
+				Allocations:     []nncv1.Allocation{{Network: "default", Pods: 260}},
+				ReleasableCIDRs: []nncv1.PodCIDR{{Network: "default", CIDR: "10.100.1.0/28"}},
+			},
+			status:           []nncv1.PodCIDR{readyPodCIDR("10.100.1.0/28"), readyPodCIDR("10.100.1.16/28")},
+			aliases:          []string{"10.100.1.0/28", "10.100.1.16/28"},
+			wantPodCIDRs:     []string{"10.100.1.16/28"},
+			wantAliases:      []string{"10.100.1.16/28"},
+			wantAliasUpdates: 1,
+		},
+		{
+			desc: "releasable pod CIDRs do not count towards capacity",
+			spec: nncv1.NodeNetworkConfigSpec{
+				Allocations:     []nncv1.Allocation{{Network: "default", Pods: 260}},
+				ReleasableCIDRs: []nncv1.PodCIDR{{Network: "default", CIDR: "10.100.1.0/28"}},
+			},
+			status:           []nncv1.PodCIDR{readyPodCIDR("10.100.1.0/28")},
+			aliases:          []string{"10.100.1.0/28"},
+			wantPodCIDRs:     []string{"10.100.1.16/28"},
+			wantAliases:      []string{"10.100.1.16/28"},
+			wantAliasUpdates: 2,
+		},
+		{
+			desc:               "publishes additional pod CIDRs of the node",
+			spec:               nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "default", Pods: 500}}},
+			additionalPodCIDRs: "10.200.1.0/24,10.200.0.0/24",
+			wantPodCIDRs:       []string{"10.200.1.0/24", "10.200.0.0/24"},
+			wantAliasUpdates:   0,
+		},
+		{
+			desc:               "drops pod CIDRs no longer additional pod CIDRs of the node",
+			spec:               nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "default", Pods: 260}}},
+			status:             []nncv1.PodCIDR{readyPodCIDR("10.100.1.0/28"), readyPodCIDR("10.200.0.0/24"), readyPodCIDR("10.200.1.0/24")},
+			aliases:            []string{"10.100.1.0/28"},
+			additionalPodCIDRs: "10.200.1.0/24",
+			wantPodCIDRs:       []string{"10.100.1.0/28", "10.200.1.0/24"},
+			wantAliases:        []string{"10.100.1.0/28"},
+			wantAliasUpdates:   0,
+		},
+		{
+			desc: "does not publish releasable additional pod CIDRs",
+			spec: nncv1.NodeNetworkConfigSpec{
+				Allocations:     []nncv1.Allocation{{Network: "default", Pods: 200}},
+				ReleasableCIDRs: []nncv1.PodCIDR{{Network: "default", CIDR: "10.200.0.0/24"}},
+			},
+			additionalPodCIDRs: "10.200.0.0/24",
+			wantAliasUpdates:   0,
+		},
+		{
+			desc: "does not release additional pod CIDRs",
+			spec: nncv1.NodeNetworkConfigSpec{
+				Allocations:     []nncv1.Allocation{{Network: "default", Pods: 200}},
+				ReleasableCIDRs: []nncv1.PodCIDR{{Network: "default", CIDR: "10.200.0.0/24"}},
+			},
+			status:             []nncv1.PodCIDR{readyPodCIDR("10.200.0.0/24")},
+			aliases:            []string{"10.200.0.0/24"},
+			additionalPodCIDRs: "10.200.0.0/24",
+			wantPodCIDRs:       []string{"10.200.0.0/24"},
+			wantAliases:        []string{"10.200.0.0/24"},
+			wantAliasUpdates:   0,
+		},
+		{
+			desc: "releases own pod CIDRs listed as additional pod CIDRs",
+			spec: nncv1.NodeNetworkConfigSpec{
+				Allocations:     []nncv1.Allocation{{Network: "default", Pods: 200}},
+				ReleasableCIDRs: []nncv1.PodCIDR{{Network: "default", CIDR: "10.100.1.0/28"}},
+			},
+			status:             []nncv1.PodCIDR{readyPodCIDR("10.100.1.0/28"), readyPodCIDR("10.200.0.0/24")},
+			aliases:            []string{"10.100.1.0/28", "10.200.0.0/24"},
+			additionalPodCIDRs: "10.100.1.0/28,10.200.0.0/24",
+			wantPodCIDRs:       []string{"10.200.0.0/24"},
+			wantAliases:        []string{"10.200.0.0/24"},
+			wantAliasUpdates:   1,
+		},
+		{
+			desc:             "non-default networks are skipped",
+			spec:             nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "other-network", Pods: 300}}},
+			wantAliasUpdates: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.Background()
+			nnc := &nncv1.NodeNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: testNodeName},
+				Spec:       tc.spec,
+				Status:     nncv1.NodeNetworkConfigStatus{PodCIDRs: tc.status},
+			}
+			node := newTestNode()
+			if tc.additionalPodCIDRs != "" {
+				node.Annotations = map[string]string{utilnode.AdditionalPodCIDRsAnnotationKey: tc.additionalPodCIDRs}
+			}
+			testVals := setupNodeNetworkConfigController(t, node, nnc, tc.aliases)
+
+			if err := testVals.controller.reconcile(ctx, testNodeName); err != nil {
+				t.Fatalf("reconcile() failed: %v", err)
+			}
+
+			got, err := testVals.nncClient.NetworkingV1().NodeNetworkConfigs().Get(ctx, testNodeName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get NodeNetworkConfig: %v", err)
+			}
+			var gotPodCIDRs []string
+			for _, podCIDR := range got.Status.PodCIDRs {
+				gotPodCIDRs = append(gotPodCIDRs, podCIDR.CIDR)
+				if podCIDR.Condition == nil || podCIDR.Condition.Status != metav1.ConditionTrue {
+					t.Errorf("Pod CIDR %s is not Ready: %+v", podCIDR.CIDR, podCIDR.Condition)
+				}
+			}
+			if diff := cmp.Diff(tc.wantPodCIDRs, gotPodCIDRs); diff != "" {
+				t.Errorf("Status.PodCIDRs mismatch (-want +got):\n%s", diff)
+			}
+			if !condmeta.IsStatusConditionTrue(got.Status.Conditions, string(nncv1.NodeNetworkConfigConditionReady)) {
+				t.Errorf("Expected NodeNetworkConfig to be Ready, got conditions %+v", got.Status.Conditions)
+			}
+
+			gotAliases, err := testVals.cloud.AliasRangesByProviderID(newTestNode().Spec.ProviderID)
+			if err != nil {
+				t.Fatalf("AliasRangesByProviderID() failed: %v", err)
+			}
+			sort.Strings(gotAliases)
+			if diff := cmp.Diff(tc.wantAliases, gotAliases); diff != "" {
+				t.Errorf("Instance alias ranges mismatch (-want +got):\n%s", diff)
+			}
+			if testVals.aliasUpdates != tc.wantAliasUpdates {
+				t.Errorf("Expected %d alias updates, got %d", tc.wantAliasUpdates, testVals.aliasUpdates)
+			}
+		})
+	}
+}
+
+func TestReconcileGCEErrors(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		err        error
+		wantReason string
+	}{
+		{
+			desc:       "quota exceeded",
+			err:        &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}},
+			wantReason: string(nncv1.NodeNetworkConfigQuotaExceededReason),
+		},
+		{
+			desc:       "rate limited",
+			err:        &googleapi.Error{Code: http.StatusTooManyRequests},
+			wantReason: string(nncv1.NodeNetworkConfigQuotaExceededReason),
+		},
+		{
+			desc:       "permission denied",
+			err:        &googleapi.Error{Code: http.StatusForbidden},
+			wantReason: string(nncv1.NodeNetworkConfigPermissionErrorsReason),
+		},
+		{
+			desc:       "invalid parameters",
+			err:        &googleapi.Error{Code: http.StatusBadRequest},
+			wantReason: string(nncv1.NodeNetworkConfigInvalidParametersReason),
+		},
+		{
+			desc:       "other error",
+			err:        fmt.Errorf("connection reset"),
+			wantReason: podCIDRAllocationFailedReason,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.Background()
+			nnc := &nncv1.NodeNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: testNodeName},
+				Spec:       nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "default", Pods: 300}}},
+			}
+			testVals := setupNodeNetworkConfigController(t, newTestNode(), nnc, nil)
+			mbi := testVals.cloud.Compute().(*cloud.MockGCE).BetaInstances().(*cloud.MockBetaInstances)
+			mbi.UpdateNetworkInterfaceHook = func(context.Context, *meta.Key, string, *computebeta.NetworkInterface, *cloud.MockBetaInstances, ...cloud.Option) error {
+				return tc.err
+			}
+
+			if err := testVals.controller.reconcile(ctx, testNodeName); err == nil {
+				t.Fatal("Expected reconcile() to fail")
+			}
+
+			got, err := testVals.nncClient.NetworkingV1().NodeNetworkConfigs().Get(ctx, testNodeName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get NodeNetworkConfig: %v", err)
+			}
+			cond := condmeta.FindStatusCondition(got.Status.Conditions, string(nncv1.NodeNetworkConfigConditionReady))
+			if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != tc.wantReason {
+				t.Errorf("Expected Ready condition False with reason %q, got %+v", tc.wantReason, cond)
+			}
+			if len(got.Status.PodCIDRs) != 0 {
+				t.Errorf("Expected no pod CIDRs to be published, got %+v", got.Status.PodCIDRs)
+			}
+		})
+	}
+}
+
+func TestReconcileIPv6PodCIDRs(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		nodePodCIDRs []string
+		spec         nncv1.NodeNetworkConfigSpec
+		status       []nncv1.PodCIDR
+		wantPodCIDRs []string
+	}{
+		{
+			desc: "does not release IPv6 pod CIDRs",
+			spec: nncv1.NodeNetworkConfigSpec{
+				Allocations:     []nncv1.Allocation{{Network: "default", Pods: 200}},
+				ReleasableCIDRs: []nncv1.PodCIDR{{Network: "default", CIDR: "fd00:100::/120"}},
+			},
+			status:       []nncv1.PodCIDR{readyPodCIDR("10.100.1.0/28")},
+			wantPodCIDRs: []string{"10.100.1.0/28"},
+		},
+		{
+			desc:         "does not allocate for IPv6-only nodes",
+			nodePodCIDRs: []string{"fd00:100::/112"},
+			spec:         nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "default", Pods: 300}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.Background()
+			node := newTestNode()
+			if tc.nodePodCIDRs != nil {
+				node.Spec.PodCIDR = tc.nodePodCIDRs[0]
+				node.Spec.PodCIDRs = tc.nodePodCIDRs
+			}
+			nnc := &nncv1.NodeNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: testNodeName},
+				Spec:       tc.spec,
+				Status:     nncv1.NodeNetworkConfigStatus{PodCIDRs: tc.status},
+			}
+			testVals := setupNodeNetworkConfigController(t, node, nnc, nil)
+
+			err := testVals.controller.reconcile(ctx, testNodeName)
+			if !errors.Is(err, errIPv6PodCIDRsUnsupported) {
+				t.Fatalf("reconcile() = %v, expected %v", err, errIPv6PodCIDRsUnsupported)
+			}
+
+			got, err := testVals.nncClient.NetworkingV1().NodeNetworkConfigs().Get(ctx, testNodeName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get NodeNetworkConfig: %v", err)
+			}
+			cond := condmeta.FindStatusCondition(got.Status.Conditions, string(nncv1.NodeNetworkConfigConditionReady))
+			if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != ipv6PodCIDRsUnsupportedReason {
+				t.Errorf("Expected Ready condition False with reason %q, got %+v", ipv6PodCIDRsUnsupportedReason, cond)
+			}
+			var gotPodCIDRs []string
+			for _, podCIDR := range got.Status.PodCIDRs {
+				gotPodCIDRs = append(gotPodCIDRs, podCIDR.CIDR)
+			}
+			if diff := cmp.Diff(tc.wantPodCIDRs, gotPodCIDRs); diff != "" {
+				t.Errorf("Status.PodCIDRs mismatch (-want +got):\n%s", diff)
+			}
+			if testVals.aliasUpdates != 0 {
+				t.Errorf("Expected no alias updates, got %d", testVals.aliasUpdates)
+			}
+		})
+	}
+}
+
+func TestReconcileNodeWithoutProviderID(t *testing.T) {
+	node := newTestNode()
+	node.Spec.ProviderID = ""
+	nnc := &nncv1.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: testNodeName},
+		Spec:       nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "default", Pods: 300}}},
+	}
+	testVals := setupNodeNetworkConfigController(t, node, nnc, nil)
+
+	if err := testVals.controller.reconcile(context.Background(), testNodeName); err == nil {
+		t.Error("Expected reconcile() to fail for a node without providerID")
+	}
+}
+
+func TestNewNodePodCIDRsAreNotAllocated(t *testing.T) {
+	nnc := &nncv1.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: testNodeName},
+		Spec:       nncv1.NodeNetworkConfigSpec{Allocations: []nncv1.Allocation{{Network: "default", Pods: 300}}},
+	}
+	testVals := setupNodeNetworkConfigController(t, newTestNode(), nnc, nil)
+
+	// A node created after startup, whose pod CIDR follows the one of the
+	// test node in the cluster CIDR.
+	newNode := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "new-node"},
+		Spec:       v1.NodeSpec{PodCIDRs: []string{"10.100.1.0/24"}},
+	}
+	testVals.controller.occupyNodePodCIDRs(newNode)
+
+	if err := testVals.controller.reconcile(context.Background(), testNodeName); err != nil {
+		t.Fatalf("reconcile() failed: %v", err)
+	}
+	got, err := testVals.nncClient.NetworkingV1().NodeNetworkConfigs().Get(context.Background(), testNodeName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get NodeNetworkConfig: %v", err)
+	}
+	_, newNodePodCIDR, _ := net.ParseCIDR("10.100.1.0/24")
+	for _, podCIDR := range got.Status.PodCIDRs {
+		ip, _, _ := net.ParseCIDR(podCIDR.CIDR)
+		if newNodePodCIDR.Contains(ip) {
+			t.Errorf("Allocated pod CIDR %s of the new node", podCIDR.CIDR)
+		}
+	}
+}
+
+func TestDeletedNodeNetworkConfigAliasesAreRemoved(t *testing.T) {
+	node := newTestNode()
+	nnc := &nncv1.NodeNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: testNodeName},
+		Status:     nncv1.NodeNetworkConfigStatus{PodCIDRs: []nncv1.PodCIDR{readyPodCIDR("10.100.1.16/28")}},
+	}
+	testVals := setupNodeNetworkConfigController(t, node, nnc, []string{"10.100.1.16/28"})
+	c := testVals.controller
+
+	if err := c.nncInformer.Informer().GetStore().Delete(nnc); err != nil {
+		t.Fatalf("Failed to delete NodeNetworkConfig from informer store: %v", err)
+	}
+	c.orphanPodCIDRs(nnc)
+	if err := c.reconcile(context.Background(), testNodeName); err != nil {
+		t.Fatalf("reconcile() failed: %v", err)
+	}
+
+	aliases, err := testVals.cloud.AliasRangesByProviderID(node.Spec.ProviderID)
+	if err != nil {
+		t.Fatalf("AliasRangesByProviderID() failed: %v", err)
+	}
+	if len(aliases) != 0 {
+		t.Errorf("Expected the aliases of the deleted NodeNetworkConfig to be removed, got %v", aliases)
+	}
+	if len(c.orphanedPodCIDRs) != 0 {
+		t.Errorf("Expected no orphaned pod CIDRs left, got %v", c.orphanedPodCIDRs)
+	}
+	// The released range is handed out again, right after the first free one.
+	for _, want := range []string{"10.100.1.0/28", "10.100.1.16/28"} {
+		if cidr, err := c.cidrSet.AllocateNext(); err != nil || cidr.String() != want {
+			t.Errorf("AllocateNext() = %v, %v, want %s", cidr, err, want)
+		}
+	}
+}
+
+func TestControllerRuns(t *testing.T) {
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	nnc := &nncv1.NodeNetworkConfig{ObjectMeta: metav1.ObjectMeta{Name: testNodeName}}
+	testVals := setupNodeNetworkConfigController(t, newTestNode(), nnc, nil)
+	go testVals.controller.Run(1, ctx.Done(), controllers.NewControllerManagerMetrics("test"))
+}
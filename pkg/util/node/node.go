@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	networkv1 "github.com/GoogleCloudPlatform/gke-networking-api/apis/network/v1"
@@ -49,6 +50,15 @@ const (
 	GKEUnmanagedNodeLabelValue = "true"
 )
 
+// Annotations definitions.
+const (
+	// AdditionalPodCIDRsAnnotationKey is the annotation listing the pod CIDRs of
+	// the node beyond Spec.PodCIDRs, as a comma-separated list. Spec.PodCIDRs
+	// holds at most one CIDR per IP family, so further alias IP ranges of the
+	// node's default network interface are published here.
+	AdditionalPodCIDRsAnnotationKey = "networking.gke.io/additional-pod-cidrs"
+)
+
 // GKEFilteringNodeInformer wraps a NodeInformer to filter out unmanaged nodes from the lister.
 type GKEFilteringNodeInformer struct {
 	coreinformers.NodeInformer
@@ -266,3 +276,37 @@ func PatchNodeMultiNetwork(c clientset.Interface, node *v1.Node) error {
 	klog.V(4).Infof("Patched capacity of node %q to %s", node.Name, patchBytes)
 	return nil
 }
+
+// GetAdditionalPodCIDRs returns the pod CIDRs listed in the
+// AdditionalPodCIDRsAnnotationKey annotation of the node, in order.
+func GetAdditionalPodCIDRs(node *v1.Node) []string {
+	ann := node.Annotations[AdditionalPodCIDRsAnnotationKey]
+	if ann == "" {
+		return nil
+	}
+	return strings.Split(ann, ",")
+}
+
+// PatchNodeAdditionalPodCIDRs sets the AdditionalPodCIDRsAnnotationKey
+// annotation of the node to cidrs, or removes it if cidrs is empty.
+func PatchNodeAdditionalPodCIDRs(c clientset.Interface, node types.NodeName, cidrs []string) error {
+	var value interface{}
+	if len(cidrs) > 0 {
+		value = strings.Join(cidrs, ",")
+	}
+	patchBytes, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				AdditionalPodCIDRsAnnotationKey: value,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build patch bytes for additional pod CIDRs: %w", err)
+	}
+	if _, err := c.CoreV1().Nodes().Patch(context.TODO(), string(node), types.StrategicMergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch additional pod CIDRs of node %q: %w", node, err)
+	}
+	klog.V(4).Infof("Patched additional pod CIDRs of node %q to %s", node, patchBytes)
+	return nil
+}
@@ -167,3 +167,55 @@ func TestPatchNodeMultiNetwork(t *testing.T) {
 		})
 	}
 }
+
+func TestPatchNodeAdditionalPodCIDRs(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		annotations map[string]string
+		cidrs       []string
+		want        map[string]string
+	}{
+		{
+			desc:  "set on node without annotations",
+			cidrs: []string{"10.2.0.0/24", "10.3.0.0/24"},
+			want:  map[string]string{AdditionalPodCIDRsAnnotationKey: "10.2.0.0/24,10.3.0.0/24"},
+		},
+		{
+			desc:        "replace and keep other annotations",
+			annotations: map[string]string{"test": "abc", AdditionalPodCIDRsAnnotationKey: "10.2.0.0/24"},
+			cidrs:       []string{"10.3.0.0/24"},
+			want:        map[string]string{"test": "abc", AdditionalPodCIDRsAnnotationKey: "10.3.0.0/24"},
+		},
+		{
+			desc:        "remove",
+			annotations: map[string]string{"test": "abc", AdditionalPodCIDRsAnnotationKey: "10.2.0.0/24"},
+			want:        map[string]string{"test": "abc"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			fakeNodeHandler := &testutil.FakeNodeHandler{
+				Existing: []*v1.Node{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "node0",
+							Annotations: tc.annotations,
+						},
+					},
+				},
+				Clientset: fake.NewSimpleClientset(),
+			}
+
+			if err := PatchNodeAdditionalPodCIDRs(fakeNodeHandler, "node0", tc.cidrs); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			gotNode := fakeNodeHandler.GetUpdatedNodesCopy()[0]
+			if diff := cmp.Diff(tc.want, gotNode.Annotations); diff != "" {
+				t.Errorf("PatchNodeAdditionalPodCIDRs() annotations mismatch (-want +got) = %s", diff)
+			}
+			if diff := cmp.Diff(tc.cidrs, GetAdditionalPodCIDRs(gotNode)); diff != "" {
+				t.Errorf("GetAdditionalPodCIDRs() mismatch (-want +got) = %s", diff)
+			}
+		})
+	}
+}
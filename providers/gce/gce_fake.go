@@ -42,6 +42,8 @@ type TestClusterValues struct {
 	NetworkURL        string
 	SubnetworkURL     string
 	StackType         StackType
+	// SecondaryRangeName is the secondary range alias IP ranges are added from.
+	SecondaryRangeName string
 }
 
 // DefaultTestClusterValues Creates a reasonable set of default cluster values
@@ -91,6 +93,7 @@ func NewFakeGCECloud(vals TestClusterValues) *Cloud {
 		networkURL:          vals.NetworkURL,
 		unsafeSubnetworkURL: vals.SubnetworkURL,
 		stackType:           vals.StackType,
+		secondaryRangeName:  vals.SecondaryRangeName,
 		nodeZones:           map[string]sets.String{},
 	}
 	c := cloud.NewMockGCE(&gceProjectRouter{gce})
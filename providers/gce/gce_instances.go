@@ -635,6 +635,49 @@ func (g *Cloud) GetIPV6Address(networkInterface *compute.NetworkInterface) *net.
 // AddAliasToInstanceByProviderID adds an alias to the given instance from the named
 // secondary range.
 func (g *Cloud) AddAliasToInstanceByProviderID(providerID string, alias *net.IPNet) error {
+	return g.updateAliasRangesByProviderID(providerID, "add_alias", func([]*computebeta.AliasIpRange) ([]*computebeta.AliasIpRange, bool) {
+		return []*computebeta.AliasIpRange{{
+			IpCidrRange:         alias.String(),
+			SubnetworkRangeName: g.secondaryRangeName,
+		}}, true
+	})
+}
+
+// AppendAliasToInstanceByProviderID adds an alias to the given instance from the
+// named secondary range, keeping the alias ranges already assigned to the
+// instance. Appending an alias that is already assigned is a no-op.
+func (g *Cloud) AppendAliasToInstanceByProviderID(providerID string, alias *net.IPNet) error {
+	return g.updateAliasRangesByProviderID(providerID, "append_alias", func(ranges []*computebeta.AliasIpRange) ([]*computebeta.AliasIpRange, bool) {
+		for _, r := range ranges {
+			if r.IpCidrRange == alias.String() {
+				return ranges, false
+			}
+		}
+		return append(ranges, &computebeta.AliasIpRange{
+			IpCidrRange:         alias.String(),
+			SubnetworkRangeName: g.secondaryRangeName,
+		}), true
+	})
+}
+
+// RemoveAliasFromInstanceByProviderID removes an alias from the given instance.
+// Removing an alias that is not assigned to the instance is a no-op.
+func (g *Cloud) RemoveAliasFromInstanceByProviderID(providerID string, alias *net.IPNet) error {
+	return g.updateAliasRangesByProviderID(providerID, "remove_alias", func(ranges []*computebeta.AliasIpRange) ([]*computebeta.AliasIpRange, bool) {
+		var kept []*computebeta.AliasIpRange
+		for _, r := range ranges {
+			if r.IpCidrRange != alias.String() {
+				kept = append(kept, r)
+			}
+		}
+		return kept, len(kept) != len(ranges)
+	})
+}
+
+// updateAliasRangesByProviderID replaces the alias ranges of the first network
+// interface of the given instance with the ranges returned by update. The
+// instance is only updated if update reports a change.
+func (g *Cloud) updateAliasRangesByProviderID(providerID, operation string, update func([]*computebeta.AliasIpRange) ([]*computebeta.AliasIpRange, bool)) error {
 	ctx, cancel := cloud.ContextWithCallTimeout()
 	defer cancel()
 
@@ -662,15 +705,22 @@ func (g *Cloud) AddAliasToInstanceByProviderID(providerID string, alias *net.IPN
 			providerID, instance.NetworkInterfaces)
 	}
 
+	aliasRanges, changed := update(instance.NetworkInterfaces[0].AliasIpRanges)
+	if !changed {
+		return nil
+	}
+
 	iface := &computebeta.NetworkInterface{}
 	iface.Name = instance.NetworkInterfaces[0].Name
 	iface.Fingerprint = instance.NetworkInterfaces[0].Fingerprint
-	iface.AliasIpRanges = append(iface.AliasIpRanges, &computebeta.AliasIpRange{
-		IpCidrRange:         alias.String(),
-		SubnetworkRangeName: g.secondaryRangeName,
-	})
+	iface.AliasIpRanges = aliasRanges
+	// An empty alias range list must be sent explicitly, otherwise it is
+	// omitted from the request and the existing ranges are kept.
+	if len(iface.AliasIpRanges) == 0 {
+		iface.ForceSendFields = []string{"AliasIpRanges"}
+	}
 
-	mc := newInstancesMetricContext("add_alias", zone)
+	mc := newInstancesMetricContext(operation, zone)
 	if g.projectFromNodeProviderID {
 		err = g.c.BetaInstances().UpdateNetworkInterface(ctx, meta.ZonalKey(instance.Name, lastComponent(instance.Zone)), iface.Name, iface, cloud.ForceProjectID(project))
 	} else {
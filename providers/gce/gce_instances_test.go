@@ -22,6 +22,7 @@ package gce
 import (
 	"context"
 	"fmt"
+	"net"
 	"strings"
 	"testing"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/GoogleCloudPlatform/k8s-cloud-provider/pkg/cloud/meta"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	computebeta "google.golang.org/api/compute/v0.beta"
 	ga "google.golang.org/api/compute/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -445,6 +447,110 @@ func TestAliasRangesByProviderID(t *testing.T) {
 	}
 }
 
+func TestUpdateAliasRangesByProviderID(t *testing.T) {
+	testcases := []struct {
+		name       string
+		existing   []string
+		update     func(g *Cloud, providerID string, alias *net.IPNet) error
+		alias      string
+		wantUpdate bool
+		wantRanges []string
+	}{
+		{
+			name:       "add alias replaces existing ranges",
+			existing:   []string{"10.11.1.0/24"},
+			update:     (*Cloud).AddAliasToInstanceByProviderID,
+			alias:      "10.11.2.0/24",
+			wantUpdate: true,
+			wantRanges: []string{"10.11.2.0/24"},
+		},
+		{
+			name:       "append alias keeps existing ranges",
+			existing:   []string{"10.11.1.0/24"},
+			update:     (*Cloud).AppendAliasToInstanceByProviderID,
+			alias:      "10.11.2.0/28",
+			wantUpdate: true,
+			wantRanges: []string{"10.11.1.0/24", "10.11.2.0/28"},
+		},
+		{
+			name:       "append alias already assigned",
+			existing:   []string{"10.11.1.0/24", "10.11.2.0/28"},
+			update:     (*Cloud).AppendAliasToInstanceByProviderID,
+			alias:      "10.11.2.0/28",
+			wantUpdate: false,
+		},
+		{
+			name:       "remove alias keeps other ranges",
+			existing:   []string{"10.11.1.0/24", "10.11.2.0/28"},
+			update:     (*Cloud).RemoveAliasFromInstanceByProviderID,
+			alias:      "10.11.2.0/28",
+			wantUpdate: true,
+			wantRanges: []string{"10.11.1.0/24"},
+		},
+		{
+			name:       "remove last alias",
+			existing:   []string{"10.11.2.0/28"},
+			update:     (*Cloud).RemoveAliasFromInstanceByProviderID,
+			alias:      "10.11.2.0/28",
+			wantUpdate: true,
+			wantRanges: nil,
+		},
+		{
+			name:       "remove alias not assigned",
+			existing:   []string{"10.11.1.0/24"},
+			update:     (*Cloud).RemoveAliasFromInstanceByProviderID,
+			alias:      "10.11.2.0/28",
+			wantUpdate: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			gce, err := fakeGCECloud(DefaultTestClusterValues())
+			require.NoError(t, err)
+
+			var aliasRanges []*computebeta.AliasIpRange
+			for _, r := range tc.existing {
+				aliasRanges = append(aliasRanges, &computebeta.AliasIpRange{IpCidrRange: r})
+			}
+			mockGCE := gce.c.(*cloud.MockGCE)
+			mbi := mockGCE.BetaInstances().(*cloud.MockBetaInstances)
+			mbi.GetHook = func(ctx context.Context, key *meta.Key, m *cloud.MockBetaInstances, options ...cloud.Option) (bool, *computebeta.Instance, error) {
+				return true, &computebeta.Instance{
+					Name: "n1",
+					Zone: "us-central1-b",
+					NetworkInterfaces: []*computebeta.NetworkInterface{
+						{Name: "nic0", AliasIpRanges: aliasRanges},
+					},
+				}, nil
+			}
+			var gotIface *computebeta.NetworkInterface
+			mbi.UpdateNetworkInterfaceHook = func(ctx context.Context, key *meta.Key, name string, iface *computebeta.NetworkInterface, m *cloud.MockBetaInstances, options ...cloud.Option) error {
+				gotIface = iface
+				return nil
+			}
+
+			_, alias, err := net.ParseCIDR(tc.alias)
+			require.NoError(t, err)
+			require.NoError(t, tc.update(gce, "gce://p1/us-central1-b/n1", alias))
+
+			if !tc.wantUpdate {
+				assert.Nil(t, gotIface)
+				return
+			}
+			require.NotNil(t, gotIface)
+			var gotRanges []string
+			for _, r := range gotIface.AliasIpRanges {
+				gotRanges = append(gotRanges, r.IpCidrRange)
+			}
+			assert.Equal(t, tc.wantRanges, gotRanges)
+			if len(tc.wantRanges) == 0 {
+				assert.Contains(t, gotIface.ForceSendFields, "AliasIpRanges")
+			}
+		})
+	}
+}
+
 func TestInstanceByProviderID(t *testing.T) {
 	gce, err := fakeGCECloud(DefaultTestClusterValues())
 	require.NoError(t, err)